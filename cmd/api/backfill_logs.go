@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/database"
+	loggerRepo "backend/internal/repository/logger"
+	loggerService "backend/internal/service/logger"
+)
+
+// runBackfillLogs повторно загружает лог-файлы за диапазон дат в request_logs.
+// Пример: api backfill-logs --from 2026-01-01 --to 2026-01-31
+func runBackfillLogs(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("backfill-logs", flag.ContinueOnError)
+	fromStr := fs.String("from", "", "первый день (YYYY-MM-DD)")
+	toStr := fs.String("to", "", "последний день включительно (YYYY-MM-DD), по умолчанию = from")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *fromStr == "" {
+		return fmt.Errorf("--from is required")
+	}
+	from, err := time.ParseInLocation("2006-01-02", *fromStr, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid --from: %w", err)
+	}
+	to := from
+	if *toStr != "" {
+		to, err = time.ParseInLocation("2006-01-02", *toStr, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+	}
+
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	svc := loggerService.NewService(loggerRepo.NewRepository(db), cfg.Logs.Dir, loggerService.NewRetentionPolicy(cfg.Logs))
+	res, err := svc.Backfill(context.Background(), from, to)
+	fmt.Printf("files: %d, lines: %d, inserted: %d, skipped: %d\n", res.Files, res.Lines, res.Inserted, res.Skipped)
+	return err
+}
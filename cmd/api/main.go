@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"backend/internal/app"
 	"backend/internal/config"
@@ -22,12 +26,44 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Подкоманды: api backfill-logs --from ... --to ...; api migrate <command>; api schema-check; api seed
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			if err := runMigrate(cfg, os.Args[2:]); err != nil {
+				log.Fatalf("migrate: %v", err)
+			}
+			return
+		case "schema-check":
+			if err := runSchemaCheck(cfg); err != nil {
+				log.Fatalf("schema-check: %v", err)
+			}
+			return
+		case "seed":
+			if err := runSeed(cfg, os.Args[2:]); err != nil {
+				log.Fatalf("seed: %v", err)
+			}
+			return
+		case "backfill-logs":
+			if err := runBackfillLogs(cfg, os.Args[2:]); err != nil {
+				log.Fatalf("backfill-logs: %v", err)
+			}
+			return
+		default:
+			log.Fatalf("unknown command %q", os.Args[1])
+		}
+	}
+
 	application, err := app.New(cfg)
 	if err != nil {
 		log.Fatalf("Failed to create application: %v", err)
 	}
 
-	if err := application.Run(); err != nil {
+	// SIGINT/SIGTERM отменяют ctx, после чего Run выполняет упорядоченный Shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := application.Run(ctx); err != nil {
 		log.Fatalf("Failed to run application: %v", err)
 	}
 }
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+
+	"backend/internal/config"
+	"backend/internal/database"
+)
+
+const migrateUsage = `usage: api migrate <command> [args]
+
+commands:
+  up                 применить все новые миграции
+  down [N] [--all]   откатить N миграций (по умолчанию 1), --all — все
+  steps N            применить (N > 0) или откатить (N < 0) N миграций
+  goto V             перейти на версию V (вверх или вниз)
+  version            текущая версия и флаг dirty
+  force V            записать версию V без выполнения SQL (снять dirty после ручного исправления), -1 — «нет версии»
+  status             список миграций: применённые и ожидающие`
+
+// runMigrate управляет схемой БД встроенными миграциями.
+// Пример: api migrate status; api migrate goto 15; api migrate force 17
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	cmd, args := args[0], args[1:]
+
+	m, err := database.NewMigrator(database.MigrationDSN(cfg.Database))
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch cmd {
+	case "up":
+		return ignoreNoChange(m.Up())
+
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		all := fs.Bool("all", false, "откатить все миграции")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *all {
+			return ignoreNoChange(m.Down())
+		}
+		n := 1
+		if fs.NArg() > 0 {
+			if n, err = strconv.Atoi(fs.Arg(0)); err != nil || n <= 0 {
+				return fmt.Errorf("invalid N %q", fs.Arg(0))
+			}
+		}
+		return ignoreNoChange(m.Steps(-n))
+
+	case "steps":
+		if len(args) != 1 {
+			return errors.New("usage: api migrate steps N")
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n == 0 {
+			return fmt.Errorf("invalid N %q", args[0])
+		}
+		return ignoreNoChange(m.Steps(n))
+
+	case "goto":
+		if len(args) != 1 {
+			return errors.New("usage: api migrate goto V")
+		}
+		v, err := database.ParseMigrationVersion(args[0])
+		if err != nil {
+			return err
+		}
+		return ignoreNoChange(m.Migrate(v))
+
+	case "version":
+		v, dirty, err := m.Version()
+		if errors.Is(err, migrate.ErrNilVersion) {
+			fmt.Println("no migrations applied")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d, dirty: %t\n", v, dirty)
+		return nil
+
+	case "force":
+		if len(args) != 1 {
+			return errors.New("usage: api migrate force V")
+		}
+		v, err := strconv.Atoi(args[0])
+		if err != nil || v < -1 {
+			return fmt.Errorf("invalid version %q", args[0])
+		}
+		return m.Force(v)
+
+	case "status":
+		current, dirty, err := m.Version()
+		if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+			return err
+		}
+		list, err := database.ListMigrations(current)
+		if err != nil {
+			return err
+		}
+		for _, mi := range list {
+			state := "pending"
+			switch {
+			case mi.Version == current && dirty:
+				state = "DIRTY"
+			case mi.Applied:
+				state = "applied"
+			}
+			fmt.Printf("%06d  %-8s %s\n", mi.Version, state, mi.Name)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate command %q\n\n%s", cmd, migrateUsage)
+	}
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		fmt.Println("no change")
+		return nil
+	}
+	return err
+}
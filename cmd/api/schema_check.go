@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/database"
+)
+
+// runSchemaCheck сверяет ожидаемые внешние ключи, UNIQUE и триггеры с живой БД.
+// Выводит таблицу и завершается ошибкой, если есть расхождения (удобно для CI и деплоя).
+// Пример: api schema-check
+func runSchemaCheck(cfg *config.Config) error {
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	checks, err := database.CheckSchema(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tKIND\tTABLE\tEXPECTED\tDETAIL")
+	for _, c := range checks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Status, c.Kind, c.Table, c.Name, c.Detail)
+	}
+	w.Flush()
+
+	if database.HasSchemaDrift(checks) {
+		return errors.New("schema drift detected")
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/internal/seed"
+)
+
+// runSeed заполняет БД демо-данными через репозитории.
+// Пример: api seed --size medium --seed 42
+func runSeed(cfg *config.Config, args []string) error {
+	sizes := make([]string, 0, len(seed.Presets))
+	for name := range seed.Presets {
+		sizes = append(sizes, name)
+	}
+	sort.Strings(sizes)
+
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	seedValue := fs.Uint64("seed", 1, "зерно генератора: одинаковое значение даёт одинаковые данные")
+	size := fs.String("size", "small", "объём данных: "+strings.Join(sizes, ", "))
+	days := fs.Int("days", 0, "глубина истории в днях (по умолчанию из пресета)")
+	pass := fs.String("password", "demo12345", "пароль всех созданных пользователей")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	preset, ok := seed.Presets[*size]
+	if !ok {
+		return fmt.Errorf("unknown --size %q (available: %s)", *size, strings.Join(sizes, ", "))
+	}
+	if *days > 0 {
+		preset.Days = *days
+	}
+
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	res, err := seed.New(db, seed.Options{Seed: *seedValue, Preset: preset, Password: *pass}).Run(context.Background())
+	fmt.Printf("users: %d, workspaces: %d, members: %d\n", res.Users, res.Workspaces, res.Memberships)
+	fmt.Printf("habits: %d (changed: %d, deleted: %d), completions: %d\n", res.Habits, res.HabitChanges, res.HabitsDeleted, res.Completions)
+	fmt.Printf("journal: %d, notes: %d, currencies: %d, counterparties: %d\n", res.JournalEntries, res.Notes, res.Currencies, res.Counterparties)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("login: %s / %s (ADMIN)\n", seed.Email(*seedValue, 0), *pass)
+	if preset.Users > 1 {
+		fmt.Printf("users: %s ... %s, same password\n", seed.Email(*seedValue, 1), seed.Email(*seedValue, preset.Users-1))
+	}
+	return nil
+}
@@ -8,18 +8,24 @@ import (
 	"backend/internal/worker"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 )
 
 type App struct {
-	cfg          *config.Config
-	router       *router.Router
-	server       *http.Server
-	logProcessor *worker.LogProcessor
-	logService   *di.Container
-	db           *sql.DB
+	cfg             *config.Config
+	router          *router.Router
+	server          *http.Server
+	logProcessor    *worker.LogProcessor
+	logRetention    *worker.LogRetention
+	attachmentPurge *worker.AttachmentPurge
+	trashPurge      *worker.TrashPurge
+	dataExport      *worker.DataExport
+	container       *di.Container
+	db              *sql.DB
 }
 
 func New(cfg *config.Config) (*App, error) {
@@ -29,55 +35,146 @@ func New(cfg *config.Config) (*App, error) {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
-	if err := database.RunMigrations(cfg.Database); err != nil {
-		return nil, fmt.Errorf("Failed to run migrations: %w", err)
+	if cfg.Database.AutoMigrate {
+		if err := database.RunMigrations(cfg.Database); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("Failed to run migrations: %w", err)
+		}
+	} else {
+		log.Println("DB_AUTO_MIGRATE=false: migrations are not applied on start, use `api migrate up`")
 	}
 
-	container := di.NewContainer(db, cfg)
+	container, err := di.NewContainer(db, cfg)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize services: %w", err)
+	}
 	r := container.Router
 	container.RegisterRoutes(r)
 
-	// Создаем и запускаем воркер для обработки логов
-	logProcessor := worker.NewLogProcessor(container.LogService)
-	logProcessor.Start(context.Background())
+	addr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
 
-	return &App{
-		cfg: cfg,
+	a := &App{
+		cfg:    cfg,
+		router: r,
+		server: &http.Server{
+			Addr:         addr,
+			Handler:      r.Handler(),
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		},
+		container: container,
+		db:        db,
+	}
+	if err := a.initWorkers(); err != nil {
+		container.LogService.Close()
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize workers: %w", err)
+	}
+	return a, nil
+}
 
-		router:       r,
-		logProcessor: logProcessor,
-		logService:   container,
-		db:           db,
-	}, nil
+// initWorkers создаёт фоновые воркеры. Ошибка — неположительный интервал.
+func (a *App) initWorkers() error {
+	c, m := a.container, a.container.WorkerMetrics
+	var err error
+	// Воркер загрузки логов в БД и ретеншн (сжатие/удаление файлов, свёртка старых строк request_logs)
+	if a.logProcessor, err = worker.NewLogProcessor(c.LogService, a.cfg.Logs.SyncInterval, m); err != nil {
+		return err
+	}
+	if a.logRetention, err = worker.NewLogRetention(c.LogService, a.cfg.Logs.RetentionInterval, m); err != nil {
+		return err
+	}
+	// Удаление из хранилища файлов удалённых вложений
+	if a.attachmentPurge, err = worker.NewAttachmentPurge(c.AttachmentsService, a.cfg.Attachments.PurgeInterval, m); err != nil {
+		return err
+	}
+	// Окончательное удаление просроченного содержимого корзины
+	if a.trashPurge, err = worker.NewTrashPurge(c.TrashService, a.cfg.Trash.PurgeInterval, m); err != nil {
+		return err
+	}
+	// Сборка выгрузок данных пользователей
+	a.dataExport, err = worker.NewDataExport(c.ExportService, a.cfg.Exports.PollInterval, m)
+	return err
 }
 
-func (a *App) Run() error {
-	addr := fmt.Sprintf("%s:%s", a.cfg.Server.Host, a.cfg.Server.Port)
+// Run запускает воркеры и HTTP-сервер и блокируется до отмены ctx (сигнал) или ошибки сервера,
+// после чего выполняет Shutdown с дедлайном SHUTDOWN_TIMEOUT.
+func (a *App) Run(ctx context.Context) error {
+	// Воркеры не наследуют ctx: при сигнале их останавливает Shutdown — после того, как HTTP дослужит запросы
+	a.logProcessor.Start(context.Background())
+	a.logRetention.Start(context.Background())
+	a.attachmentPurge.Start(context.Background())
+	a.trashPurge.Start(context.Background())
+	a.dataExport.Start(context.Background())
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting on %s", a.server.Addr)
+		if err := a.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
 
-	a.server = &http.Server{
-		Addr:         addr,
-		Handler:      a.router.Handler(),
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+	var runErr error
+	select {
+	case <-ctx.Done():
+		log.Printf("Shutdown requested, draining (deadline %v)", a.cfg.Server.ShutdownTimeout)
+	case err, ok := <-serverErr:
+		if ok {
+			runErr = fmt.Errorf("server failed: %w", err)
+		}
 	}
 
-	fmt.Printf("Server starting on %s\n", addr)
-	return a.server.ListenAndServe()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := a.Shutdown(shutdownCtx); err != nil {
+		return errors.Join(runErr, err)
+	}
+	return runErr
 }
 
+// Shutdown останавливает приложение по порядку: перестаёт принимать соединения и дожидается
+// текущих запросов, останавливает воркеры, сбрасывает лог-файл, закрывает БД.
+// Все шаги выполняются даже при ошибке предыдущего; ошибки объединяются.
 // https://habr.com/ru/articles/908344/
 func (a *App) Shutdown(ctx context.Context) error {
-	if a.logProcessor != nil {
-		a.logProcessor.Stop()
+	var errs []error
+
+	if err := a.server.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("http shutdown: %w", err))
+		// Дедлайн истёк — обрываем оставшиеся соединения
+		a.server.Close()
+	}
+
+	if err := a.logProcessor.Stop(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("stop log processor: %w", err))
+	}
+	if err := a.logRetention.Stop(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("stop log retention: %w", err))
+	}
+	if err := a.attachmentPurge.Stop(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("stop attachment purge: %w", err))
+	}
+	if err := a.trashPurge.Stop(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("stop trash purge: %w", err))
+	}
+	if err := a.dataExport.Stop(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("stop data export: %w", err))
+	}
+
+	if err := a.container.LogService.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close log file: %w", err))
 	}
 
-	if a.db != nil {
-		a.db.Close()
+	if err := a.db.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close db: %w", err))
 	}
 
-	if a.server != nil {
-		return a.server.Shutdown(ctx)
+	if len(errs) == 0 {
+		log.Println("Shutdown complete")
 	}
-	return nil
+	return errors.Join(errs...)
 }
@@ -2,16 +2,26 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Logs     LogsConfig
-	Auth     AuthConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Logs        LogsConfig
+	Auth        AuthConfig
+	Storage     StorageConfig
+	Attachments AttachmentsConfig
+	Exports     ExportsConfig
+	Backup      BackupConfig
+	HabitImport HabitImportConfig
+	Trash       TrashConfig
+	Mail        MailConfig
+	Profile     ProfileConfig
 }
 
 type ServerConfig struct {
@@ -20,6 +30,13 @@ type ServerConfig struct {
 	ExposeSwagger   bool
 	SwaggerUser     string
 	SwaggerPassword string
+	ExposeMetrics   bool
+	MetricsUser     string
+	MetricsPassword string
+	// HealthCheckTimeout — таймаут каждой проверки /health/ready
+	HealthCheckTimeout time.Duration
+	// ShutdownTimeout — сколько ждать завершения запросов и воркеров после SIGTERM
+	ShutdownTimeout time.Duration
 }
 
 type DatabaseConfig struct {
@@ -28,6 +45,8 @@ type DatabaseConfig struct {
 	User     string
 	Password string
 	DBName   string
+	// AutoMigrate — применять миграции при старте сервера (иначе только командой migrate)
+	AutoMigrate bool
 }
 
 type AuthConfig struct {
@@ -39,7 +58,87 @@ type AuthConfig struct {
 }
 
 type LogsConfig struct {
-	Dir string
+	Dir               string
+	SyncInterval      time.Duration
+	RetentionInterval time.Duration
+	Compress          bool
+	FileRetentionDays int
+	DBRetentionDays   int
+}
+
+// StorageConfig — где хранятся файлы вложений
+type StorageConfig struct {
+	// Backend — local (каталог LocalDir) или s3 (S3-совместимое хранилище, в том числе MinIO)
+	Backend  string
+	LocalDir string
+	// LocalURL — префикс подписанных ссылок локального хранилища; по нему же сервер отдаёт файлы
+	LocalURL string
+	// SigningKey подписывает ссылки локального хранилища; пустой — используется JWT_SECRET_KEY
+	SigningKey  string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool
+}
+
+type AttachmentsConfig struct {
+	MaxSize        int64
+	AllowedTypes   []string
+	WorkspaceQuota int64
+	// URLTTL — срок действия ссылки на скачивание
+	URLTTL time.Duration
+	// PurgeInterval — как часто удалять из хранилища файлы удалённых вложений
+	PurgeInterval time.Duration
+}
+
+// MailConfig — SMTP для служебных писем; без SMTP_HOST письма пишутся в лог
+type MailConfig struct {
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	From         string
+}
+
+type ProfileConfig struct {
+	// ConfirmEmailURL — страница клиента для подтверждения нового email; к ней добавляется ?token=
+	ConfirmEmailURL string
+	EmailChangeTTL  time.Duration
+	AvatarMaxSize   int64
+	// AvatarURLTTL — срок подписанной ссылки на файл аватара
+	AvatarURLTTL time.Duration
+}
+
+// ExportsConfig — выгрузка данных пользователя в ZIP
+type ExportsConfig struct {
+	// TTL — сколько хранится готовый архив
+	TTL    time.Duration
+	URLTTL time.Duration
+	// PollInterval — как часто worker проверяет очередь выгрузок
+	PollInterval time.Duration
+	// StaleAfter — через сколько зависшая сборка (процесс упал) начинается заново
+	StaleAfter time.Duration
+}
+
+// BackupConfig — перенос воркспейса в JSON-архиве
+type BackupConfig struct {
+	// ImportMaxSize — максимальный размер загружаемого архива, байт
+	ImportMaxSize int64
+}
+
+// HabitImportConfig — импорт истории привычек из CSV и других трекеров
+type HabitImportConfig struct {
+	MaxSize int64
+}
+
+// TrashConfig — корзина удалённых привычек, заметок и записей дневника
+type TrashConfig struct {
+	// Retention — сколько элемент лежит в корзине до окончательного удаления
+	Retention time.Duration
+	// PurgeInterval — как часто worker удаляет просроченное
+	PurgeInterval time.Duration
 }
 
 func Load() (*Config, error) {
@@ -47,21 +146,32 @@ func Load() (*Config, error) {
 
 	return &Config{
 		Server: ServerConfig{
-			Port:            getEnv("SERVER_PORT", ""),
-			Host:            getEnv("SERVER_HOST", ""),
-			ExposeSwagger:   getEnvBool("EXPOSE_SWAGGER", true),
-			SwaggerUser:     getEnv("SWAGGER_USER", ""),
-			SwaggerPassword: getEnv("SWAGGER_PASSWORD", ""),
+			Port:               getEnv("SERVER_PORT", ""),
+			Host:               getEnv("SERVER_HOST", ""),
+			ExposeSwagger:      getEnvBool("EXPOSE_SWAGGER", true),
+			SwaggerUser:        getEnv("SWAGGER_USER", ""),
+			SwaggerPassword:    getEnv("SWAGGER_PASSWORD", ""),
+			ExposeMetrics:      getEnvBool("EXPOSE_METRICS", true),
+			MetricsUser:        getEnv("METRICS_USER", ""),
+			MetricsPassword:    getEnv("METRICS_PASSWORD", ""),
+			HealthCheckTimeout: getEnvDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+			ShutdownTimeout:    getEnvDuration("SHUTDOWN_TIMEOUT", 20*time.Second),
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", ""),
-			Port:     getEnv("DB_PORT", ""),
-			User:     getEnv("DB_USER", ""),
-			Password: getEnv("DB_PASSWORD", ""),
-			DBName:   getEnv("DB_NAME", ""),
+			Host:        getEnv("DB_HOST", ""),
+			Port:        getEnv("DB_PORT", ""),
+			User:        getEnv("DB_USER", ""),
+			Password:    getEnv("DB_PASSWORD", ""),
+			DBName:      getEnv("DB_NAME", ""),
+			AutoMigrate: getEnvBool("DB_AUTO_MIGRATE", true),
 		},
 		Logs: LogsConfig{
-			Dir: getEnv("LOGS_DIR", "./logs"),
+			Dir:               getEnv("LOGS_DIR", "./logs"),
+			SyncInterval:      getEnvDuration("LOGS_SYNC_INTERVAL", time.Minute),
+			RetentionInterval: getEnvDuration("LOGS_RETENTION_INTERVAL", time.Hour),
+			Compress:          getEnvBool("LOGS_COMPRESS", true),
+			FileRetentionDays: getEnvInt("LOGS_FILE_RETENTION_DAYS", 90),
+			DBRetentionDays:   getEnvInt("LOGS_DB_RETENTION_DAYS", 30),
 		},
 		Auth: AuthConfig{
 			JWTSecretKey:      getEnv("JWT_SECRET_KEY", ""),
@@ -70,6 +180,54 @@ func Load() (*Config, error) {
 			CookieDomain:      getEnv("COOKIE_DOMAIN", ""),
 			SecureCookies:     getEnvBool("SECURE_COOKIES", true),
 		},
+		Storage: StorageConfig{
+			Backend:     getEnv("STORAGE_BACKEND", "local"),
+			LocalDir:    getEnv("STORAGE_LOCAL_DIR", "./data/attachments"),
+			LocalURL:    getEnv("STORAGE_LOCAL_URL", "/api/v1/files"),
+			SigningKey:  getEnv("STORAGE_SIGNING_KEY", ""),
+			S3Endpoint:  getEnv("S3_ENDPOINT", ""),
+			S3Region:    getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:    getEnv("S3_BUCKET", ""),
+			S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+			S3PathStyle: getEnvBool("S3_PATH_STYLE", true),
+		},
+		Attachments: AttachmentsConfig{
+			MaxSize:        getEnvInt64("ATTACHMENTS_MAX_SIZE", 10<<20),
+			AllowedTypes:   getEnvList("ATTACHMENTS_ALLOWED_TYPES", []string{"image/jpeg", "image/png", "image/gif", "image/webp", "application/pdf", "text/plain"}),
+			WorkspaceQuota: getEnvInt64("ATTACHMENTS_WORKSPACE_QUOTA", 1<<30),
+			URLTTL:         getEnvDuration("ATTACHMENTS_URL_TTL", 15*time.Minute),
+			PurgeInterval:  getEnvDuration("ATTACHMENTS_PURGE_INTERVAL", 5*time.Minute),
+		},
+		Exports: ExportsConfig{
+			TTL:          getEnvDuration("EXPORTS_TTL", 7*24*time.Hour),
+			URLTTL:       getEnvDuration("EXPORTS_URL_TTL", 15*time.Minute),
+			PollInterval: getEnvDuration("EXPORTS_POLL_INTERVAL", 30*time.Second),
+			StaleAfter:   getEnvDuration("EXPORTS_STALE_AFTER", time.Hour),
+		},
+		Backup: BackupConfig{
+			ImportMaxSize: getEnvInt64("WORKSPACE_IMPORT_MAX_SIZE", 50<<20),
+		},
+		HabitImport: HabitImportConfig{
+			MaxSize: getEnvInt64("HABIT_IMPORT_MAX_SIZE", 20<<20),
+		},
+		Trash: TrashConfig{
+			Retention:     time.Duration(getEnvInt("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour,
+			PurgeInterval: getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
+		},
+		Mail: MailConfig{
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     getEnvInt("SMTP_PORT", 587),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			From:         getEnv("MAIL_FROM", "noreply@localhost"),
+		},
+		Profile: ProfileConfig{
+			ConfirmEmailURL: getEnv("EMAIL_CONFIRM_URL", "http://localhost:5173/confirm-email"),
+			EmailChangeTTL:  getEnvDuration("EMAIL_CHANGE_TTL", 24*time.Hour),
+			AvatarMaxSize:   getEnvInt64("AVATAR_MAX_SIZE", 5<<20),
+			AvatarURLTTL:    getEnvDuration("AVATAR_URL_TTL", time.Hour),
+		},
 	}, nil
 }
 
@@ -88,13 +246,54 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return value == "true" || value == "1" || value == "yes" || value == "on"
 }
 
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+	return n
+}
+
+func getEnvInt64(key string, defaultValue int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return n
+}
+
+// getEnvList — значения через запятую; пустые элементы отбрасываются
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var list []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// getEnvDuration — длительность вида 30s, 5m, 1h. Все длительности конфига — интервалы, сроки и таймауты,
+// поэтому 0 и отрицательные значения, как и нераспознанные, заменяются значением по умолчанию.
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	value := os.Getenv(key)
 	if value == "" {
 		return defaultValue
 	}
 	duration, err := time.ParseDuration(value)
-	if err != nil {
+	if err != nil || duration <= 0 {
 		return defaultValue
 	}
 	return duration
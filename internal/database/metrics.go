@@ -0,0 +1,27 @@
+package database
+
+import (
+	"backend/pkg/metrics"
+	"context"
+	"database/sql"
+)
+
+// PoolCollector отдаёт статистику пула соединений database/sql (db.Stats()) в /metrics
+func PoolCollector(db *sql.DB) metrics.Collector {
+	return metrics.CollectorFunc(func(ctx context.Context, e *metrics.Exposition) {
+		st := db.Stats()
+		e.Gauge("db_pool_max_open_connections", "Максимум открытых соединений (0 — без ограничения)", float64(st.MaxOpenConnections))
+		e.Gauge("db_pool_open_connections", "Открытые соединения", float64(st.OpenConnections))
+		e.Gauge("db_pool_in_use_connections", "Соединения, занятые запросами", float64(st.InUse))
+		e.Gauge("db_pool_idle_connections", "Свободные соединения", float64(st.Idle))
+
+		e.Header("db_pool_wait_count_total", "Сколько раз запрос ждал свободное соединение", "counter")
+		e.Sample("db_pool_wait_count_total", float64(st.WaitCount))
+		e.Header("db_pool_wait_duration_seconds_total", "Суммарное время ожидания соединения", "counter")
+		e.Sample("db_pool_wait_duration_seconds_total", st.WaitDuration.Seconds())
+		e.Header("db_pool_closed_total", "Закрытые соединения по причине", "counter")
+		e.Sample("db_pool_closed_total", float64(st.MaxIdleClosed), metrics.Label{Name: "reason", Value: "max_idle"})
+		e.Sample("db_pool_closed_total", float64(st.MaxIdleTimeClosed), metrics.Label{Name: "reason", Value: "max_idle_time"})
+		e.Sample("db_pool_closed_total", float64(st.MaxLifetimeClosed), metrics.Label{Name: "reason", Value: "max_lifetime"})
+	})
+}
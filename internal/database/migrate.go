@@ -1,70 +1,135 @@
 package database
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 
 	"backend/internal/config"
+	"backend/migrations"
 )
 
-func RunMigrations(db config.DatabaseConfig) error {
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+// MigrationInfo — миграция из встроенного каталога и её состояние в БД
+type MigrationInfo struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// MigrationDSN собирает строку подключения в формате, который понимает golang-migrate
+func MigrationDSN(db config.DatabaseConfig) string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
 		db.User,
 		db.Password,
 		db.Host,
 		db.Port,
 		db.DBName,
 	)
+}
 
-	// // Для разработки: сбросим миграции
-	// conn, err := sql.Open("postgres", dsn)
-	// if err != nil {
-	// 	return err
-	// }
-	// defer conn.Close()
-
-	// // Удаляем таблицу миграций чтобы начать заново
-	// _, err = conn.Exec("DROP TABLE IF EXISTS schema_migrations")
-	// if err != nil {
-	// 	log.Printf("Warning: could not drop migrations table: %v", err)
-	// }
-
-	m, err := migrate.New(
-		"file://./migrations",
-		dsn,
-	)
+// NewMigrator создаёт migrate.Migrate поверх встроенных миграций (migrations.FS).
+// Вызывающий отвечает за Close.
+func NewMigrator(dsn string) (*migrate.Migrate, error) {
+	src, err := iofs.New(migrations.FS, ".")
 	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
+		return nil, fmt.Errorf("failed to open embedded migrations: %w", err)
 	}
-	defer m.Close()
-
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("failed to run migrations: %w", err)
+	m, err := migrate.NewWithSourceInstance("iofs", src, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
 	}
+	m.Log = migrateLogger{}
+	return m, nil
+}
 
-	log.Println("Database migrations applied successfully")
-	return nil
+func RunMigrations(db config.DatabaseConfig) error {
+	return RunMigrationsWithDSN(MigrationDSN(db))
 }
 
 // RunMigrationsWithDSN применяет миграции с готовой DSN строкой
 func RunMigrationsWithDSN(dsn string) error {
-	m, err := migrate.New(
-		"file://./migrations",
-		dsn,
-	)
+	m, err := NewMigrator(dsn)
 	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
+		return err
 	}
 	defer m.Close()
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
 	log.Println("Database migrations applied successfully")
 	return nil
 }
+
+// ListMigrations возвращает все встроенные миграции по возрастанию версии.
+// current — версия из schema_migrations (ErrNilVersion от migrate => 0): всё, что не новее, считается применённым.
+func ListMigrations(current uint) ([]MigrationInfo, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	var list []MigrationInfo
+	version, err := src.First()
+	for err == nil {
+		info := MigrationInfo{Version: version, Applied: version <= current}
+		if r, identifier, rerr := src.ReadUp(version); rerr == nil {
+			r.Close()
+			info.Name = identifier
+		}
+		list = append(list, info)
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return list, nil
+}
+
+// LatestMigrationVersion — наибольшая версия среди встроенных файлов NNNNNN_name.up.sql
+func LatestMigrationVersion() (uint, error) {
+	files, err := fs.Glob(migrations.FS, "*.up.sql")
+	if err != nil {
+		return 0, err
+	}
+	var latest uint
+	for _, f := range files {
+		m, err := source.DefaultParse(f)
+		if err != nil {
+			continue
+		}
+		if m.Version > latest {
+			latest = m.Version
+		}
+	}
+	return latest, nil
+}
+
+// ParseMigrationVersion разбирает номер версии из аргумента командной строки
+func ParseMigrationVersion(s string) (uint, error) {
+	v, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid version %q", s)
+	}
+	return uint(v), nil
+}
+
+// migrateLogger выводит шаги golang-migrate в стандартный лог
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...interface{}) {
+	log.Printf("migrate: "+strings.TrimRight(format, "\n"), v...)
+}
+
+func (migrateLogger) Verbose() bool { return false }
@@ -0,0 +1,21 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// MigrationStatus возвращает применённую версию миграций и флаг dirty из schema_migrations (golang-migrate).
+// Если миграции ещё не применялись, возвращает 0, false.
+func MigrationStatus(ctx context.Context, db *sql.DB) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return uint(version), dirty, nil
+}
@@ -0,0 +1,316 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Статусы результата проверки схемы
+const (
+	SchemaOK       = "ok"
+	SchemaMissing  = "missing"
+	SchemaMismatch = "mismatch"
+	SchemaNotValid = "not_valid"
+)
+
+// SchemaCheck — результат сверки одного ожидаемого ограничения или триггера с живой БД
+type SchemaCheck struct {
+	Kind   string // fk, unique, trigger
+	Table  string
+	Name   string
+	Status string
+	Detail string
+}
+
+// expectedFK сопоставляется по таблице, колонкам и целевой таблице: у ключей из CREATE TABLE
+// имена сгенерированы Postgres, поэтому по имени не ищем
+type expectedFK struct {
+	Table    string
+	Columns  []string
+	RefTable string
+	OnDelete byte // pg_constraint.confdeltype: a — no action, c — cascade, n — set null
+}
+
+type expectedUnique struct {
+	Table   string
+	Columns []string
+}
+
+type expectedTrigger struct {
+	Table    string
+	Name     string
+	Function string
+}
+
+// Ожидаемая схема после всех миграций. При добавлении FK, UNIQUE или триггера в миграции — дополнить.
+var (
+	expectedFKs = []expectedFK{
+		{"workspaces", []string{"owner_id"}, "users", 'a'},
+		{"user_workspaces", []string{"user_id"}, "users", 'c'},
+		{"user_workspaces", []string{"workspace_id"}, "workspaces", 'c'},
+		{"user_preferences", []string{"user_id"}, "users", 'c'},
+		{"user_preferences", []string{"current_workspace_id"}, "workspaces", 'n'},
+		{"habits", []string{"user_id"}, "users", 'a'},
+		{"habits", []string{"workspace_id"}, "workspaces", 'c'},
+		{"habit_completions", []string{"user_id"}, "users", 'c'},
+		{"habit_completions", []string{"workspace_id"}, "workspaces", 'c'},
+		{"habit_completions", []string{"habit_id"}, "habits", 'c'},
+		{"habit_history", []string{"habit_id"}, "habits", 'c'},
+		{"habit_history", []string{"user_id"}, "users", 'c'},
+		{"activities", []string{"user_id"}, "users", 'c'},
+		{"activities", []string{"workspace_id"}, "workspaces", 'c'},
+		{"workspace_modules", []string{"workspace_id"}, "workspaces", 'c'},
+		{"workspace_modules", []string{"module_id"}, "modules", 'c'},
+		{"user_module_licenses", []string{"user_id"}, "users", 'c'},
+		{"user_module_licenses", []string{"module_id"}, "modules", 'c'},
+		{"user_module_licenses", []string{"workspace_id"}, "workspaces", 'c'},
+		{"currencies", []string{"workspace_id"}, "workspaces", 'c'},
+		{"counterparties", []string{"workspace_id"}, "workspaces", 'c'},
+		{"notes", []string{"workspace_id"}, "workspaces", 'c'},
+		{"notes", []string{"user_id"}, "users", 'c'},
+		{"notes", []string{"folder_id"}, "note_folders", 'a'},
+		{"note_folders", []string{"workspace_id"}, "workspaces", 'c'},
+		{"note_folders", []string{"parent_id"}, "note_folders", 'a'},
+		{"note_shares", []string{"note_id"}, "notes", 'c'},
+		{"note_shares", []string{"user_id"}, "users", 'c'},
+		{"note_shares", []string{"created_by"}, "users", 'n'},
+		{"note_links", []string{"note_id"}, "notes", 'c'},
+		{"note_links", []string{"created_by"}, "users", 'n'},
+		{"note_link_access_log", []string{"link_id"}, "note_links", 'c'},
+		{"journal_entries", []string{"workspace_id"}, "workspaces", 'c'},
+		{"journal_entries", []string{"user_id"}, "users", 'c'},
+		{"journal_entry_revisions", []string{"entry_id"}, "journal_entries", 'c'},
+		{"journal_entry_revisions", []string{"author_id"}, "users", 'n'},
+		{"entity_links", []string{"workspace_id"}, "workspaces", 'c'},
+		{"entity_links", []string{"created_by"}, "users", 'n'},
+		{"attachments", []string{"workspace_id"}, "workspaces", 'c'},
+		{"attachments", []string{"note_id"}, "notes", 'c'},
+		{"attachments", []string{"journal_entry_id"}, "journal_entries", 'c'},
+		{"attachments", []string{"habit_completion_id"}, "habit_completions", 'c'},
+		{"attachments", []string{"uploaded_by"}, "users", 'n'},
+		{"email_change_requests", []string{"user_id"}, "users", 'c'},
+		{"data_exports", []string{"user_id"}, "users", 'c'},
+		{"workspace_imports", []string{"workspace_id"}, "workspaces", 'c'},
+		{"workspace_imports", []string{"user_id"}, "users", 'n'},
+		{"calendar_feeds", []string{"user_id"}, "users", 'c'},
+	}
+
+	expectedUniques = []expectedUnique{
+		{"users", []string{"email"}},
+		{"user_workspaces", []string{"user_id", "workspace_id"}},
+		{"user_preferences", []string{"user_id"}},
+		{"modules", []string{"code"}},
+		{"workspace_modules", []string{"workspace_id", "module_id"}},
+		{"currencies", []string{"workspace_id", "code"}},
+		{"habit_completions", []string{"habit_id", "date", "user_id"}},
+		{"journal_entry_revisions", []string{"entry_id", "revision"}},
+		{"note_shares", []string{"note_id", "user_id"}},
+		{"note_links", []string{"token_hash"}},
+		{"entity_links", []string{"source_type", "source_id", "target_type", "target_id", "origin"}},
+		{"attachments", []string{"storage_key"}},
+		{"email_change_requests", []string{"user_id"}},
+		{"email_change_requests", []string{"token_hash"}},
+		{"workspace_imports", []string{"workspace_id", "checksum"}},
+		{"calendar_feeds", []string{"token_hash"}},
+	}
+
+	expectedTriggers = []expectedTrigger{
+		{"workspaces", "update_workspaces_updated_at", "update_updated_at_column"},
+		{"habits", "update_habits_updated_at", "update_updated_at_column"},
+		{"workspaces", "tr_workspace_enable_core_modules", "fn_workspace_enable_core_modules"},
+		{"journal_entry_revisions", "tr_journal_entry_revisions_append_only", "fn_journal_entry_revisions_append_only"},
+		{"notes", "tr_notes_entity_links_cleanup", "fn_entity_links_cleanup"},
+		{"journal_entries", "tr_journal_entries_entity_links_cleanup", "fn_entity_links_cleanup"},
+		{"habits", "tr_habits_entity_links_cleanup", "fn_entity_links_cleanup"},
+		{"counterparties", "tr_counterparties_entity_links_cleanup", "fn_entity_links_cleanup"},
+		{"attachments", "tr_attachments_queue_purge", "fn_attachments_queue_purge"},
+	}
+)
+
+type liveConstraint struct {
+	table     string
+	name      string
+	kind      string
+	validated bool
+	refTable  string
+	onDelete  string
+	columns   []string
+}
+
+// CheckSchema сверяет ожидаемые внешние ключи, уникальные ограничения и триггеры с текущей схемой БД
+func CheckSchema(ctx context.Context, db *sql.DB) ([]SchemaCheck, error) {
+	constraints, err := loadConstraints(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	triggers, err := loadTriggers(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var checks []SchemaCheck
+
+	for _, exp := range expectedFKs {
+		check := SchemaCheck{
+			Kind:   "fk",
+			Table:  exp.Table,
+			Name:   fmt.Sprintf("(%s) -> %s", strings.Join(exp.Columns, ", "), exp.RefTable),
+			Status: SchemaMissing,
+		}
+		for _, c := range constraints {
+			if c.kind != "f" || c.table != exp.Table || c.refTable != exp.RefTable || !sameColumns(c.columns, exp.Columns, true) {
+				continue
+			}
+			check.Detail = c.name
+			switch {
+			case c.onDelete != string(exp.OnDelete):
+				check.Status = SchemaMismatch
+				check.Detail = fmt.Sprintf("%s: ON DELETE %s, expected %s", c.name, onDeleteName(c.onDelete), onDeleteName(string(exp.OnDelete)))
+			case !c.validated:
+				check.Status = SchemaNotValid
+			default:
+				check.Status = SchemaOK
+			}
+			break
+		}
+		checks = append(checks, check)
+	}
+
+	for _, exp := range expectedUniques {
+		check := SchemaCheck{
+			Kind:   "unique",
+			Table:  exp.Table,
+			Name:   "(" + strings.Join(exp.Columns, ", ") + ")",
+			Status: SchemaMissing,
+		}
+		for _, c := range constraints {
+			if c.kind == "u" && c.table == exp.Table && sameColumns(c.columns, exp.Columns, false) {
+				check.Status = SchemaOK
+				check.Detail = c.name
+				break
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	for _, exp := range expectedTriggers {
+		check := SchemaCheck{Kind: "trigger", Table: exp.Table, Name: exp.Name, Status: SchemaMissing}
+		if fn, ok := triggers[exp.Table+"."+exp.Name]; ok {
+			check.Status = SchemaOK
+			if fn != exp.Function {
+				check.Status = SchemaMismatch
+				check.Detail = fmt.Sprintf("executes %s, expected %s", fn, exp.Function)
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
+
+// HasSchemaDrift — есть ли среди результатов что-то кроме ok
+func HasSchemaDrift(checks []SchemaCheck) bool {
+	for _, c := range checks {
+		if c.Status != SchemaOK {
+			return true
+		}
+	}
+	return false
+}
+
+func loadConstraints(ctx context.Context, db *sql.DB) ([]liveConstraint, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT cl.relname, c.conname, c.contype, c.convalidated,
+			COALESCE(ref.relname, ''), c.confdeltype,
+			ARRAY(
+				SELECT a.attname::text
+				FROM unnest(c.conkey) WITH ORDINALITY AS k(attnum, ord)
+				JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+				ORDER BY k.ord
+			)
+		FROM pg_constraint c
+		JOIN pg_class cl ON cl.oid = c.conrelid
+		JOIN pg_namespace n ON n.oid = cl.relnamespace
+		LEFT JOIN pg_class ref ON ref.oid = c.confrelid
+		WHERE n.nspname = current_schema() AND c.contype IN ('f', 'u')
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("load constraints: %w", err)
+	}
+	defer rows.Close()
+
+	var list []liveConstraint
+	for rows.Next() {
+		var c liveConstraint
+		if err := rows.Scan(&c.table, &c.name, &c.kind, &c.validated, &c.refTable, &c.onDelete, pq.Array(&c.columns)); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+// loadTriggers возвращает "table.trigger" -> имя функции
+func loadTriggers(ctx context.Context, db *sql.DB) (map[string]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT cl.relname, t.tgname, p.proname
+		FROM pg_trigger t
+		JOIN pg_class cl ON cl.oid = t.tgrelid
+		JOIN pg_namespace n ON n.oid = cl.relnamespace
+		JOIN pg_proc p ON p.oid = t.tgfoid
+		WHERE n.nspname = current_schema() AND NOT t.tgisinternal
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("load triggers: %w", err)
+	}
+	defer rows.Close()
+
+	triggers := make(map[string]string)
+	for rows.Next() {
+		var table, name, fn string
+		if err := rows.Scan(&table, &name, &fn); err != nil {
+			return nil, err
+		}
+		triggers[table+"."+name] = fn
+	}
+	return triggers, rows.Err()
+}
+
+func sameColumns(a, b []string, ordered bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if !ordered {
+		a = append([]string(nil), a...)
+		b = append([]string(nil), b...)
+		sort.Strings(a)
+		sort.Strings(b)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func onDeleteName(code string) string {
+	switch code {
+	case "a":
+		return "NO ACTION"
+	case "r":
+		return "RESTRICT"
+	case "c":
+		return "CASCADE"
+	case "n":
+		return "SET NULL"
+	case "d":
+		return "SET DEFAULT"
+	}
+	return code
+}
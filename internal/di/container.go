@@ -2,69 +2,134 @@ package di
 
 import (
 	"backend/internal/config"
+	"backend/internal/database"
 	adminHandler "backend/internal/handler/admin"
+	attachmentsHandler "backend/internal/handler/attachments"
 	authHandler "backend/internal/handler/auth"
+	backupHandler "backend/internal/handler/backup"
+	calendarFeedHandler "backend/internal/handler/calendarfeed"
+	exportHandler "backend/internal/handler/export"
+	habitImportHandler "backend/internal/handler/habitimport"
 	habitsHandler "backend/internal/handler/habits"
+	healthHandler "backend/internal/handler/health"
 	journalHandler "backend/internal/handler/journal"
+	linksHandler "backend/internal/handler/links"
 	loggerHandler "backend/internal/handler/logger"
 	masterHandler "backend/internal/handler/master"
+	metricsHandler "backend/internal/handler/metrics"
 	notesHandler "backend/internal/handler/notes"
+	profileHandler "backend/internal/handler/profile"
+	searchHandler "backend/internal/handler/search"
 	swaggerHandler "backend/internal/handler/swagger"
+	trashHandler "backend/internal/handler/trash"
 	workspaceHandler "backend/internal/handler/workspace"
 	"backend/internal/middleware"
+	attachmentsRepo "backend/internal/repository/attachments"
+	backupRepo "backend/internal/repository/backup"
+	calendarFeedRepo "backend/internal/repository/calendarfeed"
+	exportRepo "backend/internal/repository/export"
 	habitsRepo "backend/internal/repository/habits"
 	journalRepo "backend/internal/repository/journal"
 	licenseRepo "backend/internal/repository/license"
+	linksRepo "backend/internal/repository/links"
 	loggerRepo "backend/internal/repository/logger"
 	masterRepo "backend/internal/repository/master"
+	metricsRepo "backend/internal/repository/metrics"
 	notesRepo "backend/internal/repository/notes"
+	searchRepo "backend/internal/repository/search"
+	trashRepo "backend/internal/repository/trash"
 	userRepo "backend/internal/repository/user"
 	userPrefsRepo "backend/internal/repository/user_preferences"
 	workspaceRepo "backend/internal/repository/workspace"
 	"backend/internal/router"
+	attachmentsService "backend/internal/service/attachments"
 	authService "backend/internal/service/auth"
+	backupService "backend/internal/service/backup"
+	calendarFeedService "backend/internal/service/calendarfeed"
+	exportService "backend/internal/service/export"
+	habitImportService "backend/internal/service/habitimport"
 	habitsService "backend/internal/service/habits"
+	healthService "backend/internal/service/health"
 	journalService "backend/internal/service/journal"
+	linksService "backend/internal/service/links"
 	loggerService "backend/internal/service/logger"
 	masterService "backend/internal/service/master"
+	metricsService "backend/internal/service/metrics"
 	notesService "backend/internal/service/notes"
+	profileService "backend/internal/service/profile"
+	searchService "backend/internal/service/search"
+	trashService "backend/internal/service/trash"
 	workspaceService "backend/internal/service/workspace"
+	"backend/internal/worker"
 	"backend/pkg/auth/token"
 	"backend/pkg/http/cookies"
+	"backend/pkg/mailer"
+	"backend/pkg/metrics"
 	"backend/pkg/response"
+	"backend/pkg/storage"
 	"database/sql"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
 
 type Container struct {
-	Cfg              *config.Config
-	Router           *router.Router
-	AuthHandler      *authHandler.Handler
-	AdminHandler     *adminHandler.Handler
-	WorkspaceHandler *workspaceHandler.Handler
-	WorkspaceService *workspaceService.Service
-	MasterHandler    *masterHandler.Handler
-	NotesHandler     *notesHandler.Handler
-	HabitsHandler    *habitsHandler.Handler
-	JournalHandler   *journalHandler.Handler
-	LoggerHandler    *loggerHandler.Handler
-	LogService       *loggerService.Service
-	TokenGen         *token.Generator
-	Responder        *response.Responder
-	Validate         *validator.Validate
+	Cfg                 *config.Config
+	Router              *router.Router
+	AuthHandler         *authHandler.Handler
+	ProfileHandler      *profileHandler.Handler
+	ExportHandler       *exportHandler.Handler
+	BackupHandler       *backupHandler.Handler
+	BackupService       *backupService.Service
+	HabitImportHandler  *habitImportHandler.Handler
+	HabitImportService  *habitImportService.Service
+	CalendarFeedHandler *calendarFeedHandler.Handler
+	CalendarFeedService *calendarFeedService.Service
+	ExportService       *exportService.Service
+	AdminHandler        *adminHandler.Handler
+	WorkspaceHandler    *workspaceHandler.Handler
+	WorkspaceService    *workspaceService.Service
+	MasterHandler       *masterHandler.Handler
+	NotesHandler        *notesHandler.Handler
+	HabitsHandler       *habitsHandler.Handler
+	HabitsService       *habitsService.Service
+	JournalHandler      *journalHandler.Handler
+	LinksHandler        *linksHandler.Handler
+	AttachmentsHandler  *attachmentsHandler.Handler
+	AttachmentsService  *attachmentsService.Service
+	TrashHandler        *trashHandler.Handler
+	TrashService        *trashService.Service
+	Storage             storage.Storage
+	SearchHandler       *searchHandler.Handler
+	LoggerHandler       *loggerHandler.Handler
+	HealthHandler       *healthHandler.Handler
+	LogService          *loggerService.Service
+	UserRepository      *userRepo.PostgresUserRepository
+	Metrics             *metrics.Registry
+	WorkerMetrics       *worker.RunMetrics
+	TokenGen            *token.Generator
+	Responder           *response.Responder
+	Validate            *validator.Validate
 }
 
-func NewContainer(db *sql.DB, cfg *config.Config) *Container {
+func NewContainer(db *sql.DB, cfg *config.Config) (*Container, error) {
 	responder := response.NewResponder()
 	validate := validator.New()
 	r := router.New(responder)
 
+	// Metrics (/metrics): пул БД, фоновые воркеры, бизнес-показатели; HTTP — в middleware.Metrics
+	metricsRegistry := metrics.NewRegistry()
+	metricsRegistry.RegisterCollector(database.PoolCollector(db))
+	metricsRegistry.RegisterCollector(metricsService.NewService(metricsRepo.NewRepository(db)))
+	workerMetrics := worker.NewRunMetrics(metricsRegistry)
+
 	// Logger
 	loggerRepository := loggerRepo.NewRepository(db)
-	logService := loggerService.NewService(loggerRepository, cfg.Logs.Dir)
+	logService := loggerService.NewService(loggerRepository, cfg.Logs.Dir, loggerService.NewRetentionPolicy(cfg.Logs))
 
 	workspaceRepository := workspaceRepo.NewRepository(db)
 	userPrefsRepository := userPrefsRepo.NewRepository(db)
@@ -87,9 +152,13 @@ func NewContainer(db *sql.DB, cfg *config.Config) *Container {
 	masterSvc := masterService.NewService(masterRepository)
 	masterHdlr := masterHandler.NewHandler(masterSvc, workspaceSvc, responder, validate)
 
+	// Links between notes, journal entries, habits and counterparties ([[wiki]] and manual)
+	linksSvc := linksService.NewService(linksRepo.NewRepository(db))
+	linksHdlr := linksHandler.NewHandler(linksSvc, workspaceSvc, responder, validate)
+
 	// Notes module
 	notesRepository := notesRepo.NewRepository(db)
-	notesSvc := notesService.NewService(notesRepository)
+	notesSvc := notesService.NewService(notesRepository, linksSvc)
 	notesHdlr := notesHandler.NewHandler(notesSvc, workspaceSvc, responder, validate)
 
 	// Habits
@@ -97,49 +166,142 @@ func NewContainer(db *sql.DB, cfg *config.Config) *Container {
 	habitsSvc := habitsService.NewService(habitsRepository)
 	habitsHdlr := habitsHandler.NewHandler(habitsSvc, responder, validate)
 
+	// Импорт истории привычек (CSV, Loop Habit Tracker)
+	habitImportSvc := habitImportService.NewService(habitsRepository)
+	habitImportHdlr := habitImportHandler.NewHandler(habitImportSvc, workspaceSvc, responder, cfg.HabitImport.MaxSize)
+
 	// Journal
 	journalRepository := journalRepo.NewRepository(db)
-	journalSvc := journalService.NewService(journalRepository)
+	journalSvc := journalService.NewService(journalRepository, linksSvc)
 	journalHdlr := journalHandler.NewHandler(journalSvc, workspaceSvc, responder, validate)
 
+	// Trash (корзина удалённых привычек, заметок и записей дневника; просроченное удаляет worker.TrashPurge)
+	trashSvc := trashService.NewService(trashRepo.NewRepository(db), habitsRepository, notesRepository, journalRepository, cfg.Trash.Retention)
+	trashHdlr := trashHandler.NewHandler(trashSvc, workspaceSvc, responder)
+
+	// Attachments (файлы в локальном каталоге или S3-совместимом хранилище)
+	store, err := NewStorage(cfg)
+	if err != nil {
+		return nil, err
+	}
+	attachmentsSvc := attachmentsService.NewService(attachmentsRepo.NewRepository(db), store, attachmentsService.Limits{
+		MaxSize:        cfg.Attachments.MaxSize,
+		AllowedTypes:   cfg.Attachments.AllowedTypes,
+		WorkspaceQuota: cfg.Attachments.WorkspaceQuota,
+		URLTTL:         cfg.Attachments.URLTTL,
+	})
+	attachmentsHdlr := attachmentsHandler.NewHandler(attachmentsSvc, workspaceSvc, responder)
+
+	// Profile (имя, язык, часовой пояс, смена email, аватар, удаление аккаунта)
+	profileSvc := profileService.NewService(userRepository, store, NewMailer(cfg.Mail), profileService.Options{
+		ConfirmEmailURL: cfg.Profile.ConfirmEmailURL,
+		EmailChangeTTL:  cfg.Profile.EmailChangeTTL,
+		AvatarMaxSize:   cfg.Profile.AvatarMaxSize,
+		AvatarBaseURL:   AvatarsPath,
+		AvatarURLTTL:    cfg.Profile.AvatarURLTTL,
+	})
+	profileHdlr := profileHandler.NewHandler(profileSvc, cookieManager, responder, validate, cfg.Profile.AvatarMaxSize)
+
+	// Data export (все данные пользователя в ZIP; собирает worker.DataExport)
+	exportSvc := exportService.NewService(exportRepo.NewRepository(db), store, exportService.Options{
+		TTL:        cfg.Exports.TTL,
+		URLTTL:     cfg.Exports.URLTTL,
+		StaleAfter: cfg.Exports.StaleAfter,
+	})
+	exportHdlr := exportHandler.NewHandler(exportSvc, responder)
+
+	// Workspace backup (перенос воркспейса в версионированном JSON-архиве)
+	backupSvc := backupService.NewService(backupRepo.NewRepository(db), workspaceSvc, workspaceRepository, licenseRepository)
+	backupHdlr := backupHandler.NewHandler(backupSvc, responder, cfg.Backup.ImportMaxSize)
+
+	// Calendar feed (расписание привычек в iCalendar по секретной ссылке)
+	calendarFeedSvc := calendarFeedService.NewService(calendarFeedRepo.NewRepository(db), CalendarFeedPath)
+	calendarFeedHdlr := calendarFeedHandler.NewHandler(calendarFeedSvc, responder)
+
+	// Search (полнотекстовый поиск по заметкам, дневнику, привычкам и контрагентам)
+	searchHdlr := searchHandler.NewHandler(searchService.NewService(searchRepo.NewRepository(db), workspaceSvc), responder)
+
 	// Logger
 	loggerHdlr := loggerHandler.NewHandler(logService, responder, validate)
 
+	// Health probes (/health/live, /health/ready)
+	healthHdlr := healthHandler.NewHandler(healthService.NewService(db, cfg.Logs.Dir, workerMetrics, cfg.Server.HealthCheckTimeout))
+
 	// Admin (использует workspace service и user repo)
 	adminHdlr := adminHandler.NewHandler(workspaceSvc, userRepository, responder)
 
 	return &Container{
-		Cfg:              cfg,
-		Router:           r,
-		AuthHandler:      authHdlr,
-		AdminHandler:     adminHdlr,
-		WorkspaceHandler: workspaceHdlr,
-		WorkspaceService: workspaceSvc,
-		MasterHandler:    masterHdlr,
-		NotesHandler:     notesHdlr,
-		HabitsHandler:    habitsHdlr,
-		JournalHandler:   journalHdlr,
-		LoggerHandler:    loggerHdlr,
-		LogService:       logService,
-		TokenGen:         tokenGen,
-		Responder:        responder,
-		Validate:         validate,
-	}
+		Cfg:                 cfg,
+		Router:              r,
+		AuthHandler:         authHdlr,
+		ProfileHandler:      profileHdlr,
+		ExportHandler:       exportHdlr,
+		ExportService:       exportSvc,
+		BackupHandler:       backupHdlr,
+		BackupService:       backupSvc,
+		HabitImportHandler:  habitImportHdlr,
+		HabitImportService:  habitImportSvc,
+		CalendarFeedHandler: calendarFeedHdlr,
+		CalendarFeedService: calendarFeedSvc,
+		AdminHandler:        adminHdlr,
+		WorkspaceHandler:    workspaceHdlr,
+		WorkspaceService:    workspaceSvc,
+		MasterHandler:       masterHdlr,
+		NotesHandler:        notesHdlr,
+		HabitsHandler:       habitsHdlr,
+		HabitsService:       habitsSvc,
+		JournalHandler:      journalHdlr,
+		LinksHandler:        linksHdlr,
+		AttachmentsHandler:  attachmentsHdlr,
+		AttachmentsService:  attachmentsSvc,
+		TrashHandler:        trashHdlr,
+		TrashService:        trashSvc,
+		Storage:             store,
+		SearchHandler:       searchHdlr,
+		LoggerHandler:       loggerHdlr,
+		HealthHandler:       healthHdlr,
+		LogService:          logService,
+		UserRepository:      userRepository,
+		Metrics:             metricsRegistry,
+		WorkerMetrics:       workerMetrics,
+		TokenGen:            tokenGen,
+		Responder:           responder,
+		Validate:            validate,
+	}, nil
 }
 
 func (c *Container) RegisterRoutes(r *router.Router) {
 	r.Handler().Use(middleware.CORSMiddleware())
 	r.Handler().Use(middleware.RequestLogger(c.LogService))
+	r.Handler().Use(middleware.Metrics(c.Metrics))
 
 	swaggerHandler.Register(r.Handler(), c.Cfg.Server.ExposeSwagger, c.Cfg.Server.SwaggerUser, c.Cfg.Server.SwaggerPassword)
+	metricsHandler.Register(r.Handler(), c.Metrics, c.Cfg.Server.ExposeMetrics, c.Cfg.Server.MetricsUser, c.Cfg.Server.MetricsPassword)
 
-	// Health check
+	// Health check (/api/v1/health — прежний поверхностный ответ; для проб — /health/live и /health/ready)
 	r.GET("/api/v1/health", HealthCheck)
+	c.HealthHandler.RegisterRoutes(r.Group("/health"))
 	apiV1 := r.Group("/api/v1")
 
 	// Public auth routes (login, register, logout, refresh)
 	authGroup := apiV1.Group("/auth")
 	c.AuthHandler.RegisterPublicRoutes(authGroup)
+	c.ProfileHandler.RegisterPublicRoutes(authGroup)
+
+	// Аватары по стабильным ссылкам из avatarUrl (без авторизации, чтобы работал <img src>)
+	c.ProfileHandler.RegisterAvatarRoutes(r.Group(AvatarsPath))
+
+	// Файлы локального хранилища по подписанным ссылкам (без авторизации; у S3 свои ссылки)
+	if files, ok := c.Storage.(http.Handler); ok {
+		prefix := localFilesPath(c.Cfg.Storage.LocalURL)
+		r.Handler().GET(prefix+"/*key", gin.WrapH(http.StripPrefix(prefix, files)))
+	}
+
+	// Public note links (без авторизации)
+	c.NotesHandler.RegisterPublicRoutes(apiV1.Group("/public/notes"))
+
+	// Лента привычек для календарей (без авторизации, по токену)
+	c.CalendarFeedHandler.RegisterPublicRoutes(r.Group(CalendarFeedPath))
 
 	// Protected routes
 	protected := apiV1.Group("")
@@ -148,25 +310,89 @@ func (c *Container) RegisterRoutes(r *router.Router) {
 	// Protected auth routes (me)
 	protectedAuthGroup := protected.Group("/auth")
 	c.AuthHandler.RegisterProtectedRoutes(protectedAuthGroup)
+	c.ProfileHandler.RegisterProtectedRoutes(protectedAuthGroup)
+	c.ExportHandler.RegisterRoutes(protectedAuthGroup)
+	c.CalendarFeedHandler.RegisterProtectedRoutes(protectedAuthGroup)
 
 	// Workspace routes (and nested: master data, notes)
 	workspaceGroup := protected.Group("/workspaces")
 	c.WorkspaceHandler.RegisterRoutes(workspaceGroup)
+	c.BackupHandler.RegisterRoutes(workspaceGroup)
 	wsIDGroup := workspaceGroup.Group("/:workspaceId")
 	c.MasterHandler.RegisterRoutes(wsIDGroup)
 	c.NotesHandler.RegisterRoutes(wsIDGroup)
 	c.HabitsHandler.RegisterRoutes(wsIDGroup)
+	c.HabitImportHandler.RegisterRoutes(wsIDGroup)
 	c.JournalHandler.RegisterRoutes(wsIDGroup)
+	c.SearchHandler.RegisterRoutes(wsIDGroup)
+	c.LinksHandler.RegisterRoutes(wsIDGroup)
+	c.AttachmentsHandler.RegisterRoutes(wsIDGroup)
+	c.TrashHandler.RegisterRoutes(wsIDGroup)
+
+	// Notes shared with the current user from other workspaces
+	c.NotesHandler.RegisterSharedRoutes(protected.Group("/shared/notes"))
 
 	adminGroup := protected.Group("/admin")
 	adminGroup.Use(middleware.RequireAdmin(c.Responder))
 	c.AdminHandler.RegisterRoutes(adminGroup)
 
-	// Logger routes
+	// Logger routes (admin only)
 	loggerGroup := protected.Group("/logs")
+	loggerGroup.Use(middleware.RequireAdmin(c.Responder))
 	c.LoggerHandler.RegisterRoutes(loggerGroup)
 }
 
+// AvatarsPath — маршрут стабильных ссылок на аватары (users.avatar_url)
+const AvatarsPath = "/api/v1/avatars"
+
+// CalendarFeedPath — маршрут ICS-лент привычек; полный путь ленты — CalendarFeedPath/<token>.ics
+const CalendarFeedPath = "/api/v1/public/calendar"
+
+// NewMailer — SMTP, если задан SMTP_HOST, иначе письма пишутся в лог
+func NewMailer(cfg config.MailConfig) mailer.Mailer {
+	if cfg.SMTPHost == "" {
+		return mailer.Log{}
+	}
+	return mailer.NewSMTP(mailer.SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.From,
+	})
+}
+
+// NewStorage создаёт хранилище вложений по STORAGE_BACKEND: local или s3
+func NewStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.Storage.Backend {
+	case "", "local":
+		secret := cfg.Storage.SigningKey
+		if secret == "" {
+			secret = cfg.Auth.JWTSecretKey
+		}
+		return storage.NewLocal(cfg.Storage.LocalDir, cfg.Storage.LocalURL, []byte(secret))
+	case "s3":
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.Storage.S3Endpoint,
+			Region:    cfg.Storage.S3Region,
+			Bucket:    cfg.Storage.S3Bucket,
+			AccessKey: cfg.Storage.S3AccessKey,
+			SecretKey: cfg.Storage.S3SecretKey,
+			PathStyle: cfg.Storage.S3PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q (local or s3)", cfg.Storage.Backend)
+	}
+}
+
+// localFilesPath — путь маршрута из STORAGE_LOCAL_URL, который может быть и полным адресом
+func localFilesPath(localURL string) string {
+	if u, err := url.Parse(localURL); err == nil && u.Path != "" {
+		return strings.TrimSuffix(u.Path, "/")
+	}
+	return "/api/v1/files"
+}
+
 func HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "ok",
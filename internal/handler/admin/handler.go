@@ -6,7 +6,9 @@ import (
 	"backend/internal/middleware"
 	"backend/internal/model"
 	userRepo "backend/internal/repository/user"
+	workspaceRepo "backend/internal/repository/workspace"
 	workspaceService "backend/internal/service/workspace"
+	"backend/pkg/query"
 	"backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -44,17 +46,27 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 
 // ListWorkspaces возвращает все workspaces. Вызывать только после RequireAdmin middleware.
 func (h *Handler) ListWorkspaces(c *gin.Context) {
-	list, err := h.workspaceService.ListAllForAdmin(c.Request.Context())
+	spec, err := query.Parse(c.Request.URL.Query(), workspaceRepo.ListAllSchema)
+	if err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	list, page, err := h.workspaceService.ListAllForAdmin(c.Request.Context(), spec)
 	if err != nil {
 		h.responder.InternalServerError(c, "Failed to list workspaces")
 		return
 	}
-	h.responder.SuccessWithData(c, gin.H{"workspaces": list})
+	h.responder.SuccessWithPage(c, gin.H{"workspaces": list}, page)
 }
 
 // ListUsers возвращает всех пользователей с их workspaces. Только для ADMIN.
 func (h *Handler) ListUsers(c *gin.Context) {
-	users, err := h.userRepo.ListAll(c.Request.Context())
+	spec, err := query.Parse(c.Request.URL.Query(), userRepo.ListSchema)
+	if err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	users, page, err := h.userRepo.ListAll(c.Request.Context(), spec)
 	if err != nil {
 		h.responder.InternalServerError(c, "Failed to list users")
 		return
@@ -100,7 +112,7 @@ func (h *Handler) ListUsers(c *gin.Context) {
 			Workspaces: wsList,
 		})
 	}
-	h.responder.SuccessWithData(c, gin.H{"users": result})
+	h.responder.SuccessWithPage(c, gin.H{"users": result}, page)
 }
 
 // DeleteUser удаляет пользователя (soft delete). Нельзя удалить себя. Только для ADMIN.
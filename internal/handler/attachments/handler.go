@@ -0,0 +1,206 @@
+package attachments
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"backend/internal/middleware"
+	"backend/internal/model"
+	attachmentsRepo "backend/internal/repository/attachments"
+	attachmentsService "backend/internal/service/attachments"
+	workspaceService "backend/internal/service/workspace"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead — запас на заголовки и границы multipart сверх размера файла
+const multipartOverhead = 1 << 20
+
+type Handler struct {
+	attachmentsSvc *attachmentsService.Service
+	workspaceSvc   *workspaceService.Service
+	responder      *response.Responder
+}
+
+func NewHandler(
+	attachmentsSvc *attachmentsService.Service,
+	workspaceSvc *workspaceService.Service,
+	responder *response.Responder,
+) *Handler {
+	return &Handler{
+		attachmentsSvc: attachmentsSvc,
+		workspaceSvc:   workspaceSvc,
+		responder:      responder,
+	}
+}
+
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST(RouteNoteAttachments, h.upload(model.AttachmentOwnerNote, "noteId"))
+	r.GET(RouteNoteAttachments, h.list(model.AttachmentOwnerNote, "noteId"))
+	r.POST(RouteJournalAttachments, h.upload(model.AttachmentOwnerJournal, "entryId"))
+	r.GET(RouteJournalAttachments, h.list(model.AttachmentOwnerJournal, "entryId"))
+	r.POST(RouteCompletionAttachments, h.upload(model.AttachmentOwnerHabitCompletion, "completionId"))
+	r.GET(RouteCompletionAttachments, h.list(model.AttachmentOwnerHabitCompletion, "completionId"))
+
+	attachments := r.Group("/attachments")
+	{
+		attachments.GET(RouteUsage, h.Usage)
+		attachments.GET(RouteGet, h.Get)
+		attachments.GET(RouteDownload, h.Download)
+		attachments.DELETE(RouteDelete, h.Delete)
+	}
+}
+
+func (h *Handler) requireWorkspaceAccess(c *gin.Context) (workspaceID, userID string, ok bool) {
+	userID, ok = middleware.GetUserIDFromGin(c)
+	if !ok {
+		h.responder.Unauthorized(c, "Authentication required")
+		return "", "", false
+	}
+	workspaceID = c.Param("workspaceId")
+	if workspaceID == "" {
+		h.responder.BadRequest(c, "Workspace ID required")
+		return "", "", false
+	}
+	roleVal, _ := c.Get(middleware.GinRoleKey)
+	role := model.UserRoleUser
+	if roleVal != nil {
+		role = roleVal.(model.UserRole)
+	}
+	hasAccess, err := h.workspaceSvc.HasAccess(c.Request.Context(), workspaceID, userID, role)
+	if err != nil || !hasAccess {
+		h.responder.Forbidden(c, "Access denied to this workspace")
+		return "", "", false
+	}
+	return workspaceID, userID, true
+}
+
+// upload принимает multipart/form-data с файлом в поле "file"
+func (h *Handler) upload(ownerType, ownerParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceID, userID, ok := h.requireWorkspaceAccess(c)
+		if !ok {
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.attachmentsSvc.MaxSize()+multipartOverhead)
+		fh, err := c.FormFile(FileField)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				h.responder.WriteError(c, http.StatusRequestEntityTooLarge, attachmentsService.ErrTooLarge.Error())
+				return
+			}
+			h.responder.BadRequest(c, `multipart field "file" is required`)
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			h.responder.BadRequest(c, "Invalid file")
+			return
+		}
+		defer f.Close()
+
+		a, err := h.attachmentsSvc.Upload(c.Request.Context(), workspaceID, userID, ownerType, c.Param(ownerParam), fh.Filename, fh.Size, f)
+		if err != nil {
+			h.attachmentError(c, err, "Failed to upload attachment")
+			return
+		}
+		h.responder.Created(c, "Attachment uploaded", a)
+	}
+}
+
+func (h *Handler) list(ownerType, ownerParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceID, _, ok := h.requireWorkspaceAccess(c)
+		if !ok {
+			return
+		}
+		list, err := h.attachmentsSvc.List(c.Request.Context(), workspaceID, ownerType, c.Param(ownerParam))
+		if err != nil {
+			h.attachmentError(c, err, "Failed to list attachments")
+			return
+		}
+		h.responder.SuccessWithData(c, gin.H{"attachments": list})
+	}
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	a, err := h.attachmentsSvc.Get(c.Request.Context(), workspaceID, c.Param("attachmentId"))
+	if err != nil {
+		h.responder.InternalServerError(c, "Failed to get attachment")
+		return
+	}
+	if a == nil {
+		h.responder.NotFound(c, "Attachment not found")
+		return
+	}
+	h.responder.SuccessWithData(c, a)
+}
+
+// Download перенаправляет на свежую подписанную ссылку
+func (h *Handler) Download(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	a, err := h.attachmentsSvc.Get(c.Request.Context(), workspaceID, c.Param("attachmentId"))
+	if err != nil {
+		h.responder.InternalServerError(c, "Failed to get attachment")
+		return
+	}
+	if a == nil {
+		h.responder.NotFound(c, "Attachment not found")
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, a.URL)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	if err := h.attachmentsSvc.Delete(c.Request.Context(), workspaceID, c.Param("attachmentId")); err != nil {
+		h.attachmentError(c, err, "Failed to delete attachment")
+		return
+	}
+	h.responder.SuccessWithMessage(c, "Attachment deleted")
+}
+
+// Usage — занятое место и квота воркспейса
+func (h *Handler) Usage(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	usage, err := h.attachmentsSvc.Usage(c.Request.Context(), workspaceID)
+	if err != nil {
+		h.responder.InternalServerError(c, "Failed to get attachments usage")
+		return
+	}
+	h.responder.SuccessWithData(c, usage)
+}
+
+func (h *Handler) attachmentError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		h.responder.NotFound(c, "Attachment not found")
+	case errors.Is(err, attachmentsRepo.ErrOwnerNotFound):
+		h.responder.NotFound(c, err.Error())
+	case errors.Is(err, attachmentsService.ErrEmptyFile):
+		h.responder.BadRequest(c, err.Error())
+	case errors.Is(err, attachmentsService.ErrTooLarge), errors.Is(err, attachmentsRepo.ErrQuotaExceeded):
+		h.responder.WriteError(c, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, attachmentsService.ErrTypeNotAllowed):
+		h.responder.WriteError(c, http.StatusUnsupportedMediaType, err.Error())
+	default:
+		h.responder.InternalServerError(c, msg)
+	}
+}
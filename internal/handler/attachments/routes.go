@@ -0,0 +1,19 @@
+package attachments
+
+// Загрузка и список вложений владельца (под /workspaces/:workspaceId)
+const (
+	RouteNoteAttachments       = "/notes/:noteId/attachments"
+	RouteJournalAttachments    = "/journal/:entryId/attachments"
+	RouteCompletionAttachments = "/habits/completions/:completionId/attachments"
+)
+
+// Вложение по id (под /workspaces/:workspaceId/attachments)
+const (
+	RouteUsage    = "/usage"
+	RouteGet      = "/:attachmentId"
+	RouteDownload = "/:attachmentId/download"
+	RouteDelete   = "/:attachmentId"
+)
+
+// FileField — поле multipart-формы с файлом
+const FileField = "file"
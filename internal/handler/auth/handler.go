@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -180,6 +181,11 @@ func (h *Handler) Me(c *gin.Context) {
 	}
 
 	user, err := h.service.GetUserProfile(c.Request.Context(), userID)
+	if errors.Is(err, authService.ErrUserNotFound) {
+		// Аккаунт удалён, а токен ещё не истёк
+		h.responder.Unauthorized(c, "User not found")
+		return
+	}
 	if err != nil {
 		h.responder.InternalServerError(c, "Failed to get user profile")
 		return
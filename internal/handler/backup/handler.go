@@ -0,0 +1,183 @@
+package backup
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"time"
+
+	"backend/internal/middleware"
+	"backend/internal/model"
+	backupService "backend/internal/service/backup"
+	workspaceService "backend/internal/service/workspace"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service   *backupService.Service
+	responder *response.Responder
+	maxSize   int64
+}
+
+func NewHandler(service *backupService.Service, responder *response.Responder, maxSize int64) *Handler {
+	return &Handler{service: service, responder: responder, maxSize: maxSize}
+}
+
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET(RouteExport, h.Export)
+	r.POST(RouteImportInto, h.ImportInto)
+	r.POST(RouteImport, h.Import)
+}
+
+// Export — GET /workspaces/:workspaceId/export: JSON-архив воркспейса файлом (только владелец или админ)
+func (h *Handler) Export(c *gin.Context) {
+	userID, role, ok := h.requireUser(c)
+	if !ok {
+		return
+	}
+	workspaceID, ok := h.workspaceID(c)
+	if !ok {
+		return
+	}
+	a, err := h.service.Export(c.Request.Context(), workspaceID, userID, role)
+	if err != nil {
+		h.backupError(c, err, "Failed to export workspace")
+		return
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": archiveName(a.Workspace.Name, a.ExportedAt),
+	}))
+	c.Header("Cache-Control", "no-store")
+	h.responder.WriteJSON(c, http.StatusOK, a)
+}
+
+// Import — POST /workspaces/import: создаёт новый воркспейс из архива (тело — JSON-архив).
+// 201 — создан, 200 — этот архив уже импортирован раньше (alreadyImported).
+func (h *Handler) Import(c *gin.Context) {
+	userID, role, ok := h.requireUser(c)
+	if !ok {
+		return
+	}
+	data, ok := h.readArchive(c)
+	if !ok {
+		return
+	}
+	res, err := h.service.ImportNew(c.Request.Context(), userID, role, data)
+	if err != nil {
+		h.backupError(c, err, "Failed to import workspace")
+		return
+	}
+	if res.AlreadyImported {
+		h.responder.Success(c, http.StatusOK, "Archive already imported", res)
+		return
+	}
+	h.responder.Created(c, "Workspace imported", res)
+}
+
+// ImportInto — POST /workspaces/:workspaceId/import: загружает архив в пустой воркспейс. 409 — в нём уже есть данные.
+func (h *Handler) ImportInto(c *gin.Context) {
+	userID, role, ok := h.requireUser(c)
+	if !ok {
+		return
+	}
+	workspaceID, ok := h.workspaceID(c)
+	if !ok {
+		return
+	}
+	data, ok := h.readArchive(c)
+	if !ok {
+		return
+	}
+	res, err := h.service.ImportInto(c.Request.Context(), workspaceID, userID, role, data)
+	if err != nil {
+		h.backupError(c, err, "Failed to import workspace")
+		return
+	}
+	msg := "Workspace imported"
+	if res.AlreadyImported {
+		msg = "Archive already imported"
+	}
+	h.responder.Success(c, http.StatusOK, msg, res)
+}
+
+func (h *Handler) readArchive(c *gin.Context) ([]byte, bool) {
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.responder.WriteError(c, http.StatusRequestEntityTooLarge, "Archive is too large")
+			return nil, false
+		}
+		h.responder.BadRequest(c, "Failed to read archive")
+		return nil, false
+	}
+	if len(data) == 0 {
+		h.responder.BadRequest(c, "Archive is required")
+		return nil, false
+	}
+	return data, true
+}
+
+func (h *Handler) backupError(c *gin.Context, err error, fallback string) {
+	var invalid *backupService.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		h.responder.WriteErrorWithCode(c, http.StatusBadRequest, "INVALID_ARCHIVE", "Archive is invalid", invalid.Problems)
+	case errors.Is(err, backupService.ErrUnsupportedFormat):
+		h.responder.WriteErrorWithCode(c, http.StatusBadRequest, "INVALID_ARCHIVE", err.Error(), nil)
+	case errors.Is(err, backupService.ErrUnsupportedVersion):
+		h.responder.WriteErrorWithCode(c, http.StatusBadRequest, "UNSUPPORTED_VERSION", err.Error(), nil)
+	case errors.Is(err, backupService.ErrWorkspaceNotEmpty):
+		h.responder.WriteErrorWithCode(c, http.StatusConflict, "WORKSPACE_NOT_EMPTY",
+			"Workspace already has data; import into a new or empty workspace", nil)
+	case errors.Is(err, backupService.ErrNotOwner), errors.Is(err, workspaceService.ErrAccessDenied):
+		h.responder.Forbidden(c, "Only the workspace owner can export or import it")
+	case errors.Is(err, workspaceService.ErrWorkspaceNotFound):
+		h.responder.NotFound(c, "Workspace not found")
+	default:
+		h.responder.InternalServerError(c, fallback)
+	}
+}
+
+func (h *Handler) workspaceID(c *gin.Context) (string, bool) {
+	id := c.Param("workspaceId")
+	if _, err := uuid.Parse(id); err != nil {
+		h.responder.BadRequest(c, "Invalid workspace ID")
+		return "", false
+	}
+	return id, true
+}
+
+func (h *Handler) requireUser(c *gin.Context) (string, model.UserRole, bool) {
+	userID, ok := middleware.GetUserIDFromGin(c)
+	if !ok {
+		h.responder.Unauthorized(c, "Authentication required")
+		return "", "", false
+	}
+	roleVal, _ := c.Get(middleware.GinRoleKey)
+	role := model.UserRoleUser
+	if roleVal != nil {
+		role = roleVal.(model.UserRole)
+	}
+	return userID, role, true
+}
+
+var unsafeFilename = regexp.MustCompile(`[^\p{L}\p{N}._-]+`)
+
+// archiveName — имя файла выгрузки: название воркспейса без небезопасных символов и дата.
+// Non-ASCII имя mime.FormatMediaType кодирует по RFC 2231.
+func archiveName(workspace string, at time.Time) string {
+	name := unsafeFilename.ReplaceAllString(workspace, "-")
+	if len([]rune(name)) > 50 {
+		name = string([]rune(name)[:50])
+	}
+	if name == "" || name == "-" {
+		name = "workspace"
+	}
+	return name + "-" + at.Format("2006-01-02") + ".json"
+}
@@ -0,0 +1,8 @@
+package backup
+
+// Перенос воркспейса (под /workspaces)
+const (
+	RouteExport     = "/:workspaceId/export"
+	RouteImportInto = "/:workspaceId/import"
+	RouteImport     = "/import"
+)
@@ -0,0 +1,100 @@
+package calendarfeed
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"backend/internal/middleware"
+	calendarFeedService "backend/internal/service/calendarfeed"
+	"backend/pkg/ical"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service   *calendarFeedService.Service
+	responder *response.Responder
+}
+
+func NewHandler(service *calendarFeedService.Service, responder *response.Responder) *Handler {
+	return &Handler{service: service, responder: responder}
+}
+
+func (h *Handler) RegisterProtectedRoutes(r *gin.RouterGroup) {
+	r.GET(RouteFeed, h.Get)
+	r.POST(RouteFeed, h.Regenerate)
+	r.DELETE(RouteFeed, h.Revoke)
+}
+
+// RegisterPublicRoutes — лента по токену (группа /public/calendar без GinAuthMiddleware)
+func (h *Handler) RegisterPublicRoutes(r *gin.RouterGroup) {
+	r.GET(RoutePublicFeed, h.Feed)
+}
+
+// Get — GET /auth/me/calendar-feed: включена ли лента, когда создана и когда календарь обращался к ней
+func (h *Handler) Get(c *gin.Context) {
+	userID, ok := middleware.GetUserIDFromGin(c)
+	if !ok {
+		h.responder.Unauthorized(c, "Authentication required")
+		return
+	}
+	f, err := h.service.Get(c.Request.Context(), userID)
+	if err != nil {
+		h.responder.InternalServerError(c, "Failed to get calendar feed")
+		return
+	}
+	h.responder.SuccessWithData(c, f)
+}
+
+// Regenerate — POST /auth/me/calendar-feed: создаёт ссылку или заменяет прежнюю. token и path показываются один раз.
+func (h *Handler) Regenerate(c *gin.Context) {
+	userID, ok := middleware.GetUserIDFromGin(c)
+	if !ok {
+		h.responder.Unauthorized(c, "Authentication required")
+		return
+	}
+	f, err := h.service.Regenerate(c.Request.Context(), userID)
+	if err != nil {
+		h.responder.InternalServerError(c, "Failed to create calendar feed")
+		return
+	}
+	h.responder.Created(c, "Calendar feed created", f)
+}
+
+// Revoke — DELETE /auth/me/calendar-feed: ссылка перестаёт работать
+func (h *Handler) Revoke(c *gin.Context) {
+	userID, ok := middleware.GetUserIDFromGin(c)
+	if !ok {
+		h.responder.Unauthorized(c, "Authentication required")
+		return
+	}
+	if err := h.service.Revoke(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.responder.NotFound(c, "Calendar feed is not enabled")
+			return
+		}
+		h.responder.InternalServerError(c, "Failed to revoke calendar feed")
+		return
+	}
+	h.responder.SuccessWithMessage(c, "Calendar feed revoked")
+}
+
+// Feed — GET /public/calendar/:token(.ics): лента в формате iCalendar
+func (h *Handler) Feed(c *gin.Context) {
+	data, err := h.service.Render(c.Request.Context(), strings.TrimSuffix(c.Param("token"), ".ics"))
+	if err != nil {
+		if errors.Is(err, calendarFeedService.ErrFeedNotFound) {
+			h.responder.NotFound(c, err.Error())
+			return
+		}
+		h.responder.InternalServerError(c, "Failed to render calendar feed")
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Header("X-Robots-Tag", "noindex")
+	c.Header("Content-Disposition", `inline; filename="habits.ics"`)
+	c.Data(http.StatusOK, ical.ContentType, data)
+}
@@ -0,0 +1,7 @@
+package calendarfeed
+
+// Управление лентой (под /auth, с авторизацией)
+const RouteFeed = "/me/calendar-feed"
+
+// Лента по секретной ссылке без авторизации (под /public/calendar); к токену можно добавить .ics
+const RoutePublicFeed = "/:token"
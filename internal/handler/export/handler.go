@@ -0,0 +1,124 @@
+package export
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/internal/middleware"
+	"backend/internal/model"
+	exportService "backend/internal/service/export"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service   *exportService.Service
+	responder *response.Responder
+}
+
+func NewHandler(service *exportService.Service, responder *response.Responder) *Handler {
+	return &Handler{service: service, responder: responder}
+}
+
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST(RouteExports, h.Request)
+	r.GET(RouteExports, h.List)
+	r.GET(RouteExport, h.Get)
+	r.GET(RouteExportDownload, h.Download)
+}
+
+// Request — POST /auth/me/exports: ставит выгрузку всех данных в очередь. 409 — предыдущая ещё собирается.
+func (h *Handler) Request(c *gin.Context) {
+	userID, ok := h.requireUser(c)
+	if !ok {
+		return
+	}
+	e, err := h.service.Request(c.Request.Context(), userID)
+	if errors.Is(err, exportService.ErrExportInProgress) {
+		h.responder.Conflict(c, err.Error())
+		return
+	}
+	if err != nil {
+		h.responder.InternalServerError(c, "Failed to request data export")
+		return
+	}
+	h.responder.Success(c, http.StatusAccepted, "Data export queued", e)
+}
+
+// List — GET /auth/me/exports: последние выгрузки, у готовых — downloadUrl
+func (h *Handler) List(c *gin.Context) {
+	userID, ok := h.requireUser(c)
+	if !ok {
+		return
+	}
+	list, err := h.service.List(c.Request.Context(), userID)
+	if err != nil {
+		h.responder.InternalServerError(c, "Failed to list data exports")
+		return
+	}
+	h.responder.SuccessWithData(c, list)
+}
+
+// Get — GET /auth/me/exports/:exportId: статус выгрузки для опроса
+func (h *Handler) Get(c *gin.Context) {
+	e, ok := h.export(c)
+	if !ok {
+		return
+	}
+	h.responder.SuccessWithData(c, e)
+}
+
+// Download — GET /auth/me/exports/:exportId/download: редирект на подписанную ссылку архива
+func (h *Handler) Download(c *gin.Context) {
+	e, ok := h.export(c)
+	if !ok {
+		return
+	}
+	switch e.Status {
+	case model.DataExportReady:
+		c.Header("Cache-Control", "no-store")
+		c.Redirect(http.StatusFound, e.DownloadURL)
+	case model.DataExportExpired:
+		h.responder.NotFound(c, "Data export has expired")
+	default:
+		h.responder.Conflict(c, "Data export is not ready")
+	}
+}
+
+func (h *Handler) export(c *gin.Context) (*model.DataExport, bool) {
+	userID, ok := h.requireUser(c)
+	if !ok {
+		return nil, false
+	}
+	id, err := uuid.Parse(c.Param("exportId"))
+	if err != nil {
+		h.responder.BadRequest(c, "Invalid export ID")
+		return nil, false
+	}
+	e, err := h.service.Get(c.Request.Context(), userID, id)
+	if err != nil {
+		h.responder.InternalServerError(c, "Failed to get data export")
+		return nil, false
+	}
+	if e == nil {
+		h.responder.NotFound(c, "Data export not found")
+		return nil, false
+	}
+	return e, true
+}
+
+func (h *Handler) requireUser(c *gin.Context) (uuid.UUID, bool) {
+	userID, ok := middleware.GetUserIDFromGin(c)
+	if !ok {
+		h.responder.Unauthorized(c, "Authentication required")
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		h.responder.Unauthorized(c, "Authentication required")
+		return uuid.Nil, false
+	}
+	return id, true
+}
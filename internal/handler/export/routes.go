@@ -0,0 +1,8 @@
+package export
+
+// Выгрузка своих данных (под /auth, с авторизацией)
+const (
+	RouteExports        = "/me/exports"
+	RouteExport         = "/me/exports/:exportId"
+	RouteExportDownload = "/me/exports/:exportId/download"
+)
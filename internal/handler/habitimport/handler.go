@@ -0,0 +1,106 @@
+package habitimport
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"backend/internal/middleware"
+	"backend/internal/model"
+	habitImportService "backend/internal/service/habitimport"
+	workspaceService "backend/internal/service/workspace"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead — запас на заголовки, поля и границы multipart сверх размера файла
+const multipartOverhead = 1 << 20
+
+type Handler struct {
+	service      *habitImportService.Service
+	workspaceSvc *workspaceService.Service
+	responder    *response.Responder
+	maxSize      int64
+}
+
+func NewHandler(service *habitImportService.Service, workspaceSvc *workspaceService.Service, responder *response.Responder, maxSize int64) *Handler {
+	return &Handler{service: service, workspaceSvc: workspaceSvc, responder: responder, maxSize: maxSize}
+}
+
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST(RouteImport, h.Import)
+}
+
+// Import — POST /workspaces/:workspaceId/habits/import: multipart с файлом (CSV, резервная копия .db или ZIP из Loop),
+// необязательными format и mapping. ?dryRun=true — предпросмотр без изменений.
+func (h *Handler) Import(c *gin.Context) {
+	userID, ok := middleware.GetUserIDFromGin(c)
+	if !ok {
+		h.responder.Unauthorized(c, "Authentication required")
+		return
+	}
+	workspaceID := c.Param("workspaceId")
+	roleVal, _ := c.Get(middleware.GinRoleKey)
+	role := model.UserRoleUser
+	if roleVal != nil {
+		role = roleVal.(model.UserRole)
+	}
+	hasAccess, err := h.workspaceSvc.HasAccess(c.Request.Context(), workspaceID, userID, role)
+	if err != nil || !hasAccess {
+		h.responder.Forbidden(c, "Access denied to this workspace")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+	fh, err := c.FormFile(FileField)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.responder.WriteError(c, http.StatusRequestEntityTooLarge, "File is too large")
+			return
+		}
+		h.responder.BadRequest(c, `multipart field "file" is required`)
+		return
+	}
+	if fh.Size > h.maxSize {
+		h.responder.WriteError(c, http.StatusRequestEntityTooLarge, "File is too large")
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		h.responder.BadRequest(c, "Invalid file")
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		h.responder.BadRequest(c, "Invalid file")
+		return
+	}
+
+	in := habitImportService.Input{Format: c.PostForm(FormatField), Data: data}
+	if raw := c.PostForm(MappingField); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &in.Mapping); err != nil {
+			h.responder.BadRequest(c, "Invalid mapping: "+err.Error())
+			return
+		}
+	}
+	dryRun := c.Query("dryRun") == "true"
+
+	res, err := h.service.Import(c.Request.Context(), workspaceID, userID, in, dryRun)
+	switch {
+	case errors.Is(err, habitImportService.ErrInvalidFile),
+		errors.Is(err, habitImportService.ErrUnknownFormat),
+		errors.Is(err, habitImportService.ErrTooManyHabits),
+		errors.Is(err, habitImportService.ErrNoCompletions):
+		h.responder.WriteErrorWithCode(c, http.StatusBadRequest, "INVALID_IMPORT_FILE", err.Error(), nil)
+	case err != nil:
+		h.responder.InternalServerError(c, "Failed to import habit history")
+	case dryRun:
+		h.responder.SuccessWithData(c, res)
+	default:
+		h.responder.Success(c, http.StatusOK, "Habit history imported", res)
+	}
+}
@@ -0,0 +1,11 @@
+package habitimport
+
+// Импорт истории привычек (под /workspaces/:workspaceId)
+const RouteImport = "/habits/import"
+
+// Поля multipart-формы
+const (
+	FileField    = "file"
+	FormatField  = "format"
+	MappingField = "mapping" // JSON model.HabitImportMapping
+)
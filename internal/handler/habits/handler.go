@@ -3,7 +3,9 @@ package habits
 import (
 	"backend/internal/middleware"
 	"backend/internal/model"
+	habitsRepo "backend/internal/repository/habits"
 	habitsService "backend/internal/service/habits"
+	"backend/pkg/query"
 	"backend/pkg/response"
 	"time"
 
@@ -74,13 +76,19 @@ func (h *Handler) List(c *gin.Context) {
 		targetDate = &parsedDate
 	}
 
-	list, err := h.service.List(c.Request.Context(), workspaceIDParam, targetDate)
+	spec, err := query.Parse(c.Request.URL.Query(), habitsRepo.ListSchema)
+	if err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+
+	list, page, err := h.service.List(c.Request.Context(), workspaceIDParam, targetDate, spec)
 	if err != nil {
 		h.responder.InternalServerError(c, "Failed to list habits")
 		return
 	}
 
-	h.responder.SuccessWithData(c, gin.H{"habits": list})
+	h.responder.SuccessWithPage(c, gin.H{"habits": list}, page)
 }
 
 func (h *Handler) Create(c *gin.Context) {
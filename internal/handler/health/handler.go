@@ -0,0 +1,40 @@
+package health
+
+import (
+	"backend/internal/service/health"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *health.Service
+}
+
+func NewHandler(service *health.Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes регистрирует пробы. Ответы без обёртки responder — их читают Kubernetes и балансировщики.
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET(RouteLive, h.Live)
+	r.GET(RouteReady, h.Ready)
+}
+
+// Live — процесс жив и обрабатывает запросы; зависимости не проверяются
+func (h *Handler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":   health.StatusOK,
+		"uptime_s": int64(h.service.Uptime().Seconds()),
+	})
+}
+
+// Ready — можно ли слать трафик: 200, если все проверки прошли, иначе 503 с результатами по каждой
+func (h *Handler) Ready(c *gin.Context) {
+	report := h.service.Ready(c.Request.Context())
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,85 @@
+package health_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"backend/internal/router"
+	"backend/internal/service/health"
+	"backend/internal/testutil/pgtest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	pgtest.Main(m)
+}
+
+func TestProbes(t *testing.T) {
+	env := pgtest.New(t)
+	r := router.New(env.Container.Responder)
+	env.Container.RegisterRoutes(r)
+
+	ready := func() (int, health.Report) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+		var report health.Report
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("ready body: %v\n%s", err, rec.Body)
+		}
+		return rec.Code, report
+	}
+	statusOf := func(report health.Report, name string) string {
+		for _, c := range report.Checks {
+			if c.Name == name {
+				return c.Status
+			}
+		}
+		return ""
+	}
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("live: %d", rec.Code)
+	}
+
+	code, report := ready()
+	if code != http.StatusOK || report.Status != health.StatusOK || len(report.Checks) != 4 {
+		t.Fatalf("ready: %d %+v", code, report)
+	}
+
+	// Незавершённая миграция — под не готов
+	if _, err := env.DB.Exec(`UPDATE schema_migrations SET dirty = TRUE`); err != nil {
+		t.Fatal(err)
+	}
+	code, report = ready()
+	if code != http.StatusServiceUnavailable || statusOf(report, "migrations") != health.StatusFail || statusOf(report, "database") != health.StatusOK {
+		t.Fatalf("dirty: %d %+v", code, report)
+	}
+
+	// Схема старее встроенных миграций (новый код на старой БД)
+	if _, err := env.DB.Exec(`UPDATE schema_migrations SET dirty = FALSE, version = 1`); err != nil {
+		t.Fatal(err)
+	}
+	if code, report = ready(); code != http.StatusServiceUnavailable || statusOf(report, "migrations") != health.StatusFail {
+		t.Fatalf("behind: %d %+v", code, report)
+	}
+
+	// Схема новее кода (откат релиза) не мешает, а вот пропавший каталог логов — да
+	if _, err := env.DB.Exec(`UPDATE schema_migrations SET version = 1000000`); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(env.Cfg.Logs.Dir); err != nil {
+		t.Fatal(err)
+	}
+	if code, report = ready(); code != http.StatusServiceUnavailable || statusOf(report, "log_dir") != health.StatusFail ||
+		statusOf(report, "migrations") != health.StatusOK {
+		t.Fatalf("log dir: %d %+v", code, report)
+	}
+}
@@ -0,0 +1,6 @@
+package health
+
+const (
+	RouteLive  = "/live"
+	RouteReady = "/ready"
+)
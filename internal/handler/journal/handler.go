@@ -3,17 +3,22 @@ package journal
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
+	"strconv"
 	"time"
 
 	"backend/internal/middleware"
 	"backend/internal/model"
+	journalRepo "backend/internal/repository/journal"
 	journalService "backend/internal/service/journal"
 	workspaceService "backend/internal/service/workspace"
+	"backend/pkg/query"
 	"backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
 type Handler struct {
@@ -45,6 +50,13 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 		journal.GET(RouteGet, h.Get)
 		journal.PUT(RouteUpdate, h.Update)
 		journal.DELETE(RouteDelete, h.Delete)
+		journal.GET(RouteTags, h.GetTags)
+		journal.POST(RouteTagsRename, h.RenameTags)
+		journal.GET(RouteMood, h.GetMood)
+		journal.GET(RouteRevisions, h.ListRevisions)
+		journal.GET(RouteRevisionsDiff, h.DiffRevisions)
+		journal.GET(RouteRevision, h.GetRevision)
+		journal.POST(RouteRevisionRestore, h.RestoreRevision)
 	}
 }
 
@@ -77,22 +89,18 @@ func (h *Handler) List(c *gin.Context) {
 	if !ok {
 		return
 	}
-	var date *time.Time
-	if dateStr := c.Query("date"); dateStr != "" {
-		t, err := time.Parse("2006-01-02", dateStr)
-		if err != nil {
-			h.responder.BadRequest(c, "Invalid date format. Use YYYY-MM-DD")
-			return
-		}
-		date = &t
+	spec, err := query.Parse(c.Request.URL.Query(), journalRepo.ListSchema)
+	if err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
 	}
-	list, err := h.service.List(c.Request.Context(), workspaceID, date)
+	list, page, err := h.service.List(c.Request.Context(), workspaceID, spec)
 	if err != nil {
 		log.Printf("[journal] List failed: %v", err)
 		h.responder.InternalServerError(c, "Failed to list journal entries")
 		return
 	}
-	h.responder.SuccessWithData(c, gin.H{"entries": list})
+	h.responder.SuccessWithPage(c, gin.H{"entries": list}, page)
 }
 
 func (h *Handler) Get(c *gin.Context) {
@@ -132,7 +140,7 @@ func (h *Handler) Create(c *gin.Context) {
 }
 
 func (h *Handler) Update(c *gin.Context) {
-	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	workspaceID, userID, ok := h.requireWorkspaceAccess(c)
 	if !ok {
 		return
 	}
@@ -142,7 +150,7 @@ func (h *Handler) Update(c *gin.Context) {
 		h.responder.BadRequest(c, "Invalid request")
 		return
 	}
-	entry, err := h.service.Update(c.Request.Context(), workspaceID, entryID, req)
+	entry, err := h.service.Update(c.Request.Context(), workspaceID, entryID, userID, req)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			h.responder.NotFound(c, "Entry not found")
@@ -170,3 +178,199 @@ func (h *Handler) Delete(c *gin.Context) {
 	}
 	h.responder.SuccessWithMessage(c, "Entry deleted")
 }
+
+// GetTags — облако тегов: теги и число записей. Query: from, to (YYYY-MM-DD), userId, limit.
+func (h *Handler) GetTags(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	f, err := parseStatsFilter(c)
+	if err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			h.responder.BadRequest(c, "invalid limit")
+			return
+		}
+	}
+	tags, err := h.service.TagCounts(c.Request.Context(), workspaceID, f, limit)
+	if err != nil {
+		if errors.Is(err, journalService.ErrInvalidRange) {
+			h.responder.BadRequest(c, err.Error())
+			return
+		}
+		h.responder.InternalServerErrorWithDetails(c, "Failed to get tags", err)
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"tags": tags})
+}
+
+// GetMood — настроение по дням (period=day) или неделям (period=week). Query: from, to, userId, period.
+func (h *Handler) GetMood(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	f, err := parseStatsFilter(c)
+	if err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	period := c.DefaultQuery("period", model.MoodPeriodDay)
+	points, err := h.service.MoodSeries(c.Request.Context(), workspaceID, f, period)
+	if err != nil {
+		if errors.Is(err, journalService.ErrInvalidPeriod) || errors.Is(err, journalService.ErrInvalidRange) ||
+			errors.Is(err, journalService.ErrRangeTooLong) {
+			h.responder.BadRequest(c, err.Error())
+			return
+		}
+		h.responder.InternalServerErrorWithDetails(c, "Failed to get mood stats", err)
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"period": period, "points": points})
+}
+
+// RenameTags переименовывает или сливает теги во всех записях воркспейса
+func (h *Handler) RenameTags(c *gin.Context) {
+	workspaceID, userID, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	var req model.RenameJournalTagsDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.responder.BadRequest(c, "Invalid request")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	updated, err := h.service.RenameTags(c.Request.Context(), workspaceID, userID, req)
+	if err != nil {
+		if errors.Is(err, journalService.ErrInvalidTagName) || errors.Is(err, journalService.ErrNothingToRename) {
+			h.responder.BadRequest(c, err.Error())
+			return
+		}
+		h.responder.InternalServerErrorWithDetails(c, "Failed to rename tags", err)
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"updated": updated})
+}
+
+func parseStatsFilter(c *gin.Context) (journalRepo.StatsFilter, error) {
+	var f journalRepo.StatsFilter
+	for name, dst := range map[string]**time.Time{"from": &f.From, "to": &f.To} {
+		if v := c.Query(name); v != "" {
+			t, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				return f, fmt.Errorf("invalid %s: use YYYY-MM-DD", name)
+			}
+			*dst = &t
+		}
+	}
+	if v := c.Query("userId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return f, errors.New("invalid userId")
+		}
+		f.UserID = &id
+	}
+	return f, nil
+}
+
+// ListRevisions — история правок записи, новые сверху
+func (h *Handler) ListRevisions(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	list, err := h.service.ListRevisions(c.Request.Context(), workspaceID, c.Param("entryId"))
+	if err != nil {
+		h.responder.InternalServerErrorWithDetails(c, "Failed to list revisions", err)
+		return
+	}
+	if list == nil {
+		h.responder.NotFound(c, "Entry not found")
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"revisions": list})
+}
+
+func (h *Handler) GetRevision(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision < 1 {
+		h.responder.BadRequest(c, "Invalid revision")
+		return
+	}
+	rev, err := h.service.GetRevision(c.Request.Context(), workspaceID, c.Param("entryId"), revision)
+	if err != nil {
+		h.revisionError(c, err)
+		return
+	}
+	h.responder.SuccessWithData(c, rev)
+}
+
+// DiffRevisions — разница между ревизиями. Query: from, to (номера ревизий); to по умолчанию — from+1.
+func (h *Handler) DiffRevisions(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from < 1 {
+		h.responder.BadRequest(c, "Invalid from revision")
+		return
+	}
+	to := from + 1
+	if v := c.Query("to"); v != "" {
+		if to, err = strconv.Atoi(v); err != nil || to < 1 {
+			h.responder.BadRequest(c, "Invalid to revision")
+			return
+		}
+	}
+	diff, err := h.service.DiffRevisions(c.Request.Context(), workspaceID, c.Param("entryId"), from, to)
+	if err != nil {
+		h.revisionError(c, err)
+		return
+	}
+	h.responder.SuccessWithData(c, diff)
+}
+
+// RestoreRevision возвращает запись к состоянию ревизии (новой ревизией)
+func (h *Handler) RestoreRevision(c *gin.Context) {
+	workspaceID, userID, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision < 1 {
+		h.responder.BadRequest(c, "Invalid revision")
+		return
+	}
+	entry, err := h.service.RestoreRevision(c.Request.Context(), workspaceID, c.Param("entryId"), userID, revision)
+	if err != nil {
+		h.revisionError(c, err)
+		return
+	}
+	if entry == nil {
+		h.responder.NotFound(c, "Entry not found")
+		return
+	}
+	h.responder.SuccessWithData(c, entry)
+}
+
+func (h *Handler) revisionError(c *gin.Context, err error) {
+	if errors.Is(err, journalService.ErrRevisionNotFound) {
+		h.responder.NotFound(c, "Revision not found")
+		return
+	}
+	h.responder.InternalServerErrorWithDetails(c, "Failed to process revision", err)
+}
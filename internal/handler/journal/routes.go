@@ -6,4 +6,13 @@ const (
 	RouteGet    = "/:entryId"
 	RouteUpdate = "/:entryId"
 	RouteDelete = "/:entryId"
+
+	RouteTags       = "/tags"
+	RouteTagsRename = "/tags/rename"
+	RouteMood       = "/mood"
+
+	RouteRevisions       = "/:entryId/revisions"
+	RouteRevisionsDiff   = "/:entryId/revisions/diff"
+	RouteRevision        = "/:entryId/revisions/:revision"
+	RouteRevisionRestore = "/:entryId/revisions/:revision/restore"
 )
@@ -0,0 +1,149 @@
+package links
+
+import (
+	"database/sql"
+	"errors"
+
+	"backend/internal/middleware"
+	"backend/internal/model"
+	linksRepo "backend/internal/repository/links"
+	linksService "backend/internal/service/links"
+	workspaceService "backend/internal/service/workspace"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type Handler struct {
+	linksSvc     *linksService.Service
+	workspaceSvc *workspaceService.Service
+	responder    *response.Responder
+	validate     *validator.Validate
+}
+
+func NewHandler(
+	linksSvc *linksService.Service,
+	workspaceSvc *workspaceService.Service,
+	responder *response.Responder,
+	validate *validator.Validate,
+) *Handler {
+	return &Handler{
+		linksSvc:     linksSvc,
+		workspaceSvc: workspaceSvc,
+		responder:    responder,
+		validate:     validate,
+	}
+}
+
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	links := r.Group("/links")
+	{
+		links.POST(RouteCreate, h.Create)
+		links.DELETE(RouteDelete, h.Delete)
+		links.GET(RouteEntity, h.Links)
+		links.GET(RouteBacklinks, h.Backlinks)
+	}
+}
+
+func (h *Handler) requireWorkspaceAccess(c *gin.Context) (workspaceID, userID string, ok bool) {
+	userID, ok = middleware.GetUserIDFromGin(c)
+	if !ok {
+		h.responder.Unauthorized(c, "Authentication required")
+		return "", "", false
+	}
+	workspaceID = c.Param("workspaceId")
+	if workspaceID == "" {
+		h.responder.BadRequest(c, "Workspace ID required")
+		return "", "", false
+	}
+	roleVal, _ := c.Get(middleware.GinRoleKey)
+	role := model.UserRoleUser
+	if roleVal != nil {
+		role = roleVal.(model.UserRole)
+	}
+	hasAccess, err := h.workspaceSvc.HasAccess(c.Request.Context(), workspaceID, userID, role)
+	if err != nil || !hasAccess {
+		h.responder.Forbidden(c, "Access denied to this workspace")
+		return "", "", false
+	}
+	return workspaceID, userID, true
+}
+
+// Links — связи сущности в обе стороны: outgoing (на что ссылается) и backlinks (что ссылается на неё).
+// type: note, journal, habit, counterparty.
+func (h *Handler) Links(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	links, err := h.linksSvc.Links(c.Request.Context(), workspaceID, c.Param("type"), c.Param("id"))
+	if err != nil {
+		h.linkError(c, err, "Failed to list links")
+		return
+	}
+	h.responder.SuccessWithData(c, links)
+}
+
+func (h *Handler) Backlinks(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	list, err := h.linksSvc.Backlinks(c.Request.Context(), workspaceID, c.Param("type"), c.Param("id"))
+	if err != nil {
+		h.linkError(c, err, "Failed to list backlinks")
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"backlinks": list})
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	workspaceID, userID, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	var req model.CreateLinkDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.responder.BadRequest(c, "Invalid request")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	link, err := h.linksSvc.Create(c.Request.Context(), workspaceID, userID, req)
+	if err != nil {
+		h.linkError(c, err, "Failed to create link")
+		return
+	}
+	h.responder.Created(c, "Link created", link)
+}
+
+// Delete удаляет ручную связь; wiki-связь убирается только правкой текста (409)
+func (h *Handler) Delete(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	if err := h.linksSvc.Delete(c.Request.Context(), workspaceID, c.Param("linkId")); err != nil {
+		h.linkError(c, err, "Failed to delete link")
+		return
+	}
+	h.responder.SuccessWithMessage(c, "Link deleted")
+}
+
+func (h *Handler) linkError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		h.responder.NotFound(c, "Not found")
+	case errors.Is(err, linksService.ErrEntityNotFound):
+		h.responder.NotFound(c, err.Error())
+	case errors.Is(err, linksService.ErrUnknownType), errors.Is(err, linksService.ErrSelfLink):
+		h.responder.BadRequest(c, err.Error())
+	case errors.Is(err, linksService.ErrWikiLink), errors.Is(err, linksRepo.ErrLinkExists):
+		h.responder.Conflict(c, err.Error())
+	default:
+		h.responder.InternalServerError(c, msg)
+	}
+}
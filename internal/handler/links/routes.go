@@ -0,0 +1,9 @@
+package links
+
+// Связи между сущностями (под /workspaces/:workspaceId/links)
+const (
+	RouteCreate    = ""
+	RouteDelete    = "/:linkId"
+	RouteEntity    = "/:type/:id"
+	RouteBacklinks = "/:type/:id/backlinks"
+)
@@ -1,13 +1,25 @@
 package logger
 
 import (
+	"backend/internal/model"
 	"backend/internal/service/logger"
 	"backend/pkg/response"
 	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultListLimit  = 100
+	maxListLimit      = 1000
+	defaultStatsLimit = 20
+	defaultStatsRange = 24 * time.Hour
 )
 
 type Handler struct {
@@ -27,51 +39,243 @@ func NewHandler(
 		validate:  validate,
 	}
 }
+
+// RegisterRoutes регистрирует маршруты логов. Группа должна быть закрыта RequireAdmin.
 func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
-	r.GET("", h.GetLogs)
-	r.POST("/sync", h.SyncToDB)
+	r.GET(RouteList, h.GetLogs)
+	r.POST(RouteSync, h.SyncToDB)
+	r.GET(RouteStatsLatency, h.GetLatencyStats)
+	r.GET(RouteStatsErrors, h.GetErrorStats)
+	r.GET(RouteStatsClients, h.GetClientStats)
+	r.GET(RouteStatsDaily, h.GetDailyStats)
+	r.POST(RouteRetention, h.RunRetention)
 }
 
+// GetLogs возвращает логи запросов из БД с фильтрами и пагинацией.
+// Query: date (YYYY-MM-DD, сутки целиком), from, to, status (2xx/4xx/5xx), method, path_prefix,
+// client_ip, user_id, limit, offset.
 func (h *Handler) GetLogs(c *gin.Context) {
-	// Получаем дату из query параметра
-	dateStr := c.Query("date")
-	fmt.Println("123", dateStr)
-
-	var date time.Time
-	var err error
-
-	if dateStr == "" {
-		// По умолчанию вчерашний день
-		date = time.Now().AddDate(0, 0, -1)
-	} else {
-		date, err = time.Parse("2006-01-02", dateStr)
-		if err != nil {
-			h.responder.BadRequest(c, "invalid date format, use YYYY-MM-DD")
-			return
-		}
+	f, err := parseLogFilter(c, defaultListLimit)
+	if err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+
+	offset, err := parseNonNegative(c.Query("offset"))
+	if err != nil {
+		h.responder.BadRequest(c, "invalid offset")
+		return
 	}
+	f.Offset = offset
 
-	// Получаем логи из БД
-	logs, err := h.service.GetLogsByDate(c.Request.Context(), date)
+	logs, total, err := h.service.List(c.Request.Context(), f)
 	if err != nil {
 		h.responder.InternalServerErrorWithDetails(c, "failed to get logs", err)
 		return
 	}
 
 	h.responder.SuccessWithData(c, gin.H{
-		"date":  date.Format("2006-01-02"),
-		"count": len(logs),
-		"logs":  logs,
+		"count":  len(logs),
+		"total":  total,
+		"limit":  f.Limit,
+		"offset": f.Offset,
+		"logs":   logs,
 	})
 }
 
-// SyncToDB синхронизирует вчерашние логи в БД вручную
+// GetLatencyStats — p50/p95/p99 по маршрутам (по умолчанию за последние 24 часа)
+func (h *Handler) GetLatencyStats(c *gin.Context) {
+	f, ok := h.statsFilter(c)
+	if !ok {
+		return
+	}
+	stats, err := h.service.LatencyByRoute(c.Request.Context(), f)
+	if err != nil {
+		h.responder.InternalServerErrorWithDetails(c, "failed to get latency stats", err)
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"from": f.From, "to": f.To, "routes": stats})
+}
+
+// GetErrorStats — доля 4xx/5xx по маршрутам (по умолчанию за последние 24 часа)
+func (h *Handler) GetErrorStats(c *gin.Context) {
+	f, ok := h.statsFilter(c)
+	if !ok {
+		return
+	}
+	stats, err := h.service.ErrorsByRoute(c.Request.Context(), f)
+	if err != nil {
+		h.responder.InternalServerErrorWithDetails(c, "failed to get error stats", err)
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"from": f.From, "to": f.To, "routes": stats})
+}
+
+// GetClientStats — самые активные клиенты (по умолчанию за последние 24 часа)
+func (h *Handler) GetClientStats(c *gin.Context) {
+	f, ok := h.statsFilter(c)
+	if !ok {
+		return
+	}
+	stats, err := h.service.TopClients(c.Request.Context(), f)
+	if err != nil {
+		h.responder.InternalServerErrorWithDetails(c, "failed to get client stats", err)
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"from": f.From, "to": f.To, "clients": stats})
+}
+
+// GetDailyStats — дневные агрегаты по маршрутам (свёрнутые ретеншном дни)
+func (h *Handler) GetDailyStats(c *gin.Context) {
+	f, err := parseLogFilter(c, defaultListLimit)
+	if err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	offset, err := parseNonNegative(c.Query("offset"))
+	if err != nil {
+		h.responder.BadRequest(c, "invalid offset")
+		return
+	}
+	f.Offset = offset
+
+	stats, err := h.service.DailyStats(c.Request.Context(), f)
+	if err != nil {
+		h.responder.InternalServerErrorWithDetails(c, "failed to get daily stats", err)
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"days": stats})
+}
+
+// RunRetention запускает ретеншн сейчас (то же, что делает воркер LogRetention)
+func (h *Handler) RunRetention(c *gin.Context) {
+	res, err := h.service.ApplyRetention(c.Request.Context())
+	if err != nil {
+		h.responder.InternalServerErrorWithDetails(c, "failed to apply log retention", err)
+		return
+	}
+
+	h.responder.Success(c, http.StatusOK, "log retention applied", res)
+}
+
+// SyncToDB догружает в БД новые строки из лог-файлов вручную (то же, что делает воркер)
 func (h *Handler) SyncToDB(c *gin.Context) {
-	err := h.service.SyncToDB()
+	res, err := h.service.Ingest(c.Request.Context())
 	if err != nil {
 		h.responder.InternalServerErrorWithDetails(c, "failed to sync logs", err)
 		return
 	}
 
-	h.responder.SuccessWithMessage(c, "logs synchronized successfully")
+	h.responder.Success(c, http.StatusOK, "logs synchronized successfully", res)
+}
+
+// statsFilter разбирает фильтр для агрегатов; без from/to берутся последние 24 часа
+func (h *Handler) statsFilter(c *gin.Context) (model.LogFilter, bool) {
+	f, err := parseLogFilter(c, defaultStatsLimit)
+	if err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return f, false
+	}
+	if f.From == nil && f.To == nil {
+		to := time.Now().UTC()
+		from := to.Add(-defaultStatsRange)
+		f.From, f.To = &from, &to
+	}
+	return f, true
+}
+
+func parseLogFilter(c *gin.Context, defaultLimit int) (model.LogFilter, error) {
+	f := model.LogFilter{
+		Method:     strings.ToUpper(strings.TrimSpace(c.Query("method"))),
+		PathPrefix: c.Query("path_prefix"),
+		ClientIP:   strings.TrimSpace(c.Query("client_ip")),
+		Limit:      defaultLimit,
+	}
+
+	if dateStr := c.Query("date"); dateStr != "" {
+		day, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			return f, fmt.Errorf("invalid date format, use YYYY-MM-DD")
+		}
+		next := day.AddDate(0, 0, 1)
+		f.From, f.To = &day, &next
+	}
+	if s := c.Query("from"); s != "" {
+		t, err := parseTimeParam(s, false)
+		if err != nil {
+			return f, fmt.Errorf("invalid from, use RFC3339 or YYYY-MM-DD")
+		}
+		f.From = &t
+	}
+	if s := c.Query("to"); s != "" {
+		t, err := parseTimeParam(s, true)
+		if err != nil {
+			return f, fmt.Errorf("invalid to, use RFC3339 or YYYY-MM-DD")
+		}
+		f.To = &t
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return f, fmt.Errorf("from must be before to")
+	}
+
+	if s := c.Query("status"); s != "" {
+		class, err := parseStatusClass(s)
+		if err != nil {
+			return f, err
+		}
+		f.StatusClass = class
+	}
+
+	if s := c.Query("user_id"); s != "" {
+		if _, err := uuid.Parse(s); err != nil {
+			return f, fmt.Errorf("invalid user_id")
+		}
+		f.UserID = s
+	}
+
+	if s := c.Query("limit"); s != "" {
+		limit, err := parseNonNegative(s)
+		if err != nil || limit == 0 {
+			return f, fmt.Errorf("invalid limit")
+		}
+		f.Limit = min(limit, maxListLimit)
+	}
+
+	return f, nil
+}
+
+// parseTimeParam принимает RFC3339 или YYYY-MM-DD. Дата в "to" означает конец этого дня.
+func parseTimeParam(s string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+// parseStatusClass принимает "4xx" или "4"
+func parseStatusClass(s string) (int, error) {
+	s = strings.TrimSuffix(strings.ToLower(s), "xx")
+	class, err := strconv.Atoi(s)
+	if err != nil || class < 1 || class > 5 {
+		return 0, fmt.Errorf("invalid status, use 1xx..5xx")
+	}
+	return class, nil
+}
+
+func parseNonNegative(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid number")
+	}
+	return n, nil
 }
@@ -0,0 +1,187 @@
+package logger_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	loggerRepo "backend/internal/repository/logger"
+	"backend/internal/router"
+	"backend/internal/testutil/pgtest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	pgtest.Main(m)
+}
+
+func TestLogsAPI(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	r := router.New(env.Container.Responder)
+	env.Container.RegisterRoutes(r)
+
+	admin := env.CreateAdmin(t)
+	user := env.CreateUser(t)
+	tokenFor := func(u *model.User) string {
+		t.Helper()
+		tok, err := env.Container.TokenGen.Generate(u.ID, string(u.Role))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+	adminToken, userToken := tokenFor(admin), tokenFor(user)
+
+	day := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+	seq := 0
+	entry := func(at time.Duration, method, route, path string, status int, ms float64, ip, userID string) *model.LogEntry {
+		seq++
+		raw := fmt.Sprintf("line %d", seq)
+		return &model.LogEntry{
+			Timestamp:   day.Add(at),
+			StatusCode:  status,
+			Duration:    time.Duration(ms * float64(time.Millisecond)),
+			ClientIP:    ip,
+			Method:      method,
+			Path:        path,
+			Route:       route,
+			UserID:      userID,
+			RawLog:      raw,
+			ContentHash: fmt.Sprintf("%x", sha256.Sum256([]byte(raw))),
+		}
+	}
+	const notes, habits = "/api/v1/workspaces/:workspaceId/notes", "/api/v1/workspaces/:workspaceId/habits"
+	batch := []*model.LogEntry{
+		entry(time.Hour, "GET", notes, "/api/v1/workspaces/w1/notes", 200, 10, "10.0.0.1", user.ID),
+		entry(2*time.Hour, "GET", notes, "/api/v1/workspaces/w1/notes", 200, 20, "10.0.0.1", user.ID),
+		entry(3*time.Hour, "GET", notes, "/api/v1/workspaces/w1/notes", 500, 30, "10.0.0.1", ""),
+		entry(4*time.Hour, "POST", habits, "/api/v1/workspaces/w1/habits", 404, 100, "10.0.0.2", admin.ID),
+		// Следующий день — вне диапазона date=2026-10-10
+		entry(25*time.Hour, "GET", notes, "/api/v1/workspaces/w1/notes", 200, 5, "10.0.0.3", ""),
+	}
+	if _, err := loggerRepo.NewRepository(env.DB).InsertBatch(ctx, "requests-2026-10-10.log", batch, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(token, target string, out interface{}) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		r.Handler().ServeHTTP(rec, req)
+		if out != nil && rec.Code == http.StatusOK {
+			body := struct {
+				Data json.RawMessage `json:"data"`
+			}{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("GET %s: %v\n%s", target, err, rec.Body)
+			}
+			if err := json.Unmarshal(body.Data, out); err != nil {
+				t.Fatalf("GET %s: %v\n%s", target, err, body.Data)
+			}
+		}
+		return rec.Code
+	}
+
+	// Логи доступны только администраторам
+	if code := get("", "/api/v1/logs", nil); code != http.StatusUnauthorized {
+		t.Fatalf("anonymous: %d", code)
+	}
+	for _, path := range []string{"/api/v1/logs", "/api/v1/logs/stats/latency", "/api/v1/logs/stats/errors", "/api/v1/logs/stats/clients"} {
+		if code := get(userToken, path, nil); code != http.StatusForbidden {
+			t.Fatalf("user %s: %d", path, code)
+		}
+	}
+
+	type page struct {
+		Count int               `json:"count"`
+		Total int64             `json:"total"`
+		Logs  []*model.LogEntry `json:"logs"`
+	}
+	list := func(query string) page {
+		t.Helper()
+		var p page
+		if code := get(adminToken, "/api/v1/logs?"+query, &p); code != http.StatusOK {
+			t.Fatalf("list %q: %d", query, code)
+		}
+		return p
+	}
+
+	cases := []struct {
+		query string
+		total int64
+	}{
+		{"", 5},
+		{"date=2026-10-10", 4},
+		{"from=2026-10-10T02:00:00Z&to=2026-10-10T04:00:00Z", 2},
+		{"date=2026-10-10&status=5xx", 1},
+		{"date=2026-10-10&status=4", 1},
+		{"method=post", 1},
+		{"path_prefix=/api/v1/workspaces/w1/n", 4},
+		{"path_prefix=%25", 0}, // % — не шаблон LIKE
+		{"client_ip=10.0.0.2", 1},
+		{"user_id=" + user.ID, 2},
+	}
+	for _, tc := range cases {
+		if p := list(tc.query); p.Total != tc.total {
+			t.Errorf("list %q: total = %d, want %d", tc.query, p.Total, tc.total)
+		}
+	}
+
+	// Пагинация: новые сверху
+	p := list("date=2026-10-10&limit=2&offset=1")
+	if p.Count != 2 || p.Total != 4 || p.Logs[0].StatusCode != 500 || p.Logs[1].Duration != 20*time.Millisecond {
+		t.Fatalf("page = %+v", p)
+	}
+
+	for _, q := range []string{"status=6xx", "date=10.10.2026", "from=2026-10-11&to=2026-10-10", "user_id=42", "limit=0", "offset=-1"} {
+		if code := get(adminToken, "/api/v1/logs?"+q, nil); code != http.StatusBadRequest {
+			t.Errorf("list %q: %d, want 400", q, code)
+		}
+	}
+
+	// Агрегаты за сутки
+	var latency struct {
+		Routes []model.RouteLatencyStats `json:"routes"`
+	}
+	get(adminToken, "/api/v1/logs/stats/latency?date=2026-10-10", &latency)
+	if len(latency.Routes) != 2 || latency.Routes[0].Route != habits {
+		t.Fatalf("latency = %+v", latency.Routes)
+	}
+	if st := latency.Routes[1]; st.Route != notes || st.Count != 3 || st.P50Ms != 20 || st.AvgMs != 20 {
+		t.Fatalf("notes latency = %+v", st)
+	}
+
+	var errs struct {
+		Routes []model.RouteErrorStats `json:"routes"`
+	}
+	get(adminToken, "/api/v1/logs/stats/errors?date=2026-10-10", &errs)
+	if len(errs.Routes) != 2 || errs.Routes[0].Route != habits || errs.Routes[0].ErrorRate != 1 {
+		t.Fatalf("errors = %+v", errs.Routes)
+	}
+	if st := errs.Routes[1]; st.Total != 3 || st.ServerErrors != 1 || st.ServerErrorRate != 1.0/3 {
+		t.Fatalf("notes errors = %+v", st)
+	}
+
+	var clients struct {
+		Clients []model.ClientStats `json:"clients"`
+	}
+	get(adminToken, "/api/v1/logs/stats/clients?date=2026-10-10&limit=1", &clients)
+	if len(clients.Clients) != 1 {
+		t.Fatalf("clients = %+v", clients.Clients)
+	}
+	if c := clients.Clients[0]; c.ClientIP != "10.0.0.1" || c.Requests != 3 || c.Errors != 1 || c.UniqueUsers != 1 {
+		t.Fatalf("top client = %+v", c)
+	}
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"backend/internal/model"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func filterFor(query string) (model.LogFilter, error) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/api/v1/logs?"+query, nil)
+	return parseLogFilter(c, defaultListLimit)
+}
+
+func day(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func TestParseLogFilter(t *testing.T) {
+	f, err := filterFor("date=2026-10-10&status=5xx&method=post")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.From.Equal(day("2026-10-10")) || !f.To.Equal(day("2026-10-11")) || f.StatusClass != 5 || f.Method != "POST" || f.Limit != defaultListLimit {
+		t.Fatalf("filter = %+v", f)
+	}
+
+	// Дата в to — конец этого дня; from/to перекрывают date
+	f, err = filterFor("date=2026-10-01&from=2026-10-10&to=2026-10-12&status=4&limit=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.From.Equal(day("2026-10-10")) || !f.To.Equal(day("2026-10-13")) || f.StatusClass != 4 || f.Limit != maxListLimit {
+		t.Fatalf("filter = %+v", f)
+	}
+
+	for _, q := range []string{
+		"date=10.10.2026",
+		"from=yesterday",
+		"from=2026-10-12&to=2026-10-10",
+		"from=2026-10-10T10:00:00Z&to=2026-10-10T10:00:00Z",
+		"status=6xx",
+		"status=0",
+		"status=abc",
+		"user_id=42",
+		"limit=0",
+		"limit=-1",
+	} {
+		if _, err := filterFor(q); err == nil {
+			t.Errorf("parseLogFilter(%q): no error", q)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package logger
+
+const (
+	RouteList         = ""
+	RouteSync         = "/sync"
+	RouteStatsLatency = "/stats/latency"
+	RouteStatsErrors  = "/stats/errors"
+	RouteStatsClients = "/stats/clients"
+	RouteStatsDaily   = "/stats/daily"
+	RouteRetention    = "/retention"
+)
@@ -6,8 +6,10 @@ import (
 
 	"backend/internal/middleware"
 	"backend/internal/model"
+	masterRepo "backend/internal/repository/master"
 	masterService "backend/internal/service/master"
 	workspaceService "backend/internal/service/workspace"
+	"backend/pkg/query"
 	"backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -84,12 +86,17 @@ func (h *Handler) ListCurrencies(c *gin.Context) {
 	if !ok {
 		return
 	}
-	list, err := h.masterSvc.ListCurrencies(c.Request.Context(), workspaceID)
+	spec, err := query.Parse(c.Request.URL.Query(), masterRepo.CurrencySchema)
+	if err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	list, page, err := h.masterSvc.ListCurrencies(c.Request.Context(), workspaceID, spec)
 	if err != nil {
 		h.responder.InternalServerError(c, "Failed to list currencies")
 		return
 	}
-	h.responder.SuccessWithData(c, gin.H{"currencies": list})
+	h.responder.SuccessWithPage(c, gin.H{"currencies": list}, page)
 }
 
 func (h *Handler) GetCurrency(c *gin.Context) {
@@ -189,12 +196,17 @@ func (h *Handler) ListCounterparties(c *gin.Context) {
 	if !ok {
 		return
 	}
-	list, err := h.masterSvc.ListCounterparties(c.Request.Context(), workspaceID)
+	spec, err := query.Parse(c.Request.URL.Query(), masterRepo.CounterpartySchema)
+	if err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	list, page, err := h.masterSvc.ListCounterparties(c.Request.Context(), workspaceID, spec)
 	if err != nil {
 		h.responder.InternalServerError(c, "Failed to list counterparties")
 		return
 	}
-	h.responder.SuccessWithData(c, gin.H{"counterparties": list})
+	h.responder.SuccessWithPage(c, gin.H{"counterparties": list}, page)
 }
 
 func (h *Handler) GetCounterparty(c *gin.Context) {
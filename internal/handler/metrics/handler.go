@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"backend/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RouteMetrics = "/metrics"
+
+// Register публикует /metrics (формат Prometheus). Если заданы user и password — под basic auth, как Swagger.
+func Register(r *gin.Engine, reg *metrics.Registry, expose bool, user, password string) {
+	if !expose {
+		return
+	}
+
+	handler := gin.WrapH(reg.Handler())
+	if user != "" && password != "" {
+		r.GET(RouteMetrics, gin.BasicAuth(gin.Accounts{user: password}), handler)
+		return
+	}
+	r.GET(RouteMetrics, handler)
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegister(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name           string
+		expose         bool
+		user, password string
+		auth           func(r *http.Request)
+		want           int
+	}{
+		{name: "disabled", want: http.StatusNotFound},
+		{name: "open", expose: true, want: http.StatusOK},
+		{name: "no credentials", expose: true, user: "prom", password: "secret", want: http.StatusUnauthorized},
+		{name: "wrong password", expose: true, user: "prom", password: "secret",
+			auth: func(r *http.Request) { r.SetBasicAuth("prom", "guess") }, want: http.StatusUnauthorized},
+		{name: "basic auth", expose: true, user: "prom", password: "secret",
+			auth: func(r *http.Request) { r.SetBasicAuth("prom", "secret") }, want: http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			Register(r, metrics.NewRegistry(), tc.expose, tc.user, tc.password)
+			req := httptest.NewRequest(http.MethodGet, RouteMetrics, nil)
+			if tc.auth != nil {
+				tc.auth(req)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.want)
+			}
+			if rec.Code == http.StatusOK && rec.Header().Get("Content-Type") != metrics.ContentType {
+				t.Fatalf("Content-Type = %q", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -1,13 +1,16 @@
 package notes
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 
 	"backend/internal/middleware"
 	"backend/internal/model"
+	notesRepo "backend/internal/repository/notes"
 	notesService "backend/internal/service/notes"
 	workspaceService "backend/internal/service/workspace"
+	"backend/pkg/query"
 	"backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -36,11 +39,31 @@ func NewHandler(
 }
 
 func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
-	r.GET("/notes", h.List)
-	r.POST("/notes", h.Create)
-	r.GET("/notes/:noteId", h.Get)
-	r.PUT("/notes/:noteId", h.Update)
-	r.DELETE("/notes/:noteId", h.Delete)
+	notes := r.Group("/notes")
+	{
+		notes.GET(RouteList, h.List)
+		notes.POST(RouteCreate, h.Create)
+		notes.GET(RouteGet, h.Get)
+		notes.PUT(RouteUpdate, h.Update)
+		notes.DELETE(RouteDelete, h.Delete)
+		notes.POST(RoutePin, h.Pin)
+		notes.POST(RouteUnpin, h.Unpin)
+		notes.POST(RouteArchive, h.Archive)
+		notes.POST(RouteUnarchive, h.Unarchive)
+		notes.POST(RouteMove, h.Move)
+		notes.GET(RouteFolders, h.ListFolders)
+		notes.POST(RouteFolders, h.CreateFolder)
+		notes.PUT(RouteFolderRename, h.RenameFolder)
+		notes.POST(RouteFolderMove, h.MoveFolder)
+		notes.DELETE(RouteFolderDelete, h.DeleteFolder)
+		notes.GET(RouteShares, h.ListShares)
+		notes.POST(RouteShares, h.Share)
+		notes.DELETE(RouteShare, h.Unshare)
+		notes.GET(RouteLinks, h.ListLinks)
+		notes.POST(RouteLinks, h.CreateLink)
+		notes.DELETE(RouteLink, h.RevokeLink)
+		notes.GET(RouteLinkLog, h.LinkAccessLog)
+	}
 }
 
 func (h *Handler) requireWorkspaceAccess(c *gin.Context) (workspaceID, userID string, ok bool) {
@@ -72,12 +95,17 @@ func (h *Handler) List(c *gin.Context) {
 	if !ok {
 		return
 	}
-	list, err := h.notesSvc.List(c.Request.Context(), workspaceID)
+	spec, err := query.Parse(c.Request.URL.Query(), notesRepo.ListSchema)
+	if err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	list, page, err := h.notesSvc.List(c.Request.Context(), workspaceID, spec)
 	if err != nil {
 		h.responder.InternalServerError(c, "Failed to list notes")
 		return
 	}
-	h.responder.SuccessWithData(c, gin.H{"notes": list})
+	h.responder.SuccessWithPage(c, gin.H{"notes": list}, page)
 }
 
 func (h *Handler) Get(c *gin.Context) {
@@ -103,22 +131,18 @@ func (h *Handler) Create(c *gin.Context) {
 	if !ok {
 		return
 	}
-	var req struct {
-		Title   string `json:"title" binding:"required"`
-		Content string `json:"content"`
-	}
+	var req model.CreateNoteDto
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.responder.BadRequest(c, "title is required")
+		h.responder.BadRequest(c, "Invalid request")
 		return
 	}
-	n := &model.Note{
-		WorkspaceID: workspaceID,
-		UserID:      userID,
-		Title:       req.Title,
-		Content:     req.Content,
+	if err := h.validate.Struct(req); err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
 	}
-	if err := h.notesSvc.Create(c.Request.Context(), n); err != nil {
-		h.responder.InternalServerError(c, "Failed to create note")
+	n, err := h.notesSvc.Create(c.Request.Context(), workspaceID, userID, req)
+	if err != nil {
+		h.noteError(c, err, "Failed to create note")
 		return
 	}
 	h.responder.SuccessWithData(c, n)
@@ -129,27 +153,18 @@ func (h *Handler) Update(c *gin.Context) {
 	if !ok {
 		return
 	}
-	noteID := c.Param("noteId")
-	var req struct {
-		Title   string `json:"title" binding:"required"`
-		Content string `json:"content"`
-	}
+	var req model.UpdateNoteDto
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.responder.BadRequest(c, "title is required")
+		h.responder.BadRequest(c, "Invalid request")
 		return
 	}
-	n := &model.Note{
-		ID:          noteID,
-		WorkspaceID: workspaceID,
-		Title:       req.Title,
-		Content:     req.Content,
+	if err := h.validate.Struct(req); err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
 	}
-	if err := h.notesSvc.Update(c.Request.Context(), n); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			h.responder.NotFound(c, "Note not found")
-			return
-		}
-		h.responder.InternalServerError(c, "Failed to update note")
+	n, err := h.notesSvc.Update(c.Request.Context(), workspaceID, c.Param("noteId"), req)
+	if err != nil {
+		h.noteError(c, err, "Failed to update note")
 		return
 	}
 	h.responder.SuccessWithData(c, n)
@@ -162,12 +177,163 @@ func (h *Handler) Delete(c *gin.Context) {
 	}
 	noteID := c.Param("noteId")
 	if err := h.notesSvc.Delete(c.Request.Context(), workspaceID, noteID); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			h.responder.NotFound(c, "Note not found")
-			return
-		}
-		h.responder.InternalServerError(c, "Failed to delete note")
+		h.noteError(c, err, "Failed to delete note")
 		return
 	}
 	h.responder.SuccessWithMessage(c, "Note deleted")
 }
+
+func (h *Handler) Pin(c *gin.Context)       { h.setFlag(c, h.notesSvc.SetPinned, true) }
+func (h *Handler) Unpin(c *gin.Context)     { h.setFlag(c, h.notesSvc.SetPinned, false) }
+func (h *Handler) Archive(c *gin.Context)   { h.setFlag(c, h.notesSvc.SetArchived, true) }
+func (h *Handler) Unarchive(c *gin.Context) { h.setFlag(c, h.notesSvc.SetArchived, false) }
+
+func (h *Handler) setFlag(c *gin.Context, set func(ctx context.Context, workspaceID, id string, value bool) (*model.Note, error), value bool) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	n, err := set(c.Request.Context(), workspaceID, c.Param("noteId"), value)
+	if err != nil {
+		h.noteError(c, err, "Failed to update note")
+		return
+	}
+	h.responder.SuccessWithData(c, n)
+}
+
+// Move переносит заметку в другую папку и/или меняет её место. Body: {"folderId": null|uuid, "position": 0}
+func (h *Handler) Move(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	var req model.MoveNoteDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.responder.BadRequest(c, "Invalid request")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	n, err := h.notesSvc.Move(c.Request.Context(), workspaceID, c.Param("noteId"), req)
+	if err != nil {
+		h.noteError(c, err, "Failed to move note")
+		return
+	}
+	h.responder.SuccessWithData(c, n)
+}
+
+func (h *Handler) ListFolders(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	list, err := h.notesSvc.ListFolders(c.Request.Context(), workspaceID)
+	if err != nil {
+		h.responder.InternalServerError(c, "Failed to list folders")
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"folders": list})
+}
+
+func (h *Handler) CreateFolder(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	var req model.CreateNoteFolderDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.responder.BadRequest(c, "Invalid request")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	f, err := h.notesSvc.CreateFolder(c.Request.Context(), workspaceID, req)
+	if err != nil {
+		h.noteError(c, err, "Failed to create folder")
+		return
+	}
+	h.responder.Created(c, "Folder created", f)
+}
+
+func (h *Handler) RenameFolder(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	var req model.RenameNoteFolderDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.responder.BadRequest(c, "Invalid request")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	f, err := h.notesSvc.RenameFolder(c.Request.Context(), workspaceID, c.Param("folderId"), req)
+	if err != nil {
+		h.folderError(c, err, "Failed to rename folder")
+		return
+	}
+	h.responder.SuccessWithData(c, f)
+}
+
+// MoveFolder переносит папку вместе с содержимым. Body: {"parentId": null|uuid, "position": 0}
+func (h *Handler) MoveFolder(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	var req model.MoveNoteFolderDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.responder.BadRequest(c, "Invalid request")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	f, err := h.notesSvc.MoveFolder(c.Request.Context(), workspaceID, c.Param("folderId"), req)
+	if err != nil {
+		h.folderError(c, err, "Failed to move folder")
+		return
+	}
+	h.responder.SuccessWithData(c, f)
+}
+
+// DeleteFolder удаляет только пустую папку: без подпапок и заметок, включая архивные
+func (h *Handler) DeleteFolder(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	if err := h.notesSvc.DeleteFolder(c.Request.Context(), workspaceID, c.Param("folderId")); err != nil {
+		h.folderError(c, err, "Failed to delete folder")
+		return
+	}
+	h.responder.SuccessWithMessage(c, "Folder deleted")
+}
+
+func (h *Handler) noteError(c *gin.Context, err error, msg string) {
+	if errors.Is(err, sql.ErrNoRows) {
+		h.responder.NotFound(c, "Note not found")
+		return
+	}
+	h.folderError(c, err, msg)
+}
+
+func (h *Handler) folderError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, notesRepo.ErrFolderNotFound):
+		h.responder.NotFound(c, "Folder not found")
+	case errors.Is(err, notesRepo.ErrFolderCycle), errors.Is(err, notesRepo.ErrFolderTooDeep):
+		h.responder.BadRequest(c, err.Error())
+	case errors.Is(err, notesRepo.ErrFolderNotEmpty):
+		h.responder.Conflict(c, err.Error())
+	default:
+		h.responder.InternalServerError(c, msg)
+	}
+}
@@ -0,0 +1,42 @@
+package notes
+
+const (
+	RouteList   = ""
+	RouteCreate = ""
+	RouteGet    = "/:noteId"
+	RouteUpdate = "/:noteId"
+	RouteDelete = "/:noteId"
+
+	RoutePin       = "/:noteId/pin"
+	RouteUnpin     = "/:noteId/unpin"
+	RouteArchive   = "/:noteId/archive"
+	RouteUnarchive = "/:noteId/unarchive"
+	RouteMove      = "/:noteId/move"
+
+	RouteFolders      = "/folders"
+	RouteFolderRename = "/folders/:folderId"
+	RouteFolderMove   = "/folders/:folderId/move"
+	RouteFolderDelete = "/folders/:folderId"
+)
+
+// Доступ к заметке вне воркспейса (под /workspaces/:workspaceId/notes)
+const (
+	RouteShares  = "/:noteId/shares"
+	RouteShare   = "/:noteId/shares/:shareId"
+	RouteLinks   = "/:noteId/links"
+	RouteLink    = "/:noteId/links/:linkId"
+	RouteLinkLog = "/:noteId/links/:linkId/access-log"
+)
+
+// Заметки, открытые текущему пользователю (под /shared/notes)
+const (
+	RouteSharedList   = ""
+	RouteSharedGet    = "/:noteId"
+	RouteSharedUpdate = "/:noteId"
+)
+
+// Публичные ссылки без авторизации (под /public/notes)
+const RoutePublicNote = "/:token"
+
+// LinkPasswordHeader — пароль публичной ссылки передаётся заголовком, чтобы не попадать в URL и логи
+const LinkPasswordHeader = "X-Link-Password"
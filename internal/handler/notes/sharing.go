@@ -0,0 +1,236 @@
+package notes
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"backend/internal/middleware"
+	"backend/internal/model"
+	notesRepo "backend/internal/repository/notes"
+	notesService "backend/internal/service/notes"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterSharedRoutes — заметки, открытые текущему пользователю из чужих воркспейсов (группа /shared/notes под авторизацией)
+func (h *Handler) RegisterSharedRoutes(r *gin.RouterGroup) {
+	r.GET(RouteSharedList, h.ListSharedWithMe)
+	r.GET(RouteSharedGet, h.GetSharedWithMe)
+	r.PUT(RouteSharedUpdate, h.UpdateSharedWithMe)
+}
+
+// RegisterPublicRoutes — публичные ссылки (группа /public/notes без GinAuthMiddleware)
+func (h *Handler) RegisterPublicRoutes(r *gin.RouterGroup) {
+	r.GET(RoutePublicNote, h.OpenLink)
+}
+
+func (h *Handler) ListShares(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	list, err := h.notesSvc.ListShares(c.Request.Context(), workspaceID, c.Param("noteId"))
+	if err != nil {
+		h.noteError(c, err, "Failed to list shares")
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"shares": list})
+}
+
+// Share открывает заметку пользователю по email или меняет его права. Body: {"email": "...", "permission": "read|edit"}
+func (h *Handler) Share(c *gin.Context) {
+	workspaceID, userID, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	var req model.ShareNoteDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.responder.BadRequest(c, "Invalid request")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	share, err := h.notesSvc.Share(c.Request.Context(), workspaceID, c.Param("noteId"), userID, req)
+	if err != nil {
+		if errors.Is(err, notesRepo.ErrShareUserNotFound) {
+			h.responder.NotFound(c, "User not found")
+			return
+		}
+		h.noteError(c, err, "Failed to share note")
+		return
+	}
+	h.responder.SuccessWithData(c, share)
+}
+
+func (h *Handler) Unshare(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	if err := h.notesSvc.Unshare(c.Request.Context(), workspaceID, c.Param("noteId"), c.Param("shareId")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.responder.NotFound(c, "Share not found")
+			return
+		}
+		h.responder.InternalServerError(c, "Failed to remove share")
+		return
+	}
+	h.responder.SuccessWithMessage(c, "Share removed")
+}
+
+func (h *Handler) ListLinks(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	list, err := h.notesSvc.ListLinks(c.Request.Context(), workspaceID, c.Param("noteId"))
+	if err != nil {
+		h.noteError(c, err, "Failed to list links")
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"links": list})
+}
+
+// CreateLink создаёт публичную ссылку. Body: {"expiresAt": "RFC3339", "password": "..."} — оба поля необязательны.
+// token в ответе показывается один раз.
+func (h *Handler) CreateLink(c *gin.Context) {
+	workspaceID, userID, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	var req model.CreateNoteLinkDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.responder.BadRequest(c, "Invalid request")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	link, err := h.notesSvc.CreateLink(c.Request.Context(), workspaceID, c.Param("noteId"), userID, req)
+	if err != nil {
+		if errors.Is(err, notesService.ErrInvalidExpiry) {
+			h.responder.BadRequest(c, err.Error())
+			return
+		}
+		h.noteError(c, err, "Failed to create link")
+		return
+	}
+	h.responder.Created(c, "Link created", link)
+}
+
+func (h *Handler) RevokeLink(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	if err := h.notesSvc.RevokeLink(c.Request.Context(), workspaceID, c.Param("noteId"), c.Param("linkId")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.responder.NotFound(c, "Link not found")
+			return
+		}
+		h.responder.InternalServerError(c, "Failed to revoke link")
+		return
+	}
+	h.responder.SuccessWithMessage(c, "Link revoked")
+}
+
+func (h *Handler) LinkAccessLog(c *gin.Context) {
+	workspaceID, _, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	list, err := h.notesSvc.LinkAccessLog(c.Request.Context(), workspaceID, c.Param("noteId"), c.Param("linkId"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.responder.NotFound(c, "Link not found")
+			return
+		}
+		h.responder.InternalServerError(c, "Failed to load access log")
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"accessLog": list})
+}
+
+func (h *Handler) ListSharedWithMe(c *gin.Context) {
+	userID, ok := middleware.GetUserIDFromGin(c)
+	if !ok {
+		h.responder.Unauthorized(c, "Authentication required")
+		return
+	}
+	list, err := h.notesSvc.ListSharedWithMe(c.Request.Context(), userID)
+	if err != nil {
+		h.responder.InternalServerError(c, "Failed to list shared notes")
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"notes": list})
+}
+
+func (h *Handler) GetSharedWithMe(c *gin.Context) {
+	userID, ok := middleware.GetUserIDFromGin(c)
+	if !ok {
+		h.responder.Unauthorized(c, "Authentication required")
+		return
+	}
+	n, err := h.notesSvc.GetSharedWithMe(c.Request.Context(), userID, c.Param("noteId"))
+	if err != nil {
+		h.responder.InternalServerError(c, "Failed to get note")
+		return
+	}
+	if n == nil {
+		h.responder.NotFound(c, "Note not found")
+		return
+	}
+	h.responder.SuccessWithData(c, n)
+}
+
+func (h *Handler) UpdateSharedWithMe(c *gin.Context) {
+	userID, ok := middleware.GetUserIDFromGin(c)
+	if !ok {
+		h.responder.Unauthorized(c, "Authentication required")
+		return
+	}
+	var req model.UpdateNoteDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.responder.BadRequest(c, "Invalid request")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	n, err := h.notesSvc.UpdateSharedWithMe(c.Request.Context(), userID, c.Param("noteId"), req)
+	if err != nil {
+		if errors.Is(err, notesService.ErrEditForbidden) {
+			h.responder.Forbidden(c, err.Error())
+			return
+		}
+		h.noteError(c, err, "Failed to update note")
+		return
+	}
+	h.responder.SuccessWithData(c, n)
+}
+
+// OpenLink — заметка по публичной ссылке, только чтение. Пароль — в заголовке X-Link-Password.
+func (h *Handler) OpenLink(c *gin.Context) {
+	n, err := h.notesSvc.OpenLink(c.Request.Context(), c.Param("token"), c.GetHeader(LinkPasswordHeader), c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		switch {
+		case errors.Is(err, notesService.ErrLinkNotFound):
+			h.responder.NotFound(c, err.Error())
+		case errors.Is(err, notesService.ErrLinkPasswordRequired), errors.Is(err, notesService.ErrLinkWrongPassword):
+			h.responder.Unauthorized(c, err.Error())
+		case errors.Is(err, notesService.ErrLinkLocked):
+			h.responder.WriteError(c, http.StatusTooManyRequests, err.Error())
+		default:
+			h.responder.InternalServerError(c, "Failed to open link")
+		}
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Robots-Tag", "noindex")
+	h.responder.SuccessWithData(c, n)
+}
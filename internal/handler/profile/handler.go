@@ -0,0 +1,247 @@
+package profile
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"backend/internal/middleware"
+	"backend/internal/model"
+	userRepo "backend/internal/repository/user"
+	profileService "backend/internal/service/profile"
+	"backend/pkg/http/cookies"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// multipartOverhead — запас на заголовки и границы multipart сверх размера картинки
+const multipartOverhead = 1 << 20
+
+type Handler struct {
+	service       *profileService.Service
+	cookieManager *cookies.Manager
+	responder     *response.Responder
+	validate      *validator.Validate
+	avatarMaxSize int64
+}
+
+func NewHandler(
+	service *profileService.Service,
+	cookieManager *cookies.Manager,
+	responder *response.Responder,
+	validate *validator.Validate,
+	avatarMaxSize int64,
+) *Handler {
+	return &Handler{
+		service:       service,
+		cookieManager: cookieManager,
+		responder:     responder,
+		validate:      validate,
+		avatarMaxSize: avatarMaxSize,
+	}
+}
+
+func (h *Handler) RegisterProtectedRoutes(r *gin.RouterGroup) {
+	r.PATCH(RouteProfile, h.UpdateProfile)
+	r.POST(RouteEmail, h.RequestEmailChange)
+	r.PUT(RouteAvatar, h.UploadAvatar)
+	r.DELETE(RouteDeleteAvatar, h.DeleteAvatar)
+	r.DELETE(RouteDeleteMe, h.DeleteAccount)
+}
+
+func (h *Handler) RegisterPublicRoutes(r *gin.RouterGroup) {
+	r.POST(RouteConfirmEmail, h.ConfirmEmailChange)
+}
+
+func (h *Handler) RegisterAvatarRoutes(r *gin.RouterGroup) {
+	r.GET(RouteAvatarImage, h.Avatar)
+}
+
+// UpdateProfile — PATCH /auth/me: имя, язык, часовой пояс
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	userID, ok := h.requireUser(c)
+	if !ok {
+		return
+	}
+	var dto model.UpdateProfileDto
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		h.responder.BadRequest(c, "Invalid request")
+		return
+	}
+	if err := h.validate.Struct(dto); err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	user, err := h.service.UpdateProfile(c.Request.Context(), userID, dto)
+	if err != nil {
+		h.profileError(c, err, "Failed to update profile")
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"user": user})
+}
+
+// RequestEmailChange — POST /auth/me/email: письмо со ссылкой подтверждения уходит на новый адрес
+func (h *Handler) RequestEmailChange(c *gin.Context) {
+	userID, ok := h.requireUser(c)
+	if !ok {
+		return
+	}
+	var req model.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.responder.BadRequest(c, "Invalid request")
+		return
+	}
+	req.Email = strings.TrimSpace(req.Email)
+	if err := h.validate.Struct(req); err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	change, err := h.service.RequestEmailChange(c.Request.Context(), userID, req)
+	if err != nil {
+		h.profileError(c, err, "Failed to request email change")
+		return
+	}
+	h.responder.Success(c, http.StatusAccepted, "Confirmation email sent", change)
+}
+
+// ConfirmEmailChange — POST /auth/email/confirm {"token": "..."}
+func (h *Handler) ConfirmEmailChange(c *gin.Context) {
+	var req model.ConfirmEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil || h.validate.Struct(req) != nil {
+		h.responder.BadRequest(c, "token is required")
+		return
+	}
+	user, err := h.service.ConfirmEmailChange(c.Request.Context(), req.Token)
+	if err != nil {
+		h.profileError(c, err, "Failed to confirm email change")
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"user": user})
+}
+
+// UploadAvatar — PUT /auth/me/avatar, multipart/form-data с картинкой в поле "avatar"
+func (h *Handler) UploadAvatar(c *gin.Context) {
+	userID, ok := h.requireUser(c)
+	if !ok {
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.avatarMaxSize+multipartOverhead)
+	fh, err := c.FormFile(AvatarField)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.responder.WriteError(c, http.StatusRequestEntityTooLarge, profileService.ErrAvatarTooLarge.Error())
+			return
+		}
+		h.responder.BadRequest(c, `multipart field "avatar" is required`)
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		h.responder.BadRequest(c, "Invalid file")
+		return
+	}
+	defer f.Close()
+
+	user, err := h.service.UploadAvatar(c.Request.Context(), userID, f)
+	if err != nil {
+		h.profileError(c, err, "Failed to upload avatar")
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"user": user})
+}
+
+func (h *Handler) DeleteAvatar(c *gin.Context) {
+	userID, ok := h.requireUser(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteAvatar(c.Request.Context(), userID); err != nil {
+		h.profileError(c, err, "Failed to delete avatar")
+		return
+	}
+	h.responder.SuccessWithMessage(c, "Avatar deleted")
+}
+
+// Avatar — GET /avatars/:userId?size=128: редирект на подписанную ссылку. Без авторизации, чтобы работал <img src>.
+func (h *Handler) Avatar(c *gin.Context) {
+	size, _ := strconv.Atoi(c.Query("size"))
+	url, err := h.service.AvatarURL(c.Request.Context(), c.Param("userId"), size)
+	if err != nil {
+		if errors.Is(err, profileService.ErrNoAvatar) {
+			h.responder.NotFound(c, "Avatar not found")
+			return
+		}
+		h.responder.InternalServerError(c, "Failed to get avatar")
+		return
+	}
+	// Редирект живёт меньше подписанной ссылки, на которую ведёт
+	c.Header("Cache-Control", "private, max-age="+strconv.Itoa(int(h.service.AvatarURLTTL().Seconds()/2)))
+	c.Redirect(http.StatusFound, url)
+}
+
+// DeleteAccount — DELETE /auth/me. Если есть общие воркспейсы без решения, отвечает 409 со списком.
+func (h *Handler) DeleteAccount(c *gin.Context) {
+	userID, ok := h.requireUser(c)
+	if !ok {
+		return
+	}
+	var req model.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.responder.BadRequest(c, "Invalid request")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.responder.BadRequest(c, err.Error())
+		return
+	}
+	unresolved, err := h.service.DeleteAccount(c.Request.Context(), userID, req)
+	if errors.Is(err, userRepo.ErrSharedWorkspaces) {
+		h.responder.WriteErrorWithCode(c, http.StatusConflict, "SHARED_WORKSPACES",
+			"Transfer or delete workspaces that have other members", gin.H{"workspaces": unresolved})
+		return
+	}
+	if err != nil {
+		h.profileError(c, err, "Failed to delete account")
+		return
+	}
+	h.cookieManager.Delete(c.Writer, "access_token")
+	h.responder.SuccessWithMessage(c, "Account deleted")
+}
+
+func (h *Handler) requireUser(c *gin.Context) (string, bool) {
+	userID, ok := middleware.GetUserIDFromGin(c)
+	if !ok {
+		h.responder.Unauthorized(c, "Authentication required")
+		return "", false
+	}
+	return userID, true
+}
+
+func (h *Handler) profileError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, profileService.ErrUserNotFound):
+		h.responder.Unauthorized(c, "User not found")
+	case errors.Is(err, profileService.ErrWrongPassword):
+		h.responder.WriteError(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, profileService.ErrEmptyName),
+		errors.Is(err, profileService.ErrInvalidLocale),
+		errors.Is(err, profileService.ErrInvalidTimezone),
+		errors.Is(err, profileService.ErrSameEmail),
+		errors.Is(err, userRepo.ErrInvalidTransfer):
+		h.responder.BadRequest(c, err.Error())
+	case errors.Is(err, userRepo.ErrEmailTaken):
+		h.responder.Conflict(c, err.Error())
+	case errors.Is(err, userRepo.ErrEmailChangeNotFound):
+		h.responder.NotFound(c, err.Error())
+	case errors.Is(err, profileService.ErrAvatarTooLarge):
+		h.responder.WriteError(c, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, profileService.ErrInvalidImage):
+		h.responder.WriteError(c, http.StatusUnsupportedMediaType, err.Error())
+	default:
+		h.responder.InternalServerError(c, msg)
+	}
+}
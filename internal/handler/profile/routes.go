@@ -0,0 +1,19 @@
+package profile
+
+// Свой профиль (под /auth, с авторизацией)
+const (
+	RouteProfile      = "/me"
+	RouteEmail        = "/me/email"
+	RouteAvatar       = "/me/avatar"
+	RouteDeleteAvatar = "/me/avatar"
+	RouteDeleteMe     = "/me"
+)
+
+// RouteConfirmEmail — подтверждение смены email по токену из письма (под /auth, без авторизации)
+const RouteConfirmEmail = "/email/confirm"
+
+// RouteAvatarImage — аватар пользователя по стабильной ссылке avatar_url (под /avatars, без авторизации)
+const RouteAvatarImage = "/:userId"
+
+// AvatarField — поле multipart-формы с картинкой
+const AvatarField = "avatar"
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"backend/internal/middleware"
+	"backend/internal/model"
+	searchService "backend/internal/service/search"
+	workspaceService "backend/internal/service/workspace"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type Handler struct {
+	service   *searchService.Service
+	responder *response.Responder
+}
+
+func NewHandler(service *searchService.Service, responder *response.Responder) *Handler {
+	return &Handler{
+		service:   service,
+		responder: responder,
+	}
+}
+
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET(RouteSearch, h.Search)
+}
+
+// Search — полнотекстовый поиск по воркспейсу.
+// Query: q (обязателен; поддерживает "фразу", or, -исключение и поиск по началу слова),
+// types (через запятую: note, journal, habit, counterparty; по умолчанию все), limit (до 100), offset.
+func (h *Handler) Search(c *gin.Context) {
+	userID, ok := middleware.GetUserIDFromGin(c)
+	if !ok {
+		h.responder.Unauthorized(c, "Authentication required")
+		return
+	}
+	roleVal, _ := c.Get(middleware.GinRoleKey)
+	role := model.UserRoleUser
+	if roleVal != nil {
+		role = roleVal.(model.UserRole)
+	}
+
+	limit, err := parseInt(c.Query("limit"), defaultLimit)
+	if err != nil || limit < 1 {
+		h.responder.BadRequest(c, "invalid limit")
+		return
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	offset, err := parseInt(c.Query("offset"), 0)
+	if err != nil || offset < 0 {
+		h.responder.BadRequest(c, "invalid offset")
+		return
+	}
+
+	var types []string
+	for _, v := range c.QueryArray("types") {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				types = append(types, t)
+			}
+		}
+	}
+
+	res, err := h.service.Search(c.Request.Context(), c.Param("workspaceId"), userID, role, c.Query("q"), types, limit, offset)
+	switch {
+	case err == nil:
+		h.responder.SuccessWithData(c, res)
+	case errors.Is(err, searchService.ErrEmptyQuery),
+		errors.Is(err, searchService.ErrQueryTooLong),
+		errors.Is(err, searchService.ErrUnknownType):
+		h.responder.BadRequest(c, err.Error())
+	case errors.Is(err, workspaceService.ErrAccessDenied), errors.Is(err, workspaceService.ErrWorkspaceNotFound):
+		h.responder.Forbidden(c, "Access denied to this workspace")
+	default:
+		h.responder.InternalServerErrorWithDetails(c, "search failed", err)
+	}
+}
+
+func parseInt(s string, def int) (int, error) {
+	if s == "" {
+		return def, nil
+	}
+	return strconv.Atoi(s)
+}
@@ -0,0 +1,5 @@
+package search
+
+const (
+	RouteSearch = "/search"
+)
@@ -0,0 +1,139 @@
+package trash
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"backend/internal/middleware"
+	"backend/internal/model"
+	trashService "backend/internal/service/trash"
+	workspaceService "backend/internal/service/workspace"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	trashSvc     *trashService.Service
+	workspaceSvc *workspaceService.Service
+	responder    *response.Responder
+}
+
+func NewHandler(
+	trashSvc *trashService.Service,
+	workspaceSvc *workspaceService.Service,
+	responder *response.Responder,
+) *Handler {
+	return &Handler{
+		trashSvc:     trashSvc,
+		workspaceSvc: workspaceSvc,
+		responder:    responder,
+	}
+}
+
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	trash := r.Group("/trash")
+	{
+		trash.GET(RouteList, h.List)
+		trash.DELETE(RouteEmpty, h.Empty)
+		trash.POST(RouteRestore, h.Restore)
+		trash.DELETE(RoutePurge, h.Purge)
+	}
+}
+
+func (h *Handler) requireWorkspaceAccess(c *gin.Context) (workspaceID, userID string, ok bool) {
+	userID, ok = middleware.GetUserIDFromGin(c)
+	if !ok {
+		h.responder.Unauthorized(c, "Authentication required")
+		return "", "", false
+	}
+	workspaceID = c.Param("workspaceId")
+	if workspaceID == "" {
+		h.responder.BadRequest(c, "Workspace ID required")
+		return "", "", false
+	}
+	roleVal, _ := c.Get(middleware.GinRoleKey)
+	role := model.UserRoleUser
+	if roleVal != nil {
+		role = roleVal.(model.UserRole)
+	}
+	hasAccess, err := h.workspaceSvc.HasAccess(c.Request.Context(), workspaceID, userID, role)
+	if err != nil || !hasAccess {
+		h.responder.Forbidden(c, "Access denied to this workspace")
+		return "", "", false
+	}
+	return workspaceID, userID, true
+}
+
+// List — содержимое корзины, недавно удалённые сверху. Query: types (через запятую: note, journal, habit; по умолчанию все).
+func (h *Handler) List(c *gin.Context) {
+	workspaceID, userID, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	var types []string
+	for _, v := range c.QueryArray("types") {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				types = append(types, t)
+			}
+		}
+	}
+	items, err := h.trashSvc.List(c.Request.Context(), workspaceID, userID, types)
+	if err != nil {
+		h.trashError(c, err, "Failed to list trash")
+		return
+	}
+	h.responder.SuccessWithData(c, gin.H{"items": items})
+}
+
+func (h *Handler) Restore(c *gin.Context) {
+	workspaceID, userID, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	if err := h.trashSvc.Restore(c.Request.Context(), workspaceID, userID, c.Param("type"), c.Param("itemId")); err != nil {
+		h.trashError(c, err, "Failed to restore item")
+		return
+	}
+	h.responder.SuccessWithMessage(c, "Item restored")
+}
+
+// Purge удаляет элемент из корзины окончательно, не дожидаясь срока
+func (h *Handler) Purge(c *gin.Context) {
+	workspaceID, userID, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	if err := h.trashSvc.Purge(c.Request.Context(), workspaceID, userID, c.Param("type"), c.Param("itemId")); err != nil {
+		h.trashError(c, err, "Failed to purge item")
+		return
+	}
+	h.responder.SuccessWithMessage(c, "Item deleted permanently")
+}
+
+// Empty очищает корзину: заметки и записи всех участников, привычки — только свои
+func (h *Handler) Empty(c *gin.Context) {
+	workspaceID, userID, ok := h.requireWorkspaceAccess(c)
+	if !ok {
+		return
+	}
+	n, err := h.trashSvc.Empty(c.Request.Context(), workspaceID, userID)
+	if err != nil {
+		h.trashError(c, err, "Failed to empty trash")
+		return
+	}
+	h.responder.SuccessWithData(c, model.TrashPurgeResult{Purged: n})
+}
+
+func (h *Handler) trashError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		h.responder.NotFound(c, "Item not found in trash")
+	case errors.Is(err, trashService.ErrUnknownType):
+		h.responder.BadRequest(c, err.Error())
+	default:
+		h.responder.InternalServerError(c, msg)
+	}
+}
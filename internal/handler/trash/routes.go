@@ -0,0 +1,9 @@
+package trash
+
+// Корзина воркспейса (под /workspaces/:workspaceId/trash)
+const (
+	RouteList    = ""
+	RouteEmpty   = ""
+	RouteRestore = "/:type/:itemId/restore"
+	RoutePurge   = "/:type/:itemId"
+)
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"backend/pkg/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute — метка route для запросов без маршрута (404), чтобы пути не раздували число рядов
+const unmatchedRoute = "unmatched"
+
+// Metrics считает запросы и их длительность по методу, шаблону маршрута и коду ответа
+func Metrics(reg *metrics.Registry) gin.HandlerFunc {
+	requests := reg.NewCounterVec("http_requests_total", "Количество HTTP-запросов", "method", "route", "status")
+	duration := reg.NewHistogramVec("http_request_duration_seconds", "Длительность HTTP-запросов", metrics.DefBuckets, "method", "route", "status")
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		requests.Inc(c.Request.Method, route, status)
+		duration.Observe(time.Since(start).Seconds(), c.Request.Method, route, status)
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetricsUsesRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reg := metrics.NewRegistry()
+	r := gin.New()
+	r.Use(Metrics(reg))
+	r.GET("/api/v1/workspaces/:workspaceId/notes", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/api/v1/workspaces/:workspaceId/notes", func(c *gin.Context) { c.Status(http.StatusBadRequest) })
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/workspaces/1/notes"},
+		{http.MethodGet, "/api/v1/workspaces/2/notes"},
+		{http.MethodPost, "/api/v1/workspaces/1/notes"},
+		{http.MethodGet, "/wp-login.php"},
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	var b strings.Builder
+	if err := reg.WriteTo(context.Background(), &b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, line := range []string{
+		`http_requests_total{method="GET",route="/api/v1/workspaces/:workspaceId/notes",status="200"} 2`,
+		`http_requests_total{method="POST",route="/api/v1/workspaces/:workspaceId/notes",status="400"} 1`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/api/v1/workspaces/:workspaceId/notes",status="200"} 2`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("no %s in:\n%s", line, out)
+		}
+	}
+	if strings.Contains(out, "/workspaces/1/") || strings.Contains(out, "wp-login") {
+		t.Errorf("raw path leaked into labels:\n%s", out)
+	}
+}
@@ -2,91 +2,105 @@ package middleware
 
 import (
 	"backend/internal/service/logger"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-// RequestLogger логирует все запросы в файл
+const (
+	RequestIDHeader = "X-Request-ID"
+	GinRequestIDKey = "request_id"
+)
+
+// maxRequestIDLength ограничивает длину X-Request-ID, пришедшего от клиента
+const maxRequestIDLength = 64
+
+// RequestLogger пишет в файл структурированную JSON-запись (log/slog) о каждом запросе.
+// X-Request-ID берётся из заголовка запроса или генерируется и возвращается в ответе.
 func RequestLogger(logService *logger.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.New().String()
+		}
+		c.Set(GinRequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
 		// Обрабатываем запрос
 		c.Next()
 
-		// Формируем строку лога
-		duration := time.Since(start)
-		timestamp := time.Now()
-		statusCode := c.Writer.Status()
-		method := c.Request.Method
-		path := c.Request.URL.Path
-		clientIP := c.ClientIP()
-
-		// Формат: [GIN] 2006/01/02 - 15:04:05 | 200 | 4.83725ms | ::1 | GET      "/health"
-		logLine := timestamp.Format("[GIN] 2006/01/02 - 15:04:05") +
-			" | " + formatInt(statusCode, 3) +
-			" | " + formatDuration(duration) +
-			" | " + formatString(clientIP, 15) +
-			" | " + method +
-			"      " + path
-
-		// Записываем в файл синхронно
-		logService.WriteLog(logLine)
+		latency := time.Since(start)
+		status := c.Writer.Status()
+
+		attrs := []slog.Attr{
+			slog.String("request_id", requestID),
+			slog.String("method", c.Request.Method),
+			slog.String("path", redactedPath(c)),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(latency.Nanoseconds())/1e6),
+			slog.Int("bytes_out", max(c.Writer.Size(), 0)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+		}
+		if userID, ok := GetUserIDFromGin(c); ok && isUUID(userID) {
+			attrs = append(attrs, slog.String("user_id", userID))
+		}
+		if workspaceID := requestWorkspaceID(c); workspaceID != "" {
+			attrs = append(attrs, slog.String("workspace_id", workspaceID))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		logService.Logger().LogAttrs(c.Request.Context(), level, "request", attrs...)
 	}
 }
 
-func formatInt(n int, width int) string {
-	s := ""
-	for n > 0 {
-		s = string(rune('0'+n%10)) + s
-		n /= 10
-	}
-	if s == "" {
-		s = "0"
-	}
-	for len(s) < width {
-		s = " " + s
+// secretParams — параметры маршрута, которые сами являются секретом (токены публичных ссылок) и не пишутся в лог
+var secretParams = []string{"token"}
+
+// redactedPath — путь запроса, в котором значения secretParams заменены на ***
+func redactedPath(c *gin.Context) string {
+	path := c.Request.URL.Path
+	for _, name := range secretParams {
+		if v := c.Param(name); v != "" {
+			path = strings.Replace(path, v, "***", 1)
+		}
 	}
-	return s
+	return path
 }
 
-func formatDuration(d time.Duration) string {
-	ms := float64(d.Nanoseconds()) / 1000000.0
-	if ms < 1 {
-		us := float64(d.Nanoseconds()) / 1000.0
-		return formatFloat(us, 2) + "µs"
-	}
-	return formatFloat(ms, 6) + "ms"
+// GetRequestIDFromGin возвращает X-Request-ID текущего запроса.
+func GetRequestIDFromGin(c *gin.Context) string {
+	return c.GetString(GinRequestIDKey)
 }
 
-func formatFloat(f float64, precision int) string {
-	s := ""
-	n := int(f)
-	for n > 0 {
-		s = string(rune('0'+n%10)) + s
-		n /= 10
-	}
-	if s == "" {
-		s = "0"
+// requestWorkspaceID — воркспейс запроса: из пути /workspaces/:workspaceId или из WorkspaceMiddleware.
+func requestWorkspaceID(c *gin.Context) string {
+	if id := c.Param("workspaceId"); isUUID(id) {
+		return id
 	}
-	if precision > 0 {
-		frac := f - float64(int(f))
-		s += "."
-		for i := 0; i < precision; i++ {
-			frac *= 10
-			s += string(rune('0' + int(frac)%10))
-		}
+	if id, ok := GetWorkspaceIDFromGin(c); ok && isUUID(id) {
+		return id
 	}
-	return s
+	return ""
 }
 
-func formatString(s string, width int) string {
-	for len(s) < width {
-		s += " "
-	}
-	if len(s) > width {
-		return s[:width]
-	}
-	return s
+func isUUID(s string) bool {
+	_, err := uuid.Parse(s)
+	return err == nil
 }
@@ -0,0 +1,34 @@
+package model
+
+// Владельцы вложений
+const (
+	AttachmentOwnerNote            = "note"
+	AttachmentOwnerJournal         = "journal"
+	AttachmentOwnerHabitCompletion = "habit_completion"
+)
+
+// Attachment — файл, прикреплённый к заметке, записи дневника или выполнению привычки.
+// URL — подписанная ссылка на скачивание, действует до URLExpiresAt.
+type Attachment struct {
+	ID           string  `json:"id"`
+	WorkspaceID  string  `json:"workspaceId"`
+	OwnerType    string  `json:"ownerType"`
+	OwnerID      string  `json:"ownerId"`
+	Filename     string  `json:"filename"`
+	ContentType  string  `json:"contentType"`
+	Size         int64   `json:"size"`
+	SHA256       string  `json:"sha256"`
+	UploadedBy   *string `json:"uploadedBy,omitempty"`
+	StorageKey   string  `json:"-"`
+	CreatedAt    string  `json:"createdAt"`
+	URL          string  `json:"url,omitempty"`
+	URLExpiresAt string  `json:"urlExpiresAt,omitempty"`
+}
+
+// AttachmentUsage — занятое вложениями место в воркспейсе
+type AttachmentUsage struct {
+	Count      int   `json:"count"`
+	UsedBytes  int64 `json:"usedBytes"`
+	QuotaBytes int64 `json:"quotaBytes"`
+	MaxSize    int64 `json:"maxFileSize"`
+}
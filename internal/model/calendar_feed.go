@@ -0,0 +1,11 @@
+package model
+
+// CalendarFeed — подписка на расписание привычек в календаре по секретной ссылке.
+// Token и Path показываются один раз — при создании ссылки; в базе хранится только хэш токена.
+type CalendarFeed struct {
+	Enabled        bool    `json:"enabled"`
+	Token          string  `json:"token,omitempty"`
+	Path           string  `json:"path,omitempty"` // путь ленты на этом сервере, с токеном
+	CreatedAt      *string `json:"createdAt,omitempty"`
+	LastAccessedAt *string `json:"lastAccessedAt,omitempty"` // последнее обращение календаря к ленте
+}
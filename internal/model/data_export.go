@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// Статусы выгрузки данных пользователя
+const (
+	DataExportPending = "pending"
+	DataExportRunning = "running"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+	DataExportExpired = "expired"
+)
+
+// DataExport — запрос на выгрузку всех данных пользователя в ZIP
+type DataExport struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"-"`
+	Status     string     `json:"status"`
+	SizeBytes  *int64     `json:"size,omitempty"`
+	Error      *string    `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	StorageKey string     `json:"-"`
+	// DownloadURL — подписанная ссылка на архив; только у готовой выгрузки
+	DownloadURL string `json:"downloadUrl,omitempty"`
+}
@@ -0,0 +1,63 @@
+package model
+
+// Форматы импорта истории привычек
+const (
+	HabitImportCSV  = "csv"
+	HabitImportLoop = "loop" // Loop Habit Tracker: резервная копия .db или ZIP из «Export as CSV»
+)
+
+// Раскладка CSV: long — строка на выполнение (привычка, дата), wide — строка на дату, столбец на привычку
+const (
+	HabitImportLayoutLong = "long"
+	HabitImportLayoutWide = "wide"
+)
+
+// HabitImportMapping — какие столбцы CSV что означают (по заголовку). Пустые поля определяются по заголовкам
+// (habit/name/title, date, value/done, notes/comment, rating).
+type HabitImportMapping struct {
+	Layout     string `json:"layout,omitempty"`
+	Habit      string `json:"habit,omitempty"`
+	Date       string `json:"date,omitempty"`
+	Value      string `json:"value,omitempty"` // отметка выполнения; пустая ячейка, 0, no, false — не выполнено
+	Notes      string `json:"notes,omitempty"`
+	Rating     string `json:"rating,omitempty"`
+	DateFormat string `json:"dateFormat,omitempty"` // Go-шаблон; по умолчанию пробуются ISO и распространённые форматы
+	Delimiter  string `json:"delimiter,omitempty"`  // по умолчанию — запятая, точка с запятой или табуляция по заголовку
+	// Columns — для wide: столбцы привычек; по умолчанию все, кроме даты
+	Columns []string `json:"columns,omitempty"`
+}
+
+// HabitCompletionImport — выполнение из импортируемой истории
+type HabitCompletionImport struct {
+	Date   string  `json:"date"`
+	Notes  *string `json:"notes,omitempty"`
+	Rating *int    `json:"rating,omitempty"`
+}
+
+// HabitImportItem — привычка из файла. Status: new — будет создана, existing — у пользователя уже есть
+// привычка с таким названием, выполнения добавятся к ней.
+type HabitImportItem struct {
+	Title       string `json:"title"`
+	Status      string `json:"status"`
+	HabitID     string `json:"habitId,omitempty"`
+	FirstDate   string `json:"firstDate"`
+	LastDate    string `json:"lastDate"`
+	Completions int    `json:"completions"`
+	// Imported — сколько выполнений добавлено; дни, уже отмеченные раньше, не считаются. В предпросмотре не заполняется.
+	Imported int `json:"imported"`
+}
+
+const (
+	HabitImportStatusNew      = "new"
+	HabitImportStatusExisting = "existing"
+)
+
+// HabitImportResult — итог импорта или, при DryRun, предпросмотр без изменений
+type HabitImportResult struct {
+	DryRun   bool              `json:"dryRun"`
+	Format   string            `json:"format"`
+	Habits   []HabitImportItem `json:"habits"`
+	Rows     int               `json:"rows"`    // строк данных в файле
+	Skipped  int               `json:"skipped"` // строк без выполнения или с ошибкой
+	Warnings []string          `json:"warnings"`
+}
@@ -31,3 +31,77 @@ type UpdateJournalEntryDto struct {
 	ContentType *string                `json:"contentType,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 }
+
+// JournalTagCount — тег и число записей с ним
+type JournalTagCount struct {
+	Tag      string `json:"tag"`
+	Count    int    `json:"count"`
+	LastUsed string `json:"lastUsed"` // дата последней записи с тегом
+}
+
+const (
+	MoodPeriodDay  = "day"
+	MoodPeriodWeek = "week"
+)
+
+// JournalMoodPoint — настроение за период (день или неделя с понедельника)
+type JournalMoodPoint struct {
+	Period  string  `json:"period"` // первый день периода, YYYY-MM-DD
+	Average float64 `json:"average"`
+	Min     int     `json:"min"`
+	Max     int     `json:"max"`
+	Entries int     `json:"entries"`
+}
+
+// RenameJournalTagsDto — переименование или слияние тегов: все from становятся to
+type RenameJournalTagsDto struct {
+	From []string `json:"from" validate:"required,min=1,dive,required,max=100"`
+	To   string   `json:"to" validate:"required,max=100"`
+}
+
+// JournalRevision — снимок записи дневника после одного сохранения
+type JournalRevision struct {
+	EntryID      string                 `json:"entryId"`
+	Revision     int                    `json:"revision"`
+	AuthorID     *string                `json:"authorId,omitempty"` // nil — автор удалён
+	Description  string                 `json:"description"`
+	Mood         *int                   `json:"mood,omitempty"`
+	Date         string                 `json:"date"`
+	Tags         []string               `json:"tags"`
+	ContentType  string                 `json:"contentType"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	RestoredFrom *int                   `json:"restoredFrom,omitempty"` // ревизия, из которой восстановлена эта
+	CreatedAt    string                 `json:"createdAt"`
+}
+
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// DiffLine — строка построчного диффа. OldLine/NewLine — номера строк (с 1) в старой и новой версии.
+type DiffLine struct {
+	Op      string `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"oldLine,omitempty"`
+	NewLine int    `json:"newLine,omitempty"`
+}
+
+// FieldChange — изменение поля записи помимо текста
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// JournalRevisionDiff — разница между двумя ревизиями: построчно для текста и по полям для остального
+type JournalRevisionDiff struct {
+	EntryID string        `json:"entryId"`
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Added   int           `json:"added"`
+	Removed int           `json:"removed"`
+	Lines   []DiffLine    `json:"lines"`
+	Changes []FieldChange `json:"changes"`
+}
@@ -0,0 +1,30 @@
+package model
+
+// Связь между сущностями воркспейса. Типы сущностей те же, что у поиска: SearchType*.
+const (
+	LinkOriginWiki   = "wiki"   // [[ссылка]] в тексте заметки или записи дневника
+	LinkOriginManual = "manual" // добавлена вручную
+)
+
+// LinkedEntity — сущность на другом конце связи
+type LinkedEntity struct {
+	LinkID    string `json:"linkId"`
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	Title     string `json:"title"` // для записи дневника — дата
+	Origin    string `json:"origin"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// EntityLinks — ответ GET /links/:type/:id: куда ссылается сущность и что ссылается на неё
+type EntityLinks struct {
+	Outgoing  []LinkedEntity `json:"outgoing"`
+	Backlinks []LinkedEntity `json:"backlinks"`
+}
+
+type CreateLinkDto struct {
+	SourceType string `json:"sourceType" validate:"required,oneof=note journal habit counterparty"`
+	SourceID   string `json:"sourceId" validate:"required,uuid"`
+	TargetType string `json:"targetType" validate:"required,oneof=note journal habit counterparty"`
+	TargetID   string `json:"targetId" validate:"required,uuid"`
+}
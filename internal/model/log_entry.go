@@ -3,11 +3,119 @@ package model
 import "time"
 
 type LogEntry struct {
-	Timestamp  time.Time     `json:"timestamp"`
-	StatusCode int           `json:"status_code"`
-	Duration   time.Duration `json:"duration"`
-	ClientIP   string        `json:"client_ip"`
-	Method     string        `json:"method"`
-	Path       string        `json:"path"`
-	RawLog     string        `json:"raw_log"`
+	Timestamp   time.Time     `json:"timestamp"`
+	RequestID   string        `json:"request_id,omitempty"`
+	StatusCode  int           `json:"status_code"`
+	Duration    time.Duration `json:"duration"`
+	ClientIP    string        `json:"client_ip"`
+	Method      string        `json:"method"`
+	Path        string        `json:"path"`
+	Route       string        `json:"route,omitempty"`
+	UserID      string        `json:"user_id,omitempty"`
+	WorkspaceID string        `json:"workspace_id,omitempty"`
+	BytesOut    int64         `json:"bytes_out"`
+	UserAgent   string        `json:"user_agent,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	RawLog      string        `json:"raw_log"`
+	ContentHash string        `json:"-"`
+}
+
+// RequestLogRecord — одна JSON-запись в файле logs/requests-YYYY-MM-DD.log (формат slog.JSONHandler).
+type RequestLogRecord struct {
+	Time        time.Time `json:"time"`
+	Level       string    `json:"level"`
+	Msg         string    `json:"msg"`
+	RequestID   string    `json:"request_id"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	Route       string    `json:"route,omitempty"`
+	Status      int       `json:"status"`
+	LatencyMs   float64   `json:"latency_ms"`
+	BytesOut    int64     `json:"bytes_out"`
+	ClientIP    string    `json:"client_ip"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	UserID      string    `json:"user_id,omitempty"`
+	WorkspaceID string    `json:"workspace_id,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// ToLogEntry преобразует запись из файла в строку для request_logs.
+func (r RequestLogRecord) ToLogEntry(raw string) *LogEntry {
+	return &LogEntry{
+		Timestamp:   r.Time,
+		RequestID:   r.RequestID,
+		StatusCode:  r.Status,
+		Duration:    time.Duration(r.LatencyMs * float64(time.Millisecond)),
+		ClientIP:    r.ClientIP,
+		Method:      r.Method,
+		Path:        r.Path,
+		Route:       r.Route,
+		UserID:      r.UserID,
+		WorkspaceID: r.WorkspaceID,
+		BytesOut:    r.BytesOut,
+		UserAgent:   r.UserAgent,
+		Error:       r.Error,
+		RawLog:      raw,
+	}
+}
+
+// LogFilter — фильтры выборки из request_logs. Пустые поля не фильтруют.
+type LogFilter struct {
+	From        *time.Time
+	To          *time.Time
+	StatusClass int // 2 = 2xx, 4 = 4xx, 5 = 5xx
+	Method      string
+	PathPrefix  string
+	ClientIP    string
+	UserID      string
+	Limit       int
+	Offset      int
+}
+
+// RouteLatencyStats — перцентили длительности запросов по маршруту (мс)
+type RouteLatencyStats struct {
+	Method string  `json:"method"`
+	Route  string  `json:"route"`
+	Count  int64   `json:"count"`
+	AvgMs  float64 `json:"avg_ms"`
+	P50Ms  float64 `json:"p50_ms"`
+	P95Ms  float64 `json:"p95_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+}
+
+// RouteErrorStats — доля ошибочных ответов по маршруту
+type RouteErrorStats struct {
+	Method          string  `json:"method"`
+	Route           string  `json:"route"`
+	Total           int64   `json:"total"`
+	ClientErrors    int64   `json:"client_errors"`
+	ServerErrors    int64   `json:"server_errors"`
+	ErrorRate       float64 `json:"error_rate"`
+	ServerErrorRate float64 `json:"server_error_rate"`
+}
+
+// ClientStats — активность клиента (по IP)
+type ClientStats struct {
+	ClientIP    string    `json:"client_ip"`
+	Requests    int64     `json:"requests"`
+	Errors      int64     `json:"errors"`
+	UniqueUsers int64     `json:"unique_users"`
+	AvgMs       float64   `json:"avg_ms"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// DailyRouteStats — свёртка request_logs за день по маршруту (request_log_daily_stats)
+type DailyRouteStats struct {
+	Day          string  `json:"day"`
+	Method       string  `json:"method"`
+	Route        string  `json:"route"`
+	Requests     int64   `json:"requests"`
+	ClientErrors int64   `json:"client_errors"`
+	ServerErrors int64   `json:"server_errors"`
+	AvgMs        float64 `json:"avg_ms"`
+	MaxMs        float64 `json:"max_ms"`
+	P50Ms        float64 `json:"p50_ms"`
+	P95Ms        float64 `json:"p95_ms"`
+	P99Ms        float64 `json:"p99_ms"`
+	BytesOut     int64   `json:"bytes_out"`
 }
@@ -23,14 +23,3 @@ type Counterparty struct {
 	CreatedAt   string  `json:"createdAt" db:"created_at"`
 	UpdatedAt   string  `json:"updatedAt" db:"updated_at"`
 }
-
-// Note — заметка (модуль Заметки).
-type Note struct {
-	ID          string `json:"id" db:"id"`
-	WorkspaceID string `json:"workspaceId" db:"workspace_id"`
-	UserID      string `json:"userId" db:"user_id"`
-	Title       string `json:"title" db:"title"`
-	Content     string `json:"content" db:"content"`
-	CreatedAt   string `json:"createdAt" db:"created_at"`
-	UpdatedAt   string `json:"updatedAt" db:"updated_at"`
-}
@@ -35,6 +35,7 @@ type WorkspaceModule struct {
 // Коды модулей (совпадают с modules.code в БД).
 const (
 	ModuleCodeHabits = "habits"
+	ModuleCodeNotes  = "notes"
 	ModuleCodeCRM   = "crm"
 )
 
@@ -0,0 +1,147 @@
+package model
+
+// Note — заметка (модуль Заметки).
+type Note struct {
+	ID          string   `json:"id" db:"id"`
+	WorkspaceID string   `json:"workspaceId" db:"workspace_id"`
+	UserID      string   `json:"userId" db:"user_id"`
+	FolderID    *string  `json:"folderId" db:"folder_id"` // nil — в корне
+	Title       string   `json:"title" db:"title"`
+	Content     string   `json:"content" db:"content"`
+	ContentType string   `json:"contentType" db:"content_type"` // text, markdown
+	Tags        []string `json:"tags" db:"tags"`
+	Pinned      bool     `json:"pinned" db:"pinned"`
+	Position    int      `json:"position" db:"position"` // порядок внутри папки
+	ArchivedAt  *string  `json:"archivedAt,omitempty" db:"archived_at"`
+	CreatedAt   string   `json:"createdAt" db:"created_at"`
+	UpdatedAt   string   `json:"updatedAt" db:"updated_at"`
+}
+
+// NoteFolder — папка заметок. Папки образуют дерево в пределах воркспейса.
+type NoteFolder struct {
+	ID          string  `json:"id" db:"id"`
+	WorkspaceID string  `json:"workspaceId" db:"workspace_id"`
+	ParentID    *string `json:"parentId" db:"parent_id"` // nil — в корне
+	Name        string  `json:"name" db:"name"`
+	Position    int     `json:"position" db:"position"`
+	NoteCount   int     `json:"noteCount"` // заметки прямо в папке, без архивных и вложенных
+	CreatedAt   string  `json:"createdAt" db:"created_at"`
+	UpdatedAt   string  `json:"updatedAt" db:"updated_at"`
+}
+
+const (
+	NoteContentText     = "text"
+	NoteContentMarkdown = "markdown"
+)
+
+type CreateNoteDto struct {
+	Title       string   `json:"title" validate:"required,max=500"`
+	Content     string   `json:"content"`
+	ContentType string   `json:"contentType,omitempty" validate:"omitempty,oneof=text markdown"`
+	Tags        []string `json:"tags,omitempty" validate:"omitempty,max=50,dive,required,max=100"`
+	FolderID    *string  `json:"folderId,omitempty" validate:"omitempty,uuid"`
+}
+
+// UpdateNoteDto — title и content заменяются всегда; ContentType и Tags — только если переданы.
+// Папка и порядок меняются через move, закрепление и архив — отдельными эндпоинтами.
+type UpdateNoteDto struct {
+	Title       string   `json:"title" validate:"required,max=500"`
+	Content     string   `json:"content"`
+	ContentType *string  `json:"contentType,omitempty" validate:"omitempty,oneof=text markdown"`
+	Tags        []string `json:"tags,omitempty" validate:"omitempty,max=50,dive,required,max=100"`
+}
+
+// MoveNoteDto — перенос заметки. FolderID nil — в корень; Position — место среди заметок папки с 0, nil — в конец.
+type MoveNoteDto struct {
+	FolderID *string `json:"folderId" validate:"omitempty,uuid"`
+	Position *int    `json:"position,omitempty" validate:"omitempty,min=0"`
+}
+
+type CreateNoteFolderDto struct {
+	Name     string  `json:"name" validate:"required,max=255"`
+	ParentID *string `json:"parentId,omitempty" validate:"omitempty,uuid"`
+}
+
+type RenameNoteFolderDto struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+// MoveNoteFolderDto — перенос папки. ParentID nil — в корень; Position — как в MoveNoteDto.
+type MoveNoteFolderDto struct {
+	ParentID *string `json:"parentId" validate:"omitempty,uuid"`
+	Position *int    `json:"position,omitempty" validate:"omitempty,min=0"`
+}
+
+// Права пользователя на чужую заметку
+const (
+	NotePermissionRead = "read"
+	NotePermissionEdit = "edit"
+)
+
+// NoteShare — заметка, открытая пользователю вне воркспейса
+type NoteShare struct {
+	ID         string  `json:"id"`
+	NoteID     string  `json:"noteId"`
+	UserID     string  `json:"userId"`
+	Email      string  `json:"email"`
+	Name       string  `json:"name"`
+	Permission string  `json:"permission"`
+	CreatedBy  *string `json:"createdBy,omitempty"`
+	CreatedAt  string  `json:"createdAt"`
+}
+
+// SharedNote — заметка, которую открыли текущему пользователю
+type SharedNote struct {
+	Note
+	Permission string `json:"permission"`
+}
+
+type ShareNoteDto struct {
+	Email      string `json:"email" validate:"required,email"`
+	Permission string `json:"permission" validate:"required,oneof=read edit"`
+}
+
+// NoteLink — публичная ссылка только для чтения. Token заполнен только в ответе на создание.
+type NoteLink struct {
+	ID             string  `json:"id"`
+	NoteID         string  `json:"noteId"`
+	Token          string  `json:"token,omitempty"`
+	HasPassword    bool    `json:"hasPassword"`
+	ExpiresAt      *string `json:"expiresAt,omitempty"`
+	RevokedAt      *string `json:"revokedAt,omitempty"`
+	CreatedBy      *string `json:"createdBy,omitempty"`
+	CreatedAt      string  `json:"createdAt"`
+	LastAccessedAt *string `json:"lastAccessedAt,omitempty"` // последнее успешное открытие
+	AccessCount    int     `json:"accessCount"`              // успешные открытия
+}
+
+type CreateNoteLinkDto struct {
+	ExpiresAt *string `json:"expiresAt,omitempty"` // RFC3339
+	Password  string  `json:"password,omitempty" validate:"omitempty,min=4,max=72"`
+}
+
+// Исходы обращения к публичной ссылке
+const (
+	LinkAccessOK               = "ok"
+	LinkAccessPasswordRequired = "password_required"
+	LinkAccessWrongPassword    = "wrong_password"
+	LinkAccessExpired          = "expired"
+	LinkAccessRevoked          = "revoked"
+	LinkAccessLocked           = "locked"
+)
+
+type NoteLinkAccess struct {
+	AccessedAt string `json:"accessedAt"`
+	Outcome    string `json:"outcome"`
+	ClientIP   string `json:"clientIp,omitempty"`
+	UserAgent  string `json:"userAgent,omitempty"`
+}
+
+// PublicNote — то, что видно по публичной ссылке
+type PublicNote struct {
+	Title       string   `json:"title"`
+	Content     string   `json:"content"`
+	ContentType string   `json:"contentType"`
+	Tags        []string `json:"tags"`
+	UpdatedAt   string   `json:"updatedAt"`
+}
@@ -0,0 +1,36 @@
+package model
+
+// Типы сущностей, по которым идёт полнотекстовый поиск
+const (
+	SearchTypeNote         = "note"
+	SearchTypeJournal      = "journal"
+	SearchTypeHabit        = "habit"
+	SearchTypeCounterparty = "counterparty"
+)
+
+// SearchTypes — все типы в порядке вывода фасетов
+var SearchTypes = []string{SearchTypeNote, SearchTypeJournal, SearchTypeHabit, SearchTypeCounterparty}
+
+// SearchHit — найденная сущность. Title и Snippet содержат совпадения, обёрнутые в <mark>…</mark>;
+// остальной текст не экранируется — перед вставкой в HTML экранировать на клиенте.
+type SearchHit struct {
+	Type      string   `json:"type"`
+	ID        string   `json:"id"`
+	Title     string   `json:"title"`
+	Snippet   string   `json:"snippet"`
+	Rank      float64  `json:"rank"`
+	Date      string   `json:"date,omitempty"` // дата записи дневника
+	Tags      []string `json:"tags,omitempty"` // теги записи дневника
+	UpdatedAt string   `json:"updatedAt"`
+}
+
+// SearchResult — ответ GET /workspaces/:workspaceId/search
+type SearchResult struct {
+	Query  string         `json:"query"`
+	Types  []string       `json:"types"`  // фактически просмотренные типы (с учётом включённых модулей)
+	Counts map[string]int `json:"counts"` // число совпадений по типам
+	Total  int            `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+	Hits   []SearchHit    `json:"hits"`
+}
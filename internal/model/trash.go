@@ -0,0 +1,18 @@
+package model
+
+// TrashTypes — что попадает в корзину; типы те же, что у поиска и связей
+var TrashTypes = []string{SearchTypeNote, SearchTypeJournal, SearchTypeHabit}
+
+// TrashItem — удалённая привычка, заметка или запись дневника. Title записи дневника — её дата (YYYY-MM-DD).
+type TrashItem struct {
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	DeletedAt string `json:"deletedAt"`
+	PurgeAt   string `json:"purgeAt,omitempty"` // когда воркер удалит окончательно; пусто — не удалит
+}
+
+// TrashPurgeResult — сколько удалено окончательно
+type TrashPurgeResult struct {
+	Purged int `json:"purged"`
+}
@@ -24,6 +24,8 @@ type User struct {
 	Role     UserRole `json:"role" db:"role"`
 
 	AvatarURL *string     `json:"avatarUrl,omitempty" db:"avatar_url"`
+	Locale    *string     `json:"locale,omitempty" db:"locale"`
+	Timezone  *string     `json:"timezone,omitempty" db:"timezone"`
 	Status    *UserStatus `json:"status,omitempty" db:"status"`
 	CreatedAt time.Time   `json:"createdAt" db:"created_at"`
 	UpdatedAt time.Time   `json:"updatedAt" db:"updated_at"`
@@ -44,3 +46,40 @@ type LoginResponse struct {
 	AccessToken string `json:"-"`
 	ExpiresIn   int    `json:"expires_in"`
 }
+
+// UpdateProfileDto — частичное обновление профиля: меняются только переданные поля, пустая строка очищает locale и timezone
+type UpdateProfileDto struct {
+	Name     *string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Locale   *string `json:"locale,omitempty"`
+	Timezone *string `json:"timezone,omitempty"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required"`
+}
+
+// EmailChange — ожидающая подтверждения смена email
+type EmailChange struct {
+	NewEmail  string    `json:"newEmail"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type ConfirmEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// DeleteAccountRequest — удаление своего аккаунта. Воркспейсы без других участников удаляются вместе с ним;
+// общие нужно передать участнику (Transfers: workspaceId -> userId) или явно удалить (DeleteSharedWorkspaces).
+type DeleteAccountRequest struct {
+	Password               string            `json:"password" validate:"required"`
+	Transfers              map[string]string `json:"transfers,omitempty"`
+	DeleteSharedWorkspaces bool              `json:"deleteSharedWorkspaces,omitempty"`
+}
+
+// OwnedWorkspace — воркспейс удаляемого пользователя, у которого есть другие участники
+type OwnedWorkspace struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Members int    `json:"members"`
+}
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Формат переноса воркспейса. Версия растёт при несовместимых изменениях; импорт принимает версии
+// от 1 до WorkspaceArchiveVersion.
+const (
+	WorkspaceArchiveFormat  = "habits-workspace"
+	WorkspaceArchiveVersion = 1
+)
+
+// WorkspaceArchive — содержимое воркспейса для резервной копии и переноса на другой сервер.
+// ID в архиве — исходные, по ним связаны записи внутри архива; при импорте выдаются новые.
+// Автор записей в архив не попадает: после импорта все записи принадлежат владельцу воркспейса.
+type WorkspaceArchive struct {
+	Format           string                   `json:"format"`
+	Version          int                      `json:"version"`
+	ExportedAt       time.Time                `json:"exportedAt"`
+	Workspace        ArchiveWorkspace         `json:"workspace"`
+	Modules          []ArchiveModule          `json:"modules"`
+	Currencies       []ArchiveCurrency        `json:"currencies"`
+	Counterparties   []ArchiveCounterparty    `json:"counterparties"`
+	Habits           []ArchiveHabit           `json:"habits"`
+	HabitVersions    []ArchiveHabitVersion    `json:"habitVersions"`
+	HabitCompletions []ArchiveHabitCompletion `json:"habitCompletions"`
+	JournalEntries   []ArchiveJournalEntry    `json:"journalEntries"`
+	NoteFolders      []ArchiveNoteFolder      `json:"noteFolders"`
+	Notes            []ArchiveNote            `json:"notes"`
+	Links            []ArchiveLink            `json:"links"`
+}
+
+type ArchiveWorkspace struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	Color       string  `json:"color"`
+}
+
+// ArchiveModule — состояние модуля; settings переносятся как есть
+type ArchiveModule struct {
+	Code     string          `json:"code"`
+	Status   string          `json:"status"`
+	Settings json.RawMessage `json:"settings"`
+}
+
+type ArchiveCurrency struct {
+	ID        string    `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Symbol    *string   `json:"symbol"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type ArchiveCounterparty struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Email     *string   `json:"email"`
+	Phone     *string   `json:"phone"`
+	Comment   *string   `json:"comment"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Даты — YYYY-MM-DD, время — HH:MM:SS
+type ArchiveHabit struct {
+	ID            string    `json:"id"`
+	Title         string    `json:"title"`
+	Description   *string   `json:"description"`
+	Color         string    `json:"color"`
+	Icon          *string   `json:"icon"`
+	TargetDays    *int      `json:"targetDays"`
+	DailyGoal     *int      `json:"dailyGoal"`
+	PreferredTime *string   `json:"preferredTime"`
+	Category      *string   `json:"category"`
+	ScheduleType  string    `json:"scheduleType"`
+	RecurringDays []int     `json:"recurringDays"`
+	OneTimeDate   *string   `json:"oneTimeDate"`
+	IsActive      bool      `json:"isActive"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+type ArchiveHabitVersion struct {
+	ID            string    `json:"id"`
+	HabitID       string    `json:"habitId"`
+	Title         string    `json:"title"`
+	Description   *string   `json:"description"`
+	Color         string    `json:"color"`
+	Icon          *string   `json:"icon"`
+	TargetDays    int       `json:"targetDays"`
+	DailyGoal     int       `json:"dailyGoal"`
+	PreferredTime *string   `json:"preferredTime"`
+	Category      *string   `json:"category"`
+	ScheduleType  string    `json:"scheduleType"`
+	RecurringDays []int     `json:"recurringDays"`
+	OneTimeDate   *string   `json:"oneTimeDate"`
+	IsActive      bool      `json:"isActive"`
+	ValidFrom     string    `json:"validFrom"`
+	ValidTo       *string   `json:"validTo"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type ArchiveHabitCompletion struct {
+	ID        string    `json:"id"`
+	HabitID   string    `json:"habitId"`
+	Date      string    `json:"date"`
+	Notes     *string   `json:"notes"`
+	Rating    *int      `json:"rating"`
+	Time      *string   `json:"time"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ArchiveJournalEntry struct {
+	ID          string          `json:"id"`
+	Description string          `json:"description"`
+	Mood        *int            `json:"mood"`
+	Date        string          `json:"date"`
+	Tags        []string        `json:"tags"`
+	ContentType string          `json:"contentType"`
+	Metadata    json.RawMessage `json:"metadata"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+}
+
+type ArchiveNoteFolder struct {
+	ID        string    `json:"id"`
+	ParentID  *string   `json:"parentId"`
+	Name      string    `json:"name"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type ArchiveNote struct {
+	ID          string     `json:"id"`
+	FolderID    *string    `json:"folderId"`
+	Title       string     `json:"title"`
+	Content     *string    `json:"content"`
+	ContentType string     `json:"contentType"`
+	Tags        []string   `json:"tags"`
+	Pinned      bool       `json:"pinned"`
+	Position    int        `json:"position"`
+	ArchivedAt  *time.Time `json:"archivedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// ArchiveLink — связь между записями архива (см. EntityLink)
+type ArchiveLink struct {
+	SourceType string    `json:"sourceType"`
+	SourceID   string    `json:"sourceId"`
+	TargetType string    `json:"targetType"`
+	TargetID   string    `json:"targetId"`
+	Origin     string    `json:"origin"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// WorkspaceImportResult — итог импорта. AlreadyImported — этот же архив уже был импортирован
+// в воркспейс раньше, данные не менялись, Imported и Warnings — от того импорта.
+type WorkspaceImportResult struct {
+	Workspace       *Workspace     `json:"workspace"`
+	Imported        map[string]int `json:"imported"`
+	Warnings        []string       `json:"warnings"`
+	AlreadyImported bool           `json:"alreadyImported"`
+}
@@ -0,0 +1,190 @@
+package attachments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrOwnerNotFound = errors.New("attachment owner not found")
+	ErrQuotaExceeded = errors.New("workspace storage quota exceeded")
+	ErrUnknownOwner  = errors.New("unknown attachment owner type")
+)
+
+// owners — колонка attachments, таблица владельца по его типу и условие «владелец не в корзине».
+// Вложения владельца из корзины остаются в хранилище до его окончательного удаления.
+var owners = map[string]struct{ column, table, live string }{
+	model.AttachmentOwnerNote:            {"note_id", "notes", "deleted_at IS NULL"},
+	model.AttachmentOwnerJournal:         {"journal_entry_id", "journal_entries", "deleted_at IS NULL"},
+	model.AttachmentOwnerHabitCompletion: {"habit_completion_id", "habit_completions", "habit_id IN (SELECT id FROM habits WHERE deleted_at IS NULL)"},
+}
+
+const attachmentColumns = `id, workspace_id,
+	CASE WHEN note_id IS NOT NULL THEN 'note' WHEN journal_entry_id IS NOT NULL THEN 'journal' ELSE 'habit_completion' END,
+	COALESCE(note_id, journal_entry_id, habit_completion_id), filename, content_type, size_bytes, sha256, uploaded_by, storage_key, created_at`
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// OwnerExists — есть ли владелец в воркспейсе (не в корзине)
+func (r *Repository) OwnerExists(ctx context.Context, workspaceID uuid.UUID, ownerType string, ownerID uuid.UUID) (bool, error) {
+	o, ok := owners[ownerType]
+	if !ok {
+		return false, ErrUnknownOwner
+	}
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM `+o.table+` WHERE id = $1 AND workspace_id = $2 AND `+o.live+`)`, ownerID, workspaceID).Scan(&exists)
+	return exists, err
+}
+
+// Usage — число вложений и занятые байты воркспейса
+func (r *Repository) Usage(ctx context.Context, workspaceID uuid.UUID) (count int, used int64, err error) {
+	err = r.db.QueryRowContext(ctx, `SELECT COUNT(*), COALESCE(SUM(size_bytes), 0) FROM attachments WHERE workspace_id = $1`,
+		workspaceID).Scan(&count, &used)
+	return count, used, err
+}
+
+// Create сохраняет метаданные загруженного файла. Квота проверяется под блокировкой воркспейса, поэтому
+// параллельные загрузки не превысят её вместе. ErrOwnerNotFound — владельца нет в воркспейсе, ErrQuotaExceeded — нет места.
+func (r *Repository) Create(ctx context.Context, a *model.Attachment, quota int64) error {
+	o, ok := owners[a.OwnerType]
+	if !ok {
+		return ErrUnknownOwner
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtextextended('attachments:' || $1::text, 0))`, a.WorkspaceID); err != nil {
+		return fmt.Errorf("lock workspace attachments: %w", err)
+	}
+	var used int64
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(size_bytes), 0) FROM attachments WHERE workspace_id = $1`,
+		a.WorkspaceID).Scan(&used); err != nil {
+		return fmt.Errorf("attachments usage: %w", err)
+	}
+	if used+a.Size > quota {
+		return ErrQuotaExceeded
+	}
+	var createdAt time.Time
+	err = tx.QueryRowContext(ctx, `INSERT INTO attachments (id, workspace_id, `+o.column+`, uploaded_by, storage_key, filename, content_type, size_bytes, sha256)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9
+		WHERE EXISTS (SELECT 1 FROM `+o.table+` WHERE id = $3 AND workspace_id = $2 AND `+o.live+`)
+		RETURNING created_at`,
+		a.ID, a.WorkspaceID, a.OwnerID, a.UploadedBy, a.StorageKey, a.Filename, a.ContentType, a.Size, a.SHA256,
+	).Scan(&createdAt)
+	if err == sql.ErrNoRows {
+		return ErrOwnerNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("create attachment: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	a.CreatedAt = createdAt.Format(time.RFC3339)
+	return nil
+}
+
+// List — вложения владельца в порядке загрузки
+func (r *Repository) List(ctx context.Context, workspaceID uuid.UUID, ownerType string, ownerID uuid.UUID) ([]model.Attachment, error) {
+	o, ok := owners[ownerType]
+	if !ok {
+		return nil, ErrUnknownOwner
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT `+attachmentColumns+` FROM attachments
+		WHERE workspace_id = $1 AND `+o.column+` = $2 ORDER BY created_at, id`, workspaceID, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("list attachments: %w", err)
+	}
+	defer rows.Close()
+	list := make([]model.Attachment, 0)
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *a)
+	}
+	return list, rows.Err()
+}
+
+func (r *Repository) Get(ctx context.Context, id, workspaceID uuid.UUID) (*model.Attachment, error) {
+	a, err := scanAttachment(r.db.QueryRowContext(ctx, `SELECT `+attachmentColumns+` FROM attachments WHERE id = $1 AND workspace_id = $2`,
+		id, workspaceID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+// Delete удаляет метаданные; файл попадает в attachment_purge_queue (триггер). sql.ErrNoRows — вложения нет.
+func (r *Repository) Delete(ctx context.Context, id, workspaceID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM attachments WHERE id = $1 AND workspace_id = $2`, id, workspaceID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// PurgeQueue — до limit ключей файлов, ожидающих удаления из хранилища; сначала давние и с меньшим числом попыток
+func (r *Repository) PurgeQueue(ctx context.Context, limit int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT storage_key FROM attachment_purge_queue ORDER BY attempts, queued_at LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("attachment purge queue: %w", err)
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// Purged убирает ключ из очереди после удаления файла
+func (r *Repository) Purged(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM attachment_purge_queue WHERE storage_key = $1`, key)
+	return err
+}
+
+// PurgeFailed отмечает неудачную попытку: ключ уходит в конец очереди
+func (r *Repository) PurgeFailed(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE attachment_purge_queue SET attempts = attempts + 1 WHERE storage_key = $1`, key)
+	return err
+}
+
+func scanAttachment(row interface{ Scan(...interface{}) error }) (*model.Attachment, error) {
+	var a model.Attachment
+	var uploadedBy sql.NullString
+	var createdAt time.Time
+	if err := row.Scan(&a.ID, &a.WorkspaceID, &a.OwnerType, &a.OwnerID, &a.Filename, &a.ContentType, &a.Size, &a.SHA256,
+		&uploadedBy, &a.StorageKey, &createdAt); err != nil {
+		return nil, err
+	}
+	if uploadedBy.Valid {
+		a.UploadedBy = &uploadedBy.String
+	}
+	a.CreatedAt = createdAt.Format(time.RFC3339)
+	return &a, nil
+}
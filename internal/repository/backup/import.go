@@ -0,0 +1,73 @@
+package backup
+
+// Вставка записей архива: $1 — воркспейс, $2 — JSON-массив объектов model.Archive* с уже выданными
+// новыми ID, $3 — автор записей (где он есть). Время приходит с зоной и сохраняется в UTC.
+const (
+	importCurrencies = `
+		INSERT INTO currencies (id, workspace_id, code, name, symbol, created_at, updated_at)
+		SELECT x.id, $1, x.code, x.name, x.symbol, x."createdAt"` + utc + `, x."updatedAt"` + utc + `
+		FROM json_to_recordset($2::json) AS x(id uuid, code text, name text, symbol text,
+			"createdAt" timestamptz, "updatedAt" timestamptz)`
+
+	importCounterparties = `
+		INSERT INTO counterparties (id, workspace_id, name, type, email, phone, comment, created_at, updated_at)
+		SELECT x.id, $1, x.name, x.type, x.email, x.phone, x.comment, x."createdAt"` + utc + `, x."updatedAt"` + utc + `
+		FROM json_to_recordset($2::json) AS x(id uuid, name text, type text, email text, phone text, comment text,
+			"createdAt" timestamptz, "updatedAt" timestamptz)`
+
+	importHabits = `
+		INSERT INTO habits (id, workspace_id, user_id, title, description, color, icon, target_days, daily_goal,
+			preferred_time, category, schedule_type, recurring_days, one_time_date, is_active, created_at, updated_at)
+		SELECT x.id, $1, $3, x.title, x.description, x.color, x.icon, x."targetDays", x."dailyGoal",
+			x."preferredTime", x.category, x."scheduleType", x."recurringDays", x."oneTimeDate", x."isActive",
+			x."createdAt"` + utc + `, x."updatedAt"` + utc + `
+		FROM json_to_recordset($2::json) AS x(id uuid, title text, description text, color text, icon text,
+			"targetDays" int, "dailyGoal" int, "preferredTime" time, category text, "scheduleType" text,
+			"recurringDays" int[], "oneTimeDate" date, "isActive" boolean, "createdAt" timestamptz, "updatedAt" timestamptz)`
+
+	importHabitVersions = `
+		INSERT INTO habit_versions (id, habit_id, workspace_id, user_id, title, description, color, icon, target_days,
+			daily_goal, preferred_time, category, schedule_type, recurring_days, one_time_date, is_active,
+			valid_from, valid_to, created_at)
+		SELECT x.id, x."habitId", $1, $3, x.title, x.description, x.color, x.icon, x."targetDays",
+			x."dailyGoal", x."preferredTime", x.category, x."scheduleType", x."recurringDays", x."oneTimeDate", x."isActive",
+			x."validFrom", x."validTo", x."createdAt"` + utc + `
+		FROM json_to_recordset($2::json) AS x(id uuid, "habitId" uuid, title text, description text, color text, icon text,
+			"targetDays" int, "dailyGoal" int, "preferredTime" time, category text, "scheduleType" text,
+			"recurringDays" int[], "oneTimeDate" date, "isActive" boolean, "validFrom" date, "validTo" date,
+			"createdAt" timestamptz)`
+
+	importHabitCompletions = `
+		INSERT INTO habit_completions (id, habit_id, workspace_id, user_id, date, notes, rating, time, created_at)
+		SELECT x.id, x."habitId", $1, $3, x.date, x.notes, x.rating, x.time, x."createdAt"` + utc + `
+		FROM json_to_recordset($2::json) AS x(id uuid, "habitId" uuid, date date, notes text, rating int, time time,
+			"createdAt" timestamptz)`
+
+	importJournalEntries = `
+		INSERT INTO journal_entries (id, workspace_id, user_id, description, mood, date, tags, content_type, metadata,
+			created_at, updated_at)
+		SELECT x.id, $1, $3, x.description, x.mood, x.date, COALESCE(x.tags, '{}'), x."contentType",
+			COALESCE(x.metadata, '{}'), x."createdAt"` + utc + `, x."updatedAt"` + utc + `
+		FROM json_to_recordset($2::json) AS x(id uuid, description text, mood int, date date, tags text[],
+			"contentType" text, metadata jsonb, "createdAt" timestamptz, "updatedAt" timestamptz)`
+
+	importNoteFolders = `
+		INSERT INTO note_folders (id, workspace_id, parent_id, name, position, created_at, updated_at)
+		SELECT x.id, $1, x."parentId", x.name, x.position, x."createdAt"` + utc + `, x."updatedAt"` + utc + `
+		FROM json_to_recordset($2::json) AS x(id uuid, "parentId" uuid, name text, position int,
+			"createdAt" timestamptz, "updatedAt" timestamptz)`
+
+	importNotes = `
+		INSERT INTO notes (id, workspace_id, user_id, folder_id, title, content, content_type, tags, pinned, position,
+			archived_at, created_at, updated_at)
+		SELECT x.id, $1, $3, x."folderId", x.title, x.content, x."contentType", COALESCE(x.tags, '{}'), x.pinned, x.position,
+			x."archivedAt"` + utc + `, x."createdAt"` + utc + `, x."updatedAt"` + utc + `
+		FROM json_to_recordset($2::json) AS x(id uuid, "folderId" uuid, title text, content text, "contentType" text,
+			tags text[], pinned boolean, position int, "archivedAt" timestamptz, "createdAt" timestamptz, "updatedAt" timestamptz)`
+
+	importLinks = `
+		INSERT INTO entity_links (workspace_id, source_type, source_id, target_type, target_id, origin, created_by, created_at)
+		SELECT $1, x."sourceType", x."sourceId", x."targetType", x."targetId", x.origin, $3, x."createdAt"` + utc + `
+		FROM json_to_recordset($2::json) AS x("sourceType" text, "sourceId" uuid, "targetType" text, "targetId" uuid,
+			origin text, "createdAt" timestamptz)`
+)
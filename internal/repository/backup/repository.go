@@ -0,0 +1,291 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	ErrWorkspaceNotEmpty = errors.New("workspace is not empty")
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Колонки TIMESTAMP хранят UTC; AT TIME ZONE 'UTC' превращает их в timestamptz, и в JSON время попадает с зоной
+const utc = ` AT TIME ZONE 'UTC'`
+
+// Содержимое корзины воркспейса $1 в архив не попадает — вместе с версиями, выполнениями и связями
+const (
+	trashedHabits = `SELECT id FROM habits WHERE workspace_id = $1 AND deleted_at IS NOT NULL`
+	trashed       = `SELECT 'habit' AS type, id FROM habits WHERE workspace_id = $1 AND deleted_at IS NOT NULL
+		UNION ALL SELECT 'note', id FROM notes WHERE workspace_id = $1 AND deleted_at IS NOT NULL
+		UNION ALL SELECT 'journal', id FROM journal_entries WHERE workspace_id = $1 AND deleted_at IS NOT NULL`
+)
+
+// Запросы выгрузки: строки с ключами как в model.Archive*, $1 — воркспейс
+var exportQueries = []struct {
+	name, query, orderBy string
+}{
+	{"modules", `SELECT m.code, wm.status, wm.settings FROM workspace_modules wm JOIN modules m ON m.id = wm.module_id
+		WHERE wm.workspace_id = $1`, `code`},
+	{"currencies", `SELECT id, code, name, symbol, created_at` + utc + ` AS "createdAt", updated_at` + utc + ` AS "updatedAt"
+		FROM currencies WHERE workspace_id = $1`, `code`},
+	{"counterparties", `SELECT id, name, type, email, phone, comment, created_at` + utc + ` AS "createdAt", updated_at` + utc + ` AS "updatedAt"
+		FROM counterparties WHERE workspace_id = $1`, `"createdAt", id`},
+	{"habits", `SELECT id, title, description, color, icon, target_days AS "targetDays", daily_goal AS "dailyGoal",
+		preferred_time AS "preferredTime", category, schedule_type AS "scheduleType", recurring_days AS "recurringDays",
+		one_time_date AS "oneTimeDate", is_active AS "isActive", created_at` + utc + ` AS "createdAt", updated_at` + utc + ` AS "updatedAt"
+		FROM habits WHERE workspace_id = $1 AND deleted_at IS NULL`, `"createdAt", id`},
+	{"habitVersions", `SELECT id, habit_id AS "habitId", title, description, color, icon, target_days AS "targetDays",
+		daily_goal AS "dailyGoal", preferred_time AS "preferredTime", category, schedule_type AS "scheduleType",
+		recurring_days AS "recurringDays", one_time_date AS "oneTimeDate", is_active AS "isActive",
+		valid_from AS "validFrom", valid_to AS "validTo", created_at` + utc + ` AS "createdAt"
+		FROM habit_versions WHERE workspace_id = $1 AND habit_id NOT IN (` + trashedHabits + `)`, `"habitId", "validFrom"`},
+	{"habitCompletions", `SELECT c.id, c.habit_id AS "habitId", c.date, c.notes, c.rating, c.time, c.created_at` + utc + ` AS "createdAt"
+		FROM habit_completions c JOIN habits h ON h.id = c.habit_id WHERE h.workspace_id = $1 AND h.deleted_at IS NULL`, `date, "habitId"`},
+	{"journalEntries", `SELECT id, description, mood, date, tags, content_type AS "contentType", metadata,
+		created_at` + utc + ` AS "createdAt", updated_at` + utc + ` AS "updatedAt"
+		FROM journal_entries WHERE workspace_id = $1 AND deleted_at IS NULL`, `date, "createdAt", id`},
+	{"noteFolders", `SELECT id, parent_id AS "parentId", name, position, created_at` + utc + ` AS "createdAt", updated_at` + utc + ` AS "updatedAt"
+		FROM note_folders WHERE workspace_id = $1`, `"createdAt", id`},
+	{"notes", `SELECT id, folder_id AS "folderId", title, content, content_type AS "contentType", tags, pinned, position,
+		archived_at` + utc + ` AS "archivedAt", created_at` + utc + ` AS "createdAt", updated_at` + utc + ` AS "updatedAt"
+		FROM notes WHERE workspace_id = $1 AND deleted_at IS NULL`, `"createdAt", id`},
+	{"links", `SELECT source_type AS "sourceType", source_id AS "sourceId", target_type AS "targetType", target_id AS "targetId",
+		origin, created_at` + utc + ` AS "createdAt"
+		FROM entity_links WHERE workspace_id = $1 AND NOT EXISTS (SELECT 1 FROM (` + trashed + `) t
+			WHERE (t.type = source_type AND t.id = source_id) OR (t.type = target_type AND t.id = target_id))`, `"createdAt", "sourceId", "targetId"`},
+}
+
+// Export читает содержимое воркспейса в одной транзакции REPEATABLE READ — архив согласован.
+// Описание воркспейса заполняет вызывающий. nil — воркспейса нет.
+func (r *Repository) Export(ctx context.Context, workspaceID uuid.UUID) (*model.WorkspaceArchive, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	a := &model.WorkspaceArchive{}
+	err = tx.QueryRowContext(ctx, `SELECT name, description, color FROM workspaces WHERE id = $1`, workspaceID).
+		Scan(&a.Workspace.Name, &a.Workspace.Description, &a.Workspace.Color)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get workspace: %w", err)
+	}
+
+	targets := map[string]any{
+		"modules": &a.Modules, "currencies": &a.Currencies, "counterparties": &a.Counterparties,
+		"habits": &a.Habits, "habitVersions": &a.HabitVersions, "habitCompletions": &a.HabitCompletions,
+		"journalEntries": &a.JournalEntries, "noteFolders": &a.NoteFolders, "notes": &a.Notes, "links": &a.Links,
+	}
+	for _, q := range exportQueries {
+		var raw []byte
+		err := tx.QueryRowContext(ctx,
+			`SELECT COALESCE(json_agg(t ORDER BY `+q.orderBy+`), '[]') FROM (`+q.query+`) t`, workspaceID).Scan(&raw)
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", q.name, err)
+		}
+		if err := json.Unmarshal(raw, targets[q.name]); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", q.name, err)
+		}
+	}
+	return a, nil
+}
+
+// ImportModule — модуль архива, сопоставленный с модулем этого сервера
+type ImportModule struct {
+	ModuleID uuid.UUID
+	Status   string
+	Settings json.RawMessage
+}
+
+// ImportParams — проверенный архив с уже выданными новыми ID
+type ImportParams struct {
+	// WorkspaceID — воркспейс, в который идёт импорт; при Create он создаётся с этим ID
+	WorkspaceID uuid.UUID
+	Create      bool
+	OwnerID     uuid.UUID // автор всех импортированных записей
+	ImporterID  uuid.UUID
+	Checksum    string
+	Archive     *model.WorkspaceArchive
+	Modules     []ImportModule
+	Warnings    []string
+}
+
+// Import записывает архив одной транзакцией. Если этот архив (по Checksum) уже импортирован — в этот воркспейс
+// или, при Create, в любой воркспейс владельца, — ничего не меняет и возвращает прежний результат.
+// Существующий воркспейс должен быть пустым: ErrWorkspaceNotEmpty.
+func (r *Repository) Import(ctx context.Context, p ImportParams) (workspaceID uuid.UUID, res *model.WorkspaceImportResult, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if p.Create {
+		// Повторная отправка того же архива не должна создать второй воркспейс
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, p.OwnerID.String()+p.Checksum); err != nil {
+			return uuid.Nil, nil, fmt.Errorf("lock import: %w", err)
+		}
+		prevID, prev, err := previousImport(ctx, tx, `SELECT i.workspace_id, i.stats FROM workspace_imports i
+			JOIN workspaces w ON w.id = i.workspace_id
+			WHERE w.owner_id = $1 AND i.checksum = $2 ORDER BY i.created_at LIMIT 1`, p.OwnerID, p.Checksum)
+		if err != nil || prev != nil {
+			return prevID, prev, err
+		}
+		ws := p.Archive.Workspace
+		if _, err := tx.ExecContext(ctx, `INSERT INTO workspaces (id, name, description, color, owner_id) VALUES ($1, $2, $3, $4, $5)`,
+			p.WorkspaceID, ws.Name, ws.Description, ws.Color, p.OwnerID); err != nil {
+			return uuid.Nil, nil, fmt.Errorf("create workspace: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO user_workspaces (user_id, workspace_id, role) VALUES ($1, $2, 'OWNER')`,
+			p.OwnerID, p.WorkspaceID); err != nil {
+			return uuid.Nil, nil, fmt.Errorf("add owner: %w", err)
+		}
+	} else {
+		var locked bool
+		err := tx.QueryRowContext(ctx, `SELECT true FROM workspaces WHERE id = $1 FOR UPDATE`, p.WorkspaceID).Scan(&locked)
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, nil, ErrWorkspaceNotFound
+		}
+		if err != nil {
+			return uuid.Nil, nil, fmt.Errorf("lock workspace: %w", err)
+		}
+		prevID, prev, err := previousImport(ctx, tx, `SELECT workspace_id, stats FROM workspace_imports
+			WHERE workspace_id = $1 AND checksum = $2`, p.WorkspaceID, p.Checksum)
+		if err != nil || prev != nil {
+			return prevID, prev, err
+		}
+		var hasData bool
+		if err := tx.QueryRowContext(ctx, `SELECT
+			EXISTS (SELECT 1 FROM habits WHERE workspace_id = $1) OR
+			EXISTS (SELECT 1 FROM journal_entries WHERE workspace_id = $1) OR
+			EXISTS (SELECT 1 FROM notes WHERE workspace_id = $1) OR
+			EXISTS (SELECT 1 FROM note_folders WHERE workspace_id = $1) OR
+			EXISTS (SELECT 1 FROM currencies WHERE workspace_id = $1) OR
+			EXISTS (SELECT 1 FROM counterparties WHERE workspace_id = $1)`, p.WorkspaceID).Scan(&hasData); err != nil {
+			return uuid.Nil, nil, fmt.Errorf("check workspace is empty: %w", err)
+		}
+		if hasData {
+			return uuid.Nil, nil, ErrWorkspaceNotEmpty
+		}
+	}
+
+	for _, m := range p.Modules {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO workspace_modules (workspace_id, module_id, status, settings, activated_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			ON CONFLICT (workspace_id, module_id) DO UPDATE SET status = EXCLUDED.status, settings = EXCLUDED.settings
+		`, p.WorkspaceID, m.ModuleID, m.Status, nullJSON(m.Settings)); err != nil {
+			return uuid.Nil, nil, fmt.Errorf("import module: %w", err)
+		}
+	}
+
+	a := p.Archive
+	imported := make(map[string]int)
+	for _, step := range []struct {
+		name  string
+		query string
+		rows  any
+		count int
+		owner bool // запрос принимает автора записей
+	}{
+		{"currencies", importCurrencies, a.Currencies, len(a.Currencies), false},
+		{"counterparties", importCounterparties, a.Counterparties, len(a.Counterparties), false},
+		{"habits", importHabits, a.Habits, len(a.Habits), true},
+		{"habitVersions", importHabitVersions, a.HabitVersions, len(a.HabitVersions), true},
+		{"habitCompletions", importHabitCompletions, a.HabitCompletions, len(a.HabitCompletions), true},
+		{"journalEntries", importJournalEntries, a.JournalEntries, len(a.JournalEntries), true},
+		{"noteFolders", importNoteFolders, a.NoteFolders, len(a.NoteFolders), false},
+		{"notes", importNotes, a.Notes, len(a.Notes), true},
+		{"links", importLinks, a.Links, len(a.Links), true},
+	} {
+		imported[step.name] = 0
+		if step.count == 0 {
+			continue
+		}
+		rows, err := json.Marshal(step.rows)
+		if err != nil {
+			return uuid.Nil, nil, err
+		}
+		args := []any{p.WorkspaceID, string(rows)}
+		if step.owner {
+			args = append(args, p.OwnerID)
+		}
+		res, err := tx.ExecContext(ctx, step.query, args...)
+		if err != nil {
+			return uuid.Nil, nil, fmt.Errorf("import %s: %w", step.name, err)
+		}
+		n, _ := res.RowsAffected()
+		imported[step.name] = int(n)
+	}
+	// У каждой записи дневника есть ревизия 1 — как при создании через API
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO journal_entry_revisions
+			(entry_id, workspace_id, revision, author_id, description, mood, date, tags, content_type, metadata, created_at)
+		SELECT id, workspace_id, 1, user_id, description, mood, date, tags, content_type, metadata, updated_at
+		FROM journal_entries WHERE workspace_id = $1
+	`, p.WorkspaceID); err != nil {
+		return uuid.Nil, nil, fmt.Errorf("import journal revisions: %w", err)
+	}
+
+	res = &model.WorkspaceImportResult{Imported: imported, Warnings: p.Warnings}
+	if res.Warnings == nil {
+		res.Warnings = []string{}
+	}
+	stats, err := json.Marshal(res)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO workspace_imports (workspace_id, user_id, checksum, format_version, stats) VALUES ($1, $2, $3, $4, $5)
+	`, p.WorkspaceID, p.ImporterID, p.Checksum, a.Version, stats); err != nil {
+		return uuid.Nil, nil, fmt.Errorf("record import: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, nil, fmt.Errorf("commit: %w", err)
+	}
+	return p.WorkspaceID, res, nil
+}
+
+func previousImport(ctx context.Context, tx *sql.Tx, query string, args ...any) (uuid.UUID, *model.WorkspaceImportResult, error) {
+	var wsID uuid.UUID
+	var stats []byte
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&wsID, &stats)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, nil, nil
+	}
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("find previous import: %w", err)
+	}
+	var res model.WorkspaceImportResult
+	if err := json.Unmarshal(stats, &res); err != nil {
+		return uuid.Nil, nil, fmt.Errorf("decode import stats: %w", err)
+	}
+	res.AlreadyImported = true
+	return wsID, &res, nil
+}
+
+func nullJSON(v json.RawMessage) any {
+	if len(v) == 0 || string(v) == "null" {
+		return nil
+	}
+	return []byte(v)
+}
@@ -0,0 +1,147 @@
+package calendarfeed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Owner — владелец ленты, найденной по токену
+type Owner struct {
+	UserID   uuid.UUID
+	Timezone string // IANA-зона из профиля, может быть пустой
+}
+
+// Habit — привычка в ленте. Time — время суток HH:MM:SS или пусто (без предпочтительного времени).
+type Habit struct {
+	ID            string
+	Title         string
+	Description   string
+	Category      string
+	Time          string
+	ScheduleType  string
+	RecurringDays []int
+	OneTimeDate   *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Get — состояние ленты пользователя; nil — ленты нет
+func (r *Repository) Get(ctx context.Context, userID uuid.UUID) (*model.CalendarFeed, error) {
+	var createdAt time.Time
+	var lastAccessedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `SELECT created_at, last_accessed_at FROM calendar_feeds WHERE user_id = $1`, userID).
+		Scan(&createdAt, &lastAccessedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get calendar feed: %w", err)
+	}
+	return feed(createdAt, lastAccessedAt), nil
+}
+
+// Save создаёт ленту или заменяет её токен: прежняя ссылка перестаёт работать
+func (r *Repository) Save(ctx context.Context, userID uuid.UUID, tokenHash string) (*model.CalendarFeed, error) {
+	var createdAt time.Time
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO calendar_feeds (user_id, token_hash, created_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = EXCLUDED.created_at,
+			last_accessed_at = NULL
+		RETURNING created_at`, userID, tokenHash).Scan(&createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("save calendar feed: %w", err)
+	}
+	return feed(createdAt, sql.NullTime{}), nil
+}
+
+// Delete отключает ленту; sql.ErrNoRows — её не было
+func (r *Repository) Delete(ctx context.Context, userID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM calendar_feeds WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("delete calendar feed: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Open находит ленту активного пользователя по хэшу токена и отмечает обращение; nil — нет такой
+func (r *Repository) Open(ctx context.Context, tokenHash string) (*Owner, error) {
+	var o Owner
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE calendar_feeds f SET last_accessed_at = NOW()
+		FROM users u
+		WHERE f.token_hash = $1 AND u.id = f.user_id AND u.status = 'ACTIVE'
+		RETURNING f.user_id, COALESCE(u.timezone, '')`, tokenHash).Scan(&o.UserID, &o.Timezone)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open calendar feed: %w", err)
+	}
+	return &o, nil
+}
+
+// ListHabits — активные привычки пользователя в воркспейсах, к которым у него есть доступ
+func (r *Repository) ListHabits(ctx context.Context, userID uuid.UUID) ([]Habit, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT h.id, h.title, COALESCE(h.description, ''), COALESCE(h.category, ''),
+			COALESCE(to_char(h.preferred_time, 'HH24:MI:SS'), ''), h.schedule_type, h.recurring_days, h.one_time_date,
+			h.created_at, h.updated_at
+		FROM habits h
+		JOIN workspaces w ON w.id = h.workspace_id
+		WHERE h.user_id = $1 AND h.is_active AND h.deleted_at IS NULL
+			AND (w.owner_id = $1 OR EXISTS (
+				SELECT 1 FROM user_workspaces uw WHERE uw.workspace_id = w.id AND uw.user_id = $1))
+		ORDER BY h.created_at, h.id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list feed habits: %w", err)
+	}
+	defer rows.Close()
+	var list []Habit
+	for rows.Next() {
+		var h Habit
+		var days pq.Int32Array
+		var oneTimeDate sql.NullTime
+		if err := rows.Scan(&h.ID, &h.Title, &h.Description, &h.Category, &h.Time, &h.ScheduleType, &days, &oneTimeDate,
+			&h.CreatedAt, &h.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan feed habit: %w", err)
+		}
+		for _, d := range days {
+			h.RecurringDays = append(h.RecurringDays, int(d))
+		}
+		if oneTimeDate.Valid {
+			h.OneTimeDate = &oneTimeDate.Time
+		}
+		list = append(list, h)
+	}
+	return list, rows.Err()
+}
+
+func feed(createdAt time.Time, lastAccessedAt sql.NullTime) *model.CalendarFeed {
+	f := &model.CalendarFeed{Enabled: true}
+	s := createdAt.Format(time.RFC3339)
+	f.CreatedAt = &s
+	if lastAccessedAt.Valid {
+		l := lastAccessedAt.Time.Format(time.RFC3339)
+		f.LastAccessedAt = &l
+	}
+	return f
+}
@@ -0,0 +1,53 @@
+package export
+
+// Dataset — набор строк пользователя для выгрузки. Query выбирает строки по $1 = id пользователя,
+// Table — основная таблица набора (по ней тест проверяет, что выгрузка покрывает все таблицы пользователя).
+type Dataset struct {
+	Name    string
+	Table   string
+	Query   string
+	OrderBy string
+}
+
+// Наборы, по которым строится Markdown
+var (
+	NotesDataset   = Dataset{"notes", "notes", `SELECT * FROM notes WHERE user_id = $1`, "created_at, id"}
+	JournalDataset = Dataset{"journal_entries", "journal_entries", `SELECT * FROM journal_entries WHERE user_id = $1`, "date, created_at, id"}
+)
+
+// Datasets — всё, что выгружается о пользователе, в порядке файлов архива. Секреты (хеши паролей и токенов,
+// ключи файлов в хранилище) в выгрузку не попадают. При добавлении таблицы с колонкой пользователя — дополнить.
+var Datasets = []Dataset{
+	{"profile", "users", `SELECT id, email, name, role, avatar_url, locale, timezone, status, created_at, updated_at
+		FROM users WHERE id = $1`, "created_at"},
+	{"preferences", "user_preferences", `SELECT * FROM user_preferences WHERE user_id = $1`, "created_at"},
+	{"workspaces", "workspaces", `SELECT w.*, w.owner_id = $1 AS is_owner FROM workspaces w
+		WHERE w.owner_id = $1 OR w.id IN (SELECT workspace_id FROM user_workspaces WHERE user_id = $1)`, "created_at, id"},
+	{"workspace_memberships", "user_workspaces", `SELECT * FROM user_workspaces WHERE user_id = $1`, "created_at, id"},
+	{"licenses", "user_module_licenses", `SELECT l.*, m.code AS module_code FROM user_module_licenses l
+		JOIN modules m ON m.id = l.module_id WHERE l.user_id = $1`, "created_at, id"},
+	{"habits", "habits", `SELECT * FROM habits WHERE user_id = $1`, "created_at, id"},
+	{"habit_versions", "habit_versions", `SELECT * FROM habit_versions WHERE user_id = $1`, "habit_id, valid_from"},
+	{"habit_completions", "habit_completions", `SELECT * FROM habit_completions WHERE user_id = $1`, "date, created_at, id"},
+	{"habit_history", "habit_history", `SELECT * FROM habit_history WHERE user_id = $1`, "created_at, id"},
+	JournalDataset,
+	{"journal_entry_revisions", "journal_entry_revisions", `SELECT r.* FROM journal_entry_revisions r
+		WHERE r.author_id = $1 OR r.entry_id IN (SELECT id FROM journal_entries WHERE user_id = $1)`, "entry_id, revision"},
+	NotesDataset,
+	{"note_shares", "note_shares", `SELECT * FROM note_shares WHERE user_id = $1 OR created_by = $1`, "created_at, id"},
+	{"note_links", "note_links", `SELECT id, note_id, password_hash IS NOT NULL AS has_password, expires_at, revoked_at, created_by, created_at
+		FROM note_links WHERE created_by = $1`, "created_at, id"},
+	{"entity_links", "entity_links", `SELECT * FROM entity_links WHERE created_by = $1`, "created_at, id"},
+	{"attachments", "attachments", `SELECT id, workspace_id, note_id, journal_entry_id, habit_completion_id, uploaded_by,
+		filename, content_type, size_bytes, sha256, created_at FROM attachments WHERE uploaded_by = $1`, "created_at, id"},
+	{"activities", "activities", `SELECT * FROM activities WHERE user_id = $1`, "created_at, id"},
+	{"request_log", "request_logs", `SELECT timestamp, method, path, route, status_code, duration_ms, bytes_out, client_ip,
+		user_agent, workspace_id, request_id, error FROM request_logs WHERE user_id = $1`, "timestamp"},
+	{"email_change_requests", "email_change_requests", `SELECT new_email, expires_at, created_at
+		FROM email_change_requests WHERE user_id = $1`, "created_at"},
+	{"calendar_feed", "calendar_feeds", `SELECT created_at, last_accessed_at FROM calendar_feeds WHERE user_id = $1`, "created_at"},
+	{"workspace_imports", "workspace_imports", `SELECT workspace_id, format_version, stats, created_at
+		FROM workspace_imports WHERE user_id = $1`, "created_at"},
+	{"data_exports", "data_exports", `SELECT id, status, size_bytes, error, created_at, started_at, finished_at, expires_at
+		FROM data_exports WHERE user_id = $1`, "created_at"},
+}
@@ -0,0 +1,283 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrExportInProgress = errors.New("data export is already in progress")
+
+// internalColumns — служебные колонки (поисковые индексы), которые не нужны в выгрузке
+var internalColumns = map[string]bool{"search_vector": true}
+
+const exportColumns = `id, user_id, status, size_bytes, error, created_at, started_at, finished_at, expires_at, COALESCE(storage_key, '')`
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create ставит выгрузку в очередь. ErrExportInProgress — у пользователя уже есть незавершённая.
+func (r *Repository) Create(ctx context.Context, userID uuid.UUID) (*model.DataExport, error) {
+	e, err := scanExport(r.db.QueryRowContext(ctx,
+		`INSERT INTO data_exports (user_id) VALUES ($1) RETURNING `+exportColumns, userID))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, ErrExportInProgress
+		}
+		return nil, fmt.Errorf("create data export: %w", err)
+	}
+	return e, nil
+}
+
+// List — последние выгрузки пользователя, новые первыми
+func (r *Repository) List(ctx context.Context, userID uuid.UUID, limit int) ([]model.DataExport, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+exportColumns+` FROM data_exports
+		WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list data exports: %w", err)
+	}
+	defer rows.Close()
+	list := make([]model.DataExport, 0)
+	for rows.Next() {
+		e, err := scanExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *e)
+	}
+	return list, rows.Err()
+}
+
+// Get — nil, если выгрузки нет или она чужая
+func (r *Repository) Get(ctx context.Context, id, userID uuid.UUID) (*model.DataExport, error) {
+	e, err := scanExport(r.db.QueryRowContext(ctx,
+		`SELECT `+exportColumns+` FROM data_exports WHERE id = $1 AND user_id = $2`, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return e, err
+}
+
+// Claim берёт в работу самую старую ожидающую выгрузку. Выгрузка, которая выполняется дольше staleAfter
+// (процесс упал посреди сборки), берётся заново. nil — очередь пуста.
+func (r *Repository) Claim(ctx context.Context, staleAfter time.Duration) (*model.DataExport, error) {
+	e, err := scanExport(r.db.QueryRowContext(ctx, `
+		UPDATE data_exports SET status = 'running', started_at = NOW() AT TIME ZONE 'UTC'
+		WHERE id = (
+			SELECT id FROM data_exports
+			WHERE status = 'pending'
+			   OR (status = 'running' AND started_at < NOW() AT TIME ZONE 'UTC' - make_interval(secs => $1))
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING `+exportColumns, staleAfter.Seconds()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("claim data export: %w", err)
+	}
+	return e, nil
+}
+
+// Finish отмечает выгрузку готовой: архив лежит в хранилище под key до expiresAt
+func (r *Repository) Finish(ctx context.Context, id string, key string, size int64, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE data_exports SET status = 'ready', storage_key = $2, size_bytes = $3,
+			finished_at = NOW() AT TIME ZONE 'UTC', expires_at = $4, error = NULL
+		WHERE id = $1
+	`, id, key, size, expiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("finish data export: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) Fail(ctx context.Context, id string, reason string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE data_exports SET status = 'failed', error = $2, finished_at = NOW() AT TIME ZONE 'UTC' WHERE id = $1
+	`, id, reason)
+	if err != nil {
+		return fmt.Errorf("fail data export: %w", err)
+	}
+	return nil
+}
+
+// Expire помечает истёкшие архивы и ставит их файлы в очередь удаления из хранилища
+func (r *Repository) Expire(ctx context.Context) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+		WITH expired AS (
+			UPDATE data_exports SET status = 'expired'
+			WHERE status = 'ready' AND expires_at <= NOW() AT TIME ZONE 'UTC'
+			RETURNING storage_key
+		), queued AS (
+			INSERT INTO attachment_purge_queue (storage_key)
+			SELECT storage_key FROM expired WHERE storage_key IS NOT NULL
+			ON CONFLICT DO NOTHING
+		)
+		SELECT COUNT(*) FROM expired
+	`).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("expire data exports: %w", err)
+	}
+	return n, nil
+}
+
+// Snapshot открывает согласованный снимок данных пользователя: все наборы читаются в одной
+// транзакции REPEATABLE READ, поэтому архив не смешивает состояния до и после параллельных правок
+func (r *Repository) Snapshot(ctx context.Context, userID uuid.UUID) (*Snapshot, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin snapshot: %w", err)
+	}
+	return &Snapshot{tx: tx, userID: userID}, nil
+}
+
+type Snapshot struct {
+	tx     *sql.Tx
+	userID uuid.UUID
+}
+
+func (s *Snapshot) Close() error {
+	return s.tx.Rollback()
+}
+
+// Each вызывает fn для каждой строки набора в порядке ds.OrderBy
+func (s *Snapshot) Each(ctx context.Context, ds Dataset, fn func(Row) error) error {
+	rows, err := s.tx.QueryContext(ctx,
+		`SELECT row_to_json(t)::text FROM (`+ds.Query+`) t ORDER BY `+ds.OrderBy, s.userID)
+	if err != nil {
+		return fmt.Errorf("export %s: %w", ds.Name, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			return fmt.Errorf("export %s: %w", ds.Name, err)
+		}
+		row, err := parseRow(raw)
+		if err != nil {
+			return fmt.Errorf("export %s: %w", ds.Name, err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// AttachmentFile — файл вложения, загруженного пользователем
+type AttachmentFile struct {
+	ID         string
+	Filename   string
+	StorageKey string
+}
+
+func (s *Snapshot) AttachmentFiles(ctx context.Context) ([]AttachmentFile, error) {
+	rows, err := s.tx.QueryContext(ctx,
+		`SELECT id, filename, storage_key FROM attachments WHERE uploaded_by = $1 ORDER BY created_at`, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("export attachment files: %w", err)
+	}
+	defer rows.Close()
+	var files []AttachmentFile
+	for rows.Next() {
+		var f AttachmentFile
+		if err := rows.Scan(&f.ID, &f.Filename, &f.StorageKey); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// Row — строка набора: колонки в порядке запроса и их значения в JSON
+type Row struct {
+	Columns []string
+	Values  []json.RawMessage
+}
+
+// Get — значение колонки; nil, если колонки нет
+func (r Row) Get(column string) json.RawMessage {
+	for i, c := range r.Columns {
+		if c == column {
+			return r.Values[i]
+		}
+	}
+	return nil
+}
+
+// String — строковое значение колонки; пусто для NULL и нестроковых значений
+func (r Row) String(column string) string {
+	var s string
+	_ = json.Unmarshal(r.Get(column), &s)
+	return s
+}
+
+// parseRow разбирает JSON-объект, сохраняя порядок ключей (он нужен для колонок CSV)
+func parseRow(raw []byte) (Row, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return Row{}, fmt.Errorf("row is not a JSON object")
+	}
+	var row Row
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return Row{}, err
+		}
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return Row{}, err
+		}
+		if internalColumns[t.(string)] {
+			continue
+		}
+		row.Columns = append(row.Columns, t.(string))
+		row.Values = append(row.Values, v)
+	}
+	return row, nil
+}
+
+func scanExport(row interface{ Scan(...interface{}) error }) (*model.DataExport, error) {
+	var e model.DataExport
+	var size sql.NullInt64
+	var errText sql.NullString
+	var started, finished, expires sql.NullTime
+	if err := row.Scan(&e.ID, &e.UserID, &e.Status, &size, &errText, &e.CreatedAt, &started, &finished, &expires, &e.StorageKey); err != nil {
+		return nil, err
+	}
+	if size.Valid {
+		e.SizeBytes = &size.Int64
+	}
+	if errText.Valid {
+		e.Error = &errText.String
+	}
+	if started.Valid {
+		e.StartedAt = &started.Time
+	}
+	if finished.Valid {
+		e.FinishedAt = &finished.Time
+	}
+	if expires.Valid {
+		e.ExpiresAt = &expires.Time
+	}
+	return &e, nil
+}
@@ -0,0 +1,19 @@
+package export
+
+import "testing"
+
+func TestParseRow(t *testing.T) {
+	row, err := parseRow([]byte(`{"id": "1", "title": "Заметка", "search_vector": "'заметк':1", "tags": ["a"], "mood": null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(row.Columns) != 4 || row.Columns[0] != "id" || row.Columns[1] != "title" || row.Columns[2] != "tags" || row.Columns[3] != "mood" {
+		t.Fatalf("columns = %v", row.Columns)
+	}
+	if row.String("title") != "Заметка" || string(row.Get("tags")) != `["a"]` || row.String("mood") != "" || row.Get("missing") != nil {
+		t.Fatalf("row = %+v", row)
+	}
+	if _, err := parseRow([]byte(`[1]`)); err == nil {
+		t.Fatal("array accepted as row")
+	}
+}
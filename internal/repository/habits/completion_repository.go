@@ -3,6 +3,7 @@ package habits
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -13,7 +14,7 @@ import (
 
 // CompletionRepository управляет completions привычек
 type CompletionRepository struct {
-	db *sql.DB
+	db dbtx
 }
 
 // NewCompletionRepository создает новый CompletionRepository
@@ -21,12 +22,15 @@ func NewCompletionRepository(db *sql.DB) *CompletionRepository {
 	return &CompletionRepository{db: db}
 }
 
-// Create создает запись о выполнении привычки
+// Create создает запись о выполнении привычки. Выполнение за день одно (unique_habit_date_user):
+// повторный вызов на ту же дату обновляет notes, rating и time существующей записи.
 func (r *CompletionRepository) Create(ctx context.Context, habitID, userID uuid.UUID, date time.Time, notes string, rating interface{}, completionTime *string) (*model.HabitCompletion, error) {
 	query := `
 		INSERT INTO habit_completions (
 			id, habit_id, user_id, workspace_id, date, notes, rating, time, created_at
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT ON CONSTRAINT unique_habit_date_user DO UPDATE SET
+			notes = EXCLUDED.notes, rating = EXCLUDED.rating, time = EXCLUDED.time
 		RETURNING id, habit_id, user_id, workspace_id, date, notes, rating, time, created_at
 	`
 
@@ -36,7 +40,7 @@ func (r *CompletionRepository) Create(ctx context.Context, habitID, userID uuid.
 
 	var workspaceID uuid.UUID
 	err := r.db.QueryRowContext(ctx,
-		"SELECT workspace_id FROM habits WHERE id = $1 AND user_id = $2",
+		"SELECT workspace_id FROM habits WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL",
 		habitID, userID,
 	).Scan(&workspaceID)
 	if err != nil {
@@ -87,7 +91,7 @@ func (r *CompletionRepository) Create(ctx context.Context, habitID, userID uuid.
 
 // Toggle переключает выполнение привычки на дату (добавляет или удаляет)
 func (r *CompletionRepository) Toggle(ctx context.Context, habitID, userID uuid.UUID, date time.Time) (bool, *model.HabitCompletion, error) {
-	tx, err := r.db.BeginTx(ctx, nil)
+	tx, err := beginTx(ctx, r.db)
 	if err != nil {
 		return false, nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -216,12 +220,13 @@ func (r *CompletionRepository) GetCompletionMap(ctx context.Context, userID, wor
 	return m, rows.Err()
 }
 
-// GetAllByWorkspaceAndDateRange возвращает все completions воркспейса за период
+// GetAllByWorkspaceAndDateRange возвращает все completions воркспейса за период, кроме выполнений привычек из корзины
 func (r *CompletionRepository) GetAllByWorkspaceAndDateRange(ctx context.Context, userID, workspaceID uuid.UUID, startDate, endDate time.Time) ([]model.HabitCompletion, error) {
 	query := `
 		SELECT id, habit_id, user_id, workspace_id, date, notes, rating, time, created_at
 		FROM habit_completions
 		WHERE user_id = $1 AND workspace_id = $2 AND date BETWEEN $3 AND $4
+			AND habit_id IN (SELECT id FROM habits WHERE workspace_id = $2 AND deleted_at IS NULL)
 		ORDER BY date DESC, time DESC
 	`
 	rows, err := r.db.QueryContext(ctx, query, userID, workspaceID, NormalizeDate(startDate), NormalizeDate(endDate))
@@ -231,3 +236,27 @@ func (r *CompletionRepository) GetAllByWorkspaceAndDateRange(ctx context.Context
 	defer rows.Close()
 	return scanCompletions(rows)
 }
+
+// Import добавляет выполнения задним числом (импорт истории из других трекеров). Дни, уже отмеченные
+// у привычки, не меняются — повторный импорт того же файла ничего не добавит. Возвращает число добавленных.
+func (r *CompletionRepository) Import(ctx context.Context, habitID, userID uuid.UUID, items []model.HabitCompletionImport) (int, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+	rows, err := json.Marshal(items)
+	if err != nil {
+		return 0, err
+	}
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO habit_completions (habit_id, user_id, workspace_id, date, notes, rating, created_at)
+		SELECT h.id, h.user_id, h.workspace_id, x.date, COALESCE(x.notes, ''), x.rating, $3
+		FROM habits h, json_to_recordset($4::json) AS x(date date, notes text, rating int)
+		WHERE h.id = $1 AND h.user_id = $2 AND h.deleted_at IS NULL
+		ON CONFLICT ON CONSTRAINT unique_habit_date_user DO NOTHING
+	`, habitID, userID, time.Now().UTC(), string(rows))
+	if err != nil {
+		return 0, fmt.Errorf("failed to import completions: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
@@ -3,22 +3,41 @@ package habits
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 
 	"backend/internal/model"
+	"backend/pkg/query"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
 
+// dbtx — общее у *sql.DB и *sql.Tx: запросы репозиториев пакета выполняются в транзакции InTx или вне её
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// beginTx открывает транзакцию метода. Внутри InTx вложенные транзакции не поддерживаются.
+func beginTx(ctx context.Context, db dbtx) (*sql.Tx, error) {
+	d, ok := db.(*sql.DB)
+	if !ok {
+		return nil, errors.New("nested transactions are not supported")
+	}
+	return d.BeginTx(ctx, nil)
+}
+
 type Repository struct {
-	db          *sql.DB
+	db          dbtx
 	versions    *VersionRepository
 	completions *CompletionRepository
 	statsCalc   *StatsCalculator
+	now         func() time.Time
 }
 
 func NewRepository(db *sql.DB) *Repository {
@@ -27,74 +46,145 @@ func NewRepository(db *sql.DB) *Repository {
 		versions:    NewVersionRepository(db),
 		completions: NewCompletionRepository(db),
 		statsCalc:   &StatsCalculator{},
+		now:         time.Now,
 	}
 }
 
-// List возвращает все привычки воркспейса (видят все участники, в т.ч. админ в чужом воркспейсе).
-func (r *Repository) List(ctx context.Context, workspaceID uuid.UUID, targetDate *time.Time) ([]model.Habit, error) {
+// WithClock возвращает копию репозитория, для которой «сейчас» — now(): от него считаются created_at,
+// даты версий и «сегодня». Нужна генератору демо-данных, чтобы строить историю задним числом тем же кодом, что и API.
+func (r *Repository) WithClock(now func() time.Time) *Repository {
+	c := *r
+	c.now = now
+	return &c
+}
+
+// InTx выполняет fn в одной транзакции: все запросы репозитория tx идут в неё, ошибка fn откатывает всё.
+// Методы со своей транзакцией (Delete, Restore, Purge, Toggle) внутри fn возвращают ошибку.
+func (r *Repository) InTx(ctx context.Context, fn func(tx *Repository) error) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	c := *r
+	c.db, c.versions, c.completions = tx, &VersionRepository{db: tx}, &CompletionRepository{db: tx}
+	if err := fn(&c); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListSchema — сортировки и фильтры списка привычек. Колонки есть и в habits, и в выборке из habit_versions (habitsForDateSQL).
+var ListSchema = query.Schema{
+	Sorts: map[string]query.SortField{
+		// Без времени — в конце, как preferred_time NULLS LAST
+		"preferredTime": {Column: "COALESCE(preferred_time, '24:00'::time)", Type: query.TypeTime},
+		"createdAt":     {Column: "created_at", Type: query.TypeTimestamp},
+		"title":         {Column: "title", Type: query.TypeText},
+	},
+	Filters: map[string]query.Filter{
+		"category":     {Column: "category", Op: query.OpEq, Type: query.TypeText},
+		"scheduleType": {Column: "schedule_type", Op: query.OpEq, Type: query.TypeText},
+		"isActive":     {Column: "is_active", Op: query.OpEq, Type: query.TypeBool},
+		"q":            {Column: "title", Op: query.OpContains, Type: query.TypeText},
+	},
+	DefaultSort: "preferredTime",
+}
+
+const habitColumns = `id, title, description, color, icon, target_days, daily_goal, preferred_time, category,
+	schedule_type, recurring_days, one_time_date, is_active, user_id, workspace_id, created_at, updated_at`
+
+// habitsForDateSQL — привычки воркспейса ($1), активные на дату ($2), по версиям из habit_versions.
+// Привычка из корзины пропадает с дня удаления; в прошлых днях её история остаётся.
+const habitsForDateSQL = `
+	SELECT DISTINCT ON (habit_id)
+		habit_id AS id, title, description, color, icon, target_days, daily_goal, preferred_time, category,
+		schedule_type, recurring_days, one_time_date, is_active, user_id, workspace_id,
+		(valid_from)::timestamp AS created_at, COALESCE(valid_to, valid_from)::timestamp AS updated_at
+	FROM habit_versions
+	WHERE workspace_id = $1 AND is_active = true
+		AND $2::date BETWEEN valid_from AND COALESCE(valid_to, $2::date)
+		AND (
+			(schedule_type = 'recurring' AND EXTRACT(DOW FROM $2::date) = ANY(recurring_days))
+			OR (schedule_type = 'one_time' AND one_time_date = $2::date)
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM habits d WHERE d.id = habit_id AND d.deleted_at IS NOT NULL AND d.deleted_at::date <= $2::date
+		)
+	ORDER BY habit_id, (valid_to IS NOT NULL) DESC, valid_from DESC`
+
+// habitsForDateFallbackSQL — то же по таблице habits, для привычек без версий (сегодня и будущие даты)
+const habitsForDateFallbackSQL = `
+	SELECT ` + habitColumns + `
+	FROM habits
+	WHERE workspace_id = $1 AND is_active = true AND deleted_at IS NULL AND DATE(created_at) <= $2::date
+		AND (
+			(schedule_type = 'recurring' AND EXTRACT(DOW FROM $2::date) = ANY(recurring_days))
+			OR (schedule_type = 'one_time' AND one_time_date = $2::date)
+		)`
+
+// List возвращает страницу привычек воркспейса (видят все участники, в т.ч. админ в чужом воркспейсе).
+// С targetDate — только привычки, запланированные на эту дату, в том виде, какой они имели в тот день.
+func (r *Repository) List(ctx context.Context, workspaceID uuid.UUID, targetDate *time.Time, spec query.Spec) ([]model.Habit, query.Page, error) {
+	args := []interface{}{workspaceID}
+	from := `habits WHERE workspace_id = $1 AND deleted_at IS NULL`
 	if targetDate != nil {
-		return r.GetHabitsForDate(ctx, workspaceID, *targetDate)
+		date := NormalizeDate(*targetDate)
+		args = append(args, date)
+		from = `(` + habitsForDateSQL + `) h WHERE true`
+
+		exists := false
+		if !date.Before(NormalizeDate(r.now().UTC())) {
+			if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (`+habitsForDateSQL+`)`, workspaceID, date).Scan(&exists); err != nil {
+				return nil, query.Page{}, fmt.Errorf("failed to query habits for date (versions): %w", err)
+			}
+			if !exists {
+				from = `(` + habitsForDateFallbackSQL + `) h WHERE true`
+			}
+		}
 	}
 
-	rows, err := r.db.QueryContext(ctx, `
-		SELECT id, title, description, color, icon, target_days, daily_goal, preferred_time, category,
-			schedule_type, recurring_days, one_time_date, is_active, user_id, workspace_id, created_at, updated_at
-		FROM habits WHERE workspace_id = $1
-		ORDER BY preferred_time NULLS LAST, created_at DESC
-	`, workspaceID)
+	where, tail, specArgs := spec.SQL(len(args) + 1)
+	rows, err := r.db.QueryContext(ctx, `SELECT `+habitColumns+`, `+spec.KeyColumn()+` FROM `+from+where+tail, append(args, specArgs...)...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query habits: %w", err)
+		return nil, query.Page{}, fmt.Errorf("failed to query habits: %w", err)
 	}
 	defer rows.Close()
-	return scanHabits(rows)
+
+	var keys []query.Key
+	habits, err := scanHabits(rows, &keys)
+	if err != nil {
+		return nil, query.Page{}, err
+	}
+	habits, page := query.Paginate(spec, habits, keys)
+	return habits, page, nil
 }
 
 // GetHabitsForDate возвращает все привычки воркспейса, активные на указанную дату.
 func (r *Repository) GetHabitsForDate(ctx context.Context, workspaceID uuid.UUID, targetDate time.Time) ([]model.Habit, error) {
 	normalizedDate := NormalizeDate(targetDate)
 
-	rows, err := r.db.QueryContext(ctx, `
-		SELECT DISTINCT ON (habit_id)
-			habit_id AS id, title, description, color, icon, target_days, daily_goal, preferred_time, category,
-			schedule_type, recurring_days, one_time_date, is_active, user_id, workspace_id,
-			(valid_from)::timestamp AS created_at, COALESCE(valid_to, valid_from)::timestamp AS updated_at
-		FROM habit_versions
-		WHERE workspace_id = $1 AND is_active = true
-			AND $2::date BETWEEN valid_from AND COALESCE(valid_to, $2::date)
-			AND (
-				(schedule_type = 'recurring' AND EXTRACT(DOW FROM $2::date) = ANY(recurring_days))
-				OR (schedule_type = 'one_time' AND one_time_date = $2::date)
-			)
-		ORDER BY habit_id, (valid_to IS NOT NULL) DESC, valid_from DESC
-	`, workspaceID, normalizedDate)
+	rows, err := r.db.QueryContext(ctx, habitsForDateSQL, workspaceID, normalizedDate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query habits for date (versions): %w", err)
 	}
 	defer rows.Close()
 
-	habits, err := scanHabits(rows)
+	habits, err := scanHabits(rows, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	todayStart := NormalizeDate(time.Now().UTC())
+	todayStart := NormalizeDate(r.now().UTC())
 	if len(habits) == 0 && !normalizedDate.Before(todayStart) {
-		fallbackRows, err := r.db.QueryContext(ctx, `
-			SELECT id, title, description, color, icon, target_days, daily_goal, preferred_time, category,
-				schedule_type, recurring_days, one_time_date, is_active, user_id, workspace_id, created_at, updated_at
-			FROM habits
-			WHERE workspace_id = $1 AND is_active = true AND DATE(created_at) <= $2::date
-				AND (
-					(schedule_type = 'recurring' AND EXTRACT(DOW FROM $2::date) = ANY(recurring_days))
-					OR (schedule_type = 'one_time' AND one_time_date = $2::date)
-				)
-			ORDER BY preferred_time NULLS LAST, created_at DESC
-		`, workspaceID, normalizedDate)
+		fallbackRows, err := r.db.QueryContext(ctx, habitsForDateFallbackSQL+`
+			ORDER BY preferred_time NULLS LAST, created_at DESC`, workspaceID, normalizedDate)
 		if err != nil {
 			return nil, fmt.Errorf("failed to query habits for date (fallback): %w", err)
 		}
 		defer fallbackRows.Close()
-		return scanHabits(fallbackRows)
+		return scanHabits(fallbackRows, nil)
 	}
 	return habits, nil
 }
@@ -109,7 +199,7 @@ func (r *Repository) Create(ctx context.Context, dto model.CreateHabitDto, userI
 			schedule_type, recurring_days, one_time_date, is_active, user_id, workspace_id, created_at, updated_at
 	`
 
-	now := time.Now().UTC()
+	now := r.now().UTC()
 	habitID := uuid.New()
 
 	var categoryValue, preferredTimeValue interface{}
@@ -213,7 +303,7 @@ func (r *Repository) Get(ctx context.Context, id, userID uuid.UUID) (*model.Habi
 	err := r.db.QueryRowContext(ctx, `
 		SELECT id, title, description, color, icon, target_days, daily_goal, preferred_time, category,
 			schedule_type, recurring_days, one_time_date, is_active, user_id, workspace_id, created_at, updated_at
-		FROM habits WHERE id = $1 AND user_id = $2
+		FROM habits WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 	`, id, userID).Scan(
 		&habit.ID, &habit.Title, &habit.Description, &habit.Color, &habit.Icon,
 		&habit.TargetDays, &habit.DailyGoal, &preferredTimePtr, &categoryPtr, &habit.ScheduleType,
@@ -233,7 +323,7 @@ func (r *Repository) Get(ctx context.Context, id, userID uuid.UUID) (*model.Habi
 
 func (r *Repository) Update(ctx context.Context, id, userID uuid.UUID, dto model.UpdateHabitDto) (*model.Habit, error) {
 	updates := []string{"updated_at = $1"}
-	args := []interface{}{time.Now().UTC()}
+	args := []interface{}{r.now().UTC()}
 	argIndex := 2
 	shouldVersion := false
 
@@ -289,7 +379,7 @@ func (r *Repository) Update(ctx context.Context, id, userID uuid.UUID, dto model
 	if dto.ScheduleType != nil {
 		scheduleTypeToCheck = *dto.ScheduleType
 	} else {
-		_ = r.db.QueryRowContext(ctx, "SELECT schedule_type FROM habits WHERE id = $1 AND user_id = $2", id, userID).Scan(&scheduleTypeToCheck)
+		_ = r.db.QueryRowContext(ctx, "SELECT schedule_type FROM habits WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL", id, userID).Scan(&scheduleTypeToCheck)
 	}
 
 	if dto.RecurringDays != nil && scheduleTypeToCheck == "recurring" {
@@ -336,7 +426,7 @@ func (r *Repository) Update(ctx context.Context, id, userID uuid.UUID, dto model
 	}
 
 	query := fmt.Sprintf(`
-		UPDATE habits SET %s WHERE id = $%d AND user_id = $%d
+		UPDATE habits SET %s WHERE id = $%d AND user_id = $%d AND deleted_at IS NULL
 		RETURNING id, title, description, color, icon, target_days, daily_goal, preferred_time, category,
 			schedule_type, recurring_days, one_time_date, is_active, user_id, workspace_id, created_at, updated_at
 	`, strings.Join(updates, ", "), argIndex, argIndex+1)
@@ -363,7 +453,7 @@ func (r *Repository) Update(ctx context.Context, id, userID uuid.UUID, dto model
 	}
 
 	if shouldVersion {
-		changeDate := NormalizeDate(time.Now().UTC())
+		changeDate := NormalizeDate(r.now().UTC())
 		nextDay := changeDate.AddDate(0, 0, 1)
 
 		closed, err := r.versions.ClosePrevious(ctx, habit.ID, habit.UserID, habit.WorkspaceID, changeDate)
@@ -399,22 +489,25 @@ func (r *Repository) Update(ctx context.Context, id, userID uuid.UUID, dto model
 	return &h, nil
 }
 
+// Delete переносит привычку в корзину: версии закрываются датой удаления, выполнения и история остаются.
+// Восстановление и окончательное удаление — repository/trash.
 func (r *Repository) Delete(ctx context.Context, id, userID uuid.UUID) error {
-	tx, err := r.db.BeginTx(ctx, nil)
+	tx, err := beginTx(ctx, r.db)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
 	var workspaceID uuid.UUID
-	if err := tx.QueryRowContext(ctx, "SELECT workspace_id FROM habits WHERE id = $1 AND user_id = $2", id, userID).Scan(&workspaceID); err != nil {
+	if err := tx.QueryRowContext(ctx, "SELECT workspace_id FROM habits WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE", id, userID).Scan(&workspaceID); err != nil {
 		if err == sql.ErrNoRows {
 			return sql.ErrNoRows
 		}
 		return fmt.Errorf("failed to get habit workspace_id: %w", err)
 	}
 
-	deleteDate := NormalizeDate(time.Now().UTC())
+	now := r.now().UTC()
+	deleteDate := NormalizeDate(now)
 	_, err = tx.ExecContext(ctx, `
 		UPDATE habit_versions SET valid_to = $1
 		WHERE habit_id = $2 AND user_id = $3 AND workspace_id = $4 AND valid_to IS NULL
@@ -423,9 +516,9 @@ func (r *Repository) Delete(ctx context.Context, id, userID uuid.UUID) error {
 		return fmt.Errorf("failed to close habit version: %w", err)
 	}
 
-	result, err := tx.ExecContext(ctx, "DELETE FROM habits WHERE id = $1 AND user_id = $2", id, userID)
+	result, err := tx.ExecContext(ctx, "UPDATE habits SET deleted_at = $3 WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL", id, userID, now)
 	if err != nil {
-		return fmt.Errorf("failed to delete habit: %w", err)
+		return fmt.Errorf("failed to trash habit: %w", err)
 	}
 	n, _ := result.RowsAffected()
 	if n == 0 {
@@ -434,6 +527,81 @@ func (r *Repository) Delete(ctx context.Context, id, userID uuid.UUID) error {
 	return tx.Commit()
 }
 
+// Restore возвращает привычку из корзины. Версия, закрытая удалением сегодня, снова открывается; если привычку
+// удалили раньше, история продолжается копией последней версии с сегодняшнего дня, а дни в корзине остаются пустыми.
+// sql.ErrNoRows — привычки нет в корзине.
+func (r *Repository) Restore(ctx context.Context, id, userID, workspaceID uuid.UUID) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE habits SET deleted_at = NULL, purge_exempt = FALSE
+		WHERE id = $1 AND user_id = $2 AND workspace_id = $3 AND deleted_at IS NOT NULL`, id, userID, workspaceID)
+	if err != nil {
+		return fmt.Errorf("failed to restore habit: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	today := NormalizeDate(r.now().UTC())
+	var versionID uuid.UUID
+	var validTo sql.NullTime
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, valid_to FROM habit_versions WHERE habit_id = $1 AND workspace_id = $2
+		ORDER BY valid_from DESC, created_at DESC LIMIT 1
+	`, id, workspaceID).Scan(&versionID, &validTo)
+	switch {
+	case err == sql.ErrNoRows:
+		// Привычку без версий показывает habitsForDateFallbackSQL
+	case err != nil:
+		return fmt.Errorf("failed to get last habit version: %w", err)
+	case !validTo.Valid:
+	case !validTo.Time.Before(today):
+		if _, err := tx.ExecContext(ctx, "UPDATE habit_versions SET valid_to = NULL WHERE id = $1", versionID); err != nil {
+			return fmt.Errorf("failed to reopen habit version: %w", err)
+		}
+	default:
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO habit_versions (
+				habit_id, user_id, workspace_id, title, description, color, icon, target_days, daily_goal,
+				preferred_time, category, schedule_type, recurring_days, one_time_date, is_active, valid_from
+			)
+			SELECT habit_id, user_id, workspace_id, title, description, color, icon, target_days, daily_goal,
+				preferred_time, category, schedule_type, recurring_days, one_time_date, is_active, $2
+			FROM habit_versions WHERE id = $1
+		`, versionID, today); err != nil {
+			return fmt.Errorf("failed to create habit version: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Purge окончательно удаляет привычку из корзины вместе с версиями. Выполнения и их вложения удаляются
+// каскадом, связи — триггером. sql.ErrNoRows — привычки нет в корзине.
+func (r *Repository) Purge(ctx context.Context, id, userID, workspaceID uuid.UUID) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM habits WHERE id = $1 AND user_id = $2 AND workspace_id = $3 AND deleted_at IS NOT NULL",
+		id, userID, workspaceID)
+	if err != nil {
+		return fmt.Errorf("failed to purge habit: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM habit_versions WHERE habit_id = $1", id); err != nil {
+		return fmt.Errorf("failed to purge habit versions: %w", err)
+	}
+	return tx.Commit()
+}
+
 func (r *Repository) Complete(ctx context.Context, habitID, userID uuid.UUID, date time.Time, notes string, rating interface{}, completionTime *string) (*model.HabitCompletion, error) {
 	return r.completions.Create(ctx, habitID, userID, date, notes, rating, completionTime)
 }
@@ -450,7 +618,7 @@ func (r *Repository) GetStats(ctx context.Context, habitID, userID uuid.UUID) (*
 
 	err := r.db.QueryRowContext(ctx, `
 		SELECT schedule_type, recurring_days, one_time_date, created_at
-		FROM habits WHERE id = $1 AND user_id = $2
+		FROM habits WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 	`, habitID, userID).Scan(&scheduleType, &recurringDaysArray, &oneTimeDatePtr, &createdAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -460,7 +628,7 @@ func (r *Repository) GetStats(ctx context.Context, habitID, userID uuid.UUID) (*
 	}
 
 	createdAtUTC := NormalizeDate(createdAt.UTC())
-	today := NormalizeDate(time.Now().UTC())
+	today := NormalizeDate(r.now().UTC())
 
 	var totalDays int
 	if scheduleType == "recurring" {
@@ -526,7 +694,7 @@ func (r *Repository) GetAllCompletions(ctx context.Context, userID, workspaceID
 func (r *Repository) GetCalendar(ctx context.Context, userID, workspaceID uuid.UUID, startDate, endDate time.Time) (*model.CalendarResponse, error) {
 	normalizedStart := NormalizeDate(startDate)
 	normalizedEnd := NormalizeDate(endDate)
-	todayStart := NormalizeDate(time.Now().UTC())
+	todayStart := NormalizeDate(r.now().UTC())
 
 	completionMap, err := r.completions.GetCompletionMap(ctx, userID, workspaceID, startDate, endDate)
 	if err != nil {
@@ -612,3 +780,64 @@ func appendUpdate(updates []string, args []interface{}, argIndex int, col string
 	args = append(args, val)
 	return updates, args, argIndex + 1, shouldVersion || version
 }
+
+// ListTitles — привычки пользователя в воркспейсе: название → ID. Из привычек с одинаковым названием
+// (без учёта регистра) — самая старая. Импорт истории по ним находит уже существующие.
+func (r *Repository) ListTitles(ctx context.Context, userID, workspaceID uuid.UUID) (map[string]uuid.UUID, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (LOWER(BTRIM(title))) id, title FROM habits
+		WHERE user_id = $1 AND workspace_id = $2 AND deleted_at IS NULL
+		ORDER BY LOWER(BTRIM(title)), created_at
+	`, userID, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list habit titles: %w", err)
+	}
+	defer rows.Close()
+	titles := make(map[string]uuid.UUID)
+	for rows.Next() {
+		var id uuid.UUID
+		var title string
+		if err := rows.Scan(&id, &title); err != nil {
+			return nil, err
+		}
+		titles[title] = id
+	}
+	return titles, rows.Err()
+}
+
+// ImportCompletions добавляет выполнения задним числом, не трогая уже отмеченные дни. Делегирует в CompletionRepository.Import.
+func (r *Repository) ImportCompletions(ctx context.Context, habitID, userID uuid.UUID, items []model.HabitCompletionImport) (int, error) {
+	return r.completions.Import(ctx, habitID, userID, items)
+}
+
+// Backdate начинает историю привычки не позже from: сдвигает created_at и первую версию, чтобы
+// GetCalendar показывал выполнения до даты создания. Привычке без версий создаёт версию от from.
+func (r *Repository) Backdate(ctx context.Context, habitID, userID uuid.UUID, from time.Time) error {
+	habit, err := r.Get(ctx, habitID, userID)
+	if err != nil {
+		return err
+	}
+	if habit == nil {
+		return sql.ErrNoRows
+	}
+	from = NormalizeDate(from)
+	if _, err := r.db.ExecContext(ctx,
+		"UPDATE habits SET created_at = $1 WHERE id = $2 AND created_at > $1", from, habitID); err != nil {
+		return fmt.Errorf("failed to backdate habit: %w", err)
+	}
+	wsID, err := uuid.Parse(habit.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	exists, err := r.versions.Backdate(ctx, habitID, wsID, from)
+	if err != nil {
+		return fmt.Errorf("failed to backdate habit version: %w", err)
+	}
+	if exists {
+		return nil
+	}
+	return r.versions.Create(ctx, habit.ID, habit.UserID, habit.WorkspaceID,
+		habit.Title, habit.Description, habit.Color, habit.Icon,
+		habit.TargetDays, habit.DailyGoal, habit.PreferredTime, habit.Category,
+		habit.ScheduleType, habit.RecurringDays, habit.OneTimeDate, habit.IsActive, from)
+}
@@ -0,0 +1,387 @@
+package habits_test
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository/habits"
+	"backend/internal/testutil/pgtest"
+	"backend/pkg/query"
+
+	"github.com/google/uuid"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+type versionRow struct {
+	Title     string
+	ValidFrom time.Time
+	ValidTo   *time.Time
+}
+
+func loadVersions(t *testing.T, env *pgtest.Env, habitID string) []versionRow {
+	t.Helper()
+	rows, err := env.DB.Query(`
+		SELECT title, valid_from, valid_to FROM habit_versions
+		WHERE habit_id = $1 ORDER BY valid_from, valid_to NULLS LAST
+	`, habitID)
+	if err != nil {
+		t.Fatalf("query versions: %v", err)
+	}
+	defer rows.Close()
+
+	var list []versionRow
+	for rows.Next() {
+		var v versionRow
+		if err := rows.Scan(&v.Title, &v.ValidFrom, &v.ValidTo); err != nil {
+			t.Fatalf("scan version: %v", err)
+		}
+		list = append(list, v)
+	}
+	return list
+}
+
+func titlesForDate(t *testing.T, repo *habits.Repository, workspaceID string, date time.Time) map[string]string {
+	t.Helper()
+	list, err := repo.GetHabitsForDate(context.Background(), uuid.MustParse(workspaceID), date)
+	if err != nil {
+		t.Fatalf("GetHabitsForDate(%s): %v", date.Format("2006-01-02"), err)
+	}
+	titles := make(map[string]string, len(list))
+	for _, h := range list {
+		titles[h.ID] = h.Title
+	}
+	return titles
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
+
+func TestUpdate_VersionsOnlyTrackedFields(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	repo := habits.NewRepository(env.DB)
+
+	owner := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+	h := env.CreateHabit(t, owner, ws, model.CreateHabitDto{Title: "Read"})
+	env.BackdateHabit(t, h.ID, 3)
+
+	icon := "book"
+	if _, err := repo.Update(ctx, uuid.MustParse(h.ID), uuid.MustParse(owner.ID), model.UpdateHabitDto{Icon: &icon}); err != nil {
+		t.Fatalf("update icon: %v", err)
+	}
+	if got := len(loadVersions(t, env, h.ID)); got != 1 {
+		t.Fatalf("icon change must not create a version, got %d versions", got)
+	}
+
+	title := "Read 20 pages"
+	updated, err := repo.Update(ctx, uuid.MustParse(h.ID), uuid.MustParse(owner.ID), model.UpdateHabitDto{Title: &title})
+	if err != nil {
+		t.Fatalf("update title: %v", err)
+	}
+	if updated.Title != title || updated.Icon != icon {
+		t.Fatalf("updated habit = %q/%q, want %q/%q", updated.Title, updated.Icon, title, icon)
+	}
+
+	today := habits.NormalizeDate(time.Now())
+	versions := loadVersions(t, env, h.ID)
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions after title change, got %d", len(versions))
+	}
+	old, cur := versions[0], versions[1]
+	if old.Title != "Read" || old.ValidTo == nil || !sameDay(*old.ValidTo, today) {
+		t.Errorf("previous version = %+v, want title Read closed today", old)
+	}
+	if cur.Title != title || cur.ValidTo != nil || !sameDay(cur.ValidFrom, today.AddDate(0, 0, 1)) {
+		t.Errorf("current version = %+v, want %q open from tomorrow", cur, title)
+	}
+
+	// Сегодня и раньше календарь показывает старое название, с завтрашнего дня — новое
+	for _, tc := range []struct {
+		date time.Time
+		want string
+	}{
+		{today.AddDate(0, 0, -2), "Read"},
+		{today, "Read"},
+		{today.AddDate(0, 0, 1), title},
+	} {
+		if got := titlesForDate(t, repo, ws.ID, tc.date)[h.ID]; got != tc.want {
+			t.Errorf("title on %s = %q, want %q", tc.date.Format("2006-01-02"), got, tc.want)
+		}
+	}
+}
+
+func TestUpdate_RepeatedChangesSameDay(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	repo := habits.NewRepository(env.DB)
+
+	owner := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+	h := env.CreateHabit(t, owner, ws, model.CreateHabitDto{Title: "Run"})
+	env.BackdateHabit(t, h.ID, 2)
+
+	for _, title := range []string{"Run 3km", "Run 5km"} {
+		title := title
+		if _, err := repo.Update(ctx, uuid.MustParse(h.ID), uuid.MustParse(owner.ID), model.UpdateHabitDto{Title: &title}); err != nil {
+			t.Fatalf("update %q: %v", title, err)
+		}
+	}
+
+	today := habits.NormalizeDate(time.Now())
+	if got := titlesForDate(t, repo, ws.ID, today)[h.ID]; got != "Run" {
+		t.Errorf("today title = %q, want original %q", got, "Run")
+	}
+	if got := titlesForDate(t, repo, ws.ID, today.AddDate(0, 0, 1))[h.ID]; got != "Run 5km" {
+		t.Errorf("tomorrow title = %q, want last change %q", got, "Run 5km")
+	}
+
+	open := 0
+	for _, v := range loadVersions(t, env, h.ID) {
+		if v.ValidTo == nil {
+			open++
+		}
+	}
+	if open != 1 {
+		t.Errorf("expected exactly one open version, got %d", open)
+	}
+}
+
+func TestUpdate_BackfillsMissingVersion(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	repo := habits.NewRepository(env.DB)
+
+	owner := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+	h := env.CreateHabit(t, owner, ws, model.CreateHabitDto{Title: "Meditate"})
+	env.BackdateHabit(t, h.ID, 5)
+
+	// Привычка, созданная до появления habit_versions: версий нет вовсе
+	if _, err := env.DB.Exec("DELETE FROM habit_versions WHERE habit_id = $1", h.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	title := "Meditate 10 min"
+	if _, err := repo.Update(ctx, uuid.MustParse(h.ID), uuid.MustParse(owner.ID), model.UpdateHabitDto{Title: &title}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	today := habits.NormalizeDate(time.Now())
+	versions := loadVersions(t, env, h.ID)
+	if len(versions) != 2 {
+		t.Fatalf("expected backfilled + new version, got %d", len(versions))
+	}
+	if v := versions[0]; v.Title != "Meditate" || !sameDay(v.ValidFrom, today.AddDate(0, 0, -5)) || v.ValidTo == nil || !sameDay(*v.ValidTo, today) {
+		t.Errorf("backfilled version = %+v, want Meditate from creation day closed today", v)
+	}
+	if got := titlesForDate(t, repo, ws.ID, today.AddDate(0, 0, -3))[h.ID]; got != "Meditate" {
+		t.Errorf("title 3 days ago = %q, want %q", got, "Meditate")
+	}
+}
+
+func TestGetCalendar_KeepsHistory(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	repo := habits.NewRepository(env.DB)
+
+	owner := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+	old := env.CreateHabit(t, owner, ws, model.CreateHabitDto{Title: "Stretch", Color: "#111111"})
+	env.BackdateHabit(t, old.ID, 10)
+	fresh := env.CreateHabit(t, owner, ws, model.CreateHabitDto{Title: "Journal"})
+
+	uid, wsID := uuid.MustParse(owner.ID), uuid.MustParse(ws.ID)
+	today := habits.NormalizeDate(time.Now())
+	doneDay := today.AddDate(0, 0, -5)
+	if _, err := repo.Complete(ctx, uuid.MustParse(old.ID), uid, doneDay, "", nil, nil); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+
+	title := "Stretch 15 min"
+	if _, err := repo.Update(ctx, uuid.MustParse(old.ID), uid, model.UpdateHabitDto{Title: &title}); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if err := repo.Delete(ctx, uuid.MustParse(old.ID), uid); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	cal, err := repo.GetCalendar(ctx, uid, wsID, today.AddDate(0, 0, -7), today.AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatalf("GetCalendar: %v", err)
+	}
+	if len(cal.Days) != 7 {
+		t.Fatalf("expected 7 days, got %d", len(cal.Days))
+	}
+
+	for _, day := range cal.Days {
+		var found bool
+		for _, dh := range day.Habits {
+			if dh.ID == fresh.ID {
+				t.Errorf("%s: habit created today must not appear in the past", day.Date)
+			}
+			if dh.ID != old.ID {
+				continue
+			}
+			found = true
+			if dh.Title != "Stretch" || dh.Color != "#111111" {
+				t.Errorf("%s: got %q/%s, want title and color valid on that day", day.Date, dh.Title, dh.Color)
+			}
+			if want := day.Date == doneDay.Format("2006-01-02"); dh.Completed != want {
+				t.Errorf("%s: completed = %v, want %v", day.Date, dh.Completed, want)
+			}
+		}
+		if !found {
+			t.Errorf("%s: deleted habit missing from past calendar day", day.Date)
+		}
+	}
+}
+
+func TestToggle_ConcurrentCallsKeepSingleCompletion(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	repo := habits.NewRepository(env.DB)
+
+	owner := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+	h := env.CreateHabit(t, owner, ws, model.CreateHabitDto{})
+	hid, uid := uuid.MustParse(h.ID), uuid.MustParse(owner.ID)
+	date := habits.NormalizeDate(time.Now())
+
+	const workers = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	start := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if _, _, err := repo.Toggle(ctx, hid, uid, date); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("concurrent toggle: %v", err)
+	}
+
+	count := func() int {
+		var n int
+		if err := env.DB.QueryRow(
+			"SELECT COUNT(*) FROM habit_completions WHERE habit_id = $1 AND user_id = $2 AND date = $3", hid, uid, date,
+		).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	before := count()
+	if before > 1 {
+		t.Fatalf("concurrent toggles left %d completions for one day", before)
+	}
+
+	// После гонки переключатель по-прежнему согласован с таблицей
+	completed, _, err := repo.Toggle(ctx, hid, uid, date)
+	if err != nil {
+		t.Fatalf("toggle: %v", err)
+	}
+	if want := before == 0; completed != want {
+		t.Errorf("toggle after race: completed = %v, want %v", completed, want)
+	}
+	if got, want := count(), 1-before; got != want {
+		t.Errorf("completions after toggle = %d, want %d", got, want)
+	}
+}
+
+func TestComplete_ConcurrentCallsUpsert(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	repo := habits.NewRepository(env.DB)
+
+	owner := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+	h := env.CreateHabit(t, owner, ws, model.CreateHabitDto{})
+	hid, uid := uuid.MustParse(h.ID), uuid.MustParse(owner.ID)
+	date := habits.NormalizeDate(time.Now())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := repo.Complete(ctx, hid, uid, date, "done", nil, nil); err != nil {
+				t.Errorf("complete: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var n int
+	if err := env.DB.QueryRow("SELECT COUNT(*) FROM habit_completions WHERE habit_id = $1", hid).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected one completion, got %d", n)
+	}
+}
+
+// Постраничный обход по неуникальному ключу (одинаковые title, created_at с микросекундами) отдаёт каждую привычку ровно один раз
+func TestList_KeysetPagesCoverAllHabits(t *testing.T) {
+	env := pgtest.New(t)
+	owner := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+	repo := habits.NewRepository(env.DB)
+
+	want := map[string]bool{}
+	for i := 0; i < 7; i++ {
+		dto := model.CreateHabitDto{Title: []string{"Read", "Walk"}[i%2]}
+		if i%3 == 0 {
+			dto.PreferredTime = "morning"
+		}
+		h := env.CreateHabit(t, owner, ws, dto)
+		want[h.ID] = true
+	}
+	inactive := false
+	env.CreateHabit(t, owner, ws, model.CreateHabitDto{Title: "Write", IsActive: &inactive})
+
+	for _, sort := range []string{"title", "-createdAt", "preferredTime"} {
+		seen := map[string]bool{}
+		values := url.Values{"sort": {sort}, "limit": {"3"}, "isActive": {"true"}}
+		for pages := 0; ; pages++ {
+			if pages > 5 {
+				t.Fatalf("sort %s: too many pages", sort)
+			}
+			spec, err := query.Parse(values, habits.ListSchema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			list, page, err := repo.List(context.Background(), uuid.MustParse(ws.ID), nil, spec)
+			if err != nil {
+				t.Fatalf("sort %s: %v", sort, err)
+			}
+			for _, h := range list {
+				if seen[h.ID] {
+					t.Errorf("sort %s: habit %s returned twice", sort, h.ID)
+				}
+				seen[h.ID] = true
+			}
+			if !page.HasMore {
+				break
+			}
+			values.Set("cursor", page.NextCursor)
+		}
+		if len(seen) != len(want) {
+			t.Errorf("sort %s: got %d habits, want %d", sort, len(seen), len(want))
+		}
+	}
+}
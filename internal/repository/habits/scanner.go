@@ -6,13 +6,14 @@ import (
 	"time"
 
 	"backend/internal/model"
+	"backend/pkg/query"
 
 	"github.com/lib/pq"
 )
 
-// scanHabits сканирует привычки из rows
-func scanHabits(rows *sql.Rows) ([]model.Habit, error) {
-	var habits []model.Habit
+// scanHabits сканирует привычки из rows. keys != nil — после колонок привычки идёт ключ сортировки (query.Spec.KeyColumn).
+func scanHabits(rows *sql.Rows, keys *[]query.Key) ([]model.Habit, error) {
+	habits := make([]model.Habit, 0)
 	for rows.Next() {
 		var habit model.Habit
 		var createdAt, updatedAt time.Time
@@ -21,7 +22,8 @@ func scanHabits(rows *sql.Rows) ([]model.Habit, error) {
 		var oneTimeDatePtr sql.NullTime
 		var recurringDaysArray pq.Int32Array
 
-		err := rows.Scan(
+		var key string
+		dest := []interface{}{
 			&habit.ID,
 			&habit.Title,
 			&habit.Description,
@@ -39,8 +41,11 @@ func scanHabits(rows *sql.Rows) ([]model.Habit, error) {
 			&habit.WorkspaceID,
 			&createdAt,
 			&updatedAt,
-		)
-		if err != nil {
+		}
+		if keys != nil {
+			dest = append(dest, &key)
+		}
+		if err := rows.Scan(dest...); err != nil {
 			return nil, fmt.Errorf("failed to scan habit: %w", err)
 		}
 
@@ -57,6 +62,9 @@ func scanHabits(rows *sql.Rows) ([]model.Habit, error) {
 		habit.CreatedAt = createdAt.Format(time.RFC3339)
 		habit.UpdatedAt = updatedAt.Format(time.RFC3339)
 		habits = append(habits, habit)
+		if keys != nil {
+			*keys = append(*keys, query.Key{Value: key, ID: habit.ID})
+		}
 	}
 
 	if err := rows.Err(); err != nil {
@@ -11,7 +11,7 @@ import (
 
 // VersionRepository управляет версиями привычек для исторического календаря
 type VersionRepository struct {
-	db *sql.DB
+	db dbtx
 }
 
 // NewVersionRepository создает новый VersionRepository
@@ -109,3 +109,21 @@ func (r *VersionRepository) ClosePrevious(ctx context.Context, habitID, userID,
 	}
 	return res.RowsAffected()
 }
+
+// Backdate переносит начало первой версии привычки на from, если она начинается позже.
+// false — у привычки нет версий.
+func (r *VersionRepository) Backdate(ctx context.Context, habitID, workspaceID uuid.UUID, from time.Time) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `
+		WITH first AS (
+			SELECT id, valid_from FROM habit_versions
+			WHERE habit_id = $1 AND workspace_id = $2
+			ORDER BY valid_from LIMIT 1
+		), moved AS (
+			UPDATE habit_versions v SET valid_from = $3
+			FROM first WHERE v.id = first.id AND first.valid_from > $3
+		)
+		SELECT EXISTS (SELECT 1 FROM first)
+	`, habitID, workspaceID, NormalizeDate(from)).Scan(&exists)
+	return exists, err
+}
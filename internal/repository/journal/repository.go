@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"backend/internal/model"
+	"backend/pkg/query"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -21,36 +22,56 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-const listQuery = `SELECT id, workspace_id, user_id, description, mood, date, tags, content_type, metadata, created_at, updated_at
-	FROM journal_entries WHERE workspace_id = $1 ORDER BY date DESC, updated_at DESC`
+// ListSchema — сортировки и фильтры списка записей дневника
+var ListSchema = query.Schema{
+	Sorts: map[string]query.SortField{
+		"date":      {Column: "date", Type: query.TypeDate},
+		"createdAt": {Column: "created_at", Type: query.TypeTimestamp},
+		"updatedAt": {Column: "updated_at", Type: query.TypeTimestamp},
+	},
+	Filters: map[string]query.Filter{
+		"date":     {Column: "date", Op: query.OpEq, Type: query.TypeDate},
+		"dateFrom": {Column: "date", Op: query.OpGte, Type: query.TypeDate},
+		"dateTo":   {Column: "date", Op: query.OpLte, Type: query.TypeDate},
+		"tags":     {Column: "tags", Op: query.OpAnyOf, Type: query.TypeText}, // хотя бы один из тегов
+		"tagsAll":  {Column: "tags", Op: query.OpAllOf, Type: query.TypeText}, // все теги
+		"moodMin":  {Column: "mood", Op: query.OpGte, Type: query.TypeInt},
+		"moodMax":  {Column: "mood", Op: query.OpLte, Type: query.TypeInt},
+		"userId":   {Column: "user_id", Op: query.OpEq, Type: query.TypeUUID},
+	},
+	DefaultSort: "-date",
+}
 
-func (r *Repository) List(ctx context.Context, workspaceID uuid.UUID, date *time.Time) ([]model.JournalEntry, error) {
-	query := listQuery
-	args := []interface{}{workspaceID}
-	if date != nil {
-		query = `SELECT id, workspace_id, user_id, description, mood, date, tags, content_type, metadata, created_at, updated_at
-			FROM journal_entries WHERE workspace_id = $1 AND date = $2 ORDER BY updated_at DESC`
-		args = append(args, date.Format("2006-01-02"))
-	}
-	rows, err := r.db.QueryContext(ctx, query, args...)
+func (r *Repository) List(ctx context.Context, workspaceID uuid.UUID, spec query.Spec) ([]model.JournalEntry, query.Page, error) {
+	where, tail, args := spec.SQL(2)
+	q := `SELECT id, workspace_id, user_id, description, mood, date, tags, content_type, metadata, created_at, updated_at, ` +
+		spec.KeyColumn() + ` FROM journal_entries WHERE workspace_id = $1 AND deleted_at IS NULL` + where + tail
+	rows, err := r.db.QueryContext(ctx, q, append([]interface{}{workspaceID}, args...)...)
 	if err != nil {
-		return nil, fmt.Errorf("list journal entries: %w", err)
+		return nil, query.Page{}, fmt.Errorf("list journal entries: %w", err)
 	}
 	defer rows.Close()
-	var list []model.JournalEntry
+	list := make([]model.JournalEntry, 0)
+	var keys []query.Key
 	for rows.Next() {
-		entry, err := scanEntry(rows)
+		var key string
+		entry, err := scanEntry(rows, &key)
 		if err != nil {
-			return nil, err
+			return nil, query.Page{}, err
 		}
 		list = append(list, *entry)
+		keys = append(keys, query.Key{Value: key, ID: entry.ID})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, query.Page{}, err
 	}
-	return list, rows.Err()
+	list, page := query.Paginate(spec, list, keys)
+	return list, page, nil
 }
 
 func (r *Repository) Get(ctx context.Context, id, workspaceID uuid.UUID) (*model.JournalEntry, error) {
 	query := `SELECT id, workspace_id, user_id, description, mood, date, tags, content_type, metadata, created_at, updated_at
-		FROM journal_entries WHERE id = $1 AND workspace_id = $2`
+		FROM journal_entries WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NULL`
 	row := r.db.QueryRowContext(ctx, query, id, workspaceID)
 	entry, err := scanEntryRow(row)
 	if err == sql.ErrNoRows {
@@ -86,12 +107,24 @@ func (r *Repository) Create(ctx context.Context, e *model.JournalEntry) error {
 	query := `INSERT INTO journal_entries (id, workspace_id, user_id, description, mood, date, tags, content_type, metadata, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6::date, $7, $8, $9, $10, $10)
 		RETURNING id, created_at, updated_at`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	var createdAt, updatedAt time.Time
-	err := r.db.QueryRowContext(ctx, query, id, wsID, userID, e.Description, e.Mood, date, pq.Array(tags), contentType, metadataJSON, now).
+	err = tx.QueryRowContext(ctx, query, id, wsID, userID, e.Description, e.Mood, date, pq.Array(tags), contentType, metadataJSON, now).
 		Scan(&e.ID, &createdAt, &updatedAt)
 	if err != nil {
 		return fmt.Errorf("create journal entry: %w", err)
 	}
+	if err := insertRevisions(ctx, tx, []string{e.ID}, userID, nil); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
 	e.Date = date
 	e.ContentType = contentType
 	e.CreatedAt = createdAt.Format(time.RFC3339)
@@ -99,7 +132,8 @@ func (r *Repository) Create(ctx context.Context, e *model.JournalEntry) error {
 	return nil
 }
 
-func (r *Repository) Update(ctx context.Context, e *model.JournalEntry) error {
+// Update сохраняет запись и добавляет ревизию от имени authorID. restoredFrom — номер ревизии, если это восстановление.
+func (r *Repository) Update(ctx context.Context, e *model.JournalEntry, authorID uuid.UUID, restoredFrom *int) error {
 	metadataJSON, _ := json.Marshal(e.Metadata)
 	if e.Metadata == nil {
 		metadataJSON = []byte("{}")
@@ -108,9 +142,16 @@ func (r *Repository) Update(ctx context.Context, e *model.JournalEntry) error {
 	if tags == nil {
 		tags = []string{}
 	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	var updatedAt time.Time
-	err := r.db.QueryRowContext(ctx, `UPDATE journal_entries SET description = $3, mood = $4, date = $5::date, tags = $6, content_type = $7, metadata = $8, updated_at = NOW()
-		WHERE id = $1 AND workspace_id = $2 RETURNING updated_at`,
+	err = tx.QueryRowContext(ctx, `UPDATE journal_entries SET description = $3, mood = $4, date = $5::date, tags = $6, content_type = $7, metadata = $8, updated_at = NOW()
+		WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NULL RETURNING updated_at`,
 		e.ID, e.WorkspaceID, e.Description, e.Mood, e.Date, pq.Array(tags), e.ContentType, metadataJSON).Scan(&updatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -118,17 +159,40 @@ func (r *Repository) Update(ctx context.Context, e *model.JournalEntry) error {
 		}
 		return err
 	}
+	if err := insertRevisions(ctx, tx, []string{e.ID}, authorID, restoredFrom); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
 	e.UpdatedAt = updatedAt.Format(time.RFC3339)
 	return nil
 }
 
+// Delete переносит запись в корзину; восстановление и окончательное удаление — repository/trash
 func (r *Repository) Delete(ctx context.Context, id, workspaceID uuid.UUID) error {
-	res, err := r.db.ExecContext(ctx, `DELETE FROM journal_entries WHERE id = $1 AND workspace_id = $2`, id, workspaceID)
+	return r.exec(ctx, `UPDATE journal_entries SET deleted_at = NOW() WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NULL`,
+		id, workspaceID)
+}
+
+// Restore возвращает запись из корзины; sql.ErrNoRows — записи нет в корзине
+func (r *Repository) Restore(ctx context.Context, id, workspaceID uuid.UUID) error {
+	return r.exec(ctx, `UPDATE journal_entries SET deleted_at = NULL WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NOT NULL`,
+		id, workspaceID)
+}
+
+// Purge окончательно удаляет запись из корзины вместе с ревизиями и вложениями; sql.ErrNoRows — записи нет в корзине
+func (r *Repository) Purge(ctx context.Context, id, workspaceID uuid.UUID) error {
+	return r.exec(ctx, `DELETE FROM journal_entries WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NOT NULL`, id, workspaceID)
+}
+
+// exec выполняет изменение одной строки; sql.ErrNoRows — строка не найдена
+func (r *Repository) exec(ctx context.Context, q string, args ...interface{}) error {
+	res, err := r.db.ExecContext(ctx, q, args...)
 	if err != nil {
 		return err
 	}
-	n, _ := res.RowsAffected()
-	if n == 0 {
+	if n, _ := res.RowsAffected(); n == 0 {
 		return sql.ErrNoRows
 	}
 	return nil
@@ -159,14 +223,16 @@ func scanEntryRow(row *sql.Row) (*model.JournalEntry, error) {
 	return &e, nil
 }
 
-func scanEntry(rows *sql.Rows) (*model.JournalEntry, error) {
+// scanEntry сканирует строку списка; extra — дополнительные колонки после основных (ключ сортировки)
+func scanEntry(rows *sql.Rows, extra ...interface{}) (*model.JournalEntry, error) {
 	var e model.JournalEntry
 	var mood sql.NullInt32
 	var tags pq.StringArray
 	var metadataBytes []byte
 	var date time.Time
 	var createdAt, updatedAt time.Time
-	err := rows.Scan(&e.ID, &e.WorkspaceID, &e.UserID, &e.Description, &mood, &date, &tags, &e.ContentType, &metadataBytes, &createdAt, &updatedAt)
+	dest := []interface{}{&e.ID, &e.WorkspaceID, &e.UserID, &e.Description, &mood, &date, &tags, &e.ContentType, &metadataBytes, &createdAt, &updatedAt}
+	err := rows.Scan(append(dest, extra...)...)
 	if err != nil {
 		return nil, err
 	}
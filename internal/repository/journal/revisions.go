@@ -0,0 +1,103 @@
+package journal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// insertRevisions записывает текущее состояние записей entryIDs новой ревизией (номер — следующий по записи).
+// Если запись не изменилась с последней ревизии, новая не создаётся. Вызывается в транзакции изменения записи:
+// строка записи уже заблокирована UPDATE, поэтому номера ревизий не конфликтуют.
+func insertRevisions(ctx context.Context, tx *sql.Tx, entryIDs []string, authorID uuid.UUID, restoredFrom *int) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO journal_entry_revisions
+			(entry_id, workspace_id, revision, author_id, description, mood, date, tags, content_type, metadata, restored_from, created_at)
+		SELECT e.id, e.workspace_id, COALESCE(last.revision, 0) + 1, $2,
+			e.description, e.mood, e.date, e.tags, e.content_type, e.metadata, $3, e.updated_at
+		FROM journal_entries e
+		LEFT JOIN LATERAL (
+			SELECT revision, description, mood, date, tags, content_type, metadata
+			FROM journal_entry_revisions WHERE entry_id = e.id
+			ORDER BY revision DESC LIMIT 1
+		) last ON true
+		WHERE e.id = ANY($1::uuid[])
+			AND (last.revision IS NULL
+				OR (last.description, last.mood, last.date, last.tags, last.content_type, last.metadata)
+					IS DISTINCT FROM (e.description, e.mood, e.date, e.tags, e.content_type, e.metadata))
+	`, pq.Array(entryIDs), authorID, restoredFrom)
+	if err != nil {
+		return fmt.Errorf("insert journal revision: %w", err)
+	}
+	return nil
+}
+
+const revisionColumns = `entry_id, revision, author_id, description, mood, date, tags, content_type, metadata, restored_from, created_at`
+
+// ListRevisions возвращает ревизии записи, новые сверху
+func (r *Repository) ListRevisions(ctx context.Context, entryID, workspaceID uuid.UUID) ([]model.JournalRevision, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+revisionColumns+` FROM journal_entry_revisions
+		WHERE entry_id = $1 AND workspace_id = $2 ORDER BY revision DESC`, entryID, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("list journal revisions: %w", err)
+	}
+	defer rows.Close()
+	list := make([]model.JournalRevision, 0)
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *rev)
+	}
+	return list, rows.Err()
+}
+
+// GetRevision возвращает ревизию записи; nil — нет такой
+func (r *Repository) GetRevision(ctx context.Context, entryID, workspaceID uuid.UUID, revision int) (*model.JournalRevision, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+revisionColumns+` FROM journal_entry_revisions
+		WHERE entry_id = $1 AND workspace_id = $2 AND revision = $3`, entryID, workspaceID, revision)
+	rev, err := scanRevision(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rev, err
+}
+
+func scanRevision(row interface{ Scan(...interface{}) error }) (*model.JournalRevision, error) {
+	var rev model.JournalRevision
+	var authorID sql.NullString
+	var mood, restoredFrom sql.NullInt32
+	var date, createdAt time.Time
+	var tags pq.StringArray
+	var metadata []byte
+	if err := row.Scan(&rev.EntryID, &rev.Revision, &authorID, &rev.Description, &mood, &date, &tags,
+		&rev.ContentType, &metadata, &restoredFrom, &createdAt); err != nil {
+		return nil, err
+	}
+	if authorID.Valid {
+		rev.AuthorID = &authorID.String
+	}
+	if mood.Valid {
+		m := int(mood.Int32)
+		rev.Mood = &m
+	}
+	if restoredFrom.Valid {
+		n := int(restoredFrom.Int32)
+		rev.RestoredFrom = &n
+	}
+	if len(metadata) > 0 {
+		_ = json.Unmarshal(metadata, &rev.Metadata)
+	}
+	rev.Date = date.Format("2006-01-02")
+	rev.Tags = tags
+	rev.CreatedAt = createdAt.Format(time.RFC3339)
+	return &rev, nil
+}
@@ -0,0 +1,149 @@
+package journal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// StatsFilter — период и автор для статистики дневника. Пустые поля не фильтруют.
+type StatsFilter struct {
+	From   *time.Time
+	To     *time.Time
+	UserID *uuid.UUID
+}
+
+func (f StatsFilter) where(args []interface{}) (string, []interface{}) {
+	where := ` WHERE workspace_id = $1 AND deleted_at IS NULL`
+	if f.From != nil {
+		args = append(args, f.From.Format("2006-01-02"))
+		where += fmt.Sprintf(` AND date >= $%d`, len(args))
+	}
+	if f.To != nil {
+		args = append(args, f.To.Format("2006-01-02"))
+		where += fmt.Sprintf(` AND date <= $%d`, len(args))
+	}
+	if f.UserID != nil {
+		args = append(args, *f.UserID)
+		where += fmt.Sprintf(` AND user_id = $%d`, len(args))
+	}
+	return where, args
+}
+
+// TagCounts — сколько записей с каждым тегом (облако тегов), частые сверху
+func (r *Repository) TagCounts(ctx context.Context, workspaceID uuid.UUID, f StatsFilter, limit int) ([]model.JournalTagCount, error) {
+	where, args := f.where([]interface{}{workspaceID})
+	args = append(args, limit)
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT tag, COUNT(*), MAX(date)
+		FROM journal_entries, unnest(tags) AS tag%s
+		GROUP BY tag
+		ORDER BY COUNT(*) DESC, tag
+		LIMIT $%d
+	`, where, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("journal tag counts: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]model.JournalTagCount, 0)
+	for rows.Next() {
+		var tc model.JournalTagCount
+		var lastUsed time.Time
+		if err := rows.Scan(&tc.Tag, &tc.Count, &lastUsed); err != nil {
+			return nil, err
+		}
+		tc.LastUsed = lastUsed.Format("2006-01-02")
+		list = append(list, tc)
+	}
+	return list, rows.Err()
+}
+
+// MoodSeries — настроение по периодам: среднее, минимум, максимум и число записей с оценкой.
+// period — "day" или "week" (неделя с понедельника). Периоды без оценок не возвращаются.
+func (r *Repository) MoodSeries(ctx context.Context, workspaceID uuid.UUID, f StatsFilter, period string) ([]model.JournalMoodPoint, error) {
+	bucket := `date`
+	if period == model.MoodPeriodWeek {
+		bucket = `date_trunc('week', date)::date`
+	}
+	where, args := f.where([]interface{}{workspaceID})
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT %s AS period, AVG(mood)::float8, MIN(mood), MAX(mood), COUNT(*)
+		FROM journal_entries%s AND mood IS NOT NULL
+		GROUP BY period
+		ORDER BY period
+	`, bucket, where), args...)
+	if err != nil {
+		return nil, fmt.Errorf("journal mood series: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]model.JournalMoodPoint, 0)
+	for rows.Next() {
+		var p model.JournalMoodPoint
+		var start time.Time
+		if err := rows.Scan(&start, &p.Average, &p.Min, &p.Max, &p.Entries); err != nil {
+			return nil, err
+		}
+		p.Period = start.Format("2006-01-02")
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// RenameTags заменяет теги from на to во всех записях воркспейса. Если у записи уже есть to (или несколько
+// тегов из from), остаётся один — на месте первого вхождения. Каждая изменённая запись получает ревизию от authorID.
+// Возвращает число изменённых записей.
+func (r *Repository) RenameTags(ctx context.Context, workspaceID uuid.UUID, from []string, to string, authorID uuid.UUID) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE journal_entries SET
+			tags = (
+				SELECT COALESCE(array_agg(t ORDER BY pos), '{}')
+				FROM (
+					SELECT CASE WHEN tag = ANY($2::text[]) THEN $3 ELSE tag END AS t, MIN(pos) AS pos
+					FROM unnest(tags) WITH ORDINALITY AS u(tag, pos)
+					GROUP BY 1
+				) renamed
+			),
+			updated_at = NOW()
+		WHERE workspace_id = $1 AND deleted_at IS NULL AND tags && $2::text[]
+		RETURNING id
+	`, workspaceID, pq.Array(from), to)
+	if err != nil {
+		return 0, fmt.Errorf("rename journal tags: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(ids) > 0 {
+		if err := insertRevisions(ctx, tx, ids, authorID, nil); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), nil
+}
@@ -0,0 +1,198 @@
+package links
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+var ErrLinkExists = errors.New("link already exists")
+
+// Ref — ссылка на сущность из текста: по id (Key — UUID) или по названию (для дневника — дата YYYY-MM-DD)
+type Ref struct {
+	Type string
+	Key  string
+}
+
+// entity — таблица сущности, выражение для её названия и условие «не в корзине» (алиас таблицы — e)
+type entity struct {
+	table string
+	title string
+	live  string
+}
+
+var entities = map[string]entity{
+	model.SearchTypeNote:         {"notes", "e.title", "e.deleted_at IS NULL"},
+	model.SearchTypeJournal:      {"journal_entries", "to_char(e.date, 'YYYY-MM-DD')", "e.deleted_at IS NULL"},
+	model.SearchTypeHabit:        {"habits", "e.title", "e.deleted_at IS NULL"},
+	model.SearchTypeCounterparty: {"counterparties", "e.name", "true"},
+}
+
+// titleOf — SQL-выражение с названием сущности по колонкам типа и id
+func titleOf(typeColumn, idColumn string) string {
+	var b strings.Builder
+	b.WriteString("COALESCE(CASE " + typeColumn)
+	for _, t := range model.SearchTypes {
+		e := entities[t]
+		fmt.Fprintf(&b, " WHEN '%s' THEN (SELECT %s FROM %s e WHERE e.id = %s)", t, e.title, e.table, idColumn)
+	}
+	b.WriteString(" END, '')")
+	return b.String()
+}
+
+// liveOf — SQL-условие: сущность по колонкам типа и id есть и не в корзине. Связи с сущностью из корзины
+// хранятся до окончательного удаления, но в списках не показываются.
+func liveOf(typeColumn, idColumn string) string {
+	var b strings.Builder
+	b.WriteString("COALESCE(CASE " + typeColumn)
+	for _, t := range model.SearchTypes {
+		e := entities[t]
+		fmt.Fprintf(&b, " WHEN '%s' THEN EXISTS (SELECT 1 FROM %s e WHERE e.id = %s AND %s)", t, e.table, idColumn, e.live)
+	}
+	b.WriteString(" END, false)")
+	return b.String()
+}
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Exists — есть ли сущность типа entityType в воркспейсе (не в корзине)
+func (r *Repository) Exists(ctx context.Context, workspaceID uuid.UUID, entityType string, id uuid.UUID) (bool, error) {
+	e, ok := entities[entityType]
+	if !ok {
+		return false, nil
+	}
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM `+e.table+` e WHERE e.id = $1 AND e.workspace_id = $2 AND `+e.live+`)`, id, workspaceID).Scan(&exists)
+	return exists, err
+}
+
+var (
+	selectTargets = `SELECT l.id, l.target_type, l.target_id, ` + titleOf("l.target_type", "l.target_id") + `, l.origin, l.created_at FROM entity_links l`
+	selectSources = `SELECT l.id, l.source_type, l.source_id, ` + titleOf("l.source_type", "l.source_id") + `, l.origin, l.created_at FROM entity_links l`
+)
+
+// Outgoing — на что ссылается сущность
+func (r *Repository) Outgoing(ctx context.Context, workspaceID uuid.UUID, entityType string, id uuid.UUID) ([]model.LinkedEntity, error) {
+	return r.list(ctx, selectTargets+` WHERE l.workspace_id = $1 AND l.source_type = $2 AND l.source_id = $3
+		AND `+liveOf("l.target_type", "l.target_id")+` ORDER BY l.created_at, l.id`, workspaceID, entityType, id)
+}
+
+// Backlinks — что ссылается на сущность
+func (r *Repository) Backlinks(ctx context.Context, workspaceID uuid.UUID, entityType string, id uuid.UUID) ([]model.LinkedEntity, error) {
+	return r.list(ctx, selectSources+` WHERE l.workspace_id = $1 AND l.target_type = $2 AND l.target_id = $3
+		AND `+liveOf("l.source_type", "l.source_id")+` ORDER BY l.created_at, l.id`, workspaceID, entityType, id)
+}
+
+// Target — связь со стороны источника (сущность, на которую она ведёт); nil — связи нет
+func (r *Repository) Target(ctx context.Context, workspaceID, linkID uuid.UUID) (*model.LinkedEntity, error) {
+	list, err := r.list(ctx, selectTargets+` WHERE l.id = $1 AND l.workspace_id = $2`, linkID, workspaceID)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+func (r *Repository) list(ctx context.Context, q string, args ...interface{}) ([]model.LinkedEntity, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list entity links: %w", err)
+	}
+	defer rows.Close()
+	list := make([]model.LinkedEntity, 0)
+	for rows.Next() {
+		var l model.LinkedEntity
+		var createdAt time.Time
+		if err := rows.Scan(&l.LinkID, &l.Type, &l.ID, &l.Title, &l.Origin, &createdAt); err != nil {
+			return nil, err
+		}
+		l.CreatedAt = createdAt.Format(time.RFC3339)
+		list = append(list, l)
+	}
+	return list, rows.Err()
+}
+
+// CreateManual добавляет ручную связь; ErrLinkExists — такая уже есть
+func (r *Repository) CreateManual(ctx context.Context, workspaceID uuid.UUID, sourceType string, sourceID uuid.UUID, targetType string, targetID, createdBy uuid.UUID) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, `INSERT INTO entity_links (workspace_id, source_type, source_id, target_type, target_id, origin, created_by)
+		VALUES ($1, $2, $3, $4, $5, 'manual', $6)
+		ON CONFLICT (source_type, source_id, target_type, target_id, origin) DO NOTHING
+		RETURNING id`,
+		workspaceID, sourceType, sourceID, targetType, targetID, createdBy,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, ErrLinkExists
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("create entity link: %w", err)
+	}
+	return id, nil
+}
+
+// Origin — происхождение связи (wiki/manual); sql.ErrNoRows — связи нет в воркспейсе
+func (r *Repository) Origin(ctx context.Context, workspaceID, linkID uuid.UUID) (string, error) {
+	var origin string
+	err := r.db.QueryRowContext(ctx, `SELECT origin FROM entity_links WHERE id = $1 AND workspace_id = $2`, linkID, workspaceID).Scan(&origin)
+	return origin, err
+}
+
+// DeleteManual удаляет ручную связь; sql.ErrNoRows — такой нет
+func (r *Repository) DeleteManual(ctx context.Context, workspaceID, linkID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM entity_links WHERE id = $1 AND workspace_id = $2 AND origin = 'manual'`, linkID, workspaceID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ReplaceWiki заменяет wiki-связи источника связями на refs. Ссылки, которые не нашлись в воркспейсе (или ведут
+// в корзину), и ссылки на сам источник пропускаются. Если по названию подходит несколько сущностей, берётся последняя изменённая.
+func (r *Repository) ReplaceWiki(ctx context.Context, workspaceID uuid.UUID, sourceType string, sourceID uuid.UUID, refs []Ref) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM entity_links WHERE source_type = $1 AND source_id = $2 AND origin = 'wiki'`,
+		sourceType, sourceID); err != nil {
+		return fmt.Errorf("clear wiki links: %w", err)
+	}
+	for _, ref := range refs {
+		e, ok := entities[ref.Type]
+		if !ok {
+			continue
+		}
+		match := `lower(` + e.title + `) = lower($5::text)`
+		if _, err := uuid.Parse(ref.Key); err == nil {
+			match = `e.id = $5::uuid`
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO entity_links (workspace_id, source_type, source_id, target_type, target_id, origin)
+			SELECT $1::uuid, $2::text, $3::uuid, $4::text, e.id, 'wiki' FROM `+e.table+` e
+			WHERE e.workspace_id = $1::uuid AND `+e.live+` AND `+match+` AND NOT ($2::text = $4::text AND e.id = $3::uuid)
+			ORDER BY e.updated_at DESC, e.id
+			LIMIT 1
+			ON CONFLICT (source_type, source_id, target_type, target_id, origin) DO NOTHING`,
+			workspaceID, sourceType, sourceID, ref.Type, ref.Key)
+		if err != nil {
+			return fmt.Errorf("add wiki link: %w", err)
+		}
+	}
+	return tx.Commit()
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -16,84 +17,431 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-// BatchInsert вставляет множество записей в БД
-func (r *Repository) BatchInsert(entries []*model.LogEntry) error {
-	if len(entries) == 0 {
-		return nil
-	}
-
-	ctx := context.Background()
+// InsertBatch вставляет записи одного лог-файла и в той же транзакции сдвигает чекпоинт файла.
+// Записи с уже известным content_hash пропускаются, поэтому повторная загрузка безопасна.
+// Чекпоинт не уменьшается: повторная загрузка с начала файла (backfill) его не откатывает.
+// Записи за дни, которые уже свёрнуты в request_log_daily_stats, не вставляются — иначе они были бы посчитаны дважды.
+func (r *Repository) InsertBatch(ctx context.Context, fileName string, entries []*model.LogEntry, offset int64) (int64, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO request_logs (timestamp, status_code, duration_ms, client_ip, method, path, raw_log)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
-	for _, entry := range entries {
-		durationMs := float64(entry.Duration.Nanoseconds()) / 1000000.0
-		_, err := stmt.ExecContext(ctx,
-			entry.Timestamp,
-			entry.StatusCode,
-			durationMs,
-			entry.ClientIP,
-			entry.Method,
-			entry.Path,
-			entry.RawLog,
-		)
+	// FOR SHARE: свёртка (RollupBefore) ждёт завершения загрузки и наоборот
+	var rolledUpBefore sql.NullTime
+	err = tx.QueryRowContext(ctx,
+		`SELECT rolled_up_before FROM request_log_retention WHERE id FOR SHARE`,
+	).Scan(&rolledUpBefore)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	var inserted int64
+	if len(entries) > 0 {
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO request_logs (
+				timestamp, request_id, status_code, duration_ms, client_ip, method, path, route,
+				user_id, workspace_id, bytes_out, user_agent, error, raw_log, content_hash
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+			ON CONFLICT (content_hash) DO NOTHING
+		`)
 		if err != nil {
-			return err
+			return 0, err
 		}
+		defer stmt.Close()
+
+		for _, entry := range entries {
+			if rolledUpBefore.Valid && entry.Timestamp.Format("2006-01-02") < rolledUpBefore.Time.Format("2006-01-02") {
+				continue
+			}
+			durationMs := float64(entry.Duration.Nanoseconds()) / 1000000.0
+			res, err := stmt.ExecContext(ctx,
+				entry.Timestamp,
+				nullString(entry.RequestID),
+				entry.StatusCode,
+				durationMs,
+				entry.ClientIP,
+				entry.Method,
+				entry.Path,
+				nullString(entry.Route),
+				nullString(entry.UserID),
+				nullString(entry.WorkspaceID),
+				entry.BytesOut,
+				nullString(entry.UserAgent),
+				nullString(entry.Error),
+				entry.RawLog,
+				entry.ContentHash,
+			)
+			if err != nil {
+				return 0, err
+			}
+			n, _ := res.RowsAffected()
+			inserted += n
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO request_log_checkpoints (file_name, file_offset, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (file_name) DO UPDATE
+		SET file_offset = GREATEST(request_log_checkpoints.file_offset, EXCLUDED.file_offset), updated_at = NOW()
+	`, fileName, offset)
+	if err != nil {
+		return 0, err
+	}
+
+	return inserted, tx.Commit()
+}
+
+// GetCheckpoint возвращает смещение, до которого файл уже загружен (0, если файл ещё не загружался)
+func (r *Repository) GetCheckpoint(ctx context.Context, fileName string) (int64, error) {
+	var offset int64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT file_offset FROM request_log_checkpoints WHERE file_name = $1`, fileName,
+	).Scan(&offset)
+	if err == sql.ErrNoRows {
+		return 0, nil
 	}
+	return offset, err
+}
 
-	return tx.Commit()
+// DeleteCheckpoint удаляет чекпоинт файла (после удаления самого файла по ретеншну)
+func (r *Repository) DeleteCheckpoint(ctx context.Context, fileName string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM request_log_checkpoints WHERE file_name = $1`, fileName)
+	return err
 }
 
-// GetLogsByDate возвращает логи за определенную дату
-func (r *Repository) GetLogsByDate(ctx context.Context, date time.Time) ([]*model.LogEntry, error) {
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
+// RollupBefore сворачивает строки request_logs раньше дня before в request_log_daily_stats и удаляет их.
+// Всё выполняется в одной транзакции; возвращает число строк агрегатов и число удалённых строк логов.
+func (r *Repository) RollupBefore(ctx context.Context, before time.Time) (int64, int64, error) {
+	day := before.Format("2006-01-02")
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM request_log_retention WHERE id FOR UPDATE`); err != nil {
+		return 0, 0, err
+	}
 
-	rows, err := r.db.QueryContext(ctx, `
-		SELECT timestamp, status_code, duration_ms, client_ip, method, path, raw_log
+	// Повторный день (например, после ручной правки границы) складывается с уже посчитанным;
+	// перцентили в этом случае берутся по максимуму — точно пересчитать их без сырых строк нельзя
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO request_log_daily_stats (
+			day, method, route, requests, client_errors, server_errors,
+			total_ms, max_ms, p50_ms, p95_ms, p99_ms, bytes_out
+		)
+		SELECT timestamp::date, method, `+routeExpr+`, COUNT(*),
+			COUNT(*) FILTER (WHERE status_code >= 400 AND status_code < 500),
+			COUNT(*) FILTER (WHERE status_code >= 500),
+			SUM(duration_ms)::float8,
+			MAX(duration_ms)::float8,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY duration_ms)::float8,
+			percentile_cont(0.95) WITHIN GROUP (ORDER BY duration_ms)::float8,
+			percentile_cont(0.99) WITHIN GROUP (ORDER BY duration_ms)::float8,
+			SUM(bytes_out)
 		FROM request_logs
-		WHERE timestamp >= $1 AND timestamp < $2
-		ORDER BY timestamp ASC
-	`, startOfDay, endOfDay)
+		WHERE timestamp < $1::date
+		GROUP BY 1, 2, 3
+		ON CONFLICT (day, method, route) DO UPDATE SET
+			requests = request_log_daily_stats.requests + EXCLUDED.requests,
+			client_errors = request_log_daily_stats.client_errors + EXCLUDED.client_errors,
+			server_errors = request_log_daily_stats.server_errors + EXCLUDED.server_errors,
+			total_ms = request_log_daily_stats.total_ms + EXCLUDED.total_ms,
+			max_ms = GREATEST(request_log_daily_stats.max_ms, EXCLUDED.max_ms),
+			p50_ms = GREATEST(request_log_daily_stats.p50_ms, EXCLUDED.p50_ms),
+			p95_ms = GREATEST(request_log_daily_stats.p95_ms, EXCLUDED.p95_ms),
+			p99_ms = GREATEST(request_log_daily_stats.p99_ms, EXCLUDED.p99_ms),
+			bytes_out = request_log_daily_stats.bytes_out + EXCLUDED.bytes_out
+	`, day)
 	if err != nil {
-		return nil, err
+		return 0, 0, fmt.Errorf("rollup request logs: %w", err)
+	}
+	rolled, _ := res.RowsAffected()
+
+	res, err = tx.ExecContext(ctx, `DELETE FROM request_logs WHERE timestamp < $1::date`, day)
+	if err != nil {
+		return 0, 0, fmt.Errorf("prune request logs: %w", err)
+	}
+	pruned, _ := res.RowsAffected()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE request_log_retention
+		SET rolled_up_before = GREATEST(COALESCE(rolled_up_before, $1::date), $1::date), updated_at = NOW()
+		WHERE id
+	`, day)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return rolled, pruned, tx.Commit()
+}
+
+// DailyStats возвращает дневные агрегаты (новые дни сверху). Учитываются From, To, Method, PathPrefix (по маршруту), Limit, Offset.
+func (r *Repository) DailyStats(ctx context.Context, f model.LogFilter) ([]model.DailyRouteStats, error) {
+	conds := []string{}
+	args := []interface{}{}
+	if f.From != nil {
+		args = append(args, f.From.Format("2006-01-02"))
+		conds = append(conds, fmt.Sprintf("day >= $%d::date", len(args)))
+	}
+	if f.To != nil {
+		args = append(args, f.To.Format("2006-01-02"))
+		conds = append(conds, fmt.Sprintf("day < $%d::date", len(args)))
+	}
+	if f.Method != "" {
+		args = append(args, strings.ToUpper(f.Method))
+		conds = append(conds, fmt.Sprintf("method = $%d", len(args)))
+	}
+	if f.PathPrefix != "" {
+		args = append(args, escapeLike(f.PathPrefix))
+		conds = append(conds, fmt.Sprintf(`route LIKE $%d || '%%' ESCAPE '\'`, len(args)))
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT day, method, route, requests, client_errors, server_errors,
+			CASE WHEN requests > 0 THEN total_ms / requests ELSE 0 END,
+			max_ms, p50_ms, p95_ms, p99_ms, bytes_out
+		FROM request_log_daily_stats%s
+		ORDER BY day DESC, requests DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)+1, len(args)+2)
+	rows, err := r.db.QueryContext(ctx, query, append(args, f.Limit, f.Offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("daily stats: %w", err)
 	}
 	defer rows.Close()
 
-	fmt.Println("GetLogsByDate", rows)
+	list := make([]model.DailyRouteStats, 0)
+	for rows.Next() {
+		var st model.DailyRouteStats
+		var day time.Time
+		if err := rows.Scan(&day, &st.Method, &st.Route, &st.Requests, &st.ClientErrors, &st.ServerErrors,
+			&st.AvgMs, &st.MaxMs, &st.P50Ms, &st.P95Ms, &st.P99Ms, &st.BytesOut); err != nil {
+			return nil, err
+		}
+		st.Day = day.Format("2006-01-02")
+		list = append(list, st)
+	}
+	return list, rows.Err()
+}
+
+const logColumns = `timestamp, request_id, status_code, duration_ms, client_ip, method, path, route,
+	user_id, workspace_id, bytes_out, user_agent, error, raw_log`
+
+// routeExpr — шаблон маршрута; для старых записей и 404 без маршрута берём путь
+const routeExpr = `COALESCE(NULLIF(route, ''), path)`
+
+// List возвращает страницу логов по фильтру (новые сверху) и общее количество подходящих записей
+func (r *Repository) List(ctx context.Context, f model.LogFilter) ([]*model.LogEntry, int64, error) {
+	where, args := buildLogWhere(f)
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM request_logs`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count logs: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM request_logs%s ORDER BY timestamp DESC, id DESC LIMIT $%d OFFSET $%d`,
+		logColumns, where, len(args)+1, len(args)+2)
+	rows, err := r.db.QueryContext(ctx, query, append(args, f.Limit, f.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list logs: %w", err)
+	}
+	defer rows.Close()
 
 	entries := make([]*model.LogEntry, 0)
 	for rows.Next() {
-		var entry model.LogEntry
-		var durationMs float64
-		err := rows.Scan(
-			&entry.Timestamp,
-			&entry.StatusCode,
-			&durationMs,
-			&entry.ClientIP,
-			&entry.Method,
-			&entry.Path,
-			&entry.RawLog,
-		)
+		entry, err := scanLogEntry(rows)
 		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, total, rows.Err()
+}
+
+// LatencyByRoute считает p50/p95/p99 длительности по маршрутам, самые медленные (p95) сверху
+func (r *Repository) LatencyByRoute(ctx context.Context, f model.LogFilter) ([]model.RouteLatencyStats, error) {
+	where, args := buildLogWhere(f)
+	query := fmt.Sprintf(`
+		SELECT method, %[1]s AS r, COUNT(*),
+			AVG(duration_ms)::float8,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY duration_ms)::float8,
+			percentile_cont(0.95) WITHIN GROUP (ORDER BY duration_ms)::float8,
+			percentile_cont(0.99) WITHIN GROUP (ORDER BY duration_ms)::float8
+		FROM request_logs%[2]s
+		GROUP BY method, r
+		ORDER BY 6 DESC
+		LIMIT $%[3]d
+	`, routeExpr, where, len(args)+1)
+	rows, err := r.db.QueryContext(ctx, query, append(args, f.Limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("latency by route: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]model.RouteLatencyStats, 0)
+	for rows.Next() {
+		var st model.RouteLatencyStats
+		if err := rows.Scan(&st.Method, &st.Route, &st.Count, &st.AvgMs, &st.P50Ms, &st.P95Ms, &st.P99Ms); err != nil {
+			return nil, err
+		}
+		list = append(list, st)
+	}
+	return list, rows.Err()
+}
+
+// ErrorsByRoute считает долю 4xx/5xx по маршрутам, маршруты с наибольшей долей ошибок сверху
+func (r *Repository) ErrorsByRoute(ctx context.Context, f model.LogFilter) ([]model.RouteErrorStats, error) {
+	where, args := buildLogWhere(f)
+	query := fmt.Sprintf(`
+		SELECT method, %[1]s AS r, COUNT(*),
+			COUNT(*) FILTER (WHERE status_code >= 400 AND status_code < 500),
+			COUNT(*) FILTER (WHERE status_code >= 500)
+		FROM request_logs%[2]s
+		GROUP BY method, r
+		ORDER BY (COUNT(*) FILTER (WHERE status_code >= 400))::float8 / COUNT(*) DESC, COUNT(*) DESC
+		LIMIT $%[3]d
+	`, routeExpr, where, len(args)+1)
+	rows, err := r.db.QueryContext(ctx, query, append(args, f.Limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("errors by route: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]model.RouteErrorStats, 0)
+	for rows.Next() {
+		var st model.RouteErrorStats
+		if err := rows.Scan(&st.Method, &st.Route, &st.Total, &st.ClientErrors, &st.ServerErrors); err != nil {
+			return nil, err
+		}
+		if st.Total > 0 {
+			st.ErrorRate = float64(st.ClientErrors+st.ServerErrors) / float64(st.Total)
+			st.ServerErrorRate = float64(st.ServerErrors) / float64(st.Total)
+		}
+		list = append(list, st)
+	}
+	return list, rows.Err()
+}
+
+// TopClients возвращает клиентов (IP) с наибольшим числом запросов
+func (r *Repository) TopClients(ctx context.Context, f model.LogFilter) ([]model.ClientStats, error) {
+	where, args := buildLogWhere(f)
+	query := fmt.Sprintf(`
+		SELECT client_ip, COUNT(*),
+			COUNT(*) FILTER (WHERE status_code >= 400),
+			COUNT(DISTINCT user_id),
+			AVG(duration_ms)::float8,
+			MAX(timestamp)
+		FROM request_logs%s
+		GROUP BY client_ip
+		ORDER BY 2 DESC
+		LIMIT $%d
+	`, where, len(args)+1)
+	rows, err := r.db.QueryContext(ctx, query, append(args, f.Limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("top clients: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]model.ClientStats, 0)
+	for rows.Next() {
+		var st model.ClientStats
+		if err := rows.Scan(&st.ClientIP, &st.Requests, &st.Errors, &st.UniqueUsers, &st.AvgMs, &st.LastSeen); err != nil {
 			return nil, err
 		}
-		entry.Duration = time.Duration(durationMs * 1000000)
-		entries = append(entries, &entry)
+		list = append(list, st)
+	}
+	return list, rows.Err()
+}
+
+// buildLogWhere собирает " WHERE ..." и аргументы по фильтру
+func buildLogWhere(f model.LogFilter) (string, []interface{}) {
+	conds := []string{}
+	args := []interface{}{}
+	add := func(cond string, val interface{}) {
+		args = append(args, val)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.From != nil {
+		add("timestamp >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("timestamp < $%d", *f.To)
+	}
+	if f.StatusClass > 0 {
+		add("status_code >= $%d", f.StatusClass*100)
+		add("status_code < $%d", (f.StatusClass+1)*100)
+	}
+	if f.Method != "" {
+		add("method = $%d", strings.ToUpper(f.Method))
+	}
+	if f.PathPrefix != "" {
+		add(`path LIKE $%d || '%%' ESCAPE '\'`, escapeLike(f.PathPrefix))
 	}
+	if f.ClientIP != "" {
+		add("client_ip = $%d", f.ClientIP)
+	}
+	if f.UserID != "" {
+		add("user_id = $%d", f.UserID)
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
 
-	return entries, rows.Err()
+func scanLogEntry(rows *sql.Rows) (*model.LogEntry, error) {
+	var entry model.LogEntry
+	var durationMs float64
+	var requestID, route, userID, workspaceID, userAgent, errText sql.NullString
+	err := rows.Scan(
+		&entry.Timestamp,
+		&requestID,
+		&entry.StatusCode,
+		&durationMs,
+		&entry.ClientIP,
+		&entry.Method,
+		&entry.Path,
+		&route,
+		&userID,
+		&workspaceID,
+		&entry.BytesOut,
+		&userAgent,
+		&errText,
+		&entry.RawLog,
+	)
+	if err != nil {
+		return nil, err
+	}
+	entry.Duration = time.Duration(durationMs * 1000000)
+	entry.RequestID = requestID.String
+	entry.Route = route.String
+	entry.UserID = userID.String
+	entry.WorkspaceID = workspaceID.String
+	entry.UserAgent = userAgent.String
+	entry.Error = errText.String
+	return &entry, nil
+}
+
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
 }
@@ -0,0 +1,152 @@
+package logger_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository/logger"
+	"backend/internal/testutil/pgtest"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+func entry(ts time.Time, route string, status int, ms float64) *model.LogEntry {
+	raw := fmt.Sprintf("%s %s %d %v", ts.Format(time.RFC3339Nano), route, status, ms)
+	return &model.LogEntry{
+		Timestamp:   ts,
+		StatusCode:  status,
+		Duration:    time.Duration(ms * float64(time.Millisecond)),
+		ClientIP:    "10.0.0.1",
+		Method:      "GET",
+		Path:        route,
+		Route:       route,
+		BytesOut:    100,
+		RawLog:      raw,
+		ContentHash: fmt.Sprintf("%x", sha256.Sum256([]byte(raw))),
+	}
+}
+
+func TestInsertBatchDedupAndCheckpoint(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	repo := logger.NewRepository(env.DB)
+	const file = "requests-2026-10-17.log"
+	ts := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+
+	if off, err := repo.GetCheckpoint(ctx, file); err != nil || off != 0 {
+		t.Fatalf("initial checkpoint = %d, %v", off, err)
+	}
+
+	a, b := entry(ts, "/a", 200, 1), entry(ts.Add(time.Second), "/b", 200, 2)
+	n, err := repo.InsertBatch(ctx, file, []*model.LogEntry{a, b}, 200)
+	if err != nil || n != 2 {
+		t.Fatalf("first batch = %d, %v", n, err)
+	}
+
+	// Повтор той же пачки (backfill с начала файла) ничего не вставляет и не откатывает чекпоинт
+	n, err = repo.InsertBatch(ctx, file, []*model.LogEntry{a, b}, 100)
+	if err != nil || n != 0 {
+		t.Fatalf("repeated batch = %d, %v", n, err)
+	}
+	if off, _ := repo.GetCheckpoint(ctx, file); off != 200 {
+		t.Fatalf("checkpoint after backfill = %d, want 200", off)
+	}
+
+	// Дубль внутри пачки вставляется один раз
+	c := entry(ts.Add(2*time.Second), "/c", 500, 3)
+	n, err = repo.InsertBatch(ctx, file, []*model.LogEntry{c, c}, 300)
+	if err != nil || n != 1 {
+		t.Fatalf("batch with duplicate = %d, %v", n, err)
+	}
+	if off, _ := repo.GetCheckpoint(ctx, file); off != 300 {
+		t.Fatalf("checkpoint = %d, want 300", off)
+	}
+
+	// Пустая пачка только сдвигает чекпоинт (строки, которые не являются записями запросов)
+	if _, err := repo.InsertBatch(ctx, file, nil, 350); err != nil {
+		t.Fatal(err)
+	}
+	if off, _ := repo.GetCheckpoint(ctx, file); off != 350 {
+		t.Fatalf("checkpoint after empty batch = %d, want 350", off)
+	}
+
+	if err := repo.DeleteCheckpoint(ctx, file); err != nil {
+		t.Fatal(err)
+	}
+	if off, _ := repo.GetCheckpoint(ctx, file); off != 0 {
+		t.Fatalf("checkpoint after delete = %d", off)
+	}
+
+	_, total, err := repo.List(ctx, model.LogFilter{Limit: 10})
+	if err != nil || total != 3 {
+		t.Fatalf("rows = %d, %v", total, err)
+	}
+}
+
+func TestRollupBefore(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	repo := logger.NewRepository(env.DB)
+	day1 := time.Date(2026, 10, 15, 10, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	day3 := day1.AddDate(0, 0, 2)
+
+	batch := []*model.LogEntry{
+		entry(day1, "/a", 200, 10),
+		entry(day1.Add(time.Minute), "/a", 404, 20),
+		entry(day1.Add(2*time.Minute), "/a", 500, 30),
+		entry(day2, "/a", 200, 40),
+		entry(day3, "/a", 200, 50),
+	}
+	if _, err := repo.InsertBatch(ctx, "requests.log", batch, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Сворачиваем дни раньше day3: два дня агрегатов, четыре строки удалены
+	rolled, pruned, err := repo.RollupBefore(ctx, day3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rolled != 2 || pruned != 4 {
+		t.Fatalf("rollup = %d aggregates, %d pruned", rolled, pruned)
+	}
+	stats, err := repo.DailyStats(ctx, model.LogFilter{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("daily stats = %+v", stats)
+	}
+	first := stats[1] // новые дни сверху
+	if first.Day != "2026-10-15" || first.Requests != 3 || first.ClientErrors != 1 || first.ServerErrors != 1 ||
+		first.MaxMs != 30 || first.AvgMs != 20 || first.BytesOut != 300 {
+		t.Fatalf("day1 stats = %+v", first)
+	}
+	if _, total, _ := repo.List(ctx, model.LogFilter{Limit: 10}); total != 1 {
+		t.Fatalf("raw rows left = %d, want 1", total)
+	}
+
+	// Строки за свёрнутые дни больше не загружаются — иначе их посчитали бы дважды
+	late := entry(day1.Add(time.Hour), "/a", 200, 5)
+	n, err := repo.InsertBatch(ctx, "requests.log", []*model.LogEntry{late, entry(day3.Add(time.Hour), "/a", 200, 5)}, 2)
+	if err != nil || n != 1 {
+		t.Fatalf("insert after rollup = %d, %v", n, err)
+	}
+
+	// Повторная свёртка с более ранней границей ничего не трогает и границу не откатывает
+	rolled, pruned, err = repo.RollupBefore(ctx, day2)
+	if err != nil || rolled != 0 || pruned != 0 {
+		t.Fatalf("earlier rollup = %d, %d, %v", rolled, pruned, err)
+	}
+	var before time.Time
+	if err := env.DB.QueryRowContext(ctx, `SELECT rolled_up_before FROM request_log_retention`).Scan(&before); err != nil {
+		t.Fatal(err)
+	}
+	if before.Format("2006-01-02") != "2026-10-17" {
+		t.Fatalf("rolled_up_before = %s", before)
+	}
+}
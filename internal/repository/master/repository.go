@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"backend/internal/model"
+	"backend/pkg/query"
 
 	"github.com/google/uuid"
 )
@@ -20,20 +21,39 @@ func NewRepository(db *sql.DB) *Repository {
 }
 
 // Currencies
-func (r *Repository) ListCurrencies(ctx context.Context, workspaceID uuid.UUID) ([]model.Currency, error) {
-	query := `SELECT id, workspace_id, code, name, symbol, created_at, updated_at FROM currencies WHERE workspace_id = $1 ORDER BY code`
-	rows, err := r.db.QueryContext(ctx, query, workspaceID)
+
+// CurrencySchema — сортировки и фильтры списка валют
+var CurrencySchema = query.Schema{
+	Sorts: map[string]query.SortField{
+		"code":      {Column: "code", Type: query.TypeText},
+		"name":      {Column: "name", Type: query.TypeText},
+		"createdAt": {Column: "created_at", Type: query.TypeTimestamp},
+	},
+	Filters: map[string]query.Filter{
+		"code": {Column: "code", Op: query.OpEq, Type: query.TypeText},
+		"q":    {Column: "name", Op: query.OpContains, Type: query.TypeText},
+	},
+	DefaultSort: "code",
+}
+
+func (r *Repository) ListCurrencies(ctx context.Context, workspaceID uuid.UUID, spec query.Spec) ([]model.Currency, query.Page, error) {
+	where, tail, args := spec.SQL(2)
+	q := `SELECT id, workspace_id, code, name, symbol, created_at, updated_at, ` + spec.KeyColumn() +
+		` FROM currencies WHERE workspace_id = $1` + where + tail
+	rows, err := r.db.QueryContext(ctx, q, append([]interface{}{workspaceID}, args...)...)
 	if err != nil {
-		return nil, fmt.Errorf("list currencies: %w", err)
+		return nil, query.Page{}, fmt.Errorf("list currencies: %w", err)
 	}
 	defer rows.Close()
-	var list []model.Currency
+	list := make([]model.Currency, 0)
+	var keys []query.Key
 	for rows.Next() {
 		var c model.Currency
 		var createdAt, updatedAt time.Time
 		var symbol sql.NullString
-		if err := rows.Scan(&c.ID, &c.WorkspaceID, &c.Code, &c.Name, &symbol, &createdAt, &updatedAt); err != nil {
-			return nil, err
+		var key string
+		if err := rows.Scan(&c.ID, &c.WorkspaceID, &c.Code, &c.Name, &symbol, &createdAt, &updatedAt, &key); err != nil {
+			return nil, query.Page{}, err
 		}
 		c.CreatedAt = createdAt.Format(time.RFC3339)
 		c.UpdatedAt = updatedAt.Format(time.RFC3339)
@@ -41,8 +61,13 @@ func (r *Repository) ListCurrencies(ctx context.Context, workspaceID uuid.UUID)
 			c.Symbol = &symbol.String
 		}
 		list = append(list, c)
+		keys = append(keys, query.Key{Value: key, ID: c.ID})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, query.Page{}, err
 	}
-	return list, rows.Err()
+	list, page := query.Paginate(spec, list, keys)
+	return list, page, nil
 }
 
 func (r *Repository) GetCurrency(ctx context.Context, id, workspaceID uuid.UUID) (*model.Currency, error) {
@@ -121,20 +146,39 @@ func (r *Repository) DeleteCurrency(ctx context.Context, id, workspaceID uuid.UU
 }
 
 // Counterparties
-func (r *Repository) ListCounterparties(ctx context.Context, workspaceID uuid.UUID) ([]model.Counterparty, error) {
-	query := `SELECT id, workspace_id, name, type, email, phone, comment, created_at, updated_at FROM counterparties WHERE workspace_id = $1 ORDER BY name`
-	rows, err := r.db.QueryContext(ctx, query, workspaceID)
+
+// CounterpartySchema — сортировки и фильтры списка контрагентов
+var CounterpartySchema = query.Schema{
+	Sorts: map[string]query.SortField{
+		"name":      {Column: "name", Type: query.TypeText},
+		"createdAt": {Column: "created_at", Type: query.TypeTimestamp},
+		"updatedAt": {Column: "updated_at", Type: query.TypeTimestamp},
+	},
+	Filters: map[string]query.Filter{
+		"type": {Column: "type", Op: query.OpEq, Type: query.TypeText},
+		"q":    {Column: "name", Op: query.OpContains, Type: query.TypeText},
+	},
+	DefaultSort: "name",
+}
+
+func (r *Repository) ListCounterparties(ctx context.Context, workspaceID uuid.UUID, spec query.Spec) ([]model.Counterparty, query.Page, error) {
+	where, tail, args := spec.SQL(2)
+	q := `SELECT id, workspace_id, name, type, email, phone, comment, created_at, updated_at, ` + spec.KeyColumn() +
+		` FROM counterparties WHERE workspace_id = $1` + where + tail
+	rows, err := r.db.QueryContext(ctx, q, append([]interface{}{workspaceID}, args...)...)
 	if err != nil {
-		return nil, fmt.Errorf("list counterparties: %w", err)
+		return nil, query.Page{}, fmt.Errorf("list counterparties: %w", err)
 	}
 	defer rows.Close()
-	var list []model.Counterparty
+	list := make([]model.Counterparty, 0)
+	var keys []query.Key
 	for rows.Next() {
 		var cp model.Counterparty
 		var email, phone, comment sql.NullString
 		var createdAt, updatedAt time.Time
-		if err := rows.Scan(&cp.ID, &cp.WorkspaceID, &cp.Name, &cp.Type, &email, &phone, &comment, &createdAt, &updatedAt); err != nil {
-			return nil, err
+		var key string
+		if err := rows.Scan(&cp.ID, &cp.WorkspaceID, &cp.Name, &cp.Type, &email, &phone, &comment, &createdAt, &updatedAt, &key); err != nil {
+			return nil, query.Page{}, err
 		}
 		cp.CreatedAt = createdAt.Format(time.RFC3339)
 		cp.UpdatedAt = updatedAt.Format(time.RFC3339)
@@ -148,8 +192,13 @@ func (r *Repository) ListCounterparties(ctx context.Context, workspaceID uuid.UU
 			cp.Comment = &comment.String
 		}
 		list = append(list, cp)
+		keys = append(keys, query.Key{Value: key, ID: cp.ID})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, query.Page{}, err
 	}
-	return list, rows.Err()
+	list, page := query.Paginate(spec, list, keys)
+	return list, page, nil
 }
 
 func (r *Repository) GetCounterparty(ctx context.Context, id, workspaceID uuid.UUID) (*model.Counterparty, error) {
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// CountUsersByStatus возвращает количество пользователей по статусу
+func (r *Repository) CountUsersByStatus(ctx context.Context) (map[string]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM users GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var status string
+		var n int64
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+// CountActiveUsers — сколько разных пользователей делали аутентифицированные запросы начиная с since
+func (r *Repository) CountActiveUsers(ctx context.Context, since time.Time) (int64, error) {
+	var n int64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(DISTINCT user_id) FROM request_logs WHERE timestamp >= $1 AND user_id IS NOT NULL`, since,
+	).Scan(&n)
+	return n, err
+}
+
+// CountCompletionsOn — количество выполнений привычек за день
+func (r *Repository) CountCompletionsOn(ctx context.Context, day time.Time) (int64, error) {
+	var n int64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM habit_completions WHERE date = $1::date`, day.Format("2006-01-02"),
+	).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,278 @@
+package notes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// MaxFolderDepth — максимальная вложенность папок (папка в корне — уровень 1)
+const MaxFolderDepth = 10
+
+var (
+	ErrFolderNotFound = errors.New("folder not found")
+	ErrFolderNotEmpty = errors.New("folder is not empty")
+	ErrFolderCycle    = errors.New("folder cannot be moved into itself or its subfolder")
+	ErrFolderTooDeep  = fmt.Errorf("folders cannot be nested deeper than %d levels", MaxFolderDepth)
+)
+
+// ListFolders возвращает все папки воркспейса плоским списком: по родителю, внутри — по position.
+// Дерево клиент собирает по parentId.
+func (r *Repository) ListFolders(ctx context.Context, workspaceID uuid.UUID) ([]model.NoteFolder, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT f.id, f.workspace_id, f.parent_id, f.name, f.position, f.created_at, f.updated_at,
+			(SELECT COUNT(*) FROM notes n WHERE n.folder_id = f.id AND n.archived_at IS NULL AND n.deleted_at IS NULL)
+		FROM note_folders f
+		WHERE f.workspace_id = $1
+		ORDER BY f.parent_id NULLS FIRST, f.position, f.id`, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("list note folders: %w", err)
+	}
+	defer rows.Close()
+	list := make([]model.NoteFolder, 0)
+	for rows.Next() {
+		f, err := scanFolder(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *f)
+	}
+	return list, rows.Err()
+}
+
+func (r *Repository) GetFolder(ctx context.Context, id, workspaceID uuid.UUID) (*model.NoteFolder, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT f.id, f.workspace_id, f.parent_id, f.name, f.position, f.created_at, f.updated_at,
+			(SELECT COUNT(*) FROM notes n WHERE n.folder_id = f.id AND n.archived_at IS NULL AND n.deleted_at IS NULL)
+		FROM note_folders f WHERE f.id = $1 AND f.workspace_id = $2`, id, workspaceID)
+	f, err := scanFolder(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return f, err
+}
+
+// CreateFolder добавляет папку в конец родителя f.ParentID (nil — корень)
+func (r *Repository) CreateFolder(ctx context.Context, f *model.NoteFolder) error {
+	wsID, _ := uuid.Parse(f.WorkspaceID)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockTree(ctx, tx, wsID); err != nil {
+		return err
+	}
+	if f.ParentID != nil {
+		depth, _, err := ancestry(ctx, tx, wsID, *f.ParentID, uuid.Nil)
+		if err != nil {
+			return err
+		}
+		if depth+1 > MaxFolderDepth {
+			return ErrFolderTooDeep
+		}
+	}
+	var createdAt, updatedAt time.Time
+	err = tx.QueryRowContext(ctx, `INSERT INTO note_folders (id, workspace_id, parent_id, name, position, created_at, updated_at)
+		SELECT $1, $2, $3, $4, COALESCE(MAX(position) + 1, 0), NOW(), NOW()
+		FROM note_folders WHERE workspace_id = $2 AND parent_id IS NOT DISTINCT FROM $3::uuid
+		RETURNING id, position, created_at, updated_at`,
+		uuid.New(), wsID, f.ParentID, f.Name,
+	).Scan(&f.ID, &f.Position, &createdAt, &updatedAt)
+	if err != nil {
+		return fmt.Errorf("create note folder: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	f.CreatedAt = createdAt.Format(time.RFC3339)
+	f.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return nil
+}
+
+// RenameFolder — sql.ErrNoRows, если папки нет
+func (r *Repository) RenameFolder(ctx context.Context, id, workspaceID uuid.UUID, name string) error {
+	return r.exec(ctx, `UPDATE note_folders SET name = $3, updated_at = NOW() WHERE id = $1 AND workspace_id = $2`, id, workspaceID, name)
+}
+
+// MoveFolder переносит папку вместе с содержимым в parentID (nil — корень) на место position среди соседей.
+// Нельзя перенести папку в саму себя или в свою подпапку и превысить MaxFolderDepth.
+func (r *Repository) MoveFolder(ctx context.Context, id, workspaceID uuid.UUID, parentID *string, position *int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockTree(ctx, tx, workspaceID); err != nil {
+		return err
+	}
+	if err := folderExists(ctx, tx, workspaceID, id.String()); err != nil {
+		return err
+	}
+	if parentID != nil {
+		depth, cycle, err := ancestry(ctx, tx, workspaceID, *parentID, id)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return ErrFolderCycle
+		}
+		var height int
+		err = tx.QueryRowContext(ctx, `
+			WITH RECURSIVE down AS (
+				SELECT id, 1 AS level FROM note_folders WHERE id = $1
+				UNION ALL
+				SELECT f.id, down.level + 1 FROM note_folders f JOIN down ON f.parent_id = down.id
+			)
+			SELECT MAX(level) FROM down`, id).Scan(&height)
+		if err != nil {
+			return fmt.Errorf("note folder height: %w", err)
+		}
+		if depth+height > MaxFolderDepth {
+			return ErrFolderTooDeep
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE note_folders SET parent_id = $3, updated_at = NOW() WHERE id = $1 AND workspace_id = $2`,
+		id, workspaceID, parentID); err != nil {
+		return fmt.Errorf("move note folder: %w", err)
+	}
+	if err := placeAt(ctx, tx, "note_folders", "parent_id", workspaceID, id, parentID, position); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteFolder удаляет пустую папку. В папке не должно быть подпапок и заметок, включая архивные —
+// это проверяют внешние ключи, поэтому гонки с одновременным созданием заметки нет.
+// Заметки из корзины удалению не мешают: они переносятся в корень и восстановятся туда.
+func (r *Repository) DeleteFolder(ctx context.Context, id, workspaceID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE notes SET folder_id = NULL
+		WHERE folder_id = $1 AND workspace_id = $2 AND deleted_at IS NOT NULL`, id, workspaceID); err != nil {
+		return fmt.Errorf("move trashed notes to root: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM note_folders WHERE id = $1 AND workspace_id = $2`, id, workspaceID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" { // foreign_key_violation
+		return ErrFolderNotEmpty
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+// lockTree сериализует структурные изменения дерева заметок воркспейса до конца транзакции:
+// без этого два встречных переноса папок могли бы замкнуть цикл
+func lockTree(ctx context.Context, tx *sql.Tx, workspaceID uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtextextended('note_tree:' || $1::text, 0))`, workspaceID); err != nil {
+		return fmt.Errorf("lock note tree: %w", err)
+	}
+	return nil
+}
+
+// folderExists — ErrFolderNotFound, если папки нет в воркспейсе (в том числе если id не UUID)
+func folderExists(ctx context.Context, tx *sql.Tx, workspaceID uuid.UUID, folderID string) error {
+	id, err := uuid.Parse(folderID)
+	if err != nil {
+		return ErrFolderNotFound
+	}
+	var ok bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM note_folders WHERE id = $1 AND workspace_id = $2)`,
+		id, workspaceID).Scan(&ok); err != nil {
+		return err
+	}
+	if !ok {
+		return ErrFolderNotFound
+	}
+	return nil
+}
+
+// ancestry возвращает глубину папки folderID (в корне — 1) и есть ли среди неё и её предков папка moving
+func ancestry(ctx context.Context, tx *sql.Tx, workspaceID uuid.UUID, folderID string, moving uuid.UUID) (depth int, cycle bool, err error) {
+	if err := folderExists(ctx, tx, workspaceID, folderID); err != nil {
+		return 0, false, err
+	}
+	err = tx.QueryRowContext(ctx, `
+		WITH RECURSIVE up AS (
+			SELECT id, parent_id FROM note_folders WHERE id = $1
+			UNION ALL
+			SELECT f.id, f.parent_id FROM note_folders f JOIN up ON f.id = up.parent_id
+		)
+		SELECT COUNT(*), COALESCE(bool_or(id = $2), false) FROM up`, folderID, moving).Scan(&depth, &cycle)
+	if err != nil {
+		return 0, false, fmt.Errorf("note folder ancestry: %w", err)
+	}
+	return depth, cycle, nil
+}
+
+// placeAt ставит строку id таблицы table на место position среди строк с тем же родителем (nil — в конец)
+// и перенумеровывает соседей подряд с 0. Вызывается после смены родителя, под lockTree.
+func placeAt(ctx context.Context, tx *sql.Tx, table, parentColumn string, workspaceID, id uuid.UUID, parentID *string, position *int) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM `+table+`
+		WHERE workspace_id = $1 AND `+parentColumn+` IS NOT DISTINCT FROM $2::uuid AND id <> $3
+		ORDER BY position, id`, workspaceID, parentID, id)
+	if err != nil {
+		return fmt.Errorf("load siblings: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var sibling string
+		if err := rows.Scan(&sibling); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, sibling)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	at := len(ids)
+	if position != nil && *position < at {
+		at = *position
+	}
+	ids = slices.Insert(ids, at, id.String())
+	_, err = tx.ExecContext(ctx, `UPDATE `+table+` t SET position = o.pos - 1
+		FROM unnest($1::uuid[]) WITH ORDINALITY AS o(id, pos)
+		WHERE t.id = o.id AND t.position <> o.pos - 1`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("reorder %s: %w", table, err)
+	}
+	return nil
+}
+
+func scanFolder(row interface{ Scan(...interface{}) error }) (*model.NoteFolder, error) {
+	var f model.NoteFolder
+	var parentID sql.NullString
+	var createdAt, updatedAt time.Time
+	if err := row.Scan(&f.ID, &f.WorkspaceID, &parentID, &f.Name, &f.Position, &createdAt, &updatedAt, &f.NoteCount); err != nil {
+		return nil, err
+	}
+	if parentID.Valid {
+		f.ParentID = &parentID.String
+	}
+	f.CreatedAt = createdAt.Format(time.RFC3339)
+	f.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return &f, nil
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"backend/internal/model"
+	"backend/pkg/query"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type Repository struct {
@@ -19,85 +22,209 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) List(ctx context.Context, workspaceID uuid.UUID) ([]model.Note, error) {
-	query := `SELECT id, workspace_id, user_id, title, content, created_at, updated_at FROM notes WHERE workspace_id = $1 ORDER BY updated_at DESC`
-	rows, err := r.db.QueryContext(ctx, query, workspaceID)
+// ListSchema — сортировки и фильтры списка заметок.
+// manual — ручной порядок: закреплённые первыми, дальше по position. Осмыслен внутри одной папки (folderId или root=true).
+// Архивные заметки по умолчанию скрыты: archived=true показывает только их.
+var ListSchema = query.Schema{
+	Sorts: map[string]query.SortField{
+		"updatedAt": {Column: "updated_at", Type: query.TypeTimestamp},
+		"createdAt": {Column: "created_at", Type: query.TypeTimestamp},
+		"title":     {Column: "title", Type: query.TypeText},
+		"manual":    {Column: "(CASE WHEN pinned THEN 0 ELSE 1 END)::bigint * 2147483648 + position", Type: query.TypeBigint},
+	},
+	Filters: map[string]query.Filter{
+		"q":           {Column: "title", Op: query.OpContains, Type: query.TypeText},
+		"userId":      {Column: "user_id", Op: query.OpEq, Type: query.TypeUUID},
+		"folderId":    {Column: "folder_id", Op: query.OpEq, Type: query.TypeUUID},
+		"root":        {Column: "(folder_id IS NULL)", Op: query.OpEq, Type: query.TypeBool},
+		"tags":        {Column: "tags", Op: query.OpAnyOf, Type: query.TypeText},
+		"tagsAll":     {Column: "tags", Op: query.OpAllOf, Type: query.TypeText},
+		"pinned":      {Column: "pinned", Op: query.OpEq, Type: query.TypeBool},
+		"contentType": {Column: "content_type", Op: query.OpEq, Type: query.TypeText},
+		"archived":    {Column: "(archived_at IS NOT NULL)", Op: query.OpEq, Type: query.TypeBool, Default: "false"},
+	},
+	DefaultSort: "-updatedAt",
+}
+
+const noteColumns = `id, workspace_id, user_id, folder_id, title, content, content_type, tags, pinned, position, archived_at, created_at, updated_at`
+
+func (r *Repository) List(ctx context.Context, workspaceID uuid.UUID, spec query.Spec) ([]model.Note, query.Page, error) {
+	where, tail, args := spec.SQL(2)
+	q := `SELECT ` + noteColumns + `, ` + spec.KeyColumn() + ` FROM notes WHERE workspace_id = $1 AND deleted_at IS NULL` + where + tail
+	rows, err := r.db.QueryContext(ctx, q, append([]interface{}{workspaceID}, args...)...)
 	if err != nil {
-		return nil, fmt.Errorf("list notes: %w", err)
+		return nil, query.Page{}, fmt.Errorf("list notes: %w", err)
 	}
 	defer rows.Close()
-	var list []model.Note
+	list := make([]model.Note, 0)
+	var keys []query.Key
 	for rows.Next() {
-		var n model.Note
-		var content sql.NullString
-		var createdAt, updatedAt time.Time
-		if err := rows.Scan(&n.ID, &n.WorkspaceID, &n.UserID, &n.Title, &content, &createdAt, &updatedAt); err != nil {
-			return nil, err
+		var key string
+		n, err := scanNote(rows, &key)
+		if err != nil {
+			return nil, query.Page{}, err
 		}
-		n.CreatedAt = createdAt.Format(time.RFC3339)
-		n.UpdatedAt = updatedAt.Format(time.RFC3339)
-		if content.Valid {
-			n.Content = content.String
-		}
-		list = append(list, n)
+		list = append(list, *n)
+		keys = append(keys, query.Key{Value: key, ID: n.ID})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, query.Page{}, err
 	}
-	return list, rows.Err()
+	list, page := query.Paginate(spec, list, keys)
+	return list, page, nil
 }
 
 func (r *Repository) Get(ctx context.Context, id, workspaceID uuid.UUID) (*model.Note, error) {
-	query := `SELECT id, workspace_id, user_id, title, content, created_at, updated_at FROM notes WHERE id = $1 AND workspace_id = $2`
-	var n model.Note
-	var content sql.NullString
-	var createdAt, updatedAt time.Time
-	err := r.db.QueryRowContext(ctx, query, id, workspaceID).Scan(&n.ID, &n.WorkspaceID, &n.UserID, &n.Title, &content, &createdAt, &updatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
+	row := r.db.QueryRowContext(ctx, `SELECT `+noteColumns+` FROM notes WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NULL`, id, workspaceID)
+	n, err := scanNote(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	n.CreatedAt = createdAt.Format(time.RFC3339)
-	n.UpdatedAt = updatedAt.Format(time.RFC3339)
-	if content.Valid {
-		n.Content = content.String
-	}
-	return &n, nil
+	return n, err
 }
 
+// Create добавляет заметку в конец папки n.FolderID (nil — корень). Папка должна быть в том же воркспейсе.
 func (r *Repository) Create(ctx context.Context, n *model.Note) error {
-	query := `INSERT INTO notes (id, workspace_id, user_id, title, content, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
-		RETURNING id, created_at, updated_at`
 	wsID, _ := uuid.Parse(n.WorkspaceID)
 	userID, _ := uuid.Parse(n.UserID)
-	id := uuid.New()
+	if n.ContentType == "" {
+		n.ContentType = model.NoteContentText
+	}
+	if n.Tags == nil {
+		n.Tags = []string{}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockTree(ctx, tx, wsID); err != nil {
+		return err
+	}
+	if n.FolderID != nil {
+		if err := folderExists(ctx, tx, wsID, *n.FolderID); err != nil {
+			return err
+		}
+	}
 	var createdAt, updatedAt time.Time
-	err := r.db.QueryRowContext(ctx, query, id, wsID, userID, n.Title, n.Content).Scan(&n.ID, &createdAt, &updatedAt)
+	err = tx.QueryRowContext(ctx, `INSERT INTO notes (id, workspace_id, user_id, folder_id, title, content, content_type, tags, position, created_at, updated_at)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, COALESCE(MAX(position) + 1, 0), NOW(), NOW()
+		FROM notes WHERE workspace_id = $2 AND folder_id IS NOT DISTINCT FROM $4::uuid
+		RETURNING id, position, created_at, updated_at`,
+		uuid.New(), wsID, userID, n.FolderID, n.Title, n.Content, n.ContentType, pq.Array(n.Tags),
+	).Scan(&n.ID, &n.Position, &createdAt, &updatedAt)
 	if err != nil {
 		return fmt.Errorf("create note: %w", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
 	n.CreatedAt = createdAt.Format(time.RFC3339)
 	n.UpdatedAt = updatedAt.Format(time.RFC3339)
 	return nil
 }
 
+// Update сохраняет заголовок, текст, формат и теги. Папку, порядок, закрепление и архив не трогает.
 func (r *Repository) Update(ctx context.Context, n *model.Note) error {
-	res, err := r.db.ExecContext(ctx,
-		`UPDATE notes SET title = $3, content = $4, updated_at = NOW() WHERE id = $1 AND workspace_id = $2`,
-		n.ID, n.WorkspaceID, n.Title, n.Content,
-	)
+	tags := n.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE notes SET title = $3, content = $4, content_type = $5, tags = $6, updated_at = NOW()
+		WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NULL RETURNING updated_at`,
+		n.ID, n.WorkspaceID, n.Title, n.Content, n.ContentType, pq.Array(tags),
+	).Scan(&updatedAt)
 	if err != nil {
 		return err
 	}
-	nUpd, _ := res.RowsAffected()
-	if nUpd == 0 {
+	n.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return nil
+}
+
+// SetPinned закрепляет или открепляет заметку. sql.ErrNoRows — заметки нет.
+func (r *Repository) SetPinned(ctx context.Context, id, workspaceID uuid.UUID, pinned bool) error {
+	return r.exec(ctx, `UPDATE notes SET pinned = $3, updated_at = NOW() WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NULL`, id, workspaceID, pinned)
+}
+
+// SetArchived отправляет заметку в архив или возвращает из него; место в папке сохраняется
+func (r *Repository) SetArchived(ctx context.Context, id, workspaceID uuid.UUID, archived bool) error {
+	return r.exec(ctx, `UPDATE notes SET archived_at = CASE WHEN $3 THEN COALESCE(archived_at, NOW()) END, updated_at = NOW()
+		WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NULL`, id, workspaceID, archived)
+}
+
+// Move переносит заметку в папку folderID (nil — корень) на место position среди заметок папки (nil — в конец)
+func (r *Repository) Move(ctx context.Context, id, workspaceID uuid.UUID, folderID *string, position *int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockTree(ctx, tx, workspaceID); err != nil {
+		return err
+	}
+	if folderID != nil {
+		if err := folderExists(ctx, tx, workspaceID, *folderID); err != nil {
+			return err
+		}
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE notes SET folder_id = $3, updated_at = NOW() WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NULL`,
+		id, workspaceID, folderID)
+	if err != nil {
+		return fmt.Errorf("move note: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
 		return sql.ErrNoRows
 	}
-	return nil
+	if err := placeAt(ctx, tx, "notes", "folder_id", workspaceID, id, folderID, position); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
+// Delete переносит заметку в корзину; восстановление и окончательное удаление — repository/trash
 func (r *Repository) Delete(ctx context.Context, id, workspaceID uuid.UUID) error {
-	res, err := r.db.ExecContext(ctx, `DELETE FROM notes WHERE id = $1 AND workspace_id = $2`, id, workspaceID)
+	return r.exec(ctx, `UPDATE notes SET deleted_at = NOW() WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NULL`, id, workspaceID)
+}
+
+// Restore возвращает заметку из корзины в конец её папки (в корень, если папку за это время удалили).
+// sql.ErrNoRows — заметки нет в корзине.
+func (r *Repository) Restore(ctx context.Context, id, workspaceID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockTree(ctx, tx, workspaceID); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE notes n SET deleted_at = NULL,
+			position = (SELECT COALESCE(MAX(s.position) + 1, 0) FROM notes s
+				WHERE s.workspace_id = n.workspace_id AND s.folder_id IS NOT DISTINCT FROM n.folder_id AND s.deleted_at IS NULL)
+		WHERE n.id = $1 AND n.workspace_id = $2 AND n.deleted_at IS NOT NULL`, id, workspaceID)
+	if err != nil {
+		return fmt.Errorf("restore note: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+// Purge окончательно удаляет заметку из корзины; доступы, ссылки и вложения удаляются каскадом.
+// sql.ErrNoRows — заметки нет в корзине.
+func (r *Repository) Purge(ctx context.Context, id, workspaceID uuid.UUID) error {
+	return r.exec(ctx, `DELETE FROM notes WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NOT NULL`, id, workspaceID)
+}
+
+// exec выполняет изменение одной строки; sql.ErrNoRows — строка не найдена
+func (r *Repository) exec(ctx context.Context, q string, args ...interface{}) error {
+	res, err := r.db.ExecContext(ctx, q, args...)
 	if err != nil {
 		return err
 	}
@@ -107,3 +234,38 @@ func (r *Repository) Delete(ctx context.Context, id, workspaceID uuid.UUID) erro
 	}
 	return nil
 }
+
+// prefixed добавляет псевдоним таблицы к списку колонок: "id, title" -> "n.id, n.title"
+func prefixed(alias, columns string) string {
+	return alias + "." + strings.ReplaceAll(columns, ", ", ", "+alias+".")
+}
+
+func scanNote(row interface{ Scan(...interface{}) error }, extra ...interface{}) (*model.Note, error) {
+	var n model.Note
+	var folderID, content sql.NullString
+	var tags pq.StringArray
+	var archivedAt sql.NullTime
+	var createdAt, updatedAt time.Time
+	dest := []interface{}{&n.ID, &n.WorkspaceID, &n.UserID, &folderID, &n.Title, &content, &n.ContentType, &tags,
+		&n.Pinned, &n.Position, &archivedAt, &createdAt, &updatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if folderID.Valid {
+		n.FolderID = &folderID.String
+	}
+	if content.Valid {
+		n.Content = content.String
+	}
+	n.Tags = tags
+	if n.Tags == nil {
+		n.Tags = []string{}
+	}
+	if archivedAt.Valid {
+		s := archivedAt.Time.Format(time.RFC3339)
+		n.ArchivedAt = &s
+	}
+	n.CreatedAt = createdAt.Format(time.RFC3339)
+	n.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return &n, nil
+}
@@ -0,0 +1,282 @@
+package notes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrShareUserNotFound = errors.New("user not found")
+
+// Share открывает заметку пользователю с email (без учёта регистра) или меняет права, если уже открыта.
+// Заметку вызывающий проверяет сам; ErrShareUserNotFound — нет активного пользователя с таким email.
+func (r *Repository) Share(ctx context.Context, noteID uuid.UUID, email, permission string, createdBy uuid.UUID) (*model.NoteShare, error) {
+	s := model.NoteShare{NoteID: noteID.String(), Permission: permission}
+	var createdByID sql.NullString
+	var createdAt time.Time
+	err := r.db.QueryRowContext(ctx, `
+		WITH u AS (
+			SELECT id, email, name FROM users WHERE lower(email) = lower($2) AND status = 'ACTIVE'
+		), ins AS (
+			INSERT INTO note_shares (id, note_id, user_id, permission, created_by, created_at, updated_at)
+			SELECT $4, $1, u.id, $3, $5, NOW(), NOW() FROM u
+			ON CONFLICT (note_id, user_id) DO UPDATE SET permission = EXCLUDED.permission, updated_at = NOW()
+			RETURNING id, user_id, created_by, created_at
+		)
+		SELECT ins.id, ins.user_id, u.email, u.name, ins.created_by, ins.created_at FROM ins JOIN u ON u.id = ins.user_id`,
+		noteID, email, permission, uuid.New(), createdBy,
+	).Scan(&s.ID, &s.UserID, &s.Email, &s.Name, &createdByID, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrShareUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("share note: %w", err)
+	}
+	if createdByID.Valid {
+		s.CreatedBy = &createdByID.String
+	}
+	s.CreatedAt = createdAt.Format(time.RFC3339)
+	return &s, nil
+}
+
+func (r *Repository) ListShares(ctx context.Context, noteID, workspaceID uuid.UUID) ([]model.NoteShare, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT s.id, s.note_id, s.user_id, u.email, u.name, s.permission, s.created_by, s.created_at
+		FROM note_shares s
+		JOIN notes n ON n.id = s.note_id
+		JOIN users u ON u.id = s.user_id
+		WHERE s.note_id = $1 AND n.workspace_id = $2
+		ORDER BY s.created_at, s.id`, noteID, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("list note shares: %w", err)
+	}
+	defer rows.Close()
+	list := make([]model.NoteShare, 0)
+	for rows.Next() {
+		var s model.NoteShare
+		var createdBy sql.NullString
+		var createdAt time.Time
+		if err := rows.Scan(&s.ID, &s.NoteID, &s.UserID, &s.Email, &s.Name, &s.Permission, &createdBy, &createdAt); err != nil {
+			return nil, err
+		}
+		if createdBy.Valid {
+			s.CreatedBy = &createdBy.String
+		}
+		s.CreatedAt = createdAt.Format(time.RFC3339)
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// DeleteShare закрывает доступ; sql.ErrNoRows — нет такого доступа у заметки воркспейса
+func (r *Repository) DeleteShare(ctx context.Context, shareID, noteID, workspaceID uuid.UUID) error {
+	return r.exec(ctx, `DELETE FROM note_shares s USING notes n
+		WHERE s.id = $1 AND s.note_id = $2 AND n.id = s.note_id AND n.workspace_id = $3`, shareID, noteID, workspaceID)
+}
+
+// ListSharedWithUser — заметки, открытые пользователю, кроме архивных и удалённых в корзину; недавно изменённые сверху
+func (r *Repository) ListSharedWithUser(ctx context.Context, userID uuid.UUID) ([]model.SharedNote, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+prefixed("n", noteColumns)+`, s.permission
+		FROM note_shares s JOIN notes n ON n.id = s.note_id
+		WHERE s.user_id = $1 AND n.archived_at IS NULL AND n.deleted_at IS NULL
+		ORDER BY n.updated_at DESC, n.id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list shared notes: %w", err)
+	}
+	defer rows.Close()
+	list := make([]model.SharedNote, 0)
+	for rows.Next() {
+		var permission string
+		n, err := scanNote(rows, &permission)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, model.SharedNote{Note: *n, Permission: permission})
+	}
+	return list, rows.Err()
+}
+
+// GetShared — заметка, открытая пользователю; nil — не открыта или нет такой
+func (r *Repository) GetShared(ctx context.Context, noteID, userID uuid.UUID) (*model.SharedNote, error) {
+	var permission string
+	row := r.db.QueryRowContext(ctx, `SELECT `+prefixed("n", noteColumns)+`, s.permission
+		FROM note_shares s JOIN notes n ON n.id = s.note_id
+		WHERE s.note_id = $1 AND s.user_id = $2 AND n.deleted_at IS NULL`, noteID, userID)
+	n, err := scanNote(row, &permission)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model.SharedNote{Note: *n, Permission: permission}, nil
+}
+
+// PublicLink — ссылка, найденная по токену, вместе с заметкой
+type PublicLink struct {
+	ID           string
+	PasswordHash string // пусто — без пароля
+	Expired      bool
+	Revoked      bool
+	Note         model.PublicNote
+}
+
+// CreateLink сохраняет ссылку; tokenHash — SHA-256 токена, passwordHash — bcrypt или пусто, expiresAt — в UTC
+func (r *Repository) CreateLink(ctx context.Context, l *model.NoteLink, tokenHash, passwordHash string, expiresAt *time.Time) error {
+	var pwd interface{}
+	if passwordHash != "" {
+		pwd = passwordHash
+	}
+	var createdAt time.Time
+	err := r.db.QueryRowContext(ctx, `INSERT INTO note_links (id, note_id, token_hash, password_hash, expires_at, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW()) RETURNING id, created_at`,
+		uuid.New(), l.NoteID, tokenHash, pwd, expiresAt, l.CreatedBy,
+	).Scan(&l.ID, &createdAt)
+	if err != nil {
+		return fmt.Errorf("create note link: %w", err)
+	}
+	l.HasPassword = passwordHash != ""
+	if expiresAt != nil {
+		s := expiresAt.UTC().Format(time.RFC3339)
+		l.ExpiresAt = &s
+	}
+	l.CreatedAt = createdAt.Format(time.RFC3339)
+	return nil
+}
+
+// ListLinks — ссылки заметки со статистикой успешных открытий, новые сверху
+func (r *Repository) ListLinks(ctx context.Context, noteID, workspaceID uuid.UUID) ([]model.NoteLink, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT l.id, l.note_id, l.password_hash IS NOT NULL, l.expires_at, l.revoked_at, l.created_by, l.created_at,
+			a.last_at, COALESCE(a.cnt, 0)
+		FROM note_links l
+		JOIN notes n ON n.id = l.note_id
+		LEFT JOIN LATERAL (
+			SELECT MAX(accessed_at) AS last_at, COUNT(*) AS cnt
+			FROM note_link_access_log WHERE link_id = l.id AND outcome = 'ok'
+		) a ON true
+		WHERE l.note_id = $1 AND n.workspace_id = $2
+		ORDER BY l.created_at DESC, l.id`, noteID, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("list note links: %w", err)
+	}
+	defer rows.Close()
+	list := make([]model.NoteLink, 0)
+	for rows.Next() {
+		var l model.NoteLink
+		var expiresAt, revokedAt, lastAt sql.NullTime
+		var createdBy sql.NullString
+		var createdAt time.Time
+		if err := rows.Scan(&l.ID, &l.NoteID, &l.HasPassword, &expiresAt, &revokedAt, &createdBy, &createdAt, &lastAt, &l.AccessCount); err != nil {
+			return nil, err
+		}
+		l.ExpiresAt = formatNullTime(expiresAt)
+		l.RevokedAt = formatNullTime(revokedAt)
+		l.LastAccessedAt = formatNullTime(lastAt)
+		if createdBy.Valid {
+			l.CreatedBy = &createdBy.String
+		}
+		l.CreatedAt = createdAt.Format(time.RFC3339)
+		list = append(list, l)
+	}
+	return list, rows.Err()
+}
+
+// RevokeLink отзывает ссылку (повторный отзыв не меняет время); sql.ErrNoRows — нет такой ссылки у заметки
+func (r *Repository) RevokeLink(ctx context.Context, linkID, noteID, workspaceID uuid.UUID) error {
+	return r.exec(ctx, `UPDATE note_links l SET revoked_at = COALESCE(l.revoked_at, NOW())
+		FROM notes n WHERE l.id = $1 AND l.note_id = $2 AND n.id = l.note_id AND n.workspace_id = $3`, linkID, noteID, workspaceID)
+}
+
+// FindLink ищет ссылку по хэшу токена; nil — нет такой
+func (r *Repository) FindLink(ctx context.Context, tokenHash string) (*PublicLink, error) {
+	var l PublicLink
+	var passwordHash, content sql.NullString
+	var tags pq.StringArray
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx, `
+		SELECT l.id, l.password_hash, COALESCE(l.expires_at <= NOW() AT TIME ZONE 'UTC', false), l.revoked_at IS NOT NULL,
+			n.title, n.content, n.content_type, n.tags, n.updated_at
+		FROM note_links l JOIN notes n ON n.id = l.note_id
+		WHERE l.token_hash = $1 AND n.deleted_at IS NULL`, tokenHash,
+	).Scan(&l.ID, &passwordHash, &l.Expired, &l.Revoked, &l.Note.Title, &content, &l.Note.ContentType, &tags, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.PasswordHash = passwordHash.String
+	l.Note.Content = content.String
+	l.Note.Tags = tags
+	if l.Note.Tags == nil {
+		l.Note.Tags = []string{}
+	}
+	l.Note.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return &l, nil
+}
+
+func (r *Repository) LogLinkAccess(ctx context.Context, linkID, outcome, clientIP, userAgent string) error {
+	if ua := []rune(userAgent); len(ua) > 500 {
+		userAgent = string(ua[:500])
+	}
+	_, err := r.db.ExecContext(ctx, `INSERT INTO note_link_access_log (link_id, accessed_at, outcome, client_ip, user_agent)
+		VALUES ($1, NOW(), $2, NULLIF($3, ''), NULLIF($4, ''))`, linkID, outcome, clientIP, userAgent)
+	if err != nil {
+		return fmt.Errorf("log note link access: %w", err)
+	}
+	return nil
+}
+
+// CountFailedAttempts — неверные пароли к ссылке за последние window
+func (r *Repository) CountFailedAttempts(ctx context.Context, linkID string, window time.Duration) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM note_link_access_log
+		WHERE link_id = $1 AND outcome = 'wrong_password' AND accessed_at > NOW() - make_interval(secs => $2)`,
+		linkID, window.Seconds()).Scan(&n)
+	return n, err
+}
+
+// LinkAccessLog — последние limit обращений к ссылке; sql.ErrNoRows — нет такой ссылки у заметки
+func (r *Repository) LinkAccessLog(ctx context.Context, linkID, noteID, workspaceID uuid.UUID, limit int) ([]model.NoteLinkAccess, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM note_links l JOIN notes n ON n.id = l.note_id
+		WHERE l.id = $1 AND l.note_id = $2 AND n.workspace_id = $3)`, linkID, noteID, workspaceID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT accessed_at, outcome, COALESCE(client_ip, ''), COALESCE(user_agent, '')
+		FROM note_link_access_log WHERE link_id = $1 ORDER BY accessed_at DESC, id DESC LIMIT $2`, linkID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("note link access log: %w", err)
+	}
+	defer rows.Close()
+	list := make([]model.NoteLinkAccess, 0)
+	for rows.Next() {
+		var a model.NoteLinkAccess
+		var at time.Time
+		if err := rows.Scan(&at, &a.Outcome, &a.ClientIP, &a.UserAgent); err != nil {
+			return nil, err
+		}
+		a.AccessedAt = at.Format(time.RFC3339)
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+func formatNullTime(t sql.NullTime) *string {
+	if !t.Valid {
+		return nil
+	}
+	s := t.Time.Format(time.RFC3339)
+	return &s
+}
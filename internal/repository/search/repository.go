@@ -0,0 +1,154 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Query — разобранный поисковый запрос
+type Query struct {
+	Text    string   // как ввёл пользователь: websearch_to_tsquery (кавычки, OR, -исключение)
+	Prefix  string   // to_tsquery('simple'): слова с :* для поиска по началу слова
+	Exclude string   // websearch_to_tsquery: слова-исключения через or, пустая — исключений нет
+	Types   []string // model.SearchType*, не пустой
+	Limit   int
+	Offset  int
+}
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Источники поиска: колонки в порядке hits(type, id, title, body, date, tags, updated_at, rank, vector). search_vector — generated column из миграции 000020.
+var sources = map[string]string{
+	model.SearchTypeNote: `
+		SELECT 'note', id, title::text, COALESCE(content, ''), NULL::date, NULL::text[], updated_at, ts_rank_cd(search_vector, q.query, 32), search_vector
+		FROM notes, q WHERE workspace_id = $1 AND deleted_at IS NULL AND search_vector @@ q.query`,
+	model.SearchTypeJournal: `
+		SELECT 'journal', id, '', description, date, tags, updated_at, ts_rank_cd(search_vector, q.query, 32), search_vector
+		FROM journal_entries, q WHERE workspace_id = $1 AND deleted_at IS NULL AND search_vector @@ q.query`,
+	model.SearchTypeHabit: `
+		SELECT 'habit', id, title::text, COALESCE(description, ''), NULL::date, NULL::text[], updated_at, ts_rank_cd(search_vector, q.query, 32), search_vector
+		FROM habits, q WHERE workspace_id = $1 AND deleted_at IS NULL AND search_vector @@ q.query`,
+	model.SearchTypeCounterparty: `
+		SELECT 'counterparty', id, name::text, concat_ws(' · ', email, phone, comment), NULL::date, NULL::text[], updated_at, ts_rank_cd(search_vector, q.query, 32), search_vector
+		FROM counterparties, q WHERE workspace_id = $1 AND search_vector @@ q.query`,
+}
+
+// Границы совпадений в ts_headline — управляющие символы: из текста они вырезаются до подсветки, поэтому
+// после HTML-экранирования их можно заменить на <mark>, не спутав с тегами, которые ввёл пользователь
+const (
+	markStart = "\x02"
+	markStop  = "\x03"
+)
+
+const (
+	titleHeadlineOpts = `HighlightAll=true, StartSel="` + markStart + `", StopSel="` + markStop + `"`
+	bodyHeadlineOpts  = `StartSel="` + markStart + `", StopSel="` + markStop + `", MinWords=10, MaxWords=30, MaxFragments=2, FragmentDelimiter=" … "`
+)
+
+var markReplacer = strings.NewReplacer(markStart, "<mark>", markStop, "</mark>")
+
+// highlight экранирует фрагмент ts_headline и подставляет <mark> вокруг совпадений
+func highlight(s string) string {
+	return markReplacer.Replace(html.EscapeString(s))
+}
+
+// hitsCTE: q — части запроса по конфигурациям и итоговый запрос. Исключения (-слово) применяются ко всему запросу
+// во всех трёх конфигурациях, иначе поиск по префиксу и english находили бы исключённые словоформы.
+func (r *Repository) hitsCTE(types []string) string {
+	parts := make([]string, 0, len(types))
+	for _, t := range types {
+		if src, ok := sources[t]; ok {
+			parts = append(parts, src)
+		}
+	}
+	return `
+		WITH parts AS (
+			SELECT websearch_to_tsquery('russian', $2) AS russian, websearch_to_tsquery('english', $2) AS english,
+				to_tsquery('simple', $3) AS prefix,
+				websearch_to_tsquery('russian', $4) || websearch_to_tsquery('english', $4) || websearch_to_tsquery('simple', $4) AS excluded
+		), q AS (
+			SELECT russian, english, prefix, (russian || english || prefix) && (!!excluded) AS query FROM parts
+		), hits(type, id, title, body, date, tags, updated_at, rank, vector) AS (` + strings.Join(parts, "\n\t\tUNION ALL") + `
+		)`
+}
+
+// Search возвращает страницу совпадений по убыванию релевантности и число совпадений по типам
+func (r *Repository) Search(ctx context.Context, workspaceID uuid.UUID, q Query) ([]model.SearchHit, map[string]int, error) {
+	cte := r.hitsCTE(q.Types)
+
+	counts := make(map[string]int, len(q.Types))
+	rows, err := r.db.QueryContext(ctx, cte+` SELECT type, COUNT(*) FROM hits GROUP BY type`, workspaceID, q.Text, q.Prefix, q.Exclude)
+	if err != nil {
+		return nil, nil, fmt.Errorf("search counts: %w", err)
+	}
+	for rows.Next() {
+		var t string
+		var n int
+		if err := rows.Scan(&t, &n); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		counts[t] = n
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// ts_headline дорогой — считаем его только для строк страницы. Подсвечиваем в той конфигурации, в которой
+	// документ нашёлся: russian, english или simple с запросом по префиксу.
+	rows, err = r.db.QueryContext(ctx, cte+`
+		SELECT p.type, p.id,
+			ts_headline(h.cfg, translate(p.title, $9, ''), h.query, $7),
+			ts_headline(h.cfg, translate(p.body, $9, ''), h.query, $8),
+			p.date, p.tags, p.updated_at, p.rank
+		FROM (SELECT * FROM hits ORDER BY rank DESC, updated_at DESC, id LIMIT $5 OFFSET $6) p
+		CROSS JOIN q
+		CROSS JOIN LATERAL (
+			SELECT 'russian'::regconfig, q.russian WHERE p.vector @@ q.russian
+			UNION ALL SELECT 'english'::regconfig, q.english WHERE p.vector @@ q.english
+			UNION ALL SELECT 'simple'::regconfig, q.prefix
+			LIMIT 1
+		) h(cfg, query)
+		ORDER BY p.rank DESC, p.updated_at DESC, p.id
+	`, workspaceID, q.Text, q.Prefix, q.Exclude, q.Limit, q.Offset, titleHeadlineOpts, bodyHeadlineOpts, markStart+markStop)
+	if err != nil {
+		return nil, nil, fmt.Errorf("search: %w", err)
+	}
+	defer rows.Close()
+
+	hits := make([]model.SearchHit, 0)
+	for rows.Next() {
+		var h model.SearchHit
+		var date sql.NullTime
+		var tags pq.StringArray
+		var updatedAt time.Time
+		if err := rows.Scan(&h.Type, &h.ID, &h.Title, &h.Snippet, &date, &tags, &updatedAt, &h.Rank); err != nil {
+			return nil, nil, fmt.Errorf("scan search hit: %w", err)
+		}
+		if date.Valid {
+			h.Date = date.Time.Format("2006-01-02")
+		}
+		h.Title = highlight(h.Title)
+		h.Snippet = highlight(h.Snippet)
+		h.Tags = tags
+		h.UpdatedAt = updatedAt.Format(time.RFC3339)
+		hits = append(hits, h)
+	}
+	return hits, counts, rows.Err()
+}
@@ -0,0 +1,133 @@
+package trash
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Item — элемент корзины; срок окончательного удаления считает сервис. PurgeExempt — воркер его не удаляет.
+type Item struct {
+	Type        string
+	ID          string
+	Title       string
+	DeletedAt   time.Time
+	PurgeExempt bool
+}
+
+// sources — таблица и выражения для названия и исключения из очистки по сроку по типу
+var sources = map[string]struct{ table, title, exempt string }{
+	model.SearchTypeNote:    {"notes", "title::text", "FALSE"},
+	model.SearchTypeJournal: {"journal_entries", "to_char(date, 'YYYY-MM-DD')", "FALSE"},
+	model.SearchTypeHabit:   {"habits", "title::text", "purge_exempt"},
+}
+
+// List — корзина воркспейса, недавно удалённые сверху. Заметки и записи дневника — всех участников, привычки —
+// только userID: чужую привычку нельзя ни восстановить, ни удалить. types пустой — все типы.
+func (r *Repository) List(ctx context.Context, workspaceID, userID uuid.UUID, types []string) ([]Item, error) {
+	args := []interface{}{workspaceID}
+	var parts []string
+	for _, t := range model.TrashTypes {
+		if len(types) > 0 && !slices.Contains(types, t) {
+			continue
+		}
+		s := sources[t]
+		part := fmt.Sprintf(`SELECT '%s', id, %s, deleted_at, %s FROM %s WHERE workspace_id = $1 AND deleted_at IS NOT NULL`,
+			t, s.title, s.exempt, s.table)
+		if t == model.SearchTypeHabit {
+			args = append(args, userID)
+			part += fmt.Sprintf(` AND user_id = $%d`, len(args))
+		}
+		parts = append(parts, part)
+	}
+	list := make([]Item, 0)
+	if len(parts) == 0 {
+		return list, nil
+	}
+	rows, err := r.db.QueryContext(ctx, strings.Join(parts, " UNION ALL ")+` ORDER BY 4 DESC, 2`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list trash: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var it Item
+		if err := rows.Scan(&it.Type, &it.ID, &it.Title, &it.DeletedAt, &it.PurgeExempt); err != nil {
+			return nil, err
+		}
+		list = append(list, it)
+	}
+	return list, rows.Err()
+}
+
+// Empty окончательно удаляет корзину воркспейса: заметки и записи всех участников, привычки — только userID.
+// Возвращает число удалённых элементов.
+func (r *Repository) Empty(ctx context.Context, workspaceID, userID uuid.UUID) (int, error) {
+	return r.purge(ctx, []statement{
+		{`DELETE FROM habit_versions v USING habits h
+			WHERE h.id = v.habit_id AND h.workspace_id = $1 AND h.user_id = $2 AND h.deleted_at IS NOT NULL`, false, []interface{}{workspaceID, userID}},
+		{`DELETE FROM habits WHERE workspace_id = $1 AND user_id = $2 AND deleted_at IS NOT NULL`, true, []interface{}{workspaceID, userID}},
+		{`DELETE FROM notes WHERE workspace_id = $1 AND deleted_at IS NOT NULL`, true, []interface{}{workspaceID}},
+		{`DELETE FROM journal_entries WHERE workspace_id = $1 AND deleted_at IS NOT NULL`, true, []interface{}{workspaceID}},
+	})
+}
+
+// PurgeExpired окончательно удаляет всё, что лежит в корзине с момента раньше before. Привычки с purge_exempt
+// (вернула в корзину миграция 000031) остаются, пока их не удалит пользователь.
+func (r *Repository) PurgeExpired(ctx context.Context, before time.Time) (int, error) {
+	args := []interface{}{before}
+	return r.purge(ctx, []statement{
+		{`DELETE FROM habit_versions v USING habits h
+			WHERE h.id = v.habit_id AND h.deleted_at < $1 AND NOT h.purge_exempt`, false, args},
+		{`DELETE FROM habits WHERE deleted_at < $1 AND NOT purge_exempt`, true, args},
+		{`DELETE FROM notes WHERE deleted_at < $1`, true, args},
+		{`DELETE FROM journal_entries WHERE deleted_at < $1`, true, args},
+	})
+}
+
+// statement — шаг очистки; counted — удалённые строки считаются элементами корзины
+type statement struct {
+	query   string
+	counted bool
+	args    []interface{}
+}
+
+// purge выполняет шаги очистки в одной транзакции. Версии привычек удаляются отдельно — внешнего ключа на habits
+// у них нет; выполнения, доступы, ревизии и вложения удаляются каскадом, связи — триггерами.
+func (r *Repository) purge(ctx context.Context, steps []statement) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var total int64
+	for _, s := range steps {
+		res, err := tx.ExecContext(ctx, s.query, s.args...)
+		if err != nil {
+			return 0, fmt.Errorf("purge trash: %w", err)
+		}
+		if s.counted {
+			n, _ := res.RowsAffected()
+			total += n
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int(total), nil
+}
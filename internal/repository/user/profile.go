@@ -0,0 +1,285 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrEmailTaken          = errors.New("email already in use")
+	ErrEmailChangeNotFound = errors.New("email change request not found or expired")
+	ErrSharedWorkspaces    = errors.New("owned workspaces have other members")
+	ErrInvalidTransfer     = errors.New("workspace can only be transferred to its member")
+)
+
+// EmailInUse — занят ли email другим пользователем, в том числе удалённым (email уникален среди всех)
+func (r *PostgresUserRepository) EmailInUse(ctx context.Context, email string, exceptID uuid.UUID) (bool, error) {
+	var taken bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM users WHERE lower(email) = lower($1) AND id <> $2)`, email, exceptID,
+	).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("check email in use: %w", err)
+	}
+	return taken, nil
+}
+
+// CreateEmailChange сохраняет заявку на смену email; предыдущая заявка пользователя заменяется
+func (r *PostgresUserRepository) CreateEmailChange(ctx context.Context, userID uuid.UUID, newEmail, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO email_change_requests (user_id, new_email, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			new_email = EXCLUDED.new_email,
+			token_hash = EXCLUDED.token_hash,
+			expires_at = EXCLUDED.expires_at,
+			created_at = NOW()
+	`, userID, newEmail, tokenHash, expiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("create email change: %w", err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange применяет заявку по хешу токена и возвращает id пользователя.
+// ErrEmailChangeNotFound — заявки нет или она истекла, ErrEmailTaken — адрес успели занять.
+func (r *PostgresUserRepository) ConfirmEmailChange(ctx context.Context, tokenHash string) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID, newEmail string
+	err = tx.QueryRowContext(ctx, `
+		SELECT user_id, new_email FROM email_change_requests
+		WHERE token_hash = $1 AND expires_at > NOW() AT TIME ZONE 'UTC'
+		FOR UPDATE
+	`, tokenHash).Scan(&userID, &newEmail)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrEmailChangeNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("find email change: %w", err)
+	}
+
+	var taken bool
+	if err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM users WHERE lower(email) = lower($1) AND id <> $2)`, newEmail, userID,
+	).Scan(&taken); err != nil {
+		return "", fmt.Errorf("check email in use: %w", err)
+	}
+	if taken {
+		return "", ErrEmailTaken
+	}
+	res, err := tx.ExecContext(ctx,
+		`UPDATE users SET email = $2, updated_at = NOW() WHERE id = $1 AND status = 'ACTIVE'`, userID, newEmail)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return "", ErrEmailTaken
+		}
+		return "", fmt.Errorf("update email: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return "", ErrEmailChangeNotFound
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM email_change_requests WHERE user_id = $1`, userID); err != nil {
+		return "", fmt.Errorf("delete email change: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("commit: %w", err)
+	}
+	return userID, nil
+}
+
+// SetAvatar меняет аватар (prefix и url; nil — убрать). Файлы прежнего аватара, ключи которых
+// возвращает obsolete, ставятся в очередь на удаление из хранилища.
+func (r *PostgresUserRepository) SetAvatar(ctx context.Context, userID uuid.UUID, prefix, url *string, obsolete func(prefix string) []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var old sql.NullString
+	err = tx.QueryRowContext(ctx,
+		`SELECT avatar_key FROM users WHERE id = $1 AND status = 'ACTIVE' FOR UPDATE`, userID).Scan(&old)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sql.ErrNoRows
+	}
+	if err != nil {
+		return fmt.Errorf("get avatar: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE users SET avatar_key = $2, avatar_url = $3, updated_at = NOW() WHERE id = $1`, userID, prefix, url); err != nil {
+		return fmt.Errorf("set avatar: %w", err)
+	}
+	if old.Valid {
+		if err := queuePurge(ctx, tx, obsolete(old.String)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AvatarKey — префикс файлов аватара активного пользователя; пусто, если аватара нет
+func (r *PostgresUserRepository) AvatarKey(ctx context.Context, userID uuid.UUID) (string, error) {
+	var key sql.NullString
+	err := r.db.QueryRowContext(ctx,
+		`SELECT avatar_key FROM users WHERE id = $1 AND status = 'ACTIVE'`, userID).Scan(&key)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("get avatar key: %w", err)
+	}
+	return key.String, nil
+}
+
+// DeleteAccount мягко удаляет пользователя (status = DELETED) вместе с его участием в чужих воркспейсах.
+// Свои воркспейсы без других участников удаляются; общие передаются по transfers (workspaceId -> userId)
+// или удаляются при deleteShared. Если для общего воркспейса решения нет, ничего не меняется:
+// возвращается ErrSharedWorkspaces и список таких воркспейсов.
+func (r *PostgresUserRepository) DeleteAccount(ctx context.Context, userID uuid.UUID, transfers map[uuid.UUID]uuid.UUID, deleteShared bool, obsolete func(prefix string) []string) ([]model.OwnedWorkspace, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var avatarKey sql.NullString
+	err = tx.QueryRowContext(ctx,
+		`SELECT avatar_key FROM users WHERE id = $1 AND status = 'ACTIVE' FOR UPDATE`, userID).Scan(&avatarKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, sql.ErrNoRows
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lock user: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT w.id, w.name, (
+			SELECT COUNT(*) FROM user_workspaces uw JOIN users u ON u.id = uw.user_id
+			WHERE uw.workspace_id = w.id AND uw.user_id <> w.owner_id AND u.status = 'ACTIVE'
+		)
+		FROM workspaces w WHERE w.owner_id = $1
+		ORDER BY w.created_at
+		FOR UPDATE OF w
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list owned workspaces: %w", err)
+	}
+	var owned []model.OwnedWorkspace
+	for rows.Next() {
+		var w model.OwnedWorkspace
+		if err := rows.Scan(&w.ID, &w.Name, &w.Members); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan owned workspace: %w", err)
+		}
+		owned = append(owned, w)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+
+	ownedIDs := make(map[uuid.UUID]bool, len(owned))
+	for _, w := range owned {
+		ownedIDs[uuid.MustParse(w.ID)] = true
+	}
+	for wsID := range transfers {
+		if !ownedIDs[wsID] {
+			return nil, ErrInvalidTransfer
+		}
+	}
+
+	var toDelete []string
+	var unresolved []model.OwnedWorkspace
+	for _, w := range owned {
+		wsID := uuid.MustParse(w.ID)
+		if to, ok := transfers[wsID]; ok {
+			if err := transferWorkspace(ctx, tx, wsID, userID, to); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if w.Members == 0 || deleteShared {
+			toDelete = append(toDelete, w.ID)
+			continue
+		}
+		unresolved = append(unresolved, w)
+	}
+	if len(unresolved) > 0 {
+		return unresolved, ErrSharedWorkspaces
+	}
+
+	if len(toDelete) > 0 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM workspaces WHERE id = ANY($1::uuid[])`, pq.Array(toDelete)); err != nil {
+			return nil, fmt.Errorf("delete owned workspaces: %w", err)
+		}
+	}
+	for _, q := range []string{
+		`DELETE FROM user_workspaces WHERE user_id = $1`,
+		`DELETE FROM note_shares WHERE user_id = $1`,
+		`DELETE FROM email_change_requests WHERE user_id = $1`,
+		`DELETE FROM calendar_feeds WHERE user_id = $1`,
+		`DELETE FROM user_preferences WHERE user_id = $1`,
+		`UPDATE users SET status = 'DELETED', avatar_url = NULL, avatar_key = NULL, locale = NULL, timezone = NULL,
+			updated_at = NOW() WHERE id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, userID); err != nil {
+			return nil, fmt.Errorf("delete account: %w", err)
+		}
+	}
+	if avatarKey.Valid {
+		if err := queuePurge(ctx, tx, obsolete(avatarKey.String)); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return nil, nil
+}
+
+// transferWorkspace передаёт воркспейс участнику: новый владелец должен быть активным участником
+func transferWorkspace(ctx context.Context, tx *sql.Tx, workspaceID, from, to uuid.UUID) error {
+	if to == from {
+		return ErrInvalidTransfer
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE user_workspaces uw SET role = 'OWNER'
+		FROM users u
+		WHERE uw.workspace_id = $1 AND uw.user_id = $2 AND u.id = uw.user_id AND u.status = 'ACTIVE'
+	`, workspaceID, to)
+	if err != nil {
+		return fmt.Errorf("promote new owner: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrInvalidTransfer
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE workspaces SET owner_id = $2, updated_at = NOW() WHERE id = $1`, workspaceID, to); err != nil {
+		return fmt.Errorf("transfer workspace: %w", err)
+	}
+	return nil
+}
+
+// queuePurge ставит файлы в общую очередь удаления из хранилища (см. worker.AttachmentPurge)
+func queuePurge(ctx context.Context, tx *sql.Tx, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO attachment_purge_queue (storage_key) SELECT unnest($1::text[]) ON CONFLICT DO NOTHING`, pq.Array(keys))
+	if err != nil {
+		return fmt.Errorf("queue avatar purge: %w", err)
+	}
+	return nil
+}
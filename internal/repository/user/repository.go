@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"backend/internal/model"
+	"backend/pkg/query"
 )
 
 type UserRepository interface {
@@ -28,8 +29,8 @@ func (r *PostgresUserRepository) Create(ctx context.Context, user *model.User) e
 	query := `
 		INSERT INTO users (
 			id, email, password, name, role, 
-			avatar_url, status, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			avatar_url, locale, timezone, status, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -39,6 +40,8 @@ func (r *PostgresUserRepository) Create(ctx context.Context, user *model.User) e
 		user.Name,
 		user.Role,
 		user.AvatarURL,
+		user.Locale,
+		user.Timezone,
 		user.Status,
 		user.CreatedAt,
 		user.UpdatedAt,
@@ -50,7 +53,7 @@ func (r *PostgresUserRepository) FindByEmail(ctx context.Context, email string)
 	query := `
 		SELECT 
 			id, email, password, name, role, 
-			avatar_url, status, created_at, updated_at
+			avatar_url, locale, timezone, status, created_at, updated_at
 		FROM users 
 		WHERE email = $1 AND status = 'ACTIVE'
 	`
@@ -63,6 +66,8 @@ func (r *PostgresUserRepository) FindByEmail(ctx context.Context, email string)
 		&user.Name,
 		&user.Role,
 		&user.AvatarURL,
+		&user.Locale,
+		&user.Timezone,
 		&user.Status,
 		&user.CreatedAt,
 		&user.UpdatedAt,
@@ -81,7 +86,7 @@ func (r *PostgresUserRepository) FindByEmail(ctx context.Context, email string)
 // FindByEmailAnyStatus возвращает пользователя по email без фильтра по status (в т.ч. DELETED — для повторной регистрации).
 func (r *PostgresUserRepository) FindByEmailAnyStatus(ctx context.Context, email string) (*model.User, error) {
 	query := `
-		SELECT id, email, password, name, role, avatar_url, status, created_at, updated_at
+		SELECT id, email, password, name, role, avatar_url, locale, timezone, status, created_at, updated_at
 		FROM users WHERE email = $1
 	`
 	var user model.User
@@ -94,6 +99,8 @@ func (r *PostgresUserRepository) FindByEmailAnyStatus(ctx context.Context, email
 		&name,
 		&user.Role,
 		&avatarURL,
+		&user.Locale,
+		&user.Timezone,
 		&status,
 		&user.CreatedAt,
 		&user.UpdatedAt,
@@ -121,7 +128,7 @@ func (r *PostgresUserRepository) FindByID(ctx context.Context, id string) (*mode
 	query := `
 		SELECT 
 			id, email, password, name, role, 
-			avatar_url, status, created_at, updated_at
+			avatar_url, locale, timezone, status, created_at, updated_at
 		FROM users 
 		WHERE id = $1 AND status = 'ACTIVE'
 	`
@@ -134,6 +141,8 @@ func (r *PostgresUserRepository) FindByID(ctx context.Context, id string) (*mode
 		&user.Name,
 		&user.Role,
 		&user.AvatarURL,
+		&user.Locale,
+		&user.Timezone,
 		&user.Status,
 		&user.CreatedAt,
 		&user.UpdatedAt,
@@ -158,7 +167,9 @@ func (r *PostgresUserRepository) Update(ctx context.Context, user *model.User) e
 			role = $5,
 			avatar_url = $6,
 			status = $7,
-			updated_at = $8
+			updated_at = $8,
+			locale = $9,
+			timezone = $10
 		WHERE id = $1
 	`
 
@@ -171,6 +182,8 @@ func (r *PostgresUserRepository) Update(ctx context.Context, user *model.User) e
 		user.AvatarURL,
 		user.Status,
 		user.UpdatedAt,
+		user.Locale,
+		user.Timezone,
 	)
 
 	if err != nil {
@@ -189,24 +202,38 @@ func (r *PostgresUserRepository) Update(ctx context.Context, user *model.User) e
 	return nil
 }
 
-func (r *PostgresUserRepository) ListAll(ctx context.Context) ([]model.User, error) {
-	query := `
-		SELECT id, email, name, role, avatar_url, status, created_at, updated_at
-		FROM users
-		WHERE status = 'ACTIVE'
-		ORDER BY email
-	`
-	rows, err := r.db.QueryContext(ctx, query)
+// ListSchema — сортировки и фильтры списка пользователей в админке
+var ListSchema = query.Schema{
+	Sorts: map[string]query.SortField{
+		"email":     {Column: "email", Type: query.TypeText},
+		"name":      {Column: "COALESCE(name, '')", Type: query.TypeText},
+		"createdAt": {Column: "created_at", Type: query.TypeTimestamp},
+	},
+	Filters: map[string]query.Filter{
+		"role": {Column: "role", Op: query.OpEq, Type: query.TypeText},
+		"q":    {Column: "email", Op: query.OpContains, Type: query.TypeText},
+	},
+	DefaultSort: "email",
+}
+
+// ListAll возвращает страницу активных пользователей
+func (r *PostgresUserRepository) ListAll(ctx context.Context, spec query.Spec) ([]model.User, query.Page, error) {
+	where, tail, args := spec.SQL(1)
+	q := `SELECT id, email, name, role, avatar_url, status, created_at, updated_at, ` + spec.KeyColumn() +
+		` FROM users WHERE status = 'ACTIVE'` + where + tail
+	rows, err := r.db.QueryContext(ctx, q, args...)
 	if err != nil {
-		return nil, fmt.Errorf("list all users: %w", err)
+		return nil, query.Page{}, fmt.Errorf("list all users: %w", err)
 	}
 	defer rows.Close()
 
-	var users []model.User
+	users := make([]model.User, 0)
+	var keys []query.Key
 	for rows.Next() {
 		var u model.User
 		var name, avatarURL sql.NullString
 		var status sql.NullString
+		var key string
 		err := rows.Scan(
 			&u.ID,
 			&u.Email,
@@ -216,9 +243,10 @@ func (r *PostgresUserRepository) ListAll(ctx context.Context) ([]model.User, err
 			&status,
 			&u.CreatedAt,
 			&u.UpdatedAt,
+			&key,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("scan user: %w", err)
+			return nil, query.Page{}, fmt.Errorf("scan user: %w", err)
 		}
 		if name.Valid {
 			u.Name = &name.String
@@ -231,11 +259,13 @@ func (r *PostgresUserRepository) ListAll(ctx context.Context) ([]model.User, err
 			u.Status = &s
 		}
 		users = append(users, u)
+		keys = append(keys, query.Key{Value: key, ID: u.ID})
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows: %w", err)
+		return nil, query.Page{}, fmt.Errorf("rows: %w", err)
 	}
-	return users, nil
+	users, page := query.Paginate(spec, users, keys)
+	return users, page, nil
 }
 
 func (r *PostgresUserRepository) Delete(ctx context.Context, id string) error {
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"backend/internal/model"
+	"backend/pkg/query"
 
 	"github.com/google/uuid"
 )
@@ -72,24 +73,37 @@ func (r *Repository) List(ctx context.Context, userID uuid.UUID) ([]model.Worksp
 	return workspaces, nil
 }
 
-// ListAll возвращает все workspaces (только для админа).
-func (r *Repository) ListAll(ctx context.Context) ([]model.Workspace, error) {
-	query := `
-		SELECT id, name, description, color, owner_id, created_at, updated_at
-		FROM workspaces
-		ORDER BY created_at DESC
-	`
-	rows, err := r.db.QueryContext(ctx, query)
+// ListAllSchema — сортировки и фильтры списка всех воркспейсов в админке
+var ListAllSchema = query.Schema{
+	Sorts: map[string]query.SortField{
+		"createdAt": {Column: "created_at", Type: query.TypeTimestamp},
+		"name":      {Column: "name", Type: query.TypeText},
+	},
+	Filters: map[string]query.Filter{
+		"ownerId": {Column: "owner_id", Op: query.OpEq, Type: query.TypeUUID},
+		"q":       {Column: "name", Op: query.OpContains, Type: query.TypeText},
+	},
+	DefaultSort: "-createdAt",
+}
+
+// ListAll возвращает страницу всех workspaces (только для админа).
+func (r *Repository) ListAll(ctx context.Context, spec query.Spec) ([]model.Workspace, query.Page, error) {
+	where, tail, args := spec.SQL(1)
+	q := `SELECT id, name, description, color, owner_id, created_at, updated_at, ` + spec.KeyColumn() +
+		` FROM workspaces WHERE true` + where + tail
+	rows, err := r.db.QueryContext(ctx, q, args...)
 	if err != nil {
-		return nil, fmt.Errorf("list all workspaces: %w", err)
+		return nil, query.Page{}, fmt.Errorf("list all workspaces: %w", err)
 	}
 	defer rows.Close()
 
-	var workspaces []model.Workspace
+	workspaces := make([]model.Workspace, 0)
+	var keys []query.Key
 	for rows.Next() {
 		var ws model.Workspace
 		var createdAt, updatedAt time.Time
 		var description sql.NullString
+		var key string
 		err := rows.Scan(
 			&ws.ID,
 			&ws.Name,
@@ -98,9 +112,10 @@ func (r *Repository) ListAll(ctx context.Context) ([]model.Workspace, error) {
 			&ws.OwnerID,
 			&createdAt,
 			&updatedAt,
+			&key,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("scan workspace: %w", err)
+			return nil, query.Page{}, fmt.Errorf("scan workspace: %w", err)
 		}
 		if description.Valid {
 			ws.Description = &description.String
@@ -108,11 +123,13 @@ func (r *Repository) ListAll(ctx context.Context) ([]model.Workspace, error) {
 		ws.CreatedAt = createdAt.Format(time.RFC3339)
 		ws.UpdatedAt = updatedAt.Format(time.RFC3339)
 		workspaces = append(workspaces, ws)
+		keys = append(keys, query.Key{Value: key, ID: ws.ID})
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows: %w", err)
+		return nil, query.Page{}, fmt.Errorf("rows: %w", err)
 	}
-	return workspaces, nil
+	workspaces, page := query.Paginate(spec, workspaces, keys)
+	return workspaces, page, nil
 }
 
 func (r *Repository) Create(ctx context.Context, dto model.CreateWorkspaceDto, ownerID uuid.UUID) (*model.Workspace, error) {
@@ -293,6 +310,18 @@ func (r *Repository) HasAccess(ctx context.Context, workspaceID, userID uuid.UUI
 	return ok, nil
 }
 
+// AddMember добавляет пользователя в workspace с ролью role (MEMBER, OWNER). Повторное добавление меняет роль.
+func (r *Repository) AddMember(ctx context.Context, workspaceID, userID uuid.UUID, role string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_workspaces (user_id, workspace_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, workspace_id) DO UPDATE SET role = EXCLUDED.role
+	`, userID, workspaceID, role)
+	if err != nil {
+		return fmt.Errorf("add workspace member: %w", err)
+	}
+	return nil
+}
+
 func (r *Repository) CheckAccess(ctx context.Context, workspaceID, userID uuid.UUID, userRole model.UserRole) (bool, error) {
 	if userRole == model.UserRoleAdmin {
 		return true, nil
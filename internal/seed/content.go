@@ -0,0 +1,148 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"backend/internal/model"
+)
+
+var (
+	firstNames     = []string{"Анна", "Иван", "Мария", "Алексей", "Ольга", "Дмитрий", "Елена", "Сергей", "Наталья", "Павел", "Юлия", "Никита"}
+	lastNames      = []string{"Иванова", "Петров", "Смирнова", "Кузнецов", "Попова", "Соколов", "Лебедева", "Козлов", "Новикова", "Морозов"}
+	workspaceNames = []string{"Личное", "Работа", "Спорт", "Семья", "Учёба", "Проекты", "Здоровье"}
+	colors         = []string{"#3B82F6", "#10B981", "#F59E0B", "#EF4444", "#8B5CF6", "#EC4899", "#14B8A6", "#64748B"}
+
+	journalTags      = []string{"работа", "спорт", "семья", "здоровье", "учёба", "отдых", "идеи", "путешествия", "сон", "друзья"}
+	journalSentences = []string{
+		"Продуктивный день, закрыл почти все задачи.",
+		"Плохо спал, весь день клонило в сон.",
+		"Долгая прогулка в парке, очень освежает.",
+		"Встреча с друзьями, много смеялись.",
+		"Застрял на сложной задаче, завтра попробую иначе.",
+		"Прочитал главу книги и сделал заметки.",
+		"Тренировка далась тяжело, но результат есть.",
+		"Спокойный вечер с семьёй.",
+		"Много отвлекался на телефон.",
+		"Появилась идея для нового проекта.",
+		"Весь день шёл дождь, сидел дома.",
+		"Наконец-то разобрал завалы в почте.",
+	}
+
+	noteTitles = []string{"Список покупок", "Идеи для отпуска", "План на неделю", "Книги к прочтению", "Заметки со встречи", "Рецепт", "Цели на квартал", "Подарки", "Вопросы к врачу", "Черновик письма"}
+	noteLines  = []string{"молоко, хлеб, сыр", "уточнить сроки", "позвонить в понедельник", "посмотреть варианты", "обсудить бюджет", "купить билеты", "записаться заранее", "сравнить цены", "не забыть документы", "спросить совета"}
+
+	currencies = []struct{ Code, Name, Symbol string }{
+		{"RUB", "Российский рубль", "₽"},
+		{"USD", "Доллар США", "$"},
+		{"EUR", "Евро", "€"},
+	}
+	companyWords = []string{"Northwind", "Polar", "Vector", "Orbit", "Granit", "Meridian", "Aurora", "Baltic", "Sever", "Volga", "Kedr", "Altai"}
+	companyKinds = []string{"Trading", "Logistics", "Studio", "Systems", "Foods", "Consulting", "Print", "Service"}
+	cpTypes      = []string{"client", "supplier", "both"}
+)
+
+// seedJournal — записи дневника участников за всю глубину истории: настроение плавно меняется, теги и текст случайные
+func (s *Seeder) seedJournal(ctx context.Context, ws *model.Workspace, members []*model.User) error {
+	p := s.opts.Preset
+	mood := 3
+	for d := p.Days; d >= 0; d-- {
+		if s.rnd.IntN(7) >= p.JournalPerWeek {
+			continue
+		}
+		mood = min(5, max(1, mood+s.rnd.IntN(3)-1))
+		entry := &model.JournalEntry{
+			WorkspaceID: ws.ID,
+			UserID:      pick(s.rnd, members).ID,
+			Description: s.journalText(),
+			Date:        s.today.AddDate(0, 0, -d).Format("2006-01-02"),
+			Tags:        s.pickTags(),
+			ContentType: "text",
+		}
+		if s.rnd.IntN(7) > 0 {
+			m := mood
+			entry.Mood = &m
+		}
+		if s.rnd.IntN(4) == 0 {
+			entry.ContentType = "markdown"
+			entry.Description = "## " + pick(s.rnd, journalTags) + "\n\n- " + strings.ReplaceAll(entry.Description, ". ", ".\n- ")
+		}
+		if err := s.journal.Create(ctx, entry); err != nil {
+			return err
+		}
+		s.res.JournalEntries++
+	}
+	return nil
+}
+
+func (s *Seeder) journalText() string {
+	n := 1 + s.rnd.IntN(3)
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = pick(s.rnd, journalSentences)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (s *Seeder) pickTags() []string {
+	n := s.rnd.IntN(4)
+	tags := make([]string, 0, n)
+	for _, i := range s.rnd.Perm(len(journalTags))[:n] {
+		tags = append(tags, journalTags[i])
+	}
+	return tags
+}
+
+func (s *Seeder) seedNotes(ctx context.Context, ws *model.Workspace, members []*model.User) error {
+	for i := 0; i < s.opts.Preset.NotesPerWorkspace; i++ {
+		lines := make([]string, 2+s.rnd.IntN(4))
+		for j := range lines {
+			lines[j] = "- " + pick(s.rnd, noteLines)
+		}
+		n := &model.Note{
+			WorkspaceID: ws.ID,
+			UserID:      pick(s.rnd, members).ID,
+			Title:       pick(s.rnd, noteTitles),
+			Content:     strings.Join(lines, "\n"),
+		}
+		if err := s.notes.Create(ctx, n); err != nil {
+			return err
+		}
+		s.res.Notes++
+	}
+	return nil
+}
+
+// seedMaster — справочники воркспейса: валюты и контрагенты
+func (s *Seeder) seedMaster(ctx context.Context, ws *model.Workspace) error {
+	for _, c := range currencies {
+		symbol := c.Symbol
+		if err := s.master.CreateCurrency(ctx, &model.Currency{WorkspaceID: ws.ID, Code: c.Code, Name: c.Name, Symbol: &symbol}); err != nil {
+			return err
+		}
+		s.res.Currencies++
+	}
+
+	for i := 0; i < s.opts.Preset.Counterparties; i++ {
+		word := pick(s.rnd, companyWords)
+		cp := &model.Counterparty{
+			WorkspaceID: ws.ID,
+			Name:        word + " " + pick(s.rnd, companyKinds),
+			Type:        pick(s.rnd, cpTypes),
+		}
+		if s.rnd.IntN(4) > 0 {
+			email := fmt.Sprintf("info@%s.example.com", strings.ToLower(word))
+			cp.Email = &email
+		}
+		if s.rnd.IntN(2) == 0 {
+			phone := fmt.Sprintf("+7 9%02d %03d-%02d-%02d", s.rnd.IntN(100), s.rnd.IntN(1000), s.rnd.IntN(100), s.rnd.IntN(100))
+			cp.Phone = &phone
+		}
+		if err := s.master.CreateCounterparty(ctx, cp); err != nil {
+			return err
+		}
+		s.res.Counterparties++
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+type habitTemplate struct {
+	Title, Icon, Category, PreferredTime string
+}
+
+var habitTemplates = []habitTemplate{
+	{"Зарядка", "dumbbell", "health", "morning"},
+	{"Выпить 2 литра воды", "droplet", "health", "any"},
+	{"Прочитать 20 страниц", "book", "learning", "evening"},
+	{"Медитация", "brain", "mindfulness", "morning"},
+	{"Прогулка 10 000 шагов", "footprints", "health", "afternoon"},
+	{"Английский 15 минут", "languages", "learning", "evening"},
+	{"Без сладкого", "candy-off", "health", "any"},
+	{"Лечь до 23:00", "moon", "sleep", "evening"},
+	{"Пробежка", "activity", "sport", "morning"},
+	{"Разбор входящих", "inbox", "work", "morning"},
+	{"Дневник благодарности", "heart", "mindfulness", "evening"},
+	{"Растяжка", "stretch", "sport", "evening"},
+}
+
+var scheduleVariants = [][]int{
+	{0, 1, 2, 3, 4, 5, 6},
+	{0, 1, 2, 3, 4, 5, 6},
+	{1, 2, 3, 4, 5},
+	{0, 6},
+	{1, 3, 5},
+	{2, 4},
+}
+
+// seedHabits моделирует жизнь привычек день за днём: создание в прошлом, выполнения по расписанию,
+// переименования и смену расписания (новые версии), удаление части привычек. Все изменения идут через
+// Repository.WithClock, поэтому версии получают те же даты, что при работе через API в соответствующие дни.
+func (s *Seeder) seedHabits(ctx context.Context, owner *model.User, ws *model.Workspace) error {
+	p := s.opts.Preset
+	uid, wsID := uuid.MustParse(owner.ID), uuid.MustParse(ws.ID)
+
+	for _, ti := range s.rnd.Perm(len(habitTemplates))[:min(p.HabitsPerWorkspace, len(habitTemplates))] {
+		tpl := habitTemplates[ti]
+		start := s.today.AddDate(0, 0, -(p.Days/3 + s.rnd.IntN(p.Days-p.Days/3+1)))
+
+		dto := model.CreateHabitDto{
+			Title:         tpl.Title,
+			Icon:          tpl.Icon,
+			Category:      tpl.Category,
+			PreferredTime: tpl.PreferredTime,
+			Color:         pick(s.rnd, colors),
+			ScheduleType:  "recurring",
+			RecurringDays: pick(s.rnd, scheduleVariants),
+		}
+		// Разовая задача: дата в пределах истории
+		if s.rnd.IntN(8) == 0 {
+			dto.ScheduleType = "one_time"
+			dto.RecurringDays = nil
+			dto.OneTimeDate = start.AddDate(0, 0, s.rnd.IntN(int(s.today.Sub(start).Hours()/24)+1)).Format("2006-01-02")
+		}
+
+		habit, err := s.habits.WithClock(fixedClock(start)).Create(ctx, dto, uid, wsID)
+		if err != nil {
+			return fmt.Errorf("create habit %q: %w", dto.Title, err)
+		}
+		s.res.Habits++
+		hid := uuid.MustParse(habit.ID)
+
+		days := int(s.today.Sub(start).Hours() / 24)
+		changeDay, deleteDay := -1, -1
+		if days > 7 && dto.ScheduleType == "recurring" && s.rnd.IntN(10) < 4 {
+			changeDay = 3 + s.rnd.IntN(days-6)
+		}
+		if days > 7 && s.rnd.IntN(10) == 0 {
+			deleteDay = days/2 + s.rnd.IntN(days-days/2)
+		}
+		rate := 0.5 + s.rnd.Float64()*0.4
+		schedule := dto.RecurringDays
+
+		for d := 0; d <= days; d++ {
+			day := start.AddDate(0, 0, d)
+
+			due := dto.ScheduleType == "one_time" && day.Format("2006-01-02") == dto.OneTimeDate ||
+				dto.ScheduleType == "recurring" && slices.Contains(schedule, int(day.Weekday()))
+			if due && s.rnd.Float64() < rate {
+				var rating interface{}
+				if s.rnd.IntN(3) == 0 {
+					rating = 1 + s.rnd.IntN(5)
+				}
+				notes := ""
+				if s.rnd.IntN(6) == 0 {
+					notes = pick(s.rnd, completionNotes)
+				}
+				if _, err := s.habits.Complete(ctx, hid, uid, day, notes, rating, nil); err != nil {
+					return fmt.Errorf("complete habit %q: %w", dto.Title, err)
+				}
+				s.res.Completions++
+			}
+
+			if d == changeDay {
+				// Изменение вступает в силу со следующего дня — так же ведёт себя Repository.Update
+				upd, next := s.habitChange(dto.Title, schedule)
+				if _, err := s.habits.WithClock(fixedClock(day)).Update(ctx, hid, uid, upd); err != nil {
+					return fmt.Errorf("update habit %q: %w", dto.Title, err)
+				}
+				schedule = next
+				s.res.HabitChanges++
+			}
+			if d == deleteDay {
+				if err := s.habits.WithClock(fixedClock(day)).Delete(ctx, hid, uid); err != nil {
+					return fmt.Errorf("delete habit %q: %w", dto.Title, err)
+				}
+				s.res.HabitsDeleted++
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// habitChange выбирает изменение, создающее новую версию: переименование, цвет или расписание
+func (s *Seeder) habitChange(title string, schedule []int) (model.UpdateHabitDto, []int) {
+	var upd model.UpdateHabitDto
+	switch s.rnd.IntN(3) {
+	case 0:
+		t := title + " (" + pick(s.rnd, titleSuffixes) + ")"
+		upd.Title = &t
+	case 1:
+		c := pick(s.rnd, colors)
+		upd.Color = &c
+	default:
+		next := pick(s.rnd, scheduleVariants)
+		upd.RecurringDays = &next
+		return upd, next
+	}
+	return upd, schedule
+}
+
+var titleSuffixes = []string{"усложнено", "утро", "по будням", "v2", "минимум"}
+
+var completionNotes = []string{
+	"Было тяжело, но сделал",
+	"Легко",
+	"Сделал вечером",
+	"Половину, но засчитываю",
+	"Отличное настроение после",
+}
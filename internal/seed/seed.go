@@ -0,0 +1,226 @@
+// Package seed генерирует демо-данные для разработки и QA: пользователей, воркспейсы с участниками,
+// привычки с историей выполнений и версий, дневник, заметки, валюты и контрагентов.
+// Всё создаётся через репозитории, поэтому инварианты (habit_versions, core-модули, user_workspaces) соблюдаются.
+// Одинаковый seed даёт одинаковое содержимое относительно текущей даты; UUID при этом каждый раз новые.
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"backend/internal/model"
+	habitsRepo "backend/internal/repository/habits"
+	journalRepo "backend/internal/repository/journal"
+	masterRepo "backend/internal/repository/master"
+	notesRepo "backend/internal/repository/notes"
+	userRepo "backend/internal/repository/user"
+	workspaceRepo "backend/internal/repository/workspace"
+	"backend/pkg/password"
+
+	"github.com/google/uuid"
+)
+
+// ErrAlreadySeeded — данные с этим seed уже есть в БД
+var ErrAlreadySeeded = errors.New("data for this seed already exists")
+
+// Preset — объём генерируемых данных
+type Preset struct {
+	Users               int // первый пользователь — администратор
+	WorkspacesPerUser   int
+	MembersPerWorkspace int // участников помимо владельца
+	HabitsPerWorkspace  int
+	Days                int // глубина истории привычек и дневника
+	JournalPerWeek      int // записей дневника в неделю на воркспейс
+	NotesPerWorkspace   int
+	Counterparties      int // на воркспейс
+}
+
+// Presets — готовые размеры для --size
+var Presets = map[string]Preset{
+	"small":  {Users: 3, WorkspacesPerUser: 1, MembersPerWorkspace: 1, HabitsPerWorkspace: 4, Days: 30, JournalPerWeek: 3, NotesPerWorkspace: 3, Counterparties: 3},
+	"medium": {Users: 10, WorkspacesPerUser: 2, MembersPerWorkspace: 2, HabitsPerWorkspace: 8, Days: 120, JournalPerWeek: 4, NotesPerWorkspace: 10, Counterparties: 10},
+	"large":  {Users: 30, WorkspacesPerUser: 2, MembersPerWorkspace: 4, HabitsPerWorkspace: 10, Days: 365, JournalPerWeek: 5, NotesPerWorkspace: 30, Counterparties: 40},
+}
+
+// Options — параметры генерации
+type Options struct {
+	Seed     uint64
+	Preset   Preset
+	Password string // пароль всех созданных пользователей
+}
+
+// Result — сколько чего создано
+type Result struct {
+	Users          int
+	Workspaces     int
+	Memberships    int
+	Habits         int
+	HabitChanges   int
+	HabitsDeleted  int
+	Completions    int
+	JournalEntries int
+	Notes          int
+	Currencies     int
+	Counterparties int
+}
+
+// Seeder создаёт данные. Не потокобезопасен: один Seeder — один Run.
+type Seeder struct {
+	users      *userRepo.PostgresUserRepository
+	workspaces *workspaceRepo.Repository
+	habits     *habitsRepo.Repository
+	journal    *journalRepo.Repository
+	notes      *notesRepo.Repository
+	master     *masterRepo.Repository
+
+	opts  Options
+	rnd   *rand.Rand
+	today time.Time
+	res   Result
+}
+
+func New(db *sql.DB, opts Options) *Seeder {
+	return &Seeder{
+		users:      userRepo.NewRepository(db),
+		workspaces: workspaceRepo.NewRepository(db),
+		habits:     habitsRepo.NewRepository(db),
+		journal:    journalRepo.NewRepository(db),
+		notes:      notesRepo.NewRepository(db),
+		master:     masterRepo.NewRepository(db),
+		opts:       opts,
+		rnd:        rand.New(rand.NewPCG(opts.Seed, 0x5eed)),
+		today:      habitsRepo.NormalizeDate(time.Now()),
+	}
+}
+
+// Email возвращает адрес i-го пользователя (с нуля) для данного seed
+func Email(seed uint64, i int) string {
+	return fmt.Sprintf("seed%d-user%d@example.com", seed, i+1)
+}
+
+// Run создаёт все данные. Если пользователь с первым адресом этого seed уже есть — ErrAlreadySeeded.
+func (s *Seeder) Run(ctx context.Context) (Result, error) {
+	p := s.opts.Preset
+	if p.Users < 1 || p.Days < 1 {
+		return s.res, fmt.Errorf("preset must have at least one user and one day")
+	}
+
+	existing, err := s.users.FindByEmailAnyStatus(ctx, Email(s.opts.Seed, 0))
+	if err != nil {
+		return s.res, err
+	}
+	if existing != nil {
+		return s.res, ErrAlreadySeeded
+	}
+
+	users, err := s.createUsers(ctx)
+	if err != nil {
+		return s.res, err
+	}
+
+	for i, owner := range users {
+		for j := 0; j < p.WorkspacesPerUser; j++ {
+			if err := s.seedWorkspace(ctx, owner, users, i, j); err != nil {
+				return s.res, err
+			}
+		}
+	}
+	return s.res, nil
+}
+
+func (s *Seeder) createUsers(ctx context.Context) ([]*model.User, error) {
+	hash, err := password.Hash(s.opts.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*model.User, 0, s.opts.Preset.Users)
+	createdAt := s.today.AddDate(0, 0, -s.opts.Preset.Days)
+	for i := 0; i < s.opts.Preset.Users; i++ {
+		name := pick(s.rnd, firstNames) + " " + pick(s.rnd, lastNames)
+		role := model.UserRoleUser
+		if i == 0 {
+			role = model.UserRoleAdmin
+		}
+		status := model.UserStatusActive
+		u := &model.User{
+			ID:        uuid.NewString(),
+			Email:     Email(s.opts.Seed, i),
+			Password:  hash,
+			Name:      &name,
+			Role:      role,
+			Status:    &status,
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		}
+		if err := s.users.Create(ctx, u); err != nil {
+			return nil, fmt.Errorf("create user %s: %w", u.Email, err)
+		}
+		users = append(users, u)
+		s.res.Users++
+	}
+	return users, nil
+}
+
+// seedWorkspace создаёт j-й воркспейс пользователя users[ownerIdx] и наполняет его
+func (s *Seeder) seedWorkspace(ctx context.Context, owner *model.User, users []*model.User, ownerIdx, j int) error {
+	p := s.opts.Preset
+	color := pick(s.rnd, colors)
+	description := "Демо-данные (seed " + fmt.Sprint(s.opts.Seed) + ")"
+	ws, err := s.workspaces.Create(ctx, model.CreateWorkspaceDto{
+		Name:        workspaceNames[(ownerIdx+j)%len(workspaceNames)],
+		Description: &description,
+		Color:       &color,
+	}, uuid.MustParse(owner.ID))
+	if err != nil {
+		return err
+	}
+	s.res.Workspaces++
+	wsID := uuid.MustParse(ws.ID)
+
+	// Заметки — не core-модуль: включаем явно, как это сделал бы админ
+	if mod, err := s.workspaces.GetModuleByCode(ctx, "notes"); err != nil {
+		return err
+	} else if mod != nil {
+		if err := s.workspaces.AddWorkspaceModule(ctx, wsID, uuid.MustParse(mod.ID)); err != nil {
+			return err
+		}
+	}
+
+	members := []*model.User{owner}
+	for _, k := range s.rnd.Perm(len(users)) {
+		if len(members) > p.MembersPerWorkspace || k == ownerIdx {
+			continue
+		}
+		if err := s.workspaces.AddMember(ctx, wsID, uuid.MustParse(users[k].ID), "MEMBER"); err != nil {
+			return err
+		}
+		members = append(members, users[k])
+		s.res.Memberships++
+	}
+
+	if err := s.seedHabits(ctx, owner, ws); err != nil {
+		return err
+	}
+	if err := s.seedJournal(ctx, ws, members); err != nil {
+		return err
+	}
+	if err := s.seedNotes(ctx, ws, members); err != nil {
+		return err
+	}
+	return s.seedMaster(ctx, ws)
+}
+
+func pick[T any](rnd *rand.Rand, list []T) T {
+	return list[rnd.IntN(len(list))]
+}
+
+// fixedClock — «сейчас» для репозитория привычек: полдень дня day по UTC
+func fixedClock(day time.Time) func() time.Time {
+	t := day.Add(12 * time.Hour)
+	return func() time.Time { return t }
+}
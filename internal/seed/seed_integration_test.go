@@ -0,0 +1,56 @@
+package seed_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend/internal/seed"
+	"backend/internal/testutil/pgtest"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+func TestRun_SmallPresetKeepsInvariants(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	opts := seed.Options{Seed: 7, Preset: seed.Presets["small"], Password: "demo12345"}
+
+	res, err := seed.New(env.DB, opts).Run(ctx)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Users != opts.Preset.Users || res.Habits == 0 || res.Completions == 0 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	// У каждой живой привычки ровно одна открытая версия, у удалённой — ни одной
+	var broken int
+	if err := env.DB.QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT v.habit_id, COUNT(*) FILTER (WHERE v.valid_to IS NULL) AS open, bool_or(h.id IS NOT NULL) AS alive
+			FROM habit_versions v LEFT JOIN habits h ON h.id = v.habit_id
+			GROUP BY v.habit_id
+		) s WHERE (alive AND open <> 1) OR (NOT alive AND open <> 0)
+	`).Scan(&broken); err != nil {
+		t.Fatal(err)
+	}
+	if broken != 0 {
+		t.Errorf("%d habits with inconsistent open versions", broken)
+	}
+
+	var orphans int
+	if err := env.DB.QueryRow(`
+		SELECT COUNT(*) FROM habit_completions c
+		WHERE NOT EXISTS (SELECT 1 FROM habit_versions v WHERE v.habit_id = c.habit_id AND c.date >= v.valid_from)
+	`).Scan(&orphans); err != nil {
+		t.Fatal(err)
+	}
+	if orphans != 0 {
+		t.Errorf("%d completions dated before their habit existed", orphans)
+	}
+
+	if _, err := seed.New(env.DB, opts).Run(ctx); !errors.Is(err, seed.ErrAlreadySeeded) {
+		t.Errorf("second run: err = %v, want ErrAlreadySeeded", err)
+	}
+}
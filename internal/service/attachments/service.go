@@ -0,0 +1,251 @@
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"backend/internal/model"
+	attachmentsRepo "backend/internal/repository/attachments"
+	"backend/pkg/storage"
+
+	"github.com/google/uuid"
+)
+
+// sniffLen — сколько первых байт файла смотрит http.DetectContentType
+const sniffLen = 512
+
+var (
+	ErrEmptyFile      = errors.New("file is empty")
+	ErrTooLarge       = errors.New("file is too large")
+	ErrTypeNotAllowed = errors.New("file type is not allowed")
+)
+
+// Limits — ограничения загрузки и срок ссылок на скачивание
+type Limits struct {
+	MaxSize        int64
+	AllowedTypes   []string
+	WorkspaceQuota int64
+	URLTTL         time.Duration
+}
+
+type Service struct {
+	repo   *attachmentsRepo.Repository
+	store  storage.Storage
+	limits Limits
+}
+
+func NewService(repo *attachmentsRepo.Repository, store storage.Storage, limits Limits) *Service {
+	return &Service{repo: repo, store: store, limits: limits}
+}
+
+// Upload сохраняет файл и прикрепляет его к владельцу. Тип файла определяется по содержимому, а не по
+// заголовку клиента. Ошибки: ErrEmptyFile, ErrTooLarge, ErrTypeNotAllowed, attachmentsRepo.ErrOwnerNotFound,
+// attachmentsRepo.ErrQuotaExceeded.
+func (s *Service) Upload(ctx context.Context, workspaceID, userID, ownerType, ownerID, filename string, size int64, r io.Reader) (*model.Attachment, error) {
+	if size <= 0 {
+		return nil, ErrEmptyFile
+	}
+	if size > s.limits.MaxSize {
+		return nil, ErrTooLarge
+	}
+	wsID, oID, err := parseIDs(workspaceID, ownerID)
+	if err != nil {
+		return nil, attachmentsRepo.ErrOwnerNotFound
+	}
+	ok, err := s.repo.OwnerExists(ctx, wsID, ownerType, oID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, attachmentsRepo.ErrOwnerNotFound
+	}
+	// Заранее отказываем, если файл точно не влезет; окончательная проверка — при сохранении метаданных
+	if _, used, err := s.repo.Usage(ctx, wsID); err != nil {
+		return nil, err
+	} else if used+size > s.limits.WorkspaceQuota {
+		return nil, attachmentsRepo.ErrQuotaExceeded
+	}
+
+	head := make([]byte, min(size, sniffLen))
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, fmt.Errorf("read upload: %w", err)
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !slices.Contains(s.limits.AllowedTypes, contentType) {
+		return nil, ErrTypeNotAllowed
+	}
+
+	a := &model.Attachment{
+		ID:          uuid.NewString(),
+		WorkspaceID: wsID.String(),
+		OwnerType:   ownerType,
+		OwnerID:     oID.String(),
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		Size:        size,
+		UploadedBy:  &userID,
+	}
+	a.StorageKey = "workspaces/" + a.WorkspaceID + "/" + a.ID
+	hash := sha256.New()
+	body := io.TeeReader(io.MultiReader(bytes.NewReader(head), r), hash)
+	if err := s.store.Put(ctx, a.StorageKey, body, size, contentType); err != nil {
+		return nil, err
+	}
+	a.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	if err := s.repo.Create(ctx, a, s.limits.WorkspaceQuota); err != nil {
+		// Метаданных нет — файл никто не найдёт, удаляем сразу
+		if delErr := s.store.Delete(context.WithoutCancel(ctx), a.StorageKey); delErr != nil {
+			log.Printf("[attachments] delete orphan %s: %v", a.StorageKey, delErr)
+		}
+		return nil, err
+	}
+	return s.withURL(ctx, a)
+}
+
+// List — вложения владельца со ссылками на скачивание
+func (s *Service) List(ctx context.Context, workspaceID, ownerType, ownerID string) ([]model.Attachment, error) {
+	wsID, oID, err := parseIDs(workspaceID, ownerID)
+	if err != nil {
+		return nil, attachmentsRepo.ErrOwnerNotFound
+	}
+	ok, err := s.repo.OwnerExists(ctx, wsID, ownerType, oID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, attachmentsRepo.ErrOwnerNotFound
+	}
+	list, err := s.repo.List(ctx, wsID, ownerType, oID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if _, err := s.withURL(ctx, &list[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// Get — вложение со свежей ссылкой на скачивание; nil — нет в воркспейсе
+func (s *Service) Get(ctx context.Context, workspaceID, id string) (*model.Attachment, error) {
+	wsID, aID, err := parseIDs(workspaceID, id)
+	if err != nil {
+		return nil, nil
+	}
+	a, err := s.repo.Get(ctx, aID, wsID)
+	if err != nil || a == nil {
+		return nil, err
+	}
+	return s.withURL(ctx, a)
+}
+
+// Delete удаляет вложение; файл из хранилища удаляет PurgeDeleted. sql.ErrNoRows — вложения нет.
+func (s *Service) Delete(ctx context.Context, workspaceID, id string) error {
+	wsID, aID, err := parseIDs(workspaceID, id)
+	if err != nil {
+		return sql.ErrNoRows
+	}
+	return s.repo.Delete(ctx, aID, wsID)
+}
+
+func (s *Service) Usage(ctx context.Context, workspaceID string) (*model.AttachmentUsage, error) {
+	wsID, err := uuid.Parse(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	count, used, err := s.repo.Usage(ctx, wsID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.AttachmentUsage{Count: count, UsedBytes: used, QuotaBytes: s.limits.WorkspaceQuota, MaxSize: s.limits.MaxSize}, nil
+}
+
+// PurgeDeleted удаляет из хранилища до limit файлов удалённых вложений. Неудачные попытки остаются в очереди.
+func (s *Service) PurgeDeleted(ctx context.Context, limit int) (purged, failed int, err error) {
+	keys, err := s.repo.PurgeQueue(ctx, limit)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			log.Printf("[attachments] purge %s: %v", key, err)
+			failed++
+			if err := s.repo.PurgeFailed(ctx, key); err != nil {
+				return purged, failed, err
+			}
+			continue
+		}
+		if err := s.repo.Purged(ctx, key); err != nil {
+			return purged, failed, err
+		}
+		purged++
+	}
+	return purged, failed, nil
+}
+
+// withURL подписывает ссылку на скачивание. Картинки и PDF открываются в браузере, остальное скачивается.
+func (s *Service) withURL(ctx context.Context, a *model.Attachment) (*model.Attachment, error) {
+	url, err := s.store.SignedURL(ctx, a.StorageKey, storage.URLOptions{
+		TTL:         s.limits.URLTTL,
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Inline:      strings.HasPrefix(a.ContentType, "image/") || a.ContentType == "application/pdf",
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.URL = url
+	a.URLExpiresAt = time.Now().Add(s.limits.URLTTL).UTC().Format(time.RFC3339)
+	return a, nil
+}
+
+// cleanFilename оставляет только имя файла без каталогов и управляющих символов, не длиннее 255 байт
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	return name
+}
+
+func parseIDs(workspaceID, id string) (uuid.UUID, uuid.UUID, error) {
+	wsID, err := uuid.Parse(workspaceID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return wsID, uid, nil
+}
+
+// MaxSize — предел размера одного файла
+func (s *Service) MaxSize() int64 {
+	return s.limits.MaxSize
+}
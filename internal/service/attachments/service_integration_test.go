@@ -0,0 +1,128 @@
+package attachments_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	attachmentsRepo "backend/internal/repository/attachments"
+	"backend/internal/service/attachments"
+	"backend/internal/testutil/pgtest"
+	"backend/pkg/storage"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+// png — минимальная сигнатура PNG: по ней http.DetectContentType определяет image/png
+var png = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 100)...)
+
+func TestAttachmentsUploadQuotaAndPurge(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc := env.Container.AttachmentsService
+	owner := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+	other := env.CreateWorkspace(t, owner)
+
+	var noteID string
+	if err := env.DB.QueryRowContext(ctx, `INSERT INTO notes (workspace_id, user_id, title) VALUES ($1, $2, 'n') RETURNING id`,
+		ws.ID, owner.ID).Scan(&noteID); err != nil {
+		t.Fatal(err)
+	}
+	habit := env.CreateHabit(t, owner, ws, model.CreateHabitDto{})
+	completion, err := env.Container.HabitsService.Complete(ctx, habit.ID, owner.ID, ws.ID, time.Now(), "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upload := func(ownerType, ownerID, name string, data []byte) (*model.Attachment, error) {
+		return svc.Upload(ctx, ws.ID, owner.ID, ownerType, ownerID, name, int64(len(data)), bytes.NewReader(data))
+	}
+
+	a, err := upload(model.AttachmentOwnerNote, noteID, `C:\photos\кот.png`, png)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ContentType != "image/png" || a.Filename != "кот.png" || a.Size != int64(len(png)) || len(a.SHA256) != 64 || a.URL == "" {
+		t.Fatalf("uploaded = %+v", a)
+	}
+	// Тип берётся из содержимого, а не из имени
+	txt, err := upload(model.AttachmentOwnerHabitCompletion, completion.ID, "photo.png", []byte("plain text"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txt.ContentType != "text/plain" {
+		t.Fatalf("content type = %s", txt.ContentType)
+	}
+
+	if _, err := upload(model.AttachmentOwnerNote, noteID, "x.png", []byte("<html><script>alert(1)</script></html>")); !errors.Is(err, attachments.ErrTypeNotAllowed) {
+		t.Fatalf("html: err = %v", err)
+	}
+	if _, err := upload(model.AttachmentOwnerNote, noteID, "big.txt", bytes.Repeat([]byte("a"), 1<<20+1)); !errors.Is(err, attachments.ErrTooLarge) {
+		t.Fatalf("too large: err = %v", err)
+	}
+	if _, err := upload(model.AttachmentOwnerNote, noteID, "empty.txt", nil); !errors.Is(err, attachments.ErrEmptyFile) {
+		t.Fatalf("empty: err = %v", err)
+	}
+	if _, err := svc.Upload(ctx, other.ID, owner.ID, model.AttachmentOwnerNote, noteID, "a.png", int64(len(png)), bytes.NewReader(png)); !errors.Is(err, attachmentsRepo.ErrOwnerNotFound) {
+		t.Fatalf("foreign note: err = %v", err)
+	}
+
+	// Квота 4 МиБ: три файла по 1 МиБ влезают, четвёртый — нет
+	mb := append([]byte("text "), bytes.Repeat([]byte("a"), 1<<20-5)...)
+	for i := 0; i < 3; i++ {
+		if _, err := upload(model.AttachmentOwnerNote, noteID, "mb.txt", mb); err != nil {
+			t.Fatalf("upload %d: %v", i, err)
+		}
+	}
+	if _, err := upload(model.AttachmentOwnerNote, noteID, "mb.txt", mb); !errors.Is(err, attachmentsRepo.ErrQuotaExceeded) {
+		t.Fatalf("over quota: err = %v", err)
+	}
+	usage, err := svc.Usage(ctx, ws.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Count != 5 || usage.UsedBytes != int64(len(png))+10+3<<20 {
+		t.Fatalf("usage = %+v", usage)
+	}
+
+	// Подписанная ссылка отдаёт файл
+	files, ok := env.Container.Storage.(http.Handler)
+	if !ok {
+		t.Fatal("local storage is not an http.Handler")
+	}
+	rec := httptest.NewRecorder()
+	http.StripPrefix("/api/v1/files", files).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, a.URL, nil))
+	if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), png) || !strings.HasPrefix(rec.Header().Get("Content-Disposition"), "inline") {
+		t.Fatalf("download: %d %q", rec.Code, rec.Header().Get("Content-Disposition"))
+	}
+
+	list, err := svc.List(ctx, ws.ID, model.AttachmentOwnerNote, noteID)
+	if err != nil || len(list) != 4 {
+		t.Fatalf("note attachments = %d, %v", len(list), err)
+	}
+
+	// Удаление заметки удаляет её вложения, файлы уходят из хранилища при очистке
+	if _, err := env.DB.ExecContext(ctx, `DELETE FROM notes WHERE id = $1`, noteID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Delete(ctx, ws.ID, txt.ID); err != nil {
+		t.Fatal(err)
+	}
+	purged, failed, err := svc.PurgeDeleted(ctx, 100)
+	if err != nil || purged != 5 || failed != 0 {
+		t.Fatalf("purge = %d, %d, %v", purged, failed, err)
+	}
+	if _, err := env.Container.Storage.Open(ctx, a.StorageKey); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("purged file: err = %v", err)
+	}
+	if usage, _ := svc.Usage(ctx, ws.ID); usage.Count != 0 || usage.UsedBytes != 0 {
+		t.Fatalf("usage after delete = %+v", usage)
+	}
+}
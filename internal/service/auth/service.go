@@ -79,6 +79,10 @@ func (s *AuthService) Register(ctx context.Context, req model.RegisterRequest) (
 			return nil, err
 		}
 		anyStatus.Password = ""
+		// Свои воркспейсы удаляются вместе с аккаунтом — без них пользователю негде работать
+		if list, err := s.workspaceService.List(ctx, anyStatus.ID, anyStatus.Role); err == nil && len(list) == 0 {
+			s.createDefaultWorkspace(ctx, anyStatus)
+		}
 		accessToken, err := s.tokenGen.Generate(anyStatus.ID, string(anyStatus.Role))
 		if err != nil {
 			return nil, err
@@ -107,17 +111,7 @@ func (s *AuthService) Register(ctx context.Context, req model.RegisterRequest) (
 	}
 
 	// 5. Создаем базовый workspace для пользователя
-	defaultName := "My Workspace"
-	if user.Name != nil {
-		defaultName = *user.Name + "'s Workspace"
-	}
-	_, err = s.workspaceService.Create(ctx, model.CreateWorkspaceDto{
-		Name:  defaultName,
-		Color: stringPtr("#3B82F6"),
-	}, user.ID)
-	if err != nil {
-		fmt.Printf("Failed to create default workspace for user %s: %v\n", user.ID, err)
-	}
+	s.createDefaultWorkspace(ctx, user)
 
 	accessToken, err := s.tokenGen.Generate(user.ID, string(user.Role))
 	if err != nil {
@@ -185,6 +179,21 @@ func (s *AuthService) Logout(ctx context.Context, userID string) error {
 	return nil
 }
 
+// createDefaultWorkspace создаёт пользователю первый воркспейс; ошибка не мешает регистрации
+func (s *AuthService) createDefaultWorkspace(ctx context.Context, user *model.User) {
+	defaultName := "My Workspace"
+	if user.Name != nil {
+		defaultName = *user.Name + "'s Workspace"
+	}
+	_, err := s.workspaceService.Create(ctx, model.CreateWorkspaceDto{
+		Name:  defaultName,
+		Color: stringPtr("#3B82F6"),
+	}, user.ID)
+	if err != nil {
+		fmt.Printf("Failed to create default workspace for user %s: %v\n", user.ID, err)
+	}
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
@@ -0,0 +1,258 @@
+// Package backup — перенос воркспейса: выгрузка в версионированный JSON-архив и импорт
+// в новый или пустой существующий воркспейс с выдачей новых ID.
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	backupRepo "backend/internal/repository/backup"
+	"backend/internal/repository/license"
+	workspaceRepo "backend/internal/repository/workspace"
+	workspaceService "backend/internal/service/workspace"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotOwner           = errors.New("only the workspace owner can export or import it")
+	ErrUnsupportedFormat  = errors.New("not a workspace archive")
+	ErrUnsupportedVersion = errors.New("unsupported archive version")
+	ErrWorkspaceNotEmpty  = backupRepo.ErrWorkspaceNotEmpty
+)
+
+// ValidationError — архив не прошёл проверку; Problems — что именно не так
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid archive: " + strings.Join(e.Problems, "; ")
+}
+
+type Service struct {
+	repo       *backupRepo.Repository
+	workspaces *workspaceService.Service
+	modules    *workspaceRepo.Repository
+	licenses   *license.Repository
+}
+
+func NewService(repo *backupRepo.Repository, workspaces *workspaceService.Service, modules *workspaceRepo.Repository, licenses *license.Repository) *Service {
+	return &Service{repo: repo, workspaces: workspaces, modules: modules, licenses: licenses}
+}
+
+// Export выгружает воркспейс. Доступно владельцу и админу.
+func (s *Service) Export(ctx context.Context, workspaceID, userID string, role model.UserRole) (*model.WorkspaceArchive, error) {
+	ws, err := s.ownedWorkspace(ctx, workspaceID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	a, err := s.repo.Export(ctx, uuid.MustParse(ws.ID))
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, workspaceService.ErrWorkspaceNotFound
+	}
+	a.Format = model.WorkspaceArchiveFormat
+	a.Version = model.WorkspaceArchiveVersion
+	a.ExportedAt = time.Now().UTC().Truncate(time.Second)
+	return a, nil
+}
+
+// ImportNew создаёт из архива новый воркспейс пользователя
+func (s *Service) ImportNew(ctx context.Context, userID string, role model.UserRole, data []byte) (*model.WorkspaceImportResult, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.importArchive(ctx, backupRepo.ImportParams{
+		WorkspaceID: uuid.New(),
+		Create:      true,
+		OwnerID:     uid,
+		ImporterID:  uid,
+	}, userID, role, data)
+}
+
+// ImportInto загружает архив в существующий пустой воркспейс. Записи принадлежат его владельцу.
+func (s *Service) ImportInto(ctx context.Context, workspaceID, userID string, role model.UserRole, data []byte) (*model.WorkspaceImportResult, error) {
+	ws, err := s.ownedWorkspace(ctx, workspaceID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	ownerID, err := uuid.Parse(ws.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.importArchive(ctx, backupRepo.ImportParams{
+		WorkspaceID: uuid.MustParse(ws.ID),
+		OwnerID:     ownerID,
+		ImporterID:  uid,
+	}, userID, role, data)
+}
+
+func (s *Service) ownedWorkspace(ctx context.Context, workspaceID, userID string, role model.UserRole) (*model.Workspace, error) {
+	ws, err := s.workspaces.Get(ctx, workspaceID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if role != model.UserRoleAdmin && ws.OwnerID != userID {
+		return nil, ErrNotOwner
+	}
+	return ws, nil
+}
+
+func (s *Service) importArchive(ctx context.Context, p backupRepo.ImportParams, userID string, role model.UserRole, data []byte) (*model.WorkspaceImportResult, error) {
+	a, err := decodeArchive(data)
+	if err != nil {
+		return nil, err
+	}
+	if problems := validateArchive(a, p.Create); len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	sum := sha256.Sum256(data)
+	p.Checksum = hex.EncodeToString(sum[:])
+	p.Modules, p.Warnings, err = s.resolveModules(ctx, a.Modules, p.OwnerID, p.WorkspaceID, role)
+	if err != nil {
+		return nil, err
+	}
+	remapIDs(a)
+	p.Archive = a
+
+	wsID, res, err := s.repo.Import(ctx, p)
+	if errors.Is(err, backupRepo.ErrWorkspaceNotFound) {
+		return nil, workspaceService.ErrWorkspaceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.Workspace, err = s.workspaces.Get(ctx, wsID.String(), userID, role)
+	if err != nil {
+		return nil, fmt.Errorf("load imported workspace: %w", err)
+	}
+	return res, nil
+}
+
+// decodeArchive сначала читает только заголовок: архив другой версии может не разобраться в текущие структуры
+func decodeArchive(data []byte) (*model.WorkspaceArchive, error) {
+	var head struct {
+		Format  string `json:"format"`
+		Version int    `json:"version"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, &ValidationError{Problems: []string{"malformed JSON: " + err.Error()}}
+	}
+	if head.Format != model.WorkspaceArchiveFormat {
+		return nil, ErrUnsupportedFormat
+	}
+	if head.Version < 1 || head.Version > model.WorkspaceArchiveVersion {
+		return nil, fmt.Errorf("%w: %d (supported 1-%d)", ErrUnsupportedVersion, head.Version, model.WorkspaceArchiveVersion)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var a model.WorkspaceArchive
+	if err := dec.Decode(&a); err != nil {
+		return nil, &ValidationError{Problems: []string{err.Error()}}
+	}
+	return &a, nil
+}
+
+// resolveModules сопоставляет модули архива с модулями сервера. Неизвестные пропускаются, платные без лицензии
+// владельца импортируются выключенными (настройки сохраняются) — об этом пишется предупреждение.
+func (s *Service) resolveModules(ctx context.Context, mods []model.ArchiveModule, ownerID, wsID uuid.UUID, role model.UserRole) ([]backupRepo.ImportModule, []string, error) {
+	var out []backupRepo.ImportModule
+	var warnings []string
+	for _, m := range mods {
+		mod, err := s.modules.GetModuleByCode(ctx, m.Code)
+		if err != nil {
+			return nil, nil, err
+		}
+		if mod == nil {
+			warnings = append(warnings, fmt.Sprintf("module %q is not available on this server and was skipped", m.Code))
+			continue
+		}
+		modID, err := uuid.Parse(mod.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		status := model.WorkspaceModuleStatusActive
+		if m.Status == model.WorkspaceModuleStatusDisabled {
+			status = model.WorkspaceModuleStatusDisabled
+		} else if !mod.IsCore && role != model.UserRoleAdmin {
+			has, err := s.licenses.HasLicense(ctx, ownerID, modID, &wsID)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !has {
+				status = model.WorkspaceModuleStatusDisabled
+				warnings = append(warnings, fmt.Sprintf("module %q requires a license and was imported disabled", m.Code))
+			}
+		}
+		out = append(out, backupRepo.ImportModule{ModuleID: modID, Status: status, Settings: m.Settings})
+	}
+	return out, warnings, nil
+}
+
+// remapIDs выдаёт записям новые ID и переписывает ссылки между ними.
+// Архив уже проверен: все ссылки указывают на записи архива.
+func remapIDs(a *model.WorkspaceArchive) {
+	fresh := func(n int, id func(i int) *string) map[string]string {
+		m := make(map[string]string, n)
+		for i := 0; i < n; i++ {
+			p := id(i)
+			m[*p] = uuid.NewString()
+			*p = m[*p]
+		}
+		return m
+	}
+	remap := func(m map[string]string, p *string) {
+		if p != nil {
+			*p = m[*p]
+		}
+	}
+
+	fresh(len(a.Currencies), func(i int) *string { return &a.Currencies[i].ID })
+	counterparties := fresh(len(a.Counterparties), func(i int) *string { return &a.Counterparties[i].ID })
+	habits := fresh(len(a.Habits), func(i int) *string { return &a.Habits[i].ID })
+	fresh(len(a.HabitVersions), func(i int) *string { return &a.HabitVersions[i].ID })
+	for i := range a.HabitVersions {
+		remap(habits, &a.HabitVersions[i].HabitID)
+	}
+	fresh(len(a.HabitCompletions), func(i int) *string { return &a.HabitCompletions[i].ID })
+	for i := range a.HabitCompletions {
+		remap(habits, &a.HabitCompletions[i].HabitID)
+	}
+	journal := fresh(len(a.JournalEntries), func(i int) *string { return &a.JournalEntries[i].ID })
+	folders := fresh(len(a.NoteFolders), func(i int) *string { return &a.NoteFolders[i].ID })
+	for i := range a.NoteFolders {
+		remap(folders, a.NoteFolders[i].ParentID)
+	}
+	notes := fresh(len(a.Notes), func(i int) *string { return &a.Notes[i].ID })
+	for i := range a.Notes {
+		remap(folders, a.Notes[i].FolderID)
+	}
+
+	byType := map[string]map[string]string{
+		model.SearchTypeNote:         notes,
+		model.SearchTypeJournal:      journal,
+		model.SearchTypeHabit:        habits,
+		model.SearchTypeCounterparty: counterparties,
+	}
+	for i := range a.Links {
+		l := &a.Links[i]
+		remap(byType[l.SourceType], &l.SourceID)
+		remap(byType[l.TargetType], &l.TargetID)
+	}
+}
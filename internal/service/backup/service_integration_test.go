@@ -0,0 +1,107 @@
+package backup_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"backend/internal/model"
+	"backend/internal/service/backup"
+	"backend/internal/testutil/pgtest"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+func TestWorkspaceExportImport(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc := env.Container.BackupService
+	u := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, u)
+	h := env.CreateHabit(t, u, ws, model.CreateHabitDto{})
+
+	mustExec := func(q string, args ...any) {
+		t.Helper()
+		if _, err := env.DB.ExecContext(ctx, q, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustExec(`INSERT INTO habit_completions (habit_id, workspace_id, user_id, date, rating) VALUES ($1, $2, $3, '2026-10-01', 5)`, h.ID, ws.ID, u.ID)
+	mustExec(`INSERT INTO journal_entries (workspace_id, user_id, description, mood, date) VALUES ($1, $2, 'Хороший день', 4, '2026-10-01')`, ws.ID, u.ID)
+	var folderID, noteID string
+	if err := env.DB.QueryRowContext(ctx, `INSERT INTO note_folders (workspace_id, name) VALUES ($1, 'Работа') RETURNING id`, ws.ID).Scan(&folderID); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.DB.QueryRowContext(ctx, `INSERT INTO notes (workspace_id, user_id, folder_id, title, tags) VALUES ($1, $2, $3, 'План', '{a}') RETURNING id`,
+		ws.ID, u.ID, folderID).Scan(&noteID); err != nil {
+		t.Fatal(err)
+	}
+	mustExec(`INSERT INTO currencies (workspace_id, code, name) VALUES ($1, 'RUB', 'Рубль')`, ws.ID)
+	mustExec(`INSERT INTO entity_links (workspace_id, source_type, source_id, target_type, target_id, origin, created_by)
+		VALUES ($1, 'note', $2, 'habit', $3, 'manual', $4)`, ws.ID, noteID, h.ID, u.ID)
+
+	if _, err := svc.Export(ctx, ws.ID, env.CreateUser(t).ID, model.UserRoleUser); err == nil {
+		t.Fatal("stranger exported the workspace")
+	}
+	a, err := svc.Export(ctx, ws.ID, u.ID, model.UserRoleUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Habits) != 1 || len(a.HabitVersions) != 1 || len(a.HabitCompletions) != 1 || len(a.JournalEntries) != 1 ||
+		len(a.Notes) != 1 || len(a.NoteFolders) != 1 || len(a.Currencies) != 1 || len(a.Links) != 1 || len(a.Modules) == 0 {
+		t.Fatalf("archive = %+v", a)
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := svc.ImportNew(ctx, u.ID, model.UserRoleUser, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.AlreadyImported || res.Workspace.ID == ws.ID || res.Workspace.Name != ws.Name || res.Imported["habitCompletions"] != 1 {
+		t.Fatalf("import = %+v", res)
+	}
+	var newHabitID, linkTarget string
+	if err := env.DB.QueryRowContext(ctx, `SELECT id FROM habits WHERE workspace_id = $1`, res.Workspace.ID).Scan(&newHabitID); err != nil {
+		t.Fatal(err)
+	}
+	if newHabitID == h.ID {
+		t.Fatal("habit id was not remapped")
+	}
+	if err := env.DB.QueryRowContext(ctx, `SELECT target_id FROM entity_links WHERE workspace_id = $1`, res.Workspace.ID).Scan(&linkTarget); err != nil {
+		t.Fatal(err)
+	}
+	if linkTarget != newHabitID {
+		t.Fatalf("link target = %s, want %s", linkTarget, newHabitID)
+	}
+
+	again, err := svc.ImportNew(ctx, u.ID, model.UserRoleUser, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.AlreadyImported || again.Workspace.ID != res.Workspace.ID {
+		t.Fatalf("re-import = %+v", again)
+	}
+
+	if _, err := svc.ImportInto(ctx, ws.ID, u.ID, model.UserRoleUser, data); !errors.Is(err, backup.ErrWorkspaceNotEmpty) {
+		t.Fatalf("import into non-empty workspace: err = %v", err)
+	}
+	empty := env.CreateWorkspace(t, u)
+	into, err := svc.ImportInto(ctx, empty.ID, u.ID, model.UserRoleUser, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if into.Workspace.ID != empty.ID || into.Workspace.Name != empty.Name || into.Imported["notes"] != 1 {
+		t.Fatalf("import into = %+v", into)
+	}
+	exported, err := svc.Export(ctx, empty.ID, u.ID, model.UserRoleUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported.JournalEntries[0].Description != "Хороший день" || *exported.Notes[0].FolderID != exported.NoteFolders[0].ID {
+		t.Fatalf("round trip = %+v", exported)
+	}
+}
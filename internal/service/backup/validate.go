@@ -0,0 +1,345 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"backend/internal/model"
+	notesRepo "backend/internal/repository/notes"
+)
+
+// maxProblems — сколько ошибок архива показывать; дальше проверка не нужна
+const maxProblems = 50
+
+const defaultColor = "#3B82F6"
+
+// validator собирает ошибки архива и заодно подставляет умолчания (пустой тип контента, нулевое время)
+type validator struct {
+	problems []string
+	now      time.Time
+}
+
+func (v *validator) addf(format string, args ...any) {
+	if len(v.problems) < maxProblems {
+		v.problems = append(v.problems, fmt.Sprintf(format, args...))
+	}
+}
+
+func (v *validator) maxLen(field, value string, n int) {
+	if utf8.RuneCountInString(value) > n {
+		v.addf("%s: longer than %d characters", field, n)
+	}
+}
+
+func (v *validator) required(field, value string, n int) {
+	if strings.TrimSpace(value) == "" {
+		v.addf("%s: required", field)
+	}
+	v.maxLen(field, value, n)
+}
+
+func (v *validator) optional(field string, value *string, n int) {
+	if value != nil {
+		v.maxLen(field, *value, n)
+	}
+}
+
+func (v *validator) date(field, value string) {
+	if _, err := time.Parse(time.DateOnly, value); err != nil {
+		v.addf("%s: %q is not a date (YYYY-MM-DD)", field, value)
+	}
+}
+
+func (v *validator) optionalDate(field string, value *string) {
+	if value != nil {
+		v.date(field, *value)
+	}
+}
+
+func (v *validator) clock(field string, value *string) {
+	if value == nil {
+		return
+	}
+	if _, err := time.Parse(time.TimeOnly, *value); err == nil {
+		return
+	}
+	if _, err := time.Parse("15:04", *value); err != nil {
+		v.addf("%s: %q is not a time (HH:MM:SS)", field, *value)
+	}
+}
+
+func (v *validator) oneOf(field, value string, allowed ...string) {
+	if !slices.Contains(allowed, value) {
+		v.addf("%s: %q is not one of %s", field, value, strings.Join(allowed, ", "))
+	}
+}
+
+func (v *validator) rating(field string, value *int) {
+	if value != nil && (*value < 1 || *value > 5) {
+		v.addf("%s: must be from 1 to 5", field)
+	}
+}
+
+// stamp подставляет время экспорта вместо отсутствующего
+func (v *validator) stamp(t *time.Time) {
+	if t.IsZero() {
+		*t = v.now
+	}
+}
+
+// ids проверяет, что ID записей непустые и не повторяются, и возвращает их множество
+func (v *validator) ids(kind string, n int, id func(i int) string) map[string]bool {
+	set := make(map[string]bool, n)
+	for i := 0; i < n; i++ {
+		switch key := id(i); {
+		case key == "":
+			v.addf("%s[%d].id: required", kind, i)
+		case set[key]:
+			v.addf("%s[%d].id: duplicate %q", kind, i, key)
+		default:
+			set[key] = true
+		}
+	}
+	return set
+}
+
+func (v *validator) schedule(field, scheduleType string, days []int, oneTime *string) {
+	switch scheduleType {
+	case "recurring":
+		if len(days) == 0 {
+			v.addf("%s.recurringDays: required for a recurring habit", field)
+		}
+		for _, d := range days {
+			if d < 0 || d > 6 {
+				v.addf("%s.recurringDays: %d is not a weekday (0-6)", field, d)
+			}
+		}
+		if oneTime != nil {
+			v.addf("%s.oneTimeDate: must be empty for a recurring habit", field)
+		}
+	case "one_time":
+		if oneTime == nil {
+			v.addf("%s.oneTimeDate: required for a one-time habit", field)
+		}
+	default:
+		v.addf("%s.scheduleType: %q is not one of recurring, one_time", field, scheduleType)
+	}
+	v.optionalDate(field+".oneTimeDate", oneTime)
+}
+
+// validateArchive проверяет архив целиком: поля, ссылки между записями, циклы папок.
+// create — архив импортируется в новый воркспейс, нужно его название.
+func validateArchive(a *model.WorkspaceArchive, create bool) []string {
+	v := &validator{now: a.ExportedAt}
+	if v.now.IsZero() {
+		v.now = time.Now()
+	}
+
+	if create {
+		v.required("workspace.name", a.Workspace.Name, 255)
+	}
+	if a.Workspace.Color == "" {
+		a.Workspace.Color = defaultColor
+	}
+	v.maxLen("workspace.color", a.Workspace.Color, 50)
+
+	codes := make(map[string]bool)
+	for i := range a.Modules {
+		m := &a.Modules[i]
+		f := fmt.Sprintf("modules[%d]", i)
+		v.required(f+".code", m.Code, 50)
+		if codes[m.Code] {
+			v.addf("%s.code: duplicate %q", f, m.Code)
+		}
+		codes[m.Code] = true
+		v.oneOf(f+".status", m.Status, model.WorkspaceModuleStatusActive, model.WorkspaceModuleStatusTrial, model.WorkspaceModuleStatusDisabled)
+		if s := bytes.TrimSpace(m.Settings); len(s) > 0 && s[0] != '{' && string(s) != "null" {
+			v.addf("%s.settings: must be an object", f)
+		}
+	}
+
+	v.ids("currencies", len(a.Currencies), func(i int) string { return a.Currencies[i].ID })
+	currencyCodes := make(map[string]bool)
+	for i := range a.Currencies {
+		c := &a.Currencies[i]
+		f := fmt.Sprintf("currencies[%d]", i)
+		v.required(f+".code", c.Code, 10)
+		if currencyCodes[c.Code] {
+			v.addf("%s.code: duplicate %q", f, c.Code)
+		}
+		currencyCodes[c.Code] = true
+		v.required(f+".name", c.Name, 100)
+		v.optional(f+".symbol", c.Symbol, 10)
+		v.stamp(&c.CreatedAt)
+		v.stamp(&c.UpdatedAt)
+	}
+
+	counterparties := v.ids("counterparties", len(a.Counterparties), func(i int) string { return a.Counterparties[i].ID })
+	for i := range a.Counterparties {
+		c := &a.Counterparties[i]
+		f := fmt.Sprintf("counterparties[%d]", i)
+		v.required(f+".name", c.Name, 255)
+		if c.Type == "" {
+			c.Type = "client"
+		}
+		v.oneOf(f+".type", c.Type, "client", "supplier", "both")
+		v.optional(f+".email", c.Email, 255)
+		v.optional(f+".phone", c.Phone, 50)
+		v.stamp(&c.CreatedAt)
+		v.stamp(&c.UpdatedAt)
+	}
+
+	habits := v.ids("habits", len(a.Habits), func(i int) string { return a.Habits[i].ID })
+	for i := range a.Habits {
+		h := &a.Habits[i]
+		f := fmt.Sprintf("habits[%d]", i)
+		v.required(f+".title", h.Title, 255)
+		if h.Color == "" {
+			h.Color = defaultColor
+		}
+		v.maxLen(f+".color", h.Color, 50)
+		v.optional(f+".icon", h.Icon, 100)
+		v.optional(f+".category", h.Category, 100)
+		v.clock(f+".preferredTime", h.PreferredTime)
+		v.schedule(f, h.ScheduleType, h.RecurringDays, h.OneTimeDate)
+		v.stamp(&h.CreatedAt)
+		v.stamp(&h.UpdatedAt)
+	}
+
+	v.ids("habitVersions", len(a.HabitVersions), func(i int) string { return a.HabitVersions[i].ID })
+	for i := range a.HabitVersions {
+		hv := &a.HabitVersions[i]
+		f := fmt.Sprintf("habitVersions[%d]", i)
+		if !habits[hv.HabitID] {
+			v.addf("%s.habitId: unknown habit %q", f, hv.HabitID)
+		}
+		v.required(f+".title", hv.Title, 255)
+		if hv.Color == "" {
+			hv.Color = defaultColor
+		}
+		v.maxLen(f+".color", hv.Color, 50)
+		v.optional(f+".icon", hv.Icon, 100)
+		v.optional(f+".category", hv.Category, 100)
+		v.clock(f+".preferredTime", hv.PreferredTime)
+		v.schedule(f, hv.ScheduleType, hv.RecurringDays, hv.OneTimeDate)
+		v.date(f+".validFrom", hv.ValidFrom)
+		v.optionalDate(f+".validTo", hv.ValidTo)
+		if hv.ValidTo != nil && *hv.ValidTo < hv.ValidFrom {
+			v.addf("%s.validTo: before validFrom", f)
+		}
+		v.stamp(&hv.CreatedAt)
+	}
+
+	v.ids("habitCompletions", len(a.HabitCompletions), func(i int) string { return a.HabitCompletions[i].ID })
+	completed := make(map[string]bool)
+	for i := range a.HabitCompletions {
+		hc := &a.HabitCompletions[i]
+		f := fmt.Sprintf("habitCompletions[%d]", i)
+		if !habits[hc.HabitID] {
+			v.addf("%s.habitId: unknown habit %q", f, hc.HabitID)
+		}
+		v.date(f+".date", hc.Date)
+		if key := hc.HabitID + "/" + hc.Date; completed[key] {
+			v.addf("%s: habit %q is already completed on %s", f, hc.HabitID, hc.Date)
+		} else {
+			completed[key] = true
+		}
+		v.rating(f+".rating", hc.Rating)
+		v.clock(f+".time", hc.Time)
+		v.stamp(&hc.CreatedAt)
+	}
+
+	journal := v.ids("journalEntries", len(a.JournalEntries), func(i int) string { return a.JournalEntries[i].ID })
+	for i := range a.JournalEntries {
+		e := &a.JournalEntries[i]
+		f := fmt.Sprintf("journalEntries[%d]", i)
+		v.date(f+".date", e.Date)
+		v.rating(f+".mood", e.Mood)
+		if e.ContentType == "" {
+			e.ContentType = "text"
+		}
+		v.oneOf(f+".contentType", e.ContentType, "text", "markdown")
+		if s := bytes.TrimSpace(e.Metadata); len(s) > 0 && s[0] != '{' && string(s) != "null" {
+			v.addf("%s.metadata: must be an object", f)
+		}
+		v.stamp(&e.CreatedAt)
+		v.stamp(&e.UpdatedAt)
+	}
+
+	folders := v.ids("noteFolders", len(a.NoteFolders), func(i int) string { return a.NoteFolders[i].ID })
+	parents := make(map[string]string)
+	for i := range a.NoteFolders {
+		nf := &a.NoteFolders[i]
+		f := fmt.Sprintf("noteFolders[%d]", i)
+		v.required(f+".name", nf.Name, 255)
+		if nf.ParentID != nil {
+			if !folders[*nf.ParentID] {
+				v.addf("%s.parentId: unknown folder %q", f, *nf.ParentID)
+			}
+			parents[nf.ID] = *nf.ParentID
+		}
+		v.stamp(&nf.CreatedAt)
+		v.stamp(&nf.UpdatedAt)
+	}
+	for i, nf := range a.NoteFolders {
+		depth := 1
+		for id := nf.ID; parents[id] != "" && depth <= notesRepo.MaxFolderDepth; id = parents[id] {
+			depth++
+		}
+		if depth > notesRepo.MaxFolderDepth {
+			v.addf("noteFolders[%d]: nested deeper than %d levels or in a cycle", i, notesRepo.MaxFolderDepth)
+		}
+	}
+
+	notes := v.ids("notes", len(a.Notes), func(i int) string { return a.Notes[i].ID })
+	for i := range a.Notes {
+		n := &a.Notes[i]
+		f := fmt.Sprintf("notes[%d]", i)
+		v.maxLen(f+".title", n.Title, 500)
+		if n.FolderID != nil && !folders[*n.FolderID] {
+			v.addf("%s.folderId: unknown folder %q", f, *n.FolderID)
+		}
+		if n.ContentType == "" {
+			n.ContentType = "text"
+		}
+		v.oneOf(f+".contentType", n.ContentType, "text", "markdown")
+		v.stamp(&n.CreatedAt)
+		v.stamp(&n.UpdatedAt)
+	}
+
+	entities := map[string]map[string]bool{
+		model.SearchTypeNote:         notes,
+		model.SearchTypeJournal:      journal,
+		model.SearchTypeHabit:        habits,
+		model.SearchTypeCounterparty: counterparties,
+	}
+	links := make(map[model.ArchiveLink]bool)
+	for i := range a.Links {
+		l := &a.Links[i]
+		f := fmt.Sprintf("links[%d]", i)
+		v.stamp(&l.CreatedAt)
+		v.oneOf(f+".origin", l.Origin, model.LinkOriginWiki, model.LinkOriginManual)
+		for _, end := range []struct{ name, typ, id string }{{"source", l.SourceType, l.SourceID}, {"target", l.TargetType, l.TargetID}} {
+			set, ok := entities[end.typ]
+			if !ok {
+				v.addf("%s.%sType: %q is not one of %s", f, end.name, end.typ, strings.Join(model.SearchTypes, ", "))
+			} else if !set[end.id] {
+				v.addf("%s.%sId: unknown %s %q", f, end.name, end.typ, end.id)
+			}
+		}
+		if l.SourceType == l.TargetType && l.SourceID == l.TargetID {
+			v.addf("%s: links an entity to itself", f)
+		}
+		key := *l
+		key.CreatedAt = time.Time{}
+		if links[key] {
+			v.addf("%s: duplicate link", f)
+		}
+		links[key] = true
+	}
+	return v.problems
+}
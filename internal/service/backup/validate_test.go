@@ -0,0 +1,148 @@
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+)
+
+func sampleArchive() *model.WorkspaceArchive {
+	parent, child := "f1", "f2"
+	oneTime := "2026-10-01"
+	return &model.WorkspaceArchive{
+		Format:         model.WorkspaceArchiveFormat,
+		Version:        model.WorkspaceArchiveVersion,
+		ExportedAt:     time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		Workspace:      model.ArchiveWorkspace{Name: "Дом"},
+		Modules:        []model.ArchiveModule{{Code: "habits", Status: "active", Settings: json.RawMessage(`{"a":1}`)}},
+		Currencies:     []model.ArchiveCurrency{{ID: "c1", Code: "RUB", Name: "Рубль"}},
+		Counterparties: []model.ArchiveCounterparty{{ID: "k1", Name: "Магазин"}},
+		Habits: []model.ArchiveHabit{
+			{ID: "h1", Title: "Бег", ScheduleType: "recurring", RecurringDays: []int{1, 3}},
+			{ID: "h2", Title: "Врач", ScheduleType: "one_time", OneTimeDate: &oneTime},
+		},
+		HabitVersions:    []model.ArchiveHabitVersion{{ID: "v1", HabitID: "h1", Title: "Бег", ScheduleType: "recurring", RecurringDays: []int{1}, ValidFrom: "2026-09-01"}},
+		HabitCompletions: []model.ArchiveHabitCompletion{{ID: "d1", HabitID: "h1", Date: "2026-09-02"}},
+		JournalEntries:   []model.ArchiveJournalEntry{{ID: "j1", Description: "День", Date: "2026-09-02"}},
+		NoteFolders: []model.ArchiveNoteFolder{
+			{ID: parent, Name: "Работа"},
+			{ID: child, ParentID: &parent, Name: "Проекты"},
+		},
+		Notes: []model.ArchiveNote{{ID: "n1", FolderID: &child, Title: "План"}},
+		Links: []model.ArchiveLink{
+			{SourceType: model.SearchTypeNote, SourceID: "n1", TargetType: model.SearchTypeHabit, TargetID: "h1", Origin: model.LinkOriginWiki},
+			{SourceType: model.SearchTypeJournal, SourceID: "j1", TargetType: model.SearchTypeCounterparty, TargetID: "k1", Origin: model.LinkOriginManual},
+		},
+	}
+}
+
+func TestValidateArchiveAcceptsValidAndFillsDefaults(t *testing.T) {
+	a := sampleArchive()
+	if problems := validateArchive(a, true); len(problems) > 0 {
+		t.Fatalf("problems: %v", problems)
+	}
+	if a.Workspace.Color != defaultColor || a.Habits[0].Color != defaultColor {
+		t.Errorf("color defaults not applied: %q, %q", a.Workspace.Color, a.Habits[0].Color)
+	}
+	if a.Notes[0].ContentType != "text" || a.Counterparties[0].Type != "client" {
+		t.Errorf("defaults not applied: %q, %q", a.Notes[0].ContentType, a.Counterparties[0].Type)
+	}
+	if !a.Notes[0].CreatedAt.Equal(a.ExportedAt) {
+		t.Errorf("zero time = %v, want exportedAt", a.Notes[0].CreatedAt)
+	}
+}
+
+func TestValidateArchiveReportsProblems(t *testing.T) {
+	cases := map[string]struct {
+		edit func(a *model.WorkspaceArchive)
+		want string
+	}{
+		"name":           {func(a *model.WorkspaceArchive) { a.Workspace.Name = " " }, "workspace.name: required"},
+		"duplicate id":   {func(a *model.WorkspaceArchive) { a.Habits[1].ID = "h1" }, `habits[1].id: duplicate "h1"`},
+		"unknown habit":  {func(a *model.WorkspaceArchive) { a.HabitCompletions[0].HabitID = "x" }, `unknown habit "x"`},
+		"weekday":        {func(a *model.WorkspaceArchive) { a.Habits[0].RecurringDays = []int{7} }, "7 is not a weekday"},
+		"one time date":  {func(a *model.WorkspaceArchive) { a.Habits[1].OneTimeDate = nil }, "oneTimeDate: required"},
+		"date":           {func(a *model.WorkspaceArchive) { a.JournalEntries[0].Date = "02.09.2026" }, "is not a date"},
+		"mood":           {func(a *model.WorkspaceArchive) { m := 9; a.JournalEntries[0].Mood = &m }, "mood: must be from 1 to 5"},
+		"metadata":       {func(a *model.WorkspaceArchive) { a.JournalEntries[0].Metadata = json.RawMessage(`[1]`) }, "metadata: must be an object"},
+		"folder cycle":   {func(a *model.WorkspaceArchive) { p := "f2"; a.NoteFolders[0].ParentID = &p }, "in a cycle"},
+		"unknown folder": {func(a *model.WorkspaceArchive) { f := "zz"; a.Notes[0].FolderID = &f }, `unknown folder "zz"`},
+		"link target":    {func(a *model.WorkspaceArchive) { a.Links[0].TargetID = "h9" }, `unknown habit "h9"`},
+		"link type":      {func(a *model.WorkspaceArchive) { a.Links[0].SourceType = "file" }, `"file" is not one of`},
+		"self link":      {func(a *model.WorkspaceArchive) { a.Links[0].TargetType, a.Links[0].TargetID = "note", "n1" }, "links an entity to itself"},
+		"duplicate link": {func(a *model.WorkspaceArchive) { a.Links = append(a.Links, a.Links[0]) }, "duplicate link"},
+		"module status":  {func(a *model.WorkspaceArchive) { a.Modules[0].Status = "paid" }, `"paid" is not one of`},
+		"currency code": {func(a *model.WorkspaceArchive) {
+			a.Currencies = append(a.Currencies, model.ArchiveCurrency{ID: "c2", Code: "RUB", Name: "x"})
+		}, `code: duplicate "RUB"`},
+		"completion date": {func(a *model.WorkspaceArchive) {
+			a.HabitCompletions = append(a.HabitCompletions, model.ArchiveHabitCompletion{ID: "d2", HabitID: "h1", Date: "2026-09-02"})
+		}, "already completed"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := sampleArchive()
+			tc.edit(a)
+			problems := validateArchive(a, true)
+			if !strings.Contains(strings.Join(problems, "\n"), tc.want) {
+				t.Fatalf("problems %q do not mention %q", problems, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateArchiveCapsProblems(t *testing.T) {
+	a := sampleArchive()
+	for i := 0; i < 2*maxProblems; i++ {
+		a.Habits = append(a.Habits, model.ArchiveHabit{ID: "h1", ScheduleType: "recurring", RecurringDays: []int{1}, Title: "x"})
+	}
+	if n := len(validateArchive(a, true)); n != maxProblems {
+		t.Fatalf("got %d problems, want %d", n, maxProblems)
+	}
+}
+
+func TestDecodeArchiveChecksHeader(t *testing.T) {
+	if _, err := decodeArchive([]byte(`{"format":"other","version":1}`)); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("format: err = %v", err)
+	}
+	if _, err := decodeArchive([]byte(`{"format":"habits-workspace","version":99}`)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("version: err = %v", err)
+	}
+	var invalid *ValidationError
+	if _, err := decodeArchive([]byte(`{"format":"habits-workspace","version":1,"habits":{}}`)); !errors.As(err, &invalid) {
+		t.Errorf("shape: err = %v", err)
+	}
+	if _, err := decodeArchive([]byte(`{"format":"habits-workspace","version":1,"extra":1}`)); !errors.As(err, &invalid) {
+		t.Errorf("unknown field: err = %v", err)
+	}
+}
+
+func TestRemapIDsKeepsReferences(t *testing.T) {
+	a := sampleArchive()
+	remapIDs(a)
+	seen := map[string]bool{}
+	for _, id := range []string{a.Currencies[0].ID, a.Counterparties[0].ID, a.Habits[0].ID, a.Habits[1].ID, a.HabitVersions[0].ID,
+		a.HabitCompletions[0].ID, a.JournalEntries[0].ID, a.NoteFolders[0].ID, a.NoteFolders[1].ID, a.Notes[0].ID} {
+		if len(id) != 36 || seen[id] {
+			t.Fatalf("id %q is not a fresh uuid", id)
+		}
+		seen[id] = true
+	}
+	h1 := a.Habits[0].ID
+	if a.HabitVersions[0].HabitID != h1 || a.HabitCompletions[0].HabitID != h1 {
+		t.Error("habit references not remapped")
+	}
+	if *a.NoteFolders[1].ParentID != a.NoteFolders[0].ID || *a.Notes[0].FolderID != a.NoteFolders[1].ID {
+		t.Error("folder references not remapped")
+	}
+	if l := a.Links[0]; l.SourceID != a.Notes[0].ID || l.TargetID != h1 {
+		t.Errorf("link = %+v", l)
+	}
+	if l := a.Links[1]; l.SourceID != a.JournalEntries[0].ID || l.TargetID != a.Counterparties[0].ID {
+		t.Errorf("link = %+v", l)
+	}
+}
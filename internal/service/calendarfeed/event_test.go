@@ -0,0 +1,54 @@
+package calendarfeed
+
+import (
+	"testing"
+	"time"
+
+	calendarFeedRepo "backend/internal/repository/calendarfeed"
+)
+
+func TestHabitEvent(t *testing.T) {
+	// 2026-10-14 — среда
+	created := time.Date(2026, 10, 14, 21, 30, 0, 0, time.UTC)
+	oneTime := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		habit  calendarFeedRepo.Habit
+		start  string
+		allDay bool
+		rrule  string
+	}{
+		{"daily all-day", calendarFeedRepo.Habit{ScheduleType: "recurring", RecurringDays: []int{0, 1, 2, 3, 4, 5, 6}},
+			"2026-10-14 00:00", true, "FREQ=DAILY"},
+		{"empty days", calendarFeedRepo.Habit{ScheduleType: "recurring", Time: "08:00:00"},
+			"2026-10-14 08:00", false, "FREQ=DAILY"},
+		{"weekdays from next match", calendarFeedRepo.Habit{ScheduleType: "recurring", RecurringDays: []int{5, 1, 1, 9}, Time: "20:15:00"},
+			"2026-10-16 20:15", false, "FREQ=WEEKLY;BYDAY=MO,FR"},
+		{"sunday", calendarFeedRepo.Habit{ScheduleType: "recurring", RecurringDays: []int{0}},
+			"2026-10-18 00:00", true, "FREQ=WEEKLY;BYDAY=SU"},
+		{"one time", calendarFeedRepo.Habit{ScheduleType: "one_time", OneTimeDate: &oneTime, Time: "14:00:00"},
+			"2026-11-02 14:00", false, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.habit.ID, tc.habit.Title, tc.habit.CreatedAt = "h1", "Бег", created
+			e, ok := habitEvent(tc.habit)
+			if !ok {
+				t.Fatal("no event")
+			}
+			if got := e.Start.Format("2006-01-02 15:04"); got != tc.start || e.AllDay != tc.allDay || e.RRule != tc.rrule {
+				t.Errorf("start = %s, allDay = %v, rrule = %q", got, e.AllDay, e.RRule)
+			}
+			if !e.AllDay && e.Duration != EventDuration {
+				t.Errorf("duration = %v", e.Duration)
+			}
+			if e.UID != "h1@habits-api" || e.Summary != "Бег" {
+				t.Errorf("event = %+v", e)
+			}
+		})
+	}
+
+	if _, ok := habitEvent(calendarFeedRepo.Habit{ScheduleType: "one_time"}); ok {
+		t.Error("one-time habit without date must be skipped")
+	}
+}
@@ -0,0 +1,195 @@
+// Package calendarfeed — расписание привычек в виде iCalendar-ленты по секретной ссылке: её добавляют в Google
+// или Apple Calendar подпиской. Лента только для чтения; ссылку можно отозвать или заменить новой.
+package calendarfeed
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	calendarFeedRepo "backend/internal/repository/calendarfeed"
+	"backend/pkg/ical"
+
+	"github.com/google/uuid"
+)
+
+// ErrFeedNotFound — нет ленты с таким токеном: не создавалась, отозвана, заменена новой или аккаунт удалён
+var ErrFeedNotFound = errors.New("calendar feed not found")
+
+const (
+	tokenBytes = 32
+	// EventDuration — длительность события у привычки с предпочтительным временем
+	EventDuration = 30 * time.Minute
+	prodID        = "-//habits-api//Habits//RU"
+	calendarName  = "Привычки"
+)
+
+// byDay — дни недели RRULE по номеру дня привычки (0 — воскресенье)
+var byDay = [7]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+type Service struct {
+	repo *calendarFeedRepo.Repository
+	// pathPrefix — путь публичных лент; к нему добавляется /<token>.ics
+	pathPrefix string
+}
+
+func NewService(repo *calendarFeedRepo.Repository, pathPrefix string) *Service {
+	return &Service{repo: repo, pathPrefix: pathPrefix}
+}
+
+// Get — состояние ленты пользователя без токена
+func (s *Service) Get(ctx context.Context, userID string) (*model.CalendarFeed, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := s.repo.Get(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return &model.CalendarFeed{}, nil
+	}
+	return f, nil
+}
+
+// Regenerate создаёт ленту или выдаёт ей новый токен; прежняя ссылка перестаёт работать.
+// Токен возвращается только здесь: в базе хранится его SHA-256.
+func (s *Service) Regenerate(ctx context.Context, userID string) (*model.CalendarFeed, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	raw := make([]byte, tokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("generate feed token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	f, err := s.repo.Save(ctx, uid, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	f.Token = token
+	f.Path = s.pathPrefix + "/" + token + ".ics"
+	return f, nil
+}
+
+// Revoke отключает ленту; sql.ErrNoRows — она не была включена
+func (s *Service) Revoke(ctx context.Context, userID string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, uid)
+}
+
+// Render собирает ленту по токену: активные привычки пользователя во всех его воркспейсах
+func (s *Service) Render(ctx context.Context, token string) ([]byte, error) {
+	owner, err := s.repo.Open(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if owner == nil {
+		return nil, ErrFeedNotFound
+	}
+	habits, err := s.repo.ListHabits(ctx, owner.UserID)
+	if err != nil {
+		return nil, err
+	}
+	cal := ical.Calendar{ProdID: prodID, Name: calendarName, Events: make([]ical.Event, 0, len(habits))}
+	if _, err := time.LoadLocation(owner.Timezone); err == nil && owner.Timezone != "" {
+		cal.Timezone = owner.Timezone
+	}
+	for _, h := range habits {
+		if e, ok := habitEvent(h); ok {
+			cal.Events = append(cal.Events, e)
+		}
+	}
+	return cal.Bytes(), nil
+}
+
+// habitEvent — событие привычки. Повторяющаяся привычка — серия с RRULE от первого подходящего дня не раньше
+// создания привычки, разовая — одно событие. Время — preferredTime, без него событие на весь день.
+func habitEvent(h calendarFeedRepo.Habit) (ical.Event, bool) {
+	e := ical.Event{
+		UID:          h.ID + "@habits-api",
+		Stamp:        h.UpdatedAt,
+		LastModified: h.UpdatedAt,
+		Summary:      h.Title,
+		Description:  h.Description,
+		AllDay:       h.Time == "",
+	}
+	if h.Category != "" {
+		e.Categories = []string{h.Category}
+	}
+
+	var day time.Time
+	switch h.ScheduleType {
+	case "one_time":
+		if h.OneTimeDate == nil {
+			return e, false
+		}
+		day = dateOf(*h.OneTimeDate)
+	case "recurring":
+		days := weekdays(h.RecurringDays)
+		day = dateOf(h.CreatedAt)
+		for !slices.Contains(days, int(day.Weekday())) {
+			day = day.AddDate(0, 0, 1)
+		}
+		if len(days) == 7 {
+			e.RRule = "FREQ=DAILY"
+		} else {
+			codes := make([]string, len(days))
+			for i, d := range days {
+				codes[i] = byDay[d]
+			}
+			e.RRule = "FREQ=WEEKLY;BYDAY=" + strings.Join(codes, ",")
+		}
+	default:
+		return e, false
+	}
+
+	e.Start = day
+	if !e.AllDay {
+		t, err := time.Parse(time.TimeOnly, h.Time)
+		if err != nil {
+			return e, false
+		}
+		e.Start = day.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second)
+		e.Duration = EventDuration
+	}
+	return e, true
+}
+
+// weekdays — дни недели 0–6 без повторов по возрастанию; пустой список — все дни (как при создании привычки)
+func weekdays(days []int) []int {
+	var out []int
+	for _, d := range days {
+		if d >= 0 && d <= 6 && !slices.Contains(out, d) {
+			out = append(out, d)
+		}
+	}
+	if len(out) == 0 {
+		return []int{0, 1, 2, 3, 4, 5, 6}
+	}
+	slices.Sort(out)
+	return out
+}
+
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
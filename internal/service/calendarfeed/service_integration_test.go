@@ -0,0 +1,76 @@
+package calendarfeed_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"backend/internal/model"
+	"backend/internal/service/calendarfeed"
+	"backend/internal/testutil/pgtest"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+func TestCalendarFeed(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc := env.Container.CalendarFeedService
+	u := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, u)
+	inactive := false
+	env.CreateHabit(t, u, ws, model.CreateHabitDto{Title: "Бег, утро", RecurringDays: []int{1, 3}, PreferredTime: "morning"})
+	env.CreateHabit(t, u, ws, model.CreateHabitDto{Title: "Врач", ScheduleType: "one_time", OneTimeDate: "2030-01-15"})
+	env.CreateHabit(t, u, ws, model.CreateHabitDto{Title: "Пауза", IsActive: &inactive})
+	// Привычка другого участника того же воркспейса в ленту не попадает
+	other := env.CreateUser(t)
+	env.AddMember(t, ws, other)
+	env.CreateHabit(t, other, ws, model.CreateHabitDto{Title: "Чужая"})
+
+	if f, err := svc.Get(ctx, u.ID); err != nil || f.Enabled {
+		t.Fatalf("before create: %+v, %v", f, err)
+	}
+	if err := svc.Revoke(ctx, u.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("revoke without feed: %v", err)
+	}
+
+	f, err := svc.Regenerate(ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.Enabled || f.Token == "" || !strings.HasSuffix(f.Path, "/"+f.Token+".ics") {
+		t.Fatalf("feed = %+v", f)
+	}
+	data, err := svc.Render(ctx, f.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ics := string(data)
+	for _, want := range []string{`SUMMARY:Бег\, утро`, "T080000\r\n", "RRULE:FREQ=WEEKLY;BYDAY=MO,WE", "SUMMARY:Врач", "DTSTART;VALUE=DATE:20300115"} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("missing %q in\n%s", want, ics)
+		}
+	}
+	if strings.Contains(ics, "Пауза") || strings.Contains(ics, "Чужая") || strings.Count(ics, "BEGIN:VEVENT") != 2 {
+		t.Errorf("unexpected events:\n%s", ics)
+	}
+	if got, err := svc.Get(ctx, u.ID); err != nil || got.Token != "" || got.LastAccessedAt == nil {
+		t.Errorf("after render: %+v, %v", got, err)
+	}
+
+	renewed, err := svc.Regenerate(ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Render(ctx, f.Token); !errors.Is(err, calendarfeed.ErrFeedNotFound) {
+		t.Errorf("old token: %v", err)
+	}
+	if err := svc.Revoke(ctx, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Render(ctx, renewed.Token); !errors.Is(err, calendarfeed.ErrFeedNotFound) {
+		t.Errorf("revoked token: %v", err)
+	}
+}
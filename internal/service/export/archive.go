@@ -0,0 +1,318 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	exportRepo "backend/internal/repository/export"
+	"backend/pkg/storage"
+
+	"github.com/google/uuid"
+)
+
+// utf8BOM в начале CSV — чтобы Excel открыл кириллицу без выбора кодировки
+const utf8BOM = "\ufeff"
+
+// build пишет в w ZIP со всеми данными пользователя:
+//
+//	json/<набор>.json, csv/<набор>.csv — все наборы exportRepo.Datasets
+//	notes/*.md, journal.md — заметки и дневник в Markdown
+//	attachments/<id>-<имя> — файлы, загруженные пользователем
+//	README.md — состав архива
+func (s *Service) build(ctx context.Context, userID uuid.UUID, w io.Writer) error {
+	snap, err := s.repo.Snapshot(ctx, userID)
+	if err != nil {
+		return err
+	}
+	defer snap.Close()
+
+	zw := zip.NewWriter(w)
+	counts := make(map[string]int, len(exportRepo.Datasets))
+	for _, ds := range exportRepo.Datasets {
+		n, err := writeJSON(ctx, zw, snap, ds)
+		if err != nil {
+			return err
+		}
+		counts[ds.Name] = n
+		if err := writeCSV(ctx, zw, snap, ds); err != nil {
+			return err
+		}
+	}
+	if err := writeNotes(ctx, zw, snap); err != nil {
+		return err
+	}
+	if err := writeJournal(ctx, zw, snap); err != nil {
+		return err
+	}
+	files, err := s.writeAttachments(ctx, zw, snap)
+	if err != nil {
+		return err
+	}
+	if err := writeReadme(zw, counts, files); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeJSON(ctx context.Context, zw *zip.Writer, snap *exportRepo.Snapshot, ds exportRepo.Dataset) (int, error) {
+	f, err := zw.Create("json/" + ds.Name + ".json")
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	if _, err := io.WriteString(f, "["); err != nil {
+		return 0, err
+	}
+	err = snap.Each(ctx, ds, func(row exportRepo.Row) error {
+		sep := ",\n  "
+		if n == 0 {
+			sep = "\n  "
+		}
+		n++
+		obj, err := rowJSON(row)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(f, sep+string(obj))
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	end := "\n]\n"
+	if n == 0 {
+		end = "]\n"
+	}
+	_, err = io.WriteString(f, end)
+	return n, err
+}
+
+// rowJSON — объект строки с колонками в исходном порядке, с отступами под элемент массива
+func rowJSON(row exportRepo.Row) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, col := range row.Columns {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(col)
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(row.Values[i])
+	}
+	b.WriteByte('}')
+	var out bytes.Buffer
+	if err := json.Indent(&out, b.Bytes(), "  ", "  "); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func writeCSV(ctx context.Context, zw *zip.Writer, snap *exportRepo.Snapshot, ds exportRepo.Dataset) error {
+	f, err := zw.Create("csv/" + ds.Name + ".csv")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, utf8BOM); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(f)
+	header := false
+	err = snap.Each(ctx, ds, func(row exportRepo.Row) error {
+		if !header {
+			header = true
+			if err := cw.Write(row.Columns); err != nil {
+				return err
+			}
+		}
+		cells := make([]string, len(row.Values))
+		for i, v := range row.Values {
+			cells[i] = csvCell(v)
+		}
+		return cw.Write(cells)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvCell — значение для CSV: строка без кавычек JSON, NULL — пусто, массивы и объекты — компактный JSON
+func csvCell(v json.RawMessage) string {
+	v = bytes.TrimSpace(v)
+	switch {
+	case len(v) == 0, string(v) == "null":
+		return ""
+	case v[0] == '"':
+		var s string
+		if json.Unmarshal(v, &s) == nil {
+			return s
+		}
+	case v[0] == '[' || v[0] == '{':
+		var b bytes.Buffer
+		if json.Compact(&b, v) == nil {
+			return b.String()
+		}
+	}
+	return string(v)
+}
+
+func writeNotes(ctx context.Context, zw *zip.Writer, snap *exportRepo.Snapshot) error {
+	return snap.Each(ctx, exportRepo.NotesDataset, func(row exportRepo.Row) error {
+		id := row.String("id")
+		f, err := zw.Create("notes/" + slug(row.String("title"), "note") + "-" + shortID(id) + ".md")
+		if err != nil {
+			return err
+		}
+		var b strings.Builder
+		title := row.String("title")
+		if title == "" {
+			title = "Без названия"
+		}
+		fmt.Fprintf(&b, "# %s\n\n", title)
+		fmt.Fprintf(&b, "- Создана: %s\n- Изменена: %s\n", humanTime(row.String("created_at")), humanTime(row.String("updated_at")))
+		if tags := stringList(row.Get("tags")); len(tags) > 0 {
+			fmt.Fprintf(&b, "- Теги: %s\n", strings.Join(tags, ", "))
+		}
+		if row.String("archived_at") != "" {
+			b.WriteString("- В архиве\n")
+		}
+		b.WriteString("\n---\n\n")
+		b.WriteString(row.String("content"))
+		b.WriteString("\n")
+		_, err = io.WriteString(f, b.String())
+		return err
+	})
+}
+
+func writeJournal(ctx context.Context, zw *zip.Writer, snap *exportRepo.Snapshot) error {
+	f, err := zw.Create("journal.md")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, "# Дневник\n"); err != nil {
+		return err
+	}
+	return snap.Each(ctx, exportRepo.JournalDataset, func(row exportRepo.Row) error {
+		var b strings.Builder
+		fmt.Fprintf(&b, "\n## %s\n\n", row.String("date"))
+		if mood := csvCell(row.Get("mood")); mood != "" {
+			fmt.Fprintf(&b, "- Настроение: %s\n", mood)
+		}
+		if tags := stringList(row.Get("tags")); len(tags) > 0 {
+			fmt.Fprintf(&b, "- Теги: %s\n", strings.Join(tags, ", "))
+		}
+		fmt.Fprintf(&b, "\n%s\n", strings.TrimSpace(row.String("description")))
+		_, err := io.WriteString(f, b.String())
+		return err
+	})
+}
+
+// writeAttachments копирует в архив файлы вложений пользователя; пропавшие из хранилища файлы пропускаются
+func (s *Service) writeAttachments(ctx context.Context, zw *zip.Writer, snap *exportRepo.Snapshot) (int, error) {
+	files, err := snap.AttachmentFiles(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, file := range files {
+		r, err := s.store.Open(ctx, file.StorageKey)
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Printf("[export] attachment %s is missing in storage", file.ID)
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: "attachments/" + shortID(file.ID) + "-" + strings.ReplaceAll(file.Filename, "/", "_"), Method: zip.Store})
+		if err == nil {
+			_, err = io.Copy(w, r)
+		}
+		r.Close()
+		if err != nil {
+			return 0, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func writeReadme(zw *zip.Writer, counts map[string]int, files int) error {
+	f, err := zw.Create("README.md")
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	b.WriteString("# Выгрузка данных Habits\n\n")
+	fmt.Fprintf(&b, "Создана %s. Время в данных — UTC.\n\n", time.Now().UTC().Format("2006-01-02 15:04"))
+	b.WriteString("- `json/` — все данные в машиночитаемом виде, по файлу на набор;\n")
+	b.WriteString("- `csv/` — те же наборы таблицами (UTF-8), списки и вложенные объекты записаны как JSON;\n")
+	b.WriteString("- `notes/` — заметки в Markdown, `journal.md` — дневник;\n")
+	b.WriteString("- `attachments/` — загруженные вами файлы.\n\n")
+	b.WriteString("| Набор | Записей |\n|-------|---------|\n")
+	for _, ds := range exportRepo.Datasets {
+		fmt.Fprintf(&b, "| %s | %d |\n", ds.Name, counts[ds.Name])
+	}
+	fmt.Fprintf(&b, "| attachments (файлы) | %d |\n", files)
+	_, err = io.WriteString(f, b.String())
+	return err
+}
+
+// slug — безопасное имя файла из заголовка: буквы и цифры, остальное — дефисы, не длиннее 60 символов
+func slug(title, fallback string) string {
+	var b strings.Builder
+	dash := false
+	n := 0
+	for _, r := range strings.ToLower(title) {
+		if n >= 60 {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+			n++
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+			n++
+		}
+	}
+	s := strings.Trim(b.String(), "-")
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// humanTime — TIMESTAMP из row_to_json в виде «2006-01-02 15:04 UTC»
+func humanTime(v string) string {
+	t, err := time.Parse("2006-01-02T15:04:05.999999999", v)
+	if err != nil {
+		return v
+	}
+	return t.Format("2006-01-02 15:04") + " UTC"
+}
+
+func stringList(v json.RawMessage) []string {
+	var list []string
+	_ = json.Unmarshal(v, &list)
+	return list
+}
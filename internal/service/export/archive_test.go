@@ -0,0 +1,54 @@
+package export
+
+import (
+	"encoding/json"
+	"testing"
+
+	exportRepo "backend/internal/repository/export"
+)
+
+func TestCSVCell(t *testing.T) {
+	cases := map[string]string{
+		`null`:              "",
+		`"Привет, \"мир\""`: `Привет, "мир"`,
+		`42.5`:              "42.5",
+		`true`:              "true",
+		`["a", "b"]`:        `["a","b"]`,
+		`{"k": {"n": 1}}`:   `{"k":{"n":1}}`,
+	}
+	for in, want := range cases {
+		if got := csvCell(json.RawMessage(in)); got != want {
+			t.Errorf("csvCell(%s) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRowJSONKeepsColumnOrder(t *testing.T) {
+	row := exportRepo.Row{
+		Columns: []string{"z", "a"},
+		Values:  []json.RawMessage{json.RawMessage(`1`), json.RawMessage(`["x"]`)},
+	}
+	got, err := rowJSON(row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n    \"z\": 1,\n    \"a\": [\n      \"x\"\n    ]\n  }"
+	if string(got) != want {
+		t.Fatalf("rowJSON = %q, want %q", got, want)
+	}
+}
+
+func TestSlugAndHumanTime(t *testing.T) {
+	if got := slug("  Планы на 2026: отпуск / дача!", "note"); got != "планы-на-2026-отпуск-дача" {
+		t.Errorf("slug = %q", got)
+	}
+	if got := slug("???", "note"); got != "note" {
+		t.Errorf("slug fallback = %q", got)
+	}
+	if got := humanTime("2026-10-18T09:05:33.123456"); got != "2026-10-18 09:05 UTC" {
+		t.Errorf("humanTime = %q", got)
+	}
+	if got := humanTime("not a time"); got != "not a time" {
+		t.Errorf("humanTime fallback = %q", got)
+	}
+}
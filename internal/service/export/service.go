@@ -0,0 +1,156 @@
+// Package export — выгрузка всех данных пользователя (GDPR) в ZIP-архив. Выгрузка собирается
+// в фоне (worker.DataExport), готовый архив доступен по подписанной ссылке до истечения срока.
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"backend/internal/model"
+	exportRepo "backend/internal/repository/export"
+	"backend/pkg/storage"
+
+	"github.com/google/uuid"
+)
+
+var ErrExportInProgress = exportRepo.ErrExportInProgress
+
+// listLimit — сколько последних выгрузок показывать пользователю
+const listLimit = 20
+
+type Options struct {
+	TTL        time.Duration // сколько хранится готовый архив
+	URLTTL     time.Duration // срок подписанной ссылки на скачивание
+	StaleAfter time.Duration // через сколько зависшая сборка берётся заново
+}
+
+type Service struct {
+	repo  *exportRepo.Repository
+	store storage.Storage
+	opts  Options
+}
+
+func NewService(repo *exportRepo.Repository, store storage.Storage, opts Options) *Service {
+	return &Service{repo: repo, store: store, opts: opts}
+}
+
+// Request ставит выгрузку в очередь. ErrExportInProgress — предыдущая ещё не собрана.
+func (s *Service) Request(ctx context.Context, userID uuid.UUID) (*model.DataExport, error) {
+	return s.repo.Create(ctx, userID)
+}
+
+func (s *Service) List(ctx context.Context, userID uuid.UUID) ([]model.DataExport, error) {
+	list, err := s.repo.List(ctx, userID, listLimit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if err := s.withURL(ctx, &list[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// Get — nil, если выгрузки нет или она чужая
+func (s *Service) Get(ctx context.Context, userID, id uuid.UUID) (*model.DataExport, error) {
+	e, err := s.repo.Get(ctx, id, userID)
+	if err != nil || e == nil {
+		return e, err
+	}
+	return e, s.withURL(ctx, e)
+}
+
+// withURL добавляет ссылку на скачивание готового и ещё не истёкшего архива
+func (s *Service) withURL(ctx context.Context, e *model.DataExport) error {
+	if e.Status != model.DataExportReady || e.StorageKey == "" || e.ExpiresAt == nil {
+		return nil
+	}
+	left := time.Until(*e.ExpiresAt)
+	if left <= 0 {
+		e.Status = model.DataExportExpired
+		return nil
+	}
+	url, err := s.store.SignedURL(ctx, e.StorageKey, storage.URLOptions{
+		TTL:         min(s.opts.URLTTL, left),
+		Filename:    "habits-export-" + e.CreatedAt.UTC().Format("2006-01-02") + ".zip",
+		ContentType: "application/zip",
+	})
+	if err != nil {
+		return fmt.Errorf("sign export url: %w", err)
+	}
+	e.DownloadURL = url
+	return nil
+}
+
+// ProcessPending собирает до limit выгрузок из очереди. Ошибка сборки отмечается в выгрузке (failed)
+// и не прерывает обработку остальных; при остановке сервиса незавершённая сборка остаётся running
+// и после StaleAfter берётся заново.
+func (s *Service) ProcessPending(ctx context.Context, limit int) (done, failed int, err error) {
+	for i := 0; i < limit; i++ {
+		job, err := s.repo.Claim(ctx, s.opts.StaleAfter)
+		if err != nil || job == nil {
+			return done, failed, err
+		}
+		if err := s.run(ctx, job); err != nil {
+			if ctx.Err() != nil {
+				return done, failed, ctx.Err()
+			}
+			log.Printf("[export] export %s failed: %v", job.ID, err)
+			if err := s.repo.Fail(ctx, job.ID, "не удалось собрать архив"); err != nil {
+				return done, failed, err
+			}
+			failed++
+			continue
+		}
+		done++
+	}
+	return done, failed, nil
+}
+
+// run собирает архив во временный файл (размер нужен хранилищу заранее) и сохраняет его
+func (s *Service) run(ctx context.Context, job *model.DataExport) error {
+	f, err := os.CreateTemp("", "export-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	userID, err := uuid.Parse(job.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.build(ctx, userID, f); err != nil {
+		return err
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	key := fmt.Sprintf("exports/%s/%s.zip", job.UserID, job.ID)
+	if err := s.store.Put(ctx, key, f, size, "application/zip"); err != nil {
+		return err
+	}
+	if err := s.repo.Finish(ctx, job.ID, key, size, time.Now().Add(s.opts.TTL)); err != nil {
+		// запись не обновилась — файл не должен остаться в хранилище навсегда
+		if delErr := s.store.Delete(context.WithoutCancel(ctx), key); delErr != nil && !errors.Is(delErr, storage.ErrNotFound) {
+			log.Printf("[export] delete orphan archive %s: %v", key, delErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// ExpireOld помечает истёкшие архивы; файлы удаляет worker.AttachmentPurge
+func (s *Service) ExpireOld(ctx context.Context) (int, error) {
+	return s.repo.Expire(ctx)
+}
@@ -0,0 +1,181 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"backend/internal/model"
+	exportRepo "backend/internal/repository/export"
+	"backend/internal/service/export"
+	"backend/internal/testutil/pgtest"
+
+	"github.com/google/uuid"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+// Каждая таблица с колонкой пользователя должна попасть в выгрузку
+func TestDatasetsCoverUserTables(t *testing.T) {
+	env := pgtest.New(t)
+	covered := make(map[string]bool)
+	for _, ds := range exportRepo.Datasets {
+		covered[ds.Table] = true
+	}
+	rows, err := env.DB.Query(`
+		SELECT DISTINCT table_name FROM information_schema.columns
+		WHERE table_schema = 'public'
+		  AND column_name IN ('user_id', 'owner_id', 'author_id', 'created_by', 'uploaded_by')
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			t.Fatal(err)
+		}
+		if !covered[table] {
+			t.Errorf("table %s has user data but is not exported (add it to export.Datasets)", table)
+		}
+	}
+}
+
+func TestDataExportFlow(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc := env.Container.ExportService
+	u := env.CreateUser(t)
+	other := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, u)
+	env.CreateHabit(t, u, ws, model.CreateHabitDto{})
+	var noteID string
+	if err := env.DB.QueryRowContext(ctx, `INSERT INTO notes (workspace_id, user_id, title, content, tags)
+		VALUES ($1, $2, 'Планы: отпуск', 'Купить билеты', '{travel}') RETURNING id`, ws.ID, u.ID).Scan(&noteID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.DB.ExecContext(ctx, `INSERT INTO journal_entries (workspace_id, user_id, description, mood, date)
+		VALUES ($1, $2, 'Хороший день', 4, '2026-10-01')`, ws.ID, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+	if _, err := env.Container.AttachmentsService.Upload(ctx, ws.ID, u.ID, model.AttachmentOwnerNote, noteID,
+		"фото.png", int64(len(png)), bytes.NewReader(png)); err != nil {
+		t.Fatal(err)
+	}
+
+	uid := uuid.MustParse(u.ID)
+	e, err := svc.Request(ctx, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Status != model.DataExportPending {
+		t.Fatalf("requested = %+v", e)
+	}
+	if _, err := svc.Request(ctx, uid); !errors.Is(err, export.ErrExportInProgress) {
+		t.Fatalf("second request: %v", err)
+	}
+	id := uuid.MustParse(e.ID)
+	if got, err := svc.Get(ctx, uuid.MustParse(other.ID), id); err != nil || got != nil {
+		t.Fatalf("foreign export = %+v, %v", got, err)
+	}
+
+	done, failed, err := svc.ProcessPending(ctx, 5)
+	if err != nil || done != 1 || failed != 0 {
+		t.Fatalf("process = %d/%d, %v", done, failed, err)
+	}
+	e, err = svc.Get(ctx, uid, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Status != model.DataExportReady || e.DownloadURL == "" || e.SizeBytes == nil || e.ExpiresAt == nil {
+		t.Fatalf("ready = %+v", e)
+	}
+
+	archive := readArchive(t, env, id)
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(b)
+	}
+	for _, ds := range exportRepo.Datasets {
+		if _, ok := files["json/"+ds.Name+".json"]; !ok {
+			t.Errorf("json/%s.json is missing", ds.Name)
+		}
+	}
+	if !strings.Contains(files["json/profile.json"], u.Email) || strings.Contains(files["json/profile.json"], "password") {
+		t.Errorf("profile.json = %s", files["json/profile.json"])
+	}
+	if !strings.Contains(files["csv/habits.csv"], "id,") {
+		t.Errorf("habits.csv = %s", files["csv/habits.csv"])
+	}
+	if !strings.Contains(files["journal.md"], "## 2026-10-01") || !strings.Contains(files["journal.md"], "Хороший день") {
+		t.Errorf("journal.md = %s", files["journal.md"])
+	}
+	var note, attachment string
+	for name, body := range files {
+		if strings.HasPrefix(name, "notes/планы-отпуск-") {
+			note = body
+		}
+		if strings.HasPrefix(name, "attachments/") && strings.HasSuffix(name, "-фото.png") {
+			attachment = body
+		}
+	}
+	if !strings.Contains(note, "# Планы: отпуск") || !strings.Contains(note, "Теги: travel") || !strings.Contains(note, "Купить билеты") {
+		t.Errorf("note = %q", note)
+	}
+	if attachment != string(png) {
+		t.Errorf("attachment is missing or damaged")
+	}
+
+	// После срока архив истекает, ссылки больше нет, файл уходит в очередь удаления
+	if _, err := env.DB.Exec(`UPDATE data_exports SET expires_at = NOW() AT TIME ZONE 'UTC' - INTERVAL '1 minute' WHERE id = $1`, id); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := svc.ExpireOld(ctx); err != nil || n != 1 {
+		t.Fatalf("expire = %d, %v", n, err)
+	}
+	e, err = svc.Get(ctx, uid, id)
+	if err != nil || e.Status != model.DataExportExpired || e.DownloadURL != "" {
+		t.Fatalf("expired = %+v, %v", e, err)
+	}
+	var queued bool
+	if err := env.DB.QueryRow(`SELECT EXISTS (SELECT 1 FROM attachment_purge_queue WHERE storage_key LIKE 'exports/%')`).Scan(&queued); err != nil || !queued {
+		t.Fatalf("archive not queued for purge: %v", err)
+	}
+	if _, err := svc.Request(ctx, uid); err != nil {
+		t.Fatalf("request after expiry: %v", err)
+	}
+}
+
+func readArchive(t *testing.T, env *pgtest.Env, id uuid.UUID) []byte {
+	t.Helper()
+	var key string
+	if err := env.DB.QueryRow(`SELECT storage_key FROM data_exports WHERE id = $1`, id).Scan(&key); err != nil {
+		t.Fatal(err)
+	}
+	r, err := env.Container.Storage.Open(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
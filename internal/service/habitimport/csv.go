@@ -0,0 +1,221 @@
+package habitimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"backend/internal/model"
+)
+
+// Заголовки, по которым столбцы находятся без явного сопоставления (в нижнем регистре)
+var (
+	habitHeaders  = []string{"habit", "habit name", "name", "title", "task", "task name", "привычка", "название"}
+	dateHeaders   = []string{"date", "day", "дата", "день"}
+	valueHeaders  = []string{"value", "done", "completed", "status", "выполнено", "статус"}
+	notesHeaders  = []string{"notes", "note", "comment", "comments", "заметка", "заметки", "комментарий"}
+	ratingHeaders = []string{"rating", "оценка"}
+)
+
+// parseCSV читает историю из CSV. Раскладка и столбцы берутся из m, недостающее определяется по заголовку.
+func parseCSV(data []byte, m model.HabitImportMapping, h *history) error {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	if !utf8.Valid(data) {
+		return fmt.Errorf("%w: CSV must be UTF-8", ErrInvalidFile)
+	}
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	switch {
+	case m.Delimiter == "\\t" || m.Delimiter == "tab":
+		r.Comma = '\t'
+	case m.Delimiter != "":
+		d, size := utf8.DecodeRuneInString(m.Delimiter)
+		if size != len(m.Delimiter) || d == '"' || d == '\r' || d == '\n' {
+			return fmt.Errorf("%w: delimiter must be a single character", ErrInvalidFile)
+		}
+		r.Comma = d
+	default:
+		r.Comma = sniffDelimiter(data)
+	}
+	// При табуляции TrimLeadingSpace склеил бы пустые ячейки со следующими
+	r.TrimLeadingSpace = r.Comma != '\t'
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: file is empty", ErrInvalidFile)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	cols, err := resolveColumns(header, m)
+	if err != nil {
+		return err
+	}
+
+	for line := 2; ; line++ {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: line %d: %v", ErrInvalidFile, line, err)
+		}
+		if len(rec) == 1 && strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+		h.rows++
+		date, ok := parseDate(cell(rec, cols.date), m.DateFormat)
+		if !ok {
+			h.skip("line %d: %q is not a date", line, cell(rec, cols.date))
+			continue
+		}
+		if cols.layout == model.HabitImportLayoutWide {
+			marked := false
+			for _, hc := range cols.habits {
+				if done(cell(rec, hc.col)) && h.complete(h.habit(hc.title), date, "", nil) {
+					marked = true
+				}
+			}
+			if !marked {
+				h.skipped++
+			}
+			continue
+		}
+
+		title := strings.TrimSpace(cell(rec, cols.habit))
+		if title == "" {
+			h.skip("line %d: habit name is empty", line)
+			continue
+		}
+		if cols.value >= 0 && !done(cell(rec, cols.value)) {
+			h.skipped++
+			continue
+		}
+		rating, ok := parseRating(cell(rec, cols.rating))
+		if !ok {
+			h.warnf("line %d: rating %q ignored (expected 1-5)", line, cell(rec, cols.rating))
+		}
+		if !h.complete(h.habit(title), date, cell(rec, cols.notes), rating) {
+			h.skip("line %d: date %s is in the future", line, date)
+		}
+	}
+}
+
+// columns — номера столбцов; -1 — столбца нет. habits — для wide: столбцы привычек.
+type columns struct {
+	layout                            string
+	habit, date, value, notes, rating int
+	habits                            []habitColumn
+}
+
+type habitColumn struct {
+	col   int
+	title string
+}
+
+func resolveColumns(header []string, m model.HabitImportMapping) (*columns, error) {
+	names := make([]string, len(header))
+	for i, name := range header {
+		names[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+	find := func(field, explicit string, candidates []string) (int, error) {
+		if explicit != "" {
+			if i := slices.Index(names, strings.ToLower(strings.TrimSpace(explicit))); i >= 0 {
+				return i, nil
+			}
+			return -1, fmt.Errorf("%w: column %q (%s) not found in header", ErrInvalidFile, explicit, field)
+		}
+		for _, c := range candidates {
+			if i := slices.Index(names, c); i >= 0 {
+				return i, nil
+			}
+		}
+		return -1, nil
+	}
+
+	c := &columns{layout: m.Layout}
+	var err error
+	if c.date, err = find("date", m.Date, dateHeaders); err != nil {
+		return nil, err
+	}
+	if c.date < 0 {
+		if m.Date == "" && len(header) > 0 {
+			c.date = 0 // чаще всего дата — первый столбец
+		} else {
+			return nil, fmt.Errorf("%w: date column not found", ErrInvalidFile)
+		}
+	}
+	if c.habit, err = find("habit", m.Habit, habitHeaders); err != nil {
+		return nil, err
+	}
+	if c.layout == "" {
+		c.layout = model.HabitImportLayoutWide
+		if c.habit >= 0 {
+			c.layout = model.HabitImportLayoutLong
+		}
+	}
+
+	switch c.layout {
+	case model.HabitImportLayoutLong:
+		if c.habit < 0 {
+			return nil, fmt.Errorf("%w: habit column not found; set mapping.habit", ErrInvalidFile)
+		}
+		if c.value, err = find("value", m.Value, valueHeaders); err != nil {
+			return nil, err
+		}
+		if c.notes, err = find("notes", m.Notes, notesHeaders); err != nil {
+			return nil, err
+		}
+		if c.rating, err = find("rating", m.Rating, ratingHeaders); err != nil {
+			return nil, err
+		}
+	case model.HabitImportLayoutWide:
+		c.habit, c.value, c.notes, c.rating = -1, -1, -1, -1
+		if len(m.Columns) > 0 {
+			for _, name := range m.Columns {
+				i, err := find("habit", name, nil)
+				if err != nil {
+					return nil, err
+				}
+				c.habits = append(c.habits, habitColumn{i, strings.TrimSpace(header[i])})
+			}
+		} else {
+			for i, name := range header {
+				if i != c.date && strings.TrimSpace(name) != "" {
+					c.habits = append(c.habits, habitColumn{i, strings.TrimSpace(name)})
+				}
+			}
+		}
+		if len(c.habits) == 0 {
+			return nil, fmt.Errorf("%w: no habit columns", ErrInvalidFile)
+		}
+	default:
+		return nil, fmt.Errorf("%w: layout must be %s or %s", ErrInvalidFile, model.HabitImportLayoutLong, model.HabitImportLayoutWide)
+	}
+	return c, nil
+}
+
+func cell(rec []string, i int) string {
+	if i < 0 || i >= len(rec) {
+		return ""
+	}
+	return rec[i]
+}
+
+// sniffDelimiter выбирает разделитель по первой строке: запятая, точка с запятой (Excel в русской локали) или табуляция
+func sniffDelimiter(data []byte) rune {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	best, count := ',', bytes.Count(line, []byte(","))
+	for _, d := range []rune{';', '\t'} {
+		if n := bytes.Count(line, []byte(string(d))); n > count {
+			best, count = d, n
+		}
+	}
+	return best
+}
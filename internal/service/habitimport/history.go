@@ -0,0 +1,175 @@
+package habitimport
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"backend/internal/model"
+)
+
+// maxWarnings — сколько предупреждений о строках файла показывать
+const maxWarnings = 20
+
+const maxTitleLength = 255
+
+// history — привычки и выполнения, прочитанные из файла, в порядке появления
+type history struct {
+	habits   []*habitHistory
+	byKey    map[string]*habitHistory
+	rows     int
+	skipped  int
+	warnings []string
+	today    string
+}
+
+type habitHistory struct {
+	title       string
+	description string
+	color       string
+	archived    bool
+	days        map[string]model.HabitCompletionImport
+}
+
+func newHistory(now time.Time) *history {
+	return &history{byKey: make(map[string]*habitHistory), today: now.Format(time.DateOnly)}
+}
+
+func (h *history) warnf(format string, args ...any) {
+	if len(h.warnings) < maxWarnings {
+		h.warnings = append(h.warnings, fmt.Sprintf(format, args...))
+	}
+}
+
+// skip считает строку пропущенной; причину пишет в предупреждения
+func (h *history) skip(format string, args ...any) {
+	h.skipped++
+	h.warnf(format, args...)
+}
+
+// habit возвращает привычку по названию (без учёта регистра), создавая её при первом упоминании
+func (h *history) habit(title string) *habitHistory {
+	title = strings.TrimSpace(title)
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		title = string([]rune(title)[:maxTitleLength])
+	}
+	key := titleKey(title)
+	if hh, ok := h.byKey[key]; ok {
+		return hh
+	}
+	hh := &habitHistory{title: title, days: make(map[string]model.HabitCompletionImport)}
+	h.byKey[key] = hh
+	h.habits = append(h.habits, hh)
+	return hh
+}
+
+// complete отмечает выполнение; будущие даты пропускаются. Повтор той же даты дополняет заметку и оценку.
+func (h *history) complete(hh *habitHistory, date string, notes string, rating *int) bool {
+	if date > h.today {
+		return false
+	}
+	c := hh.days[date]
+	c.Date = date
+	if notes = strings.TrimSpace(notes); notes != "" {
+		if c.Notes != nil {
+			notes = *c.Notes + "\n" + notes
+		}
+		c.Notes = &notes
+	}
+	if rating != nil {
+		c.Rating = rating
+	}
+	hh.days[date] = c
+	return true
+}
+
+// dropEmpty убирает привычки без единого выполнения — они не импортируются
+func (h *history) dropEmpty() {
+	kept := h.habits[:0]
+	for _, hh := range h.habits {
+		if len(hh.days) > 0 {
+			kept = append(kept, hh)
+		} else {
+			delete(h.byKey, titleKey(hh.title))
+		}
+	}
+	h.habits = kept
+}
+
+// completions — выполнения по возрастанию даты
+func (hh *habitHistory) completions() []model.HabitCompletionImport {
+	out := make([]model.HabitCompletionImport, 0, len(hh.days))
+	for _, c := range hh.days {
+		out = append(out, c)
+	}
+	slices.SortFunc(out, func(a, b model.HabitCompletionImport) int { return strings.Compare(a.Date, b.Date) })
+	return out
+}
+
+func titleKey(title string) string {
+	return strings.ToLower(strings.TrimSpace(title))
+}
+
+// dateLayouts — форматы дат, которые распознаются без явного dateFormat. Форматы с косой чертой
+// неоднозначны (день/месяц), для них нужен dateFormat.
+var dateLayouts = []string{
+	time.DateOnly,
+	time.DateTime,
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+	"2006-01-02 15:04",
+	"02.01.2006",
+	"02.01.2006 15:04",
+	"2006/01/02",
+	"20060102",
+}
+
+// parseDate возвращает дату YYYY-MM-DD; время и зона отбрасываются — важен день, как его видел пользователь
+func parseDate(value, layout string) (string, bool) {
+	value = strings.TrimSpace(value)
+	layouts := dateLayouts
+	if layout != "" {
+		layouts = []string{layout}
+	}
+	for _, l := range layouts {
+		if t, err := time.Parse(l, value); err == nil {
+			if t.Year() < 1970 {
+				return "", false
+			}
+			return t.Format(time.DateOnly), true
+		}
+	}
+	return "", false
+}
+
+var falseValues = map[string]bool{
+	"": true, "0": true, "no": true, "n": true, "false": true, "-": true, "нет": true, "skip": true, "skipped": true,
+}
+
+// done — есть ли в ячейке отметка выполнения: пусто, 0, no, false — нет; число — больше нуля; остальное — да
+func done(value string) bool {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if falseValues[v] {
+		return false
+	}
+	if f, err := strconv.ParseFloat(strings.Replace(v, ",", ".", 1), 64); err == nil {
+		return f > 0
+	}
+	return true
+}
+
+// parseRating — оценка 1–5; пустое или иное значение — без оценки
+func parseRating(value string) (*int, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > 5 {
+		return nil, false
+	}
+	return &n, true
+}
@@ -0,0 +1,282 @@
+package habitimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Значения Checkmarks.csv у привычек «да/нет» в Loop: 2 — отмечено вручную, 1 — засчитано автоматически
+// (частота «N раз за M дней» уже выполнена), 0 — нет, -1 — нет данных, 3 — пропуск. Выполнением считается только 2.
+const loopYesManual = 2
+
+// loopNumerical — значение столбца Type у числовых привычек; у них выполнение — значение больше нуля
+const loopNumerical = "1"
+
+// maxLoopFile — предел распакованного размера одного CSV из архива
+const maxLoopFile = 64 << 20
+
+var hexColor = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+type loopHabit struct {
+	name, description, color string
+	numerical, archived      bool
+	frequency                string // «N/M» — N раз за M дней, если не ежедневно
+}
+
+// parseLoop читает экспорт Loop Habit Tracker: полную резервную копию (SQLite) или ZIP из «Export as CSV» —
+// Habits.csv (описание привычек) и Checkmarks.csv (строка на дату, столбец на привычку).
+func parseLoop(data []byte, h *history) error {
+	if bytes.HasPrefix(data, []byte(sqliteMagic)) {
+		return parseLoopDB(data, h)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("%w: not a ZIP archive", ErrInvalidFile)
+	}
+	files := make(map[string]*zip.File)
+	depths := make(map[string]int)
+	for _, f := range zr.File {
+		// В архиве Loop файлы лежат в корне; некоторые архиваторы добавляют общий каталог. Рядом лежат каталоги
+		// привычек («001 Meditate/Checkmarks.csv») с теми же именами файлов — берётся самый верхний.
+		name, depth := path.Base(f.Name), strings.Count(strings.Trim(f.Name, "/"), "/")
+		if d, ok := depths[name]; depth <= 1 && (!ok || depth < d) {
+			files[name], depths[name] = f, depth
+		}
+	}
+	habitsFile, checkmarksFile := files["Habits.csv"], files["Checkmarks.csv"]
+	if habitsFile == nil || checkmarksFile == nil {
+		return fmt.Errorf("%w: Habits.csv and Checkmarks.csv not found; export from Loop with «Export as CSV»", ErrInvalidFile)
+	}
+
+	habitRows, err := readZipCSV(habitsFile)
+	if err != nil {
+		return err
+	}
+	habits, err := loopHabits(habitRows)
+	if err != nil {
+		return err
+	}
+	rows, err := readZipCSV(checkmarksFile)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("%w: Checkmarks.csv is empty", ErrInvalidFile)
+	}
+
+	// Столбцы Checkmarks.csv идут в порядке Habits.csv; названия сверяются на случай другого порядка
+	header := rows[0]
+	byName := make(map[string]loopHabit, len(habits))
+	for _, lh := range habits {
+		byName[lh.name] = lh
+	}
+	cols := make([]loopHabit, len(header))
+	for i := 1; i < len(header); i++ {
+		name := strings.TrimSpace(header[i])
+		switch {
+		case i-1 < len(habits) && habits[i-1].name == name:
+			cols[i] = habits[i-1]
+		case byName[name].name != "":
+			cols[i] = byName[name]
+		default:
+			cols[i] = loopHabit{name: name}
+		}
+	}
+	for _, lh := range cols[1:] {
+		if lh.name == "" {
+			continue
+		}
+		hh := h.habit(lh.name)
+		hh.description, hh.archived = lh.description, lh.archived
+		if hexColor.MatchString(lh.color) {
+			hh.color = strings.ToUpper(lh.color)
+		}
+		if lh.frequency != "" {
+			h.warnf("habit %q: frequency %s days imported as a daily habit", lh.name, lh.frequency)
+		}
+	}
+
+	for n, rec := range rows[1:] {
+		h.rows++
+		date, ok := parseDate(cell(rec, 0), "")
+		if !ok {
+			h.skip("Checkmarks.csv line %d: %q is not a date", n+2, cell(rec, 0))
+			continue
+		}
+		marked := false
+		for i := 1; i < len(rec) && i < len(cols); i++ {
+			if cols[i].name == "" || !loopDone(rec[i], cols[i].numerical) {
+				continue
+			}
+			if h.complete(h.habit(cols[i].name), date, "", nil) {
+				marked = true
+			}
+		}
+		if !marked {
+			h.skipped++
+		}
+	}
+	h.dropEmpty()
+	return nil
+}
+
+func loopDone(value string, numerical bool) bool {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return false
+	}
+	if numerical {
+		return v > 0
+	}
+	return v == loopYesManual
+}
+
+// loopHabits разбирает Habits.csv. Столбцы ищутся по заголовку: у разных версий Loop их набор отличается.
+func loopHabits(rows [][]string) ([]loopHabit, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: Habits.csv is empty", ErrInvalidFile)
+	}
+	idx := make(map[string]int)
+	for i, name := range rows[0] {
+		idx[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	col := func(rec []string, names ...string) string {
+		for _, name := range names {
+			if i, ok := idx[name]; ok {
+				return strings.TrimSpace(cell(rec, i))
+			}
+		}
+		return ""
+	}
+	if _, ok := idx["name"]; !ok {
+		return nil, fmt.Errorf("%w: Habits.csv has no Name column", ErrInvalidFile)
+	}
+
+	habits := make([]loopHabit, 0, len(rows)-1)
+	for _, rec := range rows[1:] {
+		lh := loopHabit{
+			name:      col(rec, "name"),
+			color:     col(rec, "color"),
+			numerical: col(rec, "type") == loopNumerical,
+			archived:  strings.EqualFold(col(rec, "archived?"), "true"),
+		}
+		if lh.name == "" {
+			continue
+		}
+		lh.description = col(rec, "description")
+		if lh.description == "" {
+			lh.description = col(rec, "question")
+		}
+		num, den := col(rec, "frequencynumerator", "numrepetitions"), col(rec, "frequencydenominator", "interval")
+		if num != "" && den != "" && (num != "1" || den != "1") {
+			lh.frequency = num + "/" + den
+		}
+		habits = append(habits, lh)
+	}
+	return habits, nil
+}
+
+func readZipCSV(f *zip.File) ([][]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidFile, f.Name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxLoopFile+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidFile, f.Name, err)
+	}
+	if len(data) > maxLoopFile {
+		return nil, fmt.Errorf("%w: %s is too large", ErrInvalidFile, f.Name)
+	}
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidFile, f.Name, err)
+	}
+	return rows, nil
+}
+
+// parseLoopDB читает полную резервную копию Loop («Export full backup», файл .db): таблицы Habits и Repetitions.
+// В Repetitions только ручные отметки; timestamp — начало дня по UTC в миллисекундах. Цвет в базе — номер
+// из палитры приложения, он не переносится.
+func parseLoopDB(data []byte, h *history) error {
+	db, err := openSQLite(data)
+	if err != nil {
+		return err
+	}
+	habits, ok, err := db.rows("Habits")
+	if err != nil {
+		return err
+	}
+	reps, repsOK, err := db.rows("Repetitions")
+	if err != nil {
+		return err
+	}
+	if !ok || !repsOK {
+		return fmt.Errorf("%w: Habits and Repetitions tables not found; not a Loop backup", ErrInvalidFile)
+	}
+
+	slices.SortStableFunc(habits, func(a, b map[string]any) int { return cmp.Compare(sqliteInt(a["position"]), sqliteInt(b["position"])) })
+	byID := make(map[int64]*habitHistory, len(habits))
+	numerical := make(map[int64]bool, len(habits))
+	for _, row := range habits {
+		name, _ := row["name"].(string)
+		id, isInt := row["id"].(int64)
+		if strings.TrimSpace(name) == "" || !isInt {
+			continue
+		}
+		hh := h.habit(name)
+		hh.description, _ = row["description"].(string)
+		if hh.description == "" {
+			hh.description, _ = row["question"].(string)
+		}
+		hh.description = strings.TrimSpace(hh.description)
+		hh.archived = sqliteInt(row["archived"]) != 0
+		if num, den := sqliteInt(row["freq_num"]), sqliteInt(row["freq_den"]); num > 0 && den > 0 && (num != 1 || den != 1) {
+			h.warnf("habit %q: frequency %d/%d days imported as a daily habit", hh.title, num, den)
+		}
+		byID[id] = hh
+		numerical[id] = strconv.FormatInt(sqliteInt(row["type"]), 10) == loopNumerical
+	}
+
+	for _, row := range reps {
+		h.rows++
+		habitID := sqliteInt(row["habit"])
+		hh := byID[habitID]
+		ts, isInt := row["timestamp"].(int64)
+		if hh == nil || !isInt || ts < 0 {
+			h.skip("Repetitions row %d: unknown habit or date", sqliteInt(row["id"]))
+			continue
+		}
+		// В старых версиях Loop значения нет: каждая строка — отметка
+		if v, ok := row["value"].(int64); ok && !(numerical[habitID] && v > 0) && v != loopYesManual {
+			h.skipped++
+			continue
+		}
+		notes, _ := row["notes"].(string)
+		if !h.complete(hh, time.UnixMilli(ts).UTC().Format(time.DateOnly), notes, nil) {
+			h.skipped++
+		}
+	}
+	h.dropEmpty()
+	return nil
+}
+
+// sqliteInt — целое значение столбца; NULL и нецелые — 0
+func sqliteInt(v any) int64 {
+	n, _ := v.(int64)
+	return n
+}
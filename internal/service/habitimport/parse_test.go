@@ -0,0 +1,273 @@
+package habitimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+)
+
+var testNow = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+func summary(h *history) map[string][]string {
+	out := make(map[string][]string)
+	for _, hh := range h.habits {
+		for _, c := range hh.completions() {
+			out[hh.title] = append(out[hh.title], c.Date)
+		}
+	}
+	return out
+}
+
+func TestParseCSVLongLayout(t *testing.T) {
+	data := "\ufeffHabit;Date;Done;Comment;Rating\n" +
+		"Бег;2026-01-02;yes;утро;4\n" +
+		"бег;02.01.2026;1;вечер;\n" + // тот же день и та же привычка другим регистром
+		"Бег;2026-01-03;no;;\n" +
+		"Чтение;2026-01-03 21:15:00;x;;9\n" +
+		";2026-01-04;1;;\n" +
+		"Чтение;завтра;1;;\n" +
+		"Чтение;2030-01-01;1;;\n"
+	h := newHistory(testNow)
+	if err := parseCSV([]byte(data), model.HabitImportMapping{}, h); err != nil {
+		t.Fatal(err)
+	}
+	got := summary(h)
+	if strings.Join(got["Бег"], ",") != "2026-01-02" || strings.Join(got["Чтение"], ",") != "2026-01-03" || len(got) != 2 {
+		t.Fatalf("habits = %v", got)
+	}
+	c := h.habits[0].days["2026-01-02"]
+	if c.Notes == nil || *c.Notes != "утро\nвечер" || c.Rating == nil || *c.Rating != 4 {
+		t.Fatalf("completion = %+v", c)
+	}
+	if h.rows != 7 || h.skipped != 4 {
+		t.Errorf("rows = %d, skipped = %d", h.rows, h.skipped)
+	}
+	if len(h.warnings) != 4 { // рейтинг 9, пустая привычка, «завтра», будущая дата
+		t.Errorf("warnings = %q", h.warnings)
+	}
+}
+
+func TestParseCSVWideLayoutWithMapping(t *testing.T) {
+	data := "Day\tWater\tRun\tTotal\n" +
+		"2026/03/01\t1\t0\t1\n" +
+		"2026/03/02\tTRUE\t\t1\n" +
+		"2026/03/03\t\t\t0\n"
+	h := newHistory(testNow)
+	m := model.HabitImportMapping{Date: "day", DateFormat: "2006/01/02", Columns: []string{"Water", "Run"}}
+	if err := parseCSV([]byte(data), m, h); err != nil {
+		t.Fatal(err)
+	}
+	got := summary(h)
+	if strings.Join(got["Water"], ",") != "2026-03-01,2026-03-02" || len(got) != 1 {
+		t.Fatalf("habits = %v", got)
+	}
+	if h.rows != 3 || h.skipped != 1 {
+		t.Errorf("rows = %d, skipped = %d", h.rows, h.skipped)
+	}
+}
+
+func TestParseCSVMappingErrors(t *testing.T) {
+	cases := map[string]struct {
+		data string
+		m    model.HabitImportMapping
+	}{
+		"missing column": {"date,habit\n", model.HabitImportMapping{Habit: "task"}},
+		"long no habit":  {"date,value\n", model.HabitImportMapping{Layout: model.HabitImportLayoutLong}},
+		"bad layout":     {"date,habit\n", model.HabitImportMapping{Layout: "diagonal"}},
+		"empty":          {"", model.HabitImportMapping{}},
+		"delimiter":      {"date,habit\n", model.HabitImportMapping{Delimiter: ";;"}},
+		"not utf8":       {"date,habit\n\xff\xfe,1\n", model.HabitImportMapping{}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if err := parseCSV([]byte(tc.data), tc.m, newHistory(testNow)); !errors.Is(err, ErrInvalidFile) {
+				t.Fatalf("err = %v", err)
+			}
+		})
+	}
+}
+
+func loopZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	// Порядок записей фиксирован: каталоги привычек («001 …») идут раньше файлов корня с теми же именами
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		content := files[name]
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseLoop(t *testing.T) {
+	data := loopZip(t, map[string]string{
+		"Habits.csv": "Position,Name,Type,Question,Description,FrequencyNumerator,FrequencyDenominator,Color,Unit,Target Type,Target Value,Archived?\n" +
+			"001,Meditate,0,Did you meditate?,,1,1,#e53935,,,,false\n" +
+			"002,Gym,0,,Lift,3,7,#1E88E5,,,,true\n" +
+			"003,Pages,1,,,1,1,#43A047,pages,0,10,false\n" +
+			"004,Never,0,,,1,1,#000000,,,,false\n",
+		"Checkmarks.csv": "Date,Meditate,Gym,Pages,Never\n" +
+			"2026-02-03,2,1,0,0\n" +
+			"2026-02-02,0,2,12.5,-1\n" +
+			"2026-02-01,-1,0,0,3\n",
+		"001 Meditate/Checkmarks.csv": "2026-02-03,2\n",
+		"Scores.csv":                  "Date,Meditate\n",
+	})
+	h := newHistory(testNow)
+	if err := parseLoop(data, h); err != nil {
+		t.Fatal(err)
+	}
+	got := summary(h)
+	want := map[string]string{"Meditate": "2026-02-03", "Gym": "2026-02-02", "Pages": "2026-02-02"}
+	if len(got) != len(want) {
+		t.Fatalf("habits = %v", got)
+	}
+	for title, dates := range want {
+		if strings.Join(got[title], ",") != dates {
+			t.Errorf("%s = %v, want %s", title, got[title], dates)
+		}
+	}
+	meditate, gym := h.habits[0], h.habits[1]
+	if meditate.title != "Meditate" || meditate.description != "Did you meditate?" || meditate.color != "#E53935" || meditate.archived {
+		t.Errorf("meditate = %+v", meditate)
+	}
+	if gym.description != "Lift" || !gym.archived {
+		t.Errorf("gym = %+v", gym)
+	}
+	if len(h.warnings) != 1 || !strings.Contains(h.warnings[0], "3/7") {
+		t.Errorf("warnings = %q", h.warnings)
+	}
+}
+
+func TestParseLoopRejectsOtherArchives(t *testing.T) {
+	if err := parseLoop(loopZip(t, map[string]string{"notes.txt": "x"}), newHistory(testNow)); !errors.Is(err, ErrInvalidFile) {
+		t.Fatalf("err = %v", err)
+	}
+	if err := parseLoop([]byte("not a zip"), newHistory(testNow)); !errors.Is(err, ErrInvalidFile) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+// testdata/loop.db — резервная копия со схемой Loop 2.x, страницы по 1 КиБ: у Repetitions есть внутренние
+// страницы B-дерева, а длинное описание Gym лежит на страницах переполнения
+func TestParseLoopDB(t *testing.T) {
+	data, err := os.ReadFile("testdata/loop.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Service{now: func() time.Time { return testNow }}
+	h, format, err := s.parse(Input{Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != model.HabitImportLoop {
+		t.Errorf("format = %s", format)
+	}
+	got := summary(h)
+	if len(got) != 3 || len(got["Meditate"]) != 300 || got["Meditate"][0] != "2025-01-01" || got["Meditate"][299] != "2025-10-27" {
+		t.Fatalf("habits = %v", got)
+	}
+	if strings.Join(got["Gym"], ",") != "2026-02-02" || strings.Join(got["Pages"], ",") != "2026-02-02" {
+		t.Errorf("gym = %v, pages = %v", got["Gym"], got["Pages"])
+	}
+	// Порядок — по position в Loop
+	gym, meditate := h.habits[0], h.habits[1]
+	if gym.title != "Gym" || !gym.archived || gym.description != strings.TrimSpace(strings.Repeat("Lift ", 600)) {
+		t.Errorf("gym = %q archived=%v, description %d bytes", gym.title, gym.archived, len(gym.description))
+	}
+	if meditate.description != "Did you meditate?" || meditate.archived {
+		t.Errorf("meditate = %+v", meditate)
+	}
+	if c := meditate.days["2025-01-01"]; c.Notes == nil || *c.Notes != "calm" {
+		t.Errorf("notes = %+v", c)
+	}
+	// 300 + 3 + 2 отметки, строка чужой привычки и будущая дата
+	if h.rows != 307 || h.skipped != 5 {
+		t.Errorf("rows = %d, skipped = %d", h.rows, h.skipped)
+	}
+	if len(h.warnings) != 2 || !strings.Contains(h.warnings[0], "3/7") {
+		t.Errorf("warnings = %q", h.warnings)
+	}
+}
+
+func TestParseLoopDBDamaged(t *testing.T) {
+	data, err := os.ReadFile("testdata/loop.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parseLoop(data[:2048], newHistory(testNow)); !errors.Is(err, ErrInvalidFile) {
+		t.Errorf("truncated: err = %v", err)
+	}
+	if err := parseLoop([]byte(sqliteMagic+"..."), newHistory(testNow)); !errors.Is(err, ErrInvalidFile) {
+		t.Errorf("header only: err = %v", err)
+	}
+	// Испорченные байты в любом месте файла — ошибка или неполные данные, но не паника
+	for off := 0; off < len(data); off += 7 {
+		damaged := bytes.Clone(data)
+		damaged[off] ^= 0xA5
+		_ = parseLoop(damaged, newHistory(testNow))
+	}
+}
+
+// Испорченные ссылки внутри файла не должны превращаться в копирование файла целиком для каждой ячейки
+func TestParseLoopDBRejectsRepeatedPages(t *testing.T) {
+	data, err := os.ReadFile("testdata/loop.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const pageSize = 1024
+	damage := func(page, off int, b ...byte) []byte {
+		d := bytes.Clone(data)
+		copy(d[(page-1)*pageSize+off:], b)
+		return d
+	}
+	cases := map[string][]byte{
+		// Страница 8 — первая страница переполнения описания Gym; ссылка на себя вместо следующей (9)
+		"overflow loop": damage(8, 0, 0, 0, 0, 8),
+		// Лист Repetitions: второй указатель на ячейку совпадает с первым
+		"repeated cell": damage(13, 10, data[12*pageSize+8], data[12*pageSize+9]),
+	}
+	for name, d := range cases {
+		if err := parseLoop(d, newHistory(testNow)); !errors.Is(err, ErrInvalidFile) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+
+	// Суммарный объём записей ограничен: бюджета в один лист не хватит на обе таблицы
+	db, err := openSQLite(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.budget = pageSize
+	if _, _, err := db.rows("Repetitions"); !errors.Is(err, ErrInvalidFile) {
+		t.Errorf("over budget: err = %v", err)
+	}
+}
+
+func TestDone(t *testing.T) {
+	for v, want := range map[string]bool{
+		"": false, " 0 ": false, "No": false, "false": false, "нет": false, "0,0": false, "-2": false,
+		"1": true, "0.5": true, "yes": true, "x": true, "✓": true, "TRUE": true,
+	} {
+		if got := done(v); got != want {
+			t.Errorf("done(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
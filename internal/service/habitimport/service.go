@@ -0,0 +1,177 @@
+// Package habitimport — перенос истории привычек из других трекеров: CSV с сопоставлением столбцов
+// и экспорт Loop Habit Tracker. Привычки создаются задним числом (первая версия — с даты первого выполнения),
+// поэтому календарь показывает историю так же, как если бы её отмечали у нас.
+package habitimport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+	habitsRepo "backend/internal/repository/habits"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidFile   = errors.New("invalid file")
+	ErrUnknownFormat = errors.New("format must be csv or loop")
+	ErrTooManyHabits = fmt.Errorf("file has more than %d habits", MaxHabits)
+	ErrNoCompletions = errors.New("no completions found in file")
+)
+
+// MaxHabits — сколько привычек можно импортировать за раз
+const MaxHabits = 200
+
+// Input — загруженный файл. Format пустой — определяется по содержимому (SQLite или ZIP — Loop, иначе CSV).
+type Input struct {
+	Format  string
+	Data    []byte
+	Mapping model.HabitImportMapping
+}
+
+type Service struct {
+	habits *habitsRepo.Repository
+	now    func() time.Time
+}
+
+func NewService(habits *habitsRepo.Repository) *Service {
+	return &Service{habits: habits, now: time.Now}
+}
+
+// Import разбирает файл и создаёт привычки с выполнениями. dryRun — только предпросмотр, без изменений.
+// Привычка, название которой совпадает с уже существующей привычкой пользователя (без учёта регистра),
+// не создаётся заново: выполнения добавляются к ней, а её история начинается не позже первого выполнения.
+// Уже отмеченные дни не меняются, поэтому повторный импорт того же файла ничего не добавляет.
+func (s *Service) Import(ctx context.Context, workspaceID, userID string, in Input, dryRun bool) (*model.HabitImportResult, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	wid, err := uuid.Parse(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	h, format, err := s.parse(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.habits) == 0 {
+		return nil, ErrNoCompletions
+	}
+	if len(h.habits) > MaxHabits {
+		return nil, ErrTooManyHabits
+	}
+
+	titles, err := s.habits.ListTitles(ctx, uid, wid)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]uuid.UUID, len(titles))
+	for title, id := range titles {
+		existing[titleKey(title)] = id
+	}
+
+	res := &model.HabitImportResult{
+		DryRun:   dryRun,
+		Format:   format,
+		Habits:   make([]model.HabitImportItem, 0, len(h.habits)),
+		Rows:     h.rows,
+		Skipped:  h.skipped,
+		Warnings: h.warnings,
+	}
+	if res.Warnings == nil {
+		res.Warnings = []string{}
+	}
+	completions := make([][]model.HabitCompletionImport, len(h.habits))
+	for i, hh := range h.habits {
+		completions[i] = hh.completions()
+		item := model.HabitImportItem{
+			Title:       hh.title,
+			Status:      model.HabitImportStatusNew,
+			FirstDate:   completions[i][0].Date,
+			LastDate:    completions[i][len(completions[i])-1].Date,
+			Completions: len(completions[i]),
+		}
+		if id, ok := existing[titleKey(hh.title)]; ok {
+			item.Status, item.HabitID = model.HabitImportStatusExisting, id.String()
+		}
+		res.Habits = append(res.Habits, item)
+	}
+	if dryRun {
+		return res, nil
+	}
+
+	// Весь файл — одна транзакция: при ошибке не остаётся части привычек, которую повторный импорт
+	// принял бы за уже существующие
+	err = s.habits.InTx(ctx, func(tx *habitsRepo.Repository) error {
+		for i, hh := range h.habits {
+			if err := s.importHabit(ctx, tx, uid, wid, hh, completions[i], &res.Habits[i]); err != nil {
+				return fmt.Errorf("import habit %q: %w", hh.title, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *Service) parse(in Input) (*history, string, error) {
+	format := in.Format
+	if format == "" {
+		switch {
+		case bytes.HasPrefix(in.Data, []byte(sqliteMagic)), bytes.HasPrefix(in.Data, []byte("PK\x03\x04")):
+			format = model.HabitImportLoop
+		default:
+			format = model.HabitImportCSV
+		}
+	}
+	h := newHistory(s.now())
+	switch format {
+	case model.HabitImportCSV:
+		return h, format, parseCSV(in.Data, in.Mapping, h)
+	case model.HabitImportLoop:
+		return h, format, parseLoop(in.Data, h)
+	default:
+		return nil, "", ErrUnknownFormat
+	}
+}
+
+// importHabit создаёт привычку «в день первого выполнения» через Repository.WithClock — так её первая версия
+// начинается с этой даты, — либо сдвигает начало истории существующей, и добавляет выполнения
+func (s *Service) importHabit(ctx context.Context, repo *habitsRepo.Repository, userID, workspaceID uuid.UUID, hh *habitHistory, completions []model.HabitCompletionImport, item *model.HabitImportItem) error {
+	first, err := time.Parse(time.DateOnly, item.FirstDate)
+	if err != nil {
+		return err
+	}
+	var habitID uuid.UUID
+	if item.Status == model.HabitImportStatusExisting {
+		habitID = uuid.MustParse(item.HabitID)
+		if err := repo.Backdate(ctx, habitID, userID, first); err != nil {
+			return err
+		}
+	} else {
+		active := !hh.archived
+		dto := model.CreateHabitDto{
+			Title:        hh.title,
+			Description:  hh.description,
+			Color:        hh.color,
+			ScheduleType: "recurring",
+			IsActive:     &active,
+		}
+		habit, err := repo.WithClock(func() time.Time { return first }).Create(ctx, dto, userID, workspaceID)
+		if err != nil {
+			return err
+		}
+		habitID = uuid.MustParse(habit.ID)
+		item.HabitID = habit.ID
+	}
+	item.Imported, err = repo.ImportCompletions(ctx, habitID, userID, completions)
+	return err
+}
@@ -0,0 +1,123 @@
+package habitimport_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository/habits"
+	"backend/internal/service/habitimport"
+	"backend/internal/testutil/pgtest"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+func TestImportCSVHistory(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc := env.Container.HabitImportService
+	u := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, u)
+	existing := env.CreateHabit(t, u, ws, model.CreateHabitDto{Title: "Read"})
+
+	today := habits.NormalizeDate(time.Now())
+	day := func(n int) string { return today.AddDate(0, 0, -n).Format(time.DateOnly) }
+	in := habitimport.Input{Data: []byte(fmt.Sprintf("habit,date,notes\nRun,%s,first\nRun,%s,\nread,%s,\n",
+		day(10), day(5), day(3)))}
+
+	preview, err := svc.Import(ctx, ws.ID, u.ID, in, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Habits) != 2 || preview.Habits[0].Status != model.HabitImportStatusNew ||
+		preview.Habits[1].Status != model.HabitImportStatusExisting || preview.Habits[1].HabitID != existing.ID {
+		t.Fatalf("preview = %+v", preview)
+	}
+	var count int
+	if err := env.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM habits WHERE workspace_id = $1`, ws.ID).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("dry run created habits: %d", count)
+	}
+
+	res, err := svc.Import(ctx, ws.ID, u.ID, in, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Habits[0].Imported != 2 || res.Habits[1].Imported != 1 {
+		t.Fatalf("import = %+v", res)
+	}
+	runID := res.Habits[0].HabitID
+
+	// Первая версия обеих привычек начинается с первого импортированного выполнения
+	for id, want := range map[string]string{runID: day(10), existing.ID: day(3)} {
+		var from time.Time
+		if err := env.DB.QueryRowContext(ctx, `SELECT MIN(valid_from) FROM habit_versions WHERE habit_id = $1`, id).Scan(&from); err != nil {
+			t.Fatal(err)
+		}
+		if got := from.Format(time.DateOnly); got > want {
+			t.Errorf("habit %s: valid_from = %s, want <= %s", id, got, want)
+		}
+	}
+
+	cal, err := env.Container.HabitsService.GetCalendar(ctx, u.ID, ws.ID, today.AddDate(0, 0, -10), today.AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	completed := make(map[string]bool)
+	for _, d := range cal.Days {
+		for _, dh := range d.Habits {
+			if dh.Completed {
+				completed[dh.ID+" "+d.Date] = true
+			}
+		}
+	}
+	for _, key := range []string{runID + " " + day(10), runID + " " + day(5), existing.ID + " " + day(3)} {
+		if !completed[key] {
+			t.Errorf("calendar misses %s", key)
+		}
+	}
+	if len(completed) != 3 {
+		t.Errorf("calendar completions = %v", completed)
+	}
+
+	again, err := svc.Import(ctx, ws.ID, u.ID, in, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Habits[0].Status != model.HabitImportStatusExisting || again.Habits[0].Imported != 0 || again.Habits[1].Imported != 0 {
+		t.Fatalf("re-import = %+v", again)
+	}
+}
+
+func TestImportIsAtomic(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	u := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, u)
+
+	// Вторая привычка файла не записывается — первая тоже не должна остаться
+	if _, err := env.DB.ExecContext(ctx, `
+		CREATE FUNCTION pgtest_reject_habit() RETURNS trigger LANGUAGE plpgsql AS $$
+		BEGIN RAISE EXCEPTION 'rejected'; END $$;
+		CREATE TRIGGER pgtest_reject_habit BEFORE INSERT ON habits FOR EACH ROW
+			WHEN (NEW.title = 'Broken') EXECUTE FUNCTION pgtest_reject_habit();
+	`); err != nil {
+		t.Fatal(err)
+	}
+	day := habits.NormalizeDate(time.Now()).AddDate(0, 0, -1).Format(time.DateOnly)
+	in := habitimport.Input{Data: []byte(fmt.Sprintf("habit,date\nRun,%s\nBroken,%s\n", day, day))}
+	if _, err := env.Container.HabitImportService.Import(ctx, ws.ID, u.ID, in, false); err == nil {
+		t.Fatal("import succeeded")
+	}
+	var n int
+	if err := env.DB.QueryRowContext(ctx, `SELECT (SELECT COUNT(*) FROM habits) + (SELECT COUNT(*) FROM habit_versions) + (SELECT COUNT(*) FROM habit_completions)`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("partial import left %d rows", n)
+	}
+}
@@ -0,0 +1,366 @@
+package habitimport
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf16"
+)
+
+// Чтение файла SQLite (https://www.sqlite.org/fileformat.html) — ровно столько, сколько нужно для полной
+// резервной копии Loop: обход B-дерева таблиц с rowid, страницы переполнения, разбор записей. Индексы,
+// WITHOUT ROWID и WAL не поддерживаются. Любое нарушение формата — ErrInvalidFile, без паники.
+
+const sqliteMagic = "SQLite format 3\x00"
+
+// maxSQLiteDepth — предел глубины B-дерева; в настоящих базах он не больше 5–6
+const maxSQLiteDepth = 32
+
+// sqliteReadFactor — сколько размеров файла можно прочитать записями за всё время разбора. Импорт дважды обходит
+// sqlite_schema и по разу две таблицы, то есть настоящая база укладывается в 3 размера. Испорченная база с
+// ячейками, наложенными друг на друга, иначе заставила бы копировать файл целиком для каждой ячейки.
+const sqliteReadFactor = 4
+
+var errSQLiteCorrupt = fmt.Errorf("%w: damaged SQLite database", ErrInvalidFile)
+
+type sqliteDB struct {
+	data     []byte
+	pageSize int
+	usable   int              // размер страницы без зарезервированного хвоста
+	utf16    binary.ByteOrder // nil — текст в UTF-8
+	budget   int64            // сколько байт записей ещё можно собрать, см. sqliteReadFactor
+}
+
+type sqliteColumn struct {
+	name  string // в нижнем регистре
+	rowid bool   // INTEGER PRIMARY KEY — значение хранится как rowid, в записи NULL
+}
+
+func openSQLite(data []byte) (*sqliteDB, error) {
+	if len(data) < 100 || string(data[:16]) != sqliteMagic {
+		return nil, errSQLiteCorrupt
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 || len(data)%pageSize != 0 {
+		return nil, errSQLiteCorrupt
+	}
+	db := &sqliteDB{data: data, pageSize: pageSize, usable: pageSize - int(data[20]), budget: sqliteReadFactor * int64(len(data))}
+	if db.usable < 480 {
+		return nil, errSQLiteCorrupt
+	}
+	switch binary.BigEndian.Uint32(data[56:]) {
+	case 0, 1: // 0 — кодировка ещё не выбрана (пустая база)
+	case 2:
+		db.utf16 = binary.LittleEndian
+	case 3:
+		db.utf16 = binary.BigEndian
+	default:
+		return nil, errSQLiteCorrupt
+	}
+	return db, nil
+}
+
+// rows возвращает строки таблицы: значения по именам столбцов (в нижнем регистре). Значение — int64, float64,
+// string, []byte или nil; столбцы, добавленные ALTER TABLE после записи строки, — nil. ok=false — таблицы нет.
+func (db *sqliteDB) rows(table string) (list []map[string]any, ok bool, err error) {
+	var root int64
+	var columns []sqliteColumn
+	// sqlite_schema: type, name, tbl_name, rootpage, sql; корень — страница 1
+	err = db.walk(1, func(_ int64, record []byte) error {
+		v, err := db.record(record)
+		if err != nil || len(v) < 5 || ok {
+			return err
+		}
+		name, _ := v[1].(string)
+		if v[0] != "table" || !strings.EqualFold(name, table) {
+			return nil
+		}
+		sql, _ := v[4].(string)
+		root, _ = v[3].(int64)
+		columns, ok = sqliteColumns(sql), true
+		return nil
+	})
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	if root <= 0 || root > math.MaxUint32 {
+		return nil, true, errSQLiteCorrupt
+	}
+	err = db.walk(uint32(root), func(rowid int64, record []byte) error {
+		v, err := db.record(record)
+		if err != nil {
+			return err
+		}
+		row := make(map[string]any, len(columns))
+		for i, c := range columns {
+			if i < len(v) {
+				row[c.name] = v[i]
+			}
+			if c.rowid && row[c.name] == nil {
+				row[c.name] = rowid
+			}
+		}
+		list = append(list, row)
+		return nil
+	})
+	return list, true, err
+}
+
+func (db *sqliteDB) page(n uint32) ([]byte, error) {
+	start := (int64(n) - 1) * int64(db.pageSize)
+	if n == 0 || start+int64(db.pageSize) > int64(len(db.data)) {
+		return nil, errSQLiteCorrupt
+	}
+	return db.data[start : start+int64(db.pageSize)], nil
+}
+
+// walk обходит B-дерево таблицы с корнем root по возрастанию rowid. Каждая страница дерева и каждая страница
+// переполнения посещается не больше одного раза, а ячейка — по одному указателю: испорченная база с циклом
+// в дереве или в цепочке переполнения не зациклит обход.
+func (db *sqliteDB) walk(root uint32, fn func(rowid int64, record []byte) error) error {
+	seen, overflow := make(map[uint32]bool), make(map[uint32]bool)
+	var visit func(n uint32, depth int) error
+	visit = func(n uint32, depth int) error {
+		if depth > maxSQLiteDepth || seen[n] {
+			return errSQLiteCorrupt
+		}
+		seen[n] = true
+		p, err := db.page(n)
+		if err != nil {
+			return err
+		}
+		hdr := 0
+		if n == 1 {
+			hdr = 100 // на первой странице B-дерево начинается после заголовка файла
+		}
+		kind := p[hdr]
+		cells := int(binary.BigEndian.Uint16(p[hdr+3:]))
+		ptrs := hdr + 8
+		switch kind {
+		case 0x0d: // лист таблицы
+		case 0x05: // внутренняя страница таблицы
+			ptrs = hdr + 12
+		default:
+			return errSQLiteCorrupt
+		}
+		if ptrs+2*cells > db.usable {
+			return errSQLiteCorrupt
+		}
+		offsets := make(map[int]bool, cells)
+		for i := 0; i < cells; i++ {
+			off := int(binary.BigEndian.Uint16(p[ptrs+2*i:]))
+			if off < ptrs || off >= db.usable || offsets[off] {
+				return errSQLiteCorrupt
+			}
+			offsets[off] = true
+			cell := p[off:db.usable]
+			if kind == 0x05 {
+				if len(cell) < 4 {
+					return errSQLiteCorrupt
+				}
+				if err := visit(binary.BigEndian.Uint32(cell), depth+1); err != nil {
+					return err
+				}
+				continue
+			}
+			size, k := sqliteVarint(cell)
+			rowid, m := sqliteVarint(cell[k:])
+			if k == 0 || m == 0 {
+				return errSQLiteCorrupt
+			}
+			record, err := db.payload(cell[k+m:], size, overflow)
+			if err != nil {
+				return err
+			}
+			if err := fn(int64(rowid), record); err != nil {
+				return err
+			}
+		}
+		if kind == 0x05 {
+			return visit(binary.BigEndian.Uint32(p[hdr+8:]), depth+1)
+		}
+		return nil
+	}
+	return visit(root, 0)
+}
+
+// payload собирает запись ячейки листа: начало лежит в самой ячейке, остаток — в цепочке страниц переполнения.
+// overflow — страницы переполнения, уже прочитанные в этом обходе: у каждой страницы один владелец.
+func (db *sqliteDB) payload(cell []byte, size uint64, overflow map[uint32]bool) ([]byte, error) {
+	if size > uint64(len(db.data)) || int64(size) > db.budget {
+		return nil, errSQLiteCorrupt
+	}
+	db.budget -= int64(size)
+	total, u := int(size), db.usable
+	if total <= u-35 {
+		if len(cell) < total {
+			return nil, errSQLiteCorrupt
+		}
+		return cell[:total], nil
+	}
+	minLocal := (u-12)*32/255 - 23
+	local := minLocal + (total-minLocal)%(u-4)
+	if local > u-35 {
+		local = minLocal
+	}
+	if len(cell) < local+4 {
+		return nil, errSQLiteCorrupt
+	}
+	out := make([]byte, 0, total)
+	out = append(out, cell[:local]...)
+	next := binary.BigEndian.Uint32(cell[local:])
+	for len(out) < total {
+		if overflow[next] {
+			return nil, errSQLiteCorrupt
+		}
+		overflow[next] = true
+		p, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		chunk := p[4:u]
+		if rest := total - len(out); rest < len(chunk) {
+			chunk = chunk[:rest]
+		}
+		out = append(out, chunk...)
+		next = binary.BigEndian.Uint32(p)
+	}
+	return out, nil
+}
+
+// record разбирает запись: заголовок с типами значений, затем сами значения
+func (db *sqliteDB) record(rec []byte) ([]any, error) {
+	hsize, n := sqliteVarint(rec)
+	if n == 0 || hsize < uint64(n) || hsize > uint64(len(rec)) {
+		return nil, errSQLiteCorrupt
+	}
+	header, body := rec[n:hsize], rec[hsize:]
+	var values []any
+	for len(header) > 0 {
+		t, k := sqliteVarint(header)
+		if k == 0 {
+			return nil, errSQLiteCorrupt
+		}
+		header = header[k:]
+
+		var size uint64
+		switch {
+		case t <= 4:
+			size = t
+		case t == 5:
+			size = 6
+		case t == 6, t == 7:
+			size = 8
+		case t == 8, t == 9:
+		case t >= 12:
+			size = (t - 12) / 2
+		default:
+			return nil, errSQLiteCorrupt
+		}
+		if size > uint64(len(body)) {
+			return nil, errSQLiteCorrupt
+		}
+		v := body[:size]
+		body = body[size:]
+
+		switch {
+		case t == 0:
+			values = append(values, nil)
+		case t == 8, t == 9:
+			values = append(values, int64(t-8))
+		case t <= 6:
+			var x int64
+			if v[0]&0x80 != 0 {
+				x = -1
+			}
+			for _, b := range v {
+				x = x<<8 | int64(b)
+			}
+			values = append(values, x)
+		case t == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(v)))
+		case t%2 == 0:
+			values = append(values, append([]byte(nil), v...))
+		default:
+			values = append(values, db.text(v))
+		}
+	}
+	return values, nil
+}
+
+func (db *sqliteDB) text(v []byte) string {
+	if db.utf16 == nil {
+		return string(v)
+	}
+	units := make([]uint16, len(v)/2)
+	for i := range units {
+		units[i] = db.utf16.Uint16(v[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// sqliteVarint — целое переменной длины: до 8 байт по 7 бит, девятый байт целиком. n=0 — данные кончились.
+func sqliteVarint(b []byte) (v uint64, n int) {
+	for i := 0; i < 9; i++ {
+		if i >= len(b) {
+			return 0, 0
+		}
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// sqliteColumns — столбцы из CREATE TABLE в порядке объявления; ограничения таблицы пропускаются
+func sqliteColumns(create string) []sqliteColumn {
+	start, end := strings.Index(create, "("), strings.LastIndex(create, ")")
+	if start < 0 || end < start {
+		return nil
+	}
+	var defs []string
+	depth, quote, from := 0, rune(0), start+1
+	for i, r := range create[start+1 : end] {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			defs = append(defs, create[from:start+1+i])
+			from = start + 2 + i
+		}
+	}
+	defs = append(defs, create[from:end])
+
+	var columns []sqliteColumn
+	for _, def := range defs {
+		fields := strings.Fields(def)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN":
+			continue
+		}
+		columns = append(columns, sqliteColumn{
+			name:  strings.ToLower(strings.Trim(fields[0], "\"`[]")),
+			rowid: len(fields) > 1 && strings.EqualFold(fields[1], "integer") && strings.Contains(strings.ToUpper(def), "PRIMARY KEY"),
+		})
+	}
+	return columns
+}
@@ -7,6 +7,7 @@ import (
 
 	"backend/internal/model"
 	"backend/internal/repository/habits"
+	"backend/pkg/query"
 
 	"github.com/google/uuid"
 )
@@ -24,15 +25,15 @@ func NewService(repo *habits.Repository) *Service {
 	return &Service{repo: repo}
 }
 
-func (s *Service) List(ctx context.Context, workspaceID string, targetDate *time.Time) ([]model.Habit, error) {
+func (s *Service) List(ctx context.Context, workspaceID string, targetDate *time.Time, spec query.Spec) ([]model.Habit, query.Page, error) {
 	if workspaceID == "" {
-		return nil, ErrWorkspaceNeeded
+		return nil, query.Page{}, ErrWorkspaceNeeded
 	}
 	wid, err := uuid.Parse(workspaceID)
 	if err != nil {
-		return nil, err
+		return nil, query.Page{}, err
 	}
-	return s.repo.List(ctx, wid, targetDate)
+	return s.repo.List(ctx, wid, targetDate, spec)
 }
 
 func (s *Service) Create(ctx context.Context, dto model.CreateHabitDto, userID, workspaceID string) (*model.Habit, error) {
@@ -0,0 +1,186 @@
+package health
+
+import (
+	"backend/internal/database"
+	"backend/internal/worker"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// CheckResult — результат одной проверки readiness
+type CheckResult struct {
+	Name      string      `json:"name"`
+	Status    string      `json:"status"`
+	LatencyMs float64     `json:"latency_ms"`
+	Error     string      `json:"error,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// Report — итог readiness: ok, только если все проверки ok
+type Report struct {
+	Status    string        `json:"status"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Checks    []CheckResult `json:"checks"`
+}
+
+type check struct {
+	name string
+	fn   func(ctx context.Context) (interface{}, error)
+}
+
+// Service выполняет проверки для /health/ready
+type Service struct {
+	db             *sql.DB
+	logDir         string
+	workers        *worker.RunMetrics
+	timeout        time.Duration
+	startedAt      time.Time
+	expectedSchema uint
+	expectedErr    error
+}
+
+func NewService(db *sql.DB, logDir string, workers *worker.RunMetrics, timeout time.Duration) *Service {
+	s := &Service{
+		db:        db,
+		logDir:    logDir,
+		workers:   workers,
+		timeout:   timeout,
+		startedAt: time.Now(),
+	}
+	// Ожидаемая версия схемы не меняется, пока процесс жив
+	s.expectedSchema, s.expectedErr = database.LatestMigrationVersion()
+	return s
+}
+
+// Uptime — время с запуска процесса (для /health/live)
+func (s *Service) Uptime() time.Duration {
+	return time.Since(s.startedAt)
+}
+
+// Ready запускает все проверки параллельно, каждую со своим таймаутом
+func (s *Service) Ready(ctx context.Context) Report {
+	checks := []check{
+		{"database", s.checkDatabase},
+		{"migrations", s.checkMigrations},
+		{"log_dir", s.checkLogDir},
+		{"workers", s.checkWorkers},
+	}
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			results[i] = s.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, CheckedAt: time.Now().UTC(), Checks: results}
+	for _, r := range results {
+		if r.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+func (s *Service) run(ctx context.Context, c check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	type outcome struct {
+		details interface{}
+		err     error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		details, err := c.fn(ctx)
+		done <- outcome{details, err}
+	}()
+
+	var out outcome
+	select {
+	case out = <-done:
+	case <-ctx.Done():
+		out.err = fmt.Errorf("timed out after %v", s.timeout)
+	}
+
+	res := CheckResult{
+		Name:      c.name,
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   out.details,
+	}
+	if out.err != nil {
+		res.Status = StatusFail
+		res.Error = out.err.Error()
+	}
+	return res
+}
+
+func (s *Service) checkDatabase(ctx context.Context) (interface{}, error) {
+	if err := s.db.PingContext(ctx); err != nil {
+		return nil, err
+	}
+	st := s.db.Stats()
+	return map[string]int{"open": st.OpenConnections, "in_use": st.InUse, "idle": st.Idle}, nil
+}
+
+func (s *Service) checkMigrations(ctx context.Context) (interface{}, error) {
+	version, dirty, err := database.MigrationStatus(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	details := map[string]interface{}{"version": version, "dirty": dirty, "expected": s.expectedSchema}
+	switch {
+	case dirty:
+		return details, fmt.Errorf("migration %d is dirty", version)
+	case s.expectedErr != nil:
+		return details, fmt.Errorf("read embedded migrations: %w", s.expectedErr)
+	case version < s.expectedSchema:
+		return details, fmt.Errorf("schema version %d is behind %d", version, s.expectedSchema)
+	}
+	return details, nil
+}
+
+// checkLogDir проверяет, что в каталог логов можно писать (туда пишет middleware.RequestLogger)
+func (s *Service) checkLogDir(ctx context.Context) (interface{}, error) {
+	f, err := os.CreateTemp(s.logDir, ".health-*")
+	if err != nil {
+		return nil, err
+	}
+	name := f.Name()
+	_, werr := f.WriteString("ok")
+	cerr := f.Close()
+	os.Remove(name)
+	if werr != nil {
+		return nil, werr
+	}
+	return map[string]string{"dir": s.logDir}, cerr
+}
+
+func (s *Service) checkWorkers(ctx context.Context) (interface{}, error) {
+	heartbeats := s.workers.Heartbeats()
+	var stale []string
+	for _, hb := range heartbeats {
+		if hb.Stale {
+			stale = append(stale, hb.Worker)
+		}
+	}
+	if len(stale) > 0 {
+		return heartbeats, fmt.Errorf("stale workers: %v", stale)
+	}
+	return heartbeats, nil
+}
@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/worker"
+	"backend/pkg/metrics"
+
+	_ "github.com/lib/pq"
+)
+
+func TestCheckLogDir(t *testing.T) {
+	dir := t.TempDir()
+	s := &Service{logDir: dir}
+	if _, err := s.checkLogDir(context.Background()); err != nil {
+		t.Fatalf("writable dir: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("probe file left: %v", entries)
+	}
+
+	s.logDir = filepath.Join(dir, "missing")
+	if _, err := s.checkLogDir(context.Background()); err == nil {
+		t.Fatal("missing dir: no error")
+	}
+}
+
+func TestRunTimesOut(t *testing.T) {
+	s := &Service{timeout: 20 * time.Millisecond}
+	release := make(chan struct{})
+	defer close(release)
+
+	start := time.Now()
+	res := s.run(context.Background(), check{"hang", func(ctx context.Context) (interface{}, error) {
+		<-release // проверка не слушает ctx — проба всё равно отвечает к таймауту
+		return nil, nil
+	}})
+	if res.Status != StatusFail || !strings.Contains(res.Error, "timed out") {
+		t.Fatalf("result = %+v", res)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("run took %v", elapsed)
+	}
+	if res.LatencyMs < 20 {
+		t.Fatalf("latency = %v ms", res.LatencyMs)
+	}
+}
+
+func TestReadyReportsEachCheck(t *testing.T) {
+	// Порт 1 закрыт: пинг БД и чтение версии миграций падают сразу
+	db, err := sql.Open("postgres", "host=127.0.0.1 port=1 user=x dbname=x sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	workers := worker.NewRunMetrics(metrics.NewRegistry())
+	s := NewService(db, t.TempDir(), workers, time.Second)
+	report := s.Ready(context.Background())
+
+	if report.Status != StatusFail || len(report.Checks) != 4 {
+		t.Fatalf("report = %+v", report)
+	}
+	want := map[string]string{"database": StatusFail, "migrations": StatusFail, "log_dir": StatusOK, "workers": StatusOK}
+	for _, c := range report.Checks {
+		if c.Status != want[c.Name] {
+			t.Errorf("%s: %s (%s), want %s", c.Name, c.Status, c.Error, want[c.Name])
+		}
+		if c.Status == StatusFail && c.Error == "" {
+			t.Errorf("%s: failed without an error", c.Name)
+		}
+	}
+}
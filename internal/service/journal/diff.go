@@ -0,0 +1,105 @@
+package journal
+
+import (
+	"strings"
+
+	"backend/internal/model"
+)
+
+// maxDiffCells — предел размера таблицы LCS (строк старой × строк новой после отбрасывания общих начала и конца).
+// Больше — текст считается заменённым целиком, чтобы не тратить память на гигантские записи.
+const maxDiffCells = 4_000_000
+
+// LineDiff — построчный дифф двух текстов (LCS). Общие начало и конец отбрасываются до построения таблицы,
+// поэтому типичная правка в длинной записи стоит дёшево.
+func LineDiff(oldText, newText string) []model.DiffLine {
+	a, b := splitLines(oldText), splitLines(newText)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	out := make([]model.DiffLine, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		out = append(out, model.DiffLine{Op: model.DiffEqual, Text: a[i], OldLine: i + 1, NewLine: i + 1})
+	}
+	out = append(out, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix, prefix)...)
+	for i := 0; i < suffix; i++ {
+		ai, bi := len(a)-suffix+i, len(b)-suffix+i
+		out = append(out, model.DiffLine{Op: model.DiffEqual, Text: a[ai], OldLine: ai + 1, NewLine: bi + 1})
+	}
+	return out
+}
+
+// diffMiddle — LCS по таблице; offA/offB — сколько строк отброшено в начале (для номеров строк)
+func diffMiddle(a, b []string, offA, offB int) []model.DiffLine {
+	n, m := len(a), len(b)
+	var out []model.DiffLine
+	del := func(i int) {
+		out = append(out, model.DiffLine{Op: model.DiffDelete, Text: a[i], OldLine: offA + i + 1})
+	}
+	ins := func(j int) {
+		out = append(out, model.DiffLine{Op: model.DiffInsert, Text: b[j], NewLine: offB + j + 1})
+	}
+
+	if n*m > maxDiffCells {
+		for i := range a {
+			del(i)
+		}
+		for j := range b {
+			ins(j)
+		}
+		return out
+	}
+
+	// lcs[i][j] — длина LCS суффиксов a[i:] и b[j:]
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, model.DiffLine{Op: model.DiffEqual, Text: a[i], OldLine: offA + i + 1, NewLine: offB + j + 1})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			del(i)
+			i++
+		default:
+			ins(j)
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		del(i)
+	}
+	for ; j < m; j++ {
+		ins(j)
+	}
+	return out
+}
+
+// splitLines делит текст на строки; \r\n считается одним переводом строки, пустой текст — ноль строк
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
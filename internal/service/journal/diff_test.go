@@ -0,0 +1,58 @@
+package journal
+
+import (
+	"strings"
+	"testing"
+
+	"backend/internal/model"
+)
+
+func render(lines []model.DiffLine) string {
+	var b strings.Builder
+	for _, l := range lines {
+		switch l.Op {
+		case model.DiffEqual:
+			b.WriteString(" ")
+		case model.DiffInsert:
+			b.WriteString("+")
+		case model.DiffDelete:
+			b.WriteString("-")
+		}
+		b.WriteString(l.Text + "|")
+	}
+	return b.String()
+}
+
+func TestLineDiff(t *testing.T) {
+	for _, tc := range []struct{ old, new, want string }{
+		{"", "", ""},
+		{"", "a", "+a|"},
+		{"a\nb", "", "-a|-b|"},
+		{"a\nb\nc", "a\nb\nc", " a| b| c|"},
+		{"a\nb\nc", "a\nx\nc", " a|-b|+x| c|"},
+		{"a\nb\nc\nd", "a\nc\nd\ne", " a|-b| c| d|+e|"},
+		{"a\r\nb", "a\nb", " a| b|"},
+	} {
+		if got := render(LineDiff(tc.old, tc.new)); got != tc.want {
+			t.Errorf("LineDiff(%q, %q) = %q, want %q", tc.old, tc.new, got, tc.want)
+		}
+	}
+}
+
+func TestLineDiff_LineNumbers(t *testing.T) {
+	lines := LineDiff("a\nb\nc", "a\nx\nb\nc")
+	want := []model.DiffLine{
+		{Op: model.DiffEqual, Text: "a", OldLine: 1, NewLine: 1},
+		{Op: model.DiffInsert, Text: "x", NewLine: 2},
+		{Op: model.DiffEqual, Text: "b", OldLine: 2, NewLine: 3},
+		{Op: model.DiffEqual, Text: "c", OldLine: 3, NewLine: 4},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %+v", lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, lines[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// ListRevisions — история правок записи, новые сверху. nil — записи нет.
+func (s *Service) ListRevisions(ctx context.Context, workspaceID, entryID string) ([]model.JournalRevision, error) {
+	wsID, id, err := parseIDs(workspaceID, entryID)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.repo.Get(ctx, id, wsID)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	return s.repo.ListRevisions(ctx, id, wsID)
+}
+
+// GetRevision — одна ревизия записи
+func (s *Service) GetRevision(ctx context.Context, workspaceID, entryID string, revision int) (*model.JournalRevision, error) {
+	wsID, id, err := parseIDs(workspaceID, entryID)
+	if err != nil {
+		return nil, err
+	}
+	rev, err := s.repo.GetRevision(ctx, id, wsID, revision)
+	if err != nil {
+		return nil, err
+	}
+	if rev == nil {
+		return nil, ErrRevisionNotFound
+	}
+	return rev, nil
+}
+
+// DiffRevisions сравнивает ревизии from и to: текст построчно, остальные поля — списком изменений
+func (s *Service) DiffRevisions(ctx context.Context, workspaceID, entryID string, from, to int) (*model.JournalRevisionDiff, error) {
+	a, err := s.GetRevision(ctx, workspaceID, entryID, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.GetRevision(ctx, workspaceID, entryID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &model.JournalRevisionDiff{EntryID: a.EntryID, From: from, To: to, Lines: LineDiff(a.Description, b.Description), Changes: []model.FieldChange{}}
+	for _, l := range diff.Lines {
+		switch l.Op {
+		case model.DiffInsert:
+			diff.Added++
+		case model.DiffDelete:
+			diff.Removed++
+		}
+	}
+
+	change := func(field string, x, y interface{}) {
+		diff.Changes = append(diff.Changes, model.FieldChange{Field: field, From: x, To: y})
+	}
+	if !reflect.DeepEqual(a.Mood, b.Mood) {
+		change("mood", a.Mood, b.Mood)
+	}
+	if a.Date != b.Date {
+		change("date", a.Date, b.Date)
+	}
+	if !slices.Equal(a.Tags, b.Tags) {
+		change("tags", a.Tags, b.Tags)
+	}
+	if a.ContentType != b.ContentType {
+		change("contentType", a.ContentType, b.ContentType)
+	}
+	if !reflect.DeepEqual(a.Metadata, b.Metadata) {
+		change("metadata", a.Metadata, b.Metadata)
+	}
+	return diff, nil
+}
+
+// RestoreRevision возвращает запись к состоянию ревизии. Восстановление — новая ревизия с restoredFrom,
+// история не переписывается. Если запись и так совпадает с ревизией, новая ревизия не создаётся.
+func (s *Service) RestoreRevision(ctx context.Context, workspaceID, entryID, userID string, revision int) (*model.JournalEntry, error) {
+	wsID, id, err := parseIDs(workspaceID, entryID)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.repo.Get(ctx, id, wsID)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	rev, err := s.GetRevision(ctx, workspaceID, entryID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Description = rev.Description
+	entry.Mood = rev.Mood
+	entry.Date = rev.Date
+	entry.Tags = rev.Tags
+	entry.ContentType = rev.ContentType
+	entry.Metadata = rev.Metadata
+	if err := s.repo.Update(ctx, entry, uid, &revision); err != nil {
+		return nil, err
+	}
+	s.syncLinks(ctx, entry)
+	return entry, nil
+}
+
+func parseIDs(workspaceID, entryID string) (uuid.UUID, uuid.UUID, error) {
+	wsID, err := uuid.Parse(workspaceID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	id, err := uuid.Parse(entryID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return wsID, id, nil
+}
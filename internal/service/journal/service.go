@@ -2,28 +2,35 @@ package journal
 
 import (
 	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
 	"time"
 
 	"backend/internal/model"
 	journalRepo "backend/internal/repository/journal"
+	linksService "backend/internal/service/links"
+	"backend/pkg/query"
 
 	"github.com/google/uuid"
 )
 
 type Service struct {
-	repo *journalRepo.Repository
+	repo  *journalRepo.Repository
+	links *linksService.Service
 }
 
-func NewService(repo *journalRepo.Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo *journalRepo.Repository, links *linksService.Service) *Service {
+	return &Service{repo: repo, links: links}
 }
 
-func (s *Service) List(ctx context.Context, workspaceID string, date *time.Time) ([]model.JournalEntry, error) {
+func (s *Service) List(ctx context.Context, workspaceID string, spec query.Spec) ([]model.JournalEntry, query.Page, error) {
 	wsID, err := uuid.Parse(workspaceID)
 	if err != nil {
-		return nil, err
+		return nil, query.Page{}, err
 	}
-	return s.repo.List(ctx, wsID, date)
+	return s.repo.List(ctx, wsID, spec)
 }
 
 func (s *Service) Get(ctx context.Context, workspaceID, entryID string) (*model.JournalEntry, error) {
@@ -60,10 +67,11 @@ func (s *Service) Create(ctx context.Context, workspaceID, userID string, dto mo
 	if err := s.repo.Create(ctx, e); err != nil {
 		return nil, err
 	}
+	s.syncLinks(ctx, e)
 	return e, nil
 }
 
-func (s *Service) Update(ctx context.Context, workspaceID, entryID string, dto model.UpdateJournalEntryDto) (*model.JournalEntry, error) {
+func (s *Service) Update(ctx context.Context, workspaceID, entryID, userID string, dto model.UpdateJournalEntryDto) (*model.JournalEntry, error) {
 	existing, err := s.repo.Get(ctx, uuid.MustParse(entryID), uuid.MustParse(workspaceID))
 	if err != nil || existing == nil {
 		return nil, err
@@ -86,12 +94,20 @@ func (s *Service) Update(ctx context.Context, workspaceID, entryID string, dto m
 	if dto.Metadata != nil {
 		existing.Metadata = dto.Metadata
 	}
-	if err := s.repo.Update(ctx, existing); err != nil {
+	if err := s.repo.Update(ctx, existing, uuid.MustParse(userID), nil); err != nil {
 		return nil, err
 	}
+	s.syncLinks(ctx, existing)
 	return existing, nil
 }
 
+// syncLinks пересобирает связи из [[ссылок]] в тексте записи. Ошибка только логируется: запись уже сохранена.
+func (s *Service) syncLinks(ctx context.Context, e *model.JournalEntry) {
+	if err := s.links.SyncWiki(ctx, e.WorkspaceID, model.SearchTypeJournal, e.ID, e.Description); err != nil {
+		log.Printf("[journal] sync links of %s: %v", e.ID, err)
+	}
+}
+
 func (s *Service) Delete(ctx context.Context, workspaceID, entryID string) error {
 	wsID, err := uuid.Parse(workspaceID)
 	if err != nil {
@@ -103,3 +119,96 @@ func (s *Service) Delete(ctx context.Context, workspaceID, entryID string) error
 	}
 	return s.repo.Delete(ctx, id, wsID)
 }
+
+const (
+	DefaultTagLimit = 100
+	MaxTagLimit     = 500
+	// MaxStatsRange — максимальный период статистики настроения
+	MaxStatsRange = 2 * 366 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidPeriod   = errors.New("period must be day or week")
+	ErrInvalidRange    = errors.New("from must not be after to")
+	ErrRangeTooLong    = errors.New("date range is too long (max 2 years)")
+	ErrInvalidTagName  = errors.New("tag must not be empty")
+	ErrNothingToRename = errors.New("nothing to rename: from contains only the target tag")
+)
+
+// TagCounts — облако тегов воркспейса
+func (s *Service) TagCounts(ctx context.Context, workspaceID string, f journalRepo.StatsFilter, limit int) ([]model.JournalTagCount, error) {
+	wsID, err := uuid.Parse(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if f.From != nil && f.To != nil && f.From.After(*f.To) {
+		return nil, ErrInvalidRange
+	}
+	if limit <= 0 {
+		limit = DefaultTagLimit
+	}
+	return s.repo.TagCounts(ctx, wsID, f, min(limit, MaxTagLimit))
+}
+
+// MoodSeries — настроение по дням или неделям. По умолчанию to — сегодня, from — 30 дней (day) или 12 недель (week) назад.
+func (s *Service) MoodSeries(ctx context.Context, workspaceID string, f journalRepo.StatsFilter, period string) ([]model.JournalMoodPoint, error) {
+	wsID, err := uuid.Parse(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if period == "" {
+		period = model.MoodPeriodDay
+	}
+	if period != model.MoodPeriodDay && period != model.MoodPeriodWeek {
+		return nil, ErrInvalidPeriod
+	}
+	if f.To == nil {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		f.To = &today
+	}
+	if f.From == nil {
+		from := f.To.AddDate(0, 0, -29)
+		if period == model.MoodPeriodWeek {
+			// Начало недели (понедельник) 11 недель назад — 12 полных недель с текущей
+			from = f.To.AddDate(0, 0, -(int(f.To.Weekday())+6)%7-11*7)
+		}
+		f.From = &from
+	}
+	if f.From.After(*f.To) {
+		return nil, ErrInvalidRange
+	}
+	if f.To.Sub(*f.From) > MaxStatsRange {
+		return nil, ErrRangeTooLong
+	}
+	return s.repo.MoodSeries(ctx, wsID, f, period)
+}
+
+// RenameTags переименовывает теги from в to во всём воркспейсе; несколько from — слияние в один тег
+func (s *Service) RenameTags(ctx context.Context, workspaceID, userID string, dto model.RenameJournalTagsDto) (int64, error) {
+	wsID, err := uuid.Parse(workspaceID)
+	if err != nil {
+		return 0, err
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return 0, err
+	}
+	to := strings.TrimSpace(dto.To)
+	if to == "" {
+		return 0, ErrInvalidTagName
+	}
+	from := make([]string, 0, len(dto.From))
+	// from не обрезаем: так можно слить и теги, записанные с лишними пробелами
+	for _, t := range dto.From {
+		if strings.TrimSpace(t) == "" {
+			return 0, ErrInvalidTagName
+		}
+		if t != to && !slices.Contains(from, t) {
+			from = append(from, t)
+		}
+	}
+	if len(from) == 0 {
+		return 0, ErrNothingToRename
+	}
+	return s.repo.RenameTags(ctx, wsID, from, to, uid)
+}
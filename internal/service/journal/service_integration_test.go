@@ -0,0 +1,197 @@
+package journal_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	journalRepo "backend/internal/repository/journal"
+	linksRepo "backend/internal/repository/links"
+	"backend/internal/service/journal"
+	"backend/internal/service/links"
+	"backend/internal/testutil/pgtest"
+	"backend/pkg/query"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+func date(s string) *time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return &t
+}
+
+func TestJournalFiltersStatsAndRename(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc := journal.NewService(journalRepo.NewRepository(env.DB), links.NewService(linksRepo.NewRepository(env.DB)))
+	owner := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+
+	mood := func(m int) *int { return &m }
+	// 2026-03-02 — понедельник
+	for _, e := range []model.CreateJournalEntryDto{
+		{Date: "2026-03-02", Mood: mood(2), Tags: []string{"work", "sleep"}},
+		{Date: "2026-03-03", Mood: mood(4), Tags: []string{"work"}},
+		{Date: "2026-03-03", Mood: mood(5), Tags: []string{"sport", "Work"}},
+		{Date: "2026-03-09", Tags: []string{"job", "work", "sport"}},
+		{Date: "2026-03-10", Mood: mood(1)},
+	} {
+		e.Description = "entry " + e.Date
+		if _, err := svc.Create(ctx, ws.ID, owner.ID, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := func(q string) []string {
+		t.Helper()
+		values, _ := url.ParseQuery(q)
+		spec, err := query.Parse(values, journalRepo.ListSchema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, _, err := svc.List(ctx, ws.ID, spec)
+		if err != nil {
+			t.Fatalf("List(%s): %v", q, err)
+		}
+		dates := []string{}
+		for _, e := range entries {
+			dates = append(dates, e.Date)
+		}
+		return dates
+	}
+	for q, want := range map[string][]string{
+		"tags=sport,sleep&sort=date":              {"2026-03-02", "2026-03-03", "2026-03-09"},
+		"tagsAll=work,sport":                      {"2026-03-09"},
+		"moodMin=2&moodMax=4&sort=date":           {"2026-03-02", "2026-03-03"},
+		"dateFrom=2026-03-03&dateTo=2026-03-09":   {"2026-03-09", "2026-03-03", "2026-03-03"},
+		"dateFrom=2026-03-04&tags=work&moodMin=1": {},
+	} {
+		if got := list(q); !reflect.DeepEqual(got, want) {
+			t.Errorf("List(%s) = %v, want %v", q, got, want)
+		}
+	}
+
+	tags, err := svc.TagCounts(ctx, ws.ID, journalRepo.StatsFilter{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags[0].Tag != "work" || tags[0].Count != 3 || tags[0].LastUsed != "2026-03-09" {
+		t.Errorf("top tag = %+v", tags[0])
+	}
+
+	points, err := svc.MoodSeries(ctx, ws.ID, journalRepo.StatsFilter{From: date("2026-03-01"), To: date("2026-03-31")}, model.MoodPeriodWeek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []model.JournalMoodPoint{
+		{Period: "2026-03-02", Average: 11.0 / 3, Min: 2, Max: 5, Entries: 3},
+		{Period: "2026-03-09", Average: 1, Min: 1, Max: 1, Entries: 1},
+	}
+	if !reflect.DeepEqual(points, want) {
+		t.Errorf("weekly mood = %+v, want %+v", points, want)
+	}
+	if _, err := svc.MoodSeries(ctx, ws.ID, journalRepo.StatsFilter{}, "month"); !errors.Is(err, journal.ErrInvalidPeriod) {
+		t.Errorf("period=month: err = %v", err)
+	}
+
+	// Слияние: job и Work становятся work, дубликаты схлопываются на месте первого вхождения
+	n, err := svc.RenameTags(ctx, ws.ID, owner.ID, model.RenameJournalTagsDto{From: []string{"job", "Work", "work"}, To: "work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("renamed %d entries, want 2", n)
+	}
+	values := url.Values{"dateFrom": {"2026-03-03"}, "sort": {"createdAt"}}
+	spec, _ := query.Parse(values, journalRepo.ListSchema)
+	entries, _, _ := svc.List(ctx, ws.ID, spec)
+	var got [][]string
+	for _, e := range entries {
+		got = append(got, e.Tags)
+	}
+	if wantTags := [][]string{{"work"}, {"sport", "work"}, {"work", "sport"}}; !reflect.DeepEqual(got[:3], wantTags) {
+		t.Errorf("tags after merge = %v, want %v", got[:3], wantTags)
+	}
+	if _, err := svc.RenameTags(ctx, ws.ID, owner.ID, model.RenameJournalTagsDto{From: []string{"work"}, To: "work"}); !errors.Is(err, journal.ErrNothingToRename) {
+		t.Errorf("self rename: err = %v", err)
+	}
+}
+
+func TestJournalRevisions(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc := journal.NewService(journalRepo.NewRepository(env.DB), links.NewService(linksRepo.NewRepository(env.DB)))
+	owner := env.CreateUser(t)
+	editor := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+
+	entry, err := svc.Create(ctx, ws.ID, owner.ID, model.CreateJournalEntryDto{Date: "2026-03-02", Description: "a\nb\nc", Tags: []string{"x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := "a\nB\nc\nd"
+	if _, err := svc.Update(ctx, ws.ID, entry.ID, editor.ID, model.UpdateJournalEntryDto{Description: &text, Tags: []string{"x", "y"}}); err != nil {
+		t.Fatal(err)
+	}
+	// Повторное сохранение без изменений ревизию не добавляет
+	if _, err := svc.Update(ctx, ws.ID, entry.ID, editor.ID, model.UpdateJournalEntryDto{Description: &text}); err != nil {
+		t.Fatal(err)
+	}
+
+	revs, err := svc.ListRevisions(ctx, ws.ID, entry.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 2 || revs[0].Revision != 2 || revs[1].Revision != 1 {
+		t.Fatalf("revisions = %+v, want [2 1]", revs)
+	}
+	if revs[0].AuthorID == nil || *revs[0].AuthorID != editor.ID {
+		t.Errorf("revision 2 author = %v, want %s", revs[0].AuthorID, editor.ID)
+	}
+
+	diff, err := svc.DiffRevisions(ctx, ws.ID, entry.ID, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Added != 2 || diff.Removed != 1 {
+		t.Errorf("diff +%d -%d, want +2 -1", diff.Added, diff.Removed)
+	}
+	if len(diff.Changes) != 1 || diff.Changes[0].Field != "tags" {
+		t.Errorf("changes = %+v, want only tags", diff.Changes)
+	}
+	if _, err := svc.DiffRevisions(ctx, ws.ID, entry.ID, 1, 9); !errors.Is(err, journal.ErrRevisionNotFound) {
+		t.Errorf("diff to missing revision: err = %v", err)
+	}
+
+	restored, err := svc.RestoreRevision(ctx, ws.ID, entry.ID, owner.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Description != "a\nb\nc" || !reflect.DeepEqual(restored.Tags, []string{"x"}) {
+		t.Errorf("restored entry = %+v", restored)
+	}
+	rev3, err := svc.GetRevision(ctx, ws.ID, entry.ID, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rev3.RestoredFrom == nil || *rev3.RestoredFrom != 1 {
+		t.Errorf("revision 3 restoredFrom = %v, want 1", rev3.RestoredFrom)
+	}
+
+	// Переименование тегов — тоже правка: у записи появляется ревизия 4
+	if _, err := svc.RenameTags(ctx, ws.ID, owner.ID, model.RenameJournalTagsDto{From: []string{"x"}, To: "z"}); err != nil {
+		t.Fatal(err)
+	}
+	if rev, err := svc.GetRevision(ctx, ws.ID, entry.ID, 4); err != nil || !reflect.DeepEqual(rev.Tags, []string{"z"}) {
+		t.Errorf("revision 4 = %+v, %v", rev, err)
+	}
+
+	// История неизменяема на уровне БД
+	if _, err := env.DB.ExecContext(ctx, `UPDATE journal_entry_revisions SET description = 'x' WHERE entry_id = $1`, entry.ID); err == nil {
+		t.Error("revision update succeeded, want trigger error")
+	}
+}
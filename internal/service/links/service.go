@@ -0,0 +1,136 @@
+package links
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+
+	"backend/internal/model"
+	linksRepo "backend/internal/repository/links"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownType    = errors.New("unknown entity type")
+	ErrEntityNotFound = errors.New("linked entity not found")
+	ErrSelfLink       = errors.New("entity cannot be linked to itself")
+	ErrWikiLink       = errors.New("link comes from [[wiki]] text: edit the text to remove it")
+)
+
+type Service struct {
+	repo *linksRepo.Repository
+}
+
+func NewService(repo *linksRepo.Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// SyncWiki пересобирает wiki-связи источника по его тексту. Вызывается после каждого сохранения заметки или записи дневника.
+func (s *Service) SyncWiki(ctx context.Context, workspaceID, sourceType, sourceID, text string) error {
+	wsID, id, err := parseIDs(workspaceID, sourceID)
+	if err != nil {
+		return err
+	}
+	return s.repo.ReplaceWiki(ctx, wsID, sourceType, id, parseWikiLinks(text))
+}
+
+// Links — связи сущности в обе стороны. sql.ErrNoRows — сущности нет в воркспейсе.
+func (s *Service) Links(ctx context.Context, workspaceID, entityType, entityID string) (*model.EntityLinks, error) {
+	wsID, id, err := s.requireEntity(ctx, workspaceID, entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.repo.Outgoing(ctx, wsID, entityType, id)
+	if err != nil {
+		return nil, err
+	}
+	back, err := s.repo.Backlinks(ctx, wsID, entityType, id)
+	if err != nil {
+		return nil, err
+	}
+	return &model.EntityLinks{Outgoing: out, Backlinks: back}, nil
+}
+
+// Backlinks — что ссылается на сущность. sql.ErrNoRows — сущности нет в воркспейсе.
+func (s *Service) Backlinks(ctx context.Context, workspaceID, entityType, entityID string) ([]model.LinkedEntity, error) {
+	wsID, id, err := s.requireEntity(ctx, workspaceID, entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Backlinks(ctx, wsID, entityType, id)
+}
+
+// Create добавляет ручную связь source -> target. Обе сущности должны быть в воркспейсе.
+func (s *Service) Create(ctx context.Context, workspaceID, userID string, dto model.CreateLinkDto) (*model.LinkedEntity, error) {
+	if dto.SourceType == dto.TargetType && dto.SourceID == dto.TargetID {
+		return nil, ErrSelfLink
+	}
+	wsID, sourceID, err := s.requireEntity(ctx, workspaceID, dto.SourceType, dto.SourceID)
+	if err == sql.ErrNoRows {
+		return nil, ErrEntityNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, targetID, err := s.requireEntity(ctx, workspaceID, dto.TargetType, dto.TargetID)
+	if err == sql.ErrNoRows {
+		return nil, ErrEntityNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	linkID, err := s.repo.CreateManual(ctx, wsID, dto.SourceType, sourceID, dto.TargetType, targetID, uuid.MustParse(userID))
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Target(ctx, wsID, linkID)
+}
+
+// Delete удаляет ручную связь. Wiki-связь удалить нельзя (ErrWikiLink): она вернётся при следующем сохранении текста.
+func (s *Service) Delete(ctx context.Context, workspaceID, linkID string) error {
+	wsID, id, err := parseIDs(workspaceID, linkID)
+	if err != nil {
+		return sql.ErrNoRows
+	}
+	origin, err := s.repo.Origin(ctx, wsID, id)
+	if err != nil {
+		return err
+	}
+	if origin == model.LinkOriginWiki {
+		return ErrWikiLink
+	}
+	return s.repo.DeleteManual(ctx, wsID, id)
+}
+
+// requireEntity — ErrUnknownType или sql.ErrNoRows, если сущности нет в воркспейсе (в том числе если id не UUID)
+func (s *Service) requireEntity(ctx context.Context, workspaceID, entityType, entityID string) (uuid.UUID, uuid.UUID, error) {
+	if !slices.Contains(model.SearchTypes, entityType) {
+		return uuid.Nil, uuid.Nil, ErrUnknownType
+	}
+	wsID, id, err := parseIDs(workspaceID, entityID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, sql.ErrNoRows
+	}
+	ok, err := s.repo.Exists(ctx, wsID, entityType, id)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	if !ok {
+		return uuid.Nil, uuid.Nil, sql.ErrNoRows
+	}
+	return wsID, id, nil
+}
+
+func parseIDs(workspaceID, id string) (uuid.UUID, uuid.UUID, error) {
+	wsID, err := uuid.Parse(workspaceID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return wsID, uid, nil
+}
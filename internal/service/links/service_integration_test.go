@@ -0,0 +1,152 @@
+package links_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend/internal/model"
+	journalRepo "backend/internal/repository/journal"
+	linksRepo "backend/internal/repository/links"
+	masterRepo "backend/internal/repository/master"
+	notesRepo "backend/internal/repository/notes"
+	"backend/internal/service/journal"
+	"backend/internal/service/links"
+	"backend/internal/service/master"
+	"backend/internal/service/notes"
+	"backend/internal/testutil/pgtest"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+func TestWikiLinksBacklinksAndCleanup(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc := links.NewService(linksRepo.NewRepository(env.DB))
+	notesSvc := notes.NewService(notesRepo.NewRepository(env.DB), svc)
+	journalSvc := journal.NewService(journalRepo.NewRepository(env.DB), svc)
+	masterSvc := master.NewService(masterRepo.NewRepository(env.DB))
+	owner := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+	other := env.CreateWorkspace(t, owner)
+
+	habit := env.CreateHabit(t, owner, ws, model.CreateHabitDto{Title: "Бег"})
+	cp := &model.Counterparty{WorkspaceID: ws.ID, Name: "ООО Ромашка", Type: "client"}
+	if err := masterSvc.CreateCounterparty(ctx, cp); err != nil {
+		t.Fatal(err)
+	}
+	note := func(workspaceID, title, content string) *model.Note {
+		t.Helper()
+		n, err := notesSvc.Create(ctx, workspaceID, owner.ID, model.CreateNoteDto{Title: title, Content: content})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	plan := note(ws.ID, "План", "")
+	note(other.ID, "Идеи", "") // из другого воркспейса не связывается
+	ideas := note(ws.ID, "Идеи", "")
+
+	entry, err := journalSvc.Create(ctx, ws.ID, owner.ID, model.CreateJournalEntryDto{
+		Description: "Сегодня [[habit:бег]], созвон с [[counterparty:ООО Ромашка]], см. [[план|план недели]] и [[Нет такой]]",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets := func(typ, id string) []string {
+		t.Helper()
+		l, err := svc.Links(ctx, ws.ID, typ, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, e := range l.Outgoing {
+			out = append(out, e.Type+":"+e.Title)
+		}
+		return out
+	}
+	if got := targets(model.SearchTypeJournal, entry.ID); len(got) != 3 || got[0] != "habit:Бег" || got[1] != "counterparty:ООО Ромашка" || got[2] != "note:План" {
+		t.Fatalf("journal outgoing = %v", got)
+	}
+
+	back, err := svc.Backlinks(ctx, ws.ID, model.SearchTypeHabit, habit.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back) != 1 || back[0].ID != entry.ID || back[0].Title != entry.Date || back[0].Origin != model.LinkOriginWiki {
+		t.Fatalf("habit backlinks = %+v", back)
+	}
+
+	// Правка текста пересобирает wiki-связи; ссылка на себя пропускается
+	if _, err := notesSvc.Update(ctx, ws.ID, ideas.ID, model.UpdateNoteDto{Title: "Идеи", Content: "[[Идеи]] [[journal:" + entry.Date + "]]"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := targets(model.SearchTypeNote, ideas.ID); len(got) != 1 || got[0] != "journal:"+entry.Date {
+		t.Fatalf("note outgoing = %v", got)
+	}
+	desc := "только [[Идеи]]"
+	if _, err := journalSvc.Update(ctx, ws.ID, entry.ID, owner.ID, model.UpdateJournalEntryDto{Description: &desc}); err != nil {
+		t.Fatal(err)
+	}
+	if got := targets(model.SearchTypeJournal, entry.ID); len(got) != 1 || got[0] != "note:Идеи" {
+		t.Fatalf("journal outgoing after edit = %v", got)
+	}
+
+	// Ручные связи
+	manual, err := svc.Create(ctx, ws.ID, owner.ID, model.CreateLinkDto{
+		SourceType: model.SearchTypeHabit, SourceID: habit.ID, TargetType: model.SearchTypeNote, TargetID: plan.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manual.Title != "План" || manual.Origin != model.LinkOriginManual {
+		t.Fatalf("manual link = %+v", manual)
+	}
+	dup := model.CreateLinkDto{SourceType: model.SearchTypeHabit, SourceID: habit.ID, TargetType: model.SearchTypeNote, TargetID: plan.ID}
+	if _, err := svc.Create(ctx, ws.ID, owner.ID, dup); !errors.Is(err, linksRepo.ErrLinkExists) {
+		t.Fatalf("duplicate: err = %v", err)
+	}
+	self := model.CreateLinkDto{SourceType: model.SearchTypeNote, SourceID: plan.ID, TargetType: model.SearchTypeNote, TargetID: plan.ID}
+	if _, err := svc.Create(ctx, ws.ID, owner.ID, self); !errors.Is(err, links.ErrSelfLink) {
+		t.Fatalf("self link: err = %v", err)
+	}
+	foreign := model.CreateLinkDto{SourceType: model.SearchTypeNote, SourceID: plan.ID, TargetType: model.SearchTypeNote, TargetID: ws.ID}
+	if _, err := svc.Create(ctx, ws.ID, owner.ID, foreign); !errors.Is(err, links.ErrEntityNotFound) {
+		t.Fatalf("unknown target: err = %v", err)
+	}
+
+	wiki, err := svc.Backlinks(ctx, ws.ID, model.SearchTypeNote, ideas.ID)
+	if err != nil || len(wiki) != 1 {
+		t.Fatalf("ideas backlinks = %+v, %v", wiki, err)
+	}
+	if err := svc.Delete(ctx, ws.ID, wiki[0].LinkID); !errors.Is(err, links.ErrWikiLink) {
+		t.Fatalf("delete wiki link: err = %v", err)
+	}
+	if err := svc.Delete(ctx, ws.ID, manual.LinkID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Удаление сущности убирает её связи в обе стороны
+	if _, err := svc.Create(ctx, ws.ID, owner.ID, dup); err != nil {
+		t.Fatal(err)
+	}
+	if err := notesSvc.Delete(ctx, ws.ID, plan.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := notesSvc.Delete(ctx, ws.ID, ideas.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := targets(model.SearchTypeHabit, habit.ID); len(got) != 0 {
+		t.Fatalf("habit outgoing after note deleted = %v", got)
+	}
+	if got := targets(model.SearchTypeJournal, entry.ID); len(got) != 0 {
+		t.Fatalf("journal outgoing after note deleted = %v", got)
+	}
+	var left int
+	if err := env.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM entity_links WHERE workspace_id = $1`, ws.ID).Scan(&left); err != nil {
+		t.Fatal(err)
+	}
+	if left != 0 {
+		t.Fatalf("%d links left", left)
+	}
+}
@@ -0,0 +1,52 @@
+package links
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"backend/internal/model"
+	linksRepo "backend/internal/repository/links"
+)
+
+const (
+	// MaxWikiLinks — сколько [[ссылок]] из одного текста превращаются в связи; остальные игнорируются
+	MaxWikiLinks = 100
+	maxRefLength = 500
+)
+
+var wikiLinkRe = regexp.MustCompile(`\[\[([^\[\]\n]+)\]\]`)
+
+// parseWikiLinks извлекает [[ссылки]] из текста:
+//
+//	[[Заголовок]]                — заметка по заголовку
+//	[[habit:Бег]]                — привычка, контрагент (counterparty:) или заметка (note:) по названию
+//	[[journal:2024-05-01]]       — запись дневника за дату
+//	[[note:<uuid>]]              — любая сущность по id
+//	[[Заголовок|как показать]]   — текст после | — подпись для клиента, на связь не влияет
+//
+// Регистр названий не важен, повторы схлопываются.
+func parseWikiLinks(text string) []linksRepo.Ref {
+	var refs []linksRepo.Ref
+	for _, m := range wikiLinkRe.FindAllStringSubmatch(text, -1) {
+		inner, _, _ := strings.Cut(m[1], "|")
+		ref := linksRepo.Ref{Type: model.SearchTypeNote, Key: strings.TrimSpace(inner)}
+		if prefix, rest, ok := strings.Cut(ref.Key, ":"); ok {
+			if t := strings.ToLower(strings.TrimSpace(prefix)); slices.Contains(model.SearchTypes, t) {
+				ref = linksRepo.Ref{Type: t, Key: strings.TrimSpace(rest)}
+			}
+		}
+		if ref.Key == "" || utf8.RuneCountInString(ref.Key) > maxRefLength {
+			continue
+		}
+		if slices.ContainsFunc(refs, func(r linksRepo.Ref) bool { return r.Type == ref.Type && strings.EqualFold(r.Key, ref.Key) }) {
+			continue
+		}
+		refs = append(refs, ref)
+		if len(refs) == MaxWikiLinks {
+			break
+		}
+	}
+	return refs
+}
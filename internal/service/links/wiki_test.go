@@ -0,0 +1,44 @@
+package links
+
+import (
+	"reflect"
+	"testing"
+
+	linksRepo "backend/internal/repository/links"
+)
+
+func TestParseWikiLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []linksRepo.Ref
+	}{
+		{"no links", "просто текст [одна скобка]", nil},
+		{"note by title", "см. [[План на неделю]]", []linksRepo.Ref{{Type: "note", Key: "План на неделю"}}},
+		{"typed", "[[habit: Бег ]] и [[journal:2024-05-01]] и [[counterparty:ООО Ромашка]]", []linksRepo.Ref{
+			{Type: "habit", Key: "Бег"}, {Type: "journal", Key: "2024-05-01"}, {Type: "counterparty", Key: "ООО Ромашка"},
+		}},
+		{"type prefix is case-insensitive", "[[Habit:Бег]]", []linksRepo.Ref{{Type: "habit", Key: "Бег"}}},
+		{"unknown prefix is part of the title", "[[Глава 1: начало]]", []linksRepo.Ref{{Type: "note", Key: "Глава 1: начало"}}},
+		{"label", "[[note:Идеи|мои идеи]]", []linksRepo.Ref{{Type: "note", Key: "Идеи"}}},
+		{"duplicates", "[[Идеи]] [[идеи]] [[note:ИДЕИ|x]]", []linksRepo.Ref{{Type: "note", Key: "Идеи"}}},
+		{"empty and multiline", "[[ ]] [[habit:]] [[a\nb]]", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseWikiLinks(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseWikiLinks(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWikiLinksLimit(t *testing.T) {
+	text := ""
+	for i := 0; i < MaxWikiLinks+10; i++ {
+		text += "[[note " + string(rune('a'+i%26)) + string(rune('a'+i/26)) + "]] "
+	}
+	if got := len(parseWikiLinks(text)); got != MaxWikiLinks {
+		t.Errorf("got %d links, want %d", got, MaxWikiLinks)
+	}
+}
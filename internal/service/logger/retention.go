@@ -0,0 +1,187 @@
+package logger
+
+import (
+	"backend/internal/config"
+	"backend/internal/model"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy — сколько хранить лог-файлы и сырые строки request_logs. 0 — хранить без ограничений.
+type RetentionPolicy struct {
+	// Compress сжимает в .gz файлы прошедших дней, полностью загруженные в БД
+	Compress bool
+	// FileDays — через сколько дней файлы (.log и .log.gz) удаляются
+	FileDays int
+	// DBDays — через сколько дней строки request_logs сворачиваются в дневные агрегаты и удаляются
+	DBDays int
+}
+
+// NewRetentionPolicy собирает политику из конфига логов
+func NewRetentionPolicy(cfg config.LogsConfig) RetentionPolicy {
+	return RetentionPolicy{
+		Compress: cfg.Compress,
+		FileDays: cfg.FileRetentionDays,
+		DBDays:   cfg.DBRetentionDays,
+	}
+}
+
+// RetentionResult — итог одного прохода ретеншна
+type RetentionResult struct {
+	Ingest          IngestResult `json:"ingest"`
+	FilesCompressed int          `json:"files_compressed"`
+	FilesDeleted    int          `json:"files_deleted"`
+	RolledUpBefore  string       `json:"rolled_up_before,omitempty"`
+	AggregateRows   int64        `json:"aggregate_rows"`
+	PrunedRows      int64        `json:"pruned_rows"`
+}
+
+// Retention возвращает текущую политику
+func (s *Service) Retention() RetentionPolicy {
+	return s.retention
+}
+
+// ApplyRetention догружает новые строки, затем сжимает и удаляет старые файлы
+// и сворачивает старые строки request_logs в request_log_daily_stats.
+func (s *Service) ApplyRetention(ctx context.Context) (RetentionResult, error) {
+	var res RetentionResult
+	today := truncateDay(time.Now())
+
+	s.fileMu.Lock()
+	ingest, err := s.ingest(ctx)
+	res.Ingest = ingest
+	if err == nil {
+		err = s.applyFileRetention(ctx, today, &res)
+	}
+	s.fileMu.Unlock()
+	if err != nil {
+		return res, err
+	}
+
+	if s.retention.DBDays > 0 {
+		before := today.AddDate(0, 0, -s.retention.DBDays)
+		rolled, pruned, err := s.repo.RollupBefore(ctx, before)
+		if err != nil {
+			return res, err
+		}
+		res.RolledUpBefore = before.Format("2006-01-02")
+		res.AggregateRows = rolled
+		res.PrunedRows = pruned
+	}
+
+	return res, nil
+}
+
+// DailyStats возвращает дневные агрегаты по маршрутам
+func (s *Service) DailyStats(ctx context.Context, f model.LogFilter) ([]model.DailyRouteStats, error) {
+	return s.repo.DailyStats(ctx, f)
+}
+
+// applyFileRetention вызывается под fileMu
+func (s *Service) applyFileRetention(ctx context.Context, today time.Time, res *RetentionResult) error {
+	files, err := s.listLogFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		day, ok := logFileDay(path)
+		if !ok || !day.Before(today) {
+			continue
+		}
+
+		if s.retention.FileDays > 0 && day.Before(today.AddDate(0, 0, -s.retention.FileDays)) {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			// Чекпоинт нужен, пока жив хотя бы один вариант файла
+			if !fileExists(strings.TrimSuffix(path, gzipExt)) && !fileExists(strings.TrimSuffix(path, gzipExt)+gzipExt) {
+				if err := s.repo.DeleteCheckpoint(ctx, checkpointName(path)); err != nil {
+					return err
+				}
+			}
+			res.FilesDeleted++
+			continue
+		}
+
+		if !s.retention.Compress || strings.HasSuffix(path, gzipExt) {
+			continue
+		}
+		// Сжимаем только полностью загруженный файл: иначе незагруженный хвост пришлось бы читать из .gz
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		offset, err := s.repo.GetCheckpoint(ctx, checkpointName(path))
+		if err != nil {
+			return err
+		}
+		if offset < info.Size() {
+			log.Printf("log retention: %s загружен не полностью (%d из %d байт), не сжимаем", filepath.Base(path), offset, info.Size())
+			continue
+		}
+		if err := compressFile(path); err != nil {
+			return fmt.Errorf("compress %s: %w", filepath.Base(path), err)
+		}
+		res.FilesCompressed++
+	}
+	return nil
+}
+
+// compressFile пишет path.gz через временный файл и удаляет исходный
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmpPath := path + gzipExt + ".tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	gz := gzip.NewWriter(dst)
+	gz.Name = filepath.Base(path)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path+gzipExt); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
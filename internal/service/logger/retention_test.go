@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "requests-2026-10-17.log")
+	content := logLine(1) + logLine(2)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := compressFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if fileExists(path) {
+		t.Error("original file left after compress")
+	}
+	if fileExists(path + gzipExt + ".tmp") {
+		t.Error("temporary file left after compress")
+	}
+
+	f, err := os.Open(path + gzipExt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != content || gz.Name != filepath.Base(path) {
+		t.Fatalf("compressed content = %q, name = %q", got, gz.Name)
+	}
+
+	// Загрузка продолжает сжатый файл с того же чекпоинта: размер из ISIZE совпадает с исходным
+	if size, err := logFileSize(path + gzipExt); err != nil || size != int64(len(content)) {
+		t.Fatalf("logFileSize = %d, %v", size, err)
+	}
+}
+
+func TestCompressFileMissing(t *testing.T) {
+	dir := t.TempDir()
+	if err := compressFile(filepath.Join(dir, "requests-2026-10-17.log")); err == nil {
+		t.Fatal("compressFile of a missing file: no error")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("files left: %v", entries)
+	}
+}
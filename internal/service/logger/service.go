@@ -3,258 +3,467 @@ package logger
 import (
 	"backend/internal/model"
 	"backend/internal/repository/logger"
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
+const (
+	logFilePrefix = "requests-"
+	logFileExt    = ".log"
+	gzipExt       = ".gz"
+
+	// maxLogLineSize — максимальная длина одной JSON-записи в лог-файле
+	maxLogLineSize = 1024 * 1024
+
+	// ingestBatchSize — сколько строк вставляется в одной транзакции
+	ingestBatchSize = 500
+)
+
+// errTruncated — содержимое файла короче сохранённого чекпоинта: файл обрезали или заменили
+var errTruncated = errors.New("log file is shorter than its checkpoint")
+
 type Service struct {
-	repo        *logger.Repository
-	logDir      string
-	currentFile *os.File
-	currentDate string
-	mu          sync.Mutex
+	repo      *logger.Repository
+	logDir    string
+	retention RetentionPolicy
+	writer    *dailyFileWriter
+	slogger   *slog.Logger
+
+	// fileMu не даёт загрузке и сжатию/удалению файлов работать одновременно
+	fileMu sync.Mutex
 }
 
-func NewService(repo *logger.Repository, logDir string) *Service {
+func NewService(repo *logger.Repository, logDir string, retention RetentionPolicy) *Service {
 	// Создаем директорию для логов
 	os.MkdirAll(logDir, 0755)
 
+	writer := &dailyFileWriter{dir: logDir}
 	return &Service{
-		repo:   repo,
-		logDir: logDir,
+		repo:      repo,
+		logDir:    logDir,
+		retention: retention,
+		writer:    writer,
+		slogger:   slog.New(slog.NewJSONHandler(writer, &slog.HandlerOptions{Level: slog.LevelInfo})),
 	}
 }
 
-// WriteLog записывает строку лога в файл
-func (s *Service) WriteLog(logLine string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// Logger возвращает slog-логгер, пишущий JSON-записи в файл текущего дня
+func (s *Service) Logger() *slog.Logger {
+	return s.slogger
+}
 
-	// Проверяем, нужно ли создать новый файл для нового дня
-	today := time.Now().Format("2006-01-02")
-	if s.currentDate != today {
-		if s.currentFile != nil {
-			s.currentFile.Close()
+// Close сбрасывает на диск и закрывает текущий лог-файл. Вызывать после остановки HTTP-сервера:
+// последующие записи вернут ошибку.
+func (s *Service) Close() error {
+	return s.writer.Close()
+}
+
+// IngestResult — итог загрузки лог-файлов в БД
+type IngestResult struct {
+	Files    int   `json:"files"`
+	Lines    int   `json:"lines"`
+	Inserted int64 `json:"inserted"`
+	Skipped  int   `json:"skipped"`
+}
+
+func (r *IngestResult) add(other IngestResult) {
+	r.Files += other.Files
+	r.Lines += other.Lines
+	r.Inserted += other.Inserted
+	r.Skipped += other.Skipped
+}
+
+// Ingest догружает в БД все лог-файлы начиная с сохранённого чекпоинта.
+// Файлы читаются потоково, незавершённая последняя строка остаётся до следующего запуска,
+// поэтому вызывать можно сколько угодно часто — в том числе для файла текущего дня.
+// Сжатые файлы (.log.gz) читаются так же: чекпоинт хранится в байтах несжатого содержимого.
+func (s *Service) Ingest(ctx context.Context) (IngestResult, error) {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	return s.ingest(ctx)
+}
+
+func (s *Service) ingest(ctx context.Context) (IngestResult, error) {
+	var total IngestResult
+
+	files, err := s.listLogFiles()
+	if err != nil {
+		return total, err
+	}
+
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return total, err
 		}
-		filename := filepath.Join(s.logDir, "requests-"+today+".log")
-		file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		name := checkpointName(path)
+		size, err := logFileSize(path)
+		if err != nil {
+			continue
+		}
+		offset, err := s.repo.GetCheckpoint(ctx, name)
+		if err != nil {
+			return total, fmt.Errorf("get checkpoint %s: %w", name, err)
+		}
+		if loaded(path, size, offset) {
+			continue
+		}
+		res, err := s.ingestFile(ctx, path, offset)
+		if errors.Is(err, errTruncated) {
+			// Файл обрезали или заменили — читаем его заново с начала. Уже загруженные строки
+			// отбросятся по content_hash; чекпоинт удаляется, иначе GREATEST не дал бы ему уменьшиться.
+			log.Printf("log ingest: %s короче чекпоинта (%d из %d байт), читаем заново", name, size, offset)
+			if err := s.repo.DeleteCheckpoint(ctx, name); err != nil {
+				return total, fmt.Errorf("reset checkpoint %s: %w", name, err)
+			}
+			res, err = s.ingestFile(ctx, path, 0)
+		}
+		total.add(res)
 		if err != nil {
-			return // Игнорируем ошибки записи
+			return total, fmt.Errorf("ingest %s: %w", name, err)
 		}
-		s.currentFile = file
-		s.currentDate = today
 	}
+	return total, nil
+}
 
-	// Записываем в файл
-	if s.currentFile != nil {
-		s.currentFile.WriteString(logLine + "\n")
-		s.currentFile.Sync() // Синхронизируем сразу
+// Backfill заново читает лог-файлы за диапазон дат (включительно) с начала файла.
+// Уже загруженные записи отбрасываются по content_hash, чекпоинты назад не откатываются.
+func (s *Service) Backfill(ctx context.Context, from, to time.Time) (IngestResult, error) {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+
+	var total IngestResult
+	if to.Before(from) {
+		from, to = to, from
+	}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		path := s.logFilePath(day)
+		if _, err := os.Stat(path); err != nil {
+			if !os.IsNotExist(err) {
+				return total, err
+			}
+			// Файл мог быть уже сжат ретеншном
+			path += gzipExt
+			if _, err := os.Stat(path); err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return total, err
+			}
+		}
+		res, err := s.ingestFile(ctx, path, 0)
+		total.add(res)
+		if err != nil {
+			return total, fmt.Errorf("backfill %s: %w", filepath.Base(path), err)
+		}
 	}
+	return total, nil
 }
 
-// SyncToDB синхронизирует вчерашний лог-файл в БД
-func (s *Service) SyncToDB() error {
-	// Берем вчерашний день
-	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
-	filename := filepath.Join(s.logDir, "requests-"+yesterday+".log")
-
-	fmt.Println("filename", filename)
+// List возвращает страницу логов из БД по фильтру и общее количество подходящих записей
+func (s *Service) List(ctx context.Context, f model.LogFilter) ([]*model.LogEntry, int64, error) {
+	return s.repo.List(ctx, f)
+}
 
-	// Читаем файл
-	data, err := os.ReadFile(filename)
-	fmt.Println("SyncToDB", data, err)
+// LatencyByRoute — p50/p95/p99 длительности по маршрутам
+func (s *Service) LatencyByRoute(ctx context.Context, f model.LogFilter) ([]model.RouteLatencyStats, error) {
+	return s.repo.LatencyByRoute(ctx, f)
+}
 
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return err
-	}
+// ErrorsByRoute — доля 4xx/5xx по маршрутам
+func (s *Service) ErrorsByRoute(ctx context.Context, f model.LogFilter) ([]model.RouteErrorStats, error) {
+	return s.repo.ErrorsByRoute(ctx, f)
+}
 
-	// Парсим и записываем в БД
-	entries := parseLogFile(string(data))
+// TopClients — самые активные клиенты
+func (s *Service) TopClients(ctx context.Context, f model.LogFilter) ([]model.ClientStats, error) {
+	return s.repo.TopClients(ctx, f)
+}
 
-	fmt.Println("SyncToDB", entries)
+func (s *Service) logFilePath(day time.Time) string {
+	return filepath.Join(s.logDir, logFilePrefix+day.Format("2006-01-02")+logFileExt)
+}
 
-	if len(entries) > 0 {
-		return s.repo.BatchInsert(entries)
+// listLogFiles возвращает все лог-файлы (.log и .log.gz), отсортированные по дате
+func (s *Service) listLogFiles() ([]string, error) {
+	plain, err := filepath.Glob(filepath.Join(s.logDir, logFilePrefix+"*"+logFileExt))
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := filepath.Glob(filepath.Join(s.logDir, logFilePrefix+"*"+logFileExt+gzipExt))
+	if err != nil {
+		return nil, err
 	}
+	files := append(plain, compressed...)
+	sort.Strings(files)
+	return files, nil
+}
 
-	return nil
+// checkpointName — имя чекпоинта файла: у сжатого файла тот же чекпоинт, что был у исходного
+func checkpointName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), gzipExt)
 }
 
-// GetLogsByDate возвращает логи за определенную дату из БД
-func (s *Service) GetLogsByDate(ctx context.Context, date time.Time) ([]*model.LogEntry, error) {
-	return s.repo.GetLogsByDate(ctx, date)
+// logFileDay возвращает дату из имени файла requests-YYYY-MM-DD.log[.gz]
+func logFileDay(path string) (time.Time, bool) {
+	name := strings.TrimSuffix(checkpointName(path), logFileExt)
+	day, err := time.ParseInLocation("2006-01-02", strings.TrimPrefix(name, logFilePrefix), time.Local)
+	return day, err == nil
 }
 
-// parseLogFile парсит файл логов
-func parseLogFile(content string) []*model.LogEntry {
-	entries := make([]*model.LogEntry, 0)
-
-	// Разбиваем на строки
-	lines := []string{}
-	current := ""
-	for _, char := range content {
-		if char == '\n' {
-			if current != "" {
-				lines = append(lines, current)
-				current = ""
-			}
-		} else {
-			current += string(char)
-		}
-	}
-	if current != "" {
-		lines = append(lines, current)
+// loaded сообщает, что файл загружен до конца. ISIZE сжатого файла — размер по модулю 2^32,
+// поэтому для .gz сравниваются младшие 32 бита: иначе файл больше 4 ГиБ читался бы заново при каждом проходе.
+func loaded(path string, size, offset int64) bool {
+	if strings.HasSuffix(path, gzipExt) {
+		return uint32(size) == uint32(offset)
 	}
+	return size == offset
+}
 
-	// Парсим каждую строку
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		entry := parseLogLine(line)
-		if entry != nil {
-			entries = append(entries, entry)
+// logFileSize — размер несжатого содержимого. Для .gz берётся из поля ISIZE в конце файла
+// (размер по модулю 2^32, см. loaded).
+func logFileSize(path string) (int64, error) {
+	if !strings.HasSuffix(path, gzipExt) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return 0, err
 		}
+		return info.Size(), nil
 	}
 
-	return entries
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var trailer [4]byte
+	if _, err := file.Seek(-4, io.SeekEnd); err != nil {
+		return 0, err
+	}
+	if _, err := io.ReadFull(file, trailer[:]); err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint32(trailer[:])), nil
 }
 
-// parseLogLine парсит одну строку: [GIN] 2006/01/02 - 15:04:05 | 200 | 4.83725ms | ::1 | GET      "/health"
-func parseLogLine(line string) *model.LogEntry {
-	// Разбиваем по "|"
-	parts := []string{}
-	current := ""
-	for _, char := range line {
-		if char == '|' {
-			parts = append(parts, trimSpace(current))
-			current = ""
-		} else {
-			current += string(char)
-		}
+// openLogFile открывает лог-файл (в том числе .gz) и пропускает первые offset байт содержимого.
+// Если содержимое короче offset, возвращает errTruncated.
+func openLogFile(path string, offset int64) (io.Reader, io.Closer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
 	}
-	if current != "" {
-		parts = append(parts, trimSpace(current))
+
+	if !strings.HasSuffix(path, gzipExt) {
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		if info.Size() < offset {
+			file.Close()
+			return nil, nil, errTruncated
+		}
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return file, file, nil
 	}
 
-	if len(parts) < 5 {
-		return nil
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if _, err := io.CopyN(io.Discard, gz, offset); err != nil {
+		file.Close()
+		if err == io.EOF {
+			return nil, nil, errTruncated
+		}
+		return nil, nil, err
 	}
+	return gz, file, nil
+}
 
-	// Парсим timestamp из первой части: [GIN] 2006/01/02 - 15:04:05
-	timestampStr := parts[0]
-	timestamp, err := time.Parse("[GIN] 2006/01/02 - 15:04:05", timestampStr)
+// ingestFile читает файл с offset пачками по ingestBatchSize строк. Каждая пачка вставляется
+// вместе с новым чекпоинтом в одной транзакции, так что падение посреди файла ничего не теряет.
+func (s *Service) ingestFile(ctx context.Context, path string, offset int64) (IngestResult, error) {
+	res := IngestResult{Files: 1}
+	name := checkpointName(path)
+
+	src, closer, err := openLogFile(path, offset)
 	if err != nil {
-		timestamp = time.Now() // Если не удалось распарсить, используем текущее время
+		return res, err
 	}
+	defer closer.Close()
 
-	// Парсим status code
-	statusCode := parseInt(parts[1])
+	reader := bufio.NewReaderSize(src, 64*1024)
+	batch := make([]*model.LogEntry, 0, ingestBatchSize)
+	flushedOffset := offset
 
-	// Парсим duration
-	durationStr := parts[2]
-	duration := parseDuration(durationStr)
+	flush := func() error {
+		if offset == flushedOffset {
+			return nil
+		}
+		inserted, err := s.repo.InsertBatch(ctx, name, batch, offset)
+		if err != nil {
+			return err
+		}
+		res.Inserted += inserted
+		flushedOffset = offset
+		batch = batch[:0]
+		return nil
+	}
 
-	// IP
-	clientIP := parts[3]
+	for {
+		line, n, err := readLine(reader)
+		if err == io.EOF {
+			// Строка без перевода строки ещё дописывается — заберём её в следующий раз
+			break
+		}
+		if err != nil {
+			return res, err
+		}
+		offset += n
+		res.Lines++
 
-	// Method и path из последней части
-	method, path := parseMethodPath(parts[4])
+		var entry *model.LogEntry
+		if line != nil {
+			entry = parseLogRecord(bytes.TrimRight(line, "\r\n"))
+		}
+		if entry == nil {
+			res.Skipped++
+		} else {
+			batch = append(batch, entry)
+		}
 
-	return &model.LogEntry{
-		Timestamp:  timestamp,
-		StatusCode: statusCode,
-		Duration:   duration,
-		ClientIP:   clientIP,
-		Method:     method,
-		Path:       path,
-		RawLog:     line,
+		if len(batch) >= ingestBatchSize {
+			if err := flush(); err != nil {
+				return res, err
+			}
+		}
 	}
-}
 
-func trimSpace(s string) string {
-	start := 0
-	for start < len(s) && s[start] == ' ' {
-		start++
-	}
-	end := len(s)
-	for end > start && s[end-1] == ' ' {
-		end--
-	}
-	return s[start:end]
+	return res, flush()
 }
 
-func parseInt(s string) int {
-	result := 0
-	for _, char := range s {
-		if char >= '0' && char <= '9' {
-			result = result*10 + int(char-'0')
+// readLine читает строку вместе с '\n' и возвращает её и число прочитанных байт. Строка длиннее maxLogLineSize
+// в память не собирается: её байты пропускаются до перевода строки или конца файла, line == nil. Такая строка
+// без '\n' тоже пропускается — иначе повреждённый хвост файла перечитывался бы с того же чекпоинта при каждой загрузке.
+func readLine(r *bufio.Reader) (line []byte, n int64, err error) {
+	oversized := false
+	for {
+		chunk, err := r.ReadSlice('\n')
+		n += int64(len(chunk))
+		if n > maxLogLineSize {
+			oversized, line = true, nil
+		} else {
+			line = append(line, chunk...)
+		}
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case oversized && err == io.EOF:
+			return nil, n, nil
+		case err != nil:
+			return line, n, err
 		}
+		return line, n, nil
 	}
-	return result
 }
 
-func parseDuration(s string) time.Duration {
-	// Парсим "4.83725ms" или "12.875µs"
-	var value float64
-	divisor := 1.0
-	decimal := false
-
-	for _, char := range s {
-		if char >= '0' && char <= '9' {
-			if decimal {
-				divisor *= 10
-				value += float64(char-'0') / divisor
-			} else {
-				value = value*10 + float64(char-'0')
-			}
-		} else if char == '.' {
-			decimal = true
-		}
+// parseLogRecord декодирует одну JSON-запись. Строки, которые не являются записями запросов
+// (например, старый текстовый формат), возвращают nil.
+func parseLogRecord(line []byte) *model.LogEntry {
+	if len(line) == 0 || len(line) > maxLogLineSize {
+		return nil
 	}
-
-	// Определяем единицу измерения
-	if contains(s, "ms") {
-		return time.Duration(value * 1000000)
-	} else if contains(s, "µs") {
-		return time.Duration(value * 1000)
-	} else if contains(s, "ns") {
-		return time.Duration(value)
+	var rec model.RequestLogRecord
+	if err := json.Unmarshal(line, &rec); err != nil || rec.Method == "" {
+		return nil
 	}
-	return 0
+	entry := rec.ToLogEntry(string(line))
+	sum := sha256.Sum256(line)
+	entry.ContentHash = hex.EncodeToString(sum[:])
+	return entry
 }
 
-func contains(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
-	}
-	return false
+// dailyFileWriter — io.Writer, который пишет в logs/requests-YYYY-MM-DD.log
+// и переключается на новый файл при смене дня
+type dailyFileWriter struct {
+	dir         string
+	mu          sync.Mutex
+	currentFile *os.File
+	currentDate string
+	closed      bool
 }
 
-func parseMethodPath(s string) (string, string) {
-	s = trimSpace(s)
+func (w *dailyFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
-	// Ищем первый пробел после метода
-	methodEnd := 0
-	for i := 0; i < len(s); i++ {
-		if s[i] == ' ' {
-			methodEnd = i
-			break
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+
+	// Проверяем, нужно ли создать новый файл для нового дня
+	today := time.Now().Format("2006-01-02")
+	if w.currentDate != today {
+		if w.currentFile != nil {
+			w.currentFile.Close()
+		}
+		filename := filepath.Join(w.dir, logFilePrefix+today+logFileExt)
+		file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			w.currentFile = nil
+			w.currentDate = ""
+			return 0, err
 		}
+		w.currentFile = file
+		w.currentDate = today
 	}
 
-	if methodEnd == 0 {
-		return s, ""
+	n, err := w.currentFile.Write(p)
+	if err != nil {
+		return n, err
 	}
+	return n, w.currentFile.Sync() // Синхронизируем сразу
+}
 
-	return s[:methodEnd], trimSpace(s[methodEnd:])
+func (w *dailyFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	if w.currentFile == nil {
+		return nil
+	}
+	err := w.currentFile.Sync()
+	if cerr := w.currentFile.Close(); err == nil {
+		err = cerr
+	}
+	w.currentFile = nil
+	w.currentDate = ""
+	return err
 }
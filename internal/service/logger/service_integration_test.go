@@ -0,0 +1,223 @@
+package logger_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	loggerRepo "backend/internal/repository/logger"
+	"backend/internal/service/logger"
+	"backend/internal/testutil/pgtest"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+func logLine(n int) string {
+	return fmt.Sprintf(`{"time":"2026-10-10T10:00:%02dZ","level":"INFO","msg":"request","method":"GET","path":"/api/v1/notes/%d","route":"/api/v1/notes/:id","status":200,"latency_ms":1.5,"client_ip":"10.0.0.1"}`+"\n", n, n)
+}
+
+func TestIngestResumesRotatesAndRereadsTruncatedFiles(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc := env.Container.LogService
+	repo := loggerRepo.NewRepository(env.DB)
+	dir := env.Cfg.Logs.Dir
+	day1 := filepath.Join(dir, "requests-2026-10-10.log")
+	day2 := filepath.Join(dir, "requests-2026-10-11.log")
+
+	write := func(path, content string, flag int) {
+		t.Helper()
+		f, err := os.OpenFile(path, flag|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.WriteString(content); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeGzip := func(path, content string) {
+		t.Helper()
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write([]byte(content))
+		gz.Close()
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ingest := func(step string, want logger.IngestResult) {
+		t.Helper()
+		got, err := svc.Ingest(ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+		if got != want {
+			t.Fatalf("%s: ingest = %+v, want %+v", step, got, want)
+		}
+	}
+	checkpoint := func(name string, want int) {
+		t.Helper()
+		if off, err := repo.GetCheckpoint(ctx, name); err != nil || off != int64(want) {
+			t.Fatalf("checkpoint %s = %d, %v; want %d", name, off, err, want)
+		}
+	}
+	rows := func() int {
+		t.Helper()
+		var n int
+		if err := env.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM request_logs`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// Незавершённая последняя строка ждёт, пока её допишут
+	line3 := logLine(3)
+	write(day1, logLine(1)+"not json\n"+logLine(2)+line3[:40], os.O_TRUNC)
+	ingest("first pass", logger.IngestResult{Files: 1, Lines: 3, Inserted: 2, Skipped: 1})
+	loadedDay1 := len(logLine(1) + "not json\n" + logLine(2))
+	checkpoint("requests-2026-10-10.log", loadedDay1)
+
+	// Следующий проход читает только дописанное после чекпоинта
+	write(day1, line3[40:]+logLine(4), os.O_APPEND)
+	ingest("resume", logger.IngestResult{Files: 1, Lines: 2, Inserted: 2})
+	ingest("nothing new", logger.IngestResult{})
+	full := logLine(1) + "not json\n" + logLine(2) + line3 + logLine(4)
+	checkpoint("requests-2026-10-10.log", len(full))
+
+	// Сжатый при ротации файл узнаётся по тому же чекпоинту и заново не читается
+	writeGzip(day1+".gz", full)
+	if err := os.Remove(day1); err != nil {
+		t.Fatal(err)
+	}
+	ingest("rotated", logger.IngestResult{})
+
+	// Строка, уже загруженная из другого файла, отбрасывается по content_hash
+	write(day2, logLine(4)+logLine(5), os.O_TRUNC)
+	ingest("duplicate", logger.IngestResult{Files: 1, Lines: 2, Inserted: 1})
+	checkpoint("requests-2026-10-11.log", len(logLine(4)+logLine(5)))
+
+	// Файл короче чекпоинта (обрезан или заменён) читается заново с начала
+	write(day2, logLine(6), os.O_TRUNC)
+	ingest("truncated", logger.IngestResult{Files: 1, Lines: 1, Inserted: 1})
+	checkpoint("requests-2026-10-11.log", len(logLine(6)))
+	ingest("truncated, nothing new", logger.IngestResult{})
+
+	// То же для .gz: содержимое короче чекпоинта
+	writeGzip(day1+".gz", logLine(1))
+	ingest("truncated gz", logger.IngestResult{Files: 1, Lines: 1})
+	checkpoint("requests-2026-10-10.log", len(logLine(1)))
+
+	if n := rows(); n != 6 {
+		t.Fatalf("request_logs rows = %d, want 6", n)
+	}
+
+	// Backfill перечитывает файлы с начала, ничего не дублирует и не откатывает чекпоинты
+	from := time.Date(2026, 10, 10, 0, 0, 0, 0, time.Local)
+	res, err := svc.Backfill(ctx, from.AddDate(0, 0, 1), from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != (logger.IngestResult{Files: 2, Lines: 2}) {
+		t.Fatalf("backfill = %+v", res)
+	}
+	checkpoint("requests-2026-10-11.log", len(logLine(6)))
+	if n := rows(); n != 6 {
+		t.Fatalf("request_logs rows after backfill = %d, want 6", n)
+	}
+}
+
+func TestApplyRetention(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	repo := loggerRepo.NewRepository(env.DB)
+	dir := t.TempDir()
+	svc := logger.NewService(repo, dir, logger.RetentionPolicy{Compress: true, FileDays: 30, DBDays: 7})
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	fileFor := func(daysAgo int) string {
+		return filepath.Join(dir, "requests-"+today.AddDate(0, 0, -daysAgo).Format("2006-01-02")+".log")
+	}
+	lineFor := func(daysAgo int) string {
+		at := today.AddDate(0, 0, -daysAgo).Add(12 * time.Hour).UTC()
+		return fmt.Sprintf(`{"time":%q,"level":"INFO","msg":"request","method":"GET","path":"/api/v1/notes","status":200,"latency_ms":2,"client_ip":"10.0.0.1"}`+"\n",
+			at.Format(time.RFC3339))
+	}
+	files := map[int]string{
+		40: lineFor(40),             // старше FileDays и DBDays — удаляется, строка сворачивается
+		2:  lineFor(2),              // загружен полностью — сжимается
+		3:  lineFor(3) + `{"method`, // хвост не дописан — не сжимается
+		0:  lineFor(0),              // сегодняшний файл не трогаем
+	}
+	for daysAgo, content := range files {
+		if err := os.WriteFile(fileFor(daysAgo), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := svc.ApplyRetention(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := logger.RetentionResult{
+		Ingest:          logger.IngestResult{Files: 4, Lines: 4, Inserted: 4},
+		FilesCompressed: 1,
+		FilesDeleted:    1,
+		RolledUpBefore:  today.AddDate(0, 0, -7).Format("2006-01-02"),
+		AggregateRows:   1,
+		PrunedRows:      1,
+	}
+	if res != want {
+		t.Fatalf("retention = %+v, want %+v", res, want)
+	}
+
+	if fileExists(fileFor(40)) || fileExists(fileFor(2)) || !fileExists(fileFor(2)+".gz") ||
+		!fileExists(fileFor(3)) || fileExists(fileFor(3)+".gz") || !fileExists(fileFor(0)) {
+		entries, _ := os.ReadDir(dir)
+		t.Fatalf("files after retention: %v", entries)
+	}
+	if off, _ := repo.GetCheckpoint(ctx, filepath.Base(fileFor(40))); off != 0 {
+		t.Fatalf("checkpoint of deleted file = %d", off)
+	}
+	if off, _ := repo.GetCheckpoint(ctx, filepath.Base(fileFor(2))); off != int64(len(files[2])) {
+		t.Fatalf("checkpoint of compressed file = %d", off)
+	}
+
+	stats, err := svc.DailyStats(ctx, model.LogFilter{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].Day != today.AddDate(0, 0, -40).Add(12*time.Hour).UTC().Format("2006-01-02") || stats[0].Requests != 1 {
+		t.Fatalf("daily stats = %+v", stats)
+	}
+	var rows int
+	if err := env.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM request_logs`).Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 3 {
+		t.Fatalf("request_logs rows = %d, want 3", rows)
+	}
+
+	// Повторный проход ничего не делает: сжатый файл заново не читается
+	res, err = svc.ApplyRetention(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Ingest != (logger.IngestResult{}) || res.FilesCompressed != 0 || res.FilesDeleted != 0 || res.PrunedRows != 0 {
+		t.Fatalf("second retention = %+v", res)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
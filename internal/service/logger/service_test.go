@@ -0,0 +1,197 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func logLine(n int) string {
+	return fmt.Sprintf(`{"time":"2026-10-17T10:00:%02dZ","level":"INFO","msg":"request","method":"GET","path":"/api/v1/notes/%d","status":200,"latency_ms":1.5,"client_ip":"10.0.0.1"}`+"\n", n, n)
+}
+
+func writeGzip(t *testing.T, path, content string) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(content))
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogFileSize(t *testing.T) {
+	dir := t.TempDir()
+	content := logLine(1) + logLine(2)
+
+	plain := filepath.Join(dir, "requests-2026-10-17.log")
+	if err := os.WriteFile(plain, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	compressed := filepath.Join(dir, "requests-2026-10-16.log.gz")
+	writeGzip(t, compressed, content)
+
+	for _, path := range []string{plain, compressed} {
+		size, err := logFileSize(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size != int64(len(content)) {
+			t.Errorf("logFileSize(%s) = %d, want %d", filepath.Base(path), size, len(content))
+		}
+	}
+
+	// Обрезанный .gz без трейлера — ошибка, а не мусорный размер
+	broken := filepath.Join(dir, "requests-2026-10-15.log.gz")
+	if err := os.WriteFile(broken, []byte{0x1f}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := logFileSize(broken); err == nil {
+		t.Error("logFileSize of a 1-byte .gz: no error")
+	}
+}
+
+func TestLoaded(t *testing.T) {
+	cases := []struct {
+		path         string
+		size, offset int64
+		want         bool
+	}{
+		{"requests-2026-10-17.log", 100, 100, true},
+		{"requests-2026-10-17.log", 100, 60, false},
+		{"requests-2026-10-17.log", 100, 160, false}, // обрезан — читать заново
+		{"requests-2026-10-17.log.gz", 100, 100, true},
+		{"requests-2026-10-17.log.gz", 100, 60, false},
+		// ISIZE файла в 4 ГиБ + 100 байт — это 100
+		{"requests-2026-10-17.log.gz", 100, 1<<32 + 100, true},
+		{"requests-2026-10-17.log.gz", 100, 1<<32 + 60, false},
+	}
+	for _, tc := range cases {
+		if got := loaded(tc.path, tc.size, tc.offset); got != tc.want {
+			t.Errorf("loaded(%s, %d, %d) = %v, want %v", tc.path, tc.size, tc.offset, got, tc.want)
+		}
+	}
+}
+
+func TestOpenLogFileResumesFromOffset(t *testing.T) {
+	dir := t.TempDir()
+	first, second := logLine(1), logLine(2)
+	content := first + second
+
+	plain := filepath.Join(dir, "requests-2026-10-17.log")
+	if err := os.WriteFile(plain, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	compressed := filepath.Join(dir, "requests-2026-10-16.log.gz")
+	writeGzip(t, compressed, content)
+
+	for _, path := range []string{plain, compressed} {
+		name := filepath.Base(path)
+		for _, offset := range []int64{0, int64(len(first)), int64(len(content))} {
+			r, closer, err := openLogFile(path, offset)
+			if err != nil {
+				t.Fatalf("openLogFile(%s, %d): %v", name, offset, err)
+			}
+			got, err := io.ReadAll(r)
+			closer.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != content[offset:] {
+				t.Errorf("openLogFile(%s, %d) read %q", name, offset, got)
+			}
+		}
+
+		if _, _, err := openLogFile(path, int64(len(content))+1); !errors.Is(err, errTruncated) {
+			t.Errorf("openLogFile(%s) past the end: err = %v, want errTruncated", name, err)
+		}
+	}
+}
+
+func TestParseLogRecord(t *testing.T) {
+	line := []byte(strings.TrimSuffix(logLine(1), "\n"))
+	entry := parseLogRecord(line)
+	if entry == nil {
+		t.Fatal("parseLogRecord returned nil for a request record")
+	}
+	if entry.Method != "GET" || entry.Path != "/api/v1/notes/1" || entry.StatusCode != 200 || entry.RawLog != string(line) {
+		t.Errorf("entry = %+v", entry)
+	}
+
+	// Одинаковые строки дают одинаковый content_hash — на нём держится дедупликация
+	if again := parseLogRecord(append([]byte(nil), line...)); again.ContentHash != entry.ContentHash {
+		t.Errorf("hash differs for the same line: %s vs %s", again.ContentHash, entry.ContentHash)
+	}
+	other := parseLogRecord([]byte(strings.TrimSuffix(logLine(2), "\n")))
+	if other.ContentHash == entry.ContentHash {
+		t.Error("different lines share a content_hash")
+	}
+
+	for _, bad := range []string{
+		"",
+		"2026/10/17 10:00:00 GET /api/v1/notes 200", // старый текстовый формат
+		`{"time":"2026-10-17T10:00:00Z","level":"INFO","msg":"worker started"}`,
+		`{"method":"GET",`,
+		`{"method":"GET","path":"/` + strings.Repeat("a", maxLogLineSize) + `"}`,
+	} {
+		if entry := parseLogRecord([]byte(bad)); entry != nil {
+			t.Errorf("parseLogRecord(%.40q) = %+v, want nil", bad, entry)
+		}
+	}
+}
+
+func TestLogFileDay(t *testing.T) {
+	for _, path := range []string{"/logs/requests-2026-10-17.log", "/logs/requests-2026-10-17.log.gz"} {
+		day, ok := logFileDay(path)
+		if !ok || day.Format("2006-01-02") != "2026-10-17" {
+			t.Errorf("logFileDay(%s) = %v, %v", path, day, ok)
+		}
+		if name := checkpointName(path); name != "requests-2026-10-17.log" {
+			t.Errorf("checkpointName(%s) = %s", path, name)
+		}
+	}
+	if _, ok := logFileDay("/logs/requests-latest.log"); ok {
+		t.Error("logFileDay parsed a name without a date")
+	}
+}
+
+func TestReadLineSkipsOversizedLines(t *testing.T) {
+	long := strings.Repeat("x", maxLogLineSize) + "\n"
+	tail := strings.Repeat("y", maxLogLineSize+5)
+	r := bufio.NewReaderSize(strings.NewReader(logLine(1)+long+logLine(2)+tail), 64*1024)
+
+	want := []struct {
+		line string
+		n    int
+	}{
+		{logLine(1), len(logLine(1))},
+		{"", len(long)}, // с '\n' — на байт длиннее предела
+		{logLine(2), len(logLine(2))},
+		{"", len(tail)}, // без '\n', но длиннее предела — пропускается, а не ждёт дописывания
+	}
+	for i, w := range want {
+		line, n, err := readLine(r)
+		if err != nil || string(line) != w.line || n != int64(w.n) {
+			t.Fatalf("line %d: %d bytes, n = %d, err = %v; want %d bytes, n = %d", i, len(line), n, err, len(w.line), w.n)
+		}
+	}
+	if _, n, err := readLine(r); err != io.EOF || n != 0 {
+		t.Fatalf("after last line: n = %d, err = %v", n, err)
+	}
+
+	// Короткий незавершённый хвост остаётся до следующей загрузки
+	r = bufio.NewReaderSize(strings.NewReader(logLine(3)[:10]), 64*1024)
+	if line, n, err := readLine(r); err != io.EOF || n != 10 || len(line) != 10 {
+		t.Fatalf("partial line: %q, n = %d, err = %v", line, n, err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"backend/internal/model"
 	masterRepo "backend/internal/repository/master"
+	"backend/pkg/query"
 
 	"github.com/google/uuid"
 )
@@ -17,12 +18,12 @@ func NewService(repo *masterRepo.Repository) *Service {
 	return &Service{repo: repo}
 }
 
-func (s *Service) ListCurrencies(ctx context.Context, workspaceID string) ([]model.Currency, error) {
+func (s *Service) ListCurrencies(ctx context.Context, workspaceID string, spec query.Spec) ([]model.Currency, query.Page, error) {
 	wsID, err := uuid.Parse(workspaceID)
 	if err != nil {
-		return nil, err
+		return nil, query.Page{}, err
 	}
-	return s.repo.ListCurrencies(ctx, wsID)
+	return s.repo.ListCurrencies(ctx, wsID, spec)
 }
 
 func (s *Service) GetCurrency(ctx context.Context, workspaceID, id string) (*model.Currency, error) {
@@ -57,12 +58,12 @@ func (s *Service) DeleteCurrency(ctx context.Context, workspaceID, id string) er
 	return s.repo.DeleteCurrency(ctx, uid, wsID)
 }
 
-func (s *Service) ListCounterparties(ctx context.Context, workspaceID string) ([]model.Counterparty, error) {
+func (s *Service) ListCounterparties(ctx context.Context, workspaceID string, spec query.Spec) ([]model.Counterparty, query.Page, error) {
 	wsID, err := uuid.Parse(workspaceID)
 	if err != nil {
-		return nil, err
+		return nil, query.Page{}, err
 	}
-	return s.repo.ListCounterparties(ctx, wsID)
+	return s.repo.ListCounterparties(ctx, wsID, spec)
 }
 
 func (s *Service) GetCounterparty(ctx context.Context, workspaceID, id string) (*model.Counterparty, error) {
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"backend/internal/repository/metrics"
+	pkgMetrics "backend/pkg/metrics"
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// cacheTTL — бизнес-показатели пересчитываются не чаще раза в cacheTTL, как бы часто ни ходил Prometheus
+	cacheTTL = 30 * time.Second
+	// queryTimeout ограничивает запросы к БД во время scrape
+	queryTimeout = 5 * time.Second
+)
+
+// activeUserWindows — окна для gauge активных пользователей
+var activeUserWindows = []struct {
+	label string
+	d     time.Duration
+}{
+	{"1d", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+}
+
+type snapshot struct {
+	usersByStatus    map[string]int64
+	activeUsers      map[string]int64
+	completionsToday int64
+	collectedAt      time.Time
+}
+
+// Service собирает бизнес-показатели для /metrics
+type Service struct {
+	repo *metrics.Repository
+
+	mu   sync.Mutex
+	last *snapshot
+}
+
+func NewService(repo *metrics.Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Collect реализует metrics.Collector
+func (s *Service) Collect(ctx context.Context, e *pkgMetrics.Exposition) {
+	snap, err := s.snapshot(ctx)
+	if err != nil {
+		log.Printf("metrics: не удалось собрать бизнес-показатели: %v", err)
+		e.Gauge("app_business_metrics_up", "1, если бизнес-показатели собраны успешно", 0)
+		return
+	}
+	e.Gauge("app_business_metrics_up", "1, если бизнес-показатели собраны успешно", 1)
+
+	e.Header("app_users", "Пользователи по статусу", "gauge")
+	statuses := make([]string, 0, len(snap.usersByStatus))
+	for status := range snap.usersByStatus {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		e.Sample("app_users", float64(snap.usersByStatus[status]), pkgMetrics.Label{Name: "status", Value: status})
+	}
+
+	e.Header("app_active_users", "Разные пользователи с аутентифицированными запросами за окно", "gauge")
+	for _, w := range activeUserWindows {
+		e.Sample("app_active_users", float64(snap.activeUsers[w.label]), pkgMetrics.Label{Name: "window", Value: w.label})
+	}
+
+	e.Gauge("app_habit_completions_today", "Выполнения привычек за текущий день", float64(snap.completionsToday))
+}
+
+func (s *Service) snapshot(ctx context.Context) (*snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.last != nil && time.Since(s.last.collectedAt) < cacheTTL {
+		return s.last, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	now := time.Now()
+	snap := &snapshot{activeUsers: make(map[string]int64), collectedAt: now}
+
+	var err error
+	if snap.usersByStatus, err = s.repo.CountUsersByStatus(ctx); err != nil {
+		return nil, err
+	}
+	for _, w := range activeUserWindows {
+		n, err := s.repo.CountActiveUsers(ctx, now.Add(-w.d))
+		if err != nil {
+			return nil, err
+		}
+		snap.activeUsers[w.label] = n
+	}
+	if snap.completionsToday, err = s.repo.CountCompletionsOn(ctx, now); err != nil {
+		return nil, err
+	}
+
+	s.last = snap
+	return snap, nil
+}
@@ -0,0 +1,107 @@
+package metrics_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	metricsRepo "backend/internal/repository/metrics"
+	"backend/internal/service/metrics"
+	"backend/internal/testutil/pgtest"
+	pkgMetrics "backend/pkg/metrics"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+func TestBusinessMetrics(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	reg := pkgMetrics.NewRegistry()
+	reg.RegisterCollector(metrics.NewService(metricsRepo.NewRepository(env.DB)))
+
+	owner := env.CreateUser(t)
+	weekly := env.CreateUser(t)
+	gone := env.CreateUser(t)
+	if _, err := env.DB.ExecContext(ctx, `UPDATE users SET status = 'DELETED' WHERE id = $1`, gone.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Активность — по аутентифицированным запросам в request_logs
+	now := time.Now().UTC()
+	for i, req := range []struct {
+		userID string
+		ago    time.Duration
+	}{
+		{owner.ID, time.Hour},
+		{owner.ID, 2 * time.Hour},
+		{weekly.ID, 3 * 24 * time.Hour},
+		{gone.ID, 30 * 24 * time.Hour},
+		{"", time.Minute},
+	} {
+		var userID interface{}
+		if req.userID != "" {
+			userID = req.userID
+		}
+		if _, err := env.DB.ExecContext(ctx, `
+			INSERT INTO request_logs (timestamp, status_code, duration_ms, client_ip, method, path, raw_log, content_hash, user_id)
+			VALUES ($1, 200, 1, '10.0.0.1', 'GET', '/api/v1/habits', 'line', $2, $3)
+		`, now.Add(-req.ago), strings.Repeat(string(rune('a'+i)), 64), userID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ws := env.CreateWorkspace(t, owner)
+	habit := env.CreateHabit(t, owner, ws, model.CreateHabitDto{})
+	env.BackdateHabit(t, habit.ID, 7)
+	for _, day := range []time.Time{time.Now(), time.Now().AddDate(0, 0, -1)} {
+		if _, err := env.Container.HabitsService.Complete(ctx, habit.ID, owner.ID, ws.ID, day, "", nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scrape := func() string {
+		t.Helper()
+		var b strings.Builder
+		if err := reg.WriteTo(ctx, &b); err != nil {
+			t.Fatal(err)
+		}
+		return b.String()
+	}
+	out := scrape()
+	for _, line := range []string{
+		`app_business_metrics_up 1`,
+		`app_users{status="ACTIVE"} 2`,
+		`app_users{status="DELETED"} 1`,
+		`app_active_users{window="1d"} 1`,
+		`app_active_users{window="7d"} 2`,
+		`app_habit_completions_today 1`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("no %s in:\n%s", line, out)
+		}
+	}
+
+	// Показатели кэшируются: частый scrape не ходит в БД
+	if _, err := env.DB.ExecContext(ctx, `UPDATE users SET status = 'DELETED' WHERE id = $1`, weekly.ID); err != nil {
+		t.Fatal(err)
+	}
+	if again := scrape(); !strings.Contains(again, `app_users{status="ACTIVE"} 2`+"\n") {
+		t.Errorf("cached scrape changed:\n%s", again)
+	}
+
+	// Ошибка БД не ломает /metrics: остальные метрики отдаются, up = 0
+	broken := pkgMetrics.NewRegistry()
+	broken.RegisterCollector(metrics.NewService(metricsRepo.NewRepository(env.DB)))
+	if _, err := env.DB.ExecContext(ctx, `ALTER TABLE request_logs RENAME TO request_logs_gone`); err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := broken.WriteTo(ctx, &b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "app_business_metrics_up 0\n") || strings.Contains(b.String(), "app_users") {
+		t.Fatalf("scrape with broken DB:\n%s", b.String())
+	}
+}
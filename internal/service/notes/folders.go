@@ -0,0 +1,64 @@
+package notes
+
+import (
+	"context"
+	"database/sql"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func (s *Service) ListFolders(ctx context.Context, workspaceID string) ([]model.NoteFolder, error) {
+	wsID, err := uuid.Parse(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListFolders(ctx, wsID)
+}
+
+// CreateFolder — notesRepo.ErrFolderNotFound (нет родителя) или ErrFolderTooDeep
+func (s *Service) CreateFolder(ctx context.Context, workspaceID string, dto model.CreateNoteFolderDto) (*model.NoteFolder, error) {
+	f := &model.NoteFolder{WorkspaceID: workspaceID, ParentID: dto.ParentID, Name: dto.Name}
+	if err := s.repo.CreateFolder(ctx, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// RenameFolder — sql.ErrNoRows, если папки нет
+func (s *Service) RenameFolder(ctx context.Context, workspaceID, id string, dto model.RenameNoteFolderDto) (*model.NoteFolder, error) {
+	wsID, folderID, err := parseIDs(workspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.RenameFolder(ctx, folderID, wsID, dto.Name); err != nil {
+		return nil, err
+	}
+	return s.repo.GetFolder(ctx, folderID, wsID)
+}
+
+// MoveFolder — notesRepo.ErrFolderNotFound, ErrFolderCycle или ErrFolderTooDeep
+func (s *Service) MoveFolder(ctx context.Context, workspaceID, id string, dto model.MoveNoteFolderDto) (*model.NoteFolder, error) {
+	wsID, folderID, err := parseIDs(workspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.MoveFolder(ctx, folderID, wsID, dto.ParentID, dto.Position); err != nil {
+		return nil, err
+	}
+	f, err := s.repo.GetFolder(ctx, folderID, wsID)
+	if err == nil && f == nil {
+		err = sql.ErrNoRows
+	}
+	return f, err
+}
+
+// DeleteFolder — sql.ErrNoRows (нет папки) или notesRepo.ErrFolderNotEmpty
+func (s *Service) DeleteFolder(ctx context.Context, workspaceID, id string) error {
+	wsID, folderID, err := parseIDs(workspaceID, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteFolder(ctx, folderID, wsID)
+}
@@ -2,57 +2,160 @@ package notes
 
 import (
 	"context"
+	"database/sql"
+	"log"
+	"slices"
+	"strings"
 
 	"backend/internal/model"
 	notesRepo "backend/internal/repository/notes"
+	linksService "backend/internal/service/links"
+	"backend/pkg/query"
 
 	"github.com/google/uuid"
 )
 
 type Service struct {
-	repo *notesRepo.Repository
+	repo  *notesRepo.Repository
+	links *linksService.Service
 }
 
-func NewService(repo *notesRepo.Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo *notesRepo.Repository, links *linksService.Service) *Service {
+	return &Service{repo: repo, links: links}
 }
 
-func (s *Service) List(ctx context.Context, workspaceID string) ([]model.Note, error) {
+func (s *Service) List(ctx context.Context, workspaceID string, spec query.Spec) ([]model.Note, query.Page, error) {
 	wsID, err := uuid.Parse(workspaceID)
 	if err != nil {
-		return nil, err
+		return nil, query.Page{}, err
 	}
-	return s.repo.List(ctx, wsID)
+	return s.repo.List(ctx, wsID, spec)
 }
 
 func (s *Service) Get(ctx context.Context, workspaceID, id string) (*model.Note, error) {
-	wsID, err := uuid.Parse(workspaceID)
+	wsID, noteID, err := parseIDs(workspaceID, id)
 	if err != nil {
 		return nil, err
 	}
-	uid, err := uuid.Parse(id)
+	return s.repo.Get(ctx, noteID, wsID)
+}
+
+func (s *Service) Create(ctx context.Context, workspaceID, userID string, dto model.CreateNoteDto) (*model.Note, error) {
+	n := &model.Note{
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+		FolderID:    dto.FolderID,
+		Title:       dto.Title,
+		Content:     dto.Content,
+		ContentType: dto.ContentType,
+		Tags:        normalizeTags(dto.Tags),
+	}
+	if err := s.repo.Create(ctx, n); err != nil {
+		return nil, err
+	}
+	s.syncLinks(ctx, n)
+	return n, nil
+}
+
+// Update — sql.ErrNoRows, если заметки нет
+func (s *Service) Update(ctx context.Context, workspaceID, id string, dto model.UpdateNoteDto) (*model.Note, error) {
+	wsID, noteID, err := parseIDs(workspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+	n, err := s.repo.Get(ctx, noteID, wsID)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil {
+		return nil, sql.ErrNoRows
+	}
+	n.Title = dto.Title
+	n.Content = dto.Content
+	if dto.ContentType != nil {
+		n.ContentType = *dto.ContentType
+	}
+	if dto.Tags != nil {
+		n.Tags = normalizeTags(dto.Tags)
+	}
+	if err := s.repo.Update(ctx, n); err != nil {
+		return nil, err
+	}
+	s.syncLinks(ctx, n)
+	return n, nil
+}
+
+// SetPinned и SetArchived возвращают заметку после изменения; sql.ErrNoRows — заметки нет
+func (s *Service) SetPinned(ctx context.Context, workspaceID, id string, pinned bool) (*model.Note, error) {
+	wsID, noteID, err := parseIDs(workspaceID, id)
 	if err != nil {
 		return nil, err
 	}
-	return s.repo.Get(ctx, uid, wsID)
+	if err := s.repo.SetPinned(ctx, noteID, wsID, pinned); err != nil {
+		return nil, err
+	}
+	return s.repo.Get(ctx, noteID, wsID)
 }
 
-func (s *Service) Create(ctx context.Context, n *model.Note) error {
-	return s.repo.Create(ctx, n)
+func (s *Service) SetArchived(ctx context.Context, workspaceID, id string, archived bool) (*model.Note, error) {
+	wsID, noteID, err := parseIDs(workspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetArchived(ctx, noteID, wsID, archived); err != nil {
+		return nil, err
+	}
+	return s.repo.Get(ctx, noteID, wsID)
 }
 
-func (s *Service) Update(ctx context.Context, n *model.Note) error {
-	return s.repo.Update(ctx, n)
+// Move — sql.ErrNoRows (нет заметки) или notesRepo.ErrFolderNotFound
+func (s *Service) Move(ctx context.Context, workspaceID, id string, dto model.MoveNoteDto) (*model.Note, error) {
+	wsID, noteID, err := parseIDs(workspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Move(ctx, noteID, wsID, dto.FolderID, dto.Position); err != nil {
+		return nil, err
+	}
+	return s.repo.Get(ctx, noteID, wsID)
 }
 
 func (s *Service) Delete(ctx context.Context, workspaceID, id string) error {
-	wsID, err := uuid.Parse(workspaceID)
+	wsID, noteID, err := parseIDs(workspaceID, id)
 	if err != nil {
 		return err
 	}
+	return s.repo.Delete(ctx, noteID, wsID)
+}
+
+// syncLinks пересобирает связи из [[ссылок]] в тексте. Заметка уже сохранена, поэтому ошибка только логируется:
+// связи догонят текст при следующем сохранении.
+func (s *Service) syncLinks(ctx context.Context, n *model.Note) {
+	if err := s.links.SyncWiki(ctx, n.WorkspaceID, model.SearchTypeNote, n.ID, n.Content); err != nil {
+		log.Printf("[notes] sync links of %s: %v", n.ID, err)
+	}
+}
+
+// normalizeTags обрезает пробелы, выбрасывает пустые теги и дубликаты, сохраняя порядок
+func normalizeTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t != "" && !slices.Contains(out, t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func parseIDs(workspaceID, id string) (uuid.UUID, uuid.UUID, error) {
+	wsID, err := uuid.Parse(workspaceID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
 	uid, err := uuid.Parse(id)
 	if err != nil {
-		return err
+		return uuid.Nil, uuid.Nil, err
 	}
-	return s.repo.Delete(ctx, uid, wsID)
+	return wsID, uid, nil
 }
@@ -0,0 +1,288 @@
+package notes_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"backend/internal/model"
+	linksRepo "backend/internal/repository/links"
+	notesRepo "backend/internal/repository/notes"
+	"backend/internal/service/links"
+	"backend/internal/service/notes"
+	"backend/internal/testutil/pgtest"
+	"backend/pkg/query"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+func TestNotesFoldersOrderingAndArchive(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc := notes.NewService(notesRepo.NewRepository(env.DB), links.NewService(linksRepo.NewRepository(env.DB)))
+	owner := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+	other := env.CreateWorkspace(t, owner)
+
+	folder := func(name string, parent *model.NoteFolder) *model.NoteFolder {
+		t.Helper()
+		dto := model.CreateNoteFolderDto{Name: name}
+		if parent != nil {
+			dto.ParentID = &parent.ID
+		}
+		f, err := svc.CreateFolder(ctx, ws.ID, dto)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	work := folder("Работа", nil)
+	projects := folder("Проекты", work)
+	home := folder("Дом", nil)
+
+	create := func(title string, f *model.NoteFolder, tags ...string) *model.Note {
+		t.Helper()
+		dto := model.CreateNoteDto{Title: title, Tags: tags}
+		if f != nil {
+			dto.FolderID = &f.ID
+		}
+		n, err := svc.Create(ctx, ws.ID, owner.ID, dto)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	a := create("a", work, "x", " x ", "")
+	b := create("b", work, "y")
+	c := create("c", work)
+	d := create("d", nil)
+	if !reflect.DeepEqual(a.Tags, []string{"x"}) || a.ContentType != model.NoteContentText || c.Position != 2 {
+		t.Fatalf("created note = %+v, c.Position = %d", a, c.Position)
+	}
+
+	list := func(q string) []string {
+		t.Helper()
+		values, _ := url.ParseQuery(q)
+		spec, err := query.Parse(values, notesRepo.ListSchema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items, _, err := svc.List(ctx, ws.ID, spec)
+		if err != nil {
+			t.Fatalf("List(%s): %v", q, err)
+		}
+		titles := []string{}
+		for _, n := range items {
+			titles = append(titles, n.Title)
+		}
+		return titles
+	}
+	manual := "sort=manual&folderId=" + work.ID
+	if got := list(manual); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("manual order = %v", got)
+	}
+
+	// Закреплённая заметка идёт первой, перенос c в начало сдвигает остальных
+	if _, err := svc.SetPinned(ctx, ws.ID, b.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	zero := 0
+	if _, err := svc.Move(ctx, ws.ID, c.ID, model.MoveNoteDto{FolderID: &work.ID, Position: &zero}); err != nil {
+		t.Fatal(err)
+	}
+	if got := list(manual); !reflect.DeepEqual(got, []string{"b", "c", "a"}) {
+		t.Errorf("after pin and move = %v", got)
+	}
+
+	// Перенос в корень — в конец корня; в чужую папку — нельзя
+	moved, err := svc.Move(ctx, ws.ID, a.ID, model.MoveNoteDto{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.FolderID != nil || moved.Position != d.Position+1 {
+		t.Errorf("moved to root = %+v", moved)
+	}
+	foreign, _ := svc.CreateFolder(ctx, other.ID, model.CreateNoteFolderDto{Name: "чужая"})
+	if _, err := svc.Move(ctx, ws.ID, a.ID, model.MoveNoteDto{FolderID: &foreign.ID}); !errors.Is(err, notesRepo.ErrFolderNotFound) {
+		t.Errorf("move to foreign folder: err = %v", err)
+	}
+	if got := list("root=true&sort=manual"); !reflect.DeepEqual(got, []string{"d", "a"}) {
+		t.Errorf("root = %v", got)
+	}
+
+	// Архивные скрыты по умолчанию
+	if _, err := svc.SetArchived(ctx, ws.ID, d.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := list("root=true&sort=manual"); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("root without archived = %v", got)
+	}
+	if got := list("archived=true"); !reflect.DeepEqual(got, []string{"d"}) {
+		t.Errorf("archived = %v", got)
+	}
+	if got := list("tags=x,y&sort=title"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("tags = %v", got)
+	}
+
+	// Папки: цикл, перенос с содержимым, удаление только пустой
+	if _, err := svc.MoveFolder(ctx, ws.ID, work.ID, model.MoveNoteFolderDto{ParentID: &projects.ID}); !errors.Is(err, notesRepo.ErrFolderCycle) {
+		t.Errorf("move into subfolder: err = %v", err)
+	}
+	if _, err := svc.MoveFolder(ctx, ws.ID, work.ID, model.MoveNoteFolderDto{ParentID: &work.ID}); !errors.Is(err, notesRepo.ErrFolderCycle) {
+		t.Errorf("move into itself: err = %v", err)
+	}
+	movedFolder, err := svc.MoveFolder(ctx, ws.ID, work.ID, model.MoveNoteFolderDto{ParentID: &home.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *movedFolder.ParentID != home.ID || movedFolder.NoteCount != 2 {
+		t.Errorf("moved folder = %+v", movedFolder)
+	}
+	if err := svc.DeleteFolder(ctx, ws.ID, work.ID); !errors.Is(err, notesRepo.ErrFolderNotEmpty) {
+		t.Errorf("delete non-empty folder: err = %v", err)
+	}
+	if err := svc.DeleteFolder(ctx, ws.ID, projects.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DeleteFolder(ctx, ws.ID, projects.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("delete twice: err = %v", err)
+	}
+
+	// Глубина ограничена
+	parent := home
+	for i := 0; i < notesRepo.MaxFolderDepth-1; i++ {
+		parent = folder("level", parent)
+	}
+	if _, err := svc.CreateFolder(ctx, ws.ID, model.CreateNoteFolderDto{Name: "too deep", ParentID: &parent.ID}); !errors.Is(err, notesRepo.ErrFolderTooDeep) {
+		t.Errorf("too deep: err = %v", err)
+	}
+	if _, err := svc.MoveFolder(ctx, ws.ID, work.ID, model.MoveNoteFolderDto{ParentID: &parent.ID}); !errors.Is(err, notesRepo.ErrFolderTooDeep) {
+		t.Errorf("move too deep: err = %v", err)
+	}
+}
+
+func TestNoteSharingAndLinks(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc := notes.NewService(notesRepo.NewRepository(env.DB), links.NewService(linksRepo.NewRepository(env.DB)))
+	owner := env.CreateUser(t)
+	guest := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+
+	note, err := svc.Create(ctx, ws.ID, owner.ID, model.CreateNoteDto{Title: "План", Content: "секрет"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Пользователю вне воркспейса: сначала чтение, повторный Share меняет права
+	if _, err := svc.Share(ctx, ws.ID, note.ID, owner.ID, model.ShareNoteDto{Email: "nobody@example.com", Permission: "read"}); !errors.Is(err, notesRepo.ErrShareUserNotFound) {
+		t.Errorf("share with unknown email: err = %v", err)
+	}
+	share, err := svc.Share(ctx, ws.ID, note.ID, owner.ID, model.ShareNoteDto{Email: guest.Email, Permission: model.NotePermissionRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if share.UserID != guest.ID {
+		t.Errorf("share = %+v", share)
+	}
+	title := "Изменено"
+	if _, err := svc.UpdateSharedWithMe(ctx, guest.ID, note.ID, model.UpdateNoteDto{Title: title}); !errors.Is(err, notes.ErrEditForbidden) {
+		t.Errorf("update with read permission: err = %v", err)
+	}
+	if _, err := svc.Share(ctx, ws.ID, note.ID, owner.ID, model.ShareNoteDto{Email: guest.Email, Permission: model.NotePermissionEdit}); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := svc.UpdateSharedWithMe(ctx, guest.ID, note.ID, model.UpdateNoteDto{Title: title, Content: "правка"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Title != title || updated.Permission != model.NotePermissionEdit {
+		t.Errorf("updated = %+v", updated)
+	}
+	shared, _ := svc.ListSharedWithMe(ctx, guest.ID)
+	if len(shared) != 1 || shared[0].ID != note.ID {
+		t.Errorf("shared with guest = %+v", shared)
+	}
+	if shares, _ := svc.ListShares(ctx, ws.ID, note.ID); len(shares) != 1 {
+		t.Errorf("shares = %+v", shares)
+	}
+	if err := svc.Unshare(ctx, ws.ID, note.ID, share.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := svc.GetSharedWithMe(ctx, guest.ID, note.ID); n != nil {
+		t.Errorf("note still shared after unshare")
+	}
+
+	// Публичная ссылка с паролем
+	link, err := svc.CreateLink(ctx, ws.ID, note.ID, owner.ID, model.CreateNoteLinkDto{Password: "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Token == "" || !link.HasPassword {
+		t.Fatalf("link = %+v", link)
+	}
+	if _, err := svc.OpenLink(ctx, link.Token, "", "10.0.0.1", "test"); !errors.Is(err, notes.ErrLinkPasswordRequired) {
+		t.Errorf("open without password: err = %v", err)
+	}
+	if _, err := svc.OpenLink(ctx, link.Token, "bad", "10.0.0.1", "test"); !errors.Is(err, notes.ErrLinkWrongPassword) {
+		t.Errorf("open with wrong password: err = %v", err)
+	}
+	public, err := svc.OpenLink(ctx, link.Token, "1234", "10.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public.Title != title {
+		t.Errorf("public note = %+v", public)
+	}
+	if _, err := svc.OpenLink(ctx, link.Token+"x", "1234", "", ""); !errors.Is(err, notes.ErrLinkNotFound) {
+		t.Errorf("open unknown token: err = %v", err)
+	}
+
+	// Отзыв; журнал хранит все обращения, статистика — только успешные
+	if err := svc.RevokeLink(ctx, ws.ID, note.ID, link.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.OpenLink(ctx, link.Token, "1234", "", ""); !errors.Is(err, notes.ErrLinkNotFound) {
+		t.Errorf("open revoked link: err = %v", err)
+	}
+	accessLog, err := svc.LinkAccessLog(ctx, ws.ID, note.ID, link.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var outcomes []string
+	for _, a := range accessLog {
+		outcomes = append(outcomes, a.Outcome)
+	}
+	if want := []string{"revoked", "ok", "wrong_password", "password_required"}; !reflect.DeepEqual(outcomes, want) {
+		t.Errorf("access log = %v, want %v", outcomes, want)
+	}
+	links, _ := svc.ListLinks(ctx, ws.ID, note.ID)
+	if len(links) != 1 || links[0].AccessCount != 1 || links[0].RevokedAt == nil || links[0].Token != "" {
+		t.Errorf("links = %+v", links)
+	}
+
+	// Блокировка после серии неверных паролей
+	locked, _ := svc.CreateLink(ctx, ws.ID, note.ID, owner.ID, model.CreateNoteLinkDto{Password: "1234"})
+	for i := 0; i < notes.MaxFailedAttempts; i++ {
+		_, _ = svc.OpenLink(ctx, locked.Token, "bad", "", "")
+	}
+	if _, err := svc.OpenLink(ctx, locked.Token, "1234", "", ""); !errors.Is(err, notes.ErrLinkLocked) {
+		t.Errorf("open after failed attempts: err = %v", err)
+	}
+
+	// Истёкшая ссылка и срок в прошлом
+	past := "2000-01-01T00:00:00Z"
+	if _, err := svc.CreateLink(ctx, ws.ID, note.ID, owner.ID, model.CreateNoteLinkDto{ExpiresAt: &past}); !errors.Is(err, notes.ErrInvalidExpiry) {
+		t.Errorf("expiry in the past: err = %v", err)
+	}
+	expiring, _ := svc.CreateLink(ctx, ws.ID, note.ID, owner.ID, model.CreateNoteLinkDto{})
+	if _, err := env.DB.ExecContext(ctx, `UPDATE note_links SET expires_at = NOW() AT TIME ZONE 'UTC' - INTERVAL '1 minute' WHERE id = $1`, expiring.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.OpenLink(ctx, expiring.Token, "", "", ""); !errors.Is(err, notes.ErrLinkNotFound) {
+		t.Errorf("open expired link: err = %v", err)
+	}
+}
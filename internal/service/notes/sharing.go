@@ -0,0 +1,250 @@
+package notes
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"backend/internal/model"
+	notesRepo "backend/internal/repository/notes"
+	"backend/pkg/password"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// linkTokenBytes — энтропия токена публичной ссылки (256 бит)
+	linkTokenBytes = 32
+	// MaxFailedAttempts неверных паролей за FailedAttemptsWindow блокируют ссылку до конца окна
+	MaxFailedAttempts    = 10
+	FailedAttemptsWindow = 15 * time.Minute
+	// AccessLogLimit — сколько последних обращений к ссылке отдаёт журнал
+	AccessLogLimit = 200
+)
+
+var (
+	ErrEditForbidden        = errors.New("edit permission required")
+	ErrInvalidExpiry        = errors.New("expiresAt must be an RFC3339 time in the future")
+	ErrLinkNotFound         = errors.New("link not found or no longer valid")
+	ErrLinkPasswordRequired = errors.New("password required")
+	ErrLinkWrongPassword    = errors.New("wrong password")
+	ErrLinkLocked           = errors.New("too many wrong passwords, try again later")
+)
+
+// Share открывает заметку воркспейса пользователю по email. sql.ErrNoRows — нет заметки,
+// notesRepo.ErrShareUserNotFound — нет пользователя.
+func (s *Service) Share(ctx context.Context, workspaceID, noteID, userID string, dto model.ShareNoteDto) (*model.NoteShare, error) {
+	wsID, id, err := parseIDs(workspaceID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireNote(ctx, wsID, id); err != nil {
+		return nil, err
+	}
+	return s.repo.Share(ctx, id, dto.Email, dto.Permission, uuid.MustParse(userID))
+}
+
+// ListShares — кому открыта заметка; sql.ErrNoRows — нет заметки
+func (s *Service) ListShares(ctx context.Context, workspaceID, noteID string) ([]model.NoteShare, error) {
+	wsID, id, err := parseIDs(workspaceID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireNote(ctx, wsID, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListShares(ctx, id, wsID)
+}
+
+func (s *Service) Unshare(ctx context.Context, workspaceID, noteID, shareID string) error {
+	wsID, id, err := parseIDs(workspaceID, noteID)
+	if err != nil {
+		return err
+	}
+	sid, err := uuid.Parse(shareID)
+	if err != nil {
+		return sql.ErrNoRows
+	}
+	return s.repo.DeleteShare(ctx, sid, id, wsID)
+}
+
+// ListSharedWithMe — заметки из чужих воркспейсов, открытые пользователю
+func (s *Service) ListSharedWithMe(ctx context.Context, userID string) ([]model.SharedNote, error) {
+	return s.repo.ListSharedWithUser(ctx, uuid.MustParse(userID))
+}
+
+// GetSharedWithMe — nil, если заметка пользователю не открыта
+func (s *Service) GetSharedWithMe(ctx context.Context, userID, noteID string) (*model.SharedNote, error) {
+	id, err := uuid.Parse(noteID)
+	if err != nil {
+		return nil, nil
+	}
+	return s.repo.GetShared(ctx, id, uuid.MustParse(userID))
+}
+
+// UpdateSharedWithMe правит открытую заметку: нужны права edit. sql.ErrNoRows — заметка не открыта.
+func (s *Service) UpdateSharedWithMe(ctx context.Context, userID, noteID string, dto model.UpdateNoteDto) (*model.SharedNote, error) {
+	shared, err := s.GetSharedWithMe(ctx, userID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if shared == nil {
+		return nil, sql.ErrNoRows
+	}
+	if shared.Permission != model.NotePermissionEdit {
+		return nil, ErrEditForbidden
+	}
+	n, err := s.Update(ctx, shared.WorkspaceID, shared.ID, dto)
+	if err != nil {
+		return nil, err
+	}
+	return &model.SharedNote{Note: *n, Permission: shared.Permission}, nil
+}
+
+// CreateLink создаёт публичную ссылку. Токен возвращается только здесь: в базе хранится его SHA-256.
+func (s *Service) CreateLink(ctx context.Context, workspaceID, noteID, userID string, dto model.CreateNoteLinkDto) (*model.NoteLink, error) {
+	wsID, id, err := parseIDs(workspaceID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	var expiresAt *time.Time
+	if dto.ExpiresAt != nil {
+		t, err := time.Parse(time.RFC3339, *dto.ExpiresAt)
+		if err != nil || !t.After(time.Now()) {
+			return nil, ErrInvalidExpiry
+		}
+		t = t.UTC()
+		expiresAt = &t
+	}
+	if err := s.requireNote(ctx, wsID, id); err != nil {
+		return nil, err
+	}
+
+	var passwordHash string
+	if dto.Password != "" {
+		if passwordHash, err = password.Hash(dto.Password); err != nil {
+			return nil, err
+		}
+	}
+	raw := make([]byte, linkTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("generate link token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	l := &model.NoteLink{NoteID: id.String(), CreatedBy: &userID}
+	if err := s.repo.CreateLink(ctx, l, hashToken(token), passwordHash, expiresAt); err != nil {
+		return nil, err
+	}
+	l.Token = token
+	return l, nil
+}
+
+// ListLinks — ссылки заметки; sql.ErrNoRows — нет заметки
+func (s *Service) ListLinks(ctx context.Context, workspaceID, noteID string) ([]model.NoteLink, error) {
+	wsID, id, err := parseIDs(workspaceID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireNote(ctx, wsID, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListLinks(ctx, id, wsID)
+}
+
+// RevokeLink — ссылка перестаёт открываться, но остаётся в списке вместе с журналом
+func (s *Service) RevokeLink(ctx context.Context, workspaceID, noteID, linkID string) error {
+	wsID, id, err := parseIDs(workspaceID, noteID)
+	if err != nil {
+		return err
+	}
+	lid, err := uuid.Parse(linkID)
+	if err != nil {
+		return sql.ErrNoRows
+	}
+	return s.repo.RevokeLink(ctx, lid, id, wsID)
+}
+
+func (s *Service) LinkAccessLog(ctx context.Context, workspaceID, noteID, linkID string) ([]model.NoteLinkAccess, error) {
+	wsID, id, err := parseIDs(workspaceID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	lid, err := uuid.Parse(linkID)
+	if err != nil {
+		return nil, sql.ErrNoRows
+	}
+	return s.repo.LinkAccessLog(ctx, lid, id, wsID, AccessLogLimit)
+}
+
+// OpenLink открывает заметку по публичной ссылке. Каждое обращение к существующей ссылке пишется в журнал,
+// включая неудачные. Отозванная и истёкшая ссылка неотличимы от несуществующей (ErrLinkNotFound).
+func (s *Service) OpenLink(ctx context.Context, token, pwd, clientIP, userAgent string) (*model.PublicNote, error) {
+	link, err := s.repo.FindLink(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, ErrLinkNotFound
+	}
+
+	outcome, result := s.checkLink(ctx, link, pwd)
+	if outcome != "" {
+		if err := s.repo.LogLinkAccess(ctx, link.ID, outcome, clientIP, userAgent); err != nil {
+			// Журнал не должен ломать открытие ссылки
+			log.Printf("[notes] %v", err)
+		}
+	}
+	if result != nil {
+		return nil, result
+	}
+	return &link.Note, nil
+}
+
+// checkLink возвращает исход для журнала (пусто — внутренняя ошибка, не журналируется) и ошибку для клиента
+func (s *Service) checkLink(ctx context.Context, link *notesRepo.PublicLink, pwd string) (string, error) {
+	switch {
+	case link.Revoked:
+		return model.LinkAccessRevoked, ErrLinkNotFound
+	case link.Expired:
+		return model.LinkAccessExpired, ErrLinkNotFound
+	case link.PasswordHash == "":
+		return model.LinkAccessOK, nil
+	case pwd == "":
+		return model.LinkAccessPasswordRequired, ErrLinkPasswordRequired
+	}
+	failed, err := s.repo.CountFailedAttempts(ctx, link.ID, FailedAttemptsWindow)
+	if err != nil {
+		return "", err
+	}
+	if failed >= MaxFailedAttempts {
+		return model.LinkAccessLocked, ErrLinkLocked
+	}
+	if !password.Check(pwd, link.PasswordHash) {
+		return model.LinkAccessWrongPassword, ErrLinkWrongPassword
+	}
+	return model.LinkAccessOK, nil
+}
+
+func (s *Service) requireNote(ctx context.Context, workspaceID, noteID uuid.UUID) error {
+	n, err := s.repo.Get(ctx, noteID, workspaceID)
+	if err != nil {
+		return err
+	}
+	if n == nil {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,113 @@
+package profile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // декодеры форматов аватара
+	"image/jpeg"
+	_ "image/png"
+	"io"
+)
+
+// AvatarSizes — стороны квадратных аватаров, которые сохраняются при загрузке
+var AvatarSizes = []int{64, 128, 256}
+
+const (
+	// maxAvatarPixels ограничивает размер картинки до декодирования (защита от «бомб» с огромным разрешением)
+	maxAvatarPixels = 40_000_000
+	avatarQuality   = 85
+)
+
+var ErrInvalidImage = errors.New("avatar must be a JPEG, PNG or GIF image")
+
+// renderAvatars декодирует картинку, обрезает её по центру до квадрата и возвращает JPEG для каждого
+// размера из AvatarSizes. Прозрачность заливается белым.
+func renderAvatars(data []byte) (map[int][]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width == 0 || cfg.Height == 0 {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width*cfg.Height > maxAvatarPixels {
+		return nil, fmt.Errorf("%w: image is too large (%dx%d)", ErrInvalidImage, cfg.Width, cfg.Height)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	square := cropSquare(src)
+	out := make(map[int][]byte, len(AvatarSizes))
+	for _, size := range AvatarSizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(square, size), &jpeg.Options{Quality: avatarQuality}); err != nil {
+			return nil, fmt.Errorf("encode avatar: %w", err)
+		}
+		out[size] = buf.Bytes()
+	}
+	return out, nil
+}
+
+// cropSquare вырезает центральный квадрат и переводит его в RGBA на белом фоне
+func cropSquare(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	origin := image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2)
+
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, origin, draw.Over)
+	return dst
+}
+
+// resize масштабирует квадрат до size×size: при уменьшении каждый пиксель — среднее покрываемой им области
+// исходника, при увеличении — ближайший пиксель
+func resize(src *image.RGBA, size int) *image.RGBA {
+	side := src.Bounds().Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := span(y, side, size)
+		for x := 0; x < size; x++ {
+			x0, x1 := span(x, side, size)
+			var r, g, b, n int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4:]
+					r += int(p[0])
+					g += int(p[1])
+					b += int(p[2])
+					n++
+				}
+			}
+			o := dst.PixOffset(x, y)
+			dst.Pix[o] = uint8(r / n)
+			dst.Pix[o+1] = uint8(g / n)
+			dst.Pix[o+2] = uint8(b / n)
+			dst.Pix[o+3] = 0xff
+		}
+	}
+	return dst
+}
+
+// span — диапазон исходных пикселей [from, to) для пикселя i результата; всегда хотя бы один пиксель
+func span(i, side, size int) (int, int) {
+	from := i * side / size
+	to := (i + 1) * side / size
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
+
+// readLimited читает не больше limit байт; ok = false, если данных больше
+func readLimited(r io.Reader, limit int64) (data []byte, ok bool, err error) {
+	data, err = io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, false, err
+	}
+	return data, int64(len(data)) <= limit, nil
+}
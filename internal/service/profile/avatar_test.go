@@ -0,0 +1,109 @@
+package profile
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestRenderAvatars(t *testing.T) {
+	// 300×100: слева красное, в центре синее, справа зелёное — после обрезки по центру остаётся синее
+	src := image.NewNRGBA(image.Rect(0, 0, 300, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 300; x++ {
+			c := color.NRGBA{B: 255, A: 255}
+			if x < 100 {
+				c = color.NRGBA{R: 255, A: 255}
+			} else if x >= 200 {
+				c = color.NRGBA{G: 255, A: 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := renderAvatars(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range AvatarSizes {
+		img, err := jpeg.Decode(bytes.NewReader(out[size]))
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if b := img.Bounds(); b.Dx() != size || b.Dy() != size {
+			t.Fatalf("size %d: got %v", size, b)
+		}
+		r, g, b, _ := img.At(size/2, size/2).RGBA()
+		if b>>8 < 200 || r>>8 > 60 || g>>8 > 60 {
+			t.Errorf("size %d: center = %d,%d,%d, want blue", size, r>>8, g>>8, b>>8)
+		}
+	}
+}
+
+func TestRenderAvatarsTransparentAndSmall(t *testing.T) {
+	// Прозрачная картинка 10×10 увеличивается и заливается белым
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 10, 10))); err != nil {
+		t.Fatal(err)
+	}
+	out, err := renderAvatars(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(out[64]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := img.At(5, 5).RGBA(); r>>8 < 240 || g>>8 < 240 || b>>8 < 240 {
+		t.Errorf("pixel = %d,%d,%d, want white", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestRenderAvatarsRejectsNonImages(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("<svg xmlns='http://www.w3.org/2000/svg'/>"), []byte("\x89PNG\r\n\x1a\n broken")} {
+		if _, err := renderAvatars(data); !errors.Is(err, ErrInvalidImage) {
+			t.Errorf("%q: err = %v, want ErrInvalidImage", data, err)
+		}
+	}
+}
+
+func TestPickSize(t *testing.T) {
+	for in, want := range map[int]int{0: 256, -1: 256, 1: 64, 64: 64, 65: 128, 128: 128, 200: 256, 1000: 256} {
+		if got := pickSize(in); got != want {
+			t.Errorf("pickSize(%d) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestNormalizeLocaleAndTimezone(t *testing.T) {
+	for _, ok := range []string{"ru", "en-US", "zh-Hant-TW"} {
+		if v, err := normalizeLocale(" " + ok + " "); err != nil || *v != ok {
+			t.Errorf("locale %q: %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"r", "русский", "en_US", "en-", "x-very-long-locale-tag"} {
+		if _, err := normalizeLocale(bad); !errors.Is(err, ErrInvalidLocale) {
+			t.Errorf("locale %q: err = %v", bad, err)
+		}
+	}
+	if v, err := normalizeLocale(""); err != nil || v != nil {
+		t.Errorf("empty locale = %v, %v", v, err)
+	}
+
+	if v, err := normalizeTimezone("Europe/Moscow"); err != nil || *v != "Europe/Moscow" {
+		t.Errorf("timezone: %v, %v", v, err)
+	}
+	for _, bad := range []string{"Local", "Mars/Olympus", "../etc/passwd"} {
+		if _, err := normalizeTimezone(bad); !errors.Is(err, ErrInvalidTimezone) {
+			t.Errorf("timezone %q: err = %v", bad, err)
+		}
+	}
+}
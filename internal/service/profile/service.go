@@ -0,0 +1,358 @@
+package profile
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	userRepo "backend/internal/repository/user"
+	"backend/pkg/mailer"
+	"backend/pkg/password"
+	"backend/pkg/storage"
+
+	"github.com/google/uuid"
+)
+
+// emailTokenBytes — энтропия токена подтверждения email (256 бит)
+const emailTokenBytes = 32
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrEmptyName       = errors.New("name cannot be empty")
+	ErrWrongPassword   = errors.New("wrong password")
+	ErrSameEmail       = errors.New("new email is the same as the current one")
+	ErrInvalidLocale   = errors.New("locale must be a language tag like en or ru-RU")
+	ErrInvalidTimezone = errors.New("timezone must be an IANA name like Europe/Moscow")
+	ErrAvatarTooLarge  = errors.New("avatar file is too large")
+	ErrNoAvatar        = errors.New("user has no avatar")
+)
+
+var localeRe = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// Options — ссылка подтверждения email, сроки и ограничения аватара
+type Options struct {
+	// ConfirmEmailURL — страница клиента, куда ведёт письмо; к ней добавляется ?token=
+	ConfirmEmailURL string
+	EmailChangeTTL  time.Duration
+	AvatarMaxSize   int64
+	// AvatarBaseURL — префикс стабильной ссылки на аватар (avatar_url); по ней отдаётся редирект на хранилище
+	AvatarBaseURL string
+	AvatarURLTTL  time.Duration
+}
+
+type Service struct {
+	users *userRepo.PostgresUserRepository
+	store storage.Storage
+	mail  mailer.Mailer
+	opts  Options
+}
+
+func NewService(users *userRepo.PostgresUserRepository, store storage.Storage, mail mailer.Mailer, opts Options) *Service {
+	return &Service{users: users, store: store, mail: mail, opts: opts}
+}
+
+// UpdateProfile меняет имя, язык и часовой пояс; пустые locale и timezone сбрасывают значение
+func (s *Service) UpdateProfile(ctx context.Context, userID string, dto model.UpdateProfileDto) (*model.User, error) {
+	u, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if dto.Name != nil {
+		name := strings.TrimSpace(*dto.Name)
+		if name == "" {
+			return nil, ErrEmptyName
+		}
+		u.Name = &name
+	}
+	if dto.Locale != nil {
+		if u.Locale, err = normalizeLocale(*dto.Locale); err != nil {
+			return nil, err
+		}
+	}
+	if dto.Timezone != nil {
+		if u.Timezone, err = normalizeTimezone(*dto.Timezone); err != nil {
+			return nil, err
+		}
+	}
+	u.UpdatedAt = time.Now()
+	if err := s.users.Update(ctx, u); err != nil {
+		return nil, err
+	}
+	u.Password = ""
+	return u, nil
+}
+
+// RequestEmailChange отправляет ссылку подтверждения на новый адрес. Email меняется только после
+// ConfirmEmailChange; до этого вход — по старому адресу.
+func (s *Service) RequestEmailChange(ctx context.Context, userID string, req model.ChangeEmailRequest) (*model.EmailChange, error) {
+	u, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !password.Check(req.Password, u.Password) {
+		return nil, ErrWrongPassword
+	}
+	newEmail := strings.TrimSpace(req.Email)
+	if strings.EqualFold(newEmail, u.Email) {
+		return nil, ErrSameEmail
+	}
+	taken, err := s.users.EmailInUse(ctx, newEmail, uuid.MustParse(u.ID))
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, userRepo.ErrEmailTaken
+	}
+
+	raw := make([]byte, emailTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("generate email token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	change := &model.EmailChange{NewEmail: newEmail, ExpiresAt: time.Now().Add(s.opts.EmailChangeTTL).UTC()}
+	if err := s.users.CreateEmailChange(ctx, uuid.MustParse(u.ID), newEmail, hashToken(token), change.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	link := s.opts.ConfirmEmailURL + "?token=" + url.QueryEscape(token)
+	if err := s.mail.Send(ctx, mailer.Message{
+		To:      newEmail,
+		Subject: "Подтвердите новый email",
+		Body: "Чтобы сменить email в Habits на этот адрес, перейдите по ссылке:\n\n" + link +
+			"\n\nСсылка действует до " + change.ExpiresAt.Format("2006-01-02 15:04 UTC") +
+			". Если вы не меняли email, просто проигнорируйте письмо.\n",
+	}); err != nil {
+		return nil, fmt.Errorf("send confirmation email: %w", err)
+	}
+	// Уведомление на старый адрес — чтобы владелец заметил чужую попытку; его ошибка не мешает смене
+	if err := s.mail.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Запрошена смена email",
+		Body: "Для вашего аккаунта Habits запрошена смена email на " + newEmail +
+			". Адрес изменится, только когда его подтвердят по ссылке из письма на новый адрес.\n",
+	}); err != nil {
+		log.Printf("[profile] notify old email: %v", err)
+	}
+	return change, nil
+}
+
+// ConfirmEmailChange применяет смену email по токену из письма.
+// Ошибки: userRepo.ErrEmailChangeNotFound, userRepo.ErrEmailTaken.
+func (s *Service) ConfirmEmailChange(ctx context.Context, token string) (*model.User, error) {
+	userID, err := s.users.ConfirmEmailChange(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	u.Password = ""
+	return u, nil
+}
+
+// UploadAvatar сохраняет аватар во всех размерах AvatarSizes и заменяет им прежний.
+// Ошибки: ErrAvatarTooLarge, ErrInvalidImage.
+func (s *Service) UploadAvatar(ctx context.Context, userID string, r io.Reader) (*model.User, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	data, ok, err := readLimited(r, s.opts.AvatarMaxSize)
+	if err != nil {
+		return nil, fmt.Errorf("read avatar: %w", err)
+	}
+	if !ok {
+		return nil, ErrAvatarTooLarge
+	}
+	images, err := renderAvatars(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Новый префикс на каждую загрузку: старые ссылки и кэши браузеров не покажут прежнюю картинку
+	rnd := make([]byte, 8)
+	if _, err := rand.Read(rnd); err != nil {
+		return nil, fmt.Errorf("generate avatar version: %w", err)
+	}
+	version := hex.EncodeToString(rnd)
+	prefix := "avatars/" + uid.String() + "/" + version
+	var stored []string
+	for _, size := range AvatarSizes {
+		key := avatarKey(prefix, size)
+		if err := s.store.Put(ctx, key, bytes.NewReader(images[size]), int64(len(images[size])), "image/jpeg"); err != nil {
+			s.deleteFiles(stored)
+			return nil, err
+		}
+		stored = append(stored, key)
+	}
+	avatarURL := s.opts.AvatarBaseURL + "/" + uid.String() + "?v=" + version
+	if err := s.users.SetAvatar(ctx, uid, &prefix, &avatarURL, avatarKeys); err != nil {
+		s.deleteFiles(stored)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	u, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	u.Password = ""
+	return u, nil
+}
+
+// DeleteAvatar убирает аватар; файлы удаляет фоновый воркер очистки хранилища
+func (s *Service) DeleteAvatar(ctx context.Context, userID string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if err := s.users.SetAvatar(ctx, uid, nil, nil, avatarKeys); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// AvatarURL — подписанная ссылка на аватар наименьшего размера не меньше size (0 — наибольший).
+// ErrNoAvatar — аватара нет или пользователь удалён.
+func (s *Service) AvatarURL(ctx context.Context, userID string, size int) (string, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return "", ErrNoAvatar
+	}
+	prefix, err := s.users.AvatarKey(ctx, uid)
+	if err != nil {
+		return "", err
+	}
+	if prefix == "" {
+		return "", ErrNoAvatar
+	}
+	return s.store.SignedURL(ctx, avatarKey(prefix, pickSize(size)), storage.URLOptions{
+		TTL:         s.opts.AvatarURLTTL,
+		ContentType: "image/jpeg",
+		Inline:      true,
+	})
+}
+
+// AvatarURLTTL — срок подписанной ссылки на аватар, чтобы отдать клиенту подходящий Cache-Control
+func (s *Service) AvatarURLTTL() time.Duration {
+	return s.opts.AvatarURLTTL
+}
+
+// DeleteAccount удаляет свой аккаунт после проверки пароля (см. userRepo.DeleteAccount).
+// При userRepo.ErrSharedWorkspaces возвращает воркспейсы, которые нужно передать или удалить явно.
+func (s *Service) DeleteAccount(ctx context.Context, userID string, req model.DeleteAccountRequest) ([]model.OwnedWorkspace, error) {
+	u, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !password.Check(req.Password, u.Password) {
+		return nil, ErrWrongPassword
+	}
+	transfers := make(map[uuid.UUID]uuid.UUID, len(req.Transfers))
+	for wsID, to := range req.Transfers {
+		w, err1 := uuid.Parse(wsID)
+		t, err2 := uuid.Parse(to)
+		if err1 != nil || err2 != nil {
+			return nil, userRepo.ErrInvalidTransfer
+		}
+		transfers[w] = t
+	}
+	unresolved, err := s.users.DeleteAccount(ctx, uuid.MustParse(u.ID), transfers, req.DeleteSharedWorkspaces, avatarKeys)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return unresolved, err
+}
+
+func (s *Service) activeUser(ctx context.Context, userID string) (*model.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+	u, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+	return u, nil
+}
+
+// deleteFiles убирает уже загруженные файлы, если аватар сохранить не удалось
+func (s *Service) deleteFiles(keys []string) {
+	for _, key := range keys {
+		if err := s.store.Delete(context.Background(), key); err != nil {
+			log.Printf("[profile] delete orphan %s: %v", key, err)
+		}
+	}
+}
+
+func avatarKey(prefix string, size int) string {
+	return fmt.Sprintf("%s/%d.jpg", prefix, size)
+}
+
+// avatarKeys — все файлы аватара с данным префиксом
+func avatarKeys(prefix string) []string {
+	keys := make([]string, len(AvatarSizes))
+	for i, size := range AvatarSizes {
+		keys[i] = avatarKey(prefix, size)
+	}
+	return keys
+}
+
+func pickSize(size int) int {
+	for _, s := range AvatarSizes {
+		if size > 0 && s >= size {
+			return s
+		}
+	}
+	return AvatarSizes[len(AvatarSizes)-1]
+}
+
+func normalizeLocale(v string) (*string, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, nil
+	}
+	if len(v) > 16 || !localeRe.MatchString(v) {
+		return nil, ErrInvalidLocale
+	}
+	return &v, nil
+}
+
+func normalizeTimezone(v string) (*string, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, nil
+	}
+	if len(v) > 64 || v == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+	if _, err := time.LoadLocation(v); err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return &v, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,250 @@
+package profile_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	userRepo "backend/internal/repository/user"
+	authService "backend/internal/service/auth"
+	"backend/internal/service/profile"
+	"backend/internal/testutil/pgtest"
+	"backend/pkg/mailer"
+	"backend/pkg/password"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+type captureMailer struct{ sent []mailer.Message }
+
+func (m *captureMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+const pass = "correct horse battery"
+
+func newService(t *testing.T, env *pgtest.Env) (*profile.Service, *captureMailer) {
+	mail := &captureMailer{}
+	return profile.NewService(env.Container.UserRepository, env.Container.Storage, mail, profile.Options{
+		ConfirmEmailURL: "http://client.test/confirm-email",
+		EmailChangeTTL:  time.Hour,
+		AvatarMaxSize:   1 << 20,
+		AvatarBaseURL:   "/api/v1/avatars",
+		AvatarURLTTL:    time.Minute,
+	}), mail
+}
+
+func setPassword(t *testing.T, env *pgtest.Env, u *model.User) {
+	t.Helper()
+	hash, err := password.Hash(pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.DB.Exec(`UPDATE users SET password = $2 WHERE id = $1`, u.ID, hash); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProfileUpdateAndEmailChange(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc, mail := newService(t, env)
+	u := env.CreateUser(t)
+	other := env.CreateUser(t)
+	setPassword(t, env, u)
+
+	name, locale, tz := "  Анна ", "ru-RU", "Europe/Moscow"
+	got, err := svc.UpdateProfile(ctx, u.ID, model.UpdateProfileDto{Name: &name, Locale: &locale, Timezone: &tz})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got.Name != "Анна" || *got.Locale != "ru-RU" || *got.Timezone != "Europe/Moscow" || got.Password != "" {
+		t.Fatalf("updated = %+v", got)
+	}
+	bad := "Mars/Base"
+	if _, err := svc.UpdateProfile(ctx, u.ID, model.UpdateProfileDto{Timezone: &bad}); !errors.Is(err, profile.ErrInvalidTimezone) {
+		t.Fatalf("bad timezone: %v", err)
+	}
+	empty := ""
+	got, err = svc.UpdateProfile(ctx, u.ID, model.UpdateProfileDto{Locale: &empty})
+	if err != nil || got.Locale != nil || *got.Timezone != "Europe/Moscow" {
+		t.Fatalf("clear locale: %+v, %v", got, err)
+	}
+
+	// Смена email: пароль, занятый адрес, письмо со ссылкой
+	if _, err := svc.RequestEmailChange(ctx, u.ID, model.ChangeEmailRequest{Email: "new@pgtest.local", Password: "wrong"}); !errors.Is(err, profile.ErrWrongPassword) {
+		t.Fatalf("wrong password: %v", err)
+	}
+	if _, err := svc.RequestEmailChange(ctx, u.ID, model.ChangeEmailRequest{Email: strings.ToUpper(other.Email), Password: pass}); !errors.Is(err, userRepo.ErrEmailTaken) {
+		t.Fatalf("taken email: %v", err)
+	}
+	if _, err := svc.RequestEmailChange(ctx, u.ID, model.ChangeEmailRequest{Email: "new@pgtest.local", Password: pass}); err != nil {
+		t.Fatal(err)
+	}
+	if len(mail.sent) != 2 || mail.sent[0].To != "new@pgtest.local" || mail.sent[1].To != u.Email {
+		t.Fatalf("sent = %+v", mail.sent)
+	}
+	token := confirmToken(t, mail.sent[0].Body)
+
+	// До подтверждения email прежний
+	if cur, _ := env.Container.UserRepository.FindByID(ctx, u.ID); cur.Email != u.Email {
+		t.Fatalf("email changed before confirmation: %s", cur.Email)
+	}
+	if _, err := svc.ConfirmEmailChange(ctx, "not-a-token"); !errors.Is(err, userRepo.ErrEmailChangeNotFound) {
+		t.Fatalf("unknown token: %v", err)
+	}
+	confirmed, err := svc.ConfirmEmailChange(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if confirmed.Email != "new@pgtest.local" {
+		t.Fatalf("email = %s", confirmed.Email)
+	}
+	if _, err := svc.ConfirmEmailChange(ctx, token); !errors.Is(err, userRepo.ErrEmailChangeNotFound) {
+		t.Fatalf("token reused: %v", err)
+	}
+
+	// Истёкшая заявка не применяется
+	if _, err := svc.RequestEmailChange(ctx, u.ID, model.ChangeEmailRequest{Email: "later@pgtest.local", Password: pass}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.DB.Exec(`UPDATE email_change_requests SET expires_at = NOW() AT TIME ZONE 'UTC' - INTERVAL '1 minute'`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ConfirmEmailChange(ctx, confirmToken(t, mail.sent[2].Body)); !errors.Is(err, userRepo.ErrEmailChangeNotFound) {
+		t.Fatalf("expired token: %v", err)
+	}
+}
+
+func TestAvatarAndAccountDeletion(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc, _ := newService(t, env)
+	u := env.CreateUser(t)
+	member := env.CreateUser(t)
+	outsider := env.CreateUser(t)
+	setPassword(t, env, u)
+	solo := env.CreateWorkspace(t, u)
+	shared := env.CreateWorkspace(t, u)
+	env.AddMember(t, shared, member)
+	foreign := env.CreateWorkspace(t, member)
+	env.AddMember(t, foreign, u)
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 400, 300))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.UploadAvatar(ctx, u.ID, strings.NewReader("not an image")); !errors.Is(err, profile.ErrInvalidImage) {
+		t.Fatalf("non-image: %v", err)
+	}
+	first, err := svc.UploadAvatar(ctx, u.ID, bytes.NewReader(img.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.AvatarURL == nil || !strings.HasPrefix(*first.AvatarURL, "/api/v1/avatars/"+u.ID+"?v=") {
+		t.Fatalf("avatarUrl = %v", first.AvatarURL)
+	}
+	signed, err := svc.AvatarURL(ctx, u.ID, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(signed, "/128.jpg?") {
+		t.Fatalf("signed url = %s", signed)
+	}
+	if _, err := svc.AvatarURL(ctx, member.ID, 0); !errors.Is(err, profile.ErrNoAvatar) {
+		t.Fatalf("no avatar: %v", err)
+	}
+
+	// Новый аватар — прежние файлы в очереди на удаление
+	if _, err := svc.UploadAvatar(ctx, u.ID, bytes.NewReader(img.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if n := queued(t, env); n != len(profile.AvatarSizes) {
+		t.Fatalf("queued after replace = %d", n)
+	}
+
+	// Удаление: пароль, общий воркспейс без решения, передача не участнику
+	del := model.DeleteAccountRequest{Password: pass}
+	if _, err := svc.DeleteAccount(ctx, u.ID, model.DeleteAccountRequest{Password: "wrong"}); !errors.Is(err, profile.ErrWrongPassword) {
+		t.Fatalf("wrong password: %v", err)
+	}
+	unresolved, err := svc.DeleteAccount(ctx, u.ID, del)
+	if !errors.Is(err, userRepo.ErrSharedWorkspaces) || len(unresolved) != 1 || unresolved[0].ID != shared.ID || unresolved[0].Members != 1 {
+		t.Fatalf("shared: %+v, %v", unresolved, err)
+	}
+	del.Transfers = map[string]string{shared.ID: outsider.ID}
+	if _, err := svc.DeleteAccount(ctx, u.ID, del); !errors.Is(err, userRepo.ErrInvalidTransfer) {
+		t.Fatalf("transfer to outsider: %v", err)
+	}
+	del.Transfers = map[string]string{foreign.ID: member.ID}
+	if _, err := svc.DeleteAccount(ctx, u.ID, del); !errors.Is(err, userRepo.ErrInvalidTransfer) {
+		t.Fatalf("transfer of foreign workspace: %v", err)
+	}
+	if cur, _ := env.Container.UserRepository.FindByID(ctx, u.ID); cur == nil {
+		t.Fatal("user deleted by a failed attempt")
+	}
+
+	del.Transfers = map[string]string{shared.ID: member.ID}
+	if _, err := svc.DeleteAccount(ctx, u.ID, del); err != nil {
+		t.Fatal(err)
+	}
+	if cur, _ := env.Container.UserRepository.FindByID(ctx, u.ID); cur != nil {
+		t.Fatal("user still active")
+	}
+	var soloLeft, uMemberships int
+	var sharedOwner, memberRole string
+	env.DB.QueryRow(`SELECT COUNT(*) FROM workspaces WHERE id = $1`, solo.ID).Scan(&soloLeft)
+	env.DB.QueryRow(`SELECT owner_id FROM workspaces WHERE id = $1`, shared.ID).Scan(&sharedOwner)
+	env.DB.QueryRow(`SELECT role FROM user_workspaces WHERE workspace_id = $1 AND user_id = $2`, shared.ID, member.ID).Scan(&memberRole)
+	env.DB.QueryRow(`SELECT COUNT(*) FROM user_workspaces WHERE user_id = $1`, u.ID).Scan(&uMemberships)
+	if soloLeft != 0 || sharedOwner != member.ID || memberRole != "OWNER" || uMemberships != 0 {
+		t.Fatalf("solo left = %d, shared owner = %s, member role = %s, memberships = %d", soloLeft, sharedOwner, memberRole, uMemberships)
+	}
+	if n := queued(t, env); n != 2*len(profile.AvatarSizes) {
+		t.Fatalf("queued after delete = %d", n)
+	}
+	if _, err := svc.AvatarURL(ctx, u.ID, 0); !errors.Is(err, profile.ErrNoAvatar) {
+		t.Fatalf("avatar of deleted user: %v", err)
+	}
+
+	// Повторная регистрация возвращает аккаунт с новым воркспейсом
+	auth := authService.NewService(env.Container.UserRepository, env.Container.WorkspaceService, env.Container.TokenGen, time.Hour)
+	resp, err := auth.Register(ctx, model.RegisterRequest{Email: u.Email, Password: pass, Name: "Again"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := env.Container.WorkspaceService.List(ctx, resp.User.ID, model.UserRoleUser)
+	if err != nil || resp.User.ID != u.ID || len(list) != 1 || list[0].OwnerID != u.ID {
+		t.Fatalf("reactivated: id %s, workspaces %+v, %v", resp.User.ID, list, err)
+	}
+}
+
+func confirmToken(t *testing.T, body string) string {
+	t.Helper()
+	i := strings.Index(body, "http://client.test/confirm-email?")
+	if i < 0 {
+		t.Fatalf("no link in %q", body)
+	}
+	link, err := url.Parse(strings.Fields(body[i:])[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return link.Query().Get("token")
+}
+
+func queued(t *testing.T, env *pgtest.Env) int {
+	t.Helper()
+	var n int
+	if err := env.DB.QueryRow(`SELECT COUNT(*) FROM attachment_purge_queue WHERE storage_key LIKE 'avatars/%'`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
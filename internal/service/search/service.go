@@ -0,0 +1,162 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"backend/internal/model"
+	"backend/internal/repository/search"
+	workspaceService "backend/internal/service/workspace"
+
+	"github.com/google/uuid"
+)
+
+// MaxQueryLength — максимальная длина поисковой строки в символах
+const MaxQueryLength = 200
+
+var (
+	ErrEmptyQuery   = errors.New("search query must contain at least one word")
+	ErrQueryTooLong = errors.New("search query is too long")
+	ErrUnknownType  = errors.New("unknown search type")
+)
+
+// typeModules — модуль, который должен быть включён в воркспейсе, чтобы искать по типу.
+// Контрагенты — общие справочники (Shared Schema), доступны без модуля.
+var typeModules = map[string]string{
+	model.SearchTypeNote:         model.ModuleCodeNotes,
+	model.SearchTypeJournal:      model.ModuleCodeHabits,
+	model.SearchTypeHabit:        model.ModuleCodeHabits,
+	model.SearchTypeCounterparty: "",
+}
+
+type Service struct {
+	repo         *search.Repository
+	workspaceSvc *workspaceService.Service
+}
+
+func NewService(repo *search.Repository, workspaceSvc *workspaceService.Service) *Service {
+	return &Service{repo: repo, workspaceSvc: workspaceSvc}
+}
+
+// Search ищет по воркспейсу. types пустой — все типы. Ищем только там, куда у пользователя есть доступ:
+// участник воркспейса (или админ) и модуль типа включён в воркспейсе.
+func (s *Service) Search(ctx context.Context, workspaceID, userID string, role model.UserRole, text string, types []string, limit, offset int) (*model.SearchResult, error) {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) > MaxQueryLength {
+		return nil, ErrQueryTooLong
+	}
+	prefix := PrefixQuery(text)
+	if prefix == "" {
+		return nil, ErrEmptyQuery
+	}
+
+	requested := model.SearchTypes
+	if len(types) > 0 {
+		for _, t := range types {
+			if _, ok := typeModules[t]; !ok {
+				return nil, ErrUnknownType
+			}
+		}
+		requested = types
+	}
+
+	wsID, err := uuid.Parse(workspaceID)
+	if err != nil {
+		return nil, workspaceService.ErrWorkspaceNotFound
+	}
+
+	// GetWorkspaceModules заодно проверяет доступ к воркспейсу (ErrAccessDenied)
+	modules, err := s.workspaceSvc.GetWorkspaceModules(ctx, workspaceID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	enabled := make(map[string]bool, len(modules))
+	for _, m := range modules {
+		enabled[m.ModuleName] = m.Enabled || m.Status == model.WorkspaceModuleStatusTrial
+	}
+
+	allowed := make([]string, 0, len(requested))
+	for _, t := range model.SearchTypes {
+		if !slices.Contains(requested, t) {
+			continue
+		}
+		if module := typeModules[t]; module == "" || enabled[module] {
+			allowed = append(allowed, t)
+		}
+	}
+
+	res := &model.SearchResult{
+		Query:  text,
+		Types:  allowed,
+		Counts: make(map[string]int, len(allowed)),
+		Limit:  limit,
+		Offset: offset,
+		Hits:   []model.SearchHit{},
+	}
+	if len(allowed) == 0 {
+		return res, nil
+	}
+
+	hits, counts, err := s.repo.Search(ctx, wsID, search.Query{
+		Text:    text,
+		Prefix:  prefix,
+		Exclude: ExcludeQuery(text),
+		Types:   allowed,
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range allowed {
+		res.Counts[t] = counts[t]
+		res.Total += counts[t]
+	}
+	res.Hits = hits
+	return res, nil
+}
+
+// PrefixQuery строит запрос to_tsquery('simple') «каждое слово — начало слова в документе»:
+// "прив зар" -> "прив:* & зар:*". Слова-исключения (-слово) и оператор or пропускаются —
+// их обрабатывают websearch_to_tsquery и ExcludeQuery. Пустая строка — в запросе нет ни одного слова.
+func PrefixQuery(text string) string {
+	var terms []string
+	for _, field := range strings.Fields(text) {
+		if strings.HasPrefix(field, "-") || strings.EqualFold(field, "or") {
+			continue
+		}
+		for _, w := range queryWords(field) {
+			terms = append(terms, w+":*")
+		}
+	}
+	return strings.Join(terms, " & ")
+}
+
+// ExcludeQuery собирает слова-исключения (-слово) для websearch_to_tsquery: "кофе -сахар -мёд" -> "сахар or мёд".
+// Документ с любым из них не находится. Пустая строка — исключений нет.
+func ExcludeQuery(text string) string {
+	var terms []string
+	for _, field := range strings.Fields(text) {
+		rest, ok := strings.CutPrefix(field, "-")
+		if !ok {
+			continue
+		}
+		for _, w := range queryWords(rest) {
+			if !strings.EqualFold(w, "or") {
+				terms = append(terms, w)
+			}
+		}
+	}
+	return strings.Join(terms, " or ")
+}
+
+// queryWords — буквенно-цифровые слова поля запроса: кавычки, дефисы и прочие знаки отбрасываются
+func queryWords(field string) []string {
+	return strings.FieldsFunc(field, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
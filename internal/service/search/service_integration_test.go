@@ -0,0 +1,110 @@
+package search_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"backend/internal/model"
+	journalRepo "backend/internal/repository/journal"
+	masterRepo "backend/internal/repository/master"
+	notesRepo "backend/internal/repository/notes"
+	searchRepo "backend/internal/repository/search"
+	"backend/internal/service/search"
+	"backend/internal/service/workspace"
+	"backend/internal/testutil/pgtest"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+func TestSearch(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc := search.NewService(searchRepo.NewRepository(env.DB), env.Container.WorkspaceService)
+
+	owner := env.CreateUser(t)
+	stranger := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+
+	env.CreateHabit(t, owner, ws, model.CreateHabitDto{Title: "Утренняя зарядка", Description: "Десять минут упражнений"})
+	env.CreateHabit(t, owner, ws, model.CreateHabitDto{Title: "Running", Description: "Easy pace"})
+
+	note := &model.Note{WorkspaceID: ws.ID, UserID: owner.ID, Title: "Список покупок", Content: "молоко, зарядка для телефона"}
+	if err := notesRepo.NewRepository(env.DB).Create(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	email := "sales@northwind.example.com"
+	if err := masterRepo.NewRepository(env.DB).CreateCounterparty(ctx, &model.Counterparty{WorkspaceID: ws.ID, Name: "Northwind Trading", Type: "client", Email: &email}); err != nil {
+		t.Fatal(err)
+	}
+	order := &model.Note{WorkspaceID: ws.ID, UserID: owner.ID, Title: "Заказ <img src=x onerror=alert(1)>", Content: "курьер"}
+	if err := notesRepo.NewRepository(env.DB).Create(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+	entry := &model.JournalEntry{WorkspaceID: ws.ID, UserID: owner.ID, Description: "Сделал зарядку на балконе", Tags: []string{"спорт"}, ContentType: "text"}
+	if err := journalRepo.NewRepository(env.DB).Create(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(q string, types ...string) *model.SearchResult {
+		t.Helper()
+		res, err := svc.Search(ctx, ws.ID, owner.ID, owner.Role, q, types, 20, 0)
+		if err != nil {
+			t.Fatalf("Search(%q): %v", q, err)
+		}
+		return res
+	}
+
+	// Модуль заметок не включён — заметки не ищутся, остальное ищется по словоформам (russian)
+	res := run("зарядки")
+	if res.Counts[model.SearchTypeHabit] != 1 || res.Counts[model.SearchTypeJournal] != 1 {
+		t.Errorf("counts for «зарядки» = %v", res.Counts)
+	}
+	for _, typ := range res.Types {
+		if typ == model.SearchTypeNote {
+			t.Errorf("notes searched while module is disabled: %v", res.Types)
+		}
+	}
+	if len(res.Hits) == 0 || res.Hits[0].Type != model.SearchTypeHabit || !strings.Contains(res.Hits[0].Title, "<mark>") {
+		t.Errorf("expected highlighted habit title first, got %+v", res.Hits)
+	}
+
+	if err := env.Container.WorkspaceService.EnableModule(ctx, ws.ID, owner.ID, model.UserRoleAdmin, model.ModuleCodeNotes); err != nil {
+		t.Fatal(err)
+	}
+	if res := run("зарядки", model.SearchTypeNote); res.Total != 1 || res.Hits[0].ID != note.ID {
+		t.Errorf("note search after enabling module: %+v", res)
+	}
+
+	// Текст экранирован, подсветка — только наши <mark>
+	if res := run("заказ", model.SearchTypeNote); res.Total != 1 || res.Hits[0].Title != "<mark>Заказ</mark> &lt;img src=x onerror=alert(1)&gt;" {
+		t.Errorf("escaped title: %+v", res.Hits)
+	}
+
+	if res := run("run"); res.Counts[model.SearchTypeHabit] != 1 || !strings.Contains(res.Hits[0].Title, "<mark>Running</mark>") {
+		t.Errorf("english stemming: «run» should find and highlight «Running», got %+v", res)
+	}
+	if res := run("northw", model.SearchTypeCounterparty); res.Total != 1 || !strings.Contains(res.Hits[0].Title, "<mark>Northwind</mark>") {
+		t.Errorf("prefix search on counterparty: %+v", res)
+	}
+	if res := run("спорт", model.SearchTypeJournal); res.Total != 1 || len(res.Hits[0].Tags) != 1 {
+		t.Errorf("journal tag search: %+v", res)
+	}
+	if res := run("зарядка -телефона", model.SearchTypeNote); res.Total != 0 {
+		t.Errorf("exclusion ignored: %+v", res.Hits)
+	}
+	if res := run("зар -телефон", model.SearchTypeNote); res.Total != 0 {
+		t.Errorf("exclusion ignored by prefix search: %+v", res.Hits)
+	}
+
+	if _, err := svc.Search(ctx, ws.ID, stranger.ID, stranger.Role, "зарядка", nil, 20, 0); !errors.Is(err, workspace.ErrAccessDenied) {
+		t.Errorf("stranger: err = %v, want ErrAccessDenied", err)
+	}
+	if _, err := svc.Search(ctx, ws.ID, owner.ID, owner.Role, "зарядка", []string{"file"}, 20, 0); !errors.Is(err, search.ErrUnknownType) {
+		t.Errorf("unknown type: err = %v", err)
+	}
+	if _, err := svc.Search(ctx, ws.ID, owner.ID, owner.Role, " !! ", nil, 20, 0); !errors.Is(err, search.ErrEmptyQuery) {
+		t.Errorf("empty query: err = %v", err)
+	}
+}
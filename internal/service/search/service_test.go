@@ -0,0 +1,32 @@
+package search
+
+import "testing"
+
+func TestPrefixQuery(t *testing.T) {
+	for in, want := range map[string]string{
+		"прив зар":           "прив:* & зар:*",
+		`"утренняя зарядка"`: "утренняя:* & зарядка:*",
+		"кофе -сахар":        "кофе:*",
+		"чай or кофе":        "чай:* & кофе:*",
+		"e-mail!":            "e:* & mail:*",
+		"  -- ":              "",
+	} {
+		if got := PrefixQuery(in); got != want {
+			t.Errorf("PrefixQuery(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExcludeQuery(t *testing.T) {
+	for in, want := range map[string]string{
+		"кофе -сахар":         "сахар",
+		"кофе -сахар -мёд":    "сахар or мёд",
+		`чай -"зелёный лист"`: "зелёный",
+		"e-mail":              "",
+		"чай -or --":          "",
+	} {
+		if got := ExcludeQuery(in); got != want {
+			t.Errorf("ExcludeQuery(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
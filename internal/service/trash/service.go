@@ -0,0 +1,133 @@
+// Package trash — корзина воркспейса. Удалённые привычки, заметки и записи дневника хранятся срок retention:
+// их можно восстановить или удалить окончательно раньше, а по истечении срока их удаляет worker.TrashPurge.
+package trash
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"backend/internal/model"
+	habitsRepo "backend/internal/repository/habits"
+	journalRepo "backend/internal/repository/journal"
+	notesRepo "backend/internal/repository/notes"
+	trashRepo "backend/internal/repository/trash"
+
+	"github.com/google/uuid"
+)
+
+var ErrUnknownType = errors.New("unknown trash item type")
+
+type Service struct {
+	repo      *trashRepo.Repository
+	habits    *habitsRepo.Repository
+	notes     *notesRepo.Repository
+	journal   *journalRepo.Repository
+	retention time.Duration
+}
+
+func NewService(repo *trashRepo.Repository, habits *habitsRepo.Repository, notes *notesRepo.Repository, journal *journalRepo.Repository, retention time.Duration) *Service {
+	return &Service{repo: repo, habits: habits, notes: notes, journal: journal, retention: retention}
+}
+
+// List — корзина воркспейса; привычки — только свои. types пустой — все типы, неизвестный тип — ErrUnknownType.
+func (s *Service) List(ctx context.Context, workspaceID, userID string, types []string) ([]model.TrashItem, error) {
+	for _, t := range types {
+		if !slices.Contains(model.TrashTypes, t) {
+			return nil, ErrUnknownType
+		}
+	}
+	wsID, err := uuid.Parse(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.List(ctx, wsID, uid, types)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]model.TrashItem, len(items))
+	for i, it := range items {
+		list[i] = model.TrashItem{
+			Type:      it.Type,
+			ID:        it.ID,
+			Title:     it.Title,
+			DeletedAt: it.DeletedAt.Format(time.RFC3339),
+		}
+		if !it.PurgeExempt {
+			list[i].PurgeAt = it.DeletedAt.Add(s.retention).Format(time.RFC3339)
+		}
+	}
+	return list, nil
+}
+
+// Restore возвращает элемент из корзины. sql.ErrNoRows — его нет в корзине воркспейса (или это чужая привычка).
+func (s *Service) Restore(ctx context.Context, workspaceID, userID, itemType, itemID string) error {
+	wsID, uid, id, err := parseIDs(workspaceID, userID, itemID)
+	if err != nil {
+		return err
+	}
+	switch itemType {
+	case model.SearchTypeNote:
+		return s.notes.Restore(ctx, id, wsID)
+	case model.SearchTypeJournal:
+		return s.journal.Restore(ctx, id, wsID)
+	case model.SearchTypeHabit:
+		return s.habits.Restore(ctx, id, uid, wsID)
+	}
+	return ErrUnknownType
+}
+
+// Purge удаляет элемент из корзины окончательно. sql.ErrNoRows — его нет в корзине воркспейса.
+func (s *Service) Purge(ctx context.Context, workspaceID, userID, itemType, itemID string) error {
+	wsID, uid, id, err := parseIDs(workspaceID, userID, itemID)
+	if err != nil {
+		return err
+	}
+	switch itemType {
+	case model.SearchTypeNote:
+		return s.notes.Purge(ctx, id, wsID)
+	case model.SearchTypeJournal:
+		return s.journal.Purge(ctx, id, wsID)
+	case model.SearchTypeHabit:
+		return s.habits.Purge(ctx, id, uid, wsID)
+	}
+	return ErrUnknownType
+}
+
+// Empty очищает корзину воркспейса (привычки — только свои) и возвращает число удалённых элементов
+func (s *Service) Empty(ctx context.Context, workspaceID, userID string) (int, error) {
+	wsID, err := uuid.Parse(workspaceID)
+	if err != nil {
+		return 0, err
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.Empty(ctx, wsID, uid)
+}
+
+// PurgeExpired окончательно удаляет всё, что пролежало в корзине дольше retention
+func (s *Service) PurgeExpired(ctx context.Context) (int, error) {
+	return s.repo.PurgeExpired(ctx, time.Now().UTC().Add(-s.retention))
+}
+
+// parseIDs — некорректный id элемента означает, что его нет в корзине
+func parseIDs(workspaceID, userID, itemID string) (wsID, uid, id uuid.UUID, err error) {
+	if wsID, err = uuid.Parse(workspaceID); err != nil {
+		return
+	}
+	if uid, err = uuid.Parse(userID); err != nil {
+		return
+	}
+	if id, err = uuid.Parse(itemID); err != nil {
+		err = sql.ErrNoRows
+	}
+	return
+}
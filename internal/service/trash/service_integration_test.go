@@ -0,0 +1,213 @@
+package trash_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	journalRepo "backend/internal/repository/journal"
+	notesRepo "backend/internal/repository/notes"
+	"backend/internal/service/trash"
+	"backend/internal/testutil/pgtest"
+
+	"github.com/google/uuid"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+func TestTrashRestorePurgeAndExpire(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc := env.Container.TrashService
+	habits := env.Container.HabitsService
+	notes := notesRepo.NewRepository(env.DB)
+	journal := journalRepo.NewRepository(env.DB)
+	owner := env.CreateUser(t)
+	member := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+	env.AddMember(t, ws, member)
+	wsID := uuid.MustParse(ws.ID)
+
+	count := func(q string, args ...interface{}) int {
+		t.Helper()
+		var n int
+		if err := env.DB.QueryRowContext(ctx, q, args...).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	var noteID, entryID string
+	if err := env.DB.QueryRowContext(ctx, `INSERT INTO notes (workspace_id, user_id, title) VALUES ($1, $2, 'Черновик') RETURNING id`,
+		ws.ID, owner.ID).Scan(&noteID); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.DB.QueryRowContext(ctx, `INSERT INTO journal_entries (workspace_id, user_id, description, mood, date)
+		VALUES ($1, $2, 'Хороший день', 4, '2026-10-01') RETURNING id`, ws.ID, owner.ID).Scan(&entryID); err != nil {
+		t.Fatal(err)
+	}
+	habit := env.CreateHabit(t, owner, ws, model.CreateHabitDto{Title: "Бег"})
+	if _, err := habits.Complete(ctx, habit.ID, owner.ID, ws.ID, time.Now(), "", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := notes.Delete(ctx, uuid.MustParse(noteID), wsID); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Delete(ctx, uuid.MustParse(entryID), wsID); err != nil {
+		t.Fatal(err)
+	}
+	if err := habits.Delete(ctx, habit.ID, owner.ID, ws.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Удалённое не видно через обычные запросы
+	if n, _ := notes.Get(ctx, uuid.MustParse(noteID), wsID); n != nil {
+		t.Fatal("trashed note is visible")
+	}
+	if e, _ := journal.Get(ctx, uuid.MustParse(entryID), wsID); e != nil {
+		t.Fatal("trashed journal entry is visible")
+	}
+	if _, err := habits.Get(ctx, habit.ID, owner.ID, ws.ID); err == nil {
+		t.Fatal("trashed habit is visible")
+	}
+
+	items, err := svc.List(ctx, ws.ID, owner.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("owner trash = %+v", items)
+	}
+	for _, it := range items {
+		deleted, _ := time.Parse(time.RFC3339, it.DeletedAt)
+		purge, _ := time.Parse(time.RFC3339, it.PurgeAt)
+		if purge.Sub(deleted) != 30*24*time.Hour {
+			t.Fatalf("purgeAt = %s, deletedAt = %s", it.PurgeAt, it.DeletedAt)
+		}
+	}
+	// Чужие привычки в корзине не видны и не восстанавливаются
+	if items, _ := svc.List(ctx, ws.ID, member.ID, nil); len(items) != 2 {
+		t.Fatalf("member trash = %+v", items)
+	}
+	if err := svc.Restore(ctx, ws.ID, member.ID, model.SearchTypeHabit, habit.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("foreign habit restore: err = %v", err)
+	}
+	if items, _ := svc.List(ctx, ws.ID, owner.ID, []string{model.SearchTypeHabit}); len(items) != 1 || items[0].Title != "Бег" {
+		t.Fatalf("habit trash = %+v", items)
+	}
+	if _, err := svc.List(ctx, ws.ID, owner.ID, []string{"folder"}); !errors.Is(err, trash.ErrUnknownType) {
+		t.Fatalf("unknown type: err = %v", err)
+	}
+
+	// Восстановленная привычка снова видна вместе с выполнениями
+	if err := svc.Restore(ctx, ws.ID, owner.ID, model.SearchTypeHabit, habit.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Restore(ctx, ws.ID, owner.ID, model.SearchTypeHabit, habit.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("second restore: err = %v", err)
+	}
+	stats, err := habits.GetStats(ctx, habit.ID, owner.ID, ws.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.CompletedDays != 1 {
+		t.Fatalf("restored stats = %+v", stats)
+	}
+
+	// Окончательное удаление привычки забирает выполнения и версии
+	if err := habits.Delete(ctx, habit.ID, owner.ID, ws.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Purge(ctx, ws.ID, owner.ID, model.SearchTypeHabit, habit.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(`SELECT COUNT(*) FROM habit_completions WHERE habit_id = $1`, habit.ID) +
+		count(`SELECT COUNT(*) FROM habit_versions WHERE habit_id = $1`, habit.ID); n != 0 {
+		t.Fatalf("purged habit rows left = %d", n)
+	}
+	if err := svc.Purge(ctx, ws.ID, owner.ID, model.SearchTypeNote, uuid.NewString()); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("purge missing: err = %v", err)
+	}
+
+	// Истёкшее удаляет воркер, свежее остаётся
+	if _, err := env.DB.ExecContext(ctx, `UPDATE journal_entries SET deleted_at = NOW() - interval '31 days' WHERE id = $1`, entryID); err != nil {
+		t.Fatal(err)
+	}
+	purged, err := svc.PurgeExpired(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 || count(`SELECT COUNT(*) FROM journal_entries WHERE id = $1`, entryID) != 0 {
+		t.Fatalf("expired purge = %d", purged)
+	}
+	if n, err := svc.Empty(ctx, ws.ID, member.ID); err != nil || n != 1 {
+		t.Fatalf("empty = %d, %v", n, err)
+	}
+	if count(`SELECT COUNT(*) FROM notes WHERE workspace_id = $1`, ws.ID) != 0 {
+		t.Fatal("note left after empty")
+	}
+}
+
+func TestPurgeExemptHabitSurvivesExpiry(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc := env.Container.TrashService
+	habits := env.Container.HabitsService
+	owner := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+
+	habit := env.CreateHabit(t, owner, ws, model.CreateHabitDto{Title: "Чтение"})
+	if _, err := habits.Complete(ctx, habit.ID, owner.ID, ws.ID, time.Now(), "", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := habits.Delete(ctx, habit.ID, owner.ID, ws.ID); err != nil {
+		t.Fatal(err)
+	}
+	// Так привычку, удалённую до появления корзины, возвращает миграция 000031
+	expire := func() {
+		t.Helper()
+		if _, err := env.DB.ExecContext(ctx, `UPDATE habits SET deleted_at = NOW() - interval '1 year' WHERE id = $1`, habit.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expire()
+	if _, err := env.DB.ExecContext(ctx, `UPDATE habits SET purge_exempt = TRUE WHERE id = $1`, habit.ID); err != nil {
+		t.Fatal(err)
+	}
+	exists := func() bool {
+		t.Helper()
+		var n int
+		if err := env.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM habits WHERE id = $1`, habit.ID).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n == 1
+	}
+
+	// Воркер такую привычку не удаляет, и срока удаления у неё нет
+	if n, err := svc.PurgeExpired(ctx); err != nil || n != 0 || !exists() {
+		t.Fatalf("expired purge = %d, %v", n, err)
+	}
+	items, err := svc.List(ctx, ws.ID, owner.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].PurgeAt != "" {
+		t.Fatalf("trash = %+v", items)
+	}
+
+	// Восстановление снимает флаг: после повторного удаления действует обычный срок
+	if err := svc.Restore(ctx, ws.ID, owner.ID, model.SearchTypeHabit, habit.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := habits.Delete(ctx, habit.ID, owner.ID, ws.ID); err != nil {
+		t.Fatal(err)
+	}
+	expire()
+	if n, err := svc.PurgeExpired(ctx); err != nil || n != 1 || exists() {
+		t.Fatalf("expired purge after restore = %d, %v", n, err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"backend/internal/repository/license"
 	"backend/internal/repository/user_preferences"
 	"backend/internal/repository/workspace"
+	"backend/pkg/query"
 
 	"github.com/google/uuid"
 )
@@ -157,7 +158,7 @@ func (s *Service) GetCurrentWorkspace(ctx context.Context, userID string, userRo
 	}
 	var list []model.Workspace
 	if userRole == model.UserRoleAdmin {
-		list, err = s.repo.ListAll(ctx)
+		list, _, err = s.repo.ListAll(ctx, query.Default(workspace.ListAllSchema, 1))
 	} else {
 		list, err = s.repo.List(ctx, uid)
 	}
@@ -185,8 +186,8 @@ func (s *Service) HasAccess(ctx context.Context, workspaceID, userID string, use
 	return s.repo.HasAccess(ctx, wsID, uid)
 }
 
-func (s *Service) ListAllForAdmin(ctx context.Context) ([]model.Workspace, error) {
-	return s.repo.ListAll(ctx)
+func (s *Service) ListAllForAdmin(ctx context.Context, spec query.Spec) ([]model.Workspace, query.Page, error) {
+	return s.repo.ListAll(ctx, spec)
 }
 
 // GetWorkspaceModules возвращает модули воркспейса с реальным статусом (active/disabled) из workspace_modules.
@@ -0,0 +1,134 @@
+package workspace_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend/internal/model"
+	"backend/internal/service/workspace"
+	"backend/internal/testutil/pgtest"
+)
+
+func TestMain(m *testing.M) { pgtest.Main(m) }
+
+func moduleStatus(t *testing.T, env *pgtest.Env, workspaceID, code string) string {
+	t.Helper()
+	var status string
+	err := env.DB.QueryRow(`
+		SELECT wm.status FROM workspace_modules wm
+		JOIN modules m ON m.id = wm.module_id
+		WHERE wm.workspace_id = $1 AND m.code = $2
+	`, workspaceID, code).Scan(&status)
+	if err != nil {
+		return ""
+	}
+	return status
+}
+
+func TestEnableModule_LicenseChecks(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc := env.Container.WorkspaceService
+
+	owner := env.CreateUser(t)
+	member := env.CreateUser(t)
+	admin := env.CreateAdmin(t)
+	wsA := env.CreateWorkspace(t, owner)
+	wsB := env.CreateWorkspace(t, owner)
+	env.AddMember(t, wsA, member)
+
+	enable := func(ws *model.Workspace, user *model.User, code string) error {
+		return svc.EnableModule(ctx, ws.ID, user.ID, user.Role, code)
+	}
+
+	// Core-модуль включается триггером при создании воркспейса и не требует лицензии
+	if got := moduleStatus(t, env, wsA.ID, "habits"); got != model.WorkspaceModuleStatusActive {
+		t.Errorf("core module status after workspace creation = %q", got)
+	}
+	if err := enable(wsA, owner, "habits"); err != nil {
+		t.Errorf("enable core module: %v", err)
+	}
+
+	if err := enable(wsA, owner, "notes"); !errors.Is(err, workspace.ErrLicenseRequired) {
+		t.Errorf("owner without license: err = %v, want ErrLicenseRequired", err)
+	}
+	if err := enable(wsA, member, "habits"); !errors.Is(err, workspace.ErrAccessDenied) {
+		t.Errorf("member: err = %v, want ErrAccessDenied", err)
+	}
+	if err := enable(wsA, owner, "no-such-module"); !errors.Is(err, workspace.ErrModuleNotFound) {
+		t.Errorf("unknown module: err = %v, want ErrModuleNotFound", err)
+	}
+
+	// Лицензия на один воркспейс действует только в нём
+	if _, err := svc.GrantLicense(ctx, owner.ID, "notes", model.LicenseScopeSingleWorkspace, &wsA.ID); err != nil {
+		t.Fatalf("grant single_workspace: %v", err)
+	}
+	if err := enable(wsA, owner, "notes"); err != nil {
+		t.Errorf("enable with single_workspace license: %v", err)
+	}
+	if got := moduleStatus(t, env, wsA.ID, "notes"); got != model.WorkspaceModuleStatusActive {
+		t.Errorf("notes status in licensed workspace = %q", got)
+	}
+	if err := enable(wsB, owner, "notes"); !errors.Is(err, workspace.ErrLicenseRequired) {
+		t.Errorf("other workspace with single_workspace license: err = %v, want ErrLicenseRequired", err)
+	}
+
+	// Лицензия на все воркспейсы снимает ограничение
+	if _, err := svc.GrantLicense(ctx, owner.ID, "notes", model.LicenseScopeAllWorkspaces, nil); err != nil {
+		t.Fatalf("grant all_workspaces: %v", err)
+	}
+	if err := enable(wsB, owner, "notes"); err != nil {
+		t.Errorf("enable with all_workspaces license: %v", err)
+	}
+
+	// Выключенный модуль с лицензией включается повторно без покупки
+	if err := svc.DisableModule(ctx, wsB.ID, owner.ID, owner.Role, "notes"); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	if err := enable(wsB, owner, "notes"); err != nil {
+		t.Errorf("re-enable licensed module: %v", err)
+	}
+	if got := moduleStatus(t, env, wsB.ID, "notes"); got != model.WorkspaceModuleStatusActive {
+		t.Errorf("notes status after re-enable = %q", got)
+	}
+
+	// Админу лицензия не нужна
+	if err := enable(wsA, admin, "crm"); err != nil {
+		t.Errorf("admin enable without license: %v", err)
+	}
+}
+
+func TestEnableModule_ExpiredOrCancelledLicense(t *testing.T) {
+	env := pgtest.New(t)
+	ctx := context.Background()
+	svc := env.Container.WorkspaceService
+
+	owner := env.CreateUser(t)
+	ws := env.CreateWorkspace(t, owner)
+
+	if _, err := svc.GrantLicense(ctx, owner.ID, "crm", model.LicenseScopeAllWorkspaces, nil); err != nil {
+		t.Fatalf("grant: %v", err)
+	}
+
+	if _, err := env.DB.Exec("UPDATE user_module_licenses SET expires_at = NOW() - INTERVAL '1 day' WHERE user_id = $1", owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.EnableModule(ctx, ws.ID, owner.ID, owner.Role, "crm"); !errors.Is(err, workspace.ErrLicenseRequired) {
+		t.Errorf("expired license: err = %v, want ErrLicenseRequired", err)
+	}
+
+	if _, err := env.DB.Exec("UPDATE user_module_licenses SET expires_at = NULL, status = 'cancelled' WHERE user_id = $1", owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.EnableModule(ctx, ws.ID, owner.ID, owner.Role, "crm"); !errors.Is(err, workspace.ErrLicenseRequired) {
+		t.Errorf("cancelled license: err = %v, want ErrLicenseRequired", err)
+	}
+
+	if _, err := env.DB.Exec("UPDATE user_module_licenses SET status = 'active' WHERE user_id = $1", owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.EnableModule(ctx, ws.ID, owner.ID, owner.Role, "crm"); err != nil {
+		t.Errorf("active license: %v", err)
+	}
+}
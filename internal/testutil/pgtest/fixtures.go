@@ -0,0 +1,103 @@
+package pgtest
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository/workspace"
+
+	"github.com/google/uuid"
+)
+
+var fixtureSeq atomic.Int64
+
+// CreateUser создаёт активного пользователя с ролью USER
+func (e *Env) CreateUser(t testing.TB) *model.User {
+	t.Helper()
+	return e.createUser(t, model.UserRoleUser)
+}
+
+// CreateAdmin создаёт активного пользователя с ролью ADMIN
+func (e *Env) CreateAdmin(t testing.TB) *model.User {
+	t.Helper()
+	return e.createUser(t, model.UserRoleAdmin)
+}
+
+func (e *Env) createUser(t testing.TB, role model.UserRole) *model.User {
+	t.Helper()
+	n := fixtureSeq.Add(1)
+	name := fmt.Sprintf("User %d", n)
+	status := model.UserStatusActive
+	now := time.Now().UTC()
+	u := &model.User{
+		ID:        uuid.NewString(),
+		Email:     fmt.Sprintf("user%d@pgtest.local", n),
+		Password:  "not-a-real-hash",
+		Name:      &name,
+		Role:      role,
+		Status:    &status,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := e.Container.UserRepository.Create(context.Background(), u); err != nil {
+		t.Fatalf("pgtest: create user: %v", err)
+	}
+	return u
+}
+
+// CreateWorkspace создаёт воркспейс, владелец — owner
+func (e *Env) CreateWorkspace(t testing.TB, owner *model.User) *model.Workspace {
+	t.Helper()
+	dto := model.CreateWorkspaceDto{Name: fmt.Sprintf("Workspace %d", fixtureSeq.Add(1))}
+	ws, err := e.Container.WorkspaceService.Create(context.Background(), dto, owner.ID)
+	if err != nil {
+		t.Fatalf("pgtest: create workspace: %v", err)
+	}
+	return ws
+}
+
+// AddMember добавляет пользователя в воркспейс с ролью MEMBER
+func (e *Env) AddMember(t testing.TB, ws *model.Workspace, user *model.User) {
+	t.Helper()
+	repo := workspace.NewRepository(e.DB)
+	if err := repo.AddMember(context.Background(), uuid.MustParse(ws.ID), uuid.MustParse(user.ID), "MEMBER"); err != nil {
+		t.Fatalf("pgtest: add member: %v", err)
+	}
+}
+
+// CreateHabit создаёт привычку через сервис (вместе с первой версией). Пустые Title и ScheduleType
+// заполняются: ежедневная привычка с уникальным названием.
+func (e *Env) CreateHabit(t testing.TB, owner *model.User, ws *model.Workspace, dto model.CreateHabitDto) *model.Habit {
+	t.Helper()
+	if dto.Title == "" {
+		dto.Title = fmt.Sprintf("Habit %d", fixtureSeq.Add(1))
+	}
+	if dto.ScheduleType == "" {
+		dto.ScheduleType = "recurring"
+	}
+	h, err := e.Container.HabitsService.Create(context.Background(), dto, owner.ID, ws.ID)
+	if err != nil {
+		t.Fatalf("pgtest: create habit: %v", err)
+	}
+	return h
+}
+
+// BackdateHabit сдвигает created_at привычки и начало её версий на days дней назад —
+// так можно проверять историю без ожидания смены суток
+func (e *Env) BackdateHabit(t testing.TB, habitID string, days int) {
+	t.Helper()
+	if _, err := e.DB.Exec(
+		"UPDATE habits SET created_at = created_at - make_interval(days => $2) WHERE id = $1", habitID, days,
+	); err != nil {
+		t.Fatalf("pgtest: backdate habit: %v", err)
+	}
+	if _, err := e.DB.Exec(
+		"UPDATE habit_versions SET valid_from = valid_from - $2::int WHERE habit_id = $1", habitID, days,
+	); err != nil {
+		t.Fatalf("pgtest: backdate habit versions: %v", err)
+	}
+}
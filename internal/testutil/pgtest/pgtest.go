@@ -0,0 +1,396 @@
+// Package pgtest — интеграционные тесты на настоящем Postgres: поднимает одноразовый кластер
+// из локальных бинарников (initdb/postgres) или использует сервер из PGTEST_DATABASE_URL,
+// один раз применяет миграции в шаблонную БД и для каждого теста создаёт чистую копию.
+// Если ни бинарников, ни PGTEST_DATABASE_URL нет — тесты пропускаются.
+package pgtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/internal/di"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// Переменные окружения
+const (
+	// EnvDatabaseURL — существующий сервер (суперпользователь или роль с CREATEDB), например из CI
+	EnvDatabaseURL = "PGTEST_DATABASE_URL"
+	// EnvBinDir — каталог с initdb и postgres, если их нет в PATH
+	EnvBinDir = "PGTEST_BIN_DIR"
+)
+
+// Env — окружение одного теста: своя БД с применёнными миграциями и собранный поверх неё контейнер
+type Env struct {
+	DB        *sql.DB
+	DSN       string
+	Cfg       *config.Config
+	Container *di.Container
+}
+
+var (
+	srvOnce sync.Once
+	srv     *server
+	srvErr  error
+	dbSeq   atomic.Int64
+)
+
+// Main запускает тесты пакета и останавливает кластер после них. Вызывать из TestMain:
+//
+//	func TestMain(m *testing.M) { pgtest.Main(m) }
+func Main(m *testing.M) {
+	code := m.Run()
+	if srv != nil {
+		srv.stop()
+	}
+	os.Exit(code)
+}
+
+// New создаёт для теста чистую БД из шаблона и контейнер зависимостей. БД удаляется в t.Cleanup.
+func New(t testing.TB) *Env {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("pgtest: integration test skipped in -short mode")
+	}
+
+	srvOnce.Do(func() { srv, srvErr = startServer() })
+	if errors.Is(srvErr, errUnavailable) {
+		t.Skip(srvErr.Error())
+	}
+	if srvErr != nil {
+		t.Fatalf("pgtest: %v", srvErr)
+	}
+
+	name := fmt.Sprintf("%s_%d", srv.template, dbSeq.Add(1))
+	if _, err := srv.admin.Exec(fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s", name, srv.template)); err != nil {
+		t.Fatalf("pgtest: create database: %v", err)
+	}
+
+	dsn := srv.dsn(name)
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("pgtest: open: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		if _, err := srv.admin.Exec("DROP DATABASE IF EXISTS " + name); err != nil {
+			t.Logf("pgtest: drop database %s: %v", name, err)
+		}
+	})
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{HealthCheckTimeout: 2 * time.Second},
+		Database: config.DatabaseConfig{
+			Host:     srv.host,
+			Port:     srv.port,
+			User:     srv.user,
+			Password: srv.password,
+			DBName:   name,
+		},
+		Logs:    config.LogsConfig{Dir: t.TempDir()},
+		Auth:    config.AuthConfig{JWTSecretKey: "pgtest-secret", JWTExpiration: time.Hour},
+		Storage: config.StorageConfig{Backend: "local", LocalDir: t.TempDir(), LocalURL: "/api/v1/files"},
+		Attachments: config.AttachmentsConfig{
+			MaxSize:        1 << 20,
+			AllowedTypes:   []string{"image/png", "application/pdf", "text/plain"},
+			WorkspaceQuota: 4 << 20,
+			URLTTL:         time.Minute,
+		},
+		Exports: config.ExportsConfig{
+			TTL:        time.Hour,
+			URLTTL:     time.Minute,
+			StaleAfter: time.Hour,
+		},
+		Backup:      config.BackupConfig{ImportMaxSize: 4 << 20},
+		HabitImport: config.HabitImportConfig{MaxSize: 1 << 20},
+		Trash:       config.TrashConfig{Retention: 30 * 24 * time.Hour, PurgeInterval: time.Hour},
+		Profile: config.ProfileConfig{
+			ConfirmEmailURL: "http://client.test/confirm-email",
+			EmailChangeTTL:  time.Hour,
+			AvatarMaxSize:   1 << 20,
+			AvatarURLTTL:    time.Minute,
+		},
+	}
+
+	container, err := di.NewContainer(db, cfg)
+	if err != nil {
+		t.Fatalf("pgtest: container: %v", err)
+	}
+	return &Env{DB: db, DSN: dsn, Cfg: cfg, Container: container}
+}
+
+var errUnavailable = errors.New("pgtest: no postgres available (install initdb/postgres, set " + EnvBinDir + " or " + EnvDatabaseURL + ")")
+
+// server — кластер, в котором создаются тестовые БД
+type server struct {
+	host, port     string
+	user, password string
+	template       string
+	admin          *sql.DB
+
+	cmd     *exec.Cmd // nil, если сервер внешний
+	dataDir string
+}
+
+func (s *server) dsn(dbName string) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		Host:     net.JoinHostPort(s.host, s.port),
+		Path:     "/" + dbName,
+		RawQuery: "sslmode=disable",
+	}
+	if s.password != "" {
+		u.User = url.UserPassword(s.user, s.password)
+	} else {
+		u.User = url.User(s.user)
+	}
+	return u.String()
+}
+
+func startServer() (*server, error) {
+	var s *server
+	var err error
+	if raw := os.Getenv(EnvDatabaseURL); raw != "" {
+		s, err = externalServer(raw)
+	} else {
+		s, err = localServer()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.prepareTemplate(); err != nil {
+		s.stop()
+		return nil, err
+	}
+	return s, nil
+}
+
+// externalServer подключается к серверу из PGTEST_DATABASE_URL. Имя шаблона уникально для процесса,
+// чтобы пакеты, которые go test гоняет параллельно, не мешали друг другу.
+func externalServer(raw string) (*server, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", EnvDatabaseURL, err)
+	}
+	s := &server{
+		host:     u.Hostname(),
+		port:     u.Port(),
+		template: "pgtest_" + uuid.NewString()[:8],
+	}
+	if s.port == "" {
+		s.port = "5432"
+	}
+	if u.User != nil {
+		s.user = u.User.Username()
+		s.password, _ = u.User.Password()
+	}
+	if s.admin, err = sql.Open("postgres", raw); err != nil {
+		return nil, err
+	}
+	if err := waitReady(s.admin, 10*time.Second); err != nil {
+		return nil, fmt.Errorf("connect %s: %w", EnvDatabaseURL, err)
+	}
+	return s, nil
+}
+
+// localServer выполняет initdb во временный каталог и запускает postgres на свободном порту 127.0.0.1
+func localServer() (*server, error) {
+	binDir, err := findBinDir()
+	if err != nil {
+		return nil, err
+	}
+	if os.Geteuid() == 0 {
+		// initdb отказывается работать от root
+		return nil, fmt.Errorf("%w: initdb cannot run as root", errUnavailable)
+	}
+
+	dataDir, err := os.MkdirTemp("", "pgtest-")
+	if err != nil {
+		return nil, err
+	}
+	s := &server{
+		host:     "127.0.0.1",
+		user:     "postgres",
+		template: "pgtest_template",
+		dataDir:  dataDir,
+	}
+
+	initdb := exec.Command(filepath.Join(binDir, "initdb"),
+		"-D", filepath.Join(dataDir, "data"), "-U", s.user, "-A", "trust", "-E", "UTF8", "--locale=C", "--no-sync")
+	if out, err := initdb.CombinedOutput(); err != nil {
+		os.RemoveAll(dataDir)
+		return nil, fmt.Errorf("initdb: %w\n%s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dataDir)
+		return nil, err
+	}
+	s.port = strconv.Itoa(port)
+
+	logFile, err := os.Create(filepath.Join(dataDir, "postgres.log"))
+	if err != nil {
+		os.RemoveAll(dataDir)
+		return nil, err
+	}
+	defer logFile.Close()
+
+	// Надёжность на диске тестам не нужна: fsync и прочее выключены ради скорости
+	s.cmd = exec.Command(filepath.Join(binDir, "postgres"),
+		"-D", filepath.Join(dataDir, "data"),
+		"-p", s.port,
+		"-c", "listen_addresses=127.0.0.1",
+		"-c", "unix_socket_directories=",
+		"-c", "fsync=off",
+		"-c", "synchronous_commit=off",
+		"-c", "full_page_writes=off",
+		"-c", "max_connections=200",
+	)
+	s.cmd.Stdout = logFile
+	s.cmd.Stderr = logFile
+	if err := s.cmd.Start(); err != nil {
+		os.RemoveAll(dataDir)
+		return nil, fmt.Errorf("start postgres: %w", err)
+	}
+
+	if s.admin, err = sql.Open("postgres", s.dsn("postgres")); err != nil {
+		s.stop()
+		return nil, err
+	}
+	if err := waitReady(s.admin, 30*time.Second); err != nil {
+		out, _ := os.ReadFile(filepath.Join(dataDir, "postgres.log"))
+		s.stop()
+		return nil, fmt.Errorf("postgres did not start: %w\n%s", err, out)
+	}
+	return s, nil
+}
+
+// prepareTemplate создаёт шаблонную БД и применяет в неё встроенные миграции
+func (s *server) prepareTemplate() error {
+	if _, err := s.admin.Exec("CREATE DATABASE " + s.template); err != nil {
+		return fmt.Errorf("create template database: %w", err)
+	}
+	if err := database.RunMigrationsWithDSN(s.dsn(s.template)); err != nil {
+		return err
+	}
+	// migrate держит соединения к шаблону до Close; CREATE DATABASE ... TEMPLATE требует, чтобы их не было
+	_, err := s.admin.Exec(`
+		SELECT pg_terminate_backend(pid) FROM pg_stat_activity
+		WHERE datname = $1 AND pid <> pg_backend_pid()
+	`, s.template)
+	return err
+}
+
+func (s *server) stop() {
+	if s.admin != nil {
+		if s.cmd == nil {
+			if _, err := s.admin.Exec("DROP DATABASE IF EXISTS " + s.template); err != nil {
+				log.Printf("pgtest: drop template %s: %v", s.template, err)
+			}
+		}
+		s.admin.Close()
+	}
+	if s.cmd != nil && s.cmd.Process != nil {
+		// SIGINT — fast shutdown: активные соединения обрываются, кластер останавливается без ожидания клиентов
+		_ = s.cmd.Process.Signal(syscall.SIGINT)
+		done := make(chan struct{})
+		go func() { s.cmd.Wait(); close(done) }()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			_ = s.cmd.Process.Kill()
+			<-done
+		}
+	}
+	if s.dataDir != "" {
+		os.RemoveAll(s.dataDir)
+	}
+}
+
+// findBinDir ищет каталог с initdb и postgres: PGTEST_BIN_DIR, PATH, типичные пути пакетов
+func findBinDir() (string, error) {
+	if dir := os.Getenv(EnvBinDir); dir != "" {
+		if hasBinaries(dir) {
+			return dir, nil
+		}
+		return "", fmt.Errorf("%s=%s: initdb or postgres not found", EnvBinDir, dir)
+	}
+	if p, err := exec.LookPath("initdb"); err == nil && hasBinaries(filepath.Dir(p)) {
+		return filepath.Dir(p), nil
+	}
+
+	var candidates []string
+	for _, pattern := range []string{
+		"/usr/lib/postgresql/*/bin",
+		"/usr/local/pgsql/bin",
+		"/usr/pgsql-*/bin",
+		"/opt/homebrew/opt/postgresql@*/bin",
+		"/usr/local/opt/postgresql@*/bin",
+	} {
+		matches, _ := filepath.Glob(pattern)
+		candidates = append(candidates, matches...)
+	}
+	// При нескольких установленных версиях предпочитаем последнюю по имени каталога
+	sort.Sort(sort.Reverse(sort.StringSlice(candidates)))
+	for _, dir := range candidates {
+		if hasBinaries(dir) {
+			return dir, nil
+		}
+	}
+	return "", errUnavailable
+}
+
+func hasBinaries(dir string) bool {
+	for _, name := range []string{"initdb", "postgres"} {
+		if fi, err := os.Stat(filepath.Join(dir, name)); err != nil || fi.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func waitReady(db *sql.DB, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"backend/internal/service/attachments"
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	attachmentPurgeName = "attachment_purge"
+	// attachmentPurgeBatch — сколько файлов удаляется за один прогон
+	attachmentPurgeBatch = 500
+)
+
+// AttachmentPurge удаляет из хранилища файлы удалённых вложений (attachment_purge_queue)
+type AttachmentPurge struct {
+	*Loop
+	service *attachments.Service
+}
+
+func NewAttachmentPurge(service *attachments.Service, interval time.Duration, metrics *RunMetrics) (*AttachmentPurge, error) {
+	w := &AttachmentPurge{service: service}
+	loop, err := NewLoop(attachmentPurgeName, interval, metrics, w.run)
+	if err != nil {
+		return nil, err
+	}
+	w.Loop = loop
+	return w, nil
+}
+
+func (w *AttachmentPurge) run(ctx context.Context) error {
+	purged, failed, err := w.service.PurgeDeleted(ctx, attachmentPurgeBatch)
+	if purged > 0 || failed > 0 {
+		log.Printf("AttachmentPurge: удалено файлов %d, ошибок %d", purged, failed)
+	}
+	return err
+}
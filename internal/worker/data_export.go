@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"backend/internal/service/export"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+const (
+	dataExportName = "data_export"
+	// dataExportBatch — сколько архивов собирается за один прогон
+	dataExportBatch = 5
+)
+
+// DataExport собирает выгрузки данных пользователей из очереди и помечает истёкшие архивы
+type DataExport struct {
+	*Loop
+	service *export.Service
+}
+
+func NewDataExport(service *export.Service, interval time.Duration, metrics *RunMetrics) (*DataExport, error) {
+	w := &DataExport{service: service}
+	loop, err := NewLoop(dataExportName, interval, metrics, w.run)
+	if err != nil {
+		return nil, err
+	}
+	w.Loop = loop
+	return w, nil
+}
+
+func (w *DataExport) run(ctx context.Context) error {
+	expired, expireErr := w.service.ExpireOld(ctx)
+	done, failed, err := w.service.ProcessPending(ctx, dataExportBatch)
+	if expired > 0 || done > 0 || failed > 0 {
+		log.Printf("DataExport: собрано %d, ошибок %d, истекло %d", done, failed, expired)
+	}
+	return errors.Join(expireErr, err)
+}
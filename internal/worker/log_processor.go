@@ -7,69 +7,34 @@ import (
 	"time"
 )
 
-// LogProcessor синхронизирует логи в БД раз в сутки
+// logProcessorName — имя воркера в метриках и readiness
+const logProcessorName = "log_processor"
+
+// LogProcessor непрерывно догружает лог-файлы в БД с сохранённого чекпоинта.
+// Первый прогон — сразу при старте: догоняет то, что накопилось до рестарта.
 type LogProcessor struct {
+	*Loop
 	logService *logger.Service
-	stopChan   chan struct{}
 }
 
-func NewLogProcessor(logService *logger.Service) *LogProcessor {
-	return &LogProcessor{
-		logService: logService,
-		stopChan:   make(chan struct{}),
+func NewLogProcessor(logService *logger.Service, interval time.Duration, metrics *RunMetrics) (*LogProcessor, error) {
+	w := &LogProcessor{logService: logService}
+	loop, err := NewLoop(logProcessorName, interval, metrics, w.sync)
+	if err != nil {
+		return nil, err
 	}
+	w.Loop = loop
+	return w, nil
 }
 
-// Start запускает синхронизацию раз в сутки в полночь
-func (w *LogProcessor) Start(ctx context.Context) {
-	go func() {
-		// Вычисляем время до следующей полночи
-		now := time.Now()
-		nextMidnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
-		initialDelay := nextMidnight.Sub(now)
-
-		log.Printf("LogProcessor: синхронизация начнется через %v", initialDelay)
-
-		// Первый запуск в полночь
-		timer := time.NewTimer(initialDelay)
-		select {
-		case <-timer.C:
-			w.sync()
-			// Затем каждые 24 часа
-			ticker := time.NewTicker(24 * time.Hour)
-			for {
-				select {
-				case <-ticker.C:
-					w.sync()
-				case <-w.stopChan:
-					ticker.Stop()
-					return
-				case <-ctx.Done():
-					ticker.Stop()
-					return
-				}
-			}
-		case <-w.stopChan:
-			timer.Stop()
-			return
-		case <-ctx.Done():
-			timer.Stop()
-			return
-		}
-	}()
-}
-
-// Stop останавливает воркер
-func (w *LogProcessor) Stop() {
-	close(w.stopChan)
-}
-
-// sync синхронизирует вчерашние логи в БД
-func (w *LogProcessor) sync() {
-	log.Println("LogProcessor: синхронизация логов в БД...")
-	if err := w.logService.SyncToDB(); err != nil {
-		log.Printf("LogProcessor: ошибка синхронизации: %v", err)
-	} else {
-		log.Println("LogProcessor: синхронизация завершена")
+// sync догружает новые строки из лог-файлов в БД
+func (w *LogProcessor) sync(ctx context.Context) error {
+	res, err := w.logService.Ingest(ctx)
+	if err != nil {
+		return err
+	}
+	if res.Lines > 0 {
+		log.Printf("LogProcessor: прочитано строк %d, добавлено %d, пропущено %d", res.Lines, res.Inserted, res.Skipped)
 	}
+	return nil
 }
@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"backend/internal/service/logger"
+	"context"
+	"log"
+	"time"
+)
+
+// logRetentionName — имя воркера в метриках и readiness
+const logRetentionName = "log_retention"
+
+// LogRetention периодически сжимает и удаляет старые лог-файлы и сворачивает старые строки request_logs
+type LogRetention struct {
+	*Loop
+	logService *logger.Service
+}
+
+func NewLogRetention(logService *logger.Service, interval time.Duration, metrics *RunMetrics) (*LogRetention, error) {
+	w := &LogRetention{logService: logService}
+	loop, err := NewLoop(logRetentionName, interval, metrics, w.run)
+	if err != nil {
+		return nil, err
+	}
+	w.Loop = loop
+	return w, nil
+}
+
+func (w *LogRetention) run(ctx context.Context) error {
+	res, err := w.logService.ApplyRetention(ctx)
+	if err != nil {
+		return err
+	}
+	if res.FilesCompressed > 0 || res.FilesDeleted > 0 || res.PrunedRows > 0 {
+		log.Printf("LogRetention: сжато файлов %d, удалено файлов %d, свёрнуто строк %d (до %s)",
+			res.FilesCompressed, res.FilesDeleted, res.PrunedRows, res.RolledUpBefore)
+	}
+	return nil
+}
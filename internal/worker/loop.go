@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Loop — периодический фоновый воркер: прогон сразу после Start, затем каждые interval.
+// Stop дожидается завершения текущего прогона; если не успевает к дедлайну — отменяет его контекст.
+// Прогон ограничен 2×interval + heartbeatGrace — дольше readiness и так считает воркер зависшим.
+// Ошибка или паника прогона пишется в лог и метрики, воркер продолжает работать.
+type Loop struct {
+	name     string
+	interval time.Duration
+	timeout  time.Duration
+	run      func(ctx context.Context) error
+	metrics  *RunMetrics
+
+	mu       sync.Mutex
+	started  bool
+	stopChan chan struct{}
+	done     chan struct{}
+	cancel   context.CancelFunc
+	stopOnce sync.Once
+}
+
+// NewLoop создаёт воркер name, вызывающий run каждые interval. metrics может быть nil.
+// interval должен быть положительным: иначе тикер запаниковал бы в горутине воркера и уронил процесс.
+func NewLoop(name string, interval time.Duration, metrics *RunMetrics, run func(ctx context.Context) error) (*Loop, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("%s: interval must be positive, got %v", name, interval)
+	}
+	return &Loop{
+		name:     name,
+		interval: interval,
+		timeout:  2*interval + heartbeatGrace,
+		run:      run,
+		metrics:  metrics,
+		stopChan: make(chan struct{}),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Start запускает горутину воркера. Повторный вызов ничего не делает.
+// Отмена ctx останавливает воркер так же, как Stop.
+func (l *Loop) Start(ctx context.Context) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.started {
+		return
+	}
+	l.started = true
+
+	ctx, l.cancel = context.WithCancel(ctx)
+	l.metrics.started(l.name, l.interval)
+
+	go func() {
+		defer close(l.done)
+		defer l.metrics.stopped(l.name)
+
+		log.Printf("%s: запуск каждые %v", l.name, l.interval)
+		l.runOnce(ctx)
+
+		ticker := time.NewTicker(l.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				l.runOnce(ctx)
+			case <-l.stopChan:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop просит воркер остановиться и ждёт выхода горутины. Если ctx истекает раньше,
+// контекст текущего прогона отменяется и возвращается ctx.Err(). Вызывать можно несколько раз.
+func (l *Loop) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	started := l.started
+	l.mu.Unlock()
+	if !started {
+		return nil
+	}
+
+	l.stopOnce.Do(func() { close(l.stopChan) })
+
+	select {
+	case <-l.done:
+		l.cancel()
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		<-l.done
+		return ctx.Err()
+	}
+}
+
+// Done закрывается, когда горутина воркера завершилась
+func (l *Loop) Done() <-chan struct{} {
+	return l.done
+}
+
+func (l *Loop) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+	started := time.Now()
+	err := l.call(ctx)
+	l.metrics.observe(l.name, started, err)
+	if err != nil {
+		log.Printf("%s: ошибка: %v", l.name, err)
+	}
+}
+
+// call вызывает run; паника становится ошибкой прогона
+func (l *Loop) call(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("%s: паника: %v\n%s", l.name, r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return l.run(ctx)
+}
@@ -0,0 +1,241 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/pkg/metrics"
+)
+
+// fakeJob — прогон для тестов: считает вызовы, сообщает о старте каждого и ведёт себя как behave
+type fakeJob struct {
+	calls   atomic.Int32
+	started chan int32
+	behave  func(ctx context.Context, call int32) error
+}
+
+func newFakeJob(behave func(ctx context.Context, call int32) error) *fakeJob {
+	return &fakeJob{started: make(chan int32, 100), behave: behave}
+}
+
+func (j *fakeJob) run(ctx context.Context) error {
+	n := j.calls.Add(1)
+	j.started <- n
+	return j.behave(ctx, n)
+}
+
+// waitCall ждёт старта прогона с номером не меньше n
+func (j *fakeJob) waitCall(t *testing.T, n int32) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case got := <-j.started:
+			if got >= n {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("run %d did not start, calls = %d", n, j.calls.Load())
+		}
+	}
+}
+
+func stopWithin(l *Loop, d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return l.Stop(ctx)
+}
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestLoopLifecycle(t *testing.T) {
+	boom := errors.New("boom")
+	var deadlineErr atomic.Value // ошибка контекста зависшего прогона в «run deadline»
+	cases := []struct {
+		name     string
+		interval time.Duration
+		timeout  time.Duration // 0 — по умолчанию
+		behave   func(ctx context.Context, call int32) error
+		test     func(t *testing.T, l *Loop, j *fakeJob, m *RunMetrics, cancel context.CancelFunc)
+	}{
+		{
+			name:     "stop before first tick",
+			interval: time.Hour,
+			behave:   func(context.Context, int32) error { return nil },
+			test: func(t *testing.T, l *Loop, j *fakeJob, m *RunMetrics, cancel context.CancelFunc) {
+				j.waitCall(t, 1) // первый прогон — сразу после Start
+				if err := stopWithin(l, time.Second); err != nil {
+					t.Fatalf("Stop = %v", err)
+				}
+				if n := j.calls.Load(); n != 1 {
+					t.Errorf("calls = %d, want 1", n)
+				}
+				if hb := m.Heartbeats(); len(hb) != 1 || !hb[0].Stopped {
+					t.Errorf("heartbeats = %+v", hb)
+				}
+			},
+		},
+		{
+			name:     "stop waits for running job",
+			interval: time.Hour,
+			behave: func(ctx context.Context, _ int32) error {
+				select {
+				case <-time.After(50 * time.Millisecond):
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			},
+			test: func(t *testing.T, l *Loop, j *fakeJob, m *RunMetrics, cancel context.CancelFunc) {
+				j.waitCall(t, 1)
+				if err := stopWithin(l, time.Second); err != nil {
+					t.Fatalf("Stop = %v", err)
+				}
+				if hb := m.Heartbeats(); len(hb) != 1 || hb[0].LastErr != "" {
+					t.Errorf("job was interrupted: %+v", hb)
+				}
+			},
+		},
+		{
+			name:     "stop deadline cancels running job",
+			interval: time.Hour,
+			behave: func(ctx context.Context, _ int32) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			test: func(t *testing.T, l *Loop, j *fakeJob, m *RunMetrics, cancel context.CancelFunc) {
+				j.waitCall(t, 1)
+				if err := stopWithin(l, 20*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("Stop = %v, want deadline exceeded", err)
+				}
+				if !isClosed(l.Done()) {
+					t.Error("Done is not closed after Stop")
+				}
+				if hb := m.Heartbeats(); len(hb) != 1 || hb[0].LastErr != context.Canceled.Error() {
+					t.Errorf("job context was not cancelled: %+v", hb)
+				}
+			},
+		},
+		{
+			name:     "run deadline",
+			interval: 5 * time.Millisecond,
+			timeout:  20 * time.Millisecond,
+			behave: func(ctx context.Context, call int32) error {
+				if call > 1 {
+					return nil
+				}
+				<-ctx.Done()
+				deadlineErr.Store(ctx.Err())
+				return ctx.Err()
+			},
+			test: func(t *testing.T, l *Loop, j *fakeJob, m *RunMetrics, cancel context.CancelFunc) {
+				j.waitCall(t, 2) // зависший первый прогон прерван, воркер работает дальше
+				if err, _ := deadlineErr.Load().(error); !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("first run ended with %v, want deadline exceeded", err)
+				}
+				if err := stopWithin(l, time.Second); err != nil {
+					t.Fatalf("Stop = %v", err)
+				}
+			},
+		},
+		{
+			name:     "failing and panicking jobs do not stop the loop",
+			interval: time.Millisecond,
+			behave: func(_ context.Context, call int32) error {
+				switch call {
+				case 1:
+					panic("boom")
+				case 2:
+					return boom
+				}
+				return nil
+			},
+			test: func(t *testing.T, l *Loop, j *fakeJob, m *RunMetrics, cancel context.CancelFunc) {
+				j.waitCall(t, 3)
+				if err := stopWithin(l, time.Second); err != nil {
+					t.Fatalf("Stop = %v", err)
+				}
+			},
+		},
+		{
+			name:     "parent context cancel stops the loop",
+			interval: time.Hour,
+			behave:   func(context.Context, int32) error { return nil },
+			test: func(t *testing.T, l *Loop, j *fakeJob, m *RunMetrics, cancel context.CancelFunc) {
+				j.waitCall(t, 1)
+				cancel()
+				select {
+				case <-l.Done():
+				case <-time.After(2 * time.Second):
+					t.Fatal("loop did not stop after context cancel")
+				}
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			job := newFakeJob(tc.behave)
+			m := NewRunMetrics(metrics.NewRegistry())
+			l := newTestLoop(t, tc.interval, m, job.run)
+			if tc.timeout > 0 {
+				l.timeout = tc.timeout
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			l.Start(ctx)
+			l.Start(ctx) // повторный Start ничего не делает
+
+			tc.test(t, l, job, m, cancel)
+			if !isClosed(l.Done()) {
+				t.Error("Done is not closed after Stop")
+			}
+			if err := stopWithin(l, time.Second); err != nil {
+				t.Errorf("second Stop = %v", err)
+			}
+		})
+	}
+}
+
+func newTestLoop(t *testing.T, interval time.Duration, m *RunMetrics, run func(context.Context) error) *Loop {
+	t.Helper()
+	l, err := NewLoop("test", interval, m, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestNewLoopRejectsNonPositiveInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Minute} {
+		if l, err := NewLoop("test", interval, nil, func(context.Context) error { return nil }); err == nil || l != nil {
+			t.Errorf("NewLoop(%v) = %v, %v", interval, l, err)
+		}
+	}
+}
+
+func TestLoopStopWithoutStart(t *testing.T) {
+	l := newTestLoop(t, time.Hour, nil, func(context.Context) error { return nil })
+	if err := stopWithin(l, time.Millisecond); err != nil {
+		t.Fatalf("Stop = %v", err)
+	}
+}
+
+func TestLoopRecordsPanicAsError(t *testing.T) {
+	m := NewRunMetrics(metrics.NewRegistry())
+	l := newTestLoop(t, time.Hour, m, func(context.Context) error { panic("boom") })
+	m.started("test", time.Hour)
+	l.runOnce(context.Background())
+	if hb := m.Heartbeats(); len(hb) != 1 || hb[0].LastErr != "panic: boom" {
+		t.Fatalf("heartbeats = %+v", hb)
+	}
+}
@@ -0,0 +1,150 @@
+package worker
+
+import (
+	"backend/pkg/metrics"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// runBuckets — границы гистограммы длительности прогона воркера (секунды)
+var runBuckets = []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300}
+
+// RunMetrics считает прогоны фоновых воркеров: длительность, ошибки и время последнего успешного прогона.
+// Заодно хранит heartbeat каждого воркера для readiness-проверки. Методы безопасно вызывать на nil.
+type RunMetrics struct {
+	runs     *metrics.CounterVec
+	failures *metrics.CounterVec
+	duration *metrics.HistogramVec
+
+	mu          sync.Mutex
+	lastSuccess map[string]time.Time
+	heartbeats  map[string]*heartbeat
+}
+
+type heartbeat struct {
+	interval  time.Duration
+	startedAt time.Time
+	lastRun   time.Time
+	lastErr   error
+	stopped   bool
+}
+
+// Heartbeat — состояние воркера для readiness
+type Heartbeat struct {
+	Worker   string        `json:"worker"`
+	Interval time.Duration `json:"-"`
+	LastRun  time.Time     `json:"last_run,omitempty"`
+	LastErr  string        `json:"last_error,omitempty"`
+	Stopped  bool          `json:"stopped"`
+	// Stale — прогона не было дольше 2×interval + heartbeatGrace (воркер завис или упал)
+	Stale bool `json:"stale"`
+}
+
+// heartbeatGrace — запас сверх двух интервалов на длительный прогон
+const heartbeatGrace = time.Minute
+
+func NewRunMetrics(reg *metrics.Registry) *RunMetrics {
+	m := &RunMetrics{
+		runs:        reg.NewCounterVec("worker_runs_total", "Прогоны фоновых воркеров", "worker"),
+		failures:    reg.NewCounterVec("worker_failures_total", "Прогоны фоновых воркеров, завершившиеся ошибкой", "worker"),
+		duration:    reg.NewHistogramVec("worker_run_duration_seconds", "Длительность прогона фонового воркера", runBuckets, "worker"),
+		lastSuccess: make(map[string]time.Time),
+		heartbeats:  make(map[string]*heartbeat),
+	}
+	reg.RegisterCollector(m)
+	return m
+}
+
+// started отмечает запуск воркера с периодом interval
+func (m *RunMetrics) started(worker string, interval time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.heartbeats[worker] = &heartbeat{interval: interval, startedAt: time.Now()}
+	m.mu.Unlock()
+}
+
+// stopped отмечает штатную остановку воркера: readiness перестаёт его ждать
+func (m *RunMetrics) stopped(worker string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	if hb, ok := m.heartbeats[worker]; ok {
+		hb.stopped = true
+	}
+	m.mu.Unlock()
+}
+
+func (m *RunMetrics) observe(worker string, started time.Time, err error) {
+	if m == nil {
+		return
+	}
+	m.runs.Inc(worker)
+	m.duration.Observe(time.Since(started).Seconds(), worker)
+	if err != nil {
+		m.failures.Inc(worker)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if err == nil {
+		m.lastSuccess[worker] = now
+	}
+	if hb, ok := m.heartbeats[worker]; ok {
+		hb.lastRun = now
+		hb.lastErr = err
+	}
+}
+
+// Heartbeats возвращает состояние запущенных воркеров (по имени)
+func (m *RunMetrics) Heartbeats() []Heartbeat {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	list := make([]Heartbeat, 0, len(m.heartbeats))
+	for name, hb := range m.heartbeats {
+		last := hb.lastRun
+		if last.IsZero() {
+			last = hb.startedAt
+		}
+		h := Heartbeat{
+			Worker:   name,
+			Interval: hb.interval,
+			LastRun:  hb.lastRun,
+			Stopped:  hb.stopped,
+			Stale:    !hb.stopped && now.Sub(last) > 2*hb.interval+heartbeatGrace,
+		}
+		if hb.lastErr != nil {
+			h.LastErr = hb.lastErr.Error()
+		}
+		list = append(list, h)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Worker < list[j].Worker })
+	return list
+}
+
+// Collect реализует metrics.Collector для worker_last_success_timestamp_seconds
+func (m *RunMetrics) Collect(_ context.Context, e *metrics.Exposition) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.lastSuccess))
+	for name := range m.lastSuccess {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	e.Header("worker_last_success_timestamp_seconds", "Unix-время последнего успешного прогона воркера", "gauge")
+	for _, name := range names {
+		e.Sample("worker_last_success_timestamp_seconds", float64(m.lastSuccess[name].Unix()), metrics.Label{Name: "worker", Value: name})
+	}
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/pkg/metrics"
+)
+
+func TestRunMetricsExposition(t *testing.T) {
+	reg := metrics.NewRegistry()
+	m := NewRunMetrics(reg)
+	m.observe("log_processor", time.Now(), nil)
+	m.observe("log_processor", time.Now(), errors.New("db down"))
+	m.observe("log_retention", time.Now(), errors.New("disk full"))
+
+	var b strings.Builder
+	if err := reg.WriteTo(context.Background(), &b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, line := range []string{
+		`worker_runs_total{worker="log_processor"} 2`,
+		`worker_failures_total{worker="log_processor"} 1`,
+		`worker_failures_total{worker="log_retention"} 1`,
+		`worker_run_duration_seconds_count{worker="log_processor"} 2`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("no %s in:\n%s", line, out)
+		}
+	}
+	// Время последнего успеха есть только у воркера, у которого был успешный прогон
+	if !strings.Contains(out, `worker_last_success_timestamp_seconds{worker="log_processor"}`) ||
+		strings.Contains(out, `worker_last_success_timestamp_seconds{worker="log_retention"}`) {
+		t.Errorf("last success:\n%s", out)
+	}
+}
+
+func TestHeartbeats(t *testing.T) {
+	m := NewRunMetrics(metrics.NewRegistry())
+	m.started("fresh", time.Hour)
+	m.started("stuck", time.Millisecond)
+	m.started("done", time.Millisecond)
+	m.stopped("done")
+	m.observe("fresh", time.Now(), errors.New("boom"))
+
+	// stuck не отчитывался дольше 2×interval + heartbeatGrace
+	m.mu.Lock()
+	m.heartbeats["stuck"].startedAt = time.Now().Add(-heartbeatGrace - time.Second)
+	m.heartbeats["done"].startedAt = time.Now().Add(-heartbeatGrace - time.Second)
+	m.mu.Unlock()
+
+	hb := m.Heartbeats()
+	if len(hb) != 3 || hb[0].Worker != "done" || hb[1].Worker != "fresh" || hb[2].Worker != "stuck" {
+		t.Fatalf("heartbeats = %+v", hb)
+	}
+	if hb[0].Stale || !hb[0].Stopped {
+		t.Errorf("stopped worker: %+v", hb[0])
+	}
+	if hb[1].Stale || hb[1].LastErr != "boom" || hb[1].LastRun.IsZero() {
+		t.Errorf("fresh worker: %+v", hb[1])
+	}
+	if !hb[2].Stale {
+		t.Errorf("stuck worker is not stale: %+v", hb[2])
+	}
+
+	var nilMetrics *RunMetrics
+	nilMetrics.started("x", time.Second)
+	nilMetrics.observe("x", time.Now(), nil)
+	if nilMetrics.Heartbeats() != nil {
+		t.Error("nil RunMetrics returned heartbeats")
+	}
+}
@@ -0,0 +1,34 @@
+package worker
+
+import (
+	"backend/internal/service/trash"
+	"context"
+	"log"
+	"time"
+)
+
+const trashPurgeName = "trash_purge"
+
+// TrashPurge окончательно удаляет привычки, заметки и записи дневника, пролежавшие в корзине дольше срока хранения
+type TrashPurge struct {
+	*Loop
+	service *trash.Service
+}
+
+func NewTrashPurge(service *trash.Service, interval time.Duration, metrics *RunMetrics) (*TrashPurge, error) {
+	w := &TrashPurge{service: service}
+	loop, err := NewLoop(trashPurgeName, interval, metrics, w.run)
+	if err != nil {
+		return nil, err
+	}
+	w.Loop = loop
+	return w, nil
+}
+
+func (w *TrashPurge) run(ctx context.Context) error {
+	purged, err := w.service.PurgeExpired(ctx)
+	if purged > 0 {
+		log.Printf("TrashPurge: удалено из корзины %d", purged)
+	}
+	return err
+}
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_request_logs_user_id;
+DROP INDEX IF EXISTS idx_request_logs_request_id;
+
+-- Запросы от 10 секунд в DECIMAL(10, 6) не помещаются: без ограничения откат падал бы с numeric overflow
+ALTER TABLE request_logs
+    ALTER COLUMN duration_ms TYPE DECIMAL(10, 6) USING LEAST(duration_ms, 9999.999999);
+
+ALTER TABLE request_logs
+    DROP COLUMN IF EXISTS error,
+    DROP COLUMN IF EXISTS user_agent,
+    DROP COLUMN IF EXISTS bytes_out,
+    DROP COLUMN IF EXISTS workspace_id,
+    DROP COLUMN IF EXISTS user_id,
+    DROP COLUMN IF EXISTS route,
+    DROP COLUMN IF EXISTS request_id;
@@ -0,0 +1,20 @@
+-- Структурированные JSON-логи запросов: request_id, пользователь, воркспейс, шаблон маршрута, размер ответа и ошибка
+ALTER TABLE request_logs
+    ADD COLUMN request_id VARCHAR(64),
+    ADD COLUMN route TEXT,
+    ADD COLUMN user_id UUID,
+    ADD COLUMN workspace_id UUID,
+    ADD COLUMN bytes_out BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN user_agent TEXT,
+    ADD COLUMN error TEXT;
+
+-- DECIMAL(10, 6) не вмещает запросы дольше 10 секунд
+ALTER TABLE request_logs
+    ALTER COLUMN duration_ms TYPE DECIMAL(14, 6);
+
+CREATE INDEX IF NOT EXISTS idx_request_logs_request_id ON request_logs(request_id);
+CREATE INDEX IF NOT EXISTS idx_request_logs_user_id ON request_logs(user_id);
+
+COMMENT ON COLUMN request_logs.request_id IS 'X-Request-ID: пришедший от клиента или сгенерированный middleware';
+COMMENT ON COLUMN request_logs.route IS 'Шаблон маршрута gin (например /api/v1/workspaces/:workspaceId/habits)';
+COMMENT ON COLUMN request_logs.raw_log IS 'Исходная JSON-запись из лог-файла';
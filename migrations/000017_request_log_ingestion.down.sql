@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS request_log_checkpoints;
+
+DROP INDEX IF EXISTS idx_request_logs_content_hash;
+
+ALTER TABLE request_logs
+    DROP COLUMN IF EXISTS content_hash;
@@ -0,0 +1,23 @@
+-- Инкрементальная загрузка лог-файлов: хэш содержимого для дедупликации и чекпоинт смещения в файле
+
+ALTER TABLE request_logs
+    ADD COLUMN content_hash CHAR(64);
+
+UPDATE request_logs SET content_hash = encode(sha256(convert_to(raw_log, 'UTF8')), 'hex');
+
+-- Убираем дубли, оставшиеся от повторных синхронизаций
+DELETE FROM request_logs a
+USING request_logs b
+WHERE a.content_hash = b.content_hash AND a.id > b.id;
+
+CREATE UNIQUE INDEX idx_request_logs_content_hash ON request_logs(content_hash);
+
+COMMENT ON COLUMN request_logs.content_hash IS 'sha256 исходной строки лога: повторная загрузка того же файла не создаёт дублей';
+
+CREATE TABLE request_log_checkpoints (
+    file_name VARCHAR(255) PRIMARY KEY,
+    file_offset BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE request_log_checkpoints IS 'До какого байта (включительно целые строки) лог-файл уже загружен в request_logs';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS request_log_retention;
+DROP TABLE IF EXISTS request_log_daily_stats;
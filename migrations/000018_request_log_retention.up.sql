@@ -0,0 +1,29 @@
+-- Ретеншн логов запросов: дневные агрегаты по маршрутам и граница, до которой сырые строки свёрнуты и удалены
+
+CREATE TABLE request_log_daily_stats (
+    day DATE NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    route TEXT NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    client_errors BIGINT NOT NULL DEFAULT 0,
+    server_errors BIGINT NOT NULL DEFAULT 0,
+    total_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    max_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    p50_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    p95_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    p99_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    bytes_out BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, method, route)
+);
+
+COMMENT ON TABLE request_log_daily_stats IS 'Свёртка request_logs по дню, методу и шаблону маршрута; сырые строки за эти дни удаляются';
+
+CREATE TABLE request_log_retention (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    rolled_up_before DATE,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO request_log_retention (id) VALUES (TRUE);
+
+COMMENT ON TABLE request_log_retention IS 'Одна строка: строки request_logs раньше rolled_up_before свёрнуты в request_log_daily_stats и больше не загружаются';
@@ -1,6 +1,10 @@
+DROP TRIGGER IF EXISTS update_habits_updated_at ON habits;
+DROP TRIGGER IF EXISTS update_workspaces_updated_at ON workspaces;
+DROP FUNCTION IF EXISTS update_updated_at_column();
+
 ALTER TABLE habit_completions DROP CONSTRAINT IF EXISTS unique_habit_date_user;
+ALTER TABLE habit_completions DROP CONSTRAINT IF EXISTS fk_completions_workspace;
 ALTER TABLE habit_completions DROP CONSTRAINT IF EXISTS fk_completions_user;
-ALTER TABLE habit_completions DROP CONSTRAINT IF EXISTS fk_completions_habit;
 ALTER TABLE habits DROP CONSTRAINT IF EXISTS fk_habits_workspace;
 ALTER TABLE habits DROP CONSTRAINT IF EXISTS fk_habits_user;
 ALTER TABLE workspaces DROP CONSTRAINT IF EXISTS fk_workspaces_owner;
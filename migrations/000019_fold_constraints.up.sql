@@ -0,0 +1,129 @@
+-- Ограничения и триггеры из бывшего каталога migrations/constraints, который golang-migrate не читал.
+-- В части баз их могли применить вручную, поэтому всё создаётся только при отсутствии.
+--
+-- Отличия от исходных файлов:
+--   * habits -> workspaces с ON DELETE CASCADE: без этого DELETE /workspaces/:id падает на воркспейсах с привычками;
+--     все остальные таблицы воркспейса уже удаляются каскадом.
+--   * habit_completions -> habits не добавляется: выполнения удалённых привычек намеренно остаются
+--     для исторического календаря (docs/HABITS_HISTORY.md). Вместо него — habit_completions -> workspaces.
+
+-- Временная функция: FK создаётся NOT VALID (не блокирует таблицу проверкой), затем валидируется.
+-- Если в старых данных остались нарушения, FK остаётся NOT VALID (новые строки проверяются) — это покажет schema-check.
+CREATE FUNCTION pg_temp.add_fk_if_missing(tbl regclass, cname text, def text) RETURNS void AS $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = tbl AND conname = cname) THEN
+        EXECUTE format('ALTER TABLE %s ADD CONSTRAINT %I %s NOT VALID', tbl, cname, def);
+    END IF;
+    BEGIN
+        EXECUTE format('ALTER TABLE %s VALIDATE CONSTRAINT %I', tbl, cname);
+    EXCEPTION WHEN foreign_key_violation THEN
+        RAISE WARNING 'constraint %.% left NOT VALID: existing rows violate it (%)', tbl, cname, SQLERRM;
+    END;
+END;
+$$ LANGUAGE plpgsql;
+
+-- 1. Данные, которые без ограничений остались висеть
+
+-- Привычки и выполнения удалённых воркспейсов (для остальных таблиц это сделал бы ON DELETE CASCADE)
+DELETE FROM habit_completions hc
+WHERE hc.workspace_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM workspaces w WHERE w.id = hc.workspace_id);
+
+DELETE FROM habits h
+WHERE NOT EXISTS (SELECT 1 FROM workspaces w WHERE w.id = h.workspace_id);
+
+-- Выполнения без workspace_id, у которых не осталось привычки, уже не привязать ни к одному воркспейсу
+DELETE FROM habit_completions hc
+WHERE hc.workspace_id IS NULL
+  AND NOT EXISTS (SELECT 1 FROM habits h WHERE h.id = hc.habit_id);
+
+UPDATE habit_completions hc
+SET workspace_id = h.workspace_id
+FROM habits h
+WHERE hc.habit_id = h.id AND hc.workspace_id IS NULL;
+
+-- Дубли выполнения за один день (гонка в Toggle): оставляем самое раннее
+DELETE FROM habit_completions a
+USING habit_completions b
+WHERE a.habit_id = b.habit_id
+  AND a.user_id = b.user_id
+  AND a.date = b.date
+  AND (a.created_at, a.id) > (b.created_at, b.id);
+
+-- 2. Внешние ключи
+
+SELECT pg_temp.add_fk_if_missing('workspaces', 'fk_workspaces_owner',
+    'FOREIGN KEY (owner_id) REFERENCES users(id)');
+
+SELECT pg_temp.add_fk_if_missing('habits', 'fk_habits_user',
+    'FOREIGN KEY (user_id) REFERENCES users(id)');
+
+SELECT pg_temp.add_fk_if_missing('habits', 'fk_habits_workspace',
+    'FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE');
+
+SELECT pg_temp.add_fk_if_missing('habit_completions', 'fk_completions_user',
+    'FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE');
+
+SELECT pg_temp.add_fk_if_missing('habit_completions', 'fk_completions_workspace',
+    'FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE');
+
+-- Если fk_habits_workspace когда-то создали вручную без каскада — пересоздаём с каскадом
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM pg_constraint
+        WHERE conrelid = 'habits'::regclass AND conname = 'fk_habits_workspace' AND confdeltype <> 'c'
+    ) THEN
+        ALTER TABLE habits DROP CONSTRAINT fk_habits_workspace;
+        PERFORM pg_temp.add_fk_if_missing('habits', 'fk_habits_workspace',
+            'FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE');
+    END IF;
+END;
+$$;
+
+-- 3. Одно выполнение привычки пользователем за день
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint
+        WHERE conrelid = 'habit_completions'::regclass AND conname = 'unique_habit_date_user'
+    ) THEN
+        ALTER TABLE habit_completions ADD CONSTRAINT unique_habit_date_user UNIQUE (habit_id, date, user_id);
+    END IF;
+END;
+$$;
+
+-- 4. updated_at
+
+CREATE OR REPLACE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_trigger
+        WHERE tgrelid = 'workspaces'::regclass AND tgname = 'update_workspaces_updated_at'
+    ) THEN
+        CREATE TRIGGER update_workspaces_updated_at
+            BEFORE UPDATE ON workspaces
+            FOR EACH ROW
+            EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_trigger
+        WHERE tgrelid = 'habits'::regclass AND tgname = 'update_habits_updated_at'
+    ) THEN
+        CREATE TRIGGER update_habits_updated_at
+            BEFORE UPDATE ON habits
+            FOR EACH ROW
+            EXECUTE FUNCTION update_updated_at_column();
+    END IF;
+END;
+$$;
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_counterparties_search;
+DROP INDEX IF EXISTS idx_habits_search;
+DROP INDEX IF EXISTS idx_journal_entries_search;
+DROP INDEX IF EXISTS idx_notes_search;
+
+ALTER TABLE counterparties DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE habits DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE journal_entries DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE notes DROP COLUMN IF EXISTS search_vector;
+
+DROP FUNCTION IF EXISTS search_tags_text(TEXT[]);
+DROP FUNCTION IF EXISTS search_document(TEXT, TEXT);
@@ -0,0 +1,42 @@
+-- Полнотекстовый поиск по заметкам, дневнику, привычкам и контрагентам.
+-- Контент смешанный (русский и английский), поэтому документ индексируется в трёх конфигурациях:
+-- simple (точные слова и поиск по префиксу), russian и english (словоформы). Заголовок — вес A, текст — вес B.
+
+CREATE FUNCTION search_document(title TEXT, body TEXT) RETURNS tsvector
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+    SELECT setweight(to_tsvector('simple'::regconfig, COALESCE(title, '')), 'A')
+        || setweight(to_tsvector('russian'::regconfig, COALESCE(title, '')), 'A')
+        || setweight(to_tsvector('english'::regconfig, COALESCE(title, '')), 'A')
+        || setweight(to_tsvector('simple'::regconfig, COALESCE(body, '')), 'B')
+        || setweight(to_tsvector('russian'::regconfig, COALESCE(body, '')), 'B')
+        || setweight(to_tsvector('english'::regconfig, COALESCE(body, '')), 'B')
+$$;
+
+-- array_to_string помечена STABLE, а для generated column нужна IMMUTABLE; для text[] результат детерминирован
+CREATE FUNCTION search_tags_text(tags TEXT[]) RETURNS TEXT
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+    SELECT array_to_string(tags, ' ')
+$$;
+
+ALTER TABLE notes
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (search_document(title, content)) STORED;
+
+ALTER TABLE journal_entries
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (search_document(search_tags_text(tags), description)) STORED;
+
+ALTER TABLE habits
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        search_document(title, COALESCE(description, '') || ' ' || COALESCE(category, ''))
+    ) STORED;
+
+ALTER TABLE counterparties
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        search_document(name, COALESCE(email, '') || ' ' || COALESCE(phone, '') || ' ' || COALESCE(comment, ''))
+    ) STORED;
+
+CREATE INDEX idx_notes_search ON notes USING GIN (search_vector);
+CREATE INDEX idx_journal_entries_search ON journal_entries USING GIN (search_vector);
+CREATE INDEX idx_habits_search ON habits USING GIN (search_vector);
+CREATE INDEX idx_counterparties_search ON counterparties USING GIN (search_vector);
+
+COMMENT ON FUNCTION search_document(TEXT, TEXT) IS 'tsvector для поиска: заголовок (A) и текст (B) в конфигурациях simple, russian, english';
@@ -0,0 +1,2 @@
+-- Фильтры дневника по тегам (tags && / @>) и облако тегов
+CREATE INDEX IF NOT EXISTS idx_journal_entries_tags ON journal_entries USING GIN (tags);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS journal_entry_revisions;
+DROP FUNCTION IF EXISTS fn_journal_entry_revisions_append_only();
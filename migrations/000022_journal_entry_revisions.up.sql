@@ -0,0 +1,39 @@
+-- История правок записей дневника: каждое сохранение — новая ревизия (снимок всех полей записи).
+-- Таблица только дополняется; удаляется вместе с записью.
+CREATE TABLE journal_entry_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entry_id UUID NOT NULL REFERENCES journal_entries(id) ON DELETE CASCADE,
+    workspace_id UUID NOT NULL,
+    revision INTEGER NOT NULL,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    description TEXT NOT NULL,
+    mood INTEGER,
+    date DATE NOT NULL,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    content_type VARCHAR(20) NOT NULL,
+    metadata JSONB,
+    restored_from INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_journal_entry_revisions_entry_revision UNIQUE (entry_id, revision)
+);
+
+COMMENT ON TABLE journal_entry_revisions IS 'Ревизии записей дневника (append-only). restored_from — номер ревизии, из которой восстановлена эта.';
+
+-- Изменять ревизии нельзя; единственное исключение — обнуление author_id при удалении пользователя (ON DELETE SET NULL)
+CREATE OR REPLACE FUNCTION fn_journal_entry_revisions_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    IF to_jsonb(NEW) - 'author_id' IS DISTINCT FROM to_jsonb(OLD) - 'author_id' OR NEW.author_id IS NOT NULL THEN
+        RAISE EXCEPTION 'journal_entry_revisions is append-only';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tr_journal_entry_revisions_append_only
+    BEFORE UPDATE ON journal_entry_revisions
+    FOR EACH ROW EXECUTE FUNCTION fn_journal_entry_revisions_append_only();
+
+-- Текущее состояние существующих записей — ревизия 1
+INSERT INTO journal_entry_revisions (entry_id, workspace_id, revision, author_id, description, mood, date, tags, content_type, metadata, created_at)
+SELECT id, workspace_id, 1, user_id, description, mood, date, tags, content_type, metadata, updated_at
+FROM journal_entries;
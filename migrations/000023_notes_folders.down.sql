@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_notes_tags;
+DROP INDEX IF EXISTS idx_notes_folder_position;
+
+ALTER TABLE notes
+    DROP COLUMN IF EXISTS archived_at,
+    DROP COLUMN IF EXISTS position,
+    DROP COLUMN IF EXISTS content_type,
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS pinned,
+    DROP COLUMN IF EXISTS folder_id;
+
+DROP TABLE IF EXISTS note_folders;
@@ -0,0 +1,35 @@
+-- Заметки: вложенные папки, закрепление, теги, архив, формат текста и ручной порядок внутри папки.
+-- parent_id и folder_id — NO ACTION, а не RESTRICT: проверка в конце statement, поэтому каскадное удаление
+-- воркспейса проходит, а удалить непустую папку нельзя.
+
+CREATE TABLE note_folders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    parent_id UUID REFERENCES note_folders(id),
+    name VARCHAR(255) NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (parent_id IS DISTINCT FROM id)
+);
+CREATE INDEX idx_note_folders_workspace_parent ON note_folders(workspace_id, parent_id, position);
+COMMENT ON TABLE note_folders IS 'Модуль Заметки: дерево папок воркспейса. parent_id NULL — папка в корне.';
+
+ALTER TABLE notes
+    ADD COLUMN folder_id UUID REFERENCES note_folders(id),
+    ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN content_type VARCHAR(20) NOT NULL DEFAULT 'text' CHECK (content_type IN ('text', 'markdown')),
+    ADD COLUMN position INT NOT NULL DEFAULT 0,
+    ADD COLUMN archived_at TIMESTAMP;
+
+-- Существующие заметки лежат в корне; порядок — по времени создания
+UPDATE notes n SET position = o.rn
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY workspace_id ORDER BY created_at, id) - 1 AS rn
+    FROM notes
+) o
+WHERE o.id = n.id;
+
+CREATE INDEX idx_notes_folder_position ON notes(workspace_id, folder_id, position);
+CREATE INDEX idx_notes_tags ON notes USING GIN (tags);
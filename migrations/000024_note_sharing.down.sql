@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS note_link_access_log;
+DROP TABLE IF EXISTS note_links;
+DROP TABLE IF EXISTS note_shares;
@@ -0,0 +1,40 @@
+-- Доступ к отдельной заметке вне воркспейса: пользователям (чтение или правка) и по публичной ссылке.
+
+CREATE TABLE note_shares (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    note_id UUID NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    permission VARCHAR(10) NOT NULL CHECK (permission IN ('read', 'edit')),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_note_shares_note_user UNIQUE (note_id, user_id)
+);
+CREATE INDEX idx_note_shares_user ON note_shares(user_id);
+COMMENT ON TABLE note_shares IS 'Модуль Заметки: заметка, открытая пользователю вне воркспейса (read/edit).';
+
+-- Токен ссылки хранится только как SHA-256: сам токен показывается один раз при создании
+CREATE TABLE note_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    note_id UUID NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL,
+    password_hash VARCHAR(255),
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_note_links_token_hash UNIQUE (token_hash)
+);
+CREATE INDEX idx_note_links_note ON note_links(note_id);
+COMMENT ON TABLE note_links IS 'Модуль Заметки: публичные ссылки только для чтения (срок, пароль, отзыв).';
+
+CREATE TABLE note_link_access_log (
+    id BIGSERIAL PRIMARY KEY,
+    link_id UUID NOT NULL REFERENCES note_links(id) ON DELETE CASCADE,
+    accessed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    outcome VARCHAR(20) NOT NULL,
+    client_ip VARCHAR(64),
+    user_agent VARCHAR(500)
+);
+CREATE INDEX idx_note_link_access_log_link ON note_link_access_log(link_id, accessed_at DESC);
+COMMENT ON TABLE note_link_access_log IS 'Обращения к публичным ссылкам на заметки: ok, password_required, wrong_password, expired, revoked, locked.';
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS tr_counterparties_entity_links_cleanup ON counterparties;
+DROP TRIGGER IF EXISTS tr_habits_entity_links_cleanup ON habits;
+DROP TRIGGER IF EXISTS tr_journal_entries_entity_links_cleanup ON journal_entries;
+DROP TRIGGER IF EXISTS tr_notes_entity_links_cleanup ON notes;
+DROP FUNCTION IF EXISTS fn_entity_links_cleanup();
+DROP TABLE IF EXISTS entity_links;
@@ -0,0 +1,37 @@
+-- Связи между заметками, записями дневника, привычками и контрагентами.
+-- wiki — из [[ссылок]] в тексте заметки или записи (пересобираются при сохранении), manual — добавлены вручную.
+-- Сущности лежат в разных таблицах, поэтому внешних ключей на них нет: связи удаляют триггеры ниже.
+CREATE TABLE entity_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    source_type VARCHAR(20) NOT NULL CHECK (source_type IN ('note', 'journal', 'habit', 'counterparty')),
+    source_id UUID NOT NULL,
+    target_type VARCHAR(20) NOT NULL CHECK (target_type IN ('note', 'journal', 'habit', 'counterparty')),
+    target_id UUID NOT NULL,
+    origin VARCHAR(10) NOT NULL CHECK (origin IN ('wiki', 'manual')),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_entity_links_source_target UNIQUE (source_type, source_id, target_type, target_id, origin),
+    CONSTRAINT chk_entity_links_not_self CHECK (source_type <> target_type OR source_id <> target_id)
+);
+CREATE INDEX idx_entity_links_target ON entity_links(target_type, target_id);
+COMMENT ON TABLE entity_links IS 'Связи между сущностями воркспейса (note, journal, habit, counterparty): wiki — из текста, manual — вручную.';
+
+-- Удаление сущности убирает все её связи в обе стороны. TG_ARGV[0] — тип сущности таблицы.
+CREATE OR REPLACE FUNCTION fn_entity_links_cleanup() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM entity_links
+    WHERE (source_type = TG_ARGV[0] AND source_id = OLD.id)
+       OR (target_type = TG_ARGV[0] AND target_id = OLD.id);
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tr_notes_entity_links_cleanup
+    AFTER DELETE ON notes FOR EACH ROW EXECUTE FUNCTION fn_entity_links_cleanup('note');
+CREATE TRIGGER tr_journal_entries_entity_links_cleanup
+    AFTER DELETE ON journal_entries FOR EACH ROW EXECUTE FUNCTION fn_entity_links_cleanup('journal');
+CREATE TRIGGER tr_habits_entity_links_cleanup
+    AFTER DELETE ON habits FOR EACH ROW EXECUTE FUNCTION fn_entity_links_cleanup('habit');
+CREATE TRIGGER tr_counterparties_entity_links_cleanup
+    AFTER DELETE ON counterparties FOR EACH ROW EXECUTE FUNCTION fn_entity_links_cleanup('counterparty');
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS tr_attachments_queue_purge ON attachments;
+DROP FUNCTION IF EXISTS fn_attachments_queue_purge();
+DROP TABLE IF EXISTS attachment_purge_queue;
+DROP TABLE IF EXISTS attachments;
@@ -0,0 +1,43 @@
+-- Вложения: файлы в хранилище (локальный диск или S3), привязанные к заметке, записи дневника или выполнению привычки.
+-- В БД — только метаданные; сам файл лежит в хранилище под storage_key.
+CREATE TABLE attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    note_id UUID REFERENCES notes(id) ON DELETE CASCADE,
+    journal_entry_id UUID REFERENCES journal_entries(id) ON DELETE CASCADE,
+    habit_completion_id UUID REFERENCES habit_completions(id) ON DELETE CASCADE,
+    uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    storage_key VARCHAR(500) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes >= 0),
+    sha256 VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_attachments_storage_key UNIQUE (storage_key),
+    CONSTRAINT chk_attachments_one_owner CHECK (num_nonnulls(note_id, journal_entry_id, habit_completion_id) = 1)
+);
+CREATE INDEX idx_attachments_workspace ON attachments(workspace_id);
+CREATE INDEX idx_attachments_note ON attachments(note_id) WHERE note_id IS NOT NULL;
+CREATE INDEX idx_attachments_journal_entry ON attachments(journal_entry_id) WHERE journal_entry_id IS NOT NULL;
+CREATE INDEX idx_attachments_habit_completion ON attachments(habit_completion_id) WHERE habit_completion_id IS NOT NULL;
+COMMENT ON TABLE attachments IS 'Вложения (метаданные). Ровно один владелец: заметка, запись дневника или выполнение привычки.';
+
+-- Файлы удалённых вложений: строки удаляются и каскадом (заметка, воркспейс), поэтому ключ в очередь кладёт триггер,
+-- а из хранилища файл удаляет фоновый воркер
+CREATE TABLE attachment_purge_queue (
+    storage_key VARCHAR(500) PRIMARY KEY,
+    queued_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    attempts INTEGER NOT NULL DEFAULT 0
+);
+COMMENT ON TABLE attachment_purge_queue IS 'Ключи файлов удалённых вложений, которые ещё нужно удалить из хранилища.';
+
+CREATE OR REPLACE FUNCTION fn_attachments_queue_purge() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO attachment_purge_queue (storage_key) VALUES (OLD.storage_key) ON CONFLICT DO NOTHING;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tr_attachments_queue_purge
+    AFTER DELETE ON attachments
+    FOR EACH ROW EXECUTE FUNCTION fn_attachments_queue_purge();
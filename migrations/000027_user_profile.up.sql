@@ -0,0 +1,19 @@
+-- Профиль пользователя: язык, часовой пояс, аватар в хранилище вложений
+ALTER TABLE users ADD COLUMN locale VARCHAR(16);
+ALTER TABLE users ADD COLUMN timezone VARCHAR(64);
+-- avatar_key — префикс ключей файлов аватара в хранилище (<prefix>/<size>.jpg); avatar_url — стабильная ссылка на него
+ALTER TABLE users ADD COLUMN avatar_key VARCHAR(255);
+
+-- Смена email: новый адрес применяется только после перехода по ссылке из письма.
+-- Одна незавершённая заявка на пользователя; в базе хранится SHA-256 токена.
+CREATE TABLE email_change_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    new_email VARCHAR(255) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_email_change_requests_user UNIQUE (user_id),
+    CONSTRAINT uq_email_change_requests_token UNIQUE (token_hash)
+);
+COMMENT ON TABLE email_change_requests IS 'Заявки на смену email, ожидающие подтверждения с нового адреса.';
@@ -0,0 +1,19 @@
+-- Выгрузка данных пользователя (GDPR): ZIP собирает фоновый воркер, файл лежит в хранилище вложений до expires_at
+CREATE TABLE data_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    storage_key VARCHAR(500),
+    size_bytes BIGINT,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    CONSTRAINT chk_data_exports_status CHECK (status IN ('pending', 'running', 'ready', 'failed', 'expired'))
+);
+CREATE INDEX idx_data_exports_user ON data_exports(user_id, created_at DESC);
+CREATE INDEX idx_data_exports_queue ON data_exports(created_at) WHERE status IN ('pending', 'running');
+-- Не больше одной незавершённой выгрузки на пользователя
+CREATE UNIQUE INDEX uq_data_exports_user_active ON data_exports(user_id) WHERE status IN ('pending', 'running');
+COMMENT ON TABLE data_exports IS 'Запросы на выгрузку всех данных пользователя в ZIP.';
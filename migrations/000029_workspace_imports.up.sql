@@ -0,0 +1,13 @@
+-- Импорты воркспейса из архива: повторный импорт того же архива (по SHA-256) не дублирует данные
+CREATE TABLE workspace_imports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    checksum CHAR(64) NOT NULL,
+    format_version INTEGER NOT NULL,
+    stats JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_workspace_imports_checksum UNIQUE (workspace_id, checksum)
+);
+CREATE INDEX idx_workspace_imports_user ON workspace_imports(user_id, checksum);
+COMMENT ON TABLE workspace_imports IS 'Импорты воркспейса из JSON-архива (резервная копия, перенос между серверами).';
@@ -0,0 +1,9 @@
+-- Ссылка на ICS-ленту привычек пользователя: одна на пользователя, в базе — SHA-256 секретного токена
+CREATE TABLE calendar_feeds (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_accessed_at TIMESTAMP,
+    CONSTRAINT uq_calendar_feeds_token UNIQUE (token_hash)
+);
+COMMENT ON TABLE calendar_feeds IS 'Подписка на расписание привычек в Google/Apple Calendar (iCalendar по секретной ссылке).';
@@ -0,0 +1,17 @@
+-- Без deleted_at содержимое корзины вернулось бы в списки, поэтому оно удаляется окончательно
+ALTER TABLE habit_completions DROP CONSTRAINT IF EXISTS fk_completions_habit;
+
+DELETE FROM habit_versions v USING habits h WHERE h.id = v.habit_id AND h.deleted_at IS NOT NULL;
+DELETE FROM habit_completions hc USING habits h WHERE h.id = hc.habit_id AND h.deleted_at IS NOT NULL;
+DELETE FROM habits WHERE deleted_at IS NOT NULL;
+DELETE FROM notes WHERE deleted_at IS NOT NULL;
+DELETE FROM journal_entries WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_journal_entries_trash;
+DROP INDEX IF EXISTS idx_notes_trash;
+DROP INDEX IF EXISTS idx_habits_trash;
+
+ALTER TABLE journal_entries DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE notes DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE habits DROP COLUMN IF EXISTS purge_exempt;
+ALTER TABLE habits DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,42 @@
+-- Корзина: удалённые привычки, заметки и записи дневника не стираются сразу, а помечаются deleted_at.
+-- Из корзины их можно восстановить; окончательно удаляет пользователь или фоновый воркер по истечении срока хранения.
+ALTER TABLE habits ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE notes ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE journal_entries ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_habits_trash ON habits(workspace_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_notes_trash ON notes(workspace_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_journal_entries_trash ON journal_entries(workspace_id, deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Привычки, которые миграция вернула в корзину (см. ниже): воркер не удаляет их по сроку хранения,
+-- окончательно их удаляет только пользователь. Восстановление и повторное удаление снимают флаг.
+ALTER TABLE habits ADD COLUMN purge_exempt BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN habits.purge_exempt IS 'Не удалять из корзины по TRASH_RETENTION_DAYS: привычка удалена до появления корзины';
+
+-- Раньше привычка удалялась сразу, а её выполнения оставались без внешнего ключа (000019) ради исторического
+-- календаря. Такие привычки восстанавливаем из последней версии сразу в корзину с исходной датой удаления
+-- (она же valid_to последней версии): история в календаре остаётся, а выполнения получают ключ с каскадом.
+-- Пользователь не удалял их историю, поэтому автоматически она не стирается — см. purge_exempt.
+INSERT INTO habits (id, title, description, color, icon, target_days, daily_goal, preferred_time, category,
+    schedule_type, recurring_days, one_time_date, is_active, user_id, workspace_id, created_at, updated_at,
+    deleted_at, purge_exempt)
+SELECT DISTINCT ON (v.habit_id)
+    v.habit_id, v.title, v.description, v.color, v.icon, v.target_days, v.daily_goal, v.preferred_time, v.category,
+    v.schedule_type, v.recurring_days, v.one_time_date, v.is_active, v.user_id, v.workspace_id,
+    (SELECT MIN(f.valid_from) FROM habit_versions f WHERE f.habit_id = v.habit_id), NOW(),
+    COALESCE(v.valid_to::timestamp, NOW()), TRUE
+FROM habit_versions v
+WHERE NOT EXISTS (SELECT 1 FROM habits h WHERE h.id = v.habit_id)
+  AND EXISTS (SELECT 1 FROM habit_completions hc WHERE hc.habit_id = v.habit_id)
+  AND EXISTS (SELECT 1 FROM users u WHERE u.id = v.user_id)
+  AND EXISTS (SELECT 1 FROM workspaces w WHERE w.id = v.workspace_id)
+ORDER BY v.habit_id, v.valid_from DESC, v.created_at DESC;
+
+-- Выполнения, которым не нашлось ни привычки, ни версии, в календаре и так не показывались
+DELETE FROM habit_completions hc
+WHERE NOT EXISTS (SELECT 1 FROM habits h WHERE h.id = hc.habit_id);
+
+ALTER TABLE habit_completions ADD CONSTRAINT fk_completions_habit
+    FOREIGN KEY (habit_id) REFERENCES habits(id) ON DELETE CASCADE NOT VALID;
+ALTER TABLE habit_completions VALIDATE CONSTRAINT fk_completions_habit;
@@ -1,3 +0,0 @@
-DROP TRIGGER IF EXISTS update_workspaces_updated_at ON workspaces;
-DROP TRIGGER IF EXISTS update_habits_updated_at ON habits;
-DROP FUNCTION IF EXISTS update_updated_at_column;
@@ -1,20 +0,0 @@
--- Функция для автоматического обновления updated_at
-CREATE OR REPLACE FUNCTION update_updated_at_column()
-RETURNS TRIGGER AS $$
-BEGIN
-    NEW.updated_at = NOW();
-    RETURN NEW;
-END;
-$$ language 'plpgsql';
-
--- Триггер для таблицы workspaces
-CREATE TRIGGER update_workspaces_updated_at 
-    BEFORE UPDATE ON workspaces 
-    FOR EACH ROW 
-    EXECUTE FUNCTION update_updated_at_column();
-
--- Триггер для таблицы habits
-CREATE TRIGGER update_habits_updated_at 
-    BEFORE UPDATE ON habits 
-    FOR EACH ROW 
-    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,10 @@
+// Package migrations встраивает SQL-миграции в бинарник: приложение и команда migrate
+// не зависят от рабочей директории.
+package migrations
+
+import "embed"
+
+// FS — файлы NNNNNN_name.up.sql / .down.sql из этого каталога
+//
+//go:embed *.sql
+var FS embed.FS
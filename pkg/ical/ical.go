@@ -0,0 +1,135 @@
+// Package ical собирает календарь в формате iCalendar (RFC 5545): события на весь день или со временем,
+// с правилом повторения.
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType — MIME-тип календаря
+const ContentType = "text/calendar; charset=utf-8"
+
+// maxLineOctets — предел длины строки без CRLF; длинные строки переносятся (RFC 5545, 3.1)
+const maxLineOctets = 75
+
+// Calendar — VCALENDAR. Name и Timezone пишутся расширениями X-WR-CALNAME и X-WR-TIMEZONE: их понимают
+// Google и Apple Calendar. Время событий «плавающее» (без зоны), Timezone подсказывает клиенту, в какой зоне его показывать.
+type Calendar struct {
+	ProdID   string
+	Name     string
+	Timezone string
+	Events   []Event
+}
+
+// Event — VEVENT. У AllDay из Start берётся только дата, иначе — местное время без зоны.
+type Event struct {
+	UID          string
+	Stamp        time.Time // DTSTAMP
+	LastModified time.Time // необязательно
+	Start        time.Time
+	AllDay       bool
+	Duration     time.Duration // для событий со временем; событие на весь день длится один день
+	RRule        string        // например FREQ=WEEKLY;BYDAY=MO,WE
+	Summary      string
+	Description  string
+	Categories   []string
+}
+
+// Bytes возвращает календарь: строки через CRLF, длинные строки перенесены
+func (c *Calendar) Bytes() []byte {
+	var b bytes.Buffer
+	line := func(name, value string) {
+		writeFolded(&b, name+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", c.ProdID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", EscapeText(c.Name))
+	}
+	if c.Timezone != "" {
+		line("X-WR-TIMEZONE", EscapeText(c.Timezone))
+	}
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", EscapeText(e.UID))
+		line("DTSTAMP", formatUTC(e.Stamp))
+		if e.AllDay {
+			line("DTSTART;VALUE=DATE", e.Start.Format("20060102"))
+			line("DURATION", "P1D")
+		} else {
+			line("DTSTART", e.Start.Format("20060102T150405"))
+			if minutes := int(e.Duration / time.Minute); minutes > 0 {
+				line("DURATION", fmt.Sprintf("PT%dM", minutes))
+			}
+		}
+		if e.RRule != "" {
+			line("RRULE", e.RRule)
+		}
+		line("SUMMARY", EscapeText(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", EscapeText(e.Description))
+		}
+		if len(e.Categories) > 0 {
+			values := make([]string, len(e.Categories))
+			for i, v := range e.Categories {
+				values[i] = EscapeText(v)
+			}
+			line("CATEGORIES", strings.Join(values, ","))
+		}
+		if !e.LastModified.IsZero() {
+			line("LAST-MODIFIED", formatUTC(e.LastModified))
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return b.Bytes()
+}
+
+// EscapeText экранирует значение типа TEXT: \ ; , и перевод строки; прочие управляющие символы, кроме табуляции, отбрасываются
+func EscapeText(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ReplaceAll(s, "\r\n", "\n") {
+		switch {
+		case r == '\\', r == ';', r == ',':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteRune(r)
+		case r < 0x20, r == 0x7f:
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func formatUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// writeFolded пишет строку, перенося её по 75 октетов: продолжение начинается с пробела.
+// Многобайтовые символы UTF-8 не разрываются.
+func writeFolded(b *bytes.Buffer, s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
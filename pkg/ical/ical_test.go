@@ -0,0 +1,65 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendarBytes(t *testing.T) {
+	stamp := time.Date(2026, 10, 18, 9, 30, 0, 0, time.FixedZone("MSK", 3*3600))
+	c := Calendar{
+		ProdID:   "-//test//RU",
+		Name:     "Привычки",
+		Timezone: "Europe/Moscow",
+		Events: []Event{
+			{
+				UID: "a@test", Stamp: stamp, Start: time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC), Duration: 30 * time.Minute,
+				RRule: "FREQ=WEEKLY;BYDAY=MO,WE", Summary: "Бег; 5 км, утром", Description: "строка 1\r\nстрока 2 \\",
+				Categories: []string{"Спорт", "a,b"},
+			},
+			{UID: "b@test", Stamp: stamp, LastModified: stamp, Start: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), AllDay: true, Summary: "Врач"},
+		},
+	}
+	got := string(c.Bytes())
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//RU\r\n",
+		"X-WR-TIMEZONE:Europe/Moscow\r\n",
+		"DTSTAMP:20261018T063000Z\r\n",
+		"DTSTART:20261019T080000\r\nDURATION:PT30M\r\nRRULE:FREQ=WEEKLY;BYDAY=MO,WE\r\n",
+		`SUMMARY:Бег\; 5 км\, утром` + "\r\n",
+		`DESCRIPTION:строка 1\nстрока 2 \\` + "\r\n",
+		`CATEGORIES:Спорт,a\,b` + "\r\n",
+		"DTSTART;VALUE=DATE:20261101\r\nDURATION:P1D\r\n",
+		"LAST-MODIFIED:20261018T063000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "BEGIN:VEVENT") != 2 {
+		t.Errorf("events:\n%s", got)
+	}
+}
+
+func TestFolding(t *testing.T) {
+	c := Calendar{ProdID: "-//test//RU", Events: []Event{{UID: "x", Summary: strings.Repeat("привычка ", 30)}}}
+	raw := string(c.Bytes())
+	if !strings.HasSuffix(raw, "\r\n") || strings.Contains(strings.ReplaceAll(raw, "\r\n", ""), "\n") {
+		t.Fatal("lines must end with CRLF")
+	}
+	var unfolded []string
+	for _, l := range strings.Split(strings.TrimSuffix(raw, "\r\n"), "\r\n") {
+		if len(l) > maxLineOctets {
+			t.Errorf("line is %d octets: %q", len(l), l)
+		}
+		if !strings.HasPrefix(l, " ") {
+			unfolded = append(unfolded, l)
+			continue
+		}
+		unfolded[len(unfolded)-1] += l[1:]
+	}
+	if want := "SUMMARY:" + strings.Repeat("привычка ", 30); !strings.Contains(strings.Join(unfolded, "\n"), want) {
+		t.Errorf("unfolded summary differs:\n%s", strings.Join(unfolded, "\n"))
+	}
+}
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompose(t *testing.T) {
+	body := strings.Repeat("Подтвердите адрес: https://example.com/confirm?token=abc ", 5)
+	raw := Compose("noreply@example.com", Message{To: "a@example.com", Subject: "Смена email", Body: body},
+		time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	if err != nil || subject != "Смена email" {
+		t.Errorf("subject = %q, %v", subject, err)
+	}
+	if got := m.Header.Get("To"); got != "a@example.com" {
+		t.Errorf("to = %q", got)
+	}
+	var encoded bytes.Buffer
+	if _, err := encoded.ReadFrom(m.Body); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(encoded.String()), "\r\n") {
+		if len(line) > 76 {
+			t.Fatalf("body line longer than 76: %d", len(line))
+		}
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(encoded.String(), "\r\n", ""))
+	if err != nil || string(decoded) != body {
+		t.Errorf("body = %q, %v", decoded, err)
+	}
+}
@@ -0,0 +1,328 @@
+// Package metrics — минимальная реализация метрик в текстовом формате Prometheus (exposition format 0.0.4)
+// без внешних зависимостей: счётчики и гистограммы с метками плюс значения, собираемые в момент scrape.
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ContentType — Content-Type ответа /metrics
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets — границы гистограммы длительности по умолчанию (секунды)
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector добавляет в ответ метрики, которые считаются в момент scrape (пул БД, бизнес-показатели)
+type Collector interface {
+	Collect(ctx context.Context, e *Exposition)
+}
+
+// CollectorFunc — функция как Collector
+type CollectorFunc func(ctx context.Context, e *Exposition)
+
+func (f CollectorFunc) Collect(ctx context.Context, e *Exposition) { f(ctx, e) }
+
+// Registry хранит зарегистрированные метрики и отдаёт их в текстовом формате
+type Registry struct {
+	mu         sync.Mutex
+	metrics    []metric
+	collectors []Collector
+	names      map[string]struct{}
+}
+
+type metric interface {
+	write(e *Exposition)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]struct{})}
+}
+
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.names[name]; ok {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.names[name] = struct{}{}
+	r.metrics = append(r.metrics, m)
+}
+
+// RegisterCollector добавляет Collector; он вызывается при каждом scrape
+func (r *Registry) RegisterCollector(c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteTo пишет все метрики в w
+func (r *Registry) WriteTo(ctx context.Context, w io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	e := &Exposition{}
+	for _, m := range metrics {
+		m.write(e)
+	}
+	for _, c := range collectors {
+		c.Collect(ctx, e)
+	}
+	_, err := w.Write(e.buf.Bytes())
+	return err
+}
+
+// Handler — http.Handler для /metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var buf bytes.Buffer
+		if err := r.WriteTo(req.Context(), &buf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		w.Write(buf.Bytes())
+	})
+}
+
+// Label — пара имя/значение метки
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Exposition накапливает текст ответа. Используется внутри Collector.
+type Exposition struct {
+	buf bytes.Buffer
+}
+
+// Header пишет строки # HELP и # TYPE семейства метрик (type: counter, gauge, histogram)
+func (e *Exposition) Header(name, help, typ string) {
+	fmt.Fprintf(&e.buf, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, typ)
+}
+
+// Sample пишет одно значение
+func (e *Exposition) Sample(name string, value float64, labels ...Label) {
+	e.buf.WriteString(name)
+	if len(labels) > 0 {
+		e.buf.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			e.buf.WriteString(l.Name)
+			e.buf.WriteString(`="`)
+			e.buf.WriteString(escapeLabel(l.Value))
+			e.buf.WriteByte('"')
+		}
+		e.buf.WriteByte('}')
+	}
+	e.buf.WriteByte(' ')
+	e.buf.WriteString(formatFloat(value))
+	e.buf.WriteByte('\n')
+}
+
+// Gauge пишет семейство из одного значения без меток
+func (e *Exposition) Gauge(name, help string, value float64) {
+	e.Header(name, help, "gauge")
+	e.Sample(name, value)
+}
+
+// CounterVec — счётчик с метками
+type CounterVec struct {
+	name, help string
+	labels     []string
+	mu         sync.RWMutex
+	values     map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	bits   uint64
+}
+
+// NewCounterVec создаёт и регистрирует счётчик
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, values: make(map[string]*counterValue)}
+	r.register(name, c)
+	return c
+}
+
+// Add увеличивает счётчик с метками labelValues (в порядке объявления) на v
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	cv := c.get(labelValues)
+	for {
+		old := atomic.LoadUint64(&cv.bits)
+		next := math.Float64bits(math.Float64frombits(old) + v)
+		if atomic.CompareAndSwapUint64(&cv.bits, old, next) {
+			return
+		}
+	}
+}
+
+// Inc увеличивает счётчик на 1
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) get(labelValues []string) *counterValue {
+	key := labelKey(c.name, c.labels, labelValues)
+	c.mu.RLock()
+	cv, ok := c.values[key]
+	c.mu.RUnlock()
+	if ok {
+		return cv
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cv, ok = c.values[key]; !ok {
+		cv = &counterValue{labels: append([]string(nil), labelValues...)}
+		c.values[key] = cv
+	}
+	return cv
+}
+
+func (c *CounterVec) write(e *Exposition) {
+	e.Header(c.name, c.help, "counter")
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, key := range sortedKeys(c.values) {
+		cv := c.values[key]
+		e.Sample(c.name, math.Float64frombits(atomic.LoadUint64(&cv.bits)), zipLabels(c.labels, cv.labels)...)
+	}
+}
+
+// HistogramVec — гистограмма с метками
+type HistogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+	mu         sync.RWMutex
+	values     map[string]*histogramValue
+}
+
+type histogramValue struct {
+	mu     sync.Mutex
+	labels []string
+	counts []uint64 // по бакетам, не накопительно
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec создаёт и регистрирует гистограмму. buckets — верхние границы по возрастанию (без +Inf).
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	h := &HistogramVec{name: name, help: help, labels: labels, buckets: b, values: make(map[string]*histogramValue)}
+	r.register(name, h)
+	return h
+}
+
+// Observe добавляет наблюдение v
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	hv := h.get(labelValues)
+	i := sort.SearchFloat64s(h.buckets, v)
+
+	hv.mu.Lock()
+	if i < len(h.buckets) {
+		hv.counts[i]++
+	}
+	hv.count++
+	hv.sum += v
+	hv.mu.Unlock()
+}
+
+func (h *HistogramVec) get(labelValues []string) *histogramValue {
+	key := labelKey(h.name, h.labels, labelValues)
+	h.mu.RLock()
+	hv, ok := h.values[key]
+	h.mu.RUnlock()
+	if ok {
+		return hv
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if hv, ok = h.values[key]; !ok {
+		hv = &histogramValue{labels: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hv
+	}
+	return hv
+}
+
+func (h *HistogramVec) write(e *Exposition) {
+	e.Header(h.name, h.help, "histogram")
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, key := range sortedKeys(h.values) {
+		hv := h.values[key]
+		labels := zipLabels(h.labels, hv.labels)
+
+		hv.mu.Lock()
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += hv.counts[i]
+			e.Sample(h.name+"_bucket", float64(cumulative), append(labels, Label{"le", formatFloat(upper)})...)
+		}
+		e.Sample(h.name+"_bucket", float64(hv.count), append(labels, Label{"le", "+Inf"})...)
+		e.Sample(h.name+"_sum", hv.sum, labels...)
+		e.Sample(h.name+"_count", float64(hv.count), labels...)
+		hv.mu.Unlock()
+	}
+}
+
+func labelKey(name string, names, values []string) string {
+	if len(values) != len(names) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", name, len(names), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+func zipLabels(names, values []string) []Label {
+	labels := make([]Label, len(names), len(names)+1)
+	for i := range names {
+		labels[i] = Label{names[i], values[i]}
+	}
+	return labels
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
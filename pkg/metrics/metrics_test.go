@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func expose(t *testing.T, r *Registry) string {
+	t.Helper()
+	var b strings.Builder
+	if err := r.WriteTo(context.Background(), &b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestCounterVec(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("http_requests_total", "Requests", "method", "route")
+	c.Inc("GET", "/b")
+	c.Inc("GET", "/a")
+	c.Add(2.5, "GET", "/a")
+
+	want := `# HELP http_requests_total Requests
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="/a"} 3.5
+http_requests_total{method="GET",route="/b"} 1
+`
+	if got := expose(t, r); got != want {
+		t.Fatalf("exposition:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHistogramVecBucketsAreCumulative(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("run_seconds", "Run", []float64{1, 0.1}, "worker")
+	for _, v := range []float64{0.05, 0.1, 0.5, 3} {
+		h.Observe(v, "w")
+	}
+
+	// Границы сортируются; наблюдение на границе попадает в бакет le=границы
+	want := `# HELP run_seconds Run
+# TYPE run_seconds histogram
+run_seconds_bucket{worker="w",le="0.1"} 2
+run_seconds_bucket{worker="w",le="1"} 3
+run_seconds_bucket{worker="w",le="+Inf"} 4
+run_seconds_sum{worker="w"} 3.65
+run_seconds_count{worker="w"} 4
+`
+	if got := expose(t, r); got != want {
+		t.Fatalf("exposition:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestEscapingAndCollectors(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("errors_total", "Errors \\ by\nreason", "reason").Inc("say \"hi\"\n\\")
+	r.RegisterCollector(CollectorFunc(func(_ context.Context, e *Exposition) {
+		e.Gauge("pool_open", "Open", 4)
+	}))
+
+	want := `# HELP errors_total Errors \\ by\nreason
+# TYPE errors_total counter
+errors_total{reason="say \"hi\"\n\\"} 1
+# HELP pool_open Open
+# TYPE pool_open gauge
+pool_open 4
+`
+	if got := expose(t, r); got != want {
+		t.Fatalf("exposition:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRegistryPanics(t *testing.T) {
+	mustPanic := func(name string, fn func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: no panic", name)
+			}
+		}()
+		fn()
+	}
+
+	r := NewRegistry()
+	c := r.NewCounterVec("dup_total", "Dup", "a")
+	mustPanic("duplicate name", func() { r.NewHistogramVec("dup_total", "Dup", DefBuckets) })
+	mustPanic("missing label value", func() { c.Inc() })
+	mustPanic("extra label value", func() { c.Inc("x", "y") })
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("hits_total", "Hits").Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != ContentType {
+		t.Fatalf("response: %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), "\nhits_total 1\n") {
+		t.Fatalf("body:\n%s", rec.Body)
+	}
+}
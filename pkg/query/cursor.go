@@ -0,0 +1,75 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// cursor — позиция после последней строки страницы. Непрозрачен для клиента: base64url(JSON).
+// Sort входит в курсор, чтобы курсор от другой сортировки не давал молча неверную страницу.
+type cursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   string `json:"id"`
+}
+
+func encodeCursor(c *cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// checkKey проверяет, что ключ курсора приводится к типу поля сортировки. Ключ — текстовый вид значения
+// из KeyColumn, поэтому форматы здесь постгресовые, а не те, что принимают фильтры.
+func checkKey(typ, key string) error {
+	var err error
+	switch typ {
+	case TypeText:
+		if strings.ContainsRune(key, 0) {
+			err = errors.New("NUL in text")
+		}
+	case TypeUUID:
+		_, err = uuid.Parse(key)
+	case TypeDate:
+		_, err = time.Parse("2006-01-02", key)
+	case TypeTime:
+		// '24:00' — допустимое значение time, им COALESCE отправляет пустое время в конец
+		if key != "24:00:00" {
+			_, err = time.Parse("15:04:05.999999", key)
+		}
+	case TypeTimestamp:
+		_, err = time.Parse("2006-01-02 15:04:05.999999", key)
+	case TypeBool:
+		if key != "true" && key != "false" {
+			err = errors.New("not a bool")
+		}
+	case TypeInt:
+		_, err = strconv.ParseInt(key, 10, 32)
+	case TypeBigint:
+		_, err = strconv.ParseInt(key, 10, 64)
+	}
+	return err
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
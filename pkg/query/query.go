@@ -0,0 +1,314 @@
+// Package query — общая спецификация выборки для списков: лимит, сортировка по разрешённым полям,
+// фильтры по разрешённым полям и keyset-курсор. Схема (Schema) описывает, что разрешено эндпоинту
+// и каким колонкам это соответствует; Parse разбирает query-параметры запроса по схеме.
+//
+// Параметры: limit (1..MaxLimit), sort (поле, "-поле" — по убыванию), cursor (nextCursor из прошлой страницы),
+// фильтры — отдельные параметры с именами из Schema.Filters.
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+var (
+	ErrInvalidLimit  = errors.New("invalid limit")
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidFilter = errors.New("invalid filter")
+)
+
+// Типы значений: по ним проверяется ввод и приводится параметр в SQL ($n::type)
+const (
+	TypeText      = "text"
+	TypeUUID      = "uuid"
+	TypeDate      = "date"
+	TypeTime      = "time"
+	TypeTimestamp = "timestamp"
+	TypeBool      = "bool"
+	TypeInt       = "int"
+	TypeBigint    = "bigint"
+)
+
+// Op — оператор фильтра
+type Op int
+
+const (
+	OpEq       Op = iota // column = value
+	OpContains           // column ILIKE %value%
+	OpGte                // column >= value
+	OpLte                // column <= value
+	OpHas                // value = ANY(column) — для массивов
+	OpAnyOf              // column && values — массив пересекается со списком (значения через запятую)
+	OpAllOf              // column @> values — массив содержит все значения списка
+)
+
+// SortField — поле сортировки. Column — SQL-выражение без NULL (nullable колонки заворачивать в COALESCE).
+type SortField struct {
+	Column string
+	Type   string
+}
+
+// Filter — поле фильтра. Default применяется, когда параметра нет в запросе (например, скрыть архивные).
+type Filter struct {
+	Column  string
+	Op      Op
+	Type    string
+	Default string
+}
+
+// Schema — что разрешено эндпоинту. Ключи Sorts — имена для ?sort=, ключи Filters — имена query-параметров.
+type Schema struct {
+	Sorts       map[string]SortField
+	Filters     map[string]Filter
+	DefaultSort string // например "-createdAt"
+	IDColumn    string // уникальная колонка для разрешения равенства ключей, по умолчанию "id"
+}
+
+// Spec — разобранная выборка. Создаётся через Parse или Default.
+type Spec struct {
+	Limit int
+	Sort  string // как в запросе: "title", "-createdAt"
+
+	field   SortField
+	desc    bool
+	id      string
+	after   *cursor
+	filters []filterValue
+}
+
+type filterValue struct {
+	Filter
+	value interface{}
+}
+
+// Default — первая страница с сортировкой по умолчанию и без фильтров
+func Default(s Schema, limit int) Spec {
+	spec, err := Parse(url.Values{}, s)
+	if err != nil {
+		panic(fmt.Sprintf("query: bad default sort %q", s.DefaultSort))
+	}
+	if limit > 0 {
+		spec.Limit = min(limit, MaxLimit)
+	}
+	return spec
+}
+
+// Parse разбирает limit, sort, cursor и фильтры схемы. Остальные параметры игнорируются.
+func Parse(values url.Values, s Schema) (Spec, error) {
+	spec := Spec{Limit: DefaultLimit, Sort: s.DefaultSort, id: s.IDColumn}
+	if spec.id == "" {
+		spec.id = "id"
+	}
+
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxLimit {
+			return Spec{}, fmt.Errorf("%w: must be between 1 and %d", ErrInvalidLimit, MaxLimit)
+		}
+		spec.Limit = n
+	}
+
+	if v := values.Get("sort"); v != "" {
+		spec.Sort = v
+	}
+	name := strings.TrimPrefix(spec.Sort, "-")
+	field, ok := s.Sorts[name]
+	if !ok {
+		return Spec{}, fmt.Errorf("%w: %q, allowed: %s", ErrInvalidSort, name, strings.Join(sortedKeys(s.Sorts), ", "))
+	}
+	spec.field = field
+	spec.desc = strings.HasPrefix(spec.Sort, "-")
+
+	if v := values.Get("cursor"); v != "" {
+		c, err := decodeCursor(v)
+		if err != nil || c.Sort != spec.Sort {
+			return Spec{}, ErrInvalidCursor
+		}
+		if _, err := uuid.Parse(c.ID); err != nil {
+			return Spec{}, ErrInvalidCursor
+		}
+		// Ключ уходит в SQL как $n::type: неприводимое значение дало бы ошибку БД вместо 400
+		if err := checkKey(field.Type, c.Key); err != nil {
+			return Spec{}, ErrInvalidCursor
+		}
+		spec.after = c
+	}
+
+	for _, name := range sortedKeys(s.Filters) {
+		f := s.Filters[name]
+		raw := values.Get(name)
+		if raw == "" {
+			raw = f.Default
+		}
+		if raw == "" {
+			continue
+		}
+		// NUL Postgres не принимает в тексте: запрос упал бы с invalid byte sequence вместо 400
+		if strings.ContainsRune(raw, 0) {
+			return Spec{}, fmt.Errorf("%w: %s: must not contain NUL", ErrInvalidFilter, name)
+		}
+		var v interface{}
+		var err error
+		switch f.Op {
+		case OpContains:
+			v = "%" + likeEscaper.Replace(raw) + "%"
+		case OpAnyOf, OpAllOf:
+			v, err = parseList(f.Type, raw)
+		default:
+			v, err = parseValue(f.Type, raw)
+		}
+		if err != nil {
+			return Spec{}, fmt.Errorf("%w: %s: %v", ErrInvalidFilter, name, err)
+		}
+		spec.filters = append(spec.filters, filterValue{Filter: f, value: v})
+	}
+	return spec, nil
+}
+
+// SQL возвращает условия для WHERE (каждое начинается с " AND "), хвост " ORDER BY ... LIMIT ..." и их аргументы.
+// next — номер первого свободного плейсхолдера ($next). LIMIT на одну строку больше — по ней Paginate узнаёт hasMore.
+func (s Spec) SQL(next int) (where, tail string, args []interface{}) {
+	var b strings.Builder
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(next+len(args)-1)
+	}
+
+	for _, f := range s.filters {
+		switch f.Op {
+		case OpEq:
+			fmt.Fprintf(&b, " AND %s = %s::%s", f.Column, arg(f.value), f.Type)
+		case OpContains:
+			fmt.Fprintf(&b, " AND %s ILIKE %s", f.Column, arg(f.value))
+		case OpGte:
+			fmt.Fprintf(&b, " AND %s >= %s::%s", f.Column, arg(f.value), f.Type)
+		case OpLte:
+			fmt.Fprintf(&b, " AND %s <= %s::%s", f.Column, arg(f.value), f.Type)
+		case OpHas:
+			fmt.Fprintf(&b, " AND %s::%s = ANY(%s)", arg(f.value), f.Type, f.Column)
+		case OpAnyOf:
+			fmt.Fprintf(&b, " AND %s && %s::%s[]", f.Column, arg(f.value), f.Type)
+		case OpAllOf:
+			fmt.Fprintf(&b, " AND %s @> %s::%s[]", f.Column, arg(f.value), f.Type)
+		}
+	}
+
+	dir, cmp := "ASC", ">"
+	if s.desc {
+		dir, cmp = "DESC", "<"
+	}
+	if s.after != nil {
+		fmt.Fprintf(&b, " AND (%s, %s) %s (%s::%s, %s::uuid)", s.field.Column, s.id, cmp, arg(s.after.Key), s.field.Type, arg(s.after.ID))
+	}
+
+	tail = fmt.Sprintf(" ORDER BY %s %s, %s %s LIMIT %s", s.field.Column, dir, s.id, dir, arg(s.Limit+1))
+	return b.String(), tail, args
+}
+
+// KeyColumn — выражение для SELECT: значение ключа сортировки строки в текстовом виде (без потери точности).
+// Репозиторий сканирует его в Key.Value и передаёт ключи в Paginate.
+func (s Spec) KeyColumn() string {
+	return "(" + s.field.Column + ")::text"
+}
+
+// Key — ключ строки в текущей сортировке
+type Key struct {
+	Value string
+	ID    string
+}
+
+// Page — метаданные страницы
+type Page struct {
+	Limit      int    `json:"limit"`
+	Sort       string `json:"sort"`
+	HasMore    bool   `json:"hasMore"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Paginate обрезает лишнюю (Limit+1) строку и строит курсор следующей страницы. keys — параллельно items.
+func Paginate[T any](s Spec, items []T, keys []Key) ([]T, Page) {
+	page := Page{Limit: s.Limit, Sort: s.Sort}
+	if len(items) <= s.Limit {
+		return items, page
+	}
+	items = items[:s.Limit]
+	last := keys[s.Limit-1]
+	page.HasMore = true
+	page.NextCursor = encodeCursor(&cursor{Sort: s.Sort, Key: last.Value, ID: last.ID})
+	return items, page
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// parseList разбирает список через запятую: пробелы по краям и пустые элементы отбрасываются
+func parseList(typ, raw string) (interface{}, error) {
+	var list []string
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if _, err := parseValue(typ, item); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	if len(list) == 0 {
+		return nil, errors.New("empty list")
+	}
+	return pq.StringArray(list), nil
+}
+
+func parseValue(typ, raw string) (interface{}, error) {
+	switch typ {
+	case TypeUUID:
+		if _, err := uuid.Parse(raw); err != nil {
+			return nil, errors.New("must be a UUID")
+		}
+	case TypeDate:
+		if _, err := time.Parse("2006-01-02", raw); err != nil {
+			return nil, errors.New("must be a date YYYY-MM-DD")
+		}
+	case TypeTimestamp:
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			if t, err = time.Parse("2006-01-02", raw); err != nil {
+				return nil, errors.New("must be RFC3339 or YYYY-MM-DD")
+			}
+		}
+		// Колонки TIMESTAMP хранят UTC без зоны
+		return t.UTC().Format("2006-01-02 15:04:05.999999"), nil
+	case TypeBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.New("must be true or false")
+		}
+		return b, nil
+	case TypeInt:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, errors.New("must be an integer")
+		}
+		return n, nil
+	case TypeBigint:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, errors.New("must be an integer")
+		}
+		return n, nil
+	}
+	return raw, nil
+}
@@ -0,0 +1,134 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+var testSchema = Schema{
+	Sorts: map[string]SortField{
+		"createdAt": {Column: "created_at", Type: TypeTimestamp},
+		"title":     {Column: "title", Type: TypeText},
+	},
+	Filters: map[string]Filter{
+		"q":        {Column: "title", Op: OpContains, Type: TypeText},
+		"isActive": {Column: "is_active", Op: OpEq, Type: TypeBool},
+		"tag":      {Column: "tags", Op: OpHas, Type: TypeText},
+		"tags":     {Column: "tags", Op: OpAnyOf, Type: TypeText},
+		"tagsAll":  {Column: "tags", Op: OpAllOf, Type: TypeText},
+		"archived": {Column: "(archived_at IS NOT NULL)", Op: OpEq, Type: TypeBool, Default: "false"},
+	},
+	DefaultSort: "-createdAt",
+}
+
+func TestParse_Errors(t *testing.T) {
+	const id = "3f1c1d8e-6b7a-4a34-9d0e-0c4cbb2b3a10"
+	other := encodeCursor(&cursor{Sort: "title", Key: "a", ID: id})
+	badKey := encodeCursor(&cursor{Sort: "-createdAt", Key: "yesterday", ID: id})
+	for _, tc := range []struct {
+		query string
+		err   error
+	}{
+		{"limit=0", ErrInvalidLimit},
+		{"limit=201", ErrInvalidLimit},
+		{"limit=abc", ErrInvalidLimit},
+		{"sort=password", ErrInvalidSort},
+		{"cursor=not-base64!", ErrInvalidCursor},
+		{"cursor=" + other, ErrInvalidCursor},  // курсор от другой сортировки
+		{"cursor=" + badKey, ErrInvalidCursor}, // ключ не приводится к типу поля
+		{"isActive=maybe", ErrInvalidFilter},
+		{"tags=,+,", ErrInvalidFilter},
+		{"q=a%00b", ErrInvalidFilter}, // NUL в тексте Postgres не принимает
+		{"tags=a,%00", ErrInvalidFilter},
+	} {
+		values, _ := url.ParseQuery(tc.query)
+		if _, err := Parse(values, testSchema); !errors.Is(err, tc.err) {
+			t.Errorf("Parse(%q) err = %v, want %v", tc.query, err, tc.err)
+		}
+	}
+}
+
+func TestSQL(t *testing.T) {
+	values := url.Values{"q": {"50%_off"}, "isActive": {"true"}, "tag": {"work"}, "tagsAll": {" a, b,,"}, "limit": {"10"}, "unknown": {"x"}}
+	spec, err := Parse(values, testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	where, tail, args := spec.SQL(2)
+	// Фильтры идут в порядке имён: archived (по умолчанию), isActive, q, tag, tagsAll
+	if want := " AND (archived_at IS NOT NULL) = $2::bool AND is_active = $3::bool AND title ILIKE $4 AND $5::text = ANY(tags) AND tags @> $6::text[]"; where != want {
+		t.Errorf("where = %q, want %q", where, want)
+	}
+	if want := " ORDER BY created_at DESC, id DESC LIMIT $7"; tail != want {
+		t.Errorf("tail = %q, want %q", tail, want)
+	}
+	if want := []interface{}{false, true, `%50\%\_off%`, "work", pq.StringArray{"a", "b"}, 11}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %#v, want %#v", args, want)
+	}
+}
+
+func TestPaginate_CursorRoundTrip(t *testing.T) {
+	spec, _ := Parse(url.Values{"sort": {"title"}, "limit": {"2"}}, testSchema)
+	ids := []string{
+		"00000000-0000-0000-0000-000000000001",
+		"00000000-0000-0000-0000-000000000002",
+		"00000000-0000-0000-0000-000000000003",
+	}
+	items := []string{"a", "b", "c"}
+	keys := []Key{{"a", ids[0]}, {"b", ids[1]}, {"c", ids[2]}}
+
+	got, page := Paginate(spec, items, keys)
+	if len(got) != 2 || !page.HasMore || page.NextCursor == "" || page.Sort != "title" {
+		t.Fatalf("first page = %v %+v", got, page)
+	}
+
+	next, err := Parse(url.Values{"sort": {"title"}, "limit": {"2"}, "cursor": {page.NextCursor}}, testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	where, tail, args := next.SQL(1)
+	if where != " AND (archived_at IS NOT NULL) = $1::bool AND (title, id) > ($2::text, $3::uuid)" || tail != " ORDER BY title ASC, id ASC LIMIT $4" {
+		t.Errorf("keyset SQL = %q %q", where, tail)
+	}
+	if !reflect.DeepEqual(args, []interface{}{false, "b", ids[1], 3}) {
+		t.Errorf("args = %#v", args)
+	}
+
+	if _, page := Paginate(next, items[2:], keys[2:]); page.HasMore || page.NextCursor != "" {
+		t.Errorf("last page = %+v", page)
+	}
+}
+
+func TestCheckKey(t *testing.T) {
+	// Ключи в текстовом виде Postgres, как их отдаёт KeyColumn
+	for _, tc := range []struct {
+		typ, key string
+		ok       bool
+	}{
+		{TypeTimestamp, "2026-10-10 09:15:00", true},
+		{TypeTimestamp, "2026-10-10 09:15:00.123456", true},
+		{TypeTimestamp, "2026-10-10T09:15:00Z", false},
+		{TypeDate, "2026-10-10", true},
+		{TypeDate, "2026-13-01", false},
+		{TypeTime, "07:30:00", true},
+		{TypeTime, "24:00:00", true},
+		{TypeTime, "25:00:00", false},
+		{TypeBigint, "-4294967296", true},
+		{TypeBigint, "1e3", false},
+		{TypeInt, "4294967296", false},
+		{TypeBool, "true", true},
+		{TypeBool, "1", false},
+		{TypeUUID, "3f1c1d8e-6b7a-4a34-9d0e-0c4cbb2b3a10", true},
+		{TypeUUID, "42", false},
+		{TypeText, "любой текст", true},
+		{TypeText, "a\x00b", false},
+	} {
+		if err := checkKey(tc.typ, tc.key); (err == nil) != tc.ok {
+			t.Errorf("checkKey(%s, %q) err = %v", tc.typ, tc.key, err)
+		}
+	}
+}
@@ -25,6 +25,8 @@ func simpleErrorCode(statusCode int) string {
 		return "FORBIDDEN"
 	case http.StatusNotFound: // 404
 		return "NOT_FOUND"
+	case http.StatusConflict: // 409
+		return "CONFLICT"
 	case http.StatusInternalServerError: // 500
 		return "INTERNAL_ERROR"
 	default:
@@ -77,6 +79,13 @@ func (r *Responder) NotFound(c *gin.Context, message string) {
 	r.WriteError(c, http.StatusNotFound, message)
 }
 
+func (r *Responder) Conflict(c *gin.Context, message string) {
+	if message == "" {
+		message = "conflict"
+	}
+	r.WriteError(c, http.StatusConflict, message)
+}
+
 func (r *Responder) InternalServerError(c *gin.Context, message string) {
 	if message == "" {
 		message = "internal server error"
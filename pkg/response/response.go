@@ -3,6 +3,8 @@ package response
 import (
 	"net/http"
 
+	"backend/pkg/query"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -10,6 +12,8 @@ type SuccessResponse struct {
 	Status  string      `json:"status"`
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
+	// Pagination — метаданные страницы для списков (см. pkg/query)
+	Pagination *query.Page `json:"pagination,omitempty"`
 }
 
 func (r *Responder) WriteJSON(c *gin.Context, statusCode int, data interface{}) {
@@ -36,3 +40,12 @@ func (r *Responder) SuccessWithMessage(c *gin.Context, message string) {
 func (r *Responder) Created(c *gin.Context, message string, data interface{}) {
 	r.Success(c, http.StatusCreated, message, data)
 }
+
+// SuccessWithPage — страница списка: данные и метаданные пагинации в конверте
+func (r *Responder) SuccessWithPage(c *gin.Context, data interface{}, page query.Page) {
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:     "success",
+		Data:       data,
+		Pagination: &page,
+	})
+}
@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Local хранит объекты в каталоге на диске. Подписанные ссылки ведут на baseURL — там должен быть
+// смонтирован сам Local как http.Handler (с http.StripPrefix), он проверяет подпись и отдаёт файл.
+type Local struct {
+	dir     string
+	baseURL string
+	secret  []byte
+	now     func() time.Time
+}
+
+// NewLocal создаёт каталог dir, если его нет. secret подписывает ссылки на скачивание.
+func NewLocal(dir, baseURL string, secret []byte) (*Local, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("storage: local signing secret is empty")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("storage: create %s: %w", dir, err)
+	}
+	return &Local{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/"), secret: secret, now: time.Now}, nil
+}
+
+func (l *Local) path(key string) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+// Put пишет во временный файл рядом и переименовывает: читатель не увидит недописанный объект
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return fmt.Errorf("storage: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("storage: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, io.LimitReader(r, size+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("storage: write %s: %w", key, err)
+	}
+	if n != size {
+		return fmt.Errorf("storage: write %s: got %d bytes, want %d", key, n, size)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return fmt.Errorf("storage: %w", err)
+	}
+	return nil
+}
+
+func (l *Local) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("storage: %w", err)
+	}
+	return nil
+}
+
+// SignedURL — baseURL/key?expires=…&type=…&disposition=…&signature=…; подпись — HMAC-SHA256 от всех параметров
+func (l *Local) SignedURL(ctx context.Context, key string, opts URLOptions) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(l.now().Add(opts.TTL).Unix(), 10))
+	q.Set("type", opts.ContentType)
+	q.Set("disposition", opts.disposition())
+	q.Set("signature", l.sign(key, q))
+	return l.baseURL + "/" + key + "?" + q.Encode(), nil
+}
+
+func (l *Local) sign(key string, q url.Values) string {
+	mac := hmac.New(sha256.New, l.secret)
+	for _, part := range []string{key, q.Get("expires"), q.Get("type"), q.Get("disposition")} {
+		mac.Write([]byte(part))
+		mac.Write([]byte{0})
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ServeHTTP отдаёт объект по подписанной ссылке. Путь запроса — ключ (префикс baseURL срезан StripPrefix).
+// Неверная подпись, истёкшая ссылка и отсутствующий объект неотличимы: 404.
+func (l *Local) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	q := r.URL.Query()
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if err != nil || l.now().Unix() > expires || !hmac.Equal([]byte(q.Get("signature")), []byte(l.sign(key, q))) {
+		http.NotFound(w, r)
+		return
+	}
+	p, err := l.path(key)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil || st.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	h := w.Header()
+	if t := q.Get("type"); t != "" {
+		h.Set("Content-Type", t)
+	}
+	h.Set("Content-Disposition", q.Get("disposition"))
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Cache-Control", "private, max-age="+strconv.FormatInt(max(expires-l.now().Unix(), 0), 10))
+	http.ServeContent(w, r, "", st.ModTime(), f)
+}
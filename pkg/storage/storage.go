@@ -0,0 +1,63 @@
+// Package storage — хранилище файлов: локальный диск или S3-совместимое хранилище (AWS S3, MinIO).
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("storage: object not found")
+	ErrInvalidKey = errors.New("storage: invalid object key")
+)
+
+// Storage хранит объекты по ключу. Ключ — путь из латиницы, цифр и символов "/_.-" без ".." и ведущего "/".
+type Storage interface {
+	// Put сохраняет ровно size байт из r под ключом key, перезаписывая существующий объект
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open — ErrNotFound, если объекта нет
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete удаляет объект; отсутствие объекта не ошибка
+	Delete(ctx context.Context, key string) error
+	// SignedURL — ссылка на скачивание без авторизации, действующая opts.TTL
+	SignedURL(ctx context.Context, key string, opts URLOptions) (string, error)
+}
+
+// URLOptions — как отдавать объект по подписанной ссылке
+type URLOptions struct {
+	TTL         time.Duration
+	Filename    string // имя файла в Content-Disposition; пустое — без имени
+	ContentType string // пустое — как определит хранилище
+	Inline      bool   // показать в браузере (картинки), а не скачать
+}
+
+// disposition — значение Content-Disposition; non-ASCII имя кодируется по RFC 2231
+func (o URLOptions) disposition() string {
+	kind := "attachment"
+	if o.Inline {
+		kind = "inline"
+	}
+	if o.Filename == "" {
+		return kind
+	}
+	return mime.FormatMediaType(kind, map[string]string{"filename": o.Filename})
+}
+
+var keyRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+(/[A-Za-z0-9_.-]+)*$`)
+
+func validKey(key string) bool {
+	if len(key) > 500 || !keyRe.MatchString(key) {
+		return false
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "." || seg == ".." {
+			return false
+		}
+	}
+	return true
+}